@@ -60,6 +60,16 @@ func setKiroIncognitoMode(cfg *config.Config, useIncognito, noIncognito bool) {
 	}
 }
 
+// stringListFlag collects the values of a flag that may be given more than once.
+type stringListFlag []string
+
+func (f *stringListFlag) String() string { return strings.Join(*f, ", ") }
+
+func (f *stringListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 // main is the entry point of the application.
 // It parses command-line flags, loads configuration, and starts the appropriate
 // service based on the provided flags (login, codex-login, or server mode).
@@ -82,9 +92,30 @@ func main() {
 	var kiroAWSAuthCode bool
 	var kiroImport bool
 	var githubCopilotLogin bool
+	var authLogin string
+	var authAdd string
+	var authAddRelay string
+	var relayKey string
+	var relayName string
+	var relayModel string
+	var relayDialect string
+	var accessToken string
+	var refreshToken string
+	var idToken string
+	var accountEmail string
 	var projectID string
 	var vertexImport string
+	var vertexADCProject string
+	var vertexLocation string
+	var routeExplain string
+	var routeExplainHeaders stringListFlag
+	var routeExplainTokens int
+	var managementKey string
+	var migrateConfig bool
+	var migrateConfigOut string
+	var readOnly bool
 	var configPath string
+	var configProfile string
 	var password string
 	var noIncognito bool
 	var useIncognito bool
@@ -107,9 +138,30 @@ func main() {
 	flag.BoolVar(&kiroAWSAuthCode, "kiro-aws-authcode", false, "Login to Kiro using AWS Builder ID (authorization code flow, better UX)")
 	flag.BoolVar(&kiroImport, "kiro-import", false, "Import Kiro token from Kiro IDE (~/.aws/sso/cache/kiro-auth-token.json)")
 	flag.BoolVar(&githubCopilotLogin, "github-copilot-login", false, "Login to GitHub Copilot using device flow")
+	flag.StringVar(&authLogin, "auth-login", "", "Login to the named provider using its registered authenticator")
+	flag.StringVar(&authAdd, "auth-add", "", "Import pre-supplied tokens for the named provider (use with -access-token and -refresh-token)")
+	flag.StringVar(&authAddRelay, "auth-add-relay", "", "Check a third-party relay at the given base URL and add it to the config in its detected dialect")
+	flag.StringVar(&relayKey, "relay-key", "", "API key for -auth-add-relay (prompted when omitted)")
+	flag.StringVar(&relayName, "relay-name", "", "Provider name for an OpenAI-compatible relay (defaults to the host)")
+	flag.StringVar(&relayModel, "relay-model", "", "Model used to probe the relay (defaults to one it lists)")
+	flag.StringVar(&relayDialect, "relay-dialect", "", "Skip detection and treat the relay as openai, claude or gemini")
+	flag.StringVar(&accessToken, "access-token", "", "Access token for -auth-add")
+	flag.StringVar(&refreshToken, "refresh-token", "", "Refresh token for -auth-add")
+	flag.StringVar(&idToken, "id-token", "", "Optional ID token for -auth-add (Claude/Codex)")
+	flag.StringVar(&accountEmail, "email", "", "Optional account email for -auth-add")
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
+	flag.StringVar(&configProfile, "profile", "", "Config profile to apply over base values (overrides CLIPROXY_PROFILE and active-profile)")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.StringVar(&vertexADCProject, "vertex-adc", "", "Add a Vertex credential for the given GCP project using Application Default Credentials (workload identity)")
+	flag.StringVar(&vertexLocation, "vertex-location", "", "Vertex AI region for -vertex-import/-vertex-adc, or \"global\" (default us-central1)")
+	flag.StringVar(&routeExplain, "route-explain", "", "Explain how the running server would route a request for the given model")
+	flag.Var(&routeExplainHeaders, "route-explain-header", "Request header for -route-explain as \"Name: value\", e.g. the client key or X-Session-ID (repeatable)")
+	flag.IntVar(&routeExplainTokens, "route-explain-tokens", 0, "Estimated prompt tokens for -route-explain, as size routing sees them")
+	flag.StringVar(&managementKey, "management-key", "", "Management key for -route-explain (defaults to MANAGEMENT_PASSWORD)")
+	flag.BoolVar(&readOnly, "read-only", false, "Reject management changes and new logins while proxying continues")
+	flag.BoolVar(&migrateConfig, "migrate-config", false, "Convert the config file to the current format and report deprecated keys")
+	flag.StringVar(&migrateConfigOut, "migrate-config-out", "", "Output path for -migrate-config (defaults to <config>.migrated.yaml)")
 	flag.StringVar(&password, "password", "", "")
 
 	flag.CommandLine.Usage = func() {
@@ -141,6 +193,13 @@ func main() {
 
 	// Parse the command-line flags.
 	flag.Parse()
+	config.SetProfileOverride(configProfile)
+
+	// Config migration runs before loading so the legacy file is never rewritten in place.
+	if migrateConfig {
+		cmd.DoConfigMigrate(configPath, migrateConfigOut)
+		return
+	}
 
 	// Core application variables.
 	var err error
@@ -476,9 +535,36 @@ func main() {
 
 	// Handle different command modes based on the provided flags.
 
-	if vertexImport != "" {
+	if routeExplain != "" {
+		cmd.DoRouteExplain(cfg, cmd.RouteExplainRequest{
+			Model:   routeExplain,
+			Headers: routeExplainHeaders,
+			Tokens:  routeExplainTokens,
+		}, managementKey)
+	} else if authAdd != "" {
+		cmd.DoAuthAdd(cfg, sdkAuth.TokenImport{
+			Provider:     authAdd,
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			IDToken:      idToken,
+			Email:        accountEmail,
+			ProjectID:    projectID,
+		})
+	} else if authAddRelay != "" {
+		cmd.DoAuthAddRelay(cfg, configFilePath, cmd.RelayOptions{
+			BaseURL: authAddRelay,
+			APIKey:  relayKey,
+			Name:    relayName,
+			Model:   relayModel,
+			Dialect: relayDialect,
+		}, options)
+	} else if authLogin != "" {
+		cmd.DoAuthLogin(cfg, authLogin, options)
+	} else if vertexImport != "" {
 		// Handle Vertex service account import
-		cmd.DoVertexImport(cfg, vertexImport)
+		cmd.DoVertexImport(cfg, vertexImport, vertexLocation)
+	} else if vertexADCProject != "" {
+		cmd.DoVertexADCImport(cfg, vertexADCProject, vertexLocation)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -533,6 +619,6 @@ func main() {
 		}
 		// Start the main proxy service
 		managementasset.StartAutoUpdater(context.Background(), configFilePath)
-		cmd.StartService(cfg, configFilePath, password)
+		cmd.StartService(cfg, configFilePath, password, readOnly)
 	}
 }
@@ -0,0 +1,84 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/artifact"
+)
+
+// artifactDirectory returns the configured artifact directory, writing an error
+// response and returning false when artifacts are unavailable.
+func (h *Handler) artifactDirectory(c *gin.Context) (string, bool) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return "", false
+	}
+	if h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
+		return "", false
+	}
+	dir := strings.TrimSpace(h.cfg.Streaming.ArtifactDir)
+	if dir == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "artifacts are disabled"})
+		return "", false
+	}
+	return dir, true
+}
+
+// ListArtifacts lists the responses saved through the X-Artifact-Name header.
+func (h *Handler) ListArtifacts(c *gin.Context) {
+	dir, ok := h.artifactDirectory(c)
+	if !ok {
+		return
+	}
+	files, err := artifact.List(dir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list artifacts: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"artifacts": files})
+}
+
+// DownloadArtifact serves one saved response. A response that is still streaming, or
+// ended in an upstream error, is served as written so far with X-Artifact-Complete: false.
+func (h *Handler) DownloadArtifact(c *gin.Context) {
+	dir, ok := h.artifactDirectory(c)
+	if !ok {
+		return
+	}
+	path, info, err := artifact.Path(dir, strings.TrimSpace(c.Param("name")))
+	if err != nil {
+		c.JSON(artifactErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("X-Artifact-Complete", fmt.Sprintf("%t", info.Complete))
+	c.FileAttachment(path, info.Name)
+}
+
+// DeleteArtifact removes one saved response.
+func (h *Handler) DeleteArtifact(c *gin.Context) {
+	dir, ok := h.artifactDirectory(c)
+	if !ok {
+		return
+	}
+	if err := artifact.Remove(dir, strings.TrimSpace(c.Param("name"))); err != nil {
+		c.JSON(artifactErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func artifactErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, artifact.ErrInvalidName):
+		return http.StatusBadRequest
+	case errors.Is(err, artifact.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -564,6 +564,11 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read saved file: %v", errRead)})
 			return
 		}
+		if errValidate := validateAuthFileData(data); errValidate != nil {
+			_ = os.Remove(dst)
+			c.JSON(400, gin.H{"error": errValidate.Error()})
+			return
+		}
 		if errReg := h.registerAuthFromFile(ctx, dst, data); errReg != nil {
 			c.JSON(500, gin.H{"error": errReg.Error()})
 			return
@@ -585,6 +590,10 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "failed to read body"})
 		return
 	}
+	if err = validateAuthFileData(data); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	dst := filepath.Join(h.cfg.AuthDir, filepath.Base(name))
 	if !filepath.IsAbs(dst) {
 		if abs, errAbs := filepath.Abs(dst); errAbs == nil {
@@ -602,6 +611,36 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+// ImportAuthTokens builds a token file from pre-supplied tokens (provider, access_token,
+// refresh_token and optional id_token, email, project_id, expires_at) and registers it.
+func (h *Handler) ImportAuthTokens(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var body sdkAuth.TokenImport
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	record, err := sdkAuth.BuildImportedAuth(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+	savedPath, err := h.saveTokenRecord(ctx, record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save token file: %v", err)})
+		return
+	}
+	if err = h.registerAuthFromFile(ctx, savedPath, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "name": filepath.Base(savedPath), "provider": record.Provider})
+}
+
 // Delete auth files: single by name or all
 func (h *Handler) DeleteAuthFile(c *gin.Context) {
 	if h.authManager == nil {
@@ -687,6 +726,17 @@ func (h *Handler) authIDForPath(path string) string {
 	return path
 }
 
+// validateAuthFileData checks uploaded auth JSON against the current auth file schema,
+// upgrading older layouts before validating required fields.
+func validateAuthFileData(data []byte) error {
+	metadata := make(map[string]any)
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("invalid auth file: %w", err)
+	}
+	_, err := coreauth.LoadMetadata(metadata)
+	return err
+}
+
 func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []byte) error {
 	if h.authManager == nil {
 		return nil
@@ -705,6 +755,9 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		return fmt.Errorf("invalid auth file: %w", err)
 	}
+	if _, err := coreauth.LoadMetadata(metadata); err != nil {
+		return err
+	}
 	provider, _ := metadata["type"].(string)
 	if provider == "" {
 		provider = "unknown"
@@ -1697,7 +1750,7 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 
 	go func() {
 		fmt.Println("Waiting for authentication...")
-		tokenData, errPollForToken := qwenAuth.PollForToken(deviceFlow.DeviceCode, deviceFlow.CodeVerifier)
+		tokenData, errPollForToken := qwenAuth.PollForToken(ctx, deviceFlow.DeviceCode, deviceFlow.CodeVerifier)
 		if errPollForToken != nil {
 			SetOAuthSessionError(state, "Authentication failed")
 			fmt.Printf("Authentication failed: %v\n", errPollForToken)
@@ -0,0 +1,22 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCanaryStats compares, per model, the request and error counts of canary credentials
+// with those of regular credentials since startup, to judge whether a canary rollout is
+// safe to widen.
+//
+// Endpoint:
+//
+//	GET /v0/management/canary
+func (h *Handler) GetCanaryStats(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"models": h.authManager.CanaryStats()})
+}
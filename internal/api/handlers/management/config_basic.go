@@ -186,6 +186,16 @@ func (h *Handler) GetConfigYAML(c *gin.Context) {
 func (h *Handler) GetDebug(c *gin.Context) { c.JSON(200, gin.H{"debug": h.cfg.Debug}) }
 func (h *Handler) PutDebug(c *gin.Context) { h.updateBoolField(c, func(v bool) { h.cfg.Debug = v }) }
 
+// ReadOnly
+func (h *Handler) GetReadOnly(c *gin.Context) { c.JSON(200, gin.H{"read-only": h.ReadOnly()}) }
+func (h *Handler) PutReadOnly(c *gin.Context) {
+	if h.readOnlyOverride {
+		c.JSON(http.StatusConflict, gin.H{"error": "read-only mode is pinned by the -read-only flag"})
+		return
+	}
+	h.updateBoolField(c, func(v bool) { h.cfg.ReadOnly = v })
+}
+
 // UsageStatisticsEnabled
 func (h *Handler) GetUsageStatisticsEnabled(c *gin.Context) {
 	c.JSON(200, gin.H{"usage-statistics-enabled": h.cfg.UsageStatisticsEnabled})
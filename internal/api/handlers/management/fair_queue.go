@@ -0,0 +1,15 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/fairqueue"
+)
+
+// GetFairQueue reports the fair-queuing slots and, per client API key, the requests in
+// flight and waiting, the average wait and the share of capacity against the share the
+// weights entitle the key to.
+func (h *Handler) GetFairQueue(c *gin.Context) {
+	c.JSON(http.StatusOK, fairqueue.Default.Stats())
+}
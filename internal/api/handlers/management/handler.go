@@ -10,9 +10,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
@@ -45,8 +47,12 @@ type Handler struct {
 	tokenStore          coreauth.Store
 	localPassword       string
 	allowRemoteOverride bool
+	readOnlyOverride    bool
 	envSecret           string
 	logDir              string
+	abuseDetector       *middleware.AbuseDetector
+	upstreamCursor      atomic.Uint64
+	statusFunc          func() Status
 }
 
 // NewHandler creates a new management handler instance.
@@ -112,9 +118,46 @@ func (h *Handler) SetAuthManager(manager *coreauth.Manager) { h.authManager = ma
 // SetUsageStatistics allows replacing the usage statistics reference.
 func (h *Handler) SetUsageStatistics(stats *usage.RequestStatistics) { h.usageStats = stats }
 
+// SetAbuseDetector wires the abuse detector whose audit events are exposed via the API.
+func (h *Handler) SetAbuseDetector(detector *middleware.AbuseDetector) { h.abuseDetector = detector }
+
 // SetLocalPassword configures the runtime-local password accepted for localhost requests.
 func (h *Handler) SetLocalPassword(password string) { h.localPassword = password }
 
+// SetReadOnlyOverride pins read-only mode on regardless of the config, for servers started
+// with the -read-only flag.
+func (h *Handler) SetReadOnlyOverride(readOnly bool) { h.readOnlyOverride = readOnly }
+
+// ReadOnly reports whether management changes and new logins are currently rejected.
+func (h *Handler) ReadOnly() bool {
+	return h.readOnlyOverride || (h.cfg != nil && h.cfg.ReadOnly)
+}
+
+// ReadOnlyGuard rejects management requests that change state or start a provider login
+// while read-only mode is on. The read-only toggle itself stays writable so the mode can
+// be lifted through the API.
+func (h *Handler) ReadOnlyGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.ReadOnly() || !readOnlyBlocked(c.Request.Method, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+	}
+}
+
+// readOnlyBlocked reports whether a management request is a mutation or a login start.
+func readOnlyBlocked(method, path string) bool {
+	if strings.HasSuffix(path, "/read-only") {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return strings.HasSuffix(path, "-auth-url")
+	}
+	return true
+}
+
 // SetLogDirectory updates the directory where main.log should be looked up.
 func (h *Handler) SetLogDirectory(dir string) {
 	if dir == "" {
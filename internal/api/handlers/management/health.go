@@ -0,0 +1,207 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// Health statuses reported by GetHealth.
+const (
+	healthStatusOK          = "ok"
+	healthStatusDegraded    = "degraded"
+	healthStatusUnavailable = "unavailable"
+)
+
+// Health reason types.
+const (
+	healthReasonProviderErrors = "provider_errors"
+	healthReasonQuotaExhausted = "quota_exhausted"
+	healthReasonNoCredentials  = "no_available_credentials"
+	healthReasonErrorSample    = "error_sample"
+)
+
+// healthErrorSamples caps the number of recent error samples in a health response.
+const healthErrorSamples = 5
+
+type healthReason struct {
+	Type       string     `json:"type"`
+	Provider   string     `json:"provider,omitempty"`
+	AuthID     string     `json:"auth_id,omitempty"`
+	Model      string     `json:"model,omitempty"`
+	Message    string     `json:"message"`
+	HTTPStatus int        `json:"http_status,omitempty"`
+	At         *time.Time `json:"at,omitempty"`
+	RetryAt    *time.Time `json:"retry_at,omitempty"`
+}
+
+type healthProvider struct {
+	Total     int `json:"total"`
+	Available int `json:"available"`
+	Errored   int `json:"errored"`
+	Exhausted int `json:"quota_exhausted"`
+}
+
+// GetHealth reports overall credential health. When the status is degraded, reasons
+// explains which providers are failing, which quotas are exhausted and the most recent
+// error samples with timestamps. When warm-up requests are configured, warmups lists the
+// latest latency or failure per credential and model.
+//
+// Endpoint:
+//
+//	GET /v0/health
+//
+// Responds 200 for ok/degraded and 503 when no credential is available.
+func (h *Handler) GetHealth(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": healthStatusUnavailable, "error": "core auth manager unavailable"})
+		return
+	}
+	status, providers, reasons := evaluateHealth(h.authManager.List(), time.Now())
+	code := http.StatusOK
+	if status == healthStatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	body := gin.H{
+		"status":    status,
+		"providers": providers,
+		"reasons":   reasons,
+	}
+	if warmups := h.authManager.WarmupResults(); len(warmups) > 0 {
+		body["warmups"] = warmups
+	}
+	c.JSON(code, body)
+}
+
+func evaluateHealth(auths []*coreauth.Auth, now time.Time) (string, map[string]*healthProvider, []healthReason) {
+	providers := make(map[string]*healthProvider)
+	var quotaReasons, samples []healthReason
+	available := 0
+
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		stats := providers[provider]
+		if stats == nil {
+			stats = &healthProvider{}
+			providers[provider] = stats
+		}
+		stats.Total++
+
+		exhausted := false
+		if auth.Quota.Exceeded && auth.Quota.NextRecoverAt.After(now) {
+			exhausted = true
+			quotaReasons = append(quotaReasons, quotaReason(provider, auth.ID, "", auth.Quota))
+		}
+		for model, state := range auth.ModelStates {
+			if state == nil {
+				continue
+			}
+			if state.Quota.Exceeded && state.Quota.NextRecoverAt.After(now) {
+				quotaReasons = append(quotaReasons, quotaReason(provider, auth.ID, model, state.Quota))
+			}
+			if state.LastError != nil && !state.UpdatedAt.IsZero() {
+				samples = append(samples, errorSample(provider, auth.ID, model, state.LastError, state.UpdatedAt))
+			}
+		}
+
+		errored := auth.Status == coreauth.StatusError || (auth.Unavailable && !exhausted && auth.NextRetryAfter.After(now))
+		if auth.LastError != nil {
+			samples = append(samples, errorSample(provider, auth.ID, "", auth.LastError, auth.UpdatedAt))
+		}
+		switch {
+		case exhausted:
+			stats.Exhausted++
+		case errored:
+			stats.Errored++
+		default:
+			stats.Available++
+			available++
+		}
+	}
+
+	var reasons []healthReason
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats := providers[name]
+		if stats.Errored > 0 {
+			reasons = append(reasons, healthReason{
+				Type:     healthReasonProviderErrors,
+				Provider: name,
+				Message:  fmt.Sprintf("%d of %d credentials failing", stats.Errored, stats.Total),
+			})
+		}
+		if stats.Available == 0 {
+			reasons = append(reasons, healthReason{
+				Type:     healthReasonNoCredentials,
+				Provider: name,
+				Message:  "no credential is currently available",
+			})
+		}
+	}
+	sort.SliceStable(quotaReasons, func(i, j int) bool {
+		return quotaReasons[i].Provider+quotaReasons[i].AuthID+quotaReasons[i].Model <
+			quotaReasons[j].Provider+quotaReasons[j].AuthID+quotaReasons[j].Model
+	})
+	reasons = append(reasons, quotaReasons...)
+
+	// Most recent errors first.
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].At.After(*samples[j].At) })
+	if len(samples) > healthErrorSamples {
+		samples = samples[:healthErrorSamples]
+	}
+	if len(reasons) > 0 {
+		reasons = append(reasons, samples...)
+	}
+
+	status := healthStatusOK
+	switch {
+	case len(providers) == 0 || available == 0:
+		status = healthStatusUnavailable
+	case len(reasons) > 0:
+		status = healthStatusDegraded
+	}
+	if reasons == nil {
+		reasons = []healthReason{}
+	}
+	return status, providers, reasons
+}
+
+func quotaReason(provider, authID, model string, quota coreauth.QuotaState) healthReason {
+	retryAt := quota.NextRecoverAt
+	message := "quota exhausted"
+	if quota.Reason != "" {
+		message += ": " + quota.Reason
+	}
+	return healthReason{
+		Type:     healthReasonQuotaExhausted,
+		Provider: provider,
+		AuthID:   authID,
+		Model:    model,
+		Message:  message,
+		RetryAt:  &retryAt,
+	}
+}
+
+func errorSample(provider, authID, model string, err *coreauth.Error, at time.Time) healthReason {
+	return healthReason{
+		Type:       healthReasonErrorSample,
+		Provider:   provider,
+		AuthID:     authID,
+		Model:      model,
+		Message:    err.Message,
+		HTTPStatus: err.HTTPStatus,
+		At:         &at,
+	}
+}
@@ -0,0 +1,51 @@
+package management
+
+import (
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestEvaluateHealth_DegradedReasons(t *testing.T) {
+	now := time.Now()
+	auths := []*coreauth.Auth{
+		{ID: "c1", Provider: "claude", Status: coreauth.StatusActive},
+		{ID: "c2", Provider: "claude", Status: coreauth.StatusError, UpdatedAt: now.Add(-time.Minute),
+			LastError: &coreauth.Error{Message: "unauthorized", HTTPStatus: 401}},
+		{ID: "g1", Provider: "gemini", Status: coreauth.StatusActive, Unavailable: true,
+			Quota: coreauth.QuotaState{Exceeded: true, Reason: "quota", NextRecoverAt: now.Add(time.Hour)}},
+		{ID: "off", Provider: "codex", Disabled: true},
+	}
+
+	status, providers, reasons := evaluateHealth(auths, now)
+	if status != healthStatusDegraded {
+		t.Fatalf("status = %q, want %q", status, healthStatusDegraded)
+	}
+	if _, ok := providers["codex"]; ok {
+		t.Fatal("disabled credentials must not be counted")
+	}
+	if p := providers["claude"]; p.Total != 2 || p.Available != 1 || p.Errored != 1 {
+		t.Fatalf("claude stats = %+v", p)
+	}
+	types := make(map[string]int)
+	for _, r := range reasons {
+		types[r.Type]++
+	}
+	if types[healthReasonProviderErrors] != 1 || types[healthReasonQuotaExhausted] != 1 ||
+		types[healthReasonNoCredentials] != 1 || types[healthReasonErrorSample] != 1 {
+		t.Fatalf("unexpected reasons: %+v", reasons)
+	}
+}
+
+func TestEvaluateHealth_OKAndUnavailable(t *testing.T) {
+	now := time.Now()
+	status, _, reasons := evaluateHealth([]*coreauth.Auth{{ID: "a", Provider: "claude", Status: coreauth.StatusActive}}, now)
+	if status != healthStatusOK || len(reasons) != 0 {
+		t.Fatalf("status = %q reasons = %+v", status, reasons)
+	}
+	status, _, _ = evaluateHealth(nil, now)
+	if status != healthStatusUnavailable {
+		t.Fatalf("status = %q, want %q", status, healthStatusUnavailable)
+	}
+}
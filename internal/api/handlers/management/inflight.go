@@ -0,0 +1,25 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+)
+
+// GetInflightRequests lists the requests being served, oldest first, with a summary for
+// watching queues drain.
+func (h *Handler) GetInflightRequests(c *gin.Context) {
+	requests := inflight.List()
+	c.JSON(http.StatusOK, gin.H{"requests": requests, "summary": inflight.Summarize(requests)})
+}
+
+// CancelInflightRequest cancels one in-flight request, releasing its credential.
+func (h *Handler) CancelInflightRequest(c *gin.Context) {
+	id := c.Param("id")
+	if !inflight.Cancel(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "cancelled", "id": id})
+}
@@ -0,0 +1,18 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
+)
+
+// GetJanitorStats reports each state sweep's schedule, runs and reclaimed item counts.
+func (h *Handler) GetJanitorStats(c *gin.Context) {
+	tasks := janitor.Stats()
+	var reclaimed int64
+	for _, task := range tasks {
+		reclaimed += task.Reclaimed
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks, "reclaimed": reclaimed})
+}
@@ -221,33 +221,40 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 		return
 	}
 
-	dir := h.logDirectory()
-	if strings.TrimSpace(dir) == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
-		return
-	}
-
 	requestID := strings.TrimSpace(c.Param("id"))
 	if requestID == "" {
 		requestID = strings.TrimSpace(c.Query("id"))
 	}
-	if requestID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing request ID"})
+	fullPath, matchedFile, status, errMsg := h.findRequestLogFile(requestID)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"error": errMsg})
 		return
 	}
+
+	c.FileAttachment(fullPath, matchedFile)
+}
+
+// findRequestLogFile locates the request log for requestID inside the log directory.
+// It returns the absolute path and file name, or a non-200 status with a client-facing message.
+func (h *Handler) findRequestLogFile(requestID string) (string, string, int, string) {
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		return "", "", http.StatusInternalServerError, "log directory not configured"
+	}
+
+	if requestID == "" {
+		return "", "", http.StatusBadRequest, "missing request ID"
+	}
 	if strings.ContainsAny(requestID, "/\\") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
-		return
+		return "", "", http.StatusBadRequest, "invalid request ID"
 	}
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "log directory not found"})
-			return
+			return "", "", http.StatusNotFound, "log directory not found"
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list log directory: %v", err)})
-		return
+		return "", "", http.StatusInternalServerError, fmt.Sprintf("failed to list log directory: %v", err)
 	}
 
 	suffix := "-" + requestID + ".log"
@@ -264,37 +271,31 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	}
 
 	if matchedFile == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
-		return
+		return "", "", http.StatusNotFound, "log file not found for the given request ID"
 	}
 
 	dirAbs, errAbs := filepath.Abs(dir)
 	if errAbs != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to resolve log directory: %v", errAbs)})
-		return
+		return "", "", http.StatusInternalServerError, fmt.Sprintf("failed to resolve log directory: %v", errAbs)
 	}
 	fullPath := filepath.Clean(filepath.Join(dirAbs, matchedFile))
 	prefix := dirAbs + string(os.PathSeparator)
 	if !strings.HasPrefix(fullPath, prefix) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file path"})
-		return
+		return "", "", http.StatusBadRequest, "invalid log file path"
 	}
 
 	info, errStat := os.Stat(fullPath)
 	if errStat != nil {
 		if os.IsNotExist(errStat) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "log file not found"})
-			return
+			return "", "", http.StatusNotFound, "log file not found"
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errStat)})
-		return
+		return "", "", http.StatusInternalServerError, fmt.Sprintf("failed to read log file: %v", errStat)
 	}
 	if info.IsDir() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file"})
-		return
+		return "", "", http.StatusBadRequest, "invalid log file"
 	}
 
-	c.FileAttachment(fullPath, matchedFile)
+	return fullPath, matchedFile, http.StatusOK, ""
 }
 
 // DownloadRequestErrorLog downloads a specific error request log file by name.
@@ -0,0 +1,47 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestReadOnlyGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{ReadOnly: true}}
+	engine := gin.New()
+	engine.Use(h.ReadOnlyGuard())
+	engine.Any("/v0/management/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/v0/management/config", http.StatusOK},
+		{http.MethodPut, "/v0/management/debug", http.StatusForbidden},
+		{http.MethodDelete, "/v0/management/auth-files", http.StatusForbidden},
+		{http.MethodGet, "/v0/management/anthropic-auth-url", http.StatusForbidden},
+		{http.MethodPut, "/v0/management/read-only", http.StatusOK},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, rec.Code, tc.want)
+		}
+	}
+
+	h.cfg.ReadOnly = false
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/v0/management/debug", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("mutations must pass outside read-only mode, got %d", rec.Code)
+	}
+	h.SetReadOnlyOverride(true)
+	if !h.ReadOnly() {
+		t.Fatal("the flag override must pin read-only mode")
+	}
+}
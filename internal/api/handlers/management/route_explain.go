@@ -0,0 +1,88 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// GetRouteExplain reports how a request for the given model would be routed: the routes
+// it is tried on in order, after model routes and fallback chains, and for each why every
+// credential is or is not selectable right now. tokens is the estimated prompt size size
+// routing sees; each header ("Name: value") is a request header, of which the client API
+// key (Authorization, X-Goog-Api-Key or X-Api-Key) and X-Session-ID affect routing.
+//
+// Endpoint:
+//
+//	GET /v0/route/explain?model=<model>[&tokens=<n>][&header=<Name: value>...]
+func (h *Handler) GetRouteExplain(c *gin.Context) {
+	if h == nil || h.authManager == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing model"})
+		return
+	}
+	query := coreauth.RouteQuery{}
+	if raw := strings.TrimSpace(c.Query("tokens")); raw != "" {
+		tokens, err := strconv.Atoi(raw)
+		if err != nil || tokens < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tokens must be a non-negative integer"})
+			return
+		}
+		query.Tokens = tokens
+	}
+	headers := make(http.Header)
+	for _, raw := range c.QueryArray("header") {
+		name, value, ok := strings.Cut(raw, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "header must be given as \"Name: value\""})
+			return
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	if key := clientKeyFromHeaders(headers); key != "" {
+		query.Tenant = usage.TenantKey(key)
+	}
+	query.SessionID = headers.Get("X-Session-ID")
+
+	explainer := handlers.NewBaseAPIHandlers(&h.cfg.SDKConfig, h.authManager)
+	routes, errMsg := explainer.ExplainRoute(c.Request.Context(), model, query)
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status == 0 {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": errMsg.Error.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"requested_model": model,
+		"tenant":          query.Tenant,
+		"routes":          routes,
+	})
+}
+
+// clientKeyFromHeaders returns the client API key carried by headers, checked in the
+// order the config access provider checks them.
+func clientKeyFromHeaders(headers http.Header) string {
+	if auth := strings.TrimSpace(headers.Get("Authorization")); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(token)
+		}
+		return auth
+	}
+	for _, name := range []string{"X-Goog-Api-Key", "X-Api-Key"} {
+		if key := strings.TrimSpace(headers.Get(name)); key != "" {
+			return key
+		}
+	}
+	return ""
+}
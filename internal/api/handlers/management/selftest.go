@@ -0,0 +1,26 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/selftest"
+)
+
+// GetSelfTest runs canned requests through the translation stack against an in-process
+// mock provider and reports pass/fail with timings. It makes no upstream calls, so it
+// suits deployment smoke tests and container health checks.
+//
+// Endpoint:
+//
+//	GET /v0/selftest
+//
+// Responds 200 when every case passes and 503 otherwise.
+func (h *Handler) GetSelfTest(c *gin.Context) {
+	report := selftest.Run(c.Request.Context())
+	code := http.StatusOK
+	if !report.Passed {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
@@ -0,0 +1,169 @@
+package management
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultShareLinkTTL = 24 * time.Hour
+	maxShareLinkTTL     = 7 * 24 * time.Hour
+	shareLinkPathPrefix = "/v0/share/"
+)
+
+var errInvalidShareToken = errors.New("share link is invalid or expired")
+
+// CreateRequestLogShareLink issues an expiring, signed URL for the request log of the
+// given request ID. The link can be opened without the management key until it expires.
+// Links are signed with a key derived from the management secret, so rotating the
+// secret revokes every outstanding link.
+//
+// Optional TTL: query ?ttl-seconds=N or JSON body {"ttl-seconds": N}; default 24h, max 7 days.
+func (h *Handler) CreateRequestLogShareLink(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
+		return
+	}
+	key := h.shareLinkKey()
+	if key == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "share links require a management secret"})
+		return
+	}
+
+	requestID := strings.TrimSpace(c.Param("id"))
+	if _, _, status, errMsg := h.findRequestLogFile(requestID); status != http.StatusOK {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	ttl := defaultShareLinkTTL
+	var body struct {
+		TTLSeconds int64 `json:"ttl-seconds"`
+	}
+	if raw := strings.TrimSpace(c.Query("ttl-seconds")); raw != "" {
+		seconds, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || seconds <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ttl-seconds"})
+			return
+		}
+		body.TTLSeconds = seconds
+	} else if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil || body.TTLSeconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	if body.TTLSeconds > 0 {
+		ttl = time.Duration(body.TTLSeconds) * time.Second
+	}
+	if ttl > maxShareLinkTTL {
+		ttl = maxShareLinkTTL
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	token := signShareToken(key, requestID, expiresAt)
+	path := shareLinkPathPrefix + token
+	c.JSON(http.StatusOK, gin.H{
+		"request-id": requestID,
+		"path":       path,
+		"url":        requestBaseURL(c) + path,
+		"expires-at": expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// GetSharedRequestLog serves the request log referenced by a share token. It is mounted
+// outside the management middleware; the token signature is the only credential.
+func (h *Handler) GetSharedRequestLog(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
+		return
+	}
+	key := h.shareLinkKey()
+	if key == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errInvalidShareToken.Error()})
+		return
+	}
+	requestID, err := verifyShareToken(key, c.Param("token"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	fullPath, _, status, errMsg := h.findRequestLogFile(requestID)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+	c.File(fullPath)
+}
+
+// shareLinkKey derives the signing key from the configured management secret, or
+// returns nil when no secret is set.
+func (h *Handler) shareLinkKey() []byte {
+	secret := strings.TrimSpace(h.cfg.RemoteManagement.SecretKey)
+	if secret == "" {
+		secret = h.envSecret
+	}
+	if secret == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte("request-log-share:" + secret))
+	return sum[:]
+}
+
+// signShareToken encodes requestID and expiry as base64url(requestID "|" unix).base64url(hmac).
+func signShareToken(key []byte, requestID string, expiresAt time.Time) string {
+	payload := []byte(requestID + "|" + strconv.FormatInt(expiresAt.Unix(), 10))
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyShareToken checks the signature and expiry of token and returns its request ID.
+func verifyShareToken(key []byte, token string, now time.Time) (string, error) {
+	encodedPayload, encodedSig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok {
+		return "", errInvalidShareToken
+	}
+	payload, errPayload := base64.RawURLEncoding.DecodeString(encodedPayload)
+	sig, errSig := base64.RawURLEncoding.DecodeString(encodedSig)
+	if errPayload != nil || errSig != nil {
+		return "", errInvalidShareToken
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", errInvalidShareToken
+	}
+	sep := strings.LastIndexByte(string(payload), '|')
+	if sep <= 0 {
+		return "", errInvalidShareToken
+	}
+	expiresAt, err := strconv.ParseInt(string(payload[sep+1:]), 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return "", errInvalidShareToken
+	}
+	return string(payload[:sep]), nil
+}
+
+// requestBaseURL reconstructs the externally visible scheme and host of the request.
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.TrimSpace(c.GetHeader("X-Forwarded-Proto")); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,28 @@
+package management
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShareTokenRoundTrip(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Now()
+	token := signShareToken(key, "req-1|x", now.Add(time.Hour))
+
+	id, err := verifyShareToken(key, token, now)
+	if err != nil || id != "req-1|x" {
+		t.Fatalf("verify = %q, %v", id, err)
+	}
+	if _, err = verifyShareToken(key, token, now.Add(2*time.Hour)); err == nil {
+		t.Fatal("expired token accepted")
+	}
+	if _, err = verifyShareToken([]byte("other-key"), token, now); err == nil {
+		t.Fatal("token accepted with a different key")
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+	if _, err = verifyShareToken(key, payload+"x."+sig, now); err == nil {
+		t.Fatal("tampered token accepted")
+	}
+}
@@ -0,0 +1,127 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// StatusProvider summarizes one provider in a Status.
+type StatusProvider struct {
+	// Executor reports whether an executor is registered for the provider.
+	Executor    bool `json:"executor"`
+	Credentials int  `json:"credentials"`
+	Active      int  `json:"active"`
+	Disabled    int  `json:"disabled"`
+}
+
+// Status is the capability summary logged at startup and served by GET /v0/status.
+type Status struct {
+	Version     string                     `json:"version"`
+	Commit      string                     `json:"commit"`
+	Listen      string                     `json:"listen"`
+	TLS         bool                       `json:"tls"`
+	Providers   map[string]*StatusProvider `json:"providers"`
+	Credentials int                        `json:"credentials"`
+	Endpoints   []string                   `json:"endpoints"`
+	Features    map[string]bool            `json:"features"`
+	Warnings    []string                   `json:"warnings,omitempty"`
+	// TranslationFailures counts the stream chunks each translator pair skipped.
+	TranslationFailures []sdktranslator.StreamFailure `json:"translation_failures,omitempty"`
+}
+
+// BuildStatus summarizes the providers, credentials, endpoints and optional features of a
+// running server. endpoints lists the client-facing routes as "METHOD /path".
+func BuildStatus(cfg *config.Config, manager *coreauth.Manager, endpoints []string) Status {
+	status := Status{
+		Version:   buildinfo.Version,
+		Commit:    buildinfo.Commit,
+		Providers: make(map[string]*StatusProvider),
+		Endpoints: endpoints,
+		Features:  make(map[string]bool),
+	}
+	if cfg != nil {
+		status.Listen = fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+		status.TLS = cfg.TLS.Enable
+		status.Features["request-log"] = cfg.RequestLog
+		status.Features["usage-statistics"] = cfg.UsageStatisticsEnabled
+		status.Features["stateless-responses"] = cfg.ResponsesState.StatelessTokens
+		status.Features["ws-auth"] = cfg.WebsocketAuth
+		status.Features["remote-management"] = cfg.RemoteManagement.AllowRemote
+		status.Features["read-only"] = cfg.ReadOnly
+		status.Features["commercial-mode"] = cfg.CommercialMode
+	}
+	if manager != nil {
+		for _, provider := range manager.ExecutorProviders() {
+			status.Providers[provider] = &StatusProvider{Executor: true}
+		}
+		for _, auth := range manager.List() {
+			if auth == nil {
+				continue
+			}
+			provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+			entry := status.Providers[provider]
+			if entry == nil {
+				entry = &StatusProvider{}
+				status.Providers[provider] = entry
+			}
+			entry.Credentials++
+			status.Credentials++
+			switch {
+			case auth.Disabled || auth.Status == coreauth.StatusDisabled:
+				entry.Disabled++
+			case auth.Status != coreauth.StatusError:
+				entry.Active++
+			}
+		}
+	}
+	status.Warnings = statusWarnings(status)
+	status.TranslationFailures = sdktranslator.StreamFailures()
+	return status
+}
+
+// statusWarnings flags summaries that point at a misconfiguration.
+func statusWarnings(status Status) []string {
+	var warnings []string
+	if status.Credentials == 0 {
+		warnings = append(warnings, "no credentials loaded; every request will fail until one is added")
+	}
+	names := make([]string, 0, len(status.Providers))
+	for name := range status.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry := status.Providers[name]
+		switch {
+		case !entry.Executor:
+			warnings = append(warnings, fmt.Sprintf("provider %s has credentials but no executor", name))
+		case entry.Credentials > 0 && entry.Active == 0:
+			warnings = append(warnings, fmt.Sprintf("provider %s has no active credentials", name))
+		}
+	}
+	return warnings
+}
+
+// SetStatusFunc installs the function that builds the summary served by GetStatus.
+func (h *Handler) SetStatusFunc(fn func() Status) { h.statusFunc = fn }
+
+// GetStatus returns the capability summary of the running server.
+//
+// Endpoint:
+//
+//	GET /v0/status
+func (h *Handler) GetStatus(c *gin.Context) {
+	if h.statusFunc != nil {
+		c.JSON(http.StatusOK, h.statusFunc())
+		return
+	}
+	c.JSON(http.StatusOK, BuildStatus(h.cfg, h.authManager, nil))
+}
@@ -0,0 +1,38 @@
+package management
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestBuildStatus(t *testing.T) {
+	status := BuildStatus(&config.Config{Port: 8317}, coreauth.NewManager(nil, nil, nil), nil)
+	if status.Listen != ":8317" || status.Credentials != 0 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if len(status.Warnings) != 1 || !strings.Contains(status.Warnings[0], "no credentials") {
+		t.Fatalf("an empty pool must be flagged: %v", status.Warnings)
+	}
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "a", Provider: "claude", Status: coreauth.StatusActive},
+		{ID: "b", Provider: "claude", Disabled: true, Status: coreauth.StatusDisabled},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	status = BuildStatus(nil, manager, []string{"POST /v1/chat/completions"})
+	claude := status.Providers["claude"]
+	if claude == nil || claude.Credentials != 2 || claude.Active != 1 || claude.Disabled != 1 {
+		t.Fatalf("claude = %+v", claude)
+	}
+	if len(status.Warnings) != 1 || !strings.Contains(status.Warnings[0], "no executor") {
+		t.Fatalf("credentials without an executor must be flagged: %v", status.Warnings)
+	}
+}
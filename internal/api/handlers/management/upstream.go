@@ -0,0 +1,167 @@
+package management
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultUpstreamBaseURLs holds provider API roots used when a credential does not carry
+// its own base_url attribute.
+var defaultUpstreamBaseURLs = map[string]string{
+	"claude":         "https://api.anthropic.com",
+	"codex":          "https://chatgpt.com/backend-api/codex",
+	"gemini":         "https://generativelanguage.googleapis.com",
+	"qwen":           "https://portal.qwen.ai/v1",
+	"github-copilot": "https://api.githubcopilot.com",
+}
+
+// upstreamStrippedHeaders are client headers that must not reach the provider: proxy
+// credentials, hop-by-hop headers and values recomputed by the HTTP client.
+var upstreamStrippedHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"X-Management-Key":    {},
+	"X-Api-Key":           {},
+	"X-Goog-Api-Key":      {},
+	"X-Auth-Index":        {},
+	"Host":                {},
+	"Connection":          {},
+	"Keep-Alive":          {},
+	"Proxy-Authorization": {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+	"Content-Length":      {},
+	"Accept-Encoding":     {},
+}
+
+// UpstreamPassthrough forwards an arbitrary API call to a provider using a pooled
+// credential, for provider endpoints the proxy does not model (files, fine-tuning, ...).
+//
+// Endpoint:
+//
+//	ANY /v0/upstream/{provider}/*path
+//
+// The credential is chosen round-robin among active credentials of the provider, or
+// pinned with the X-Auth-Index header. Provider credentials are injected by the
+// executor's PrepareRequest; the caller authenticates with the management key.
+func (h *Handler) UpstreamPassthrough(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(c.Param("provider")))
+	if provider == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing provider"})
+		return
+	}
+
+	auth := h.upstreamAuth(provider, c.GetHeader("X-Auth-Index"))
+	if auth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no active credential for provider " + provider})
+		return
+	}
+	baseURL := ""
+	if auth.Attributes != nil {
+		baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	if baseURL == "" {
+		baseURL = defaultUpstreamBaseURLs[provider]
+	}
+	if baseURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no base url known for provider " + provider})
+		return
+	}
+
+	target := strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(c.Param("path"), "/")
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	req, errReq := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, target, c.Request.Body)
+	if errReq != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid upstream request"})
+		return
+	}
+	req.ContentLength = c.Request.ContentLength
+	for key, values := range c.Request.Header {
+		if _, skip := upstreamStrippedHeaders[http.CanonicalHeaderKey(key)]; skip {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, errDo := h.authManager.HttpRequest(c.Request.Context(), auth, req)
+	if errDo != nil {
+		log.Warnf("upstream pass-through to %s failed: %v", provider, errDo)
+		c.JSON(http.StatusBadGateway, gin.H{"error": errDo.Error()})
+		return
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("upstream pass-through: close response body: %v", errClose)
+		}
+	}()
+
+	for key, values := range resp.Header {
+		if _, skip := upstreamStrippedHeaders[http.CanonicalHeaderKey(key)]; skip {
+			continue
+		}
+		for _, value := range values {
+			c.Writer.Header().Add(key, value)
+		}
+	}
+	c.Status(resp.StatusCode)
+	flusher, _ := c.Writer.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, errRead := resp.Body.Read(buf)
+		if n > 0 {
+			if _, errWrite := c.Writer.Write(buf[:n]); errWrite != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if errRead != nil {
+			if errRead != io.EOF {
+				log.Warnf("upstream pass-through: read response body: %v", errRead)
+			}
+			return
+		}
+	}
+}
+
+// upstreamAuth returns the credential pinned by authIndex, or the next active credential
+// for provider in round-robin order.
+func (h *Handler) upstreamAuth(provider, authIndex string) *coreauth.Auth {
+	if authIndex = strings.TrimSpace(authIndex); authIndex != "" {
+		auth := h.authByIndex(authIndex)
+		if auth == nil || !strings.EqualFold(auth.Provider, provider) {
+			return nil
+		}
+		return auth
+	}
+	var candidates []*coreauth.Auth
+	for _, auth := range h.authManager.List() {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		if strings.EqualFold(auth.Provider, provider) {
+			candidates = append(candidates, auth)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	return candidates[h.upstreamCursor.Add(1)%uint64(len(candidates))]
+}
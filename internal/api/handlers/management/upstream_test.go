@@ -0,0 +1,91 @@
+package management
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type passthroughTestExecutor struct{}
+
+func (passthroughTestExecutor) Identifier() string { return "test-provider" }
+
+func (passthroughTestExecutor) Execute(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (passthroughTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (passthroughTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (passthroughTestExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (passthroughTestExecutor) HttpRequest(ctx context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+auth.Attributes["api_key"])
+	return http.DefaultClient.Do(req.WithContext(ctx))
+}
+
+func TestUpstreamPassthrough_InjectsCredential(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer upstream-key" {
+			t.Errorf("unexpected authorization %q", got)
+		}
+		if r.Header.Get("X-Management-Key") != "" {
+			t.Error("management key leaked upstream")
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery + " " + string(body)))
+	}))
+	defer upstream.Close()
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(passthroughTestExecutor{})
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{
+		ID:         "a1",
+		Provider:   "test-provider",
+		Attributes: map[string]string{"api_key": "upstream-key", "base_url": upstream.URL + "/v1"},
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	h := &Handler{authManager: manager}
+
+	router := gin.New()
+	router.Any("/v0/upstream/:provider/*path", h.UpstreamPassthrough)
+	req := httptest.NewRequest(http.MethodPost, "/v0/upstream/test-provider/files?purpose=batch", strings.NewReader("payload"))
+	req.Header.Set("Authorization", "Bearer management-secret")
+	req.Header.Set("X-Management-Key", "management-secret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Body.String(); got != "/v1/files?purpose=batch payload" {
+		t.Fatalf("unexpected body %q", got)
+	}
+	if rec.Header().Get("X-Upstream") != "yes" {
+		t.Fatal("expected upstream headers to be copied")
+	}
+
+	missing := httptest.NewRecorder()
+	router.ServeHTTP(missing, httptest.NewRequest(http.MethodGet, "/v0/upstream/unknown/models", nil))
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for provider without credentials, got %d", missing.Code)
+	}
+}
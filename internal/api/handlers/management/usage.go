@@ -3,9 +3,12 @@ package management
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 )
 
@@ -77,3 +80,66 @@ func (h *Handler) ImportUsageStatistics(c *gin.Context) {
 		"failed_requests": snapshot.FailureCount,
 	})
 }
+
+// GetCredentialHistory returns per-credential success/error counts in rolling one-minute
+// buckets, oldest first, for sparklines. The optional minutes query parameter narrows the
+// window (default and maximum 60).
+func (h *Handler) GetCredentialHistory(c *gin.Context) {
+	minutes := usage.CredentialHistoryMinutes
+	if raw := c.Query("minutes"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid minutes"})
+			return
+		}
+		minutes = value
+	}
+	history := map[string]usage.CredentialHistory{}
+	if h != nil && h.usageStats != nil {
+		history = h.usageStats.CredentialHistory(time.Now(), minutes)
+	}
+	c.JSON(http.StatusOK, gin.H{"bucket_seconds": 60, "credentials": history})
+}
+
+// GetUsageRollups returns the daily usage rollups from the usage database. The optional
+// group-by query parameter lists the dimensions to aggregate by (day, provider, model,
+// auth_index; default all); from, to (YYYY-MM-DD, inclusive), provider, model and
+// auth-index filter the rows.
+func (h *Handler) GetUsageRollups(c *gin.Context) {
+	store := usage.PersistentStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage persistence is disabled"})
+		return
+	}
+	query := usage.RollupQuery{
+		From:      c.Query("from"),
+		To:        c.Query("to"),
+		Provider:  c.Query("provider"),
+		Model:     c.Query("model"),
+		AuthIndex: c.Query("auth-index"),
+	}
+	if raw := strings.TrimSpace(c.Query("group-by")); raw != "" {
+		query.GroupBy = strings.Split(raw, ",")
+	}
+	rollups, err := store.Rollups(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if rollups == nil {
+		rollups = []usage.UsageRollup{}
+	}
+	c.JSON(http.StatusOK, gin.H{"rollups": rollups})
+}
+
+// GetAbuseEvents returns recent abuse detection audit events, oldest first.
+func (h *Handler) GetAbuseEvents(c *gin.Context) {
+	var events []middleware.AbuseEvent
+	if h != nil && h.abuseDetector != nil {
+		events = h.abuseDetector.Events()
+	}
+	if events == nil {
+		events = []middleware.AbuseEvent{}
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
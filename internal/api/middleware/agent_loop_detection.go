@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Agent loop actions.
+const (
+	AgentLoopActionWarn  = "warn"
+	AgentLoopActionError = "error"
+)
+
+const (
+	defaultAgentLoopMaxRepeats = 5
+	defaultAgentLoopWarning    = "Loop detected: you have called the same tool with the same arguments repeatedly and it has not made progress. Do not repeat this call; change your approach or ask the user for guidance."
+)
+
+// AgentLoopDetector inspects conversation history for repeated identical tool calls.
+// The history is carried by each request, so no per-conversation state is kept. It is
+// safe for concurrent use and supports hot configuration reloads via SetConfig.
+type AgentLoopDetector struct {
+	mu  sync.RWMutex
+	cfg config.AgentLoopDetectionConfig
+}
+
+// NewAgentLoopDetector creates a detector using the provided configuration.
+func NewAgentLoopDetector(cfg config.AgentLoopDetectionConfig) *AgentLoopDetector {
+	return &AgentLoopDetector{cfg: cfg}
+}
+
+// SetConfig replaces the active configuration.
+func (d *AgentLoopDetector) SetConfig(cfg config.AgentLoopDetectionConfig) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.cfg = cfg
+	d.mu.Unlock()
+}
+
+// Middleware returns a Gin handler that must run after authentication so detections can
+// be attributed to a client.
+func (d *AgentLoopDetector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d == nil || c.Request.Method != http.MethodPost || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		d.mu.RLock()
+		cfg := d.cfg
+		d.mu.RUnlock()
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Next()
+			return
+		}
+		maxRepeats := cfg.MaxRepeats
+		if maxRepeats <= 0 {
+			maxRepeats = defaultAgentLoopMaxRepeats
+		}
+		name, repeats := mostRepeatedToolCall(body)
+		if repeats > maxRepeats {
+			_, client := abuseClientKey(c)
+			log.WithFields(log.Fields{
+				"client": client,
+				"tool":   name,
+				"path":   c.Request.URL.Path,
+			}).Warnf("agent loop detected: tool call repeated %d times", repeats)
+
+			if strings.EqualFold(strings.TrimSpace(cfg.Action), AgentLoopActionError) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error": gin.H{
+						"message": "agent loop detected: tool " + strconv.Quote(name) + " was called " + strconv.Itoa(repeats) + " times with identical arguments",
+						"type":    "agent_loop_detected",
+					},
+				})
+				return
+			}
+			warning := strings.TrimSpace(cfg.WarningMessage)
+			if warning == "" {
+				warning = defaultAgentLoopWarning
+			}
+			body = injectLoopWarning(body, warning)
+			c.Request.ContentLength = int64(len(body))
+			c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// mostRepeatedToolCall returns the tool name and length of the run of identical tool
+// calls (same name and arguments) that ends the history of an OpenAI chat, OpenAI
+// Responses, Claude Messages, or Gemini request body. Repeats separated by other calls
+// are progress, not a loop, and do not count.
+func mostRepeatedToolCall(body []byte) (string, int) {
+	root := gjson.ParseBytes(body)
+	lastKey, lastName, run := "", "", 0
+	add := func(name string, args gjson.Result) {
+		if name == "" {
+			return
+		}
+		key := name + "\x00" + canonicalToolArgs(args)
+		if key == lastKey {
+			run++
+			return
+		}
+		lastKey, lastName, run = key, name, 1
+	}
+
+	root.Get("messages").ForEach(func(_, msg gjson.Result) bool {
+		msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			add(call.Get("function.name").String(), call.Get("function.arguments"))
+			return true
+		})
+		msg.Get("content").ForEach(func(_, block gjson.Result) bool {
+			if block.Get("type").String() == "tool_use" {
+				add(block.Get("name").String(), block.Get("input"))
+			}
+			return true
+		})
+		return true
+	})
+	root.Get("input").ForEach(func(_, item gjson.Result) bool {
+		if item.Get("type").String() == "function_call" {
+			add(item.Get("name").String(), item.Get("arguments"))
+		}
+		return true
+	})
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		content.Get("parts").ForEach(func(_, part gjson.Result) bool {
+			if call := part.Get("functionCall"); call.Exists() {
+				add(call.Get("name").String(), call.Get("args"))
+			}
+			return true
+		})
+		return true
+	})
+	return lastName, run
+}
+
+// canonicalToolArgs renders tool arguments with sorted keys so that equivalent argument
+// objects compare equal. OpenAI encodes arguments as a JSON string, which is decoded first.
+func canonicalToolArgs(args gjson.Result) string {
+	raw := args.Raw
+	if args.Type == gjson.String {
+		raw = args.Str
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return strings.TrimSpace(raw)
+	}
+	out, err := json.Marshal(decoded)
+	if err != nil {
+		return strings.TrimSpace(raw)
+	}
+	return string(out)
+}
+
+// injectLoopWarning appends warning as a user turn in the request's own format. For
+// Claude and Gemini, whose roles must alternate, it is merged into a trailing user turn.
+func injectLoopWarning(body []byte, warning string) []byte {
+	root := gjson.ParseBytes(body)
+	if messages := root.Get("messages"); messages.IsArray() {
+		items := messages.Array()
+		if n := len(items); n > 0 && items[n-1].Get("role").String() == "user" {
+			path := "messages." + strconv.Itoa(n-1) + ".content"
+			content := items[n-1].Get("content")
+			if content.IsArray() {
+				out, _ := sjson.SetBytes(body, path+".-1", map[string]string{"type": "text", "text": warning})
+				return out
+			}
+			if content.Type == gjson.String {
+				text := content.String()
+				if strings.TrimSpace(text) != "" {
+					text += "\n\n"
+				}
+				out, _ := sjson.SetBytes(body, path, text+warning)
+				return out
+			}
+		}
+		out, _ := sjson.SetBytes(body, "messages.-1", map[string]string{"role": "user", "content": warning})
+		return out
+	}
+	if input := root.Get("input"); input.IsArray() {
+		out, _ := sjson.SetBytes(body, "input.-1", map[string]any{
+			"type":    "message",
+			"role":    "user",
+			"content": []map[string]string{{"type": "input_text", "text": warning}},
+		})
+		return out
+	}
+	if contents := root.Get("contents"); contents.IsArray() {
+		items := contents.Array()
+		if n := len(items); n > 0 && items[n-1].Get("role").String() == "user" {
+			out, _ := sjson.SetBytes(body, "contents."+strconv.Itoa(n-1)+".parts.-1", map[string]string{"text": warning})
+			return out
+		}
+		out, _ := sjson.SetBytes(body, "contents.-1", map[string]any{"role": "user", "parts": []map[string]string{{"text": warning}}})
+		return out
+	}
+	return body
+}
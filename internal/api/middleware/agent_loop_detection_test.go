@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestMostRepeatedToolCall(t *testing.T) {
+	openAI := `{"messages":[
+		{"role":"assistant","tool_calls":[{"function":{"name":"read","arguments":"{\"path\":\"a\",\"n\":1}"}}]},
+		{"role":"assistant","tool_calls":[{"function":{"name":"read","arguments":"{\"n\":1, \"path\":\"a\"}"}}]},
+		{"role":"assistant","tool_calls":[{"function":{"name":"read","arguments":"{\"path\":\"b\"}"}}]}]}`
+	if name, count := mostRepeatedToolCall([]byte(openAI)); name != "read" || count != 1 {
+		t.Fatalf("openai = %q %d, want the trailing run only", name, count)
+	}
+	interleaved := `{"input":[
+		{"type":"function_call","name":"read","arguments":"{\"path\":\"a\"}"},
+		{"type":"function_call","name":"edit","arguments":"{\"path\":\"a\"}"},
+		{"type":"function_call","name":"read","arguments":"{\"path\":\"a\"}"},
+		{"type":"function_call","name":"edit","arguments":"{\"path\":\"a\"}"}]}`
+	if _, count := mostRepeatedToolCall([]byte(interleaved)); count != 1 {
+		t.Fatalf("interleaved calls counted as a loop: %d", count)
+	}
+	claude := `{"messages":[{"role":"assistant","content":[{"type":"tool_use","name":"bash","input":{"cmd":"ls"}}]},{"role":"assistant","content":[{"type":"tool_use","name":"bash","input":{"cmd":"ls"}}]}]}`
+	if name, count := mostRepeatedToolCall([]byte(claude)); name != "bash" || count != 2 {
+		t.Fatalf("claude = %q %d", name, count)
+	}
+	gemini := `{"contents":[{"role":"model","parts":[{"functionCall":{"name":"f","args":{}}}]},{"role":"model","parts":[{"functionCall":{"name":"f","args":{}}}]},{"role":"model","parts":[{"functionCall":{"name":"f","args":{}}}]}]}`
+	if _, count := mostRepeatedToolCall([]byte(gemini)); count != 3 {
+		t.Fatalf("gemini count = %d", count)
+	}
+}
+
+func TestAgentLoopDetectorActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"messages":[
+		{"role":"assistant","content":[{"type":"tool_use","name":"bash","input":{"cmd":"ls"}}]},
+		{"role":"user","content":[{"type":"tool_result","content":"x"}]},
+		{"role":"assistant","content":[{"type":"tool_use","name":"bash","input":{"cmd":"ls"}}]},
+		{"role":"user","content":[{"type":"tool_result","content":"x"}]}]}`
+
+	run := func(cfg config.AgentLoopDetectionConfig) (*httptest.ResponseRecorder, []byte) {
+		var seen []byte
+		engine := gin.New()
+		engine.Use(NewAgentLoopDetector(cfg).Middleware())
+		engine.POST("/v1/messages", func(c *gin.Context) {
+			seen, _ = io.ReadAll(c.Request.Body)
+			c.Status(http.StatusOK)
+		})
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/messages", bytes.NewReader([]byte(body))))
+		return rec, seen
+	}
+
+	_, seen := run(config.AgentLoopDetectionConfig{Enabled: true, MaxRepeats: 1, WarningMessage: "stop looping"})
+	if got := gjson.GetBytes(seen, "messages.3.content.1.text").String(); got != "stop looping" {
+		t.Fatalf("warning not appended to trailing user turn: %s", seen)
+	}
+
+	rec, _ := run(config.AgentLoopDetectionConfig{Enabled: true, MaxRepeats: 1, Action: "error"})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "agent_loop_detected") {
+		t.Fatalf("error action: %d %s", rec.Code, rec.Body.String())
+	}
+
+	_, seen = run(config.AgentLoopDetectionConfig{Enabled: true})
+	if string(seen) != body {
+		t.Fatal("request below the threshold must pass through unchanged")
+	}
+}
+
+func TestInjectLoopWarningMergesStringUserTurn(t *testing.T) {
+	body := `{"messages":[{"role":"assistant","content":"ok"},{"role":"user","content":"go on"}]}`
+	out := injectLoopWarning([]byte(body), "stop looping")
+	if n := len(gjson.GetBytes(out, "messages").Array()); n != 2 {
+		t.Fatalf("expected the warning to be merged, got %d messages: %s", n, out)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content").String(); got != "go on\n\nstop looping" {
+		t.Fatalf("merged content = %q", got)
+	}
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// rateLimitIdleExpiry drops the buckets of keys that have gone quiet long enough to be full.
+const rateLimitIdleExpiry = 10 * time.Minute
+
+// rateLimitCleanupEvery controls how often (in admitted requests) idle keys are purged.
+const rateLimitCleanupEvery = 1024
+
+// bucket is a token bucket holding up to one minute's worth of a per-minute rate.
+type bucket struct {
+	level float64
+}
+
+// rateLimitState holds the buckets of one client key.
+type rateLimitState struct {
+	requests bucket
+	tokens   bucket
+	updated  time.Time
+}
+
+// RateLimiter enforces the request and token rates of client API keys. Requests are
+// counted when they are admitted; tokens when usage is reported, through HandleUsage. It
+// is safe for concurrent use and supports hot configuration reloads via SetConfig.
+type RateLimiter struct {
+	mu       sync.Mutex
+	cfg      config.RateLimitConfig
+	keys     map[string]*rateLimitState
+	requests int
+	now      func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter using the provided configuration.
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{cfg: cfg, keys: make(map[string]*rateLimitState), now: time.Now}
+}
+
+// SetConfig replaces the active configuration. Bucket levels are kept.
+func (l *RateLimiter) SetConfig(cfg config.RateLimitConfig) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.cfg = cfg
+	l.mu.Unlock()
+}
+
+// limits returns the per-minute request and token rates of key; 0 means unlimited.
+// l.mu must be held.
+func (l *RateLimiter) limits(key string) (int, int) {
+	rpm, tpm := l.cfg.RequestsPerMinute, l.cfg.TokensPerMinute
+	if own, ok := l.cfg.Keys[key]; ok {
+		if own.RequestsPerMinute != 0 {
+			rpm = own.RequestsPerMinute
+		}
+		if own.TokensPerMinute != 0 {
+			tpm = own.TokensPerMinute
+		}
+	}
+	return max(rpm, 0), max(tpm, 0)
+}
+
+// refill returns the state of key with its buckets topped up to now. l.mu must be held.
+func (l *RateLimiter) refill(key string, rpm, tpm int, now time.Time) *rateLimitState {
+	state := l.keys[key]
+	if state == nil {
+		state = &rateLimitState{requests: bucket{level: float64(rpm)}, tokens: bucket{level: float64(tpm)}, updated: now}
+		l.keys[key] = state
+		return state
+	}
+	elapsed := now.Sub(state.updated).Minutes()
+	if elapsed > 0 {
+		state.requests.level = math.Min(float64(rpm), state.requests.level+elapsed*float64(rpm))
+		state.tokens.level = math.Min(float64(tpm), state.tokens.level+elapsed*float64(tpm))
+		state.updated = now
+	}
+	return state
+}
+
+// Middleware returns a Gin handler that must run after authentication so the client
+// API key is available. Requests without a key are not limited.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, _ := c.Get("apiKey")
+		apiKey, _ := key.(string)
+		if l == nil || apiKey == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		allowed, headers, retryAfter, message := l.admit(apiKey)
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		if allowed {
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": gin.H{
+				"message": message,
+				"type":    "rate_limit_exceeded",
+			},
+		})
+	}
+}
+
+// admit counts a request of apiKey against its buckets. It returns the rate limit headers
+// and, for a rejected request, the seconds until it may retry and the reason.
+func (l *RateLimiter) admit(apiKey string) (bool, map[string]string, int, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rpm, tpm := l.limits(apiKey)
+	if rpm == 0 && tpm == 0 {
+		return true, nil, 0, ""
+	}
+	now := l.now()
+	l.requests++
+	if l.requests%rateLimitCleanupEvery == 0 {
+		l.purgeIdleLocked(now)
+	}
+	state := l.refill(apiKey, rpm, tpm, now)
+
+	allowed, retryAfter, message := true, 0, ""
+	if rpm > 0 && state.requests.level < 1 {
+		allowed, message = false, "request rate limit of "+strconv.Itoa(rpm)+" per minute exceeded"
+		retryAfter = secondsUntil(1-state.requests.level, rpm)
+	} else if tpm > 0 && state.tokens.level <= 0 {
+		allowed, message = false, "token rate limit of "+strconv.Itoa(tpm)+" per minute exceeded"
+		retryAfter = secondsUntil(math.Max(1, -state.tokens.level), tpm)
+	}
+	if allowed && rpm > 0 {
+		state.requests.level--
+	}
+
+	headers := make(map[string]string, 6)
+	if rpm > 0 {
+		headers["X-RateLimit-Limit-Requests"] = strconv.Itoa(rpm)
+		headers["X-RateLimit-Remaining-Requests"] = strconv.Itoa(int(math.Max(0, state.requests.level)))
+		headers["X-RateLimit-Reset-Requests"] = strconv.Itoa(secondsUntil(float64(rpm)-state.requests.level, rpm)) + "s"
+	}
+	if tpm > 0 {
+		headers["X-RateLimit-Limit-Tokens"] = strconv.Itoa(tpm)
+		headers["X-RateLimit-Remaining-Tokens"] = strconv.Itoa(int(math.Max(0, state.tokens.level)))
+		headers["X-RateLimit-Reset-Tokens"] = strconv.Itoa(secondsUntil(float64(tpm)-state.tokens.level, tpm)) + "s"
+	}
+	return allowed, headers, retryAfter, message
+}
+
+// HandleUsage implements coreusage.Plugin. It charges the tokens of a finished request to
+// the bucket of its client key.
+func (l *RateLimiter) HandleUsage(_ context.Context, record coreusage.Record) {
+	if l == nil || record.APIKey == "" {
+		return
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens <= 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens
+	}
+	if tokens <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rpm, tpm := l.limits(record.APIKey)
+	if tpm == 0 {
+		return
+	}
+	state := l.refill(record.APIKey, rpm, tpm, l.now())
+	state.tokens.level -= float64(tokens)
+}
+
+func (l *RateLimiter) purgeIdleLocked(now time.Time) {
+	for key, state := range l.keys {
+		if now.Sub(state.updated) > rateLimitIdleExpiry {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// secondsUntil returns how many whole seconds a bucket refilling perMinute per minute
+// needs to gain amount, at least 1.
+func secondsUntil(amount float64, perMinute int) int {
+	if amount <= 0 || perMinute <= 0 {
+		return 0
+	}
+	return max(1, int(math.Ceil(amount*60/float64(perMinute))))
+}
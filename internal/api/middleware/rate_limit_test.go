@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func newRateLimitTestEngine(limiter *RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	})
+	engine.Use(limiter.Middleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func sendRateLimitTestRequest(engine *gin.Engine, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+	req.Header.Set("X-Test-Key", key)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimiter_Requests(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewRateLimiter(config.RateLimitConfig{
+		RequestsPerMinute: 2,
+		Keys:              map[string]config.RateLimit{"vip": {RequestsPerMinute: -1}},
+	})
+	limiter.now = func() time.Time { return now }
+	engine := newRateLimitTestEngine(limiter)
+
+	for i := 0; i < 2; i++ {
+		if rec := sendRateLimitTestRequest(engine, "client"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	rec := sendRateLimitTestRequest(engine, "client")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected 429 with Retry-After 30, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining-Requests"); got != "0" || rec.Header().Get("X-RateLimit-Limit-Requests") != "2" {
+		t.Fatalf("remaining = %q, want 0 of 2", got)
+	}
+	for i := 0; i < 5; i++ {
+		if rec = sendRateLimitTestRequest(engine, "vip"); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit-Requests") != "" {
+			t.Fatalf("a key with a lifted limit was limited: %d", rec.Code)
+		}
+	}
+
+	now = now.Add(30 * time.Second)
+	if rec = sendRateLimitTestRequest(engine, "client"); rec.Code != http.StatusOK {
+		t.Fatalf("expected a request after refilling, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiter_Tokens(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewRateLimiter(config.RateLimitConfig{TokensPerMinute: 1000})
+	limiter.now = func() time.Time { return now }
+	engine := newRateLimitTestEngine(limiter)
+
+	if rec := sendRateLimitTestRequest(engine, "client"); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Remaining-Tokens") != "1000" {
+		t.Fatalf("first request: %d, remaining %q", rec.Code, rec.Header().Get("X-RateLimit-Remaining-Tokens"))
+	}
+	limiter.HandleUsage(context.Background(), coreusage.Record{APIKey: "client", Detail: coreusage.Detail{TotalTokens: 1500}})
+	rec := sendRateLimitTestRequest(engine, "client")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected 429 until the token debt is repaid, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(31 * time.Second)
+	if rec = sendRateLimitTestRequest(engine, "client"); rec.Code != http.StatusOK {
+		t.Fatalf("expected a request once tokens refilled, got %d", rec.Code)
+	}
+}
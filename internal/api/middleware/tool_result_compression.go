@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultToolResultMaxBytes  = 32 << 10
+	defaultToolResultHeadRatio = 0.5
+)
+
+// ToolResultCompressor truncates large tool results in inference requests. It is safe
+// for concurrent use and supports hot configuration reloads via SetConfig.
+type ToolResultCompressor struct {
+	mu  sync.RWMutex
+	cfg config.ToolResultCompressionConfig
+}
+
+// NewToolResultCompressor creates a compressor using the provided configuration.
+func NewToolResultCompressor(cfg config.ToolResultCompressionConfig) *ToolResultCompressor {
+	return &ToolResultCompressor{cfg: cfg}
+}
+
+// SetConfig replaces the active configuration.
+func (t *ToolResultCompressor) SetConfig(cfg config.ToolResultCompressionConfig) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.cfg = cfg
+	t.mu.Unlock()
+}
+
+// settingsFor resolves whether compression applies to apiKey and the limits to use.
+func (t *ToolResultCompressor) settingsFor(apiKey string) (enabled bool, maxBytes int, headRatio float64) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	enabled = t.cfg.Enabled
+	maxBytes = t.cfg.MaxBytes
+	if apiKey != "" {
+		if override, ok := t.cfg.EnabledPerKey[apiKey]; ok {
+			enabled = override
+		}
+		if override, ok := t.cfg.MaxBytesPerKey[apiKey]; ok {
+			maxBytes = override
+		}
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultToolResultMaxBytes
+	}
+	headRatio = t.cfg.HeadRatio
+	if headRatio <= 0 || headRatio >= 1 {
+		headRatio = defaultToolResultHeadRatio
+	}
+	return enabled, maxBytes, headRatio
+}
+
+// Middleware returns a Gin handler that must run after authentication so per-key
+// settings can be resolved.
+func (t *ToolResultCompressor) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if t == nil || c.Request.Method != http.MethodPost || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		apiKey := ""
+		if v, exists := c.Get("apiKey"); exists {
+			apiKey, _ = v.(string)
+		}
+		enabled, maxBytes, headRatio := t.settingsFor(apiKey)
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Next()
+			return
+		}
+		if len(body) > maxBytes {
+			if out, count := compressToolResults(body, maxBytes, headRatio); count > 0 {
+				log.Debugf("tool result compression: truncated %d tool result(s), %d -> %d bytes", count, len(body), len(out))
+				body = out
+				c.Request.ContentLength = int64(len(body))
+				c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			}
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// compressToolResults truncates every tool result larger than maxBytes in an OpenAI chat,
+// OpenAI Responses, Claude Messages, or Gemini request body. It returns the rewritten
+// body and the number of truncated results.
+func compressToolResults(body []byte, maxBytes int, headRatio float64) ([]byte, int) {
+	root := gjson.ParseBytes(body)
+	count := 0
+	truncateAt := func(path string, value gjson.Result) {
+		if value.Type != gjson.String || len(value.Str) <= maxBytes {
+			return
+		}
+		updated, err := sjson.SetBytes(body, path, truncateMiddle(value.Str, maxBytes, headRatio))
+		if err != nil {
+			return
+		}
+		body = updated
+		count++
+	}
+	truncateTextParts := func(path string, content gjson.Result) {
+		if content.Type == gjson.String {
+			truncateAt(path, content)
+			return
+		}
+		content.ForEach(func(key, part gjson.Result) bool {
+			if part.Get("type").String() == "text" {
+				truncateAt(fmt.Sprintf("%s.%d.text", path, key.Int()), part.Get("text"))
+			}
+			return true
+		})
+	}
+
+	// OpenAI chat tool messages and Claude tool_result blocks.
+	root.Get("messages").ForEach(func(i, msg gjson.Result) bool {
+		contentPath := fmt.Sprintf("messages.%d.content", i.Int())
+		if msg.Get("role").String() == "tool" {
+			truncateTextParts(contentPath, msg.Get("content"))
+			return true
+		}
+		msg.Get("content").ForEach(func(j, block gjson.Result) bool {
+			if block.Get("type").String() == "tool_result" {
+				truncateTextParts(fmt.Sprintf("%s.%d.content", contentPath, j.Int()), block.Get("content"))
+			}
+			return true
+		})
+		return true
+	})
+
+	// OpenAI Responses function call outputs.
+	root.Get("input").ForEach(func(i, item gjson.Result) bool {
+		if item.Get("type").String() == "function_call_output" {
+			truncateAt(fmt.Sprintf("input.%d.output", i.Int()), item.Get("output"))
+		}
+		return true
+	})
+
+	// Gemini function responses: string fields of the response object.
+	root.Get("contents").ForEach(func(i, content gjson.Result) bool {
+		content.Get("parts").ForEach(func(j, part gjson.Result) bool {
+			part.Get("functionResponse.response").ForEach(func(key, value gjson.Result) bool {
+				truncateAt(fmt.Sprintf("contents.%d.parts.%d.functionResponse.response.%s", i.Int(), j.Int(), escapeJSONPathKey(key.String())), value)
+				return true
+			})
+			return true
+		})
+		return true
+	})
+	return body, count
+}
+
+// truncateMiddle keeps roughly maxBytes of s split between its head and tail and replaces
+// the middle with a marker stating how much was removed. Cuts respect UTF-8 boundaries.
+func truncateMiddle(s string, maxBytes int, headRatio float64) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	head := int(float64(maxBytes) * headRatio)
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	tailStart := len(s) - (maxBytes - head)
+	for tailStart < len(s) && !utf8.RuneStart(s[tailStart]) {
+		tailStart++
+	}
+	omitted := tailStart - head
+	return s[:head] + fmt.Sprintf("\n\n[... %d bytes of tool output omitted ...]\n\n", omitted) + s[tailStart:]
+}
+
+// escapeJSONPathKey escapes characters that carry meaning in gjson/sjson paths.
+func escapeJSONPathKey(key string) string {
+	var b bytes.Buffer
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '\\', '|', '#', '@', '!', '=', '<', '>', '%':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	s := strings.Repeat("a", 50) + strings.Repeat("é", 100) + strings.Repeat("z", 50)
+	out := truncateMiddle(s, 100, 0.5)
+	if !strings.HasPrefix(out, strings.Repeat("a", 50)) || !strings.HasSuffix(out, strings.Repeat("z", 50)) {
+		t.Fatalf("head/tail not preserved: %q", out)
+	}
+	if !strings.Contains(out, "bytes of tool output omitted") || !utf8.ValidString(out) {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if got := truncateMiddle("short", 100, 0.5); got != "short" {
+		t.Fatalf("short input changed: %q", got)
+	}
+}
+
+func TestCompressToolResults(t *testing.T) {
+	big := strings.Repeat("x", 500)
+	body := []byte(`{}`)
+	body, _ = sjson.SetBytes(body, "messages.0", map[string]any{"role": "tool", "tool_call_id": "1", "content": big})
+	body, _ = sjson.SetBytes(body, "messages.1", map[string]any{"role": "user", "content": []any{map[string]any{"type": "tool_result", "content": []any{map[string]any{"type": "text", "text": big}}}}})
+	body, _ = sjson.SetBytes(body, "messages.2", map[string]any{"role": "user", "content": big})
+	body, _ = sjson.SetBytes(body, "input.0", map[string]any{"type": "function_call_output", "output": big})
+	body, _ = sjson.SetBytes(body, "contents.0.parts.0.functionResponse.response", map[string]any{"file.txt": big})
+
+	out, count := compressToolResults(body, 100, 0.5)
+	if count != 4 {
+		t.Fatalf("count = %d, want 4", count)
+	}
+	for _, path := range []string{"messages.0.content", "messages.1.content.0.content.0.text", "input.0.output", `contents.0.parts.0.functionResponse.response.file\.txt`} {
+		if got := gjson.GetBytes(out, path).String(); len(got) >= len(big) {
+			t.Fatalf("%s not truncated (%d bytes)", path, len(got))
+		}
+	}
+	if got := gjson.GetBytes(out, "messages.2.content").String(); got != big {
+		t.Fatal("plain user content must not be truncated")
+	}
+}
+
+func TestToolResultCompressorSettingsPerKey(t *testing.T) {
+	c := NewToolResultCompressor(config.ToolResultCompressionConfig{
+		EnabledPerKey:  map[string]bool{"agent": true},
+		MaxBytesPerKey: map[string]int{"agent": 2048},
+	})
+	if enabled, _, _ := c.settingsFor("other"); enabled {
+		t.Fatal("compression should be disabled by default")
+	}
+	enabled, maxBytes, headRatio := c.settingsFor("agent")
+	if !enabled || maxBytes != 2048 || headRatio != defaultToolResultHeadRatio {
+		t.Fatalf("settings = %v %d %v", enabled, maxBytes, headRatio)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -84,9 +85,11 @@ func createReverseProxy(upstreamURL string, secretSource SecretSource) (*httputi
 		removeQueryValuesMatching(req, "key", clientKey)
 		removeQueryValuesMatching(req, "auth_token", clientKey)
 
-		// Preserve correlation headers for debugging
-		if req.Header.Get("X-Request-ID") == "" {
-			// Could generate one here if needed
+		// Preserve correlation headers for debugging; requests without one carry ours.
+		if req.Header.Get(logging.RequestIDHeader) == "" {
+			if requestID := logging.GetRequestID(req.Context()); requestID != "" {
+				req.Header.Set(logging.RequestIDHeader, requestID)
+			}
 		}
 
 		// Note: We do NOT filter Anthropic-Beta headers in the proxy path
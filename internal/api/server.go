@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -24,17 +25,24 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/bedrock"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/cohere"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/jobs"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/ollama"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -47,6 +55,7 @@ type serverOptionConfig struct {
 	routerConfigurator   func(*gin.Engine, *handlers.BaseAPIHandler, *config.Config)
 	requestLoggerFactory func(*config.Config, string) logging.RequestLogger
 	localPassword        string
+	readOnly             bool
 	keepAliveEnabled     bool
 	keepAliveTimeout     time.Duration
 	keepAliveOnTimeout   func()
@@ -91,6 +100,14 @@ func WithLocalManagementPassword(password string) ServerOption {
 	}
 }
 
+// WithReadOnly pins read-only mode on for the lifetime of the server, regardless of the
+// read-only config key.
+func WithReadOnly() ServerOption {
+	return func(cfg *serverOptionConfig) {
+		cfg.readOnly = true
+	}
+}
+
 // WithKeepAliveEndpoint enables a keep-alive endpoint with the provided timeout and callback.
 func WithKeepAliveEndpoint(timeout time.Duration, onTimeout func()) ServerOption {
 	return func(cfg *serverOptionConfig) {
@@ -151,6 +168,18 @@ type Server struct {
 	// management handler
 	mgmt *managementHandlers.Handler
 
+	// abuseDetector applies abuse heuristics to inference routes.
+	abuseDetector *middleware.AbuseDetector
+
+	// toolResultCompressor truncates oversized tool results on inference routes.
+	toolResultCompressor *middleware.ToolResultCompressor
+
+	// agentLoopDetector catches agent clients repeating identical tool calls.
+	agentLoopDetector *middleware.AgentLoopDetector
+
+	// rateLimiter enforces the request and token rates of client API keys.
+	rateLimiter *middleware.RateLimiter
+
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
@@ -200,6 +229,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 
 	// Add middleware
+	if err := tracing.Apply(cfg.Tracing); err != nil {
+		log.Errorf("failed to configure tracing: %v", err)
+	}
+	engine.Use(tracing.Middleware())
 	engine.Use(logging.GinLogrusLogger())
 	engine.Use(logging.GinLogrusRecovery())
 	for _, mw := range optionState.extraMiddleware {
@@ -219,6 +252,16 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 			if setter, ok := requestLogger.(interface{ SetEnabled(bool) }); ok {
 				toggle = setter.SetEnabled
 			}
+			if remover, ok := requestLogger.(interface {
+				RemoveLogsOlderThan(time.Time) (int, error)
+			}); ok {
+				janitor.Register("request-logs", time.Hour, func(cfg *config.Config, now time.Time) (int, error) {
+					if cfg == nil || cfg.Janitor.RequestLogMaxAgeHours <= 0 {
+						return 0, nil
+					}
+					return remover.RemoveLogsOlderThan(now.Add(-time.Duration(cfg.Janitor.RequestLogMaxAgeHours) * time.Hour))
+				})
+			}
 		}
 	}
 
@@ -254,8 +297,19 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
+	s.abuseDetector = middleware.NewAbuseDetector(cfg.AbuseDetection)
+	s.toolResultCompressor = middleware.NewToolResultCompressor(cfg.ToolResultCompression)
+	s.agentLoopDetector = middleware.NewAgentLoopDetector(cfg.AgentLoopDetection)
+	s.rateLimiter = middleware.NewRateLimiter(cfg.RateLimits)
+	coreusage.RegisterPlugin(s.rateLimiter)
+	if err := usage.ConfigurePersistence(cfg.UsagePersistence, filepath.Dir(configFilePath)); err != nil {
+		log.Errorf("failed to open usage database: %v", err)
+	}
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
+	s.mgmt.SetAbuseDetector(s.abuseDetector)
+	s.mgmt.SetReadOnlyOverride(optionState.readOnly)
+	s.mgmt.SetStatusFunc(s.Status)
 	if optionState.localPassword != "" {
 		s.mgmt.SetLocalPassword(optionState.localPassword)
 	}
@@ -315,28 +369,65 @@ func (s *Server) setupRoutes() {
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	ollamaHandlers := ollama.NewOllamaAPIHandler(s.handlers)
+	bedrockHandlers := bedrock.NewBedrockAPIHandler(s.handlers)
+	cohereHandlers := cohere.NewCohereAPIHandler(s.handlers)
+	jobsHandlers := jobs.NewJobsAPIHandler(s.handlers)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(AuthMiddleware(s.accessManager), s.rateLimiter.Middleware(), s.abuseDetector.Middleware(), s.agentLoopDetector.Middleware(), s.toolResultCompressor.Middleware())
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/rerank", openaiHandlers.Rerank)
+		v1.POST("/moderations", openaiHandlers.Moderations)
+		v1.GET("/realtime", openaiHandlers.Realtime)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.GET("/responses/:id", openaiResponsesHandlers.GetResponse)
+		v1.DELETE("/responses/:id", openaiResponsesHandlers.DeleteResponse)
+		v1.POST("/jobs", jobsHandlers.CreateJob)
+		v1.GET("/jobs/:id", jobsHandlers.GetJob)
+		v1.POST("/jobs/:id/cancel", jobsHandlers.CancelJob)
+		v1.DELETE("/jobs/:id", jobsHandlers.DeleteJob)
 	}
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(AuthMiddleware(s.accessManager), s.rateLimiter.Middleware(), s.abuseDetector.Middleware(), s.agentLoopDetector.Middleware(), s.toolResultCompressor.Middleware())
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
 		v1beta.GET("/models/*action", geminiHandlers.GeminiGetHandler)
 	}
 
+	// Ollama compatible API routes
+	ollamaAPI := s.engine.Group("/api")
+	ollamaAPI.Use(AuthMiddleware(s.accessManager), s.rateLimiter.Middleware(), s.abuseDetector.Middleware(), s.agentLoopDetector.Middleware(), s.toolResultCompressor.Middleware())
+	{
+		ollamaAPI.GET("/tags", ollamaHandlers.Tags)
+		ollamaAPI.POST("/chat", ollamaHandlers.Chat)
+		ollamaAPI.POST("/generate", ollamaHandlers.Generate)
+	}
+
+	// Bedrock Runtime Converse compatible API routes
+	bedrockModel := s.engine.Group("/model")
+	bedrockModel.Use(AuthMiddleware(s.accessManager), s.rateLimiter.Middleware(), s.abuseDetector.Middleware(), s.agentLoopDetector.Middleware(), s.toolResultCompressor.Middleware())
+	{
+		bedrockModel.POST("/*action", bedrockHandlers.ModelHandler)
+	}
+
+	// Cohere v2 chat compatible API routes
+	v2 := s.engine.Group("/v2")
+	v2.Use(AuthMiddleware(s.accessManager), s.rateLimiter.Middleware(), s.abuseDetector.Middleware(), s.agentLoopDetector.Middleware(), s.toolResultCompressor.Middleware())
+	{
+		v2.POST("/chat", cohereHandlers.Chat)
+	}
+
 	// Root endpoint
 	s.engine.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -344,6 +435,9 @@ func (s *Server) setupRoutes() {
 			"endpoints": []string{
 				"POST /v1/chat/completions",
 				"POST /v1/completions",
+				"POST /v1/embeddings",
+				"POST /v1/rerank",
+				"POST /v1/moderations",
 				"GET /v1/models",
 			},
 		})
@@ -359,7 +453,7 @@ func (s *Server) setupRoutes() {
 	// OAuth callback endpoints (reuse main server port)
 	// These endpoints receive provider redirects and persist
 	// the short-lived code/state for the waiting goroutine.
-	s.engine.GET("/anthropic/callback", func(c *gin.Context) {
+	s.engine.GET("/anthropic/callback", s.loginCallbackGuard(), func(c *gin.Context) {
 		code := c.Query("code")
 		state := c.Query("state")
 		errStr := c.Query("error")
@@ -373,7 +467,7 @@ func (s *Server) setupRoutes() {
 		c.String(http.StatusOK, oauthCallbackSuccessHTML)
 	})
 
-	s.engine.GET("/codex/callback", func(c *gin.Context) {
+	s.engine.GET("/codex/callback", s.loginCallbackGuard(), func(c *gin.Context) {
 		code := c.Query("code")
 		state := c.Query("state")
 		errStr := c.Query("error")
@@ -387,7 +481,7 @@ func (s *Server) setupRoutes() {
 		c.String(http.StatusOK, oauthCallbackSuccessHTML)
 	})
 
-	s.engine.GET("/google/callback", func(c *gin.Context) {
+	s.engine.GET("/google/callback", s.loginCallbackGuard(), func(c *gin.Context) {
 		code := c.Query("code")
 		state := c.Query("state")
 		errStr := c.Query("error")
@@ -401,7 +495,7 @@ func (s *Server) setupRoutes() {
 		c.String(http.StatusOK, oauthCallbackSuccessHTML)
 	})
 
-	s.engine.GET("/iflow/callback", func(c *gin.Context) {
+	s.engine.GET("/iflow/callback", s.loginCallbackGuard(), func(c *gin.Context) {
 		code := c.Query("code")
 		state := c.Query("state")
 		errStr := c.Query("error")
@@ -415,7 +509,7 @@ func (s *Server) setupRoutes() {
 		c.String(http.StatusOK, oauthCallbackSuccessHTML)
 	})
 
-	s.engine.GET("/antigravity/callback", func(c *gin.Context) {
+	s.engine.GET("/antigravity/callback", s.loginCallbackGuard(), func(c *gin.Context) {
 		code := c.Query("code")
 		state := c.Query("state")
 		errStr := c.Query("error")
@@ -429,7 +523,7 @@ func (s *Server) setupRoutes() {
 		c.String(http.StatusOK, oauthCallbackSuccessHTML)
 	})
 
-	s.engine.GET("/kiro/callback", func(c *gin.Context) {
+	s.engine.GET("/kiro/callback", s.loginCallbackGuard(), func(c *gin.Context) {
 		code := c.Query("code")
 		state := c.Query("state")
 		errStr := c.Query("error")
@@ -493,17 +587,47 @@ func (s *Server) registerManagementRoutes() {
 
 	log.Info("management routes registered after secret key configuration")
 
+	upstream := s.engine.Group("/v0/upstream")
+	upstream.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	upstream.Any("/:provider/*path", s.mgmt.UpstreamPassthrough)
+
+	route := s.engine.Group("/v0/route")
+	route.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	route.GET("/explain", s.mgmt.GetRouteExplain)
+
+	health := s.engine.Group("/v0/health")
+	health.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	health.GET("", s.mgmt.GetHealth)
+
+	status := s.engine.Group("/v0/status")
+	status.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	status.GET("", s.mgmt.GetStatus)
+
+	selfTest := s.engine.Group("/v0/selftest")
+	selfTest.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	selfTest.GET("", s.mgmt.GetSelfTest)
+
+	// Share links carry their own signature and are served without the management key.
+	s.engine.GET("/v0/share/:token", s.managementAvailabilityMiddleware(), s.mgmt.GetSharedRequestLog)
+
 	mgmt := s.engine.Group("/v0/management")
-	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware(), s.mgmt.ReadOnlyGuard())
 	{
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
+		mgmt.GET("/usage/credential-history", s.mgmt.GetCredentialHistory)
+		mgmt.GET("/usage/rollups", s.mgmt.GetUsageRollups)
+		mgmt.GET("/abuse-events", s.mgmt.GetAbuseEvents)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
 
+		mgmt.GET("/read-only", s.mgmt.GetReadOnly)
+		mgmt.PUT("/read-only", s.mgmt.PutReadOnly)
+		mgmt.PATCH("/read-only", s.mgmt.PutReadOnly)
+
 		mgmt.GET("/debug", s.mgmt.GetDebug)
 		mgmt.PUT("/debug", s.mgmt.PutDebug)
 		mgmt.PATCH("/debug", s.mgmt.PutDebug)
@@ -512,6 +636,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/logging-to-file", s.mgmt.PutLoggingToFile)
 		mgmt.PATCH("/logging-to-file", s.mgmt.PutLoggingToFile)
 
+		mgmt.GET("/janitor", s.mgmt.GetJanitorStats)
+		mgmt.GET("/inflight-requests", s.mgmt.GetInflightRequests)
+		mgmt.DELETE("/inflight-requests/:id", s.mgmt.CancelInflightRequest)
+		mgmt.GET("/fair-queue", s.mgmt.GetFairQueue)
+		mgmt.GET("/canary", s.mgmt.GetCanaryStats)
 		mgmt.GET("/logs-max-total-size-mb", s.mgmt.GetLogsMaxTotalSizeMB)
 		mgmt.PUT("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
 		mgmt.PATCH("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
@@ -549,7 +678,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.DELETE("/logs", s.mgmt.DeleteLogs)
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
+		mgmt.GET("/artifacts", s.mgmt.ListArtifacts)
+		mgmt.GET("/artifacts/:name", s.mgmt.DownloadArtifact)
+		mgmt.DELETE("/artifacts/:name", s.mgmt.DeleteArtifact)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.POST("/request-log-by-id/:id/share", s.mgmt.CreateRequestLogShareLink)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
@@ -630,6 +763,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
+		mgmt.POST("/auth-files/import", s.mgmt.ImportAuthTokens)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
@@ -646,6 +780,44 @@ func (s *Server) registerManagementRoutes() {
 	}
 }
 
+// Status summarizes the providers, credentials, client endpoints and optional features of
+// the server.
+func (s *Server) Status() managementHandlers.Status {
+	var manager *auth.Manager
+	if s.handlers != nil {
+		manager = s.handlers.AuthManager
+	}
+	return managementHandlers.BuildStatus(s.cfg, manager, clientEndpoints(s.engine.Routes()))
+}
+
+// clientEndpoints lists the client-facing routes as "METHOD /path", leaving out
+// management, OAuth callback and control panel routes.
+func clientEndpoints(routes gin.RoutesInfo) []string {
+	out := make([]string, 0, len(routes))
+	for _, route := range routes {
+		switch {
+		case route.Path == "/", strings.HasPrefix(route.Path, "/v0/"), strings.HasPrefix(route.Path, "/management"),
+			strings.HasSuffix(route.Path, "/callback"):
+			continue
+		}
+		out = append(out, route.Method+" "+route.Path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// loginCallbackGuard refuses OAuth provider redirects while read-only mode is on, so no
+// new credential is saved.
+func (s *Server) loginCallbackGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.mgmt != nil && s.mgmt.ReadOnly() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+			return
+		}
+		c.Next()
+	}
+}
+
 func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !s.managementRoutesEnabled.Load() {
@@ -834,6 +1006,9 @@ func (s *Server) Stop(ctx context.Context) error {
 	if err := s.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
+	if err := tracing.Shutdown(ctx); err != nil {
+		log.Warnf("failed to flush traces: %v", err)
+	}
 
 	log.Debug("API server stopped")
 	return nil
@@ -849,6 +1024,7 @@ func corsMiddleware() gin.HandlerFunc {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "*")
+		c.Header("Access-Control-Expose-Headers", logging.RequestIDHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -990,6 +1166,16 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 
 	s.handlers.UpdateClients(&cfg.SDKConfig)
+	s.abuseDetector.SetConfig(cfg.AbuseDetection)
+	s.toolResultCompressor.SetConfig(cfg.ToolResultCompression)
+	s.agentLoopDetector.SetConfig(cfg.AgentLoopDetection)
+	s.rateLimiter.SetConfig(cfg.RateLimits)
+	if err := tracing.Apply(cfg.Tracing); err != nil {
+		log.Errorf("failed to apply tracing config: %v", err)
+	}
+	if err := usage.ConfigurePersistence(cfg.UsagePersistence, filepath.Dir(s.configFilePath)); err != nil {
+		log.Errorf("failed to apply usage persistence config: %v", err)
+	}
 
 	if !cfg.RemoteManagement.DisableControlPanel {
 		staticDir := managementasset.StaticDir(s.configFilePath)
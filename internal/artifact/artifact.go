@@ -0,0 +1,208 @@
+// Package artifact stores copies of model responses that clients ask to keep
+// server-side (see the X-Artifact-Name request header), so long generations survive a
+// client disconnect and can be fetched later through the management API.
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// partialSuffix marks an artifact whose response is still streaming or ended in error.
+const partialSuffix = ".partial"
+
+var (
+	// ErrInvalidName is returned for names outside [A-Za-z0-9._-] or longer than 128 bytes.
+	ErrInvalidName = errors.New("invalid artifact name")
+	// ErrNotFound is returned when no artifact with the name exists.
+	ErrNotFound = errors.New("artifact not found")
+
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+)
+
+// ValidName reports whether name can be used as an artifact name.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name) && !strings.HasSuffix(name, partialSuffix)
+}
+
+// Info describes one stored artifact.
+type Info struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Modified int64  `json:"modified"`
+	// Complete is false while the response is still being written, or when it ended in
+	// an upstream error.
+	Complete bool `json:"complete"`
+}
+
+// Writer appends a response to an artifact. The content is kept under a partial name
+// until Commit succeeds.
+type Writer struct {
+	mu      sync.Mutex
+	file    *os.File
+	partial string
+	final   string
+	failed  bool
+}
+
+// Create starts a new artifact named name in dir, replacing any earlier artifact with
+// the same name.
+func Create(dir, name string) (*Writer, error) {
+	if !ValidName(name) {
+		return nil, ErrInvalidName
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create artifact directory: %w", err)
+	}
+	final := filepath.Join(dir, name)
+	partial := final + partialSuffix
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create artifact: %w", err)
+	}
+	_ = os.Remove(final)
+	return &Writer{file: file, partial: partial, final: final}, nil
+}
+
+// Write appends p to the artifact. After the first failed write the artifact stops
+// growing and is left partial; the error is returned once.
+func (w *Writer) Write(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil || w.failed {
+		return nil
+	}
+	if _, err := w.file.Write(p); err != nil {
+		w.failed = true
+		return err
+	}
+	return nil
+}
+
+// Commit closes the artifact and publishes it under its final name.
+func (w *Writer) Commit() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	errClose := w.file.Close()
+	w.file = nil
+	if errClose != nil || w.failed {
+		return errors.Join(errClose, errors.New("artifact left partial after a write error"))
+	}
+	return os.Rename(w.partial, w.final)
+}
+
+// Abort closes the artifact and keeps whatever was written under the partial name.
+func (w *Writer) Abort() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// List returns the artifacts stored in dir, newest first.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Info{}, nil
+		}
+		return nil, err
+	}
+	out := make([]Info, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name, partial := strings.CutSuffix(entry.Name(), partialSuffix)
+		if !ValidName(name) {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil {
+			return nil, errInfo
+		}
+		out = append(out, Info{Name: name, Size: info.Size(), Modified: info.ModTime().Unix(), Complete: !partial})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Modified > out[j].Modified })
+	return out, nil
+}
+
+// Path returns the file holding artifact name in dir, preferring the committed copy.
+func Path(dir, name string) (string, Info, error) {
+	if !ValidName(name) {
+		return "", Info{}, ErrInvalidName
+	}
+	for _, candidate := range []string{name, name + partialSuffix} {
+		path := filepath.Join(dir, candidate)
+		stat, err := os.Stat(path)
+		if err != nil || stat.IsDir() {
+			continue
+		}
+		return path, Info{Name: name, Size: stat.Size(), Modified: stat.ModTime().Unix(), Complete: candidate == name}, nil
+	}
+	return "", Info{}, ErrNotFound
+}
+
+// Remove deletes artifact name from dir, including a partial copy.
+func Remove(dir, name string) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+	removed := false
+	for _, candidate := range []string{name, name + partialSuffix} {
+		err := os.Remove(filepath.Join(dir, candidate))
+		if err == nil {
+			removed = true
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if !removed {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveStalePartials deletes partial artifacts in dir last written before cutoff: the
+// leftovers of failed responses, or of streams the process never finished.
+func RemoveStalePartials(dir string, cutoff time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		name, partial := strings.CutSuffix(entry.Name(), partialSuffix)
+		if entry.IsDir() || !partial || !ValidName(name) {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if errRemove := os.Remove(filepath.Join(dir, entry.Name())); errRemove != nil && !os.IsNotExist(errRemove) {
+			return removed, errRemove
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,100 @@
+package artifact
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriterCommitAndAbort(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := Create(dir, "job-1.txt")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err = w.Write([]byte("hello ")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, info, _ := Path(dir, "job-1.txt"); info.Complete {
+		t.Fatal("artifact must stay partial until committed")
+	}
+	_ = w.Write([]byte("world"))
+	if err = w.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	path, info, err := Path(dir, "job-1.txt")
+	if err != nil || !info.Complete {
+		t.Fatalf("Path = %q %+v %v", path, info, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "hello world" {
+		t.Fatalf("content = %q", data)
+	}
+
+	aborted, err := Create(dir, "job-2")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	_ = aborted.Write([]byte("half"))
+	_ = aborted.Abort()
+
+	list, err := List(dir)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List = %+v %v", list, err)
+	}
+	for _, item := range list {
+		if item.Complete != (item.Name == "job-1.txt") {
+			t.Fatalf("unexpected completion state: %+v", item)
+		}
+	}
+
+	if err = Remove(dir, "job-2"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, _, err = Path(dir, "job-2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Path after remove: %v", err)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"../etc", "", ".hidden", "a/b", "x.partial"} {
+		if ValidName(name) {
+			t.Errorf("ValidName(%q) = true", name)
+		}
+	}
+	if _, err := Create(t.TempDir(), "../escape"); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("Create with traversal: %v", err)
+	}
+}
+
+func TestRemoveStalePartials(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"stale", "fresh", "done"} {
+		w, err := Create(dir, name)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if name == "done" {
+			_ = w.Commit()
+		} else {
+			_ = w.Abort()
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	for _, file := range []string{"stale.partial", "done"} {
+		if err := os.Chtimes(filepath.Join(dir, file), old, old); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+
+	removed, err := RemoveStalePartials(dir, time.Now().Add(-24*time.Hour))
+	if err != nil || removed != 1 {
+		t.Fatalf("RemoveStalePartials = %d, %v", removed, err)
+	}
+	list, _ := List(dir)
+	if len(list) != 2 {
+		t.Fatalf("committed and fresh artifacts must stay: %+v", list)
+	}
+}
@@ -224,7 +224,7 @@ func (qa *QwenAuth) InitiateDeviceFlow(ctx context.Context) (*DeviceFlow, error)
 }
 
 // PollForToken polls the token endpoint with the device code to obtain an access token.
-func (qa *QwenAuth) PollForToken(deviceCode, codeVerifier string) (*QwenTokenData, error) {
+func (qa *QwenAuth) PollForToken(ctx context.Context, deviceCode, codeVerifier string) (*QwenTokenData, error) {
 	pollInterval := 5 * time.Second
 	maxAttempts := 60 // 5 minutes max
 
@@ -235,10 +235,22 @@ func (qa *QwenAuth) PollForToken(deviceCode, codeVerifier string) (*QwenTokenDat
 		data.Set("device_code", deviceCode)
 		data.Set("code_verifier", codeVerifier)
 
-		resp, err := http.PostForm(QwenOAuthTokenEndpoint, data)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, QwenOAuthTokenEndpoint, strings.NewReader(data.Encode()))
 		if err != nil {
+			return nil, fmt.Errorf("failed to create token request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := qa.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			fmt.Printf("Polling attempt %d/%d failed: %v\n", attempt+1, maxAttempts, err)
-			time.Sleep(pollInterval)
+			if errWait := waitPollInterval(ctx, pollInterval); errWait != nil {
+				return nil, errWait
+			}
 			continue
 		}
 
@@ -246,7 +258,9 @@ func (qa *QwenAuth) PollForToken(deviceCode, codeVerifier string) (*QwenTokenDat
 		_ = resp.Body.Close()
 		if err != nil {
 			fmt.Printf("Polling attempt %d/%d failed: %v\n", attempt+1, maxAttempts, err)
-			time.Sleep(pollInterval)
+			if errWait := waitPollInterval(ctx, pollInterval); errWait != nil {
+				return nil, errWait
+			}
 			continue
 		}
 
@@ -261,7 +275,9 @@ func (qa *QwenAuth) PollForToken(deviceCode, codeVerifier string) (*QwenTokenDat
 					case "authorization_pending":
 						// User has not yet approved the authorization request. Continue polling.
 						fmt.Printf("Polling attempt %d/%d...\n\n", attempt+1, maxAttempts)
-						time.Sleep(pollInterval)
+						if errWait := waitPollInterval(ctx, pollInterval); errWait != nil {
+							return nil, errWait
+						}
 						continue
 					case "slow_down":
 						// Client is polling too frequently. Increase poll interval.
@@ -270,7 +286,9 @@ func (qa *QwenAuth) PollForToken(deviceCode, codeVerifier string) (*QwenTokenDat
 							pollInterval = 10 * time.Second
 						}
 						fmt.Printf("Server requested to slow down, increasing poll interval to %v\n\n", pollInterval)
-						time.Sleep(pollInterval)
+						if errWait := waitPollInterval(ctx, pollInterval); errWait != nil {
+							return nil, errWait
+						}
 						continue
 					case "expired_token":
 						return nil, fmt.Errorf("device code expired. Please restart the authentication process")
@@ -310,6 +328,19 @@ func (qa *QwenAuth) PollForToken(deviceCode, codeVerifier string) (*QwenTokenDat
 	return nil, fmt.Errorf("authentication timeout. Please restart the authentication process")
 }
 
+// waitPollInterval sleeps for interval between device-flow polls, returning early with
+// the context error when ctx is done.
+func waitPollInterval(ctx context.Context, interval time.Duration) error {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // RefreshTokensWithRetry attempts to refresh tokens with a specified number of retries upon failure.
 func (o *QwenAuth) RefreshTokensWithRetry(ctx context.Context, refreshToken string, maxRetries int) (*QwenTokenData, error) {
 	var lastErr error
@@ -0,0 +1,24 @@
+package qwen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPollForToken_StopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	qa := &QwenAuth{httpClient: &http.Client{}}
+
+	start := time.Now()
+	_, err := qa.PollForToken(ctx, "device", "verifier")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("polling must stop at once, took %v", elapsed)
+	}
+}
@@ -0,0 +1,87 @@
+package relay
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// AddToConfig adds the relay found by Detect to cfg under apiKey: an openai-compatibility
+// provider called name (derived from the host when empty), or a claude-api-key or
+// gemini-api-key entry. It returns the config section written to and whether anything
+// changed; a relay already configured with the same key is left as is.
+func AddToConfig(cfg *config.Config, res *Result, apiKey, name string) (string, bool, error) {
+	if cfg == nil || res == nil {
+		return "", false, fmt.Errorf("nothing to add")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	switch res.Dialect {
+	case DialectClaude:
+		for _, k := range cfg.ClaudeKey {
+			if k.APIKey == apiKey && sameBaseURL(k.BaseURL, res.BaseURL) {
+				return "claude-api-key", false, nil
+			}
+		}
+		cfg.ClaudeKey = append(cfg.ClaudeKey, config.ClaudeKey{APIKey: apiKey, BaseURL: res.BaseURL})
+		return "claude-api-key", true, nil
+	case DialectGemini:
+		for _, k := range cfg.GeminiKey {
+			if k.APIKey == apiKey && sameBaseURL(k.BaseURL, res.BaseURL) {
+				return "gemini-api-key", false, nil
+			}
+		}
+		cfg.GeminiKey = append(cfg.GeminiKey, config.GeminiKey{APIKey: apiKey, BaseURL: res.BaseURL})
+		return "gemini-api-key", true, nil
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = nameFromBaseURL(res.BaseURL)
+	}
+	section := "openai-compatibility " + name
+	for i := range cfg.OpenAICompatibility {
+		compat := &cfg.OpenAICompatibility[i]
+		if !strings.EqualFold(compat.Name, name) {
+			continue
+		}
+		if !sameBaseURL(compat.BaseURL, res.BaseURL) {
+			return section, false, fmt.Errorf("provider %s already uses base URL %s; pass another name", name, compat.BaseURL)
+		}
+		for _, entry := range compat.APIKeyEntries {
+			if entry.APIKey == apiKey {
+				return section, false, nil
+			}
+		}
+		compat.APIKeyEntries = append(compat.APIKeyEntries, config.OpenAICompatibilityAPIKey{APIKey: apiKey})
+		return section, true, nil
+	}
+	compat := config.OpenAICompatibility{
+		Name:          name,
+		BaseURL:       res.BaseURL,
+		APIKeyEntries: []config.OpenAICompatibilityAPIKey{{APIKey: apiKey}},
+	}
+	if len(res.Models) > 0 {
+		// The relay lists its models, so they are discovered at startup and stay current.
+		compat.ModelsURL = "models"
+	} else {
+		compat.Models = []config.OpenAICompatibilityModel{{Name: res.Model, Alias: res.Model}}
+	}
+	cfg.OpenAICompatibility = append(cfg.OpenAICompatibility, compat)
+	return section, true, nil
+}
+
+// nameFromBaseURL derives a provider name from the host of baseURL, e.g. "relay-example-com".
+func nameFromBaseURL(baseURL string) string {
+	host := baseURL
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+	return strings.ReplaceAll(strings.ToLower(host), ".", "-")
+}
+
+// sameBaseURL reports whether two base URLs differ at most in case and a trailing slash.
+func sameBaseURL(a, b string) bool {
+	return strings.EqualFold(strings.TrimRight(strings.TrimSpace(a), "/"), strings.TrimRight(strings.TrimSpace(b), "/"))
+}
@@ -0,0 +1,281 @@
+// Package relay validates a third-party relay (a base URL and an API key) before it is
+// written to the configuration. It lists the relay's models, sends a one-token completion,
+// and works out which upstream dialect (OpenAI, Claude or Gemini) the relay speaks.
+package relay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Dialect is the API a relay speaks.
+type Dialect string
+
+const (
+	// DialectOpenAI is the OpenAI chat completions API, configured as openai-compatibility.
+	DialectOpenAI Dialect = "openai"
+	// DialectClaude is the Anthropic messages API, configured as claude-api-key.
+	DialectClaude Dialect = "claude"
+	// DialectGemini is the Gemini generateContent API, configured as gemini-api-key.
+	DialectGemini Dialect = "gemini"
+)
+
+// dialects lists the dialects in the order they are tried. OpenAI comes first because most
+// relays speak it, and a Claude relay's model listing looks like an OpenAI one.
+var dialects = []Dialect{DialectOpenAI, DialectClaude, DialectGemini}
+
+// ParseDialect parses a dialect name. An empty name means the dialect is detected.
+func ParseDialect(name string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(strings.TrimSpace(name))); d {
+	case "":
+		return "", nil
+	case DialectOpenAI, DialectClaude, DialectGemini:
+		return d, nil
+	case "anthropic":
+		return DialectClaude, nil
+	}
+	return "", fmt.Errorf("unknown dialect %q (want openai, claude or gemini)", name)
+}
+
+// Probe describes the relay to check.
+type Probe struct {
+	// BaseURL is the relay URL as the user supplied it, with or without a version path.
+	BaseURL string
+	// APIKey is the key sent to the relay.
+	APIKey string
+	// Model is the model used for the completion probe. Empty picks a listed model.
+	Model string
+	// Dialect restricts detection to one dialect. Empty tries all of them.
+	Dialect Dialect
+	// Client sends the probe requests; nil uses http.DefaultClient.
+	Client *http.Client
+}
+
+// Result is a relay that answered a completion.
+type Result struct {
+	// Dialect is the API the relay speaks.
+	Dialect Dialect
+	// BaseURL is the base URL in the form the configuration expects for Dialect.
+	BaseURL string
+	// Models are the models the relay listed; empty when it has no listing endpoint.
+	Models []string
+	// Model is the model the completion probe used.
+	Model string
+}
+
+// Detect checks the relay and returns the first dialect it completes a request in. The
+// error lists why every dialect tried was rejected.
+func Detect(ctx context.Context, p Probe) (*Result, error) {
+	base := strings.TrimRight(strings.TrimSpace(p.BaseURL), "/")
+	if base == "" {
+		return nil, fmt.Errorf("missing base URL")
+	}
+	parsed, err := url.Parse(base)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", p.BaseURL)
+	}
+	if strings.TrimSpace(p.APIKey) == "" {
+		return nil, fmt.Errorf("missing API key")
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	tried := dialects
+	if p.Dialect != "" {
+		tried = []Dialect{p.Dialect}
+	}
+	var failures []string
+	for _, d := range tried {
+		for _, candidate := range candidateBaseURLs(d, base) {
+			res, errProbe := probe(ctx, client, d, candidate, p)
+			if errProbe == nil {
+				return res, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			failures = append(failures, fmt.Sprintf("%s at %s: %v", d, candidate, errProbe))
+		}
+	}
+	return nil, fmt.Errorf("relay did not answer a completion:\n  %s", strings.Join(failures, "\n  "))
+}
+
+// candidateBaseURLs returns the base URLs tried for d. OpenAI-compatible base URLs end in
+// the version path; Claude and Gemini ones stop before it, as the executors append it.
+func candidateBaseURLs(d Dialect, base string) []string {
+	switch d {
+	case DialectClaude:
+		return []string{strings.TrimSuffix(base, "/v1")}
+	case DialectGemini:
+		return []string{strings.TrimSuffix(strings.TrimSuffix(base, "/v1beta"), "/v1")}
+	}
+	if strings.HasSuffix(base, "/v1") {
+		return []string{base}
+	}
+	return []string{base + "/v1", base}
+}
+
+// probe lists the models of the relay at base in dialect d and sends a completion to one
+// of them.
+func probe(ctx context.Context, client *http.Client, d Dialect, base string, p Probe) (*Result, error) {
+	models, errList := listModels(ctx, client, d, base, p.APIKey)
+	model := strings.TrimSpace(p.Model)
+	if model == "" {
+		model = pickModel(models)
+	}
+	if model == "" {
+		if errList != nil {
+			return nil, fmt.Errorf("list models: %w (pass a model to probe without a listing)", errList)
+		}
+		return nil, fmt.Errorf("no models listed")
+	}
+	if errComplete := complete(ctx, client, d, base, p.APIKey, model); errComplete != nil {
+		return nil, fmt.Errorf("completion with %s: %w", model, errComplete)
+	}
+	return &Result{Dialect: d, BaseURL: base, Models: models, Model: model}, nil
+}
+
+// listModels returns the model IDs the relay lists in dialect d.
+func listModels(ctx context.Context, client *http.Client, d Dialect, base, apiKey string) ([]string, error) {
+	endpoint := base + "/models"
+	switch d {
+	case DialectClaude:
+		endpoint = base + "/v1/models"
+	case DialectGemini:
+		endpoint = base + "/v1beta/models"
+	}
+	data, err := send(ctx, client, d, http.MethodGet, endpoint, apiKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	listing := gjson.ParseBytes(data)
+	var ids []string
+	if d == DialectGemini {
+		for _, item := range listing.Get("models").Array() {
+			if methods := item.Get("supportedGenerationMethods"); methods.Exists() && !strings.Contains(methods.Raw, `"generateContent"`) {
+				continue
+			}
+			if id := strings.TrimPrefix(item.Get("name").String(), "models/"); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	} else {
+		for _, item := range listing.Get("data").Array() {
+			if id := item.Get("id").String(); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("listing has no %s models", d)
+	}
+	return ids, nil
+}
+
+// nonChatMarkers are substrings of model IDs that do not serve chat completions.
+var nonChatMarkers = []string{"embed", "whisper", "tts", "dall-e", "moderation", "rerank", "image"}
+
+// pickModel returns the first of models that looks like a chat model.
+func pickModel(models []string) string {
+	for _, m := range models {
+		lower := strings.ToLower(m)
+		chat := true
+		for _, marker := range nonChatMarkers {
+			if strings.Contains(lower, marker) {
+				chat = false
+				break
+			}
+		}
+		if chat {
+			return m
+		}
+	}
+	return ""
+}
+
+// complete sends a one-token completion to model in dialect d and checks the reply has the
+// dialect's shape.
+func complete(ctx context.Context, client *http.Client, d Dialect, base, apiKey, model string) error {
+	var endpoint, body, want string
+	switch d {
+	case DialectClaude:
+		endpoint = base + "/v1/messages"
+		body = fmt.Sprintf(`{"model":%q,"max_tokens":1,"messages":[{"role":"user","content":"ping"}]}`, model)
+		want = "content"
+	case DialectGemini:
+		endpoint = fmt.Sprintf("%s/v1beta/models/%s:generateContent", base, url.PathEscape(model))
+		body = `{"contents":[{"role":"user","parts":[{"text":"ping"}]}],"generationConfig":{"maxOutputTokens":1}}`
+		want = "candidates"
+	default:
+		endpoint = base + "/chat/completions"
+		body = fmt.Sprintf(`{"model":%q,"max_tokens":1,"messages":[{"role":"user","content":"ping"}]}`, model)
+		want = "choices"
+	}
+	data, err := send(ctx, client, d, http.MethodPost, endpoint, apiKey, []byte(body))
+	if err != nil {
+		return err
+	}
+	if !gjson.GetBytes(data, want).Exists() {
+		return fmt.Errorf("reply is not a %s response", d)
+	}
+	return nil
+}
+
+// send makes one request authenticated the way the executor for d authenticates to a relay,
+// and returns the body of a 2xx JSON reply.
+func send(ctx context.Context, client *http.Client, d Dialect, method, endpoint, apiKey string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch d {
+	case DialectGemini:
+		req.Header.Set("x-goog-api-key", apiKey)
+	case DialectClaude:
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	default:
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, snippet(data))
+	}
+	if !gjson.ValidBytes(data) {
+		return nil, fmt.Errorf("reply is not JSON: %s", snippet(data))
+	}
+	return data, nil
+}
+
+// snippet shortens an upstream body for an error message.
+func snippet(data []byte) string {
+	s := strings.TrimSpace(string(data))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
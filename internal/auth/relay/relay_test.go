@@ -0,0 +1,88 @@
+package relay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func newRelay(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-relay" && r.Header.Get("x-goog-api-key") != "sk-relay" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDetect(t *testing.T) {
+	claude := newRelay(t, map[string]string{
+		"GET /v1/models":    `{"data":[{"id":"claude-sonnet-4","type":"model"}]}`,
+		"POST /v1/messages": `{"type":"message","content":[{"type":"text","text":"p"}]}`,
+	})
+	res, err := Detect(context.Background(), Probe{BaseURL: claude.URL + "/v1/", APIKey: "sk-relay"})
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if res.Dialect != DialectClaude || res.BaseURL != claude.URL || res.Model != "claude-sonnet-4" {
+		t.Fatalf("got %+v, want the Claude dialect at %s", res, claude.URL)
+	}
+
+	openai := newRelay(t, map[string]string{
+		"GET /v1/models":            `{"object":"list","data":[{"id":"text-embedding-3"},{"id":"gpt-4o"}]}`,
+		"POST /v1/chat/completions": `{"choices":[{"message":{"content":"p"}}]}`,
+	})
+	res, err = Detect(context.Background(), Probe{BaseURL: openai.URL, APIKey: "sk-relay"})
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if res.Dialect != DialectOpenAI || res.BaseURL != openai.URL+"/v1" || res.Model != "gpt-4o" || len(res.Models) != 2 {
+		t.Fatalf("got %+v, want the OpenAI dialect at %s/v1 probing gpt-4o", res, openai.URL)
+	}
+
+	if _, err = Detect(context.Background(), Probe{BaseURL: openai.URL, APIKey: "wrong"}); err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("err = %v, want the rejected key reported", err)
+	}
+}
+
+func TestAddToConfig(t *testing.T) {
+	cfg := &config.Config{}
+	res := &Result{Dialect: DialectOpenAI, BaseURL: "https://relay.example.com/v1", Models: []string{"gpt-4o"}, Model: "gpt-4o"}
+	section, changed, err := AddToConfig(cfg, res, "sk-1", "")
+	if err != nil || !changed || section != "openai-compatibility relay-example-com" {
+		t.Fatalf("AddToConfig = %q, %v, %v", section, changed, err)
+	}
+	if compat := cfg.OpenAICompatibility[0]; compat.ModelsURL != "models" || len(compat.Models) != 0 || compat.APIKeyEntries[0].APIKey != "sk-1" {
+		t.Fatalf("compat entry = %+v, want models discovered from the listing", compat)
+	}
+	if _, changed, _ = AddToConfig(cfg, res, "sk-1", ""); changed {
+		t.Fatal("adding the same key twice changed the config")
+	}
+	if _, changed, _ = AddToConfig(cfg, res, "sk-2", ""); !changed || len(cfg.OpenAICompatibility[0].APIKeyEntries) != 2 {
+		t.Fatal("a second key was not added to the existing provider")
+	}
+	other := &Result{Dialect: DialectOpenAI, BaseURL: "https://other.example.com/v1", Model: "m"}
+	if _, _, err = AddToConfig(cfg, other, "sk-3", "relay-example-com"); err == nil {
+		t.Fatal("a provider name reused for another base URL was accepted")
+	}
+
+	if _, _, err = AddToConfig(cfg, &Result{Dialect: DialectClaude, BaseURL: "https://claude.example.com", Model: "m"}, "sk-c", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.ClaudeKey) != 1 || cfg.ClaudeKey[0].BaseURL != "https://claude.example.com" {
+		t.Fatalf("claude keys = %+v", cfg.ClaudeKey)
+	}
+}
@@ -17,7 +17,11 @@ import (
 // helper fields for project, location and email to improve logging and discovery.
 type VertexCredentialStorage struct {
 	// ServiceAccount holds the parsed service account JSON content.
-	ServiceAccount map[string]any `json:"service_account"`
+	ServiceAccount map[string]any `json:"service_account,omitempty"`
+
+	// CredentialSource is "adc" for credentials that authenticate with Application Default
+	// Credentials (workload identity, metadata server) instead of ServiceAccount.
+	CredentialSource string `json:"credential_source,omitempty"`
 
 	// ProjectID is derived from the service account JSON (project_id).
 	ProjectID string `json:"project_id"`
@@ -39,7 +43,7 @@ func (s *VertexCredentialStorage) SaveTokenToFile(authFilePath string) error {
 	if s == nil {
 		return fmt.Errorf("vertex credential: storage is nil")
 	}
-	if s.ServiceAccount == nil {
+	if s.ServiceAccount == nil && s.CredentialSource == "" {
 		return fmt.Errorf("vertex credential: service account content is empty")
 	}
 	// Ensure we tag the file with the provider type.
@@ -5,6 +5,9 @@ import (
 	"encoding/hex"
 	"sync"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
 )
 
 // SignatureEntry holds a cached thinking signature with timestamp
@@ -23,16 +26,13 @@ const (
 	// MinValidSignatureLen is the minimum length for a signature to be considered valid
 	MinValidSignatureLen = 50
 
-	// SessionCleanupInterval controls how often stale sessions are purged
+	// SessionCleanupInterval is the default janitor schedule for purging stale sessions
 	SessionCleanupInterval = 10 * time.Minute
 )
 
 // signatureCache stores signatures by sessionId -> textHash -> SignatureEntry
 var signatureCache sync.Map
 
-// sessionCleanupOnce ensures the background cleanup goroutine starts only once
-var sessionCleanupOnce sync.Once
-
 // sessionCache is the inner map type
 type sessionCache struct {
 	mu      sync.RWMutex
@@ -47,9 +47,6 @@ func hashText(text string) string {
 
 // getOrCreateSession gets or creates a session cache
 func getOrCreateSession(sessionID string) *sessionCache {
-	// Start background cleanup on first access
-	sessionCleanupOnce.Do(startSessionCleanup)
-
 	if val, ok := signatureCache.Load(sessionID); ok {
 		return val.(*sessionCache)
 	}
@@ -58,21 +55,16 @@ func getOrCreateSession(sessionID string) *sessionCache {
 	return actual.(*sessionCache)
 }
 
-// startSessionCleanup launches a background goroutine that periodically
-// removes sessions where all entries have expired.
-func startSessionCleanup() {
-	go func() {
-		ticker := time.NewTicker(SessionCleanupInterval)
-		defer ticker.Stop()
-		for range ticker.C {
-			purgeExpiredSessions()
-		}
-	}()
+func init() {
+	janitor.Register("signature-sessions", SessionCleanupInterval, func(_ *config.Config, now time.Time) (int, error) {
+		return purgeExpiredSessions(now), nil
+	})
 }
 
-// purgeExpiredSessions removes sessions with no valid (non-expired) entries.
-func purgeExpiredSessions() {
-	now := time.Now()
+// purgeExpiredSessions removes expired entries and the sessions left without any, and
+// returns how many entries it removed.
+func purgeExpiredSessions(now time.Time) int {
+	removed := 0
 	signatureCache.Range(func(key, value any) bool {
 		sc := value.(*sessionCache)
 		sc.mu.Lock()
@@ -80,6 +72,7 @@ func purgeExpiredSessions() {
 		for k, entry := range sc.entries {
 			if now.Sub(entry.Timestamp) > SignatureCacheTTL {
 				delete(sc.entries, k)
+				removed++
 			}
 		}
 		isEmpty := len(sc.entries) == 0
@@ -90,6 +83,7 @@ func purgeExpiredSessions() {
 		}
 		return true
 	})
+	return removed
 }
 
 // CacheSignature stores a thinking signature for a given session and text.
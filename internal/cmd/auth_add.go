@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+)
+
+// DoAuthAdd writes a token file from tokens obtained elsewhere, without running an
+// interactive login. The tokens are validated before anything is saved.
+//
+// Parameters:
+//   - cfg: The application configuration
+//   - in: The provider and tokens to import
+func DoAuthAdd(cfg *config.Config, in sdkAuth.TokenImport) {
+	manager := newAuthManager()
+	record, savedPath, err := manager.Import(context.Background(), in, cfg)
+	if err != nil {
+		fmt.Printf("Token import failed: %v\n", err)
+		return
+	}
+	if savedPath != "" {
+		fmt.Printf("Authentication saved to %s\n", savedPath)
+	}
+	fmt.Printf("%s credentials imported for %s\n", record.Provider, record.Label)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+)
+
+// DoAuthLogin runs the login flow of any authenticator known to the auth manager,
+// including those added by embedders through sdkAuth.Register.
+//
+// Parameters:
+//   - cfg: The application configuration
+//   - provider: The provider identifier of the authenticator
+//   - options: Login options including browser behavior and prompts
+func DoAuthLogin(cfg *config.Config, provider string, options *LoginOptions) {
+	if options == nil {
+		options = &LoginOptions{}
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+
+	promptFn := options.Prompt
+	if promptFn == nil {
+		promptFn = defaultProjectPrompt()
+	}
+
+	manager := newAuthManager()
+
+	authOpts := &sdkAuth.LoginOptions{
+		NoBrowser:    options.NoBrowser,
+		CallbackPort: options.CallbackPort,
+		Metadata:     map[string]string{},
+		Prompt:       promptFn,
+	}
+
+	_, savedPath, err := manager.Login(context.Background(), provider, cfg, authOpts)
+	if err != nil {
+		fmt.Printf("%s authentication failed: %v\n", provider, err)
+		if registered := sdkAuth.RegisteredProviders(); len(registered) > 0 {
+			fmt.Printf("Registered custom providers: %s\n", strings.Join(registered, ", "))
+		}
+		return
+	}
+
+	if savedPath != "" {
+		fmt.Printf("Authentication saved to %s\n", savedPath)
+	}
+	fmt.Printf("%s authentication successful!\n", provider)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoConfigMigrate writes a copy of the configuration at src converted to the current
+// schema. src defaults to config.yaml in the working directory and dst to
+// "<src>.migrated.yaml". The deprecated keys found in src are printed so operators can
+// review the upgrade.
+func DoConfigMigrate(src, dst string) {
+	src = strings.TrimSpace(src)
+	dst = strings.TrimSpace(dst)
+	if src == "" {
+		src = "config.yaml"
+	}
+	if dst == "" {
+		dst = strings.TrimSuffix(strings.TrimSuffix(src, ".yaml"), ".yml") + ".migrated.yaml"
+	}
+	found, err := config.MigrateConfigFile(src, dst)
+	if err != nil {
+		log.Errorf("config migration failed: %v", err)
+		return
+	}
+	if len(found) == 0 {
+		fmt.Printf("No deprecated keys found in %s; copied to %s\n", src, dst)
+		return
+	}
+	fmt.Printf("Deprecated keys found in %s:\n", src)
+	for _, key := range found {
+		fmt.Printf("  %s\n", key)
+	}
+	fmt.Printf("Migrated configuration written to %s\n", dst)
+}
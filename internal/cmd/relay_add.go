@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/relay"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// RelayOptions describes the relay added by DoAuthAddRelay.
+type RelayOptions struct {
+	// BaseURL is the relay URL.
+	BaseURL string
+	// APIKey is the relay key; when empty the user is prompted for it.
+	APIKey string
+	// Name names the openai-compatibility provider; empty derives it from the host.
+	Name string
+	// Model is the model probed; empty picks one the relay lists.
+	Model string
+	// Dialect forces openai, claude or gemini instead of detecting it.
+	Dialect string
+}
+
+// DoAuthAddRelay checks a third-party relay by listing its models and sending a one-token
+// completion, detects whether it speaks the OpenAI, Claude or Gemini API, and adds it to
+// the configuration file in the matching section. Nothing is written when the check fails.
+//
+// Parameters:
+//   - cfg: The application configuration
+//   - configFilePath: The configuration file the relay is saved to
+//   - in: The relay to add
+//   - options: Login options; Prompt is used to ask for a missing key
+func DoAuthAddRelay(cfg *config.Config, configFilePath string, in RelayOptions, options *LoginOptions) {
+	if options == nil {
+		options = &LoginOptions{}
+	}
+	dialect, err := relay.ParseDialect(in.Dialect)
+	if err != nil {
+		fmt.Printf("Relay setup failed: %v\n", err)
+		return
+	}
+	apiKey := strings.TrimSpace(in.APIKey)
+	if apiKey == "" {
+		promptFn := options.Prompt
+		if promptFn == nil {
+			reader := bufio.NewReader(os.Stdin)
+			promptFn = func(prompt string) (string, error) {
+				fmt.Print(prompt)
+				value, errRead := reader.ReadString('\n')
+				if errRead != nil {
+					return "", errRead
+				}
+				return strings.TrimSpace(value), nil
+			}
+		}
+		if apiKey, err = promptFn("Relay API key: "); err != nil {
+			fmt.Printf("Failed to read the API key: %v\n", err)
+			return
+		}
+	}
+
+	fmt.Printf("Checking relay %s...\n", in.BaseURL)
+	client := util.SetProxy(&cfg.SDKConfig, &http.Client{Timeout: 60 * time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	res, err := relay.Detect(ctx, relay.Probe{
+		BaseURL: in.BaseURL,
+		APIKey:  apiKey,
+		Model:   in.Model,
+		Dialect: dialect,
+		Client:  client,
+	})
+	if err != nil {
+		fmt.Printf("Relay check failed: %v\n", err)
+		return
+	}
+	fmt.Printf("Relay speaks the %s API at %s; %d models listed, completion with %s succeeded\n", res.Dialect, res.BaseURL, len(res.Models), res.Model)
+
+	section, changed, err := relay.AddToConfig(cfg, res, apiKey, in.Name)
+	if err != nil {
+		fmt.Printf("Relay setup failed: %v\n", err)
+		return
+	}
+	if !changed {
+		fmt.Printf("Relay is already configured in %s\n", section)
+		return
+	}
+	if err = config.SaveConfigPreserveComments(configFilePath, cfg); err != nil {
+		fmt.Printf("Failed to save %s: %v\n", configFilePath, err)
+		return
+	}
+	fmt.Printf("Relay added to %s in %s\n", section, configFilePath)
+}
@@ -0,0 +1,216 @@
+// Package cmd contains CLI helpers. This file implements the route explain command,
+// which asks a running server how it would route a request for a model.
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// RouteExplainRequest describes the request DoRouteExplain asks about.
+type RouteExplainRequest struct {
+	Model string
+	// Headers are request headers as "Name: value", such as the client API key or
+	// X-Session-ID.
+	Headers []string
+	// Tokens is the estimated prompt size.
+	Tokens int
+}
+
+type routeExplainResponse struct {
+	RequestedModel string                      `json:"requested_model"`
+	Tenant         string                      `json:"tenant"`
+	Routes         []coreauth.RouteExplanation `json:"routes"`
+}
+
+// DoRouteExplain queries /v0/route/explain on the locally configured server and prints
+// the routing decision tree. The management key falls back to MANAGEMENT_PASSWORD.
+func DoRouteExplain(cfg *config.Config, request RouteExplainRequest, managementKey string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	model := strings.TrimSpace(request.Model)
+	if model == "" {
+		log.Errorf("route-explain: missing model")
+		return
+	}
+	if strings.TrimSpace(managementKey) == "" {
+		managementKey = strings.TrimSpace(os.Getenv("MANAGEMENT_PASSWORD"))
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: 15 * time.Second}
+	if cfg.TLS.Enable {
+		scheme = "https"
+		tlsConfig, errTLS := pinnedServerTLSConfig(cfg.TLS.Cert)
+		if errTLS != nil {
+			log.Errorf("route-explain: %v", errTLS)
+			return
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+	host := strings.TrimSpace(cfg.Host)
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	query := url.Values{"model": {model}}
+	for _, header := range request.Headers {
+		query.Add("header", header)
+	}
+	if request.Tokens > 0 {
+		query.Set("tokens", strconv.Itoa(request.Tokens))
+	}
+	endpoint := fmt.Sprintf("%s://%s/v0/route/explain?%s", scheme, net.JoinHostPort(host, strconv.Itoa(cfg.Port)), query.Encode())
+
+	req, errReq := http.NewRequest(http.MethodGet, endpoint, nil)
+	if errReq != nil {
+		log.Errorf("route-explain: build request failed: %v", errReq)
+		return
+	}
+	if managementKey != "" {
+		req.Header.Set("Authorization", "Bearer "+managementKey)
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		log.Errorf("route-explain: request failed (is the server running?): %v", errDo)
+		return
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("route-explain: close response body: %v", errClose)
+		}
+	}()
+	body, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		log.Errorf("route-explain: read response failed: %v", errRead)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Errorf("route-explain: server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return
+	}
+	var result routeExplainResponse
+	if errUnmarshal := json.Unmarshal(body, &result); errUnmarshal != nil {
+		log.Errorf("route-explain: decode response failed: %v", errUnmarshal)
+		return
+	}
+	printRouteExplanation(os.Stdout, result)
+}
+
+// pinnedServerTLSConfig trusts exactly the certificate the server is configured with.
+// The certificate is usually issued for a public name rather than loopback, so the
+// presented leaf is compared byte-for-byte with the configured one instead of being
+// verified against the dialed host name.
+func pinnedServerTLSConfig(certFile string) (*tls.Config, error) {
+	certPEM, errRead := os.ReadFile(certFile)
+	if errRead != nil {
+		return nil, fmt.Errorf("read tls certificate: %w", errRead)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("tls certificate %s is not PEM encoded", certFile)
+	}
+	expected := block.Bytes
+	return &tls.Config{
+		// Standard verification is replaced by the pin check below.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], expected) {
+				return errors.New("server certificate does not match the configured tls.cert")
+			}
+			return nil
+		},
+	}, nil
+}
+
+func printRouteExplanation(w io.Writer, result routeExplainResponse) {
+	_, _ = fmt.Fprintf(w, "model %s", result.RequestedModel)
+	if result.Tenant != "" {
+		_, _ = fmt.Fprintf(w, " (tenant %s)", result.Tenant)
+	}
+	_, _ = fmt.Fprintln(w)
+	for i, route := range result.Routes {
+		if len(result.Routes) > 1 {
+			// Later routes are the fallback chain steps tried when the earlier ones fail.
+			_, _ = fmt.Fprintf(w, "route %d of %d: %s\n", i+1, len(result.Routes), route.Model)
+		} else if route.Model != result.RequestedModel {
+			_, _ = fmt.Fprintf(w, "routed as %s\n", route.Model)
+		}
+		printRoute(w, route)
+	}
+}
+
+func printRoute(w io.Writer, route coreauth.RouteExplanation) {
+	if len(route.Providers) == 0 {
+		_, _ = fmt.Fprintf(w, "└─ %s\n", route.Error)
+		return
+	}
+	if len(route.PreferredProviders) > 0 {
+		_, _ = fmt.Fprintf(w, "size routing prefers: %s\n", strings.Join(route.PreferredProviders, ", "))
+	}
+	for pi, provider := range route.Providers {
+		lastProvider := pi == len(route.Providers)-1
+		branch, indent := "├─", "│  "
+		if lastProvider {
+			branch, indent = "└─", "   "
+		}
+		_, _ = fmt.Fprintf(w, "%s provider %s\n", branch, provider)
+		var candidates []coreauth.RouteCandidate
+		for _, candidate := range route.Candidates {
+			if candidate.Provider == provider {
+				candidates = append(candidates, candidate)
+			}
+		}
+		if len(candidates) == 0 {
+			_, _ = fmt.Fprintf(w, "%s└─ no credentials\n", indent)
+			continue
+		}
+		for ci, candidate := range candidates {
+			leaf := "├─"
+			if ci == len(candidates)-1 {
+				leaf = "└─"
+			}
+			mark := "✗"
+			if candidate.Eligible {
+				mark = "✓"
+			}
+			name := candidate.AuthID
+			if candidate.Label != "" {
+				name += " (" + candidate.Label + ")"
+			}
+			line := fmt.Sprintf("%s%s %s %s priority=%d: %s", indent, leaf, mark, name, candidate.Priority, candidate.Reason)
+			if candidate.RetryAt != nil {
+				line += " until " + candidate.RetryAt.Format(time.RFC3339)
+			}
+			if candidate.UpstreamModel != "" && candidate.UpstreamModel != route.Model {
+				line += " [upstream model " + candidate.UpstreamModel + "]"
+			}
+			_, _ = fmt.Fprintln(w, line)
+		}
+	}
+	switch {
+	case route.Pinned != "":
+		_, _ = fmt.Fprintf(w, "=> conversation stays on: %s\n", route.Pinned)
+	case len(route.Selectable) > 0:
+		_, _ = fmt.Fprintf(w, "=> next request rotates between: %s\n", strings.Join(route.Selectable, ", "))
+	case route.Error != "":
+		_, _ = fmt.Fprintf(w, "=> %s\n", route.Error)
+	}
+}
@@ -24,11 +24,15 @@ import (
 //   - cfg: The application configuration
 //   - configPath: The path to the configuration file
 //   - localPassword: Optional password accepted for local management requests
-func StartService(cfg *config.Config, configPath string, localPassword string) {
+//   - readOnly: Pins read-only mode on for the lifetime of the process
+func StartService(cfg *config.Config, configPath string, localPassword string, readOnly bool) {
 	builder := cliproxy.NewBuilder().
 		WithConfig(cfg).
 		WithConfigPath(configPath).
 		WithLocalManagementPassword(localPassword)
+	if readOnly {
+		builder = builder.WithServerOptions(api.WithReadOnly())
+	}
 
 	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
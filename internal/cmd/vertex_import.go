@@ -20,7 +20,7 @@ import (
 // DoVertexImport imports a Google Cloud service account key JSON and persists
 // it as a "vertex" provider credential. The file content is embedded in the auth
 // file to allow portable deployment across stores.
-func DoVertexImport(cfg *config.Config, keyPath string) {
+func DoVertexImport(cfg *config.Config, keyPath, location string) {
 	if cfg == nil {
 		cfg = &config.Config{}
 	}
@@ -60,7 +60,7 @@ func DoVertexImport(cfg *config.Config, keyPath string) {
 		log.Warn("vertex-import: client_email missing in service account json")
 	}
 	// Default location if not provided by user. Can be edited in the saved file later.
-	location := "us-central1"
+	location = vertexLocationOrDefault(location)
 
 	fileName := fmt.Sprintf("vertex-%s.json", sanitizeFilePart(projectID))
 	// Build auth record
@@ -98,6 +98,61 @@ func DoVertexImport(cfg *config.Config, keyPath string) {
 	fmt.Printf("Vertex credentials imported: %s\n", path)
 }
 
+// DoVertexADCImport stores a "vertex" credential that authenticates with Application
+// Default Credentials, for deployments using GKE workload identity, the GCE metadata
+// server, or GOOGLE_APPLICATION_CREDENTIALS instead of an embedded key.
+func DoVertexADCImport(cfg *config.Config, projectID, location string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	projectID = strings.TrimSpace(projectID)
+	if projectID == "" {
+		log.Errorf("vertex-adc: missing project ID")
+		return
+	}
+	location = vertexLocationOrDefault(location)
+
+	fileName := fmt.Sprintf("vertex-%s-adc.json", sanitizeFilePart(projectID))
+	record := &coreauth.Auth{
+		ID:       fileName,
+		Provider: "vertex",
+		FileName: fileName,
+		Storage: &vertex.VertexCredentialStorage{
+			CredentialSource: "adc",
+			ProjectID:        projectID,
+			Location:         location,
+		},
+		Metadata: map[string]any{
+			"credential_source": "adc",
+			"project_id":        projectID,
+			"location":          location,
+			"type":              "vertex",
+			"label":             projectID + " (workload identity)",
+		},
+	}
+
+	store := sdkAuth.GetTokenStore()
+	if setter, ok := store.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+	path, errSave := store.Save(context.Background(), record)
+	if errSave != nil {
+		log.Errorf("vertex-adc: save credential failed: %v", errSave)
+		return
+	}
+	fmt.Printf("Vertex workload identity credential saved: %s\n", path)
+}
+
+func vertexLocationOrDefault(location string) string {
+	if loc := strings.TrimSpace(location); loc != "" {
+		return loc
+	}
+	return "us-central1"
+}
+
 func sanitizeFilePart(s string) string {
 	out := strings.TrimSpace(s)
 	replacers := []string{"/", "_", "\\", "_", ":", "_", " ", "-"}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeBaseURLs(t *testing.T) {
+	cases := []struct {
+		name        string
+		primary     string
+		extra       []string
+		wantPrimary string
+		wantExtra   []string
+	}{
+		{name: "promotes first entry", extra: []string{" https://eu.example.com ", "https://us.example.com"}, wantPrimary: "https://eu.example.com", wantExtra: []string{"https://us.example.com"}},
+		{name: "single entry", extra: []string{"https://eu.example.com"}, wantPrimary: "https://eu.example.com"},
+		{name: "drops duplicates of primary", primary: "https://a.example.com", extra: []string{"https://a.example.com/", "", "https://b.example.com"}, wantPrimary: "https://a.example.com", wantExtra: []string{"https://b.example.com"}},
+		{name: "no extras", primary: " https://a.example.com ", wantPrimary: "https://a.example.com"},
+	}
+	for _, tc := range cases {
+		primary, extra := normalizeBaseURLs(tc.primary, tc.extra)
+		if primary != tc.wantPrimary || !reflect.DeepEqual(extra, tc.wantExtra) {
+			t.Errorf("%s: got %q %q, want %q %q", tc.name, primary, extra, tc.wantPrimary, tc.wantExtra)
+		}
+	}
+}
+
+func TestSanitizeOpenAICompatibilityUsesFirstBaseURL(t *testing.T) {
+	cfg := &Config{OpenAICompatibility: []OpenAICompatibility{{Name: "p", BaseURLs: []string{"https://eu.example.com"}}}}
+	cfg.SanitizeOpenAICompatibility()
+	if len(cfg.OpenAICompatibility) != 1 || cfg.OpenAICompatibility[0].BaseURL != "https://eu.example.com" {
+		t.Fatalf("entry configured only through base-urls was dropped or lost its primary: %+v", cfg.OpenAICompatibility)
+	}
+}
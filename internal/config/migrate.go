@@ -0,0 +1,192 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeprecatedKey describes a legacy configuration key found during migration.
+type DeprecatedKey struct {
+	// Key is the dotted path of the deprecated key in the source file.
+	Key string
+	// Replacement is the dotted path of the key that supersedes it.
+	Replacement string
+}
+
+// String renders the key and its replacement for CLI reports.
+func (d DeprecatedKey) String() string {
+	return fmt.Sprintf("%s -> %s", d.Key, d.Replacement)
+}
+
+// legacyAuthKey is reported only in its legacy shape; see isLegacyAuthBlock.
+var legacyAuthKey = DeprecatedKey{Key: "auth", Replacement: "api-keys"}
+
+// legacyTopLevelKeys maps deprecated top-level keys to their current location.
+// The order matches the report order.
+var legacyTopLevelKeys = []DeprecatedKey{
+	{Key: "generative-language-api-key", Replacement: "gemini-api-key"},
+	{Key: "amp-upstream-url", Replacement: "ampcode.upstream-url"},
+	{Key: "amp-upstream-api-key", Replacement: "ampcode.upstream-api-key"},
+	{Key: "amp-restrict-management-to-localhost", Replacement: "ampcode.restrict-management-to-localhost"},
+	{Key: "amp-model-mappings", Replacement: "ampcode.model-mappings"},
+}
+
+// DetectDeprecatedKeys reports the legacy keys present in a YAML configuration document.
+func DetectDeprecatedKeys(data []byte) ([]DeprecatedKey, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var found []DeprecatedKey
+	if idx := findMapKeyIndex(root, legacyAuthKey.Key); idx >= 0 && idx+1 < len(root.Content) && isLegacyAuthBlock(root.Content[idx+1]) {
+		found = append(found, legacyAuthKey)
+	}
+	for _, legacy := range legacyTopLevelKeys {
+		if findMapKeyIndex(root, legacy.Key) >= 0 {
+			found = append(found, legacy)
+		}
+	}
+	if idx := findMapKeyIndex(root, "openai-compatibility"); idx >= 0 && idx+1 < len(root.Content) {
+		seq := root.Content[idx+1]
+		if seq != nil && seq.Kind == yaml.SequenceNode {
+			for i, entry := range seq.Content {
+				if entry != nil && entry.Kind == yaml.MappingNode && findMapKeyIndex(entry, "api-keys") >= 0 {
+					found = append(found, DeprecatedKey{
+						Key:         fmt.Sprintf("openai-compatibility[%d].api-keys", i),
+						Replacement: fmt.Sprintf("openai-compatibility[%d].api-key-entries", i),
+					})
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+// isLegacyAuthBlock reports whether an auth block only carries inline API keys, which
+// have moved to the top-level api-keys list. Blocks declaring other access providers
+// are live configuration and are kept.
+func isLegacyAuthBlock(node *yaml.Node) bool {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return true
+	}
+	idx := findMapKeyIndex(node, "providers")
+	if idx < 0 || idx+1 >= len(node.Content) {
+		return true
+	}
+	for _, provider := range node.Content[idx+1].Content {
+		if provider == nil || provider.Kind != yaml.MappingNode {
+			continue
+		}
+		typeIdx := findMapKeyIndex(provider, "type")
+		if typeIdx < 0 || typeIdx+1 >= len(provider.Content) || provider.Content[typeIdx+1].Value != AccessProviderTypeConfigAPIKey {
+			return false
+		}
+	}
+	return true
+}
+
+// MigrateConfigFile rewrites the configuration at src into the current schema and writes
+// the result to dst, leaving src untouched. It returns the deprecated keys that were found.
+// dst must not exist yet. The result is assembled in a temporary file next to dst and
+// renamed into place, so a failed migration leaves nothing behind.
+func MigrateConfigFile(src, dst string) ([]DeprecatedKey, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	found, err := DetectDeprecatedKeys(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, errStat := os.Stat(dst); errStat == nil {
+		return nil, fmt.Errorf("refusing to overwrite existing file %s", dst)
+	} else if !errors.Is(errStat, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to inspect %s: %w", dst, errStat)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrated config: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	_, err = tmp.Write(data)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+
+	// LoadConfig applies the legacy migrations; persist explicitly so keys that are only
+	// dropped (such as a legacy auth block) are removed even when nothing was moved.
+	cfg, err := LoadConfig(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) > 0 {
+		if err = SaveConfigPreserveComments(tmpPath, cfg); err != nil {
+			return nil, fmt.Errorf("failed to persist migrated config: %w", err)
+		}
+		// Saving drops every auth block; put back one that declares access providers.
+		if err = restoreAuthBlock(tmpPath, data); err != nil {
+			return nil, fmt.Errorf("failed to persist migrated config: %w", err)
+		}
+	}
+	if err = os.Rename(tmpPath, dst); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+	return found, nil
+}
+
+// restoreAuthBlock copies a non-legacy auth block from the source document into the
+// migrated file at path.
+func restoreAuthBlock(path string, source []byte) error {
+	var src yaml.Node
+	if err := yaml.Unmarshal(source, &src); err != nil || len(src.Content) == 0 || src.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	idx := findMapKeyIndex(src.Content[0], legacyAuthKey.Key)
+	if idx < 0 || idx+1 >= len(src.Content[0].Content) || isLegacyAuthBlock(src.Content[0].Content[idx+1]) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("expected root mapping node")
+	}
+	root := doc.Content[0]
+	removeMapKey(root, legacyAuthKey.Key)
+	root.Content = append(root.Content, deepCopyNode(src.Content[0].Content[idx]), deepCopyNode(src.Content[0].Content[idx+1]))
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		_ = enc.Close()
+		return err
+	}
+	if err = enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, NormalizeCommentIndentation(buf.Bytes()), 0o600)
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateConfigFile_RewritesLegacyKeys(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.yaml")
+	dst := filepath.Join(dir, "config.migrated.yaml")
+	legacy := `port: 8317
+generative-language-api-key:
+  - "gem-key"
+amp-upstream-url: "https://amp.example.com"
+`
+	if err := os.WriteFile(src, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+
+	found, err := MigrateConfigFile(src, dst)
+	if err != nil {
+		t.Fatalf("MigrateConfigFile: %v", err)
+	}
+	if len(found) != 2 || found[0].Key != "generative-language-api-key" || found[1].Key != "amp-upstream-url" {
+		t.Fatalf("unexpected deprecated keys: %v", found)
+	}
+
+	original, _ := os.ReadFile(src)
+	if string(original) != legacy {
+		t.Fatalf("source file was modified")
+	}
+	migrated, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("read migrated: %v", err)
+	}
+	out := string(migrated)
+	if strings.Contains(out, "generative-language-api-key") || strings.Contains(out, "amp-upstream-url") {
+		t.Fatalf("legacy keys survived migration:\n%s", out)
+	}
+	cfg, err := LoadConfig(dst)
+	if err != nil {
+		t.Fatalf("load migrated: %v", err)
+	}
+	if len(cfg.GeminiKey) != 1 || cfg.GeminiKey[0].APIKey != "gem-key" {
+		t.Fatalf("gemini key not migrated: %+v", cfg.GeminiKey)
+	}
+	if cfg.AmpCode.UpstreamURL != "https://amp.example.com" {
+		t.Fatalf("amp upstream not migrated: %q", cfg.AmpCode.UpstreamURL)
+	}
+}
+
+func TestMigrateConfigFile_RefusesToOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(src, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	if _, err := MigrateConfigFile(src, src); err == nil {
+		t.Fatal("expected error when destination exists")
+	}
+}
+
+func TestMigrateConfigFile_KeepsAccessProviders(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.yaml")
+	dst := filepath.Join(dir, "config.migrated.yaml")
+	legacy := `port: 8317
+amp-upstream-url: "https://amp.example.com"
+auth:
+  providers:
+    - name: sso
+      type: custom-sso
+`
+	if err := os.WriteFile(src, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+
+	found, err := MigrateConfigFile(src, dst)
+	if err != nil {
+		t.Fatalf("MigrateConfigFile: %v", err)
+	}
+	for _, key := range found {
+		if key.Key == "auth" {
+			t.Fatalf("auth with access providers reported as deprecated: %v", found)
+		}
+	}
+	migrated, _ := os.ReadFile(dst)
+	if !strings.Contains(string(migrated), "custom-sso") {
+		t.Fatalf("access providers lost:\n%s", migrated)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("expected only source and destination files, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnvVar selects the active configuration profile when set.
+const ProfileEnvVar = "CLIPROXY_PROFILE"
+
+const (
+	profilesKey      = "profiles"
+	activeProfileKey = "active-profile"
+)
+
+// profileOverride holds the profile chosen on the command line.
+var profileOverride atomic.Value
+
+// SetProfileOverride selects the configuration profile for subsequent loads, taking
+// precedence over the CLIPROXY_PROFILE environment variable and the active-profile key.
+func SetProfileOverride(name string) {
+	profileOverride.Store(strings.TrimSpace(name))
+}
+
+// resolveActiveProfile returns the profile to apply: the command-line override, then
+// the environment, then the active-profile key from the file.
+func resolveActiveProfile(fileValue string) string {
+	if name, _ := profileOverride.Load().(string); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(os.Getenv(ProfileEnvVar)); name != "" {
+		return name
+	}
+	return strings.TrimSpace(fileValue)
+}
+
+// ActiveProfileName returns the profile applied when the configuration was loaded, or
+// "" when only base values are in effect.
+func (cfg *Config) ActiveProfileName() string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.activeProfile
+}
+
+// applyConfigProfile merges the selected profile over the base document. Mappings are
+// merged recursively; any other value in the profile replaces the base value. It returns
+// the merged YAML, the applied profile name and the leaf key paths the profile set.
+func applyConfigProfile(data []byte) ([]byte, string, [][]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, "", nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, "", nil, nil
+	}
+	root := doc.Content[0]
+
+	fileValue := ""
+	if idx := findMapKeyIndex(root, activeProfileKey); idx >= 0 && idx+1 < len(root.Content) {
+		fileValue = root.Content[idx+1].Value
+	}
+	name := resolveActiveProfile(fileValue)
+	if name == "" {
+		return data, "", nil, nil
+	}
+	profile := profileNode(root, name)
+	if profile == nil {
+		return nil, "", nil, fmt.Errorf("config profile %q not found", name)
+	}
+
+	var paths [][]string
+	for i := 0; i+1 < len(profile.Content); i += 2 {
+		key := profile.Content[i].Value
+		if key == profilesKey || key == activeProfileKey {
+			continue
+		}
+		paths = appendLeafPaths(paths, []string{key}, profile.Content[i+1])
+		overlayProfileValue(root, key, profile.Content[i+1])
+	}
+	merged, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return merged, name, paths, nil
+}
+
+// appendLeafPaths appends the path of every non-mapping value under node. Mappings are
+// merged key by key, so only their leaves are owned by the profile.
+func appendLeafPaths(paths [][]string, prefix []string, node *yaml.Node) [][]string {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return append(paths, append([]string(nil), prefix...))
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		paths = appendLeafPaths(paths, append(prefix, node.Content[i].Value), node.Content[i+1])
+	}
+	return paths
+}
+
+// profileNode returns the mapping for profiles.<name>, or nil.
+func profileNode(root *yaml.Node, name string) *yaml.Node {
+	idx := findMapKeyIndex(root, profilesKey)
+	if idx < 0 || idx+1 >= len(root.Content) {
+		return nil
+	}
+	profiles := root.Content[idx+1]
+	if profiles.Kind != yaml.MappingNode {
+		return nil
+	}
+	idx = findMapKeyIndex(profiles, name)
+	if idx < 0 || idx+1 >= len(profiles.Content) || profiles.Content[idx+1].Kind != yaml.MappingNode {
+		return nil
+	}
+	return profiles.Content[idx+1]
+}
+
+func overlayProfileValue(dst *yaml.Node, key string, value *yaml.Node) {
+	idx := findMapKeyIndex(dst, key)
+	if idx < 0 {
+		dst.Content = append(dst.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, deepCopyNode(value))
+		return
+	}
+	existing := dst.Content[idx+1]
+	if existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			overlayProfileValue(existing, value.Content[i].Value, value.Content[i+1])
+		}
+		return
+	}
+	dst.Content[idx+1] = deepCopyNode(value)
+}
+
+// redirectProfileKeys moves the values owned by the active profile from generated into
+// profiles.<name> of original. Only the leaves the profile overrides are moved; the rest
+// of a shared section stays in generated and is saved to the base as usual.
+func redirectProfileKeys(original, generated *yaml.Node, cfg *Config) {
+	if cfg == nil || cfg.activeProfile == "" || len(cfg.profilePaths) == 0 {
+		return
+	}
+	profile := profileNode(original, cfg.activeProfile)
+	if profile == nil {
+		return
+	}
+	for _, path := range cfg.profilePaths {
+		value := takeNodeAtPath(generated, path)
+		if value == nil {
+			continue
+		}
+		parent := profile
+		for _, key := range path[:len(path)-1] {
+			parent = getOrCreateMapValue(parent, key)
+		}
+		leaf := path[len(path)-1]
+		if idx := findMapKeyIndex(parent, leaf); idx >= 0 && idx+1 < len(parent.Content) {
+			mergeNodePreserve(parent.Content[idx+1], value)
+		} else {
+			parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: leaf}, value)
+		}
+	}
+}
+
+// takeNodeAtPath removes and returns the value at path in the mapping root, or nil.
+func takeNodeAtPath(root *yaml.Node, path []string) *yaml.Node {
+	node := root
+	for i, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		idx := findMapKeyIndex(node, key)
+		if idx < 0 || idx+1 >= len(node.Content) {
+			return nil
+		}
+		if i == len(path)-1 {
+			value := node.Content[idx+1]
+			removeMapKey(node, key)
+			return value
+		}
+		node = node.Content[idx+1]
+	}
+	return nil
+}
+
+// profileOwns reports whether the active profile sets the top-level key or any key below it.
+func (cfg *Config) profileOwns(key string) bool {
+	if cfg == nil || cfg.activeProfile == "" {
+		return false
+	}
+	for _, path := range cfg.profilePaths {
+		if path[0] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// persistHashedSecretKeys replaces every plaintext remote-management.secret-key in the
+// file, in the base section and in each profile, with its bcrypt hash. data is the raw
+// file content before any profile is merged. Failures are ignored; the next load retries.
+func persistHashedSecretKeys(configFile string, data []byte) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+	secretPath := []string{"remote-management", "secret-key"}
+	paths := [][]string{secretPath}
+	if idx := findMapKeyIndex(root, profilesKey); idx >= 0 && idx+1 < len(root.Content) && root.Content[idx+1].Kind == yaml.MappingNode {
+		profiles := root.Content[idx+1]
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			paths = append(paths, append([]string{profilesKey, profiles.Content[i].Value}, secretPath...))
+		}
+	}
+	for _, path := range paths {
+		node := root
+		for _, key := range path {
+			idx := findMapKeyIndex(node, key)
+			if node.Kind != yaml.MappingNode || idx < 0 || idx+1 >= len(node.Content) {
+				node = nil
+				break
+			}
+			node = node.Content[idx+1]
+		}
+		if node == nil || node.Kind != yaml.ScalarNode || node.Value == "" || looksLikeBcrypt(node.Value) {
+			continue
+		}
+		hashed, err := hashSecret(node.Value)
+		if err != nil {
+			continue
+		}
+		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, path, hashed)
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const profileTestConfig = `port: 8317
+debug: false
+remote-management:
+  allow-remote: false
+active-profile: dev
+profiles:
+  dev:
+    debug: true
+  prod:
+    port: 9000
+    remote-management:
+      allow-remote: true
+`
+
+func writeProfileTestConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(profileTestConfig), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_AppliesActiveProfile(t *testing.T) {
+	path := writeProfileTestConfig(t)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.ActiveProfileName() != "dev" || !cfg.Debug || cfg.Port != 8317 {
+		t.Fatalf("dev profile not applied: profile=%q debug=%v port=%d", cfg.ActiveProfileName(), cfg.Debug, cfg.Port)
+	}
+}
+
+func TestLoadConfig_ProfileOverrideTakesPrecedence(t *testing.T) {
+	path := writeProfileTestConfig(t)
+	t.Setenv(ProfileEnvVar, "prod")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.ActiveProfileName() != "prod" || cfg.Debug || cfg.Port != 9000 || !cfg.RemoteManagement.AllowRemote {
+		t.Fatalf("prod profile not applied: %+v", cfg)
+	}
+
+	SetProfileOverride("missing")
+	defer SetProfileOverride("")
+	if _, err = LoadConfig(path); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+}
+
+func TestSaveConfig_WritesProfileOwnedKeysToProfile(t *testing.T) {
+	path := writeProfileTestConfig(t)
+	t.Setenv(ProfileEnvVar, "prod")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.Port = 9100
+	cfg.Debug = true
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments: %v", err)
+	}
+
+	t.Setenv(ProfileEnvVar, "")
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "port: 9100") {
+		t.Fatalf("profile port not persisted:\n%s", data)
+	}
+	base, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	// Base port stays put; debug is not owned by prod so it lands in the base.
+	if base.Port != 8317 {
+		t.Fatalf("base port changed to %d", base.Port)
+	}
+
+	t.Setenv(ProfileEnvVar, "prod")
+	prod, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("reload prod: %v", err)
+	}
+	if prod.Port != 9100 || !prod.Debug {
+		t.Fatalf("prod reload mismatch: port=%d debug=%v", prod.Port, prod.Debug)
+	}
+}
+
+func TestLoadConfig_HashesBaseSecretWhenProfileOwnsSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := strings.Replace(profileTestConfig, "  allow-remote: false\n", "  allow-remote: false\n  secret-key: plain-secret\n", 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv(ProfileEnvVar, "prod")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !looksLikeBcrypt(cfg.RemoteManagement.SecretKey) {
+		t.Fatal("expected in-memory secret to be hashed")
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "plain-secret") {
+		t.Fatalf("plaintext secret left on disk:\n%s", data)
+	}
+
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments: %v", err)
+	}
+	t.Setenv(ProfileEnvVar, "")
+	base, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if base.RemoteManagement.AllowRemote || base.RemoteManagement.SecretKey == "" {
+		t.Fatalf("base remote-management changed: %+v", base.RemoteManagement)
+	}
+	data, _ = os.ReadFile(path)
+	if strings.Count(string(data), "secret-key:") != 1 {
+		t.Fatalf("secret-key duplicated into the profile:\n%s", data)
+	}
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAuthHeader is the auth-header template of OpenAI-compatible providers.
+const DefaultAuthHeader = "Authorization: Bearer {api-key}"
+
+// authHeaderKeyPlaceholder stands for the API key in auth-header templates.
+const authHeaderKeyPlaceholder = "{api-key}"
+
+// LoadProviderDefinitions reads the provider definition files of dir in name order. Each
+// file holds one openai-compatibility entry as YAML or JSON; an entry without a name is
+// named after its file.
+func LoadProviderDefinitions(dir string) ([]OpenAICompatibility, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider definitions: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	definitions := make([]OpenAICompatibility, 0, len(names))
+	for _, name := range names {
+		data, errRead := os.ReadFile(filepath.Join(dir, name))
+		if errRead != nil {
+			return nil, fmt.Errorf("failed to read provider definition %s: %w", name, errRead)
+		}
+		var definition OpenAICompatibility
+		// JSON is a subset of YAML, so one decoder serves both formats.
+		if errParse := yaml.Unmarshal(data, &definition); errParse != nil {
+			return nil, fmt.Errorf("failed to parse provider definition %s: %w", name, errParse)
+		}
+		definition.Name = strings.TrimSpace(definition.Name)
+		if definition.Name == "" {
+			definition.Name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, nil
+}
+
+// providerDefinitionOrigin records how a provider definition file shaped an
+// openai-compatibility entry: the inline entry it completed (nil when the definition was
+// appended) and the resulting entry.
+type providerDefinitionOrigin struct {
+	inline *OpenAICompatibility
+	merged OpenAICompatibility
+}
+
+// loadProviderDefinitions merges the files of provider-definitions-dir into the
+// openai-compatibility entries and validates their auth-header templates. A definition
+// whose name matches an inline entry fills the fields that entry leaves empty, so the
+// config file only needs to add credentials; other definitions are appended.
+func (cfg *Config) loadProviderDefinitions(configFile string) error {
+	dir := strings.TrimSpace(cfg.ProviderDefinitionsDir)
+	if dir != "" {
+		if !filepath.IsAbs(dir) && configFile != "" {
+			dir = filepath.Join(filepath.Dir(configFile), dir)
+		}
+		definitions, err := LoadProviderDefinitions(dir)
+		if err != nil {
+			return err
+		}
+		inline := make(map[string]*OpenAICompatibility, len(definitions))
+		for _, definition := range definitions {
+			key := strings.ToLower(definition.Name)
+			if entry := cfg.openAICompatEntry(definition.Name); entry != nil {
+				if _, seen := inline[key]; !seen {
+					original := *entry
+					inline[key] = &original
+				}
+				applyProviderDefinition(entry, definition)
+				continue
+			}
+			inline[key] = nil
+			cfg.OpenAICompatibility = append(cfg.OpenAICompatibility, definition)
+		}
+		cfg.SanitizeOpenAICompatibility()
+		cfg.providerDefinitions = make(map[string]providerDefinitionOrigin, len(inline))
+		for key, original := range inline {
+			if entry := cfg.openAICompatEntry(key); entry != nil {
+				cfg.providerDefinitions[key] = providerDefinitionOrigin{inline: original, merged: *entry}
+			}
+		}
+	}
+	for i := range cfg.OpenAICompatibility {
+		if template := strings.TrimSpace(cfg.OpenAICompatibility[i].AuthHeader); template != "" {
+			if _, _, err := AuthHeader(template, ""); err != nil {
+				return fmt.Errorf("openai-compatibility %s: %w", cfg.OpenAICompatibility[i].Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// withoutProviderDefinitions returns the openai-compatibility entries as the config file
+// configured them: entries still matching what a definition file produced are reverted to
+// their inline form or dropped, while entries changed since loading are kept as they are.
+func (cfg *Config) withoutProviderDefinitions() []OpenAICompatibility {
+	if len(cfg.providerDefinitions) == 0 {
+		return cfg.OpenAICompatibility
+	}
+	out := make([]OpenAICompatibility, 0, len(cfg.OpenAICompatibility))
+	for _, entry := range cfg.OpenAICompatibility {
+		origin, ok := cfg.providerDefinitions[strings.ToLower(entry.Name)]
+		if !ok || !reflect.DeepEqual(entry, origin.merged) {
+			out = append(out, entry)
+			continue
+		}
+		if origin.inline != nil {
+			out = append(out, *origin.inline)
+		}
+	}
+	return out
+}
+
+// openAICompatEntry returns the openai-compatibility entry named name, or nil.
+func (cfg *Config) openAICompatEntry(name string) *OpenAICompatibility {
+	for i := range cfg.OpenAICompatibility {
+		if strings.EqualFold(strings.TrimSpace(cfg.OpenAICompatibility[i].Name), name) {
+			return &cfg.OpenAICompatibility[i]
+		}
+	}
+	return nil
+}
+
+// applyProviderDefinition copies the fields of definition that entry leaves empty.
+func applyProviderDefinition(entry *OpenAICompatibility, definition OpenAICompatibility) {
+	if strings.TrimSpace(entry.BaseURL) == "" && len(entry.BaseURLs) == 0 {
+		entry.BaseURL, entry.BaseURLs = definition.BaseURL, definition.BaseURLs
+	}
+	if strings.TrimSpace(entry.Prefix) == "" {
+		entry.Prefix = definition.Prefix
+	}
+	if entry.Priority == 0 {
+		entry.Priority = definition.Priority
+	}
+	if len(entry.APIKeyEntries) == 0 {
+		entry.APIKeyEntries = definition.APIKeyEntries
+	}
+	if len(entry.Models) == 0 {
+		entry.Models = definition.Models
+	}
+	if len(definition.Headers) > 0 {
+		headers := make(map[string]string, len(definition.Headers)+len(entry.Headers))
+		for k, v := range definition.Headers {
+			headers[k] = v
+		}
+		for k, v := range entry.Headers {
+			headers[k] = v
+		}
+		entry.Headers = headers
+	}
+	if strings.TrimSpace(entry.FIM) == "" {
+		entry.FIM = definition.FIM
+	}
+	if strings.TrimSpace(entry.AuthHeader) == "" {
+		entry.AuthHeader = definition.AuthHeader
+	}
+	if strings.TrimSpace(entry.ModelsURL) == "" {
+		entry.ModelsURL = definition.ModelsURL
+	}
+	if entry.Quirks == (CompatQuirks{}) {
+		entry.Quirks = definition.Quirks
+	}
+}
+
+// AuthHeader expands an auth-header template ("Name: value", see
+// OpenAICompatibility.AuthHeader) for apiKey. An empty template yields DefaultAuthHeader.
+func AuthHeader(template, apiKey string) (name, value string, err error) {
+	template = strings.TrimSpace(template)
+	if template == "" {
+		template = DefaultAuthHeader
+	}
+	name, value, ok := strings.Cut(template, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid auth-header %q: want \"Name: value\"", template)
+	}
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, authHeaderKeyPlaceholder) {
+		return "", "", fmt.Errorf("invalid auth-header %q: the value must contain %s", template, authHeaderKeyPlaceholder)
+	}
+	return name, strings.ReplaceAll(value, authHeaderKeyPlaceholder, apiKey), nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigProviderDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	providers := filepath.Join(dir, "providers")
+	if err := os.Mkdir(providers, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"acme.yaml": "base-url: https://api.acme.example/v1\nauth-header: \"x-api-key: {api-key}\"\nmodels-url: /models\nquirks:\n  legacy-max-tokens: true\n",
+		"beta.json": `{"name":"beta","base-url":"https://beta.example/v1","models":[{"name":"beta-large","alias":"beta"}]}`,
+		"notes.txt": "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(providers, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configFile := filepath.Join(dir, "config.yaml")
+	content := "provider-definitions-dir: providers\nopenai-compatibility:\n  - name: acme\n    api-key-entries:\n      - api-key: acme-key\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.OpenAICompatibility) != 2 {
+		t.Fatalf("entries = %+v, want acme and beta", cfg.OpenAICompatibility)
+	}
+	acme := cfg.OpenAICompatibility[0]
+	if acme.BaseURL != "https://api.acme.example/v1" || acme.AuthHeader != "x-api-key: {api-key}" || acme.ModelsURL != "/models" || !acme.Quirks.LegacyMaxTokens {
+		t.Fatalf("acme was not completed by its definition: %+v", acme)
+	}
+	if len(acme.APIKeyEntries) != 1 || acme.APIKeyEntries[0].APIKey != "acme-key" {
+		t.Fatalf("acme lost its inline keys: %+v", acme.APIKeyEntries)
+	}
+	if beta := cfg.OpenAICompatibility[1]; beta.Name != "beta" || len(beta.Models) != 1 {
+		t.Fatalf("beta = %+v", beta)
+	}
+
+	if err = SaveConfigPreserveComments(configFile, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments: %v", err)
+	}
+	saved, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(saved), "acme.example") || strings.Contains(string(saved), "beta") {
+		t.Fatalf("definitions were written into the config file:\n%s", saved)
+	}
+}
+
+func TestLoadConfigRejectsInvalidAuthHeader(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := "openai-compatibility:\n  - name: acme\n    base-url: https://api.acme.example/v1\n    auth-header: \"x-api-key\"\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(configFile); err == nil || !strings.Contains(err.Error(), "auth-header") {
+		t.Fatalf("LoadConfig error = %v, want an auth-header error", err)
+	}
+}
+
+func TestAuthHeader(t *testing.T) {
+	name, value, err := AuthHeader("", "k")
+	if err != nil || name != "Authorization" || value != "Bearer k" {
+		t.Fatalf("default = %q %q %v", name, value, err)
+	}
+	name, value, err = AuthHeader("api-key: {api-key}", "k")
+	if err != nil || name != "api-key" || value != "k" {
+		t.Fatalf("custom = %q %q %v", name, value, err)
+	}
+	if _, _, err = AuthHeader("api-key: static", "k"); err == nil {
+		t.Fatal("template without {api-key} must be rejected")
+	}
+}
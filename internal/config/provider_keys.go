@@ -0,0 +1,242 @@
+package config
+
+import "strings"
+
+// ProviderKey represents a credential for an API-key provider that needs no settings
+// beyond the endpoint, models and transport (Groq, xAI, OpenRouter, Cohere, Fireworks,
+// NVIDIA NIM, Moonshot, Zhipu GLM, Cerebras and Ollama). Each provider has its own config
+// section of ProviderKey entries.
+type ProviderKey struct {
+	// APIKey is the authentication key for the provider. It is required for every
+	// provider except Ollama, whose servers are identified by base-url.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// MaxConcurrency caps the requests in flight on this credential, overriding
+	// concurrency.per-auth. <= 0 uses the default.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "fast/llama-3.3-70b-versatile").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL overrides the provider endpoint. Empty values default to the provider's
+	// public API root (see ProviderKeySpec.DefaultBaseURL).
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models defines upstream model names and aliases. When empty, the provider's
+	// built-in list is registered or its catalog is discovered from the upstream.
+	Models []ProviderModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// ProviderModel describes a mapping between an alias and the actual upstream model name.
+type ProviderModel struct {
+	// Name is the upstream model identifier used when issuing requests.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m ProviderModel) GetName() string  { return m.Name }
+func (m ProviderModel) GetAlias() string { return m.Alias }
+
+// Per-provider names for ProviderKey, kept so each config section reads naturally.
+type (
+	GroqKey         = ProviderKey
+	GroqModel       = ProviderModel
+	XAIKey          = ProviderKey
+	XAIModel        = ProviderModel
+	OpenRouterKey   = ProviderKey
+	OpenRouterModel = ProviderModel
+	CohereKey       = ProviderKey
+	CohereModel     = ProviderModel
+	FireworksKey    = ProviderKey
+	FireworksModel  = ProviderModel
+	NVIDIAKey       = ProviderKey
+	NVIDIAModel     = ProviderModel
+	MoonshotKey     = ProviderKey
+	MoonshotModel   = ProviderModel
+	ZhipuKey        = ProviderKey
+	ZhipuModel      = ProviderModel
+	CerebrasKey     = ProviderKey
+	CerebrasModel   = ProviderModel
+	OllamaKey       = ProviderKey
+	OllamaModel     = ProviderModel
+)
+
+const (
+	// DefaultGroqBaseURL is Groq's OpenAI-compatible API root.
+	DefaultGroqBaseURL = "https://api.groq.com/openai/v1"
+	// DefaultXAIBaseURL is the xAI API root.
+	DefaultXAIBaseURL = "https://api.x.ai/v1"
+	// DefaultOpenRouterBaseURL is the OpenRouter API root.
+	DefaultOpenRouterBaseURL = "https://openrouter.ai/api/v1"
+	// DefaultCohereBaseURL is the Cohere API root; the executor appends /v2/chat.
+	DefaultCohereBaseURL = "https://api.cohere.com"
+	// DefaultFireworksBaseURL is the Fireworks AI inference API root.
+	DefaultFireworksBaseURL = "https://api.fireworks.ai/inference/v1"
+	// DefaultNVIDIABaseURL is the hosted NIM API root of build.nvidia.com.
+	DefaultNVIDIABaseURL = "https://integrate.api.nvidia.com/v1"
+	// DefaultMoonshotBaseURL is the Moonshot AI (Kimi) API root.
+	DefaultMoonshotBaseURL = "https://api.moonshot.ai/v1"
+	// DefaultZhipuBaseURL is the Zhipu AI open platform (BigModel) API root.
+	DefaultZhipuBaseURL = "https://open.bigmodel.cn/api/paas/v4"
+	// DefaultCerebrasBaseURL is the Cerebras Inference API root.
+	DefaultCerebrasBaseURL = "https://api.cerebras.ai/v1"
+	// DefaultOllamaBaseURL is the address of a local Ollama server.
+	DefaultOllamaBaseURL = "http://localhost:11434"
+)
+
+// ProviderKeySpec describes one provider-key section of the config file.
+type ProviderKeySpec struct {
+	// Provider is the auth provider name, e.g. "groq".
+	Provider string
+	// Section is the YAML key of the section, e.g. "groq-api-key".
+	Section string
+	// DefaultBaseURL is used for entries without a base-url.
+	DefaultBaseURL string
+	// ServerKeyed marks providers whose entries are identified by base-url and for
+	// which the api-key is optional (Ollama).
+	ServerKeyed bool
+}
+
+// ProviderKeySection pairs a provider-key spec with its configured entries.
+type ProviderKeySection struct {
+	ProviderKeySpec
+	Keys []ProviderKey
+}
+
+// providerKeyList points at the slice backing one provider-key section.
+type providerKeyList struct {
+	spec ProviderKeySpec
+	keys *[]ProviderKey
+}
+
+// providerKeyLists returns the provider-key sections of cfg in a stable order, with
+// pointers to the underlying slices so they can be rewritten in place.
+func (cfg *Config) providerKeyLists() []providerKeyList {
+	return []providerKeyList{
+		{ProviderKeySpec{Provider: "groq", Section: "groq-api-key", DefaultBaseURL: DefaultGroqBaseURL}, &cfg.GroqKey},
+		{ProviderKeySpec{Provider: "xai", Section: "xai-api-key", DefaultBaseURL: DefaultXAIBaseURL}, &cfg.XAIKey},
+		{ProviderKeySpec{Provider: "openrouter", Section: "openrouter-api-key", DefaultBaseURL: DefaultOpenRouterBaseURL}, &cfg.OpenRouterKey},
+		{ProviderKeySpec{Provider: "cohere", Section: "cohere-api-key", DefaultBaseURL: DefaultCohereBaseURL}, &cfg.CohereKey},
+		{ProviderKeySpec{Provider: "fireworks", Section: "fireworks-api-key", DefaultBaseURL: DefaultFireworksBaseURL}, &cfg.FireworksKey},
+		{ProviderKeySpec{Provider: "nvidia", Section: "nvidia-api-key", DefaultBaseURL: DefaultNVIDIABaseURL}, &cfg.NVIDIAKey},
+		{ProviderKeySpec{Provider: "moonshot", Section: "moonshot-api-key", DefaultBaseURL: DefaultMoonshotBaseURL}, &cfg.MoonshotKey},
+		{ProviderKeySpec{Provider: "zhipu", Section: "zhipu-api-key", DefaultBaseURL: DefaultZhipuBaseURL}, &cfg.ZhipuKey},
+		{ProviderKeySpec{Provider: "cerebras", Section: "cerebras-api-key", DefaultBaseURL: DefaultCerebrasBaseURL}, &cfg.CerebrasKey},
+		{ProviderKeySpec{Provider: "ollama", Section: "ollama", DefaultBaseURL: DefaultOllamaBaseURL, ServerKeyed: true}, &cfg.OllamaKey},
+	}
+}
+
+// ProviderKeySections lists every provider-key section, including empty ones, in a
+// stable order.
+func (cfg *Config) ProviderKeySections() []ProviderKeySection {
+	if cfg == nil {
+		return nil
+	}
+	lists := cfg.providerKeyLists()
+	out := make([]ProviderKeySection, 0, len(lists))
+	for _, list := range lists {
+		out = append(out, ProviderKeySection{ProviderKeySpec: list.spec, Keys: *list.keys})
+	}
+	return out
+}
+
+// ProviderKeySpecFor returns the provider-key spec for provider, if it has one.
+func ProviderKeySpecFor(provider string) (ProviderKeySpec, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, list := range (&Config{}).providerKeyLists() {
+		if list.spec.Provider == provider {
+			return list.spec, true
+		}
+	}
+	return ProviderKeySpec{}, false
+}
+
+// SanitizeProviderKeys normalizes every provider-key section: it defaults an empty
+// base-url and drops entries without an api-key, or, for server-keyed providers,
+// entries that repeat a base-url.
+func (cfg *Config) SanitizeProviderKeys() {
+	if cfg == nil {
+		return
+	}
+	for _, list := range cfg.providerKeyLists() {
+		*list.keys = sanitizeProviderKeys(*list.keys, list.spec)
+	}
+}
+
+func sanitizeProviderKeys(keys []ProviderKey, spec ProviderKeySpec) []ProviderKey {
+	if len(keys) == 0 {
+		return keys
+	}
+	seen := make(map[string]struct{}, len(keys))
+	out := make([]ProviderKey, 0, len(keys))
+	for i := range keys {
+		e := keys[i]
+		e.APIKey = strings.TrimSpace(e.APIKey)
+		if e.APIKey == "" && !spec.ServerKeyed {
+			continue
+		}
+		e.BaseURL = strings.TrimRight(strings.TrimSpace(e.BaseURL), "/")
+		if e.BaseURL == "" {
+			e.BaseURL = spec.DefaultBaseURL
+		}
+		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.ProxyURL = strings.TrimSpace(e.ProxyURL)
+		e.Headers = NormalizeHeaders(e.Headers)
+		e.ExcludedModels = NormalizeExcludedModels(e.ExcludedModels)
+		if spec.ServerKeyed {
+			key := strings.ToLower(e.BaseURL)
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// FindProviderKey returns the entry of provider's section that matches the given api
+// key and base URL, or nil. Server-keyed providers match on base URL alone.
+func (cfg *Config) FindProviderKey(provider, apiKey, baseURL string) *ProviderKey {
+	if cfg == nil {
+		return nil
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	apiKey = strings.TrimSpace(apiKey)
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	for _, list := range cfg.providerKeyLists() {
+		if list.spec.Provider != provider {
+			continue
+		}
+		keys := *list.keys
+		for i := range keys {
+			entry := &keys[i]
+			entryBase := strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/")
+			if list.spec.ServerKeyed {
+				if strings.EqualFold(entryBase, baseURL) {
+					return entry
+				}
+				continue
+			}
+			if strings.TrimSpace(entry.APIKey) == apiKey && (baseURL == "" || strings.EqualFold(entryBase, baseURL)) {
+				return entry
+			}
+		}
+	}
+	return nil
+}
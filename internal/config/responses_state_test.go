@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig_StatelessTokensRequireSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "responses-state:\n  stateless-tokens: true\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected error for stateless-tokens without a secret")
+	}
+
+	data += "  secret: s3cret\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !cfg.ResponsesState.StatelessTokens {
+		t.Fatal("stateless-tokens not loaded")
+	}
+}
+
+func TestLoadConfig_ResponsesFileStoreRequiresDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "responses-state:\n  store: file\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected error for the file store without store-dir")
+	}
+}
@@ -26,9 +26,239 @@ type SDKConfig struct {
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
+	// DebugErrorKeys lists client API keys whose error responses include the untranslated
+	// upstream status and body under a "debug" field.
+	DebugErrorKeys []string `yaml:"debug-error-keys,omitempty" json:"debug-error-keys,omitempty"`
+
+	// ErrorFormat selects the error body returned to clients. "unified" always returns the
+	// proxy error envelope, whatever the request format; empty or "native" keeps the error
+	// shape of each API format.
+	ErrorFormat string `yaml:"error-format,omitempty" json:"error-format,omitempty"`
+
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// ResponsesState configures how Responses API conversation state is carried between turns.
+	ResponsesState ResponsesStateConfig `yaml:"responses-state,omitempty" json:"responses-state,omitempty"`
+
+	// Moderation configures the backend serving /v1/moderations and moderation checks.
+	Moderation ModerationConfig `yaml:"moderation,omitempty" json:"moderation,omitempty"`
+
+	// LocalePolicies maps client API keys to the language their responses must be in.
+	LocalePolicies map[string]LocalePolicy `yaml:"locale-policies,omitempty" json:"locale-policies,omitempty"`
+
+	// Jobs configures the asynchronous job API under /v1/jobs.
+	Jobs JobsConfig `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+
+	// StructuredOutput configures how JSON replies requested with response_format are checked.
+	StructuredOutput StructuredOutputConfig `yaml:"structured-output,omitempty" json:"structured-output,omitempty"`
+
+	// BestOf configures the parallel multi-provider mode requested with the X-Best-Of header.
+	BestOf BestOfConfig `yaml:"best-of,omitempty" json:"best-of,omitempty"`
+
+	// ModelRoutes rewrite requested model names matching a pattern, in order.
+	ModelRoutes []ModelRoute `yaml:"model-routes,omitempty" json:"model-routes,omitempty"`
+
+	// FallbackChains orders the providers tried for a model alias.
+	FallbackChains []FallbackChain `yaml:"fallback-chains,omitempty" json:"fallback-chains,omitempty"`
+
+	// FairQueuing shares upstream capacity between client API keys under contention.
+	FairQueuing FairQueuingConfig `yaml:"fair-queuing,omitempty" json:"fair-queuing,omitempty"`
+}
+
+// JobsConfig controls the asynchronous job API. A job runs one non-streaming generation
+// server-side; clients poll GET /v1/jobs/{id} or have the finished job posted to a
+// webhook. The API is disabled while Store is empty.
+type JobsConfig struct {
+	// Store selects where jobs are kept: "memory", or "file" to survive restarts.
+	Store string `yaml:"store,omitempty" json:"store,omitempty"`
+
+	// StoreDir is the directory of the file store. Required for the file store.
+	StoreDir string `yaml:"store-dir,omitempty" json:"store-dir,omitempty"`
+
+	// TTLSeconds is how long jobs are kept after they are created. <= 0 uses 24 hours.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// WebhookSecret signs webhook deliveries: each carries an X-Job-Signature header
+	// holding "sha256=" and the hex HMAC-SHA256 of the body. Empty sends them unsigned.
+	WebhookSecret string `yaml:"webhook-secret,omitempty" json:"webhook-secret,omitempty"`
+
+	// WebhookAllowedHosts lists host names, IP addresses and CIDR ranges webhooks may
+	// reach although they are loopback, private or link-local. Other such targets are
+	// rejected so clients cannot make the proxy call internal services.
+	WebhookAllowedHosts []string `yaml:"webhook-allowed-hosts,omitempty" json:"webhook-allowed-hosts,omitempty"`
+
+	// MaxRunningPerKey caps the jobs one client API key may have in progress. <= 0 uses 16.
+	MaxRunningPerKey int `yaml:"max-running-per-key,omitempty" json:"max-running-per-key,omitempty"`
+}
+
+// StructuredOutputConfig controls the checks on non-streaming replies to OpenAI requests
+// with a response_format. Replies must hold a JSON object when the format is emulated for
+// the provider, and must match the schema when a json_schema is marked strict.
+type StructuredOutputConfig struct {
+	// DisableReask fails invalid replies at once with json_output_invalid instead of
+	// asking the model again.
+	DisableReask bool `yaml:"disable-reask,omitempty" json:"disable-reask,omitempty"`
+}
+
+// DefaultBestOfMaxModels is the number of models a best-of request may fan out to when
+// MaxModels is not set.
+const DefaultBestOfMaxModels = 4
+
+// BestOfConfig controls best-of requests, which send one non-streaming prompt to several
+// models in parallel and return the fastest answer or all of them.
+type BestOfConfig struct {
+	// Enabled allows clients to request best-of mode. When false the headers are ignored.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// MaxModels caps the models one request may fan out to, including the requested
+	// model. <= 0 uses DefaultBestOfMaxModels.
+	MaxModels int `yaml:"max-models,omitempty" json:"max-models,omitempty"`
+}
+
+// ModelRoute sends every requested model matching a pattern to a provider and/or target
+// model, so a whole model family is routed without listing each alias. Routes are
+// evaluated in order and the first match applies; models matching none are routed as
+// usual.
+type ModelRoute struct {
+	// Match is the pattern for the requested model name. '*' matches any run of
+	// characters and matching is case-insensitive, unless Regex is set.
+	Match string `yaml:"match" json:"match"`
+
+	// Regex interprets Match as a regular expression (unanchored, like amp model-mappings).
+	Regex bool `yaml:"regex,omitempty" json:"regex,omitempty"`
+
+	// Provider restricts the request to one provider, e.g. "codex" or an
+	// openai-compatibility name. Empty allows every provider of the target model.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+
+	// Model is the model requested from the provider. With Regex, $1 or ${name} expand the
+	// groups captured by Match. Empty keeps the requested name.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+}
+
+// FallbackChain sends requests for a model alias through an ordered list of providers.
+// The first step serves the request; when it fails with 429, a 5xx status or a timeout,
+// the next step is tried, and the first successful response is returned. Other errors,
+// such as invalid requests, are returned without trying further steps.
+type FallbackChain struct {
+	// Model is the client-facing model name the chain applies to.
+	Model string `yaml:"model" json:"model"`
+
+	// Chain lists the steps in the order they are tried.
+	Chain []FallbackStep `yaml:"chain" json:"chain"`
+}
+
+// FallbackStep is one provider of a fallback chain.
+type FallbackStep struct {
+	// Provider restricts the step to one provider, e.g. "codex" or an openai-compatibility
+	// name. Empty allows every provider of the step's model.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+
+	// Model is the model requested from the provider. Empty keeps the alias.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+}
+
+// DefaultFairQueueMaxWaitSeconds is how long a request waits for an upstream slot when
+// MaxWaitSeconds is not set.
+const DefaultFairQueueMaxWaitSeconds = 60
+
+// FairQueuingConfig limits the requests sent upstream at once. Beyond the limit, requests
+// wait in one queue per client API key and free slots are handed out by weighted fair
+// queuing, so a key with weight 2 is served twice as often as a key with weight 1 while
+// both are waiting. Keys with a higher priority are served before all lower-priority keys.
+type FairQueuingConfig struct {
+	// MaxConcurrent is the number of requests in flight upstream at once. <= 0 disables
+	// fair queuing.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MaxWaitSeconds is how long a request may wait for a slot before it is rejected with
+	// 503. <= 0 uses DefaultFairQueueMaxWaitSeconds.
+	MaxWaitSeconds int `yaml:"max-wait-seconds,omitempty" json:"max-wait-seconds,omitempty"`
+
+	// DefaultWeight is the share weight of keys not listed in Weights. <= 0 uses 1.
+	DefaultWeight float64 `yaml:"default-weight,omitempty" json:"default-weight,omitempty"`
+
+	// Weights sets the share weight of specific client API keys.
+	Weights map[string]float64 `yaml:"weights,omitempty" json:"weights,omitempty"`
+
+	// MaxQueueDepth caps the requests waiting for a slot. When the queue is full, a new
+	// request displaces the newest waiting request of a lower priority, or is rejected with
+	// 503 if there is none. <= 0 means no cap.
+	MaxQueueDepth int `yaml:"max-queue-depth,omitempty" json:"max-queue-depth,omitempty"`
+
+	// Priorities sets the priority of specific client API keys; other keys have priority 0.
+	// Waiting requests of a higher priority are always admitted first.
+	Priorities map[string]int `yaml:"priorities,omitempty" json:"priorities,omitempty"`
+}
+
+// LocalePolicy pins the response language for a client API key (tenant). The proxy
+// adds a system instruction naming the language and, when Verify is set, checks
+// non-streaming replies with a lightweight heuristic and re-asks once on mismatch.
+type LocalePolicy struct {
+	// Language is a language code or English name, e.g. "vi" or "Vietnamese".
+	Language string `yaml:"language" json:"language"`
+
+	// Instruction replaces the default "Always respond in <language>." system text.
+	Instruction string `yaml:"instruction,omitempty" json:"instruction,omitempty"`
+
+	// Verify enables the language check and single re-ask for non-streaming replies.
+	// Verification is skipped for languages the heuristic does not recognise.
+	Verify bool `yaml:"verify,omitempty" json:"verify,omitempty"`
+}
+
+// DefaultModerationBaseURL is the moderation backend used when no base URL is configured.
+const DefaultModerationBaseURL = "https://api.openai.com/v1"
+
+// DefaultModerationModel is the moderation model used when a request does not name one.
+const DefaultModerationModel = "omni-moderation-latest"
+
+// ModerationConfig points moderation requests at OpenAI or any service exposing an
+// OpenAI-compatible /moderations endpoint.
+type ModerationConfig struct {
+	// BaseURL is the API root; "/moderations" is appended. Empty means OpenAI.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// APIKey authenticates against the moderation backend. Moderation is disabled when empty.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Model is the default moderation model. Empty means omni-moderation-latest.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+
+	// ProxyURL overrides the global proxy-url for moderation requests.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers are extra HTTP headers sent with each moderation request.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// ResponsesStateConfig controls how Responses API conversation state survives between
+// turns. With stateless tokens, the response id returned to clients is an encrypted token
+// holding the conversation so far; clients send it back as previous_response_id and the
+// proxy restores the history without keeping any server-side storage. With a store,
+// responses are kept server-side, can be fetched with GET /v1/responses/{id} and are
+// chained through their plain ids.
+type ResponsesStateConfig struct {
+	// StatelessTokens enables encrypted resume tokens in place of response ids.
+	StatelessTokens bool `yaml:"stateless-tokens,omitempty" json:"stateless-tokens,omitempty"`
+
+	// Secret is the key material used to encrypt and authenticate resume tokens.
+	// All replicas sharing tokens must use the same secret. Required when
+	// StatelessTokens is enabled; config loading fails without it.
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+
+	// TTLSeconds bounds how long an issued token may be resumed and a stored response is
+	// kept. <= 0 means 24 hours.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// Store keeps responses of requests that do not set store:false: "memory", "file",
+	// "sqlite", or empty to disable server-side storage.
+	Store string `yaml:"store,omitempty" json:"store,omitempty"`
+
+	// StoreDir is the directory of the file store, or of the SQLite store's responses.db.
+	// Required when Store is "file" or "sqlite".
+	StoreDir string `yaml:"store-dir,omitempty" json:"store-dir,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -41,6 +271,47 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// MaxOutputBytes caps the bytes streamed to a client for a single request. Once reached,
+	// the proxy ends the stream with a length-style stop and cancels the upstream request.
+	// <= 0 disables the cap. Default is 0.
+	MaxOutputBytes int64 `yaml:"max-output-bytes,omitempty" json:"max-output-bytes,omitempty"`
+
+	// MaxOutputBytesPerKey overrides MaxOutputBytes for specific client API keys (tenants).
+	// A value <= 0 disables the cap for that key.
+	MaxOutputBytesPerKey map[string]int64 `yaml:"max-output-bytes-per-key,omitempty" json:"max-output-bytes-per-key,omitempty"`
+
+	// SanitizeOutput repairs common streaming artifacts before chunks reach the client:
+	// unterminated code fences when a stream ends abruptly and a resent first chunk. Every
+	// choice of a multi-choice stream is handled.
+	SanitizeOutput bool `yaml:"sanitize-output,omitempty" json:"sanitize-output,omitempty"`
+
+	// SanitizeOutputPerKey overrides SanitizeOutput for specific client API keys.
+	SanitizeOutputPerKey map[string]bool `yaml:"sanitize-output-per-key,omitempty" json:"sanitize-output-per-key,omitempty"`
+
+	// MaxLineBytes caps the size of a single upstream stream line (one SSE event or NDJSON
+	// record). A longer line is skipped with a warning and the stream continues.
+	// <= 0 uses the built-in limit of 50 MB.
+	MaxLineBytes int `yaml:"max-line-bytes,omitempty" json:"max-line-bytes,omitempty"`
+
+	// MaxLineBytesPerProvider overrides MaxLineBytes for specific providers.
+	MaxLineBytesPerProvider map[string]int `yaml:"max-line-bytes-per-provider,omitempty" json:"max-line-bytes-per-provider,omitempty"`
+
+	// BytePassthroughProviders lists providers whose streams are forwarded byte for byte,
+	// without splitting them into lines, when the client speaks the provider's own format.
+	// This keeps the exact upstream event framing. Only the claude provider supports it.
+	BytePassthroughProviders []string `yaml:"byte-passthrough-providers,omitempty" json:"byte-passthrough-providers,omitempty"`
+
+	// StreamOnlyProviders lists providers that only answer streaming requests. Non-streaming
+	// requests served only by these providers are streamed upstream and the events are
+	// aggregated into a single JSON response (see also the X-Stream-Aggregate header).
+	StreamOnlyProviders []string `yaml:"stream-only-providers,omitempty" json:"stream-only-providers,omitempty"`
+
+	// ArtifactDir enables the X-Artifact-Name request header: the response of such a
+	// request is also written to this directory under the given name, keeps running
+	// upstream if the client disconnects, and can be fetched via the management API.
+	// Empty disables artifacts.
+	ArtifactDir string `yaml:"artifact-dir,omitempty" json:"artifact-dir,omitempty"`
 }
 
 // AccessConfig groups request authentication providers.
@@ -27,4 +27,7 @@ const (
 
 	// Kiro represents the AWS CodeWhisperer (Kiro) provider identifier.
 	Kiro = "kiro"
+
+	// Cohere represents the Cohere chat (v2) format identifier.
+	Cohere = "cohere"
 )
@@ -0,0 +1,112 @@
+// Package expr evaluates the small expressions config rules use as conditions, such as
+//
+//	model startsWith "gpt-" && tokens > 8000 && hour >= 9 && hour < 18
+//
+// against request attributes. Expressions use the expr-lang language
+// (https://expr-lang.org) and are type-checked against the variables of RequestEnv when
+// compiled, so a misspelt variable or a comparison of mismatched types is reported when
+// the config is loaded rather than on every request.
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	exprlang "github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Program is a compiled expression. It is safe for concurrent use.
+type Program struct {
+	source  string
+	program *vm.Program
+}
+
+// String returns the source of the expression.
+func (p *Program) String() string { return p.source }
+
+// compileEnv declares the variables and their types expressions are checked against: those
+// of RequestEnv and protocol, which payload rules add.
+var compileEnv = func() map[string]any {
+	env := RequestEnv("", 0, "", time.Time{})
+	env["protocol"] = ""
+	return env
+}()
+
+// Compile parses and type-checks source into a program that yields a boolean.
+func Compile(source string) (*Program, error) {
+	program, err := exprlang.Compile(source, exprlang.Env(compileEnv), exprlang.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("expr: %w", err)
+	}
+	return &Program{source: source, program: program}, nil
+}
+
+// Eval evaluates the program with the variables of env.
+func (p *Program) Eval(env map[string]any) (any, error) {
+	v, err := exprlang.Run(p.program, env)
+	if err != nil {
+		return nil, fmt.Errorf("expr: %q: %w", p.source, err)
+	}
+	return v, nil
+}
+
+// EvalBool evaluates the program and requires a boolean result.
+func (p *Program) EvalBool(env map[string]any) (bool, error) {
+	v, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expr: %q is %T, not a boolean", p.source, v)
+	}
+	return b, nil
+}
+
+type cached struct {
+	program *Program
+	err     error
+}
+
+var cache sync.Map
+
+// Cached returns the compiled program for source, compiling it on first use. Config
+// conditions are evaluated for every request, so programs and errors are kept.
+func Cached(source string) (*Program, error) {
+	if v, ok := cache.Load(source); ok {
+		c := v.(cached)
+		return c.program, c.err
+	}
+	program, err := Compile(source)
+	cache.Store(source, cached{program: program, err: err})
+	return program, err
+}
+
+// RequestEnv returns the request attributes config conditions are evaluated against:
+// model, tokens (the estimated prompt size), tenant (the tenant key of the client API
+// key, as reported in usage statistics), hour (0-23) and weekday (0 is Sunday) in local
+// time.
+func RequestEnv(model string, tokens int, tenant string, now time.Time) map[string]any {
+	return map[string]any{
+		"model":   model,
+		"tokens":  tokens,
+		"tenant":  tenant,
+		"hour":    now.Hour(),
+		"weekday": int(now.Weekday()),
+	}
+}
+
+// Match evaluates the condition when against env. An empty condition matches.
+func Match(when string, env map[string]any) (bool, error) {
+	if strings.TrimSpace(when) == "" {
+		return true, nil
+	}
+	program, err := Cached(when)
+	if err != nil {
+		return false, err
+	}
+	return program.EvalBool(env)
+}
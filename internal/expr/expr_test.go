@@ -0,0 +1,72 @@
+package expr
+
+import "testing"
+
+func TestEvalBool(t *testing.T) {
+	env := map[string]any{
+		"model":   "gpt-5-mini",
+		"tokens":  12000,
+		"tenant":  "sk-a...b#1234",
+		"hour":    10,
+		"weekday": 3,
+	}
+	cases := []struct {
+		source string
+		want   bool
+	}{
+		{`model == "gpt-5-mini"`, true},
+		{`model startsWith "gpt-" && tokens > 8000`, true},
+		{`tokens >= 12_000 and tokens < 2 * 10000`, true},
+		{`hour >= 9 && hour < 18 && weekday in [1, 2, 3, 4, 5]`, true},
+		{`weekday not in [0, 6]`, true},
+		{`!(tenant contains "#1234")`, false},
+		{`not (model endsWith "mini") || model matches "^gpt-[0-9]+"`, true},
+		{`lower("GPT") == 'gpt' && len(model) == 10`, true},
+		{`model in ["claude-sonnet-4", "gemini-2.5-pro"]`, false},
+		{`tokens % 1000 == 0 && -tokens < 0`, true},
+	}
+	for _, tc := range cases {
+		program, err := Compile(tc.source)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tc.source, err)
+		}
+		got, err := program.EvalBool(env)
+		if err != nil {
+			t.Fatalf("EvalBool(%q): %v", tc.source, err)
+		}
+		if got != tc.want {
+			t.Errorf("EvalBool(%q) = %v, want %v", tc.source, got, tc.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	// Unknown variables and mismatched types are caught when compiling.
+	for _, source := range []string{`model ==`, `(tokens > 1`, `model matches "("`, `"open`, `foo(1)`, `tokens $ 2`,
+		`missing == 1`, `false && unknown`, `model > 1`, `tokens`} {
+		if _, err := Compile(source); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", source)
+		}
+	}
+	program, err := Compile(`tokens % 0 > 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = program.EvalBool(map[string]any{"model": "m", "tokens": 1}); err == nil {
+		t.Error("EvalBool succeeded for a division by zero, want an error")
+	}
+}
+
+func TestCached(t *testing.T) {
+	first, err := Cached(`tokens > 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := Cached(`tokens > 1`)
+	if first != second {
+		t.Error("Cached compiled the same source twice")
+	}
+	if _, err = Cached(`tokens >`); err == nil {
+		t.Error("Cached accepted an invalid expression")
+	}
+}
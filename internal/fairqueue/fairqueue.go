@@ -0,0 +1,319 @@
+// Package fairqueue shares a fixed number of upstream request slots between tenants
+// (client API keys) by weighted fair queuing, so one heavy user cannot starve the others
+// once capacity is saturated. A request of a tenant with weight w is tagged with a
+// virtual finish time 1/w after the later of the tenant's previous tag and the current
+// virtual time; free slots go to the waiting request with the smallest tag. Tenants may
+// also have a priority: waiting requests of a higher priority are always admitted first,
+// and when the queue is full they displace the newest request of a lower priority.
+package fairqueue
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrQueueTimeout is returned by Acquire when no slot became free in time.
+var ErrQueueTimeout = errors.New("fair queue: no upstream capacity became available in time")
+
+// ErrQueueFull is returned by Acquire when the queue is at its maximum depth, or when a
+// waiting request was displaced by one of a higher priority.
+var ErrQueueFull = errors.New("fair queue: too many requests are waiting for upstream capacity")
+
+// Scheduler admits requests into a limited number of slots. The zero value is not usable;
+// create one with New.
+type Scheduler struct {
+	mu       sync.Mutex
+	capacity int
+	maxQueue int
+	inUse    int
+	vtime    float64
+	seq      uint64
+	tenants  map[string]*tenant
+}
+
+// tenant is the queue and counters of one client key.
+type tenant struct {
+	label    string
+	weight   float64
+	priority int
+	finish   float64
+	queue    []*waiter
+	inFlight int
+	admitted uint64
+	rejected uint64
+	waited   time.Duration
+}
+
+// waiter is a queued request.
+type waiter struct {
+	tenant   *tenant
+	start    float64
+	finish   float64
+	seq      uint64
+	enqueued time.Time
+	ready    chan struct{}
+	admitted bool
+	evicted  bool
+}
+
+// TenantStats describes the share of one tenant.
+type TenantStats struct {
+	Tenant   string  `json:"tenant"`
+	Weight   float64 `json:"weight"`
+	Priority int     `json:"priority"`
+	InFlight int     `json:"in_flight"`
+	Queued   int     `json:"queued"`
+	Admitted uint64  `json:"admitted"`
+	// Rejected counts requests that were turned away by a full queue or gave up waiting,
+	// by timeout or cancellation.
+	Rejected  uint64  `json:"rejected"`
+	AvgWaitMS float64 `json:"avg_wait_ms"`
+	// Share is the tenant's fraction of the slots in use.
+	Share float64 `json:"share"`
+	// FairShare is the fraction the weights entitle the tenant to among the tenants with
+	// requests in flight or queued.
+	FairShare float64 `json:"fair_share"`
+}
+
+// Stats describes the scheduler at one point in time.
+type Stats struct {
+	Capacity int           `json:"capacity"`
+	MaxQueue int           `json:"max_queue"`
+	InUse    int           `json:"in_use"`
+	Queued   int           `json:"queued"`
+	Tenants  []TenantStats `json:"tenants"`
+}
+
+// New returns a scheduler with capacity slots.
+func New(capacity int) *Scheduler {
+	return &Scheduler{capacity: capacity, tenants: make(map[string]*tenant)}
+}
+
+// Default is the scheduler shared by the API handlers and the management API.
+var Default = New(0)
+
+// SetCapacity changes the number of slots. Waiting requests are admitted when it grows;
+// when it shrinks, requests in flight finish before new ones are admitted.
+func (s *Scheduler) SetCapacity(capacity int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if capacity == s.capacity {
+		return
+	}
+	s.capacity = capacity
+	s.dispatch()
+}
+
+// SetMaxQueue limits the number of waiting requests; <= 0 means no limit. Requests
+// already waiting are kept when it shrinks.
+func (s *Scheduler) SetMaxQueue(maxQueue int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxQueue = maxQueue
+}
+
+// Acquire waits for a slot for a request of the tenant identified by key, shown as label
+// in Stats, with the given weight (values <= 0 count as 1) and priority. It returns a
+// release function to call once the request is done, ErrQueueFull when the request cannot
+// wait, or ErrQueueTimeout or the context's error when ctx ends first.
+func (s *Scheduler) Acquire(ctx context.Context, key, label string, weight float64, priority int) (func(), error) {
+	if weight <= 0 {
+		weight = 1
+	}
+	s.mu.Lock()
+	t := s.tenants[key]
+	if t == nil {
+		t = &tenant{}
+		s.tenants[key] = t
+	}
+	t.label, t.weight, t.priority = label, weight, priority
+	start := s.vtime
+	if t.finish > start {
+		start = t.finish
+	}
+	s.seq++
+	w := &waiter{tenant: t, start: start, finish: start + 1/weight, seq: s.seq, enqueued: time.Now(), ready: make(chan struct{})}
+	t.finish = w.finish
+	if s.inUse < s.capacity && s.queued() == 0 {
+		s.admit(w)
+		s.mu.Unlock()
+		return s.releaser(t), nil
+	}
+	if s.maxQueue > 0 && s.queued() >= s.maxQueue {
+		victim := s.displaceable(priority)
+		if victim == nil {
+			s.dequeue(w)
+			t.rejected++
+			s.mu.Unlock()
+			return nil, ErrQueueFull
+		}
+		s.dequeue(victim)
+		victim.evicted = true
+		victim.tenant.rejected++
+		close(victim.ready)
+	}
+	t.queue = append(t.queue, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		if w.evicted {
+			return nil, ErrQueueFull
+		}
+		return s.releaser(t), nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.evicted {
+		return nil, ErrQueueFull
+	}
+	if w.admitted {
+		// The slot was granted while the context ended; hand it on.
+		s.inUse--
+		t.inFlight--
+		s.dispatch()
+	} else {
+		s.dequeue(w)
+	}
+	t.rejected++
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, ErrQueueTimeout
+	}
+	return nil, ctx.Err()
+}
+
+// Stats returns the per-tenant shares, busiest tenants first.
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := Stats{Capacity: s.capacity, MaxQueue: s.maxQueue, InUse: s.inUse, Queued: s.queued()}
+	activeWeight := 0.0
+	for _, t := range s.tenants {
+		if t.inFlight > 0 || len(t.queue) > 0 {
+			activeWeight += t.weight
+		}
+	}
+	for _, t := range s.tenants {
+		entry := TenantStats{
+			Tenant:   t.label,
+			Weight:   t.weight,
+			Priority: t.priority,
+			InFlight: t.inFlight,
+			Queued:   len(t.queue),
+			Admitted: t.admitted,
+			Rejected: t.rejected,
+		}
+		if t.admitted > 0 {
+			entry.AvgWaitMS = float64(t.waited.Milliseconds()) / float64(t.admitted)
+		}
+		if s.inUse > 0 {
+			entry.Share = float64(t.inFlight) / float64(s.inUse)
+		}
+		if activeWeight > 0 && (t.inFlight > 0 || len(t.queue) > 0) {
+			entry.FairShare = t.weight / activeWeight
+		}
+		stats.Tenants = append(stats.Tenants, entry)
+	}
+	sort.Slice(stats.Tenants, func(i, j int) bool {
+		a, b := stats.Tenants[i], stats.Tenants[j]
+		if a.InFlight+a.Queued != b.InFlight+b.Queued {
+			return a.InFlight+a.Queued > b.InFlight+b.Queued
+		}
+		return a.Tenant < b.Tenant
+	})
+	return stats
+}
+
+// releaser returns the function that frees the slot of a request of t, once.
+func (s *Scheduler) releaser(t *tenant) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.inUse--
+			t.inFlight--
+			s.dispatch()
+			s.mu.Unlock()
+		})
+	}
+}
+
+// admit gives w a slot. s.mu must be held.
+func (s *Scheduler) admit(w *waiter) {
+	s.inUse++
+	s.vtime = w.start
+	w.admitted = true
+	w.tenant.inFlight++
+	w.tenant.admitted++
+	w.tenant.waited += time.Since(w.enqueued)
+	close(w.ready)
+}
+
+// dequeue removes w from its tenant's queue, if it is there. s.mu must be held.
+func (s *Scheduler) dequeue(w *waiter) {
+	t := w.tenant
+	for i, queued := range t.queue {
+		if queued == w {
+			t.queue = append(t.queue[:i], t.queue[i+1:]...)
+			break
+		}
+	}
+	if t.finish == w.finish {
+		// The request was the tenant's last; leaving should not cost the tenant.
+		t.finish = w.start
+	}
+}
+
+// displaceable returns the newest waiting request of the lowest priority below priority,
+// or nil. s.mu must be held.
+func (s *Scheduler) displaceable(priority int) *waiter {
+	var victim *waiter
+	for _, t := range s.tenants {
+		if len(t.queue) == 0 || t.priority >= priority {
+			continue
+		}
+		last := t.queue[len(t.queue)-1]
+		if victim == nil || t.priority < victim.tenant.priority ||
+			t.priority == victim.tenant.priority && last.seq > victim.seq {
+			victim = last
+		}
+	}
+	return victim
+}
+
+// dispatch admits queued requests, highest priority and then smallest finish tag first,
+// while slots are free. s.mu must be held.
+func (s *Scheduler) dispatch() {
+	for s.inUse < s.capacity {
+		var next *tenant
+		for _, t := range s.tenants {
+			if len(t.queue) == 0 {
+				continue
+			}
+			if next == nil || t.priority > next.priority || t.priority == next.priority &&
+				(t.queue[0].finish < next.queue[0].finish ||
+					t.queue[0].finish == next.queue[0].finish && t.queue[0].seq < next.queue[0].seq) {
+				next = t
+			}
+		}
+		if next == nil {
+			return
+		}
+		w := next.queue[0]
+		next.queue = next.queue[1:]
+		s.admit(w)
+	}
+}
+
+// queued counts the waiting requests. s.mu must be held.
+func (s *Scheduler) queued() int {
+	n := 0
+	for _, t := range s.tenants {
+		n += len(t.queue)
+	}
+	return n
+}
@@ -0,0 +1,142 @@
+package fairqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type admission struct {
+	tenant  string
+	release func()
+}
+
+func TestSchedulerWeightedOrder(t *testing.T) {
+	s := New(1)
+	holder, err := s.Acquire(context.Background(), "holder", "holder", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := make(chan admission)
+	enqueue := func(tenant string, weight float64) {
+		want := s.Stats().Queued + 1
+		go func() {
+			release, errAcquire := s.Acquire(context.Background(), tenant, tenant, weight, 0)
+			if errAcquire != nil {
+				t.Error(errAcquire)
+				return
+			}
+			admitted <- admission{tenant: tenant, release: release}
+		}()
+		for s.Stats().Queued != want {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		enqueue("heavy", 1)
+	}
+	for i := 0; i < 4; i++ {
+		enqueue("light", 3)
+	}
+
+	holder()
+	var order []string
+	for i := 0; i < 8; i++ {
+		next := <-admitted
+		order = append(order, next.tenant)
+		next.release()
+	}
+	light := 0
+	for _, tenant := range order[:4] {
+		if tenant == "light" {
+			light++
+		}
+	}
+	if light != 3 {
+		t.Fatalf("order = %v, want the weight-3 tenant to get 3 of the first 4 slots", order)
+	}
+	if stats := s.Stats(); stats.InUse != 0 || stats.Queued != 0 {
+		t.Fatalf("stats after draining = %+v", stats)
+	}
+}
+
+func TestSchedulerTimeout(t *testing.T) {
+	s := New(1)
+	release, err := s.Acquire(context.Background(), "a", "a", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = s.Acquire(ctx, "b", "b", 1, 0); !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("err = %v, want ErrQueueTimeout", err)
+	}
+	stats := s.Stats()
+	if stats.Queued != 0 || stats.InUse != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	for _, tenant := range stats.Tenants {
+		if tenant.Tenant == "b" && tenant.Rejected != 1 {
+			t.Fatalf("b = %+v, want one rejection", tenant)
+		}
+	}
+	release()
+	release()
+	if got := s.Stats().InUse; got != 0 {
+		t.Fatalf("in use = %d after a double release", got)
+	}
+}
+
+func TestSchedulerPriorityAndDepth(t *testing.T) {
+	s := New(1)
+	s.SetMaxQueue(2)
+	holder, err := s.Acquire(context.Background(), "holder", "holder", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := make(chan admission, 4)
+	enqueue := func(tenant string, priority int) {
+		want := s.Stats().Queued + 1
+		go func() {
+			release, errAcquire := s.Acquire(context.Background(), tenant, tenant, 1, priority)
+			if errAcquire != nil {
+				results <- admission{tenant: tenant + ":" + errAcquire.Error()}
+				return
+			}
+			results <- admission{tenant: tenant, release: release}
+		}()
+		for s.Stats().Queued != want {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	enqueue("low-1", 0)
+	enqueue("low-2", 0)
+	if _, err = s.Acquire(context.Background(), "low-3", "low-3", 1, 0); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("err = %v, want ErrQueueFull for a full queue", err)
+	}
+
+	// A high-priority request displaces the newest low-priority waiter.
+	go func() {
+		release, errAcquire := s.Acquire(context.Background(), "high", "high", 1, 10)
+		if errAcquire != nil {
+			t.Error(errAcquire)
+			return
+		}
+		results <- admission{tenant: "high", release: release}
+	}()
+	if evicted := <-results; evicted.tenant != "low-2:"+ErrQueueFull.Error() {
+		t.Fatalf("first result = %q, want low-2 displaced", evicted.tenant)
+	}
+
+	holder()
+	for _, want := range []string{"high", "low-1"} {
+		next := <-results
+		if next.tenant != want {
+			t.Fatalf("admitted %q, want %q", next.tenant, want)
+		}
+		next.release()
+	}
+}
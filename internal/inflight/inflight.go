@@ -0,0 +1,204 @@
+// Package inflight tracks the client requests the proxy is currently serving, so
+// operators can see what is queued or streaming on which credential and cancel a request
+// that is stuck, e.g. a stream pinning a rate-limited credential.
+package inflight
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Request is one tracked client request. Its fields are filled in as the request
+// progresses: the model once the handler parsed it, the credential once an executor
+// sent it upstream.
+type Request struct {
+	id       string
+	handler  string
+	client   string
+	tenant   string
+	started  time.Time
+	cancel   context.CancelFunc
+	bytes    atomic.Int64
+	mu       sync.Mutex
+	model    string
+	provider string
+	authID   string
+}
+
+// Snapshot describes a tracked request at one point in time.
+type Snapshot struct {
+	ID         string    `json:"id"`
+	Handler    string    `json:"handler,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	Provider   string    `json:"provider,omitempty"`
+	AuthID     string    `json:"auth_id,omitempty"`
+	Client     string    `json:"client,omitempty"`
+	Tenant     string    `json:"tenant,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	AgeSeconds float64   `json:"age_seconds"`
+	// BytesStreamed counts the response bytes received from upstream so far.
+	BytesStreamed int64 `json:"bytes_streamed"`
+	// Queued reports a request that has not reached an upstream provider yet.
+	Queued bool `json:"queued"`
+}
+
+// Summary aggregates the tracked requests, for watching a queue drain.
+type Summary struct {
+	Total            int            `json:"total"`
+	Queued           int            `json:"queued"`
+	Streaming        int            `json:"streaming"`
+	OldestAgeSeconds float64        `json:"oldest_age_seconds"`
+	ByModel          map[string]int `json:"by_model"`
+	ByAuth           map[string]int `json:"by_auth"`
+}
+
+type contextKey struct{}
+
+var (
+	mu       sync.Mutex
+	requests = make(map[string]*Request)
+)
+
+// Start tracks a request until ctx is done. cancel must cancel ctx; Cancel uses it to stop
+// the request. The returned context carries the request for SetModel, SetCredential and
+// AddBytes.
+func Start(ctx context.Context, cancel context.CancelFunc, id, handler, client, tenant string) (context.Context, *Request) {
+	r := &Request{id: id, handler: handler, client: client, tenant: tenant, started: time.Now(), cancel: cancel}
+	mu.Lock()
+	requests[id] = r
+	mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		mu.Lock()
+		if requests[id] == r {
+			delete(requests, id)
+		}
+		mu.Unlock()
+	}()
+	return context.WithValue(ctx, contextKey{}, r), r
+}
+
+// FromContext returns the request tracked for ctx, or nil.
+func FromContext(ctx context.Context) *Request {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(contextKey{}).(*Request)
+	return r
+}
+
+// SetModel records the requested model.
+func (r *Request) SetModel(model string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.model = model
+	r.mu.Unlock()
+}
+
+// SetCredential records the credential the request was sent upstream with. Retries on
+// another credential overwrite it.
+func (r *Request) SetCredential(provider, authID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.provider, r.authID = provider, authID
+	r.mu.Unlock()
+}
+
+// Provider returns the provider of the last credential the request was sent upstream
+// with, or "" while it is queued.
+func (r *Request) Provider() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.provider
+}
+
+// Tenant returns the tenant key of the client API key the request was made with.
+func (r *Request) Tenant() string {
+	if r == nil {
+		return ""
+	}
+	return r.tenant
+}
+
+// AddBytes adds n response bytes received from upstream.
+func (r *Request) AddBytes(n int64) {
+	if r == nil {
+		return
+	}
+	r.bytes.Add(n)
+}
+
+func (r *Request) snapshot(now time.Time) Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Snapshot{
+		ID:            r.id,
+		Handler:       r.handler,
+		Model:         r.model,
+		Provider:      r.provider,
+		AuthID:        r.authID,
+		Client:        r.client,
+		Tenant:        r.tenant,
+		StartedAt:     r.started,
+		AgeSeconds:    now.Sub(r.started).Seconds(),
+		BytesStreamed: r.bytes.Load(),
+		Queued:        r.authID == "",
+	}
+}
+
+// List returns the tracked requests, oldest first.
+func List() []Snapshot {
+	now := time.Now()
+	mu.Lock()
+	out := make([]Snapshot, 0, len(requests))
+	for _, r := range requests {
+		out = append(out, r.snapshot(now))
+	}
+	mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+// Summarize aggregates snapshots as returned by List.
+func Summarize(snapshots []Snapshot) Summary {
+	summary := Summary{Total: len(snapshots), ByModel: make(map[string]int), ByAuth: make(map[string]int)}
+	for _, s := range snapshots {
+		if s.Queued {
+			summary.Queued++
+		} else {
+			summary.ByAuth[s.AuthID]++
+		}
+		if s.BytesStreamed > 0 {
+			summary.Streaming++
+		}
+		if s.Model != "" {
+			summary.ByModel[s.Model]++
+		}
+		if s.AgeSeconds > summary.OldestAgeSeconds {
+			summary.OldestAgeSeconds = s.AgeSeconds
+		}
+	}
+	return summary
+}
+
+// Cancel cancels the tracked request id and reports whether it was found.
+func Cancel(id string) bool {
+	mu.Lock()
+	r := requests[id]
+	mu.Unlock()
+	if r == nil {
+		return false
+	}
+	r.cancel()
+	return true
+}
@@ -0,0 +1,48 @@
+package inflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartListCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, r := Start(ctx, cancel, "req-1", "openai", "127.0.0.1", "sk-***#abcd")
+	if FromContext(ctx) != r {
+		t.Fatal("context must carry the request")
+	}
+	FromContext(ctx).SetModel("claude-sonnet-4")
+
+	summary := Summarize(List())
+	if summary.Total != 1 || summary.Queued != 1 || summary.ByModel["claude-sonnet-4"] != 1 {
+		t.Fatalf("queued summary = %+v", summary)
+	}
+
+	r.SetCredential("claude", "auth-1")
+	r.AddBytes(42)
+	list := List()
+	if len(list) != 1 || list[0].AuthID != "auth-1" || list[0].BytesStreamed != 42 || list[0].Queued {
+		t.Fatalf("list = %+v", list)
+	}
+	if summary = Summarize(list); summary.Streaming != 1 || summary.ByAuth["auth-1"] != 1 {
+		t.Fatalf("streaming summary = %+v", summary)
+	}
+
+	if Cancel("missing") {
+		t.Fatal("unknown ids must not be found")
+	}
+	if !Cancel("req-1") {
+		t.Fatal("Cancel must find the request")
+	}
+	if ctx.Err() == nil {
+		t.Fatal("Cancel must cancel the request context")
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(List()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(List()) != 0 {
+		t.Fatal("finished requests must be removed")
+	}
+}
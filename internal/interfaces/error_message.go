@@ -18,3 +18,16 @@ type ErrorMessage struct {
 	// Addon contains additional headers to be added to the response.
 	Addon http.Header
 }
+
+// UpstreamErrorContextKey is the Gin context key holding the last UpstreamError seen
+// for the current request.
+const UpstreamErrorContextKey = "UPSTREAM_ERROR"
+
+// UpstreamError captures a provider's raw error response before translation.
+type UpstreamError struct {
+	// StatusCode is the HTTP status returned by the provider.
+	StatusCode int
+
+	// Body is the (possibly truncated) untranslated response body.
+	Body []byte
+}
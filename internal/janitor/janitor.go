@@ -0,0 +1,156 @@
+// Package janitor runs the periodic sweeps that reclaim expired or orphaned in-process
+// and on-disk state: caches, session mappings, partial artifacts, old request logs and
+// quota counters. Packages owning such state register a sweep; the service runs them on
+// the schedule from the janitor config section and reports what each reclaimed.
+package janitor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultInterval is the sweep interval of tasks registered without their own default.
+const DefaultInterval = 5 * time.Minute
+
+// tickInterval is how often Run checks which tasks are due.
+const tickInterval = 30 * time.Second
+
+// Sweep removes expired state as of now and returns how many items it reclaimed.
+type Sweep func(cfg *config.Config, now time.Time) (int, error)
+
+// TaskStats reports the activity of one registered sweep.
+type TaskStats struct {
+	Name            string    `json:"name"`
+	IntervalSeconds int64     `json:"interval_seconds"`
+	Runs            int64     `json:"runs"`
+	Reclaimed       int64     `json:"reclaimed"`
+	LastRun         time.Time `json:"last_run,omitempty"`
+	LastReclaimed   int       `json:"last_reclaimed"`
+	LastError       string    `json:"last_error,omitempty"`
+}
+
+type task struct {
+	name     string
+	interval time.Duration
+	sweep    Sweep
+	stats    TaskStats
+}
+
+var (
+	mu    sync.Mutex
+	tasks = make(map[string]*task)
+)
+
+// Register adds a sweep under name, replacing any earlier one. defaultInterval applies
+// unless the config sets a schedule; <= 0 uses DefaultInterval.
+func Register(name string, defaultInterval time.Duration, sweep Sweep) {
+	if name == "" || sweep == nil {
+		return
+	}
+	mu.Lock()
+	tasks[name] = &task{name: name, interval: defaultInterval, sweep: sweep}
+	mu.Unlock()
+}
+
+// Unregister removes the sweep registered under name.
+func Unregister(name string) {
+	mu.Lock()
+	delete(tasks, name)
+	mu.Unlock()
+}
+
+// intervalFor resolves the schedule of t under cfg: the per-task interval, then the
+// global one, then the task default. A negative per-task interval disables the task.
+func intervalFor(t *task, cfg *config.Config) time.Duration {
+	var settings config.JanitorConfig
+	if cfg != nil {
+		settings = cfg.Janitor
+	}
+	if seconds, ok := settings.TaskIntervals[t.name]; ok {
+		if seconds < 0 {
+			return 0
+		}
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	if settings.IntervalSeconds > 0 {
+		return time.Duration(settings.IntervalSeconds) * time.Second
+	}
+	if t.interval > 0 {
+		return t.interval
+	}
+	return DefaultInterval
+}
+
+// RunDue runs every enabled task whose interval has elapsed since its last run and
+// returns the total number of reclaimed items.
+func RunDue(cfg *config.Config, now time.Time) int {
+	mu.Lock()
+	var due []*task
+	for _, t := range tasks {
+		interval := intervalFor(t, cfg)
+		t.stats.IntervalSeconds = int64(interval / time.Second)
+		if interval <= 0 || (!t.stats.LastRun.IsZero() && now.Sub(t.stats.LastRun) < interval) {
+			continue
+		}
+		due = append(due, t)
+	}
+	mu.Unlock()
+
+	total := 0
+	for _, t := range due {
+		reclaimed, err := t.sweep(cfg, now)
+		mu.Lock()
+		t.stats.Runs++
+		t.stats.LastRun = now
+		t.stats.LastReclaimed = reclaimed
+		t.stats.Reclaimed += int64(reclaimed)
+		t.stats.LastError = ""
+		if err != nil {
+			t.stats.LastError = err.Error()
+		}
+		mu.Unlock()
+		if err != nil {
+			log.Warnf("janitor: %s: %v", t.name, err)
+		}
+		if reclaimed > 0 {
+			log.Debugf("janitor: %s reclaimed %d item(s)", t.name, reclaimed)
+		}
+		total += reclaimed
+	}
+	return total
+}
+
+// Run sweeps due tasks until ctx is done, reading the config through cfg on every tick.
+func Run(ctx context.Context, cfg func() *config.Config) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			RunDue(cfg(), now)
+		}
+	}
+}
+
+// Stats returns the activity of every registered task, sorted by name.
+func Stats() []TaskStats {
+	mu.Lock()
+	out := make([]TaskStats, 0, len(tasks))
+	for _, t := range tasks {
+		stats := t.stats
+		stats.Name = t.name
+		out = append(out, stats)
+	}
+	mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,50 @@
+package janitor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestRunDue_FollowsScheduleAndRecordsStats(t *testing.T) {
+	runs := 0
+	Register("test-sweep", time.Minute, func(_ *config.Config, _ time.Time) (int, error) {
+		runs++
+		return 3, nil
+	})
+	Register("test-failing", time.Minute, func(_ *config.Config, _ time.Time) (int, error) {
+		return 0, errors.New("disk gone")
+	})
+	t.Cleanup(func() {
+		Unregister("test-sweep")
+		Unregister("test-failing")
+	})
+
+	cfg := &config.Config{}
+	start := time.Unix(1_700_000_000, 0)
+	RunDue(cfg, start)
+	RunDue(cfg, start.Add(30*time.Second))
+	RunDue(cfg, start.Add(time.Minute))
+	if runs != 2 {
+		t.Fatalf("runs = %d, want 2 with a one-minute schedule", runs)
+	}
+
+	cfg.Janitor.TaskIntervals = map[string]int{"test-sweep": -1}
+	RunDue(cfg, start.Add(time.Hour))
+	if runs != 2 {
+		t.Fatal("a negative task interval must disable the sweep")
+	}
+
+	stats := map[string]TaskStats{}
+	for _, s := range Stats() {
+		stats[s.Name] = s
+	}
+	if s := stats["test-sweep"]; s.Runs != 2 || s.Reclaimed != 6 || s.LastReclaimed != 3 {
+		t.Fatalf("test-sweep stats = %+v", s)
+	}
+	if s := stats["test-failing"]; s.LastError != "disk gone" || s.Runs != 3 {
+		t.Fatalf("test-failing stats = %+v", s)
+	}
+}
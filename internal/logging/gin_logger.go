@@ -19,9 +19,18 @@ import (
 var aiAPIPrefixes = []string{
 	"/v1/chat/completions",
 	"/v1/completions",
+	"/v1/embeddings",
+	"/v1/rerank",
+	"/v1/moderations",
 	"/v1/messages",
 	"/v1/responses",
+	"/v1/jobs",
 	"/v1beta/models/",
+	"/v1internal:",
+	"/v2/chat",
+	"/model/",
+	"/api/chat",
+	"/api/generate",
 	"/api/provider/",
 }
 
@@ -42,11 +51,16 @@ func GinLogrusLogger() gin.HandlerFunc {
 		path := c.Request.URL.Path
 		raw := util.MaskSensitiveQuery(c.Request.URL.RawQuery)
 
-		// Only generate request ID for AI API paths
+		// Only track request IDs for AI API paths. A valid ID sent by the client is kept so
+		// its logs can be matched with ours.
 		var requestID string
 		if isAIAPIPath(path) {
-			requestID = GenerateRequestID()
+			requestID = ClientRequestID(c.GetHeader(RequestIDHeader))
+			if requestID == "" {
+				requestID = GenerateRequestID()
+			}
 			SetGinRequestID(c, requestID)
+			c.Header(RequestIDHeader, requestID)
 			ctx := WithRequestID(c.Request.Context(), requestID)
 			c.Request = c.Request.WithContext(ctx)
 		}
@@ -44,6 +44,9 @@ func (m *LogFormatter) Format(entry *log.Entry) ([]byte, error) {
 	reqID := "--------"
 	if id, ok := entry.Data["request_id"].(string); ok && id != "" {
 		reqID = id
+	} else if id = GetRequestID(entry.Context); id != "" {
+		// Entries logged with log.WithContext(ctx) carry the ID of the request behind ctx.
+		reqID = id
 	}
 
 	level := entry.Level.String()
@@ -467,6 +467,35 @@ func (l *FileRequestLogger) cleanupOldErrorLogs() error {
 	return nil
 }
 
+// RemoveLogsOlderThan deletes request and error logs last written before cutoff and
+// returns how many it removed. The application log (main.log and its rotated backups)
+// is left to the log directory size limit.
+func (l *FileRequestLogger) RemoveLogsOlderThan(cutoff time.Time) (int, error) {
+	entries, errRead := os.ReadDir(l.logsDir)
+	if errRead != nil {
+		if os.IsNotExist(errRead) {
+			return 0, nil
+		}
+		return 0, errRead
+	}
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".log") || name == "main.log" || strings.HasPrefix(name, "main-") {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if errRemove := os.Remove(filepath.Join(l.logsDir, name)); errRemove != nil && !os.IsNotExist(errRemove) {
+			return removed, errRemove
+		}
+		removed++
+	}
+	return removed, nil
+}
+
 func (l *FileRequestLogger) writeRequestBodyTempFile(body []byte) (string, error) {
 	tmpFile, errCreate := os.CreateTemp(l.logsDir, "request-body-*.tmp")
 	if errCreate != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +15,32 @@ type requestIDKey struct{}
 // ginRequestIDKey is the Gin context key for request IDs.
 const ginRequestIDKey = "__request_id__"
 
+// RequestIDHeader carries the request ID: clients may send one, responses always carry
+// it, and upstreams that are safe to tell receive it.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds client-provided request IDs, which also name log files.
+const maxRequestIDLength = 64
+
+// ClientRequestID returns the request ID a client sent, or "" when it is absent or unsafe
+// to use: IDs longer than 64 characters or with characters other than letters, digits
+// and "-_." are ignored.
+func ClientRequestID(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || len(value) > maxRequestIDLength {
+		return ""
+	}
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return ""
+		}
+	}
+	return value
+}
+
 // GenerateRequestID creates a new 8-character hex request ID.
 func GenerateRequestID() string {
 	b := make([]byte, 4)
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientRequestID(t *testing.T) {
+	cases := map[string]string{
+		"  trace-01.A_b ":       "trace-01.A_b",
+		"":                      "",
+		"../../etc/passwd":      "",
+		"id with space":         "",
+		strings.Repeat("a", 65): "",
+	}
+	for in, want := range cases {
+		if got := ClientRequestID(in); got != want {
+			t.Errorf("ClientRequestID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGinLogrusLogger_PropagatesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(GinLogrusLogger())
+	var seen string
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		seen = GetRequestID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set(RequestIDHeader, "client-trace-1")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	if seen != "client-trace-1" || recorder.Header().Get(RequestIDHeader) != "client-trace-1" {
+		t.Fatalf("client id: context %q, header %q", seen, recorder.Header().Get(RequestIDHeader))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set(RequestIDHeader, "bad/id")
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	if len(seen) != 8 || seen == "bad/id" || recorder.Header().Get(RequestIDHeader) != seen {
+		t.Fatalf("generated id: context %q, header %q", seen, recorder.Header().Get(RequestIDHeader))
+	}
+}
@@ -0,0 +1,45 @@
+package misc
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ParseDataURL splits a base64 data URL such as "data:image/png;base64,iVBOR..." into its
+// MIME type and payload. It reports false for anything else, including remote URLs.
+func ParseDataURL(dataURL string) (mimeType, data string, ok bool) {
+	rest, found := strings.CutPrefix(dataURL, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(rest, ",")
+	if !found || data == "" {
+		return "", "", false
+	}
+	mimeType, _, _ = strings.Cut(meta, ";")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return mimeType, data, true
+}
+
+// IsRemoteURL reports whether rawURL is an http or https URL.
+func IsRemoteURL(rawURL string) bool {
+	lower := strings.ToLower(rawURL)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// MimeTypeFromURL guesses the MIME type of a remote file from the extension of its path,
+// falling back to fallback when the extension is missing or unknown.
+func MimeTypeFromURL(rawURL, fallback string) string {
+	p := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		p = parsed.Path
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(p), "."))
+	if mimeType, ok := MimeTypes[ext]; ok {
+		return mimeType
+	}
+	return fallback
+}
@@ -0,0 +1,189 @@
+// Package moderation forwards content moderation requests to OpenAI or an
+// OpenAI-compatible alternative configured under the "moderation" config block.
+// The same client backs the /v1/moderations endpoint and in-process checks such as
+// guardrails, and reports its token usage under a separate usage category.
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ErrNotConfigured is returned when no moderation API key is configured.
+var ErrNotConfigured = errors.New("moderation backend is not configured")
+
+// StatusError reports a non-2xx response from the moderation backend.
+type StatusError struct {
+	Code int
+	Body []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("moderation backend returned status %d: %s", e.Code, strings.TrimSpace(string(e.Body)))
+}
+
+// Result is the verdict for a single moderation input.
+type Result struct {
+	Flagged    bool
+	Categories []string
+}
+
+// Client sends requests to the configured moderation backend.
+type Client struct {
+	cfg        *config.SDKConfig
+	httpClient *http.Client
+}
+
+// NewClient builds a client for cfg.Moderation, honouring its proxy-url or the global one.
+func NewClient(cfg *config.SDKConfig) *Client {
+	httpClient := &http.Client{Timeout: 60 * time.Second}
+	if cfg != nil {
+		proxyCfg := *cfg
+		if proxyURL := strings.TrimSpace(cfg.Moderation.ProxyURL); proxyURL != "" {
+			proxyCfg.ProxyURL = proxyURL
+		}
+		if proxyCfg.ProxyURL != "" {
+			httpClient = util.SetProxy(&proxyCfg, httpClient)
+		}
+	}
+	return &Client{cfg: cfg, httpClient: httpClient}
+}
+
+// Enabled reports whether a moderation backend is configured.
+func (c *Client) Enabled() bool {
+	return c != nil && c.cfg != nil && strings.TrimSpace(c.cfg.Moderation.APIKey) != ""
+}
+
+// Moderate forwards an OpenAI moderation request body and returns the raw response body.
+// The model defaults to the configured one when the payload does not name a model.
+func (c *Client) Moderate(ctx context.Context, payload []byte) ([]byte, error) {
+	if !c.Enabled() {
+		return nil, ErrNotConfigured
+	}
+	mod := c.cfg.Moderation
+	model := gjson.GetBytes(payload, "model").String()
+	if model == "" {
+		model = strings.TrimSpace(mod.Model)
+		if model == "" {
+			model = config.DefaultModerationModel
+		}
+		payload, _ = sjson.SetBytes(payload, "model", model)
+	}
+	baseURL := strings.TrimSuffix(strings.TrimSpace(mod.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = config.DefaultModerationBaseURL
+	}
+
+	record := coreusage.Record{
+		Provider:    "moderation",
+		Model:       model,
+		APIKey:      apiKeyFromContext(ctx),
+		RequestedAt: time.Now(),
+		Category:    coreusage.CategoryModeration,
+		RequestID:   logging.GetRequestID(ctx),
+	}
+	body, err := c.post(ctx, baseURL+"/moderations", payload)
+	record.Latency = time.Since(record.RequestedAt)
+	if err != nil {
+		record.Failed = true
+		coreusage.PublishRecord(ctx, record)
+		return nil, err
+	}
+	// Moderation responses rarely carry usage; fall back to zero tokens so requests still count.
+	if tokens := gjson.GetBytes(body, "usage.total_tokens").Int(); tokens > 0 {
+		record.Detail = coreusage.Detail{InputTokens: gjson.GetBytes(body, "usage.prompt_tokens").Int(), TotalTokens: tokens}
+	}
+	coreusage.PublishRecord(ctx, record)
+	return body, nil
+}
+
+// Check moderates a single text input and returns the verdict. It is intended for
+// in-process callers such as guardrails that need a decision rather than the raw response.
+func (c *Client) Check(ctx context.Context, input string) (Result, error) {
+	payload, _ := sjson.SetBytes([]byte(`{}`), "input", input)
+	body, err := c.Moderate(ctx, payload)
+	if err != nil {
+		return Result{}, err
+	}
+	return ParseResult(body), nil
+}
+
+// ParseResult folds every result in a moderation response into one verdict.
+func ParseResult(body []byte) Result {
+	var result Result
+	seen := make(map[string]struct{})
+	gjson.GetBytes(body, "results").ForEach(func(_, item gjson.Result) bool {
+		if item.Get("flagged").Bool() {
+			result.Flagged = true
+		}
+		item.Get("categories").ForEach(func(key, value gjson.Result) bool {
+			if value.Bool() {
+				if _, ok := seen[key.String()]; !ok {
+					seen[key.String()] = struct{}{}
+					result.Categories = append(result.Categories, key.String())
+				}
+			}
+			return true
+		})
+		return true
+	})
+	return result
+}
+
+func (c *Client) post(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(c.cfg.Moderation.APIKey))
+	for key, value := range c.cfg.Moderation.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("moderation: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusError{Code: resp.StatusCode, Body: body}
+	}
+	return body, nil
+}
+
+func apiKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	if v, exists := ginCtx.Get("apiKey"); exists {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
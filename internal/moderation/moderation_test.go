@@ -0,0 +1,57 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestClientCheck(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"id":"modr-1","results":[{"flagged":true,"categories":{"violence":true,"harassment":false}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.SDKConfig{Moderation: config.ModerationConfig{BaseURL: server.URL + "/", APIKey: "sk-test"}})
+	result, err := client.Check(context.Background(), "some text")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if gotPath != "/moderations" || gotAuth != "Bearer sk-test" {
+		t.Fatalf("path=%q auth=%q", gotPath, gotAuth)
+	}
+	if got := gjson.GetBytes(gotBody, "model").String(); got != config.DefaultModerationModel {
+		t.Fatalf("model = %q", got)
+	}
+	if !result.Flagged || len(result.Categories) != 1 || result.Categories[0] != "violence" {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	if _, err := NewClient(&config.SDKConfig{}).Moderate(context.Background(), []byte(`{"input":"x"}`)); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"slow down"}}`))
+	}))
+	defer server.Close()
+	client := NewClient(&config.SDKConfig{Moderation: config.ModerationConfig{BaseURL: server.URL, APIKey: "k"}})
+	_, err := client.Moderate(context.Background(), []byte(`{"input":"x","model":"text-moderation-stable"}`))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status error, got %v", err)
+	}
+}
@@ -192,6 +192,20 @@ func GetGeminiModels() []*ModelInfo {
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
 		},
+		{
+			ID:                         "gemini-embedding-001",
+			Object:                     "model",
+			Created:                    1752624000,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/gemini-embedding-001",
+			Version:                    "001",
+			DisplayName:                "Gemini Embedding 001",
+			Description:                "Text embedding model served through /v1/embeddings",
+			InputTokenLimit:            2048,
+			OutputTokenLimit:           1,
+			SupportedGenerationMethods: []string{"embedContent", "countTextTokens", "countTokens"},
+		},
 	}
 }
 
@@ -797,6 +811,13 @@ func LookupStaticModelInfo(modelID string) *ModelInfo {
 		GetOpenAIModels(),
 		GetQwenModels(),
 		GetIFlowModels(),
+		GetXAIModels(),
+		GetCohereModels(),
+		GetFireworksModels(),
+		GetNVIDIAModels(),
+		GetMoonshotModels(),
+		GetZhipuModels(),
+		GetCerebrasModels(),
 	}
 	for _, models := range allModels {
 		for _, m := range models {
@@ -1168,3 +1189,541 @@ func GetAmazonQModels() []*ModelInfo {
 		},
 	}
 }
+
+// GetXAIModels returns the xAI Grok model definitions served from api.x.ai.
+func GetXAIModels() []*ModelInfo {
+	now := int64(1752019200) // 2025-07-09
+	return []*ModelInfo{
+		{
+			ID:                  "grok-4",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "xai",
+			Type:                "xai",
+			Version:             "grok-4-0709",
+			DisplayName:         "Grok 4",
+			Description:         "xAI flagship reasoning model with image understanding",
+			ContextLength:       256000,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "grok-4-fast-reasoning",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "xai",
+			Type:                "xai",
+			DisplayName:         "Grok 4 Fast (Reasoning)",
+			Description:         "Cost-efficient Grok 4 variant with reasoning and image understanding",
+			ContextLength:       2000000,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "grok-4-fast-non-reasoning",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "xai",
+			Type:                "xai",
+			DisplayName:         "Grok 4 Fast (Non-Reasoning)",
+			Description:         "Cost-efficient Grok 4 variant without reasoning",
+			ContextLength:       2000000,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "grok-code-fast-1",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "xai",
+			Type:                "xai",
+			DisplayName:         "Grok Code Fast 1",
+			Description:         "Fast reasoning model tuned for agentic coding",
+			ContextLength:       256000,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "grok-3",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "xai",
+			Type:                "xai",
+			DisplayName:         "Grok 3",
+			Description:         "xAI general purpose model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "grok-3-mini",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "xai",
+			Type:                "xai",
+			DisplayName:         "Grok 3 Mini",
+			Description:         "Lightweight reasoning model with adjustable reasoning effort",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+			Thinking:            &ThinkingSupport{Levels: []string{"low", "high"}},
+		},
+		{
+			ID:            "grok-2-vision-1212",
+			Object:        "model",
+			Created:       now,
+			OwnedBy:       "xai",
+			Type:          "xai",
+			DisplayName:   "Grok 2 Vision",
+			Description:   "xAI image understanding model",
+			ContextLength: 32768,
+		},
+	}
+}
+
+// GetCohereModels returns the Cohere Command model definitions served from api.cohere.com.
+func GetCohereModels() []*ModelInfo {
+	now := int64(1741651200) // 2025-03-11
+	return []*ModelInfo{
+		{
+			ID:                  "command-a-03-2025",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cohere",
+			Type:                "cohere",
+			DisplayName:         "Command A",
+			Description:         "Cohere flagship model for tool use, RAG and agents",
+			ContextLength:       256000,
+			MaxCompletionTokens: 8000,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "command-r-plus-08-2024",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cohere",
+			Type:                "cohere",
+			DisplayName:         "Command R+",
+			Description:         "Retrieval-augmented generation model with grounded citations",
+			ContextLength:       128000,
+			MaxCompletionTokens: 4000,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "command-r-08-2024",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cohere",
+			Type:                "cohere",
+			DisplayName:         "Command R",
+			Description:         "Balanced retrieval and tool-use model",
+			ContextLength:       128000,
+			MaxCompletionTokens: 4000,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "command-r7b-12-2024",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cohere",
+			Type:                "cohere",
+			DisplayName:         "Command R7B",
+			Description:         "Small, fast model for RAG and tool use",
+			ContextLength:       128000,
+			MaxCompletionTokens: 4000,
+			SupportedParameters: []string{"tools"},
+		},
+	}
+}
+
+// GetFireworksModels returns the Fireworks AI serverless model definitions. IDs are the
+// short names under accounts/fireworks/models; the executor expands them on request.
+func GetFireworksModels() []*ModelInfo {
+	now := int64(1752192000) // 2025-07-11
+	return []*ModelInfo{
+		{
+			ID:                  "deepseek-v3p1",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "fireworks",
+			Type:                "fireworks",
+			DisplayName:         "DeepSeek V3.1",
+			Description:         "DeepSeek V3.1 hybrid reasoning model served by Fireworks AI",
+			ContextLength:       163840,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:            "deepseek-r1-0528",
+			Object:        "model",
+			Created:       now,
+			OwnedBy:       "fireworks",
+			Type:          "fireworks",
+			DisplayName:   "DeepSeek R1 (0528)",
+			Description:   "DeepSeek R1 reasoning model served by Fireworks AI",
+			ContextLength: 163840,
+		},
+		{
+			ID:                  "kimi-k2-instruct",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "fireworks",
+			Type:                "fireworks",
+			DisplayName:         "Kimi K2 Instruct",
+			Description:         "Moonshot AI Kimi K2 agentic model served by Fireworks AI",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "qwen3-coder-480b-a35b-instruct",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "fireworks",
+			Type:                "fireworks",
+			DisplayName:         "Qwen3 Coder 480B A35B Instruct",
+			Description:         "Qwen3 agentic coding model served by Fireworks AI",
+			ContextLength:       262144,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "llama-v3p3-70b-instruct",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "fireworks",
+			Type:                "fireworks",
+			DisplayName:         "Llama 3.3 70B Instruct",
+			Description:         "Meta Llama 3.3 70B instruction model served by Fireworks AI",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:            "llama-v3p1-8b-instruct",
+			Object:        "model",
+			Created:       now,
+			OwnedBy:       "fireworks",
+			Type:          "fireworks",
+			DisplayName:   "Llama 3.1 8B Instruct",
+			Description:   "Meta Llama 3.1 8B instruction model served by Fireworks AI",
+			ContextLength: 131072,
+		},
+	}
+}
+
+// GetNVIDIAModels returns the NVIDIA NIM model definitions served from build.nvidia.com.
+// IDs carry the publisher namespace NIM expects.
+func GetNVIDIAModels() []*ModelInfo {
+	now := int64(1754006400) // 2025-08-01
+	return []*ModelInfo{
+		{
+			ID:                  "nvidia/llama-3.3-nemotron-super-49b-v1.5",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "nvidia",
+			Type:                "nvidia",
+			DisplayName:         "Llama 3.3 Nemotron Super 49B v1.5",
+			Description:         "NVIDIA Nemotron reasoning model with a detailed-thinking toggle",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:            "nvidia/llama-3.1-nemotron-ultra-253b-v1",
+			Object:        "model",
+			Created:       now,
+			OwnedBy:       "nvidia",
+			Type:          "nvidia",
+			DisplayName:   "Llama 3.1 Nemotron Ultra 253B v1",
+			Description:   "NVIDIA Nemotron flagship reasoning model with a detailed-thinking toggle",
+			ContextLength: 131072,
+		},
+		{
+			ID:                  "meta/llama-3.3-70b-instruct",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "nvidia",
+			Type:                "nvidia",
+			DisplayName:         "Llama 3.3 70B Instruct",
+			Description:         "Meta Llama 3.3 70B instruction model served by NVIDIA NIM",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:            "deepseek-ai/deepseek-r1-0528",
+			Object:        "model",
+			Created:       now,
+			OwnedBy:       "nvidia",
+			Type:          "nvidia",
+			DisplayName:   "DeepSeek R1 (0528)",
+			Description:   "DeepSeek R1 reasoning model served by NVIDIA NIM",
+			ContextLength: 131072,
+		},
+		{
+			ID:                  "qwen/qwen3-coder-480b-a35b-instruct",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "nvidia",
+			Type:                "nvidia",
+			DisplayName:         "Qwen3 Coder 480B A35B Instruct",
+			Description:         "Qwen3 agentic coding model served by NVIDIA NIM",
+			ContextLength:       262144,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "openai/gpt-oss-120b",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "nvidia",
+			Type:                "nvidia",
+			DisplayName:         "gpt-oss-120b",
+			Description:         "OpenAI open-weight reasoning model served by NVIDIA NIM",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+	}
+}
+
+// GetMoonshotModels returns the Moonshot AI Kimi model definitions served from
+// api.moonshot.ai.
+func GetMoonshotModels() []*ModelInfo {
+	now := int64(1757030400) // 2025-09-05
+	return []*ModelInfo{
+		{
+			ID:                  "kimi-k2-0905-preview",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "moonshot",
+			Type:                "moonshot",
+			DisplayName:         "Kimi K2 (0905)",
+			Description:         "Kimi K2 agentic coding model",
+			ContextLength:       262144,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "kimi-k2-turbo-preview",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "moonshot",
+			Type:                "moonshot",
+			DisplayName:         "Kimi K2 Turbo",
+			Description:         "High-throughput Kimi K2 variant",
+			ContextLength:       262144,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "kimi-k2-thinking",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "moonshot",
+			Type:                "moonshot",
+			DisplayName:         "Kimi K2 Thinking",
+			Description:         "Kimi K2 reasoning model with interleaved tool use",
+			ContextLength:       262144,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "moonshot-v1-8k",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "moonshot",
+			Type:                "moonshot",
+			DisplayName:         "Moonshot v1 8K",
+			Description:         "Moonshot v1 general model with an 8K context window",
+			ContextLength:       8192,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "moonshot-v1-32k",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "moonshot",
+			Type:                "moonshot",
+			DisplayName:         "Moonshot v1 32K",
+			Description:         "Moonshot v1 general model with a 32K context window",
+			ContextLength:       32768,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "moonshot-v1-128k",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "moonshot",
+			Type:                "moonshot",
+			DisplayName:         "Moonshot v1 128K",
+			Description:         "Moonshot v1 general model with a 128K context window",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "moonshot-v1-auto",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "moonshot",
+			Type:                "moonshot",
+			DisplayName:         "Moonshot v1 Auto",
+			Description:         "Moonshot v1 with the context window chosen upstream per request",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+	}
+}
+
+// GetZhipuModels returns the Zhipu AI GLM model definitions served from
+// open.bigmodel.cn.
+func GetZhipuModels() []*ModelInfo {
+	now := int64(1759190400) // 2025-09-30
+	return []*ModelInfo{
+		{
+			ID:                  "glm-4.6",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "zhipu",
+			Type:                "zhipu",
+			DisplayName:         "GLM-4.6",
+			Description:         "Flagship GLM model for agentic coding and reasoning",
+			ContextLength:       204800,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "glm-4.5",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "zhipu",
+			Type:                "zhipu",
+			DisplayName:         "GLM-4.5",
+			Description:         "Hybrid reasoning GLM model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "glm-4.5-air",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "zhipu",
+			Type:                "zhipu",
+			DisplayName:         "GLM-4.5-Air",
+			Description:         "Lightweight hybrid reasoning GLM model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "glm-4.5-flash",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "zhipu",
+			Type:                "zhipu",
+			DisplayName:         "GLM-4.5-Flash",
+			Description:         "Free-tier hybrid reasoning GLM model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "glm-4-plus",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "zhipu",
+			Type:                "zhipu",
+			DisplayName:         "GLM-4-Plus",
+			Description:         "GLM-4 general model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "glm-4-air-250414",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "zhipu",
+			Type:                "zhipu",
+			DisplayName:         "GLM-4-Air",
+			Description:         "Cost-efficient GLM-4 model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "glm-4-flash-250414",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "zhipu",
+			Type:                "zhipu",
+			DisplayName:         "GLM-4-Flash",
+			Description:         "Free-tier GLM-4 model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+	}
+}
+
+// GetCerebrasModels returns the model definitions served from api.cerebras.ai.
+func GetCerebrasModels() []*ModelInfo {
+	now := int64(1754006400) // 2025-08-01
+	return []*ModelInfo{
+		{
+			ID:                  "llama3.1-8b",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cerebras",
+			Type:                "cerebras",
+			DisplayName:         "Llama 3.1 8B",
+			Description:         "Meta Llama 3.1 8B on Cerebras wafer-scale inference",
+			ContextLength:       32768,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "llama-3.3-70b",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cerebras",
+			Type:                "cerebras",
+			DisplayName:         "Llama 3.3 70B",
+			Description:         "Meta Llama 3.3 70B on Cerebras wafer-scale inference",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "gpt-oss-120b",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cerebras",
+			Type:                "cerebras",
+			DisplayName:         "gpt-oss-120b",
+			Description:         "OpenAI gpt-oss 120B open-weight reasoning model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "qwen-3-32b",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cerebras",
+			Type:                "cerebras",
+			DisplayName:         "Qwen 3 32B",
+			Description:         "Qwen 3 32B hybrid reasoning model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+		{
+			ID:                  "qwen-3-235b-a22b-instruct-2507",
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "cerebras",
+			Type:                "cerebras",
+			DisplayName:         "Qwen 3 235B Instruct",
+			Description:         "Qwen 3 235B A22B instruct model",
+			ContextLength:       131072,
+			SupportedParameters: []string{"tools"},
+		},
+	}
+}
+
+// GetVertexAnthropicModels returns the Claude models served by Vertex AI through the
+// Anthropic publisher. IDs match the Anthropic API; the executor converts them to the
+// Vertex "name@date" form on request.
+func GetVertexAnthropicModels() []*ModelInfo {
+	vertexIDs := map[string]struct{}{
+		"claude-haiku-4-5-20251001":  {},
+		"claude-sonnet-4-5-20250929": {},
+		"claude-opus-4-5-20251101":   {},
+		"claude-opus-4-1-20250805":   {},
+		"claude-opus-4-20250514":     {},
+		"claude-sonnet-4-20250514":   {},
+		"claude-3-7-sonnet-20250219": {},
+	}
+	var models []*ModelInfo
+	for _, m := range GetClaudeModels() {
+		if _, ok := vertexIDs[m.ID]; ok {
+			models = append(models, m)
+		}
+	}
+	return models
+}
@@ -51,6 +51,22 @@ type ModelInfo struct {
 	// Thinking holds provider-specific reasoning/thinking budget capabilities.
 	// This is optional and currently used for Gemini thinking budget normalization.
 	Thinking *ThinkingSupport `json:"thinking,omitempty"`
+
+	// Pricing holds upstream-reported prices, when the provider publishes them.
+	Pricing *ModelPricing `json:"pricing,omitempty"`
+}
+
+// ModelPricing describes upstream prices in USD as reported by the provider, kept as
+// decimal strings to avoid rounding (e.g. "0.000003" per prompt token).
+type ModelPricing struct {
+	// Prompt is the price per input token.
+	Prompt string `json:"prompt,omitempty"`
+	// Completion is the price per output token.
+	Completion string `json:"completion,omitempty"`
+	// Request is the fixed price per request.
+	Request string `json:"request,omitempty"`
+	// Image is the price per input image.
+	Image string `json:"image,omitempty"`
 }
 
 // ThinkingSupport describes a model family's supported internal reasoning budget range.
@@ -968,6 +984,9 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		if len(model.SupportedParameters) > 0 {
 			result["supported_parameters"] = model.SupportedParameters
 		}
+		if model.Pricing != nil {
+			result["pricing"] = model.Pricing
+		}
 		return result
 
 	case "claude", "kiro", "antigravity":
@@ -1047,22 +1066,25 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 	}
 }
 
-// CleanupExpiredQuotas removes expired quota tracking entries
-func (r *ModelRegistry) CleanupExpiredQuotas() {
+// CleanupExpiredQuotas removes expired quota tracking entries and returns how many it removed
+func (r *ModelRegistry) CleanupExpiredQuotas() int {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	now := time.Now()
 	quotaExpiredDuration := 5 * time.Minute
 
+	removed := 0
 	for modelID, registration := range r.models {
 		for clientID, quotaTime := range registration.QuotaExceededClients {
 			if quotaTime != nil && now.Sub(*quotaTime) >= quotaExpiredDuration {
 				delete(registration.QuotaExceededClients, clientID)
+				removed++
 				log.Debugf("Cleaned up expired quota tracking for model %s, client %s", modelID, clientID)
 			}
 		}
 	}
+	return removed
 }
 
 // GetFirstAvailableModel returns the first available model for the given handler type.
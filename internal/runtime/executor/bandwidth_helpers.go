@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// bandwidthTransport counts request and response body bytes exchanged with upstream
+// providers and publishes one bandwidth record per request once the response body is
+// closed.
+type bandwidthTransport struct {
+	base      http.RoundTripper
+	provider  string
+	authID    string
+	authIndex string
+}
+
+// withBandwidthAccounting wraps client so upstream traffic is attributed to auth.
+// The underlying transport is shared, so connection reuse is unaffected.
+func withBandwidthAccounting(client *http.Client, auth *cliproxyauth.Auth) *http.Client {
+	if client == nil || auth == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: &bandwidthTransport{
+			base:      base,
+			provider:  auth.Provider,
+			authID:    auth.ID,
+			authIndex: auth.EnsureIndex(),
+		},
+		Timeout: client.Timeout,
+	}
+}
+
+func (t *bandwidthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracker := &bandwidthTracker{
+		ctx: req.Context(),
+		record: usage.BandwidthRecord{
+			Provider:    t.provider,
+			AuthID:      t.authID,
+			AuthIndex:   t.authIndex,
+			APIKey:      apiKeyFromContext(req.Context()),
+			RequestedAt: time.Now(),
+		},
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		clone := req.Clone(req.Context())
+		clone.Body = &countingReadCloser{ReadCloser: req.Body, count: &tracker.sent}
+		req = clone
+	}
+	request := inflight.FromContext(req.Context())
+	request.SetCredential(t.provider, t.authID)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		tracker.publish()
+		return resp, err
+	}
+	resp.Body = &countingReadCloser{ReadCloser: resp.Body, count: &tracker.received, onRead: request.AddBytes, onClose: tracker.publish}
+	return resp, nil
+}
+
+// bandwidthTracker accumulates byte counts for one upstream request.
+type bandwidthTracker struct {
+	ctx      context.Context
+	record   usage.BandwidthRecord
+	sent     atomic.Int64
+	received atomic.Int64
+	once     sync.Once
+}
+
+func (b *bandwidthTracker) publish() {
+	b.once.Do(func() {
+		b.record.BytesSent = b.sent.Load()
+		b.record.BytesReceived = b.received.Load()
+		usage.PublishBandwidth(b.ctx, b.record)
+	})
+}
+
+// countingReadCloser counts bytes read through it, reports them to onRead if set, and
+// runs onClose once on Close.
+type countingReadCloser struct {
+	io.ReadCloser
+	count   *atomic.Int64
+	onRead  func(n int64)
+	onClose func()
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if n > 0 {
+		c.count.Add(int64(n))
+		if c.onRead != nil {
+			c.onRead(int64(n))
+		}
+	}
+	return n, err
+}
+
+func (c *countingReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	if c.onClose != nil {
+		c.onClose()
+	}
+	return err
+}
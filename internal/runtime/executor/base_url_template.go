@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"net/url"
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// expandBaseURL resolves the template variables of a configured base URL for one request,
+// so endpoints that embed the model, region or project in the path need no bespoke
+// executor code:
+//
+//   - {model}: the upstream model of the request
+//   - {region}: the credential's region or location
+//   - {project}: the credential's project id
+//
+// Variables without a value are left in place and logged.
+func expandBaseURL(baseURL string, auth *cliproxyauth.Auth, model string) string {
+	if !strings.Contains(baseURL, "{") {
+		return baseURL
+	}
+	vars := map[string]string{
+		"model":   url.PathEscape(strings.TrimSpace(model)),
+		"region":  authTemplateValue(auth, "region", "location"),
+		"project": authTemplateValue(auth, "project_id", "project"),
+	}
+	var out strings.Builder
+	rest := baseURL
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			out.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			out.WriteString(rest)
+			break
+		}
+		end += start
+		out.WriteString(rest[:start])
+		name := rest[start+1 : end]
+		if value := vars[name]; value != "" {
+			out.WriteString(value)
+		} else {
+			log.Debugf("base url %s: no value for {%s}", baseURL, name)
+			out.WriteString(rest[start : end+1])
+		}
+		rest = rest[end+1:]
+	}
+	return out.String()
+}
+
+// authTemplateValue returns the first non-empty attribute or metadata value of auth
+// under keys.
+func authTemplateValue(auth *cliproxyauth.Auth, keys ...string) string {
+	if auth == nil {
+		return ""
+	}
+	for _, key := range keys {
+		if v := strings.TrimSpace(auth.Attributes[key]); v != "" {
+			return v
+		}
+	}
+	for _, key := range keys {
+		if v, ok := auth.Metadata[key].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package executor
+
+import (
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestExpandBaseURL(t *testing.T) {
+	auth := &cliproxyauth.Auth{
+		Attributes: map[string]string{"region": "eastus"},
+		Metadata:   map[string]any{"project_id": "acme"},
+	}
+	got := expandBaseURL("https://{region}.example.com/projects/{project}/deployments/{model}", auth, "gpt-4o mini")
+	if want := "https://eastus.example.com/projects/acme/deployments/gpt-4o%20mini"; got != want {
+		t.Fatalf("expandBaseURL = %q, want %q", got, want)
+	}
+	if got = expandBaseURL("https://api.example.com/{tenant}/{model}", nil, "m"); got != "https://api.example.com/{tenant}/m" {
+		t.Fatalf("unknown variables must be left in place, got %q", got)
+	}
+	if got = expandBaseURL("https://api.example.com/v1", auth, "m"); got != "https://api.example.com/v1" {
+		t.Fatalf("plain base URLs must pass through, got %q", got)
+	}
+}
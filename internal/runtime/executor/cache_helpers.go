@@ -3,6 +3,9 @@ package executor
 import (
 	"sync"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
 )
 
 type codexCache struct {
@@ -17,42 +20,32 @@ var (
 	codexCacheMu  sync.RWMutex
 )
 
-// codexCacheCleanupInterval controls how often expired entries are purged.
+// codexCacheCleanupInterval is the default janitor schedule for purging expired entries.
 const codexCacheCleanupInterval = 15 * time.Minute
 
-// codexCacheCleanupOnce ensures the background cleanup goroutine starts only once.
-var codexCacheCleanupOnce sync.Once
-
-// startCodexCacheCleanup launches a background goroutine that periodically
-// removes expired entries from codexCacheMap to prevent memory leaks.
-func startCodexCacheCleanup() {
-	go func() {
-		ticker := time.NewTicker(codexCacheCleanupInterval)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			purgeExpiredCodexCache()
-		}
-	}()
+func init() {
+	janitor.Register("codex-prompt-cache", codexCacheCleanupInterval, func(_ *config.Config, now time.Time) (int, error) {
+		return purgeExpiredCodexCache(now), nil
+	})
 }
 
-// purgeExpiredCodexCache removes entries that have expired.
-func purgeExpiredCodexCache() {
-	now := time.Now()
-
+// purgeExpiredCodexCache removes entries expired at now and returns how many it removed.
+func purgeExpiredCodexCache(now time.Time) int {
 	codexCacheMu.Lock()
 	defer codexCacheMu.Unlock()
 
+	removed := 0
 	for key, cache := range codexCacheMap {
 		if cache.Expire.Before(now) {
 			delete(codexCacheMap, key)
+			removed++
 		}
 	}
+	return removed
 }
 
 // getCodexCache retrieves a cached entry, returning ok=false if not found or expired.
 func getCodexCache(key string) (codexCache, bool) {
-	codexCacheCleanupOnce.Do(startCodexCacheCleanup)
 	codexCacheMu.RLock()
 	cache, ok := codexCacheMap[key]
 	codexCacheMu.RUnlock()
@@ -64,7 +57,6 @@ func getCodexCache(key string) (codexCache, bool) {
 
 // setCodexCache stores a cache entry.
 func setCodexCache(key string, cache codexCache) {
-	codexCacheCleanupOnce.Do(startCodexCacheCleanup)
 	codexCacheMu.Lock()
 	codexCacheMap[key] = cache
 	codexCacheMu.Unlock()
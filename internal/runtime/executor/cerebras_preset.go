@@ -0,0 +1,5 @@
+package executor
+
+// cerebrasRateLimitWindows are the suffixes of Cerebras' x-ratelimit-* headers: a daily
+// request budget and a per-minute token budget, with resets in seconds.
+var cerebrasRateLimitWindows = []string{"requests-day", "tokens-minute"}
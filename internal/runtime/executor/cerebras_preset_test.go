@@ -0,0 +1,27 @@
+package executor
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCerebrasBudgetExhausted(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-ratelimit-remaining-requests-day", "14399")
+	header.Set("x-ratelimit-reset-requests-day", "33011.382867")
+	header.Set("x-ratelimit-remaining-tokens-minute", "0")
+	header.Set("x-ratelimit-reset-tokens-minute", "11.5")
+	wait, exhausted := quotaExhaustedFromHeader(header, time.Now())
+	if !exhausted || wait != 11500*time.Millisecond {
+		t.Fatalf("wait = %s, exhausted = %t; want the per-minute reset", wait, exhausted)
+	}
+	if got, ok := retryAfterFromHeader(header, time.Now()); !ok || got != wait {
+		t.Fatalf("429 retry hint = %s, %t; want %s", got, ok, wait)
+	}
+
+	header.Set("x-ratelimit-remaining-tokens-minute", "60000")
+	if _, exhausted = quotaExhaustedFromHeader(header, time.Now()); exhausted {
+		t.Fatal("a response with remaining budget must not report exhaustion")
+	}
+}
@@ -2,8 +2,15 @@ package executor
 
 import (
 	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	"github.com/tidwall/gjson"
 )
 
@@ -49,3 +56,36 @@ func TestStripClaudeToolPrefixFromStreamLine(t *testing.T) {
 		t.Fatalf("content_block.name = %q, want %q", got, "alpha")
 	}
 }
+
+func TestClaudeCountTokens_FallsBackWithoutEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	e := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "compat", Provider: "claude", Attributes: map[string]string{"api_key": "k", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "claude-sonnet-4", Payload: []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"count these tokens please"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	resp, err := e.CountTokens(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("CountTokens: %v", err)
+	}
+	if !gjson.GetBytes(resp.Payload, "approximate").Bool() || gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int() <= 0 {
+		t.Fatalf("payload = %s, want an approximate local count", resp.Payload)
+	}
+
+	// Errors other than a missing endpoint are returned as they are.
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"bad key"}`, http.StatusUnauthorized)
+	})
+	if _, err = e.CountTokens(context.Background(), auth, req, opts); !isStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("err = %v, want the upstream 401", err)
+	}
+}
+
+func isStatus(err error, code int) bool {
+	se, ok := err.(statusErr)
+	return ok && se.code == code
+}
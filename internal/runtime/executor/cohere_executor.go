@@ -0,0 +1,330 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CohereExecutor is a stateless executor for the Cohere v2 chat API. Requests are
+// translated into Cohere's chat schema and responses, including tool calls and
+// citations, back into the client's format.
+type CohereExecutor struct {
+	cfg *config.Config
+}
+
+// NewCohereExecutor creates a new Cohere executor.
+func NewCohereExecutor(cfg *config.Config) *CohereExecutor { return &CohereExecutor{cfg: cfg} }
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *CohereExecutor) Identifier() string { return "cohere" }
+
+// PrepareRequest injects Cohere credentials into the outgoing HTTP request.
+func (e *CohereExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	_, apiKey := cohereCredentials(auth)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	setUpstreamRequestID(req)
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return nil
+}
+
+// HttpRequest injects Cohere credentials into the request and executes it.
+func (e *CohereExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("cohere executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *CohereExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("cohere")
+	translated := e.translateRequest(ctx, auth, req, opts, false)
+
+	httpResp, err := e.doRequest(ctx, auth, "/v2/chat", translated, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("cohere executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, body)
+	reporter.publish(ctx, parseCohereUsage(gjson.GetBytes(body, "usage")))
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translated, body, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *CohereExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("cohere")
+	translated := e.translateRequest(ctx, auth, req, opts, true)
+
+	httpResp, err := e.doRequest(ctx, auth, "/v2/chat", translated, true)
+	if err != nil {
+		return nil, err
+	}
+	out := newStreamChannel(opts)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("cohere executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := newStreamScanner(httpResp.Body, e.Identifier(), streamLineLimit(e.cfg, e.Identifier(), opts))
+		stream := newStreamTranslator(ctx, opts, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translated)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseCohereStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			if !bytes.HasPrefix(line, []byte("data:")) {
+				continue
+			}
+			chunks := stream.Translate(bytes.Clone(line))
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return stream, nil
+}
+
+func (e *CohereExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	// Cohere has no token counting endpoint; approximate on the OpenAI form of the request.
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
+
+	enc, err := tokenizerForModel(e.resolveUpstreamModel(req.Model, auth))
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("cohere executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("cohere executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: markApproximateCount([]byte(translatedUsage))}, nil
+}
+
+// Refresh is a no-op for API-key based credentials.
+func (e *CohereExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	_ = ctx
+	return auth, nil
+}
+
+// Rerank implements cliproxyauth.RerankExecutor using the Cohere v2 rerank endpoint.
+func (e *CohereExecutor) Rerank(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	model := req.Model
+	if upstream := e.resolveUpstreamModel(req.Model, auth); upstream != "" {
+		model = upstream
+	}
+	httpResp, err := e.doRequest(ctx, auth, "/v2/rerank", buildUpstreamRerankPayload(req.Payload, model), false)
+	if err != nil {
+		return resp, err
+	}
+	body, err := readUpstreamBody(ctx, e.cfg, httpResp, e.Identifier())
+	if err != nil {
+		return resp, err
+	}
+	reporter.ensurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: body}, nil
+}
+
+// Embed implements cliproxyauth.EmbeddingExecutor using the Cohere v2 embed endpoint.
+func (e *CohereExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	model := req.Model
+	if upstream := e.resolveUpstreamModel(req.Model, auth); upstream != "" {
+		model = upstream
+	}
+	payload := []byte(`{"input_type":"search_document","embedding_types":["float"]}`)
+	payload, _ = sjson.SetBytes(payload, "model", model)
+	payload, _ = sjson.SetBytes(payload, "texts", embeddingInputs(req.Payload))
+	if inputType := gjson.GetBytes(req.Payload, "input_type"); inputType.Exists() {
+		payload, _ = sjson.SetBytes(payload, "input_type", inputType.String())
+	}
+	httpResp, err := e.doRequest(ctx, auth, "/v2/embed", payload, false)
+	if err != nil {
+		return resp, err
+	}
+	body, err := readUpstreamBody(ctx, e.cfg, httpResp, e.Identifier())
+	if err != nil {
+		return resp, err
+	}
+	reporter.ensurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: convertCohereEmbedResponseToOpenAI(body, req.Model)}, nil
+}
+
+func (e *CohereExecutor) translateRequest(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) []byte {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("cohere")
+	originalPayload := bytes.Clone(req.Payload)
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = bytes.Clone(opts.OriginalRequest)
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, stream)
+	translated := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), stream)
+	if model := e.resolveUpstreamModel(req.Model, auth); model != "" {
+		translated, _ = sjson.SetBytes(translated, "model", model)
+	}
+	translated = applyPayloadConfigWithRoot(ctx, e.cfg, req.Model, to.String(), "", translated, originalTranslated)
+	return translated
+}
+
+func (e *CohereExecutor) doRequest(ctx context.Context, auth *cliproxyauth.Auth, path string, body []byte, stream bool) (*http.Response, error) {
+	baseURL, _ := cohereCredentials(auth)
+	url := strings.TrimSuffix(expandBaseURL(baseURL, auth, gjson.GetBytes(body, "model").String()), "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "cli-proxy-cohere")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	}
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	reportQuotaHeaders(ctx, httpResp.Header)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("cohere executor: close response body error: %v", errClose)
+		}
+		return nil, newUpstreamStatusErr(httpResp, b)
+	}
+	return httpResp, nil
+}
+
+// resolveUpstreamModel maps a configured alias to its upstream model name, or "" when
+// the model is not aliased.
+func (e *CohereExecutor) resolveUpstreamModel(alias string, auth *cliproxyauth.Auth) string {
+	entry := e.resolveCohereConfig(auth)
+	if entry == nil {
+		return ""
+	}
+	alias = strings.TrimSpace(alias)
+	for i := range entry.Models {
+		name := strings.TrimSpace(entry.Models[i].Name)
+		modelAlias := strings.TrimSpace(entry.Models[i].Alias)
+		if modelAlias != "" && strings.EqualFold(modelAlias, alias) && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func (e *CohereExecutor) resolveCohereConfig(auth *cliproxyauth.Auth) *config.CohereKey {
+	if auth == nil || e.cfg == nil {
+		return nil
+	}
+	_, apiKey := cohereCredentials(auth)
+	for i := range e.cfg.CohereKey {
+		entry := &e.cfg.CohereKey[i]
+		if strings.TrimSpace(entry.APIKey) == apiKey {
+			return entry
+		}
+	}
+	return nil
+}
+
+func cohereCredentials(auth *cliproxyauth.Auth) (baseURL, apiKey string) {
+	if auth != nil && auth.Attributes != nil {
+		baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+		apiKey = strings.TrimSpace(auth.Attributes["api_key"])
+	}
+	if baseURL == "" {
+		baseURL = config.DefaultCohereBaseURL
+	}
+	return baseURL, apiKey
+}
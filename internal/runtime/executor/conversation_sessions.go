@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultConversationSessionTTL = time.Hour
+	// conversationSessionCleanup is the default janitor schedule for expired mappings.
+	conversationSessionCleanup = 5 * time.Minute
+	// defaultRedisSessionPrefix namespaces mapping keys when redis-key-prefix is empty.
+	defaultRedisSessionPrefix = "cliproxy:conversation:"
+	// redisSessionTimeout bounds a single Redis round trip so an unreachable server only
+	// costs the request its session reuse.
+	redisSessionTimeout = 2 * time.Second
+)
+
+// conversationSessionStore persists downstream fingerprint -> provider conversation ID.
+type conversationSessionStore interface {
+	get(ctx context.Context, key string) (string, bool, error)
+	set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// conversationSessions holds the active store and settings shared by all executors.
+type conversationSessions struct {
+	mu       sync.RWMutex
+	settings config.ConversationSessionConfig
+	store    conversationSessionStore
+	// target identifies the open store so reloads only reopen it when it changes.
+	target string
+}
+
+var sharedConversationSessions = &conversationSessions{}
+
+func init() {
+	janitor.Register("conversation-sessions", conversationSessionCleanup, func(_ *config.Config, now time.Time) (int, error) {
+		s := sharedConversationSessions
+		s.mu.RLock()
+		store := s.store
+		s.mu.RUnlock()
+		if sweeper, ok := store.(interface{ purgeExpired(time.Time) int }); ok {
+			return sweeper.purgeExpired(now), nil
+		}
+		return 0, nil
+	})
+}
+
+// configureConversationSessions applies the conversation-sessions section of cfg.
+// Existing mappings survive reloads that keep the same store. A Redis store that cannot
+// be set up falls back to memory so sessions still work within this instance.
+func configureConversationSessions(cfg *config.Config) {
+	var settings config.ConversationSessionConfig
+	if cfg != nil {
+		settings = cfg.ConversationSessions
+	}
+	s := sharedConversationSessions
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+	target := conversationStoreTarget(settings)
+	if target == s.target {
+		return
+	}
+
+	var next conversationSessionStore
+	switch {
+	case target == "":
+	case strings.HasPrefix(target, "redis|"):
+		redisStore, err := newRedisConversationStore(settings.RedisURL, settings.RedisKeyPrefix)
+		if err != nil {
+			log.Errorf("conversation sessions: %v; keeping sessions in memory", err)
+			next = newMemoryConversationStore()
+			break
+		}
+		next = redisStore
+	default:
+		next = newMemoryConversationStore()
+	}
+	if closer, ok := s.store.(interface{ close() error }); ok {
+		if err := closer.close(); err != nil {
+			log.Warnf("conversation sessions: closing the previous store: %v", err)
+		}
+	}
+	s.store, s.target = next, target
+}
+
+// conversationStoreTarget identifies the store settings selects; empty when disabled.
+func conversationStoreTarget(settings config.ConversationSessionConfig) string {
+	if !settings.Enabled {
+		return ""
+	}
+	switch kind := strings.ToLower(strings.TrimSpace(settings.Store)); kind {
+	case "", "memory":
+		return "memory"
+	case "redis":
+		return "redis|" + strings.TrimSpace(settings.RedisURL) + "|" + settings.RedisKeyPrefix
+	default:
+		log.Warnf("conversation sessions: unknown store %q, keeping sessions in memory", kind)
+		return "memory"
+	}
+}
+
+// reuseConversationID rewrites the conversation ID at path in payload with the ID
+// previously issued for the same downstream conversation. On the first turn the
+// payload's own ID is remembered. The payload is returned unchanged when sessions are
+// disabled or the conversation cannot be fingerprinted.
+func reuseConversationID(ctx context.Context, scope string, body, payload []byte, path string) []byte {
+	s := sharedConversationSessions
+	s.mu.RLock()
+	store, settings := s.store, s.settings
+	s.mu.RUnlock()
+	if store == nil {
+		return payload
+	}
+	fingerprint := conversationFingerprint(scope, body)
+	if fingerprint == "" {
+		return payload
+	}
+	ttl := time.Duration(settings.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = defaultConversationSessionTTL
+	}
+
+	id, found, err := store.get(ctx, fingerprint)
+	if err != nil {
+		log.Warnf("conversation sessions: lookup failed: %v", err)
+		return payload
+	}
+	if !found {
+		id = gjson.GetBytes(payload, path).String()
+		if id == "" {
+			return payload
+		}
+	}
+	// Refresh the TTL on every turn so active conversations stay mapped.
+	if err = store.set(ctx, fingerprint, id, ttl); err != nil {
+		log.Warnf("conversation sessions: store failed: %v", err)
+	}
+	if !found {
+		return payload
+	}
+	updated, err := sjson.SetBytes(payload, path, id)
+	if err != nil {
+		return payload
+	}
+	return updated
+}
+
+// conversationFingerprint identifies a downstream conversation by its system prompt and
+// first user message, which stay constant as clients resend the growing history.
+func conversationFingerprint(scope string, body []byte) string {
+	messages := gjson.GetBytes(body, "messages")
+	if !messages.IsArray() {
+		return ""
+	}
+	first := ""
+	for _, msg := range messages.Array() {
+		if msg.Get("role").String() == "user" {
+			first = msg.Get("content").Raw
+			break
+		}
+	}
+	if first == "" {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write([]byte(gjson.GetBytes(body, "system").Raw))
+	h.Write([]byte{0})
+	h.Write([]byte(first))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+type memorySessionEntry struct {
+	value  string
+	expire time.Time
+}
+
+// memoryConversationStore keeps mappings in process; the janitor purges expired ones.
+type memoryConversationStore struct {
+	mu      sync.Mutex
+	entries map[string]memorySessionEntry
+	now     func() time.Time
+}
+
+func newMemoryConversationStore() *memoryConversationStore {
+	return &memoryConversationStore{entries: make(map[string]memorySessionEntry), now: time.Now}
+}
+
+func (m *memoryConversationStore) get(_ context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok || !m.now().Before(entry.expire) {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (m *memoryConversationStore) set(_ context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memorySessionEntry{value: value, expire: m.now().Add(ttl)}
+	return nil
+}
+
+// purgeExpired removes mappings expired at now and returns how many it removed.
+func (m *memoryConversationStore) purgeExpired(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := 0
+	for k, entry := range m.entries {
+		if !now.Before(entry.expire) {
+			delete(m.entries, k)
+			removed++
+		}
+	}
+	return removed
+}
+
+// redisConversationStore keeps mappings in Redis so instances behind a load balancer share
+// them. Redis expires keys itself, so the janitor has nothing to sweep.
+type redisConversationStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func newRedisConversationStore(rawURL, prefix string) (*redisConversationStore, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, errors.New("store is redis but redis-url is empty")
+	}
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis-url: %w", err)
+	}
+	if prefix == "" {
+		prefix = defaultRedisSessionPrefix
+	}
+	return &redisConversationStore{client: redis.NewClient(opts), prefix: prefix}, nil
+}
+
+func (r *redisConversationStore) get(ctx context.Context, key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisSessionTimeout)
+	defer cancel()
+	value, err := r.client.Get(ctx, r.prefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (r *redisConversationStore) set(ctx context.Context, key, value string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, redisSessionTimeout)
+	defer cancel()
+	return r.client.Set(ctx, r.prefix+key, value, ttl).Err()
+}
+
+func (r *redisConversationStore) close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,187 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestReuseConversationID_ReusesAcrossTurns(t *testing.T) {
+	configureConversationSessions(&config.Config{ConversationSessions: config.ConversationSessionConfig{Enabled: true}})
+	t.Cleanup(func() { configureConversationSessions(nil) })
+
+	turn1 := []byte(`{"system":"be brief","messages":[{"role":"user","content":"hello"}]}`)
+	turn2 := []byte(`{"system":"be brief","messages":[{"role":"user","content":"hello"},{"role":"assistant","content":"hi"},{"role":"user","content":"again"}]}`)
+	other := []byte(`{"system":"be brief","messages":[{"role":"user","content":"different"}]}`)
+	path := "conversationState.conversationId"
+
+	first := reuseConversationID(context.Background(), "kiro|auth-1|key-a|model", turn1, []byte(`{"conversationState":{"conversationId":"id-1"}}`), path)
+	if got := gjson.GetBytes(first, path).String(); got != "id-1" {
+		t.Fatalf("first turn should keep its id, got %q", got)
+	}
+	second := reuseConversationID(context.Background(), "kiro|auth-1|key-a|model", turn2, []byte(`{"conversationState":{"conversationId":"id-2"}}`), path)
+	if got := gjson.GetBytes(second, path).String(); got != "id-1" {
+		t.Fatalf("follow-up turn should reuse id-1, got %q", got)
+	}
+	fresh := reuseConversationID(context.Background(), "kiro|auth-1|key-a|model", other, []byte(`{"conversationState":{"conversationId":"id-3"}}`), path)
+	if got := gjson.GetBytes(fresh, path).String(); got != "id-3" {
+		t.Fatalf("different conversation should keep its own id, got %q", got)
+	}
+	scoped := reuseConversationID(context.Background(), "kiro|auth-2|key-a|model", turn2, []byte(`{"conversationState":{"conversationId":"id-4"}}`), path)
+	if got := gjson.GetBytes(scoped, path).String(); got != "id-4" {
+		t.Fatalf("different scope should not share ids, got %q", got)
+	}
+}
+
+func TestReuseConversationID_DisabledPassesThrough(t *testing.T) {
+	configureConversationSessions(nil)
+	payload := []byte(`{"conversationState":{"conversationId":"id-1"}}`)
+	body := []byte(`{"messages":[{"role":"user","content":"hello"}]}`)
+	if got := reuseConversationID(context.Background(), "kiro", body, payload, "conversationState.conversationId"); string(got) != string(payload) {
+		t.Fatalf("expected payload unchanged, got %s", got)
+	}
+}
+
+func TestBuildKiroPayload_ScopesSessionsByAuth(t *testing.T) {
+	configureConversationSessions(&config.Config{ConversationSessions: config.ConversationSessionConfig{Enabled: true}})
+	t.Cleanup(func() { configureConversationSessions(nil) })
+
+	body := []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hello"}]}`)
+	from := sdktranslator.FromString("claude")
+	path := "conversationState.conversationId"
+	build := func(authID string) string {
+		payload, _ := buildKiroPayloadForFormat(context.Background(), &cliproxyauth.Auth{ID: authID}, body, "model", "", "", false, false, from, nil)
+		return gjson.GetBytes(payload, path).String()
+	}
+
+	first := build("kiro-a")
+	if first == "" {
+		t.Fatal("expected a conversation id")
+	}
+	if again := build("kiro-a"); again != first {
+		t.Fatalf("same account should reuse %q, got %q", first, again)
+	}
+	if other := build("kiro-b"); other == first {
+		t.Fatal("different accounts must not share a conversation id")
+	}
+}
+
+func TestReuseConversationID_SharedThroughRedis(t *testing.T) {
+	addr := startFakeRedis(t)
+	cfg := &config.Config{ConversationSessions: config.ConversationSessionConfig{
+		Enabled:        true,
+		Store:          "redis",
+		RedisURL:       "redis://" + addr + "/0",
+		RedisKeyPrefix: "test:",
+	}}
+	configureConversationSessions(cfg)
+	t.Cleanup(func() { configureConversationSessions(nil) })
+	if _, ok := sharedConversationSessions.store.(*redisConversationStore); !ok {
+		t.Fatalf("store = %T, want the redis store", sharedConversationSessions.store)
+	}
+
+	turn1 := []byte(`{"messages":[{"role":"user","content":"hello"}]}`)
+	turn2 := []byte(`{"messages":[{"role":"user","content":"hello"},{"role":"assistant","content":"hi"},{"role":"user","content":"again"}]}`)
+	path := "conversationState.conversationId"
+	reuseConversationID(context.Background(), "kiro|a", turn1, []byte(`{"conversationState":{"conversationId":"id-1"}}`), path)
+
+	// A second instance starts with an empty process but reaches the same Redis.
+	configureConversationSessions(nil)
+	configureConversationSessions(cfg)
+	got := reuseConversationID(context.Background(), "kiro|a", turn2, []byte(`{"conversationState":{"conversationId":"id-2"}}`), path)
+	if id := gjson.GetBytes(got, path).String(); id != "id-1" {
+		t.Fatalf("follow-up turn should reuse id-1 from redis, got %q", id)
+	}
+}
+
+func TestConfigureConversationSessions_BadRedisURLFallsBackToMemory(t *testing.T) {
+	configureConversationSessions(&config.Config{ConversationSessions: config.ConversationSessionConfig{Enabled: true, Store: "redis", RedisURL: "http://nope"}})
+	t.Cleanup(func() { configureConversationSessions(nil) })
+	if _, ok := sharedConversationSessions.store.(*memoryConversationStore); !ok {
+		t.Fatalf("store = %T, want the memory fallback", sharedConversationSessions.store)
+	}
+}
+
+// startFakeRedis serves GET and SET over RESP, enough for the session store; other
+// commands (the client's handshake) get an error reply.
+func startFakeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, errAccept := ln.Accept()
+			if errAccept != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() { _ = conn.Close() }()
+				r := bufio.NewReader(conn)
+				for {
+					args, errRead := readRESPCommand(r)
+					if errRead != nil {
+						return
+					}
+					reply := "-ERR unknown command\r\n"
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+						} else {
+							reply = "$-1\r\n"
+						}
+					case "SET":
+						data[args[1]] = args[2]
+						reply = "+OK\r\n"
+					case "PING":
+						reply = "+PONG\r\n"
+					}
+					mu.Unlock()
+					if _, errWrite := conn.Write([]byte(reply)); errWrite != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad command header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, errArg := r.ReadString('\n')
+		if errArg != nil {
+			return nil, errArg
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// countTokensLocally approximates the prompt tokens of req with the local tokenizer, for
+// providers without a counting endpoint. The count is returned in the client's format and
+// flagged approximate.
+func countTokensLocally(ctx context.Context, model string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
+
+	enc, err := tokenizerForModel(model)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("token counting failed: %w", err)
+	}
+
+	usageJSON := buildOpenAIUsageJSON(count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: markApproximateCount([]byte(translated))}, nil
+}
+
+// markApproximateCount flags a token count response as a local estimate with a top-level
+// "approximate": true, which clients that need exact counts can check.
+func markApproximateCount(payload []byte) []byte {
+	if !gjson.ValidBytes(payload) || !gjson.ParseBytes(payload).IsObject() {
+		return payload
+	}
+	updated, err := sjson.SetBytes(payload, "approximate", true)
+	if err != nil {
+		return payload
+	}
+	return updated
+}
+
+// countEndpointMissing reports whether an upstream answered a token counting request as if
+// it had no counting endpoint, as OpenAI-style and self-hosted backends behind a custom
+// base URL do.
+func countEndpointMissing(err error) bool {
+	var se statusErr
+	if !errors.As(err, &se) {
+		return false
+	}
+	switch se.code {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// withCountFallback returns the upstream count, or a local estimate when the upstream has
+// no counting endpoint.
+func withCountFallback(ctx context.Context, provider string, resp cliproxyexecutor.Response, err error, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if !countEndpointMissing(err) {
+		return resp, err
+	}
+	log.WithContext(ctx).Debugf("%s: upstream has no token counting endpoint, counting locally: %v", provider, err)
+	local, errLocal := countTokensLocally(ctx, req.Model, req, opts)
+	if errLocal != nil {
+		return resp, err
+	}
+	return local, nil
+}
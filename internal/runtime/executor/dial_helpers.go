@@ -0,0 +1,290 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// dnsCacheEntry holds resolved addresses for a host until expiresAt.
+type dnsCacheEntry struct {
+	addrs     []net.IP
+	expiresAt time.Time
+}
+
+// upstreamDialer resolves hosts with optional static mappings and TTL-overridden caching,
+// orders addresses by the preferred IP family, and races the other family after a delay
+// (RFC 8305 style) so a broken family does not stall the request.
+type upstreamDialer struct {
+	mu       sync.RWMutex
+	settings config.NetworkConfig
+	cache    map[string]dnsCacheEntry
+
+	dialer   net.Dialer
+	resolver *net.Resolver
+	now      func() time.Time
+}
+
+var sharedUpstreamDialer = &upstreamDialer{
+	cache:    make(map[string]dnsCacheEntry),
+	dialer:   net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	resolver: net.DefaultResolver,
+	now:      time.Now,
+}
+
+// configureUpstreamDialer applies the network section of cfg to the shared dialer.
+// The DNS cache is dropped whenever the settings change.
+func configureUpstreamDialer(cfg *config.Config) *upstreamDialer {
+	var settings config.NetworkConfig
+	if cfg != nil {
+		settings = cfg.Network
+	}
+	d := sharedUpstreamDialer
+	d.mu.Lock()
+	if !networkConfigEqual(d.settings, settings) {
+		d.settings = settings
+		d.cache = make(map[string]dnsCacheEntry)
+	}
+	d.mu.Unlock()
+	return d
+}
+
+// enabled reports whether custom dialing is configured.
+func (d *upstreamDialer) enabled() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.settings.Enabled()
+}
+
+// newTransport returns a transport cloned from the default one that dials through d.
+func (d *upstreamDialer) newTransport() *http.Transport {
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return &http.Transport{DialContext: d.DialContext}
+	}
+	transport := base.Clone()
+	transport.DialContext = d.DialContext
+	return transport
+}
+
+// Dial satisfies proxy.Dialer so the SOCKS5 client reaches the proxy through d.
+func (d *upstreamDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext resolves addr using the configured policy and connects to the first
+// address that answers.
+func (d *upstreamDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	d.mu.RLock()
+	settings := d.settings
+	d.mu.RUnlock()
+	if !settings.Enabled() {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := d.resolve(ctx, host, settings)
+	if err != nil {
+		return nil, err
+	}
+	primaries, fallbacks := splitByFamily(ips, settings.IPPreference)
+	delay := defaultFallbackDelay
+	if settings.FallbackDelayMS > 0 {
+		delay = time.Duration(settings.FallbackDelayMS) * time.Millisecond
+	}
+	return d.dialRace(ctx, network, port, primaries, fallbacks, delay)
+}
+
+func (d *upstreamDialer) resolve(ctx context.Context, host string, settings config.NetworkConfig) ([]net.IP, error) {
+	key := strings.ToLower(host)
+	if static := staticHostIPs(settings.StaticHosts, key); len(static) > 0 {
+		return static, nil
+	}
+
+	if settings.DNSCacheTTLSeconds > 0 {
+		d.mu.RLock()
+		entry, ok := d.cache[key]
+		d.mu.RUnlock()
+		if ok && d.now().Before(entry.expiresAt) {
+			return entry.addrs, nil
+		}
+	}
+
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+	}
+
+	if settings.DNSCacheTTLSeconds > 0 {
+		d.mu.Lock()
+		d.cache[key] = dnsCacheEntry{
+			addrs:     ips,
+			expiresAt: d.now().Add(time.Duration(settings.DNSCacheTTLSeconds) * time.Second),
+		}
+		d.mu.Unlock()
+	}
+	return ips, nil
+}
+
+// dialRace dials primaries serially, starting the fallback list after delay; the first
+// established connection wins and the loser is closed.
+func (d *upstreamDialer) dialRace(ctx context.Context, network, port string, primaries, fallbacks []net.IP, delay time.Duration) (net.Conn, error) {
+	if len(fallbacks) == 0 {
+		return d.dialSerial(ctx, network, port, primaries)
+	}
+	if len(primaries) == 0 {
+		return d.dialSerial(ctx, network, port, fallbacks)
+	}
+
+	type dialResult struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, 2)
+	start := func(primary bool, ips []net.IP) {
+		conn, err := d.dialSerial(raceCtx, network, port, ips)
+		results <- dialResult{conn: conn, err: err, primary: primary}
+	}
+	go start(true, primaries)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var firstErr error
+	fallbackStarted := false
+	pending := 1
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go start(false, fallbacks)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				if pending > 0 {
+					// Drain and close the connection that lost the race.
+					go func() {
+						if late := <-results; late.conn != nil {
+							_ = late.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			if res.primary && !fallbackStarted {
+				timer.Stop()
+				fallbackStarted = true
+				pending++
+				go start(false, fallbacks)
+			}
+		}
+	}
+	return nil, firstErr
+}
+
+func (d *upstreamDialer) dialSerial(ctx context.Context, network, port string, ips []net.IP) (net.Conn, error) {
+	var lastErr error
+	for _, ip := range ips {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no addresses to dial")
+	}
+	return nil, lastErr
+}
+
+// splitByFamily partitions ips into the preferred family and the rest. With no preference
+// the first address's family is treated as preferred, mirroring the resolver order.
+func splitByFamily(ips []net.IP, preference string) (primaries, fallbacks []net.IP) {
+	if len(ips) == 0 {
+		return nil, nil
+	}
+	var preferV4 bool
+	switch strings.ToLower(strings.TrimSpace(preference)) {
+	case "ipv4", "v4", "4":
+		preferV4 = true
+	case "ipv6", "v6", "6":
+		preferV4 = false
+	default:
+		preferV4 = ips[0].To4() != nil
+	}
+	for _, ip := range ips {
+		if (ip.To4() != nil) == preferV4 {
+			primaries = append(primaries, ip)
+		} else {
+			fallbacks = append(fallbacks, ip)
+		}
+	}
+	return primaries, fallbacks
+}
+
+func staticHostIPs(hosts map[string][]string, host string) []net.IP {
+	if len(hosts) == 0 {
+		return nil
+	}
+	for name, values := range hosts {
+		if !strings.EqualFold(strings.TrimSpace(name), host) {
+			continue
+		}
+		ips := make([]net.IP, 0, len(values))
+		for _, v := range values {
+			if ip := net.ParseIP(strings.TrimSpace(v)); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+		return ips
+	}
+	return nil
+}
+
+func networkConfigEqual(a, b config.NetworkConfig) bool {
+	if a.DNSCacheTTLSeconds != b.DNSCacheTTLSeconds || a.IPPreference != b.IPPreference || a.FallbackDelayMS != b.FallbackDelayMS {
+		return false
+	}
+	if len(a.StaticHosts) != len(b.StaticHosts) {
+		return false
+	}
+	for host, ipsA := range a.StaticHosts {
+		ipsB, ok := b.StaticHosts[host]
+		if !ok || strings.Join(ipsA, ",") != strings.Join(ipsB, ",") {
+			return false
+		}
+	}
+	return true
+}
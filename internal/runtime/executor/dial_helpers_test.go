@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestSplitByFamily(t *testing.T) {
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}
+
+	primaries, fallbacks := splitByFamily(ips, "ipv4")
+	if len(primaries) != 2 || len(fallbacks) != 1 || primaries[0].To4() == nil {
+		t.Fatalf("ipv4 preference: primaries=%v fallbacks=%v", primaries, fallbacks)
+	}
+
+	primaries, fallbacks = splitByFamily(ips, "")
+	if len(primaries) != 1 || primaries[0].To4() != nil || len(fallbacks) != 2 {
+		t.Fatalf("system order: primaries=%v fallbacks=%v", primaries, fallbacks)
+	}
+}
+
+func TestUpstreamDialer_StaticHostsAndFallback(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, errAccept := ln.Accept()
+			if errAccept != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	d := &upstreamDialer{
+		cache:    make(map[string]dnsCacheEntry),
+		dialer:   net.Dialer{Timeout: 2 * time.Second},
+		resolver: net.DefaultResolver,
+		now:      time.Now,
+		settings: config.NetworkConfig{
+			// The preferred IPv6 address refuses connections, so the IPv4 fallback must win.
+			StaticHosts:     map[string][]string{"Upstream.Test": {"::1", "127.0.0.1"}},
+			IPPreference:    "ipv6",
+			FallbackDelayMS: 50,
+		},
+	}
+	conn, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("upstream.test", port))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if host, _, _ := net.SplitHostPort(conn.RemoteAddr().String()); host != "127.0.0.1" {
+		t.Fatalf("expected IPv4 fallback, got %s", conn.RemoteAddr())
+	}
+}
@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// endpointLatencyWeight is the EWMA smoothing factor applied to new latency samples.
+	endpointLatencyWeight = 0.3
+	// endpointBaseCooldown is the initial cooldown applied after an endpoint failure.
+	endpointBaseCooldown = 15 * time.Second
+	// endpointMaxCooldown caps the exponential cooldown for repeatedly failing endpoints.
+	endpointMaxCooldown = 5 * time.Minute
+)
+
+// endpointStats tracks observed health for a single upstream base URL.
+type endpointStats struct {
+	latency       time.Duration
+	failures      int
+	cooldownUntil time.Time
+}
+
+// endpointTracker selects between multiple base URLs configured for one credential,
+// preferring the lowest observed latency and skipping endpoints in failure cooldown.
+// Failover happens between requests: a request that fails on one endpoint is not
+// replayed on another, but the next request avoids the endpoint while it cools down.
+type endpointTracker struct {
+	mu    sync.Mutex
+	stats map[string]*endpointStats
+	now   func() time.Time
+}
+
+var defaultEndpointTracker = &endpointTracker{
+	stats: make(map[string]*endpointStats),
+	now:   time.Now,
+}
+
+// authBaseURLCandidates returns the base URLs configured for an auth entry.
+// The first entry is the primary base_url; base_urls lists all regional endpoints.
+func authBaseURLCandidates(auth *cliproxyauth.Auth) []string {
+	if auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	raw := strings.TrimSpace(auth.Attributes["base_urls"])
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if trimmed := strings.TrimRight(strings.TrimSpace(part), "/"); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+// selectBaseURL returns the base URL to use for the auth entry. Credentials with a
+// single endpoint return their base_url attribute unchanged.
+func selectBaseURL(auth *cliproxyauth.Auth) string {
+	candidates := authBaseURLCandidates(auth)
+	if len(candidates) <= 1 {
+		if auth == nil || auth.Attributes == nil {
+			return ""
+		}
+		return strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	return defaultEndpointTracker.pick(candidates)
+}
+
+// observeBaseURL records the outcome of a request sent to baseURL for a multi-endpoint
+// credential. Network errors and 5xx responses put the endpoint into cooldown.
+func observeBaseURL(auth *cliproxyauth.Auth, baseURL string, started time.Time, resp *http.Response, err error) {
+	if len(authBaseURLCandidates(auth)) <= 1 || baseURL == "" {
+		return
+	}
+	failed := err != nil || (resp != nil && resp.StatusCode >= http.StatusInternalServerError)
+	defaultEndpointTracker.observe(strings.TrimRight(baseURL, "/"), time.Since(started), failed)
+}
+
+func (t *endpointTracker) pick(candidates []string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	best := ""
+	var bestLatency time.Duration
+	fallback := candidates[0]
+	var fallbackUntil time.Time
+	for i, candidate := range candidates {
+		stats := t.stats[candidate]
+		if stats != nil && now.Before(stats.cooldownUntil) {
+			if i == 0 || stats.cooldownUntil.Before(fallbackUntil) {
+				fallback, fallbackUntil = candidate, stats.cooldownUntil
+			}
+			continue
+		}
+		var latency time.Duration
+		if stats != nil {
+			latency = stats.latency
+		}
+		// Untried endpoints report zero latency so each region gets probed once.
+		if best == "" || latency < bestLatency {
+			best, bestLatency = candidate, latency
+		}
+	}
+	if best != "" {
+		return best
+	}
+	// Every endpoint is cooling down; use the one that recovers first.
+	return fallback
+}
+
+func (t *endpointTracker) observe(baseURL string, latency time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats[baseURL]
+	if stats == nil {
+		stats = &endpointStats{}
+		t.stats[baseURL] = stats
+	}
+	if failed {
+		stats.failures++
+		cooldown := endpointBaseCooldown << min(stats.failures-1, 5)
+		if cooldown > endpointMaxCooldown {
+			cooldown = endpointMaxCooldown
+		}
+		stats.cooldownUntil = t.now().Add(cooldown)
+		log.Debugf("upstream endpoint %s failed %d time(s), cooling down for %s", baseURL, stats.failures, cooldown)
+		return
+	}
+	stats.failures = 0
+	stats.cooldownUntil = time.Time{}
+	if stats.latency == 0 {
+		stats.latency = latency
+		return
+	}
+	stats.latency = time.Duration(endpointLatencyWeight*float64(latency) + (1-endpointLatencyWeight)*float64(stats.latency))
+}
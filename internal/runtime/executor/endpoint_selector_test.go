@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestEndpointTracker_PrefersLowLatencyAndFailsOver(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tracker := &endpointTracker{stats: make(map[string]*endpointStats), now: func() time.Time { return now }}
+	candidates := []string{"https://us.example.com", "https://eu.example.com"}
+
+	if got := tracker.pick(candidates); got != candidates[0] {
+		t.Fatalf("expected primary endpoint first, got %s", got)
+	}
+	tracker.observe(candidates[0], 400*time.Millisecond, false)
+	if got := tracker.pick(candidates); got != candidates[1] {
+		t.Fatalf("expected untried endpoint to be probed, got %s", got)
+	}
+	tracker.observe(candidates[1], 100*time.Millisecond, false)
+	if got := tracker.pick(candidates); got != candidates[1] {
+		t.Fatalf("expected lowest-latency endpoint, got %s", got)
+	}
+
+	tracker.observe(candidates[1], 0, true)
+	if got := tracker.pick(candidates); got != candidates[0] {
+		t.Fatalf("expected failover to healthy endpoint, got %s", got)
+	}
+
+	now = now.Add(time.Second)
+	tracker.observe(candidates[0], 0, true)
+	if got := tracker.pick(candidates); got != candidates[1] {
+		t.Fatalf("expected endpoint recovering first when all are cooling down, got %s", got)
+	}
+
+	now = now.Add(endpointBaseCooldown + time.Second)
+	if got := tracker.pick(candidates); got != candidates[1] {
+		t.Fatalf("expected recovered low-latency endpoint, got %s", got)
+	}
+}
+
+func TestSelectBaseURL_SingleEndpoint(t *testing.T) {
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": "https://api.example.com"}}
+	if got := selectBaseURL(auth); got != "https://api.example.com" {
+		t.Fatalf("expected base_url passthrough, got %s", got)
+	}
+	// Single-endpoint credentials are never tracked.
+	observeBaseURL(auth, "https://api.example.com", time.Now(), &http.Response{StatusCode: http.StatusBadGateway}, nil)
+	defaultEndpointTracker.mu.Lock()
+	_, tracked := defaultEndpointTracker.stats["https://api.example.com"]
+	defaultEndpointTracker.mu.Unlock()
+	if tracked {
+		t.Fatal("expected single-endpoint credential to be ignored by the tracker")
+	}
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// maxUpstreamErrorCapture bounds how much of an upstream error body is kept for debugging.
+const maxUpstreamErrorCapture = 64 << 10
+
+// upstreamErrorTransport records the raw body of upstream error responses on the Gin
+// context so handlers can expose it to clients with error debugging enabled.
+type upstreamErrorTransport struct {
+	base http.RoundTripper
+}
+
+// withUpstreamErrorCapture wraps client so upstream error bodies are captured as read.
+func withUpstreamErrorCapture(client *http.Client) *http.Client {
+	if client == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{Transport: &upstreamErrorTransport{base: base}, Timeout: client.Timeout}
+}
+
+func (t *upstreamErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+	ginCtx := ginContextFrom(req.Context())
+	if ginCtx == nil {
+		return resp, err
+	}
+	resp.Body = &errorCaptureReadCloser{ReadCloser: resp.Body, ginCtx: ginCtx, status: resp.StatusCode}
+	return resp, nil
+}
+
+// errorCaptureReadCloser tees up to maxUpstreamErrorCapture bytes and stores them once the
+// body is fully read or closed.
+type errorCaptureReadCloser struct {
+	io.ReadCloser
+	ginCtx *gin.Context
+	status int
+	buf    bytes.Buffer
+	once   sync.Once
+}
+
+func (r *errorCaptureReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && r.buf.Len() < maxUpstreamErrorCapture {
+		r.buf.Write(p[:min(n, maxUpstreamErrorCapture-r.buf.Len())])
+	}
+	if err == io.EOF {
+		r.store()
+	}
+	return n, err
+}
+
+func (r *errorCaptureReadCloser) Close() error {
+	r.store()
+	return r.ReadCloser.Close()
+}
+
+func (r *errorCaptureReadCloser) store() {
+	r.once.Do(func() {
+		r.ginCtx.Set(interfaces.UpstreamErrorContextKey, interfaces.UpstreamError{
+			StatusCode: r.status,
+			Body:       bytes.Clone(r.buf.Bytes()),
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+func TestUpstreamErrorCapture_StoresErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			_, _ = w.Write([]byte("fine"))
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"slow down"}`))
+	}))
+	defer server.Close()
+
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	client := withUpstreamErrorCapture(&http.Client{})
+
+	for _, path := range []string{"/ok", "/fail"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request %s: %v", path, err)
+		}
+		_, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if path == "/ok" {
+			if _, exists := ginCtx.Get(interfaces.UpstreamErrorContextKey); exists {
+				t.Fatal("successful responses must not be captured")
+			}
+		}
+	}
+
+	value, exists := ginCtx.Get(interfaces.UpstreamErrorContextKey)
+	if !exists {
+		t.Fatal("expected upstream error to be captured")
+	}
+	captured := value.(interfaces.UpstreamError)
+	if captured.StatusCode != http.StatusTooManyRequests || string(captured.Body) != `{"error":"slow down"}` {
+		t.Fatalf("unexpected capture: %d %s", captured.StatusCode, captured.Body)
+	}
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	fimModeNone        = "none"
+	fimModeMistral     = "mistral"
+	fimModeDeepSeek    = "deepseek"
+	fimModeCompletions = "completions"
+)
+
+// resolveFIMMode returns the native fill-in-the-middle dialect for a provider. An empty
+// configured mode auto-detects well-known hosts; unknown providers fall back to chat emulation.
+func resolveFIMMode(configured, baseURL string) string {
+	mode := strings.ToLower(strings.TrimSpace(configured))
+	switch mode {
+	case fimModeMistral, fimModeDeepSeek, fimModeCompletions:
+		return mode
+	case fimModeNone:
+		return ""
+	}
+	parsed, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case host == "mistral.ai" || strings.HasSuffix(host, ".mistral.ai"):
+		return fimModeMistral
+	case host == "deepseek.com" || strings.HasSuffix(host, ".deepseek.com"):
+		return fimModeDeepSeek
+	}
+	return ""
+}
+
+// fimEndpoint builds the upstream URL for a native FIM request.
+func fimEndpoint(mode, baseURL string) string {
+	base := strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	switch mode {
+	case fimModeMistral:
+		return base + "/fim/completions"
+	case fimModeDeepSeek:
+		// FIM is only served from the beta API regardless of the configured version path.
+		if parsed, err := url.Parse(base); err == nil && parsed.Host != "" {
+			return parsed.Scheme + "://" + parsed.Host + "/beta/completions"
+		}
+		return base + "/beta/completions"
+	default:
+		return base + "/completions"
+	}
+}
+
+// buildFIMPayload converts a translated chat completions payload into a prompt/suffix
+// request, keeping the sampling parameters shared by both schemas.
+func buildFIMPayload(chatPayload []byte, prompt, suffix string) []byte {
+	root := gjson.ParseBytes(chatPayload)
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", root.Get("model").String())
+	out, _ = sjson.SetBytes(out, "prompt", prompt)
+	out, _ = sjson.SetBytes(out, "suffix", suffix)
+	for _, key := range []string{"max_tokens", "temperature", "top_p", "stop", "stream", "seed", "frequency_penalty", "presence_penalty"} {
+		if value := root.Get(key); value.Exists() {
+			out, _ = sjson.SetRawBytes(out, key, []byte(value.Raw))
+		}
+	}
+	return out
+}
+
+// convertFIMResponseToChat rewrites a text_completion response into chat completion form
+// so the regular OpenAI translators can process it. Chat-shaped responses pass through.
+func convertFIMResponseToChat(body []byte) []byte {
+	choices := gjson.GetBytes(body, "choices")
+	if !choices.IsArray() {
+		return body
+	}
+	out := body
+	converted := false
+	choices.ForEach(func(key, choice gjson.Result) bool {
+		text := choice.Get("text")
+		if !text.Exists() || choice.Get("message").Exists() {
+			return true
+		}
+		idx := key.String()
+		out, _ = sjson.SetBytes(out, "choices."+idx+".message.role", "assistant")
+		out, _ = sjson.SetBytes(out, "choices."+idx+".message.content", text.String())
+		out, _ = sjson.DeleteBytes(out, "choices."+idx+".text")
+		converted = true
+		return true
+	})
+	if converted {
+		out, _ = sjson.SetBytes(out, "object", "chat.completion")
+	}
+	return out
+}
+
+// convertFIMStreamLineToChat rewrites a text_completion SSE line into a chat completion chunk.
+func convertFIMStreamLineToChat(line []byte) []byte {
+	if !bytes.HasPrefix(line, []byte("data:")) {
+		return line
+	}
+	data := bytes.TrimSpace(line[len("data:"):])
+	if len(data) == 0 || bytes.Equal(data, []byte("[DONE]")) || !gjson.ValidBytes(data) {
+		return line
+	}
+	choices := gjson.GetBytes(data, "choices")
+	if !choices.IsArray() {
+		return line
+	}
+	out := data
+	converted := false
+	choices.ForEach(func(key, choice gjson.Result) bool {
+		text := choice.Get("text")
+		if !text.Exists() || choice.Get("delta").Exists() {
+			return true
+		}
+		idx := key.String()
+		out, _ = sjson.SetBytes(out, "choices."+idx+".delta.content", text.String())
+		out, _ = sjson.DeleteBytes(out, "choices."+idx+".text")
+		converted = true
+		return true
+	})
+	if !converted {
+		return line
+	}
+	out, _ = sjson.SetBytes(out, "object", "chat.completion.chunk")
+	return append([]byte("data: "), out...)
+}
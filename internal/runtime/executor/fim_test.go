@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestResolveFIMMode(t *testing.T) {
+	cases := []struct {
+		configured, baseURL, want string
+	}{
+		{"", "https://codestral.mistral.ai/v1", fimModeMistral},
+		{"", "https://api.deepseek.com/v1", fimModeDeepSeek},
+		{"", "https://openrouter.ai/api/v1", ""},
+		{"none", "https://api.mistral.ai/v1", ""},
+		{"Completions", "http://localhost:8080/v1", fimModeCompletions},
+	}
+	for _, tc := range cases {
+		if got := resolveFIMMode(tc.configured, tc.baseURL); got != tc.want {
+			t.Errorf("resolveFIMMode(%q, %q) = %q, want %q", tc.configured, tc.baseURL, got, tc.want)
+		}
+	}
+	if got := fimEndpoint(fimModeDeepSeek, "https://api.deepseek.com/v1"); got != "https://api.deepseek.com/beta/completions" {
+		t.Errorf("deepseek endpoint = %q", got)
+	}
+	if got := fimEndpoint(fimModeMistral, "https://api.mistral.ai/v1/"); got != "https://api.mistral.ai/v1/fim/completions" {
+		t.Errorf("mistral endpoint = %q", got)
+	}
+}
+
+func TestConvertFIMStreamLineToChat(t *testing.T) {
+	line := convertFIMStreamLineToChat([]byte(`data: {"id":"1","object":"text_completion","choices":[{"index":0,"text":"x + y","finish_reason":null}]}`))
+	data := line[len("data: "):]
+	if got := gjson.GetBytes(data, "choices.0.delta.content").String(); got != "x + y" {
+		t.Fatalf("delta.content = %q in %s", got, line)
+	}
+	if gjson.GetBytes(data, "choices.0.text").Exists() {
+		t.Fatalf("text should be removed: %s", line)
+	}
+	done := []byte("data: [DONE]")
+	if got := convertFIMStreamLineToChat(done); string(got) != string(done) {
+		t.Fatalf("[DONE] changed to %q", got)
+	}
+}
+
+func TestOpenAICompatExecutorNativeFIM(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","object":"text_completion","choices":[{"index":0,"text":"return a + b","finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{Name: "local", BaseURL: server.URL, FIM: "completions"}}}
+	auth := &cliproxyauth.Auth{ID: "local-fim", Provider: "local", Attributes: map[string]string{"base_url": server.URL, "compat_name": "local"}}
+	req := cliproxyexecutor.Request{Model: "coder", Payload: []byte(`{"model":"coder","max_tokens":32,"messages":[{"role":"system","content":"emulated"},{"role":"user","content":"def add(a, b):\n<FILL_ME>\n"}]}`)}
+	opts := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai"),
+		Metadata:     map[string]any{util.FIMPromptMetadataKey: "def add(a, b):\n", util.FIMSuffixMetadataKey: "\n"},
+	}
+
+	resp, err := NewOpenAICompatExecutor("local", cfg).Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotPath != "/completions" {
+		t.Fatalf("path = %q, want /completions", gotPath)
+	}
+	if gjson.GetBytes(gotBody, "messages").Exists() || gjson.GetBytes(gotBody, "suffix").String() != "\n" || gjson.GetBytes(gotBody, "max_tokens").Int() != 32 {
+		t.Fatalf("unexpected upstream body: %s", gotBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "return a + b" {
+		t.Fatalf("content = %q in %s", got, resp.Payload)
+	}
+}
@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// prepareFireworksPayload maps structured-output constraints onto Fireworks' extensions.
+func prepareFireworksPayload(payload []byte, _ string) []byte {
+	return normalizeFireworksResponseFormat(payload)
+}
+
+// fireworksModelPath expands a short model name into the accounts/fireworks/models
+// namespace. Names that already carry an account path are returned unchanged.
+func fireworksModelPath(model string) string {
+	model = strings.TrimSpace(model)
+	if model == "" || strings.Contains(model, "/") {
+		return model
+	}
+	return "accounts/fireworks/models/" + model
+}
+
+// normalizeFireworksResponseFormat maps structured-output constraints onto Fireworks'
+// response_format extensions. A top-level GBNF "grammar" becomes a grammar response
+// format, and OpenAI json_schema requests use the json_object mode with an inline schema.
+func normalizeFireworksResponseFormat(payload []byte) []byte {
+	if grammar := gjson.GetBytes(payload, "grammar"); grammar.Exists() {
+		payload, _ = sjson.DeleteBytes(payload, "grammar")
+		if grammar.Type == gjson.String && grammar.String() != "" {
+			payload, _ = sjson.SetBytes(payload, "response_format", map[string]string{"type": "grammar", "grammar": grammar.String()})
+			return payload
+		}
+	}
+	format := gjson.GetBytes(payload, "response_format")
+	if format.Get("type").String() != "json_schema" {
+		return payload
+	}
+	out := []byte(`{"type":"json_object"}`)
+	if schema := format.Get("json_schema.schema"); schema.Exists() {
+		out, _ = sjson.SetRawBytes(out, "schema", []byte(schema.Raw))
+	}
+	payload, _ = sjson.SetRawBytes(payload, "response_format", out)
+	return payload
+}
@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestFireworksModelPath(t *testing.T) {
+	cases := map[string]string{
+		"deepseek-v3p1":                    "accounts/fireworks/models/deepseek-v3p1",
+		"accounts/acme/models/fine-tune-1": "accounts/acme/models/fine-tune-1",
+		"":                                 "",
+	}
+	for in, want := range cases {
+		if got := fireworksModelPath(in); got != want {
+			t.Errorf("fireworksModelPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeFireworksResponseFormat(t *testing.T) {
+	out := normalizeFireworksResponseFormat([]byte(`{"grammar":"root ::= \"yes\" | \"no\"","response_format":{"type":"text"}}`))
+	if gjson.GetBytes(out, "grammar").Exists() {
+		t.Fatalf("top-level grammar must be removed: %s", out)
+	}
+	if got := gjson.GetBytes(out, "response_format.type").String(); got != "grammar" {
+		t.Fatalf("response_format.type = %q", got)
+	}
+	if got := gjson.GetBytes(out, "response_format.grammar").String(); got != `root ::= "yes" | "no"` {
+		t.Fatalf("response_format.grammar = %q", got)
+	}
+
+	out = normalizeFireworksResponseFormat([]byte(`{"response_format":{"type":"json_schema","json_schema":{"name":"x","schema":{"type":"object","required":["a"]}}}}`))
+	if got := gjson.GetBytes(out, "response_format.type").String(); got != "json_object" {
+		t.Fatalf("response_format.type = %q", got)
+	}
+	if got := gjson.GetBytes(out, "response_format.schema.required.0").String(); got != "a" {
+		t.Fatalf("schema not inlined: %s", out)
+	}
+
+	passthrough := []byte(`{"response_format":{"type":"json_object"}}`)
+	if got := normalizeFireworksResponseFormat(passthrough); string(got) != string(passthrough) {
+		t.Fatalf("json_object should pass through, got %s", got)
+	}
+}
@@ -0,0 +1,244 @@
+// Package executor provides runtime execution capabilities for various AI service providers.
+// This file implements Anthropic Claude models served through Vertex AI. They share the
+// Vertex credentials (service account or Application Default Credentials) and regional
+// endpoints with Gemini, but use the Anthropic publisher's rawPredict endpoints and the
+// Claude Messages payload.
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// vertexAnthropicVersion is the anthropic_version Vertex AI requires in Claude payloads.
+const vertexAnthropicVersion = "vertex-2023-10-16"
+
+// vertexClaudeDateSuffix matches the snapshot date Anthropic appends to model IDs.
+var vertexClaudeDateSuffix = regexp.MustCompile(`-(\d{8})$`)
+
+// isVertexClaudeModel reports whether model is an Anthropic model served by Vertex AI.
+func isVertexClaudeModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(model)), "claude-")
+}
+
+// vertexClaudeModelID converts an Anthropic model ID to its Vertex form, which separates
+// the snapshot date with "@" (claude-sonnet-4-5-20250929 -> claude-sonnet-4-5@20250929).
+func vertexClaudeModelID(model string) string {
+	if strings.Contains(model, "@") {
+		return model
+	}
+	return vertexClaudeDateSuffix.ReplaceAllString(model, "@$1")
+}
+
+// vertexClaudeURL builds the Anthropic publisher endpoint for the given action.
+func vertexClaudeURL(projectID, location, model, action string) string {
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/anthropic/models/%s:%s", vertexBaseURL(location), vertexAPIVersion, projectID, location, model, action)
+}
+
+// buildVertexClaudeBody translates the request to the Claude Messages format and adapts it
+// for Vertex: the model moves to the URL and anthropic_version is required.
+func (e *GeminiVertexExecutor) buildVertexClaudeBody(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (body []byte, extraBetas []string) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	originalPayload := bytes.Clone(req.Payload)
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = bytes.Clone(opts.OriginalRequest)
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, stream)
+	body = sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), stream)
+	if budget, ok := util.ResolveClaudeThinkingConfig(req.Model, req.Metadata); ok {
+		body = util.ApplyClaudeThinkingConfig(body, budget)
+	}
+	body = applyPayloadConfigWithRoot(ctx, e.cfg, req.Model, to.String(), "", body, originalTranslated)
+	body = disableThinkingIfToolChoiceForced(body)
+	body = ensureMaxTokensForThinking(req.Model, body)
+	extraBetas, body = extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.SetBytes(body, "anthropic_version", vertexAnthropicVersion)
+	body, _ = sjson.SetBytes(body, "stream", stream)
+	return body, extraBetas
+}
+
+// doVertexClaudeRequest sends a Claude payload to Vertex and returns the successful response.
+func (e *GeminiVertexExecutor) doVertexClaudeRequest(ctx context.Context, auth *cliproxyauth.Auth, url string, body, saJSON []byte, extraBetas []string) (*http.Response, error) {
+	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if errNewReq != nil {
+		return nil, errNewReq
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if len(extraBetas) > 0 {
+		httpReq.Header.Set("anthropic-beta", strings.Join(extraBetas, ","))
+	}
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+	if errTok != nil {
+		log.Errorf("vertex executor: access token error: %v", errTok)
+		return nil, statusErr{code: 500, msg: "internal server error"}
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, errDo := httpClient.Do(httpReq)
+	if errDo != nil {
+		recordAPIResponseError(ctx, e.cfg, errDo)
+		return nil, errDo
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	reportQuotaHeaders(ctx, httpResp.Header)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+		return nil, newUpstreamStatusErr(httpResp, b)
+	}
+	return httpResp, nil
+}
+
+// executeClaudeWithServiceAccount performs a non-streaming Claude request on Vertex AI.
+func (e *GeminiVertexExecutor) executeClaudeWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	// Use streaming translation to preserve function calling, except for claude.
+	stream := from != to
+	body, extraBetas := e.buildVertexClaudeBody(ctx, req, opts, stream)
+	action := "rawPredict"
+	if stream {
+		action = "streamRawPredict"
+	}
+	url := vertexClaudeURL(projectID, location, vertexClaudeModelID(req.Model), action)
+
+	httpResp, err := e.doVertexClaudeRequest(ctx, auth, url, body, saJSON, extraBetas)
+	if err != nil {
+		return resp, err
+	}
+	data, err := readUpstreamBody(ctx, e.cfg, httpResp, "vertex")
+	if err != nil {
+		return resp, err
+	}
+	if stream {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+		}
+	} else {
+		reporter.publish(ctx, parseClaudeUsage(data))
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+// executeClaudeStreamWithServiceAccount performs a streaming Claude request on Vertex AI.
+func (e *GeminiVertexExecutor) executeClaudeStreamWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body, extraBetas := e.buildVertexClaudeBody(ctx, req, opts, true)
+	url := vertexClaudeURL(projectID, location, vertexClaudeModelID(req.Model), "streamRawPredict")
+
+	httpResp, err := e.doVertexClaudeRequest(ctx, auth, url, body, saJSON, extraBetas)
+	if err != nil {
+		return nil, err
+	}
+	out := newStreamChannel(opts)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("vertex executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := newStreamScanner(httpResp.Body, e.Identifier(), streamLineLimit(e.cfg, e.Identifier(), opts))
+		stream := newStreamTranslator(ctx, opts, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			if from == to {
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				continue
+			}
+			chunks := stream.Translate(bytes.Clone(line))
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+	}()
+	return stream, nil
+}
+
+// countClaudeTokensWithServiceAccount counts tokens through the Vertex count-tokens endpoint,
+// which takes the Vertex model ID in the payload.
+func (e *GeminiVertexExecutor) countClaudeTokensWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), from != to)
+	var extraBetas []string
+	extraBetas, body = extractAndRemoveBetas(body)
+	body, _ = sjson.SetBytes(body, "model", vertexClaudeModelID(req.Model))
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.DeleteBytes(body, "max_tokens")
+	url := vertexClaudeURL(projectID, location, "count-tokens", "rawPredict")
+
+	httpResp, err := e.doVertexClaudeRequest(ctx, auth, url, body, saJSON, extraBetas)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	data, err := readUpstreamBody(ctx, e.cfg, httpResp, "vertex")
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	count := gjson.GetBytes(data, "input_tokens").Int()
+	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
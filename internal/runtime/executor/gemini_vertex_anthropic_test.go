@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestVertexClaudeModelID(t *testing.T) {
+	cases := map[string]string{
+		"claude-sonnet-4-5-20250929": "claude-sonnet-4-5@20250929",
+		"claude-opus-4-1@20250805":   "claude-opus-4-1@20250805",
+		"claude-sonnet-4-5":          "claude-sonnet-4-5",
+	}
+	for in, want := range cases {
+		if got := vertexClaudeModelID(in); got != want {
+			t.Errorf("vertexClaudeModelID(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if !isVertexClaudeModel("claude-haiku-4-5-20251001") || isVertexClaudeModel("gemini-2.5-pro") {
+		t.Fatal("isVertexClaudeModel misclassified models")
+	}
+}
+
+func TestVertexEndpointsAndADCCreds(t *testing.T) {
+	if got := vertexBaseURL("global"); got != "https://aiplatform.googleapis.com" {
+		t.Fatalf("global base URL = %q", got)
+	}
+	if got := vertexClaudeURL("p", "europe-west1", "claude-sonnet-4-5@20250929", "rawPredict"); got != "https://europe-west1-aiplatform.googleapis.com/v1/projects/p/locations/europe-west1/publishers/anthropic/models/claude-sonnet-4-5@20250929:rawPredict" {
+		t.Fatalf("claude URL = %q", got)
+	}
+
+	auth := &cliproxyauth.Auth{Metadata: map[string]any{"project_id": "p", "credential_source": "adc", "location": "global"}}
+	project, location, saJSON, err := vertexCreds(auth)
+	if err != nil || project != "p" || location != "global" || saJSON != nil {
+		t.Fatalf("vertexCreds = %q %q %v %v", project, location, saJSON, err)
+	}
+}
+
+func TestBuildVertexClaudeBody(t *testing.T) {
+	e := NewGeminiVertexExecutor(nil)
+	req := cliproxyexecutor.Request{Model: "claude-sonnet-4-5-20250929", Payload: []byte(`{"model":"claude-sonnet-4-5-20250929","max_tokens":64,"messages":[{"role":"user","content":"hi"}],"betas":["context-1m-2025-08-07"]}`)}
+	body, betas := e.buildVertexClaudeBody(context.Background(), req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")}, false)
+	if gjson.GetBytes(body, "model").Exists() {
+		t.Fatalf("model must move to the URL: %s", body)
+	}
+	if got := gjson.GetBytes(body, "anthropic_version").String(); got != vertexAnthropicVersion {
+		t.Fatalf("anthropic_version = %q", got)
+	}
+	if len(betas) != 1 || betas[0] != "context-1m-2025-08-07" {
+		t.Fatalf("betas = %v", betas)
+	}
+}
@@ -0,0 +1,18 @@
+package executor
+
+import "testing"
+
+func TestCopilotAPIBaseURL(t *testing.T) {
+	cases := map[string]string{
+		"https://api.business.githubcopilot.com/":  "https://api.business.githubcopilot.com",
+		"https://api.enterprise.githubcopilot.com": "https://api.enterprise.githubcopilot.com",
+		"":                                      githubCopilotBaseURL,
+		"http://api.business.githubcopilot.com": githubCopilotBaseURL,
+		"https://githubcopilot.com.attacker.example": githubCopilotBaseURL,
+	}
+	for advertised, want := range cases {
+		if got := copilotAPIBaseURL(advertised); got != want {
+			t.Errorf("copilotAPIBaseURL(%q) = %q, want %q", advertised, got, want)
+		}
+	}
+}
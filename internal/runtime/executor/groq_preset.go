@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// FetchGroqModels lists the models available to auth via the OpenAI-compatible /models endpoint.
+func FetchGroqModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	data := fetchCompatModelList(ctx, "groq", auth, cfg)
+	if data == nil {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	var models []*registry.ModelInfo
+	for _, item := range gjson.GetBytes(data, "data").Array() {
+		id := strings.TrimSpace(item.Get("id").String())
+		if id == "" || !item.Get("active").Bool() && item.Get("active").Exists() {
+			continue
+		}
+		ownedBy := item.Get("owned_by").String()
+		if ownedBy == "" {
+			ownedBy = "groq"
+		}
+		models = append(models, &registry.ModelInfo{
+			ID:            id,
+			Object:        "model",
+			Created:       now,
+			OwnedBy:       ownedBy,
+			Type:          "groq",
+			DisplayName:   id,
+			ContextLength: int(item.Get("context_window").Int()),
+		})
+	}
+	return models
+}
+
+// rateLimitWindowsExhausted returns the longest reset among the given x-ratelimit-*
+// windows whose remaining budget is spent.
+func rateLimitWindowsExhausted(header http.Header, windows ...string) (time.Duration, bool) {
+	var wait time.Duration
+	for _, window := range windows {
+		remaining := strings.TrimSpace(header.Get("x-ratelimit-remaining-" + window))
+		if remaining == "" {
+			continue
+		}
+		if n, err := strconv.ParseFloat(remaining, 64); err != nil || n > 0 {
+			continue
+		}
+		if reset, ok := parseRateLimitReset(header.Get("x-ratelimit-reset-" + window)); ok && reset > wait {
+			wait = reset
+		}
+	}
+	return wait, wait > 0
+}
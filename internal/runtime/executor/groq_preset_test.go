@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestGroqBudgetExhausted(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-ratelimit-remaining-requests", "0")
+	header.Set("x-ratelimit-reset-requests", "2m59.56s")
+	header.Set("x-ratelimit-remaining-tokens", "0")
+	header.Set("x-ratelimit-reset-tokens", "7.66s")
+	wait, exhausted := quotaExhaustedFromHeader(header, time.Now())
+	if !exhausted {
+		t.Fatal("expected the budget to be exhausted")
+	}
+	if want := 2*time.Minute + 59560*time.Millisecond; wait != want {
+		t.Fatalf("wait = %s, want %s", wait, want)
+	}
+
+	header.Set("x-ratelimit-remaining-requests", "14")
+	header.Set("x-ratelimit-remaining-tokens", "5000")
+	if _, exhausted = quotaExhaustedFromHeader(header, time.Now()); exhausted {
+		t.Fatal("a response with remaining budget must not report exhaustion")
+	}
+}
+
+func TestRetryAfterFromHeader(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("x-ratelimit-reset-requests", "1.5s")
+	header.Set("x-ratelimit-reset-tokens", "7.66s")
+	if wait, ok := retryAfterFromHeader(header, now); !ok || wait != 7660*time.Millisecond {
+		t.Fatalf("reset header wait = %s, %v", wait, ok)
+	}
+	header.Set("Retry-After", "12")
+	if wait, ok := retryAfterFromHeader(header, now); !ok || wait != 12*time.Second {
+		t.Fatalf("retry-after seconds wait = %s, %v", wait, ok)
+	}
+	header.Set("Retry-After", now.Add(30*time.Second).Format(http.TimeFormat))
+	if wait, ok := retryAfterFromHeader(header, now); !ok || wait != 30*time.Second {
+		t.Fatalf("retry-after date wait = %s, %v", wait, ok)
+	}
+	if _, ok := retryAfterFromHeader(http.Header{}, now); ok {
+		t.Fatal("expected no wait without headers")
+	}
+}
+
+func TestOpenAICompatExecutorGroq429CarriesRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ratelimit-reset-tokens", "30s")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "groq-429-test", Provider: "groq", Attributes: map[string]string{"base_url": server.URL, "api_key": "gsk"}}
+	exec := NewOpenAICompatExecutor("groq", nil)
+	req := cliproxyexecutor.Request{Model: "llama-3.3-70b-versatile", Payload: []byte(`{"model":"llama-3.3-70b-versatile","messages":[{"role":"user","content":"hi"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	_, err := exec.Execute(context.Background(), auth, req, opts)
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 statusErr, got %v", err)
+	}
+	if se.RetryAfter() == nil || *se.RetryAfter() != 30*time.Second {
+		t.Fatalf("unexpected retry-after: %v", se.RetryAfter())
+	}
+}
@@ -196,20 +196,34 @@ func isIDCAuth(auth *cliproxyauth.Auth) bool {
 // - Claude: tools[].name, tools[].description
 // headers parameter allows checking Anthropic-Beta header for thinking mode detection.
 // Returns the serialized JSON payload and a boolean indicating whether thinking mode was injected.
-func buildKiroPayloadForFormat(body []byte, modelID, profileArn, origin string, isAgentic, isChatOnly bool, sourceFormat sdktranslator.Format, headers http.Header) ([]byte, bool) {
+func buildKiroPayloadForFormat(ctx context.Context, auth *cliproxyauth.Auth, body []byte, modelID, profileArn, origin string, isAgentic, isChatOnly bool, sourceFormat sdktranslator.Format, headers http.Header) ([]byte, bool) {
+	var payload []byte
+	var thinkingEnabled bool
 	switch sourceFormat.String() {
 	case "openai":
 		log.Debugf("kiro: using OpenAI payload builder for source format: %s", sourceFormat.String())
-		return kiroopenai.BuildKiroPayloadFromOpenAI(body, modelID, profileArn, origin, isAgentic, isChatOnly, headers, nil)
+		payload, thinkingEnabled = kiroopenai.BuildKiroPayloadFromOpenAI(body, modelID, profileArn, origin, isAgentic, isChatOnly, headers, nil)
 	default:
 		// Default to Claude format (also handles "claude", "kiro", etc.)
 		log.Debugf("kiro: using Claude payload builder for source format: %s", sourceFormat.String())
-		return kiroclaude.BuildKiroPayload(body, modelID, profileArn, origin, isAgentic, isChatOnly, headers, nil)
+		payload, thinkingEnabled = kiroclaude.BuildKiroPayload(body, modelID, profileArn, origin, isAgentic, isChatOnly, headers, nil)
 	}
+	// Keep multi-turn chats on one server-side conversation when session mapping is enabled.
+	// The scope pins the mapping to one client key and one Kiro account so conversation
+	// IDs are never shared across tenants or credentials.
+	authID := ""
+	if auth != nil {
+		authID = auth.ID
+	}
+	scope := "kiro|" + authID + "|" + apiKeyFromContext(ctx) + "|" + modelID
+	payload = reuseConversationID(ctx, scope, body, payload, "conversationState.conversationId")
+	return payload, thinkingEnabled
 }
 
 // NewKiroExecutor creates a new Kiro executor instance.
 func NewKiroExecutor(cfg *config.Config) *KiroExecutor {
+	// Executors are rebuilt on every config reload, so this is where session settings apply.
+	configureConversationSessions(cfg)
 	return &KiroExecutor{cfg: cfg}
 }
 
@@ -323,7 +337,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 
 		// Rebuild payload with the correct origin for this endpoint
 		// Each endpoint requires its matching Origin value in the request body
-		kiroPayload, _ = buildKiroPayloadForFormat(body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
+		kiroPayload, _ = buildKiroPayloadForFormat(ctx, auth, body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
 
 		log.Debugf("kiro: trying endpoint %d/%d: %s (Name: %s, Origin: %s)",
 			endpointIdx+1, len(endpointConfigs), url, endpointConfig.Name, currentOrigin)
@@ -388,6 +402,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 				return resp, err
 			}
 			recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+			reportQuotaHeaders(ctx, httpResp.Header)
 
 			// Handle 429 errors (quota exhausted) - try next endpoint
 			// Each endpoint has its own quota pool, so we can try different endpoints
@@ -397,7 +412,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 				appendAPIResponseChunk(ctx, e.cfg, respBody)
 
 				// Preserve last 429 so callers can correctly backoff when all endpoints are exhausted
-				last429Err = statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				last429Err = newUpstreamStatusErr(httpResp, respBody)
 
 				log.Warnf("kiro: %s endpoint quota exhausted (429), will try next endpoint, body: %s",
 					endpointConfig.Name, summarizeErrorBody(httpResp.Header.Get("Content-Type"), respBody))
@@ -423,7 +438,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 					continue
 				}
 				log.Errorf("kiro: server error %d after %d retries", httpResp.StatusCode, maxRetries)
-				return resp, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return resp, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			// Handle 401 errors with token refresh and retry
@@ -439,7 +454,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 					refreshedAuth, refreshErr := e.Refresh(ctx, auth)
 					if refreshErr != nil {
 						log.Errorf("kiro: token refresh failed: %v", refreshErr)
-						return resp, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+						return resp, newUpstreamStatusErr(httpResp, respBody)
 					}
 
 					if refreshedAuth != nil {
@@ -451,14 +466,14 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 						}
 						accessToken, profileArn = kiroCredentials(auth)
 						// Rebuild payload with new profile ARN if changed
-						kiroPayload, _ = buildKiroPayloadForFormat(body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
+						kiroPayload, _ = buildKiroPayloadForFormat(ctx, auth, body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
 						log.Infof("kiro: token refreshed successfully, retrying request")
 						continue
 					}
 				}
 
 				log.Warnf("kiro request error, status: 401, body: %s", summarizeErrorBody(httpResp.Header.Get("Content-Type"), respBody))
-				return resp, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return resp, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			// Handle 402 errors - Monthly Limit Reached
@@ -470,7 +485,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 				log.Warnf("kiro: received 402 (monthly limit). Upstream body: %s", string(respBody))
 
 				// Return upstream error body directly
-				return resp, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return resp, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			// Handle 403 errors - Access Denied / Token Expired
@@ -503,7 +518,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 					if refreshErr != nil {
 						log.Errorf("kiro: token refresh failed: %v", refreshErr)
 						// Token refresh failed - return error immediately
-						return resp, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+						return resp, newUpstreamStatusErr(httpResp, respBody)
 					}
 					if refreshedAuth != nil {
 						auth = refreshedAuth
@@ -513,7 +528,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 							// Continue anyway - the token is valid for this request
 						}
 						accessToken, profileArn = kiroCredentials(auth)
-						kiroPayload, _ = buildKiroPayloadForFormat(body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
+						kiroPayload, _ = buildKiroPayloadForFormat(ctx, auth, body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
 						log.Infof("kiro: token refreshed for 403, retrying request")
 						continue
 					}
@@ -522,14 +537,14 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 				// For non-token 403 or after max retries, return error immediately
 				// Do NOT switch endpoints for 403 errors
 				log.Warnf("kiro: 403 error, returning immediately (no endpoint switch)")
-				return resp, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return resp, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 				b, _ := io.ReadAll(httpResp.Body)
 				appendAPIResponseChunk(ctx, e.cfg, b)
 				log.Debugf("kiro request error, status: %d, body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-				err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+				err = newUpstreamStatusErr(httpResp, b)
 				if errClose := httpResp.Body.Close(); errClose != nil {
 					log.Errorf("response body close error: %v", errClose)
 				}
@@ -656,7 +671,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 
 		// Rebuild payload with the correct origin for this endpoint
 		// Each endpoint requires its matching Origin value in the request body
-		kiroPayload, thinkingEnabled := buildKiroPayloadForFormat(body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
+		kiroPayload, thinkingEnabled := buildKiroPayloadForFormat(ctx, auth, body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
 
 		log.Debugf("kiro: stream trying endpoint %d/%d: %s (Name: %s, Origin: %s)",
 			endpointIdx+1, len(endpointConfigs), url, endpointConfig.Name, currentOrigin)
@@ -721,6 +736,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				return nil, err
 			}
 			recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+			reportQuotaHeaders(ctx, httpResp.Header)
 
 			// Handle 429 errors (quota exhausted) - try next endpoint
 			// Each endpoint has its own quota pool, so we can try different endpoints
@@ -730,7 +746,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				appendAPIResponseChunk(ctx, e.cfg, respBody)
 
 				// Preserve last 429 so callers can correctly backoff when all endpoints are exhausted
-				last429Err = statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				last429Err = newUpstreamStatusErr(httpResp, respBody)
 
 				log.Warnf("kiro: stream %s endpoint quota exhausted (429), will try next endpoint, body: %s",
 					endpointConfig.Name, summarizeErrorBody(httpResp.Header.Get("Content-Type"), respBody))
@@ -756,7 +772,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 					continue
 				}
 				log.Errorf("kiro: stream server error %d after %d retries", httpResp.StatusCode, maxRetries)
-				return nil, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return nil, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			// Handle 400 errors - Credential/Validation issues
@@ -769,7 +785,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				log.Warnf("kiro: received 400 error (attempt %d/%d), body: %s", attempt+1, maxRetries+1, summarizeErrorBody(httpResp.Header.Get("Content-Type"), respBody))
 
 				// 400 errors indicate request validation issues - return immediately without retry
-				return nil, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return nil, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			// Handle 401 errors with token refresh and retry
@@ -785,7 +801,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 					refreshedAuth, refreshErr := e.Refresh(ctx, auth)
 					if refreshErr != nil {
 						log.Errorf("kiro: token refresh failed: %v", refreshErr)
-						return nil, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+						return nil, newUpstreamStatusErr(httpResp, respBody)
 					}
 
 					if refreshedAuth != nil {
@@ -797,14 +813,14 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 						}
 						accessToken, profileArn = kiroCredentials(auth)
 						// Rebuild payload with new profile ARN if changed
-						kiroPayload, _ = buildKiroPayloadForFormat(body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
+						kiroPayload, _ = buildKiroPayloadForFormat(ctx, auth, body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
 						log.Infof("kiro: token refreshed successfully, retrying stream request")
 						continue
 					}
 				}
 
 				log.Warnf("kiro stream error, status: 401, body: %s", string(respBody))
-				return nil, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return nil, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			// Handle 402 errors - Monthly Limit Reached
@@ -816,7 +832,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				log.Warnf("kiro: stream received 402 (monthly limit). Upstream body: %s", string(respBody))
 
 				// Return upstream error body directly
-				return nil, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return nil, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			// Handle 403 errors - Access Denied / Token Expired
@@ -849,7 +865,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 					if refreshErr != nil {
 						log.Errorf("kiro: token refresh failed: %v", refreshErr)
 						// Token refresh failed - return error immediately
-						return nil, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+						return nil, newUpstreamStatusErr(httpResp, respBody)
 					}
 					if refreshedAuth != nil {
 						auth = refreshedAuth
@@ -859,7 +875,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 							// Continue anyway - the token is valid for this request
 						}
 						accessToken, profileArn = kiroCredentials(auth)
-						kiroPayload, _ = buildKiroPayloadForFormat(body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
+						kiroPayload, _ = buildKiroPayloadForFormat(ctx, auth, body, kiroModelID, profileArn, currentOrigin, isAgentic, isChatOnly, from, opts.Headers)
 						log.Infof("kiro: token refreshed for 403, retrying stream request")
 						continue
 					}
@@ -868,7 +884,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				// For non-token 403 or after max retries, return error immediately
 				// Do NOT switch endpoints for 403 errors
 				log.Warnf("kiro: 403 error, returning immediately (no endpoint switch)")
-				return nil, statusErr{code: httpResp.StatusCode, msg: string(respBody)}
+				return nil, newUpstreamStatusErr(httpResp, respBody)
 			}
 
 			if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
@@ -878,10 +894,10 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				if errClose := httpResp.Body.Close(); errClose != nil {
 					log.Errorf("response body close error: %v", errClose)
 				}
-				return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+				return nil, newUpstreamStatusErr(httpResp, b)
 			}
 
-			out := make(chan cliproxyexecutor.StreamChunk)
+			out := newStreamChannel(opts)
 
 			go func(resp *http.Response, thinkingEnabled bool) {
 				defer close(out)
@@ -901,7 +917,12 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				// So we always enable thinking parsing for Kiro responses
 				log.Debugf("kiro: stream thinkingEnabled = %v (always true for Kiro)", thinkingEnabled)
 
-				e.streamToChannel(ctx, resp.Body, out, from, req.Model, opts.OriginalRequest, body, reporter, thinkingEnabled)
+				// Raw pass-through emits the Claude events the binary stream decodes into.
+				target := from
+				if opts.StreamTuning.RawPassthrough {
+					target = sdktranslator.FromString("claude")
+				}
+				e.streamToChannel(ctx, resp.Body, out, target, req.Model, opts.OriginalRequest, body, reporter, thinkingEnabled)
 			}(httpResp, thinkingEnabled)
 
 			return out, nil
@@ -1932,9 +1953,9 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 	var upstreamContextPercentage float64 // Context usage percentage from upstream (e.g., 78.56)
 	var hasUpstreamUsage bool             // Whether we received usage from upstream
 
-	// Translator param for maintaining tool call state across streaming events
-	// IMPORTANT: This must persist across all TranslateStream calls
-	var translatorParam any
+	// Translator for the Claude events built from the Kiro stream; it keeps the tool call
+	// state across events, so all events of the stream must go through it.
+	translator := sdktranslator.NewStreamTranslator(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody)
 
 	// Thinking mode state tracking - tag-based parsing for <thinking> tags in content
 	inThinkBlock := false                          // Whether we're currently inside a <thinking> block
@@ -2017,7 +2038,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 
 				// Send tool_use content block
 				blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "tool_use", currentToolUse.ToolUseID, currentToolUse.Name)
-				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+				sseData := translator.Translate(blockStart)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2027,7 +2048,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				// Send tool input as delta
 				inputBytes, _ := json.Marshal(finalInput)
 				inputDelta := kiroclaude.BuildClaudeInputJsonDeltaEvent(string(inputBytes), contentBlockIndex)
-				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, inputDelta, &translatorParam)
+				sseData = translator.Translate(inputDelta)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2036,7 +2057,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 
 				// Close block
 				blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+				sseData = translator.Translate(blockStop)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2108,7 +2129,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 		// Send message_start on first event
 		if !messageStartSent {
 			msgStart := kiroclaude.BuildClaudeMessageStartEvent(model, totalUsage.InputTokens)
-			sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, msgStart, &translatorParam)
+			sseData := translator.Translate(msgStart)
 			for _, chunk := range sseData {
 				if chunk != "" {
 					out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2344,7 +2365,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 						// Send ping event with usage information
 						// This is a non-blocking update that clients can optionally process
 						pingEvent := kiroclaude.BuildClaudePingEventWithUsage(totalUsage.InputTokens, currentOutputTokens)
-						sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, pingEvent, &translatorParam)
+						sseData := translator.Translate(pingEvent)
 						for _, chunk := range sseData {
 							if chunk != "" {
 								out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2381,7 +2402,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 									thinkingBlockIndex = contentBlockIndex
 									isThinkingBlockOpen = true
 									blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(thinkingBlockIndex, "thinking", "", "")
-									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+									sseData := translator.Translate(blockStart)
 									for _, chunk := range sseData {
 										if chunk != "" {
 											out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2390,7 +2411,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 								}
 								// Send thinking delta
 								thinkingEvent := kiroclaude.BuildClaudeThinkingDeltaEvent(thinkingText, thinkingBlockIndex)
-								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, thinkingEvent, &translatorParam)
+								sseData := translator.Translate(thinkingEvent)
 								for _, chunk := range sseData {
 									if chunk != "" {
 										out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2401,7 +2422,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 							// Close thinking block
 							if isThinkingBlockOpen {
 								blockStop := kiroclaude.BuildClaudeThinkingBlockStopEvent(thinkingBlockIndex)
-								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+								sseData := translator.Translate(blockStop)
 								for _, chunk := range sseData {
 									if chunk != "" {
 										out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2432,7 +2453,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 										thinkingBlockIndex = contentBlockIndex
 										isThinkingBlockOpen = true
 										blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(thinkingBlockIndex, "thinking", "", "")
-										sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+										sseData := translator.Translate(blockStart)
 										for _, chunk := range sseData {
 											if chunk != "" {
 												out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2440,7 +2461,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 										}
 									}
 									thinkingEvent := kiroclaude.BuildClaudeThinkingDeltaEvent(processContent, thinkingBlockIndex)
-									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, thinkingEvent, &translatorParam)
+									sseData := translator.Translate(thinkingEvent)
 									for _, chunk := range sseData {
 										if chunk != "" {
 											out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2461,7 +2482,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 								// Close thinking block if open
 								if isThinkingBlockOpen {
 									blockStop := kiroclaude.BuildClaudeThinkingBlockStopEvent(thinkingBlockIndex)
-									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+									sseData := translator.Translate(blockStop)
 									for _, chunk := range sseData {
 										if chunk != "" {
 											out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2474,7 +2495,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 									contentBlockIndex++
 									isTextBlockOpen = true
 									blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "text", "", "")
-									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+									sseData := translator.Translate(blockStart)
 									for _, chunk := range sseData {
 										if chunk != "" {
 											out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2483,7 +2504,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 								}
 								// Send text delta
 								claudeEvent := kiroclaude.BuildClaudeStreamEvent(textBefore, contentBlockIndex)
-								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, claudeEvent, &translatorParam)
+								sseData := translator.Translate(claudeEvent)
 								for _, chunk := range sseData {
 									if chunk != "" {
 										out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2493,7 +2514,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 							// Close text block before entering thinking
 							if isTextBlockOpen {
 								blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+								sseData := translator.Translate(blockStop)
 								for _, chunk := range sseData {
 									if chunk != "" {
 										out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2523,7 +2544,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 										contentBlockIndex++
 										isTextBlockOpen = true
 										blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "text", "", "")
-										sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+										sseData := translator.Translate(blockStart)
 										for _, chunk := range sseData {
 											if chunk != "" {
 												out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2531,7 +2552,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 										}
 									}
 									claudeEvent := kiroclaude.BuildClaudeStreamEvent(processContent, contentBlockIndex)
-									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, claudeEvent, &translatorParam)
+									sseData := translator.Translate(claudeEvent)
 									for _, chunk := range sseData {
 										if chunk != "" {
 											out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2561,7 +2582,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				// Close text block if open before starting tool_use block
 				if isTextBlockOpen && contentBlockIndex >= 0 {
 					blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+					sseData := translator.Translate(blockStop)
 					for _, chunk := range sseData {
 						if chunk != "" {
 							out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2574,7 +2595,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				contentBlockIndex++
 
 				blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "tool_use", toolUseID, toolName)
-				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+				sseData := translator.Translate(blockStart)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2589,7 +2610,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 						// Don't continue - still need to close the block
 					} else {
 						inputDelta := kiroclaude.BuildClaudeInputJsonDeltaEvent(string(inputJSON), contentBlockIndex)
-						sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, inputDelta, &translatorParam)
+						sseData = translator.Translate(inputDelta)
 						for _, chunk := range sseData {
 							if chunk != "" {
 								out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2600,7 +2621,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 
 				// Close tool_use block (always close even if input marshal failed)
 				blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+				sseData = translator.Translate(blockStop)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2641,7 +2662,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				// Close text block if open before starting thinking block
 				if isTextBlockOpen && contentBlockIndex >= 0 {
 					blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+					sseData := translator.Translate(blockStop)
 					for _, chunk := range sseData {
 						if chunk != "" {
 							out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2656,7 +2677,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 					thinkingBlockIndex = contentBlockIndex
 					isThinkingBlockOpen = true
 					blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(thinkingBlockIndex, "thinking", "", "")
-					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+					sseData := translator.Translate(blockStart)
 					for _, chunk := range sseData {
 						if chunk != "" {
 							out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2666,7 +2687,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 
 				// Send thinking content
 				thinkingEvent := kiroclaude.BuildClaudeThinkingDeltaEvent(thinkingText, thinkingBlockIndex)
-				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, thinkingEvent, &translatorParam)
+				sseData := translator.Translate(thinkingEvent)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2694,7 +2715,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				// Close text block if open
 				if isTextBlockOpen && contentBlockIndex >= 0 {
 					blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+					sseData := translator.Translate(blockStop)
 					for _, chunk := range sseData {
 						if chunk != "" {
 							out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2706,7 +2727,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				contentBlockIndex++
 
 				blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "tool_use", tu.ToolUseID, tu.Name)
-				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
+				sseData := translator.Translate(blockStart)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2719,7 +2740,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 						log.Debugf("kiro: failed to marshal tool input in toolUseEvent: %v", err)
 					} else {
 						inputDelta := kiroclaude.BuildClaudeInputJsonDeltaEvent(string(inputJSON), contentBlockIndex)
-						sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, inputDelta, &translatorParam)
+						sseData = translator.Translate(inputDelta)
 						for _, chunk := range sseData {
 							if chunk != "" {
 								out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2729,7 +2750,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				}
 
 				blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+				sseData = translator.Translate(blockStop)
 				for _, chunk := range sseData {
 					if chunk != "" {
 						out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -2918,7 +2939,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 	// Close content block if open
 	if isTextBlockOpen && contentBlockIndex >= 0 {
 		blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
-		sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
+		sseData := translator.Translate(blockStop)
 		for _, chunk := range sseData {
 			if chunk != "" {
 				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -3005,7 +3026,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 
 	// Send message_delta event
 	msgDelta := kiroclaude.BuildClaudeMessageDeltaEvent(stopReason, totalUsage)
-	sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, msgDelta, &translatorParam)
+	sseData := translator.Translate(msgDelta)
 	for _, chunk := range sseData {
 		if chunk != "" {
 			out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -3014,7 +3035,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 
 	// Send message_stop event separately
 	msgStop := kiroclaude.BuildClaudeMessageStopOnlyEvent()
-	sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, msgStop, &translatorParam)
+	sseData = translator.Translate(msgStop)
 	for _, chunk := range sseData {
 		if chunk != "" {
 			out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk + "\n\n")}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 )
 
@@ -179,6 +180,15 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	updateAggregatedResponse(ginCtx, attempts)
 }
 
+// setUpstreamRequestID sends the proxy request id upstream as X-Request-Id so upstream logs
+// can be matched with ours. Only executors for generic APIs and self-hosted backends use
+// it; executors emulating a specific client keep that client's headers.
+func setUpstreamRequestID(req *http.Request) {
+	if requestID := logging.GetRequestID(req.Context()); requestID != "" {
+		req.Header.Set(logging.RequestIDHeader, requestID)
+	}
+}
+
 func ginContextFrom(ctx context.Context) *gin.Context {
 	ginCtx, _ := ctx.Value("gin").(*gin.Context)
 	return ginCtx
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// moonshotContextTiers lists the fixed-window moonshot-v1 models from smallest to
+// largest context.
+var moonshotContextTiers = []struct {
+	model  string
+	window int64
+}{
+	{"moonshot-v1-8k", 8192},
+	{"moonshot-v1-32k", 32768},
+	{"moonshot-v1-128k", 131072},
+}
+
+// moonshotMaxTemperature is the upper bound of Moonshot's temperature range.
+const moonshotMaxTemperature = 1.0
+
+// prepareMoonshotPayload adapts an OpenAI chat request to Kimi's partial mode,
+// temperature range and context windows.
+func prepareMoonshotPayload(payload []byte, model string) []byte {
+	payload = markMoonshotPartial(payload)
+	if temp := gjson.GetBytes(payload, "temperature"); temp.Exists() && temp.Float() > moonshotMaxTemperature {
+		payload, _ = sjson.SetBytes(payload, "temperature", moonshotMaxTemperature)
+	}
+	return fitMoonshotContext(payload, model)
+}
+
+// markMoonshotPartial turns a trailing assistant message into a Kimi partial-mode
+// prefill, so the model continues the given text instead of answering after it.
+func markMoonshotPartial(payload []byte) []byte {
+	messages := gjson.GetBytes(payload, "messages").Array()
+	if len(messages) == 0 {
+		return payload
+	}
+	last := messages[len(messages)-1]
+	if last.Get("role").String() != "assistant" || last.Get("tool_calls").Exists() || last.Get("partial").Exists() {
+		return payload
+	}
+	payload, _ = sjson.SetBytes(payload, fmt.Sprintf("messages.%d.partial", len(messages)-1), true)
+	return payload
+}
+
+// fitMoonshotContext keeps a request within the model's context window. A moonshot-v1
+// fixed-window model is moved to the smallest tier that holds the prompt plus
+// max_tokens, and max_tokens is lowered when the window would still be exceeded.
+func fitMoonshotContext(payload []byte, model string) []byte {
+	enc, err := getTokenizer(model)
+	if err != nil {
+		return payload
+	}
+	prompt, err := countOpenAIChatTokens(enc, payload)
+	if err != nil || prompt == 0 {
+		return payload
+	}
+	maxTokens := gjson.GetBytes(payload, "max_tokens").Int()
+
+	window := int64(0)
+	if i := moonshotTierIndex(model); i >= 0 {
+		chosen := moonshotContextTiers[len(moonshotContextTiers)-1]
+		for _, tier := range moonshotContextTiers[i:] {
+			if prompt+maxTokens <= tier.window {
+				chosen = tier
+				break
+			}
+		}
+		if chosen.model != moonshotContextTiers[i].model {
+			payload, _ = sjson.SetBytes(payload, "model", chosen.model)
+		}
+		window = chosen.window
+	}
+	if window == 0 {
+		if info := registry.LookupStaticModelInfo(model); info != nil && info.ContextLength > 0 {
+			window = int64(info.ContextLength)
+		}
+	}
+	if window > 0 && maxTokens > 0 && prompt+maxTokens > window && prompt < window {
+		payload, _ = sjson.SetBytes(payload, "max_tokens", window-prompt)
+	}
+	return payload
+}
+
+func moonshotTierIndex(model string) int {
+	for i, tier := range moonshotContextTiers {
+		if strings.EqualFold(model, tier.model) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestMarkMoonshotPartial(t *testing.T) {
+	out := markMoonshotPartial([]byte(`{"messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"{"}]}`))
+	if !gjson.GetBytes(out, "messages.1.partial").Bool() {
+		t.Fatalf("trailing assistant message must become a partial prefill: %s", out)
+	}
+
+	plain := `{"messages":[{"role":"assistant","content":"hello"},{"role":"user","content":"hi"}]}`
+	if got := string(markMoonshotPartial([]byte(plain))); got != plain {
+		t.Fatalf("request ending with a user turn must pass through, got %s", got)
+	}
+}
+
+func TestFitMoonshotContext(t *testing.T) {
+	long := strings.Repeat("word ", 12000)
+	payload := []byte(`{"model":"moonshot-v1-8k","max_tokens":1024,"messages":[{"role":"user","content":"` + long + `"}]}`)
+	out := fitMoonshotContext(payload, "moonshot-v1-8k")
+	if got := gjson.GetBytes(out, "model").String(); got != "moonshot-v1-32k" {
+		t.Fatalf("model = %q, want moonshot-v1-32k", got)
+	}
+
+	short := []byte(`{"model":"moonshot-v1-8k","max_tokens":1024,"messages":[{"role":"user","content":"hi"}]}`)
+	if got := gjson.GetBytes(fitMoonshotContext(short, "moonshot-v1-8k"), "model").String(); got != "moonshot-v1-8k" {
+		t.Fatalf("short prompt must keep its model, got %q", got)
+	}
+
+	capped := []byte(`{"model":"moonshot-v1-128k","max_tokens":200000,"messages":[{"role":"user","content":"hi"}]}`)
+	if got := gjson.GetBytes(fitMoonshotContext(capped, "moonshot-v1-128k"), "max_tokens").Int(); got >= 131072 {
+		t.Fatalf("max_tokens = %d, want it lowered to fit the window", got)
+	}
+}
+
+func TestPrepareMoonshotPayloadClampsTemperature(t *testing.T) {
+	out := prepareMoonshotPayload([]byte(`{"temperature":1.6,"messages":[{"role":"user","content":"hi"}]}`), "kimi-k2-0905-preview")
+	if got := gjson.GetBytes(out, "temperature").Float(); got != 1 {
+		t.Fatalf("temperature = %v, want 1", got)
+	}
+}
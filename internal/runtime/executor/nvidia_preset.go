@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// nvidiaExtensionFields are sampling and guided-decoding parameters NIM accepts only
+// inside the nvext object.
+var nvidiaExtensionFields = []string{
+	"top_k",
+	"repetition_penalty",
+	"min_tokens",
+	"ignore_eos",
+	"guided_json",
+	"guided_regex",
+	"guided_choice",
+	"guided_grammar",
+}
+
+// prepareNVIDIAPayload adapts an OpenAI chat request to NIM's extension and reasoning rules.
+func prepareNVIDIAPayload(payload []byte, model string) []byte {
+	return normalizeNVIDIAReasoning(normalizeNVIDIAExtensions(payload), model)
+}
+
+// normalizeNVIDIAExtensions moves top-level NIM extension parameters into nvext and maps
+// an OpenAI json_schema response format onto nvext.guided_json. Values already set in
+// nvext by the client win.
+func normalizeNVIDIAExtensions(payload []byte) []byte {
+	for _, field := range nvidiaExtensionFields {
+		value := gjson.GetBytes(payload, field)
+		if !value.Exists() {
+			continue
+		}
+		payload, _ = sjson.DeleteBytes(payload, field)
+		if gjson.GetBytes(payload, "nvext."+field).Exists() {
+			continue
+		}
+		payload, _ = sjson.SetRawBytes(payload, "nvext."+field, []byte(value.Raw))
+	}
+	format := gjson.GetBytes(payload, "response_format")
+	if format.Get("type").String() != "json_schema" {
+		return payload
+	}
+	payload, _ = sjson.DeleteBytes(payload, "response_format")
+	if schema := format.Get("json_schema.schema"); schema.Exists() && !gjson.GetBytes(payload, "nvext.guided_json").Exists() {
+		payload, _ = sjson.SetRawBytes(payload, "nvext.guided_json", []byte(schema.Raw))
+	}
+	return payload
+}
+
+// normalizeNVIDIAReasoning maps reasoning_effort onto the model's own switch. Nemotron
+// models toggle reasoning with a "detailed thinking on|off" system prompt, gpt-oss models
+// take reasoning_effort as is, and other NIM models reject the field.
+func normalizeNVIDIAReasoning(payload []byte, model string) []byte {
+	effort := gjson.GetBytes(payload, "reasoning_effort")
+	if !effort.Exists() {
+		return payload
+	}
+	model = strings.ToLower(model)
+	if strings.Contains(model, "gpt-oss") {
+		return payload
+	}
+	payload, _ = sjson.DeleteBytes(payload, "reasoning_effort")
+	if !strings.Contains(model, "nemotron") {
+		return payload
+	}
+	toggle := "detailed thinking on"
+	switch strings.ToLower(strings.TrimSpace(effort.String())) {
+	case "none", "minimal":
+		toggle = "detailed thinking off"
+	}
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return payload
+	}
+	system, _ := json.Marshal(map[string]string{"role": "system", "content": toggle})
+	items := make([]json.RawMessage, 0, len(messages.Array())+1)
+	items = append(items, system)
+	for _, msg := range messages.Array() {
+		items = append(items, json.RawMessage(msg.Raw))
+	}
+	out, err := json.Marshal(items)
+	if err != nil {
+		return payload
+	}
+	payload, _ = sjson.SetRawBytes(payload, "messages", out)
+	return payload
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeNVIDIAExtensions(t *testing.T) {
+	out := normalizeNVIDIAExtensions([]byte(`{"top_k":40,"repetition_penalty":1.1,"nvext":{"top_k":20},
+		"response_format":{"type":"json_schema","json_schema":{"name":"x","schema":{"type":"object"}}}}`))
+	if gjson.GetBytes(out, "top_k").Exists() || gjson.GetBytes(out, "repetition_penalty").Exists() {
+		t.Fatalf("extension fields must move into nvext: %s", out)
+	}
+	if got := gjson.GetBytes(out, "nvext.top_k").Int(); got != 20 {
+		t.Fatalf("nvext.top_k = %d, want client value 20", got)
+	}
+	if got := gjson.GetBytes(out, "nvext.repetition_penalty").Float(); got != 1.1 {
+		t.Fatalf("nvext.repetition_penalty = %v", got)
+	}
+	if gjson.GetBytes(out, "response_format").Exists() {
+		t.Fatalf("json_schema response_format must be removed: %s", out)
+	}
+	if got := gjson.GetBytes(out, "nvext.guided_json.type").String(); got != "object" {
+		t.Fatalf("nvext.guided_json = %s", gjson.GetBytes(out, "nvext.guided_json").Raw)
+	}
+
+	plain := `{"response_format":{"type":"json_object"}}`
+	if got := string(normalizeNVIDIAExtensions([]byte(plain))); got != plain {
+		t.Fatalf("json_object response_format must pass through, got %s", got)
+	}
+}
+
+func TestNormalizeNVIDIAReasoning(t *testing.T) {
+	payload := []byte(`{"reasoning_effort":"high","messages":[{"role":"user","content":"hi"}]}`)
+
+	out := normalizeNVIDIAReasoning(payload, "nvidia/llama-3.3-nemotron-super-49b-v1.5")
+	if gjson.GetBytes(out, "reasoning_effort").Exists() {
+		t.Fatalf("reasoning_effort must be removed for nemotron: %s", out)
+	}
+	if got := gjson.GetBytes(out, "messages.0.content").String(); got != "detailed thinking on" {
+		t.Fatalf("system toggle = %q", got)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content").String(); got != "hi" {
+		t.Fatalf("user message = %q", got)
+	}
+
+	off := normalizeNVIDIAReasoning([]byte(`{"reasoning_effort":"none","messages":[]}`), "nvidia/llama-3.1-nemotron-ultra-253b-v1")
+	if got := gjson.GetBytes(off, "messages.0.content").String(); got != "detailed thinking off" {
+		t.Fatalf("system toggle = %q", got)
+	}
+
+	if out = normalizeNVIDIAReasoning(payload, "openai/gpt-oss-120b"); gjson.GetBytes(out, "reasoning_effort").String() != "high" {
+		t.Fatalf("gpt-oss must keep reasoning_effort: %s", out)
+	}
+	if out = normalizeNVIDIAReasoning(payload, "meta/llama-3.3-70b-instruct"); gjson.GetBytes(out, "reasoning_effort").Exists() {
+		t.Fatalf("reasoning_effort must be dropped for other models: %s", out)
+	}
+}
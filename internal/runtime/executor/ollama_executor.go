@@ -0,0 +1,636 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	ollamaChatPath     = "/api/chat"
+	ollamaGeneratePath = "/api/generate"
+	ollamaEmbedPath    = "/api/embed"
+	ollamaTagsPath     = "/api/tags"
+)
+
+// OllamaExecutor is a stateless executor for Ollama servers. Requests are translated to
+// the OpenAI chat format and then mapped onto Ollama's native /api/chat (or /api/generate
+// for bare prompts); responses are mapped back to OpenAI chat completions before the
+// regular response translators run.
+type OllamaExecutor struct {
+	cfg *config.Config
+}
+
+// NewOllamaExecutor creates a new Ollama executor.
+func NewOllamaExecutor(cfg *config.Config) *OllamaExecutor { return &OllamaExecutor{cfg: cfg} }
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *OllamaExecutor) Identifier() string { return "ollama" }
+
+// PrepareRequest injects the optional bearer token and configured headers.
+func (e *OllamaExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	_, apiKey := ollamaCredentials(auth)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	setUpstreamRequestID(req)
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return nil
+}
+
+// HttpRequest injects Ollama credentials into the request and executes it.
+func (e *OllamaExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("ollama executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *OllamaExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated, path, body := e.buildRequest(ctx, auth, req, opts, false)
+
+	httpResp, err := e.doRequest(ctx, auth, path, body)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+
+	converted := convertOllamaResponseToOpenAI(data, req.Model)
+	reporter.publish(ctx, parseOpenAIUsage(converted))
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translated, converted, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *OllamaExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated, path, body := e.buildRequest(ctx, auth, req, opts, true)
+
+	httpResp, err := e.doRequest(ctx, auth, path, body)
+	if err != nil {
+		return nil, err
+	}
+	out := newStreamChannel(opts)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("ollama executor: close response body error: %v", errClose)
+			}
+		}()
+		// Ollama streams newline-delimited JSON; each object becomes OpenAI SSE lines.
+		scanner := newStreamScanner(httpResp.Body, e.Identifier(), streamLineLimit(e.cfg, e.Identifier(), opts))
+		state := newOllamaStreamState(req.Model)
+		stream := newStreamTranslator(ctx, opts, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translated)
+		emit := func(line []byte) {
+			if detail, ok := parseOpenAIStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			chunks := stream.Translate(line)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if len(line) == 0 || !gjson.ValidBytes(line) {
+				continue
+			}
+			if msg := gjson.GetBytes(line, "error"); msg.Exists() {
+				out <- cliproxyexecutor.StreamChunk{Err: statusErr{code: http.StatusBadGateway, msg: msg.String()}}
+				return
+			}
+			for _, chunk := range state.convert(line) {
+				emit(chunk)
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+			return
+		}
+		emit([]byte("data: [DONE]"))
+		reporter.ensurePublished(ctx)
+	}()
+	return stream, nil
+}
+
+func (e *OllamaExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
+
+	enc, err := tokenizerForModel(e.resolveUpstreamModel(req.Model, auth))
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("ollama executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("ollama executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: markApproximateCount([]byte(translatedUsage))}, nil
+}
+
+// Refresh is a no-op; Ollama servers have no expiring credentials.
+func (e *OllamaExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	_ = ctx
+	return auth, nil
+}
+
+// Embed implements cliproxyauth.EmbeddingExecutor using Ollama's /api/embed endpoint.
+func (e *OllamaExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	payload := []byte(`{}`)
+	payload, _ = sjson.SetBytes(payload, "model", e.resolveUpstreamModel(req.Model, auth))
+	payload, _ = sjson.SetBytes(payload, "input", embeddingInputs(req.Payload))
+	httpResp, err := e.doRequest(ctx, auth, ollamaEmbedPath, payload)
+	if err != nil {
+		return resp, err
+	}
+	body, err := readUpstreamBody(ctx, e.cfg, httpResp, e.Identifier())
+	if err != nil {
+		return resp, err
+	}
+	reporter.ensurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: convertOllamaEmbedResponseToOpenAI(body, req.Model)}, nil
+}
+
+// buildRequest translates the inbound payload to OpenAI format and maps it onto the
+// native Ollama endpoint. It returns the OpenAI-format request (used as translator
+// context), the Ollama path and the Ollama request body.
+func (e *OllamaExecutor) buildRequest(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) ([]byte, string, []byte) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := bytes.Clone(req.Payload)
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = bytes.Clone(opts.OriginalRequest)
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, stream)
+	translated := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), stream)
+	translated = applyPayloadConfigWithRoot(ctx, e.cfg, req.Model, to.String(), "", translated, originalTranslated)
+
+	upstream := translated
+	// Ollama's /api/generate understands prompt+suffix natively for code models.
+	if prompt, suffix, ok := util.FIMFromMetadata(opts.Metadata); ok {
+		upstream = buildFIMPayload(translated, prompt, suffix)
+	}
+	path, body := convertOpenAIRequestToOllama(upstream, e.resolveUpstreamModel(req.Model, auth), stream)
+	return translated, path, body
+}
+
+func (e *OllamaExecutor) doRequest(ctx context.Context, auth *cliproxyauth.Auth, path string, body []byte) (*http.Response, error) {
+	baseURL, _ := ollamaCredentials(auth)
+	if baseURL == "" {
+		baseURL = config.DefaultOllamaBaseURL
+	}
+	url := strings.TrimSuffix(expandBaseURL(baseURL, auth, gjson.GetBytes(body, "model").String()), "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "cli-proxy-ollama")
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	reportQuotaHeaders(ctx, httpResp.Header)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+		return nil, newUpstreamStatusErr(httpResp, b)
+	}
+	return httpResp, nil
+}
+
+// resolveUpstreamModel maps a configured alias to its upstream model name. Models
+// without a configured alias are sent unchanged.
+func (e *OllamaExecutor) resolveUpstreamModel(model string, auth *cliproxyauth.Auth) string {
+	model = strings.TrimSpace(model)
+	entry := e.resolveOllamaConfig(auth)
+	if entry == nil {
+		return model
+	}
+	for i := range entry.Models {
+		name := strings.TrimSpace(entry.Models[i].Name)
+		alias := strings.TrimSpace(entry.Models[i].Alias)
+		if alias != "" && strings.EqualFold(alias, model) && name != "" {
+			return name
+		}
+	}
+	return model
+}
+
+func (e *OllamaExecutor) resolveOllamaConfig(auth *cliproxyauth.Auth) *config.OllamaKey {
+	if auth == nil || e.cfg == nil {
+		return nil
+	}
+	baseURL, _ := ollamaCredentials(auth)
+	for i := range e.cfg.OllamaKey {
+		entry := &e.cfg.OllamaKey[i]
+		if strings.EqualFold(strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/"), strings.TrimRight(baseURL, "/")) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func ollamaCredentials(auth *cliproxyauth.Auth) (baseURL, apiKey string) {
+	if auth == nil || auth.Attributes == nil {
+		return "", ""
+	}
+	return strings.TrimSpace(auth.Attributes["base_url"]), strings.TrimSpace(auth.Attributes["api_key"])
+}
+
+// FetchOllamaModels lists the models installed on the server behind auth via /api/tags.
+func FetchOllamaModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	baseURL, _ := ollamaCredentials(auth)
+	if baseURL == "" {
+		baseURL = config.DefaultOllamaBaseURL
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+ollamaTagsPath, nil)
+	if err != nil {
+		return nil
+	}
+	exec := &OllamaExecutor{cfg: cfg}
+	httpResp, err := exec.HttpRequest(ctx, auth, httpReq)
+	if err != nil {
+		log.Debugf("ollama executor: list models from %s failed: %v", baseURL, err)
+		return nil
+	}
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errClose := httpResp.Body.Close(); errClose != nil {
+		log.Errorf("ollama executor: close response body error: %v", errClose)
+	}
+	if errRead != nil || httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		log.Debugf("ollama executor: list models from %s returned status %d", baseURL, httpResp.StatusCode)
+		return nil
+	}
+
+	now := time.Now().Unix()
+	var models []*registry.ModelInfo
+	for _, item := range gjson.GetBytes(data, "models").Array() {
+		name := strings.TrimSpace(item.Get("name").String())
+		if name == "" {
+			name = strings.TrimSpace(item.Get("model").String())
+		}
+		if name == "" {
+			continue
+		}
+		models = append(models, &registry.ModelInfo{
+			ID:          name,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     "ollama",
+			Type:        "ollama",
+			DisplayName: name,
+		})
+	}
+	return models
+}
+
+// convertOpenAIRequestToOllama maps an OpenAI chat (or legacy completions) request onto
+// Ollama's native API and returns the endpoint path with the request body.
+func convertOpenAIRequestToOllama(payload []byte, model string, stream bool) (string, []byte) {
+	root := gjson.ParseBytes(payload)
+	if model == "" {
+		model = root.Get("model").String()
+	}
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "stream", stream)
+
+	path := ollamaChatPath
+	if prompt := root.Get("prompt"); prompt.Exists() && !root.Get("messages").Exists() {
+		path = ollamaGeneratePath
+		out, _ = sjson.SetBytes(out, "prompt", prompt.String())
+		if suffix := root.Get("suffix").String(); suffix != "" {
+			out, _ = sjson.SetBytes(out, "suffix", suffix)
+		}
+	} else {
+		out, _ = sjson.SetRawBytes(out, "messages", convertOpenAIMessagesToOllama(root.Get("messages")))
+		if tools := root.Get("tools"); tools.IsArray() && len(tools.Array()) > 0 {
+			out, _ = sjson.SetRawBytes(out, "tools", []byte(tools.Raw))
+		}
+	}
+
+	switch format := root.Get("response_format"); format.Get("type").String() {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, "format", "json")
+	case "json_schema":
+		if schema := format.Get("json_schema.schema"); schema.Exists() {
+			out, _ = sjson.SetRawBytes(out, "format", []byte(schema.Raw))
+		}
+	}
+	if effort := root.Get("reasoning_effort"); effort.Exists() {
+		out, _ = sjson.SetBytes(out, "think", effort.String() != "none")
+	}
+
+	options := []byte(`{}`)
+	for _, field := range []string{"temperature", "top_p", "top_k", "seed", "frequency_penalty", "presence_penalty"} {
+		if v := root.Get(field); v.Exists() {
+			options, _ = sjson.SetRawBytes(options, field, []byte(v.Raw))
+		}
+	}
+	if v := root.Get("max_completion_tokens"); v.Exists() {
+		options, _ = sjson.SetBytes(options, "num_predict", v.Int())
+	} else if v = root.Get("max_tokens"); v.Exists() {
+		options, _ = sjson.SetBytes(options, "num_predict", v.Int())
+	}
+	if stop := root.Get("stop"); stop.Exists() {
+		if stop.IsArray() {
+			options, _ = sjson.SetRawBytes(options, "stop", []byte(stop.Raw))
+		} else if stop.String() != "" {
+			options, _ = sjson.SetBytes(options, "stop", []string{stop.String()})
+		}
+	}
+	if len(gjson.ParseBytes(options).Map()) > 0 {
+		out, _ = sjson.SetRawBytes(out, "options", options)
+	}
+	return path, out
+}
+
+func convertOpenAIMessagesToOllama(messages gjson.Result) []byte {
+	out := []byte(`[]`)
+	for _, msg := range messages.Array() {
+		role := msg.Get("role").String()
+		if role == "developer" {
+			role = "system"
+		}
+		item := []byte(`{}`)
+		item, _ = sjson.SetBytes(item, "role", role)
+
+		var text strings.Builder
+		var images []string
+		content := msg.Get("content")
+		if content.IsArray() {
+			for _, part := range content.Array() {
+				switch part.Get("type").String() {
+				case "text":
+					text.WriteString(part.Get("text").String())
+				case "image_url":
+					// Ollama takes raw base64 image data; remote URLs are not supported.
+					url := part.Get("image_url.url").String()
+					if idx := strings.Index(url, ";base64,"); strings.HasPrefix(url, "data:") && idx >= 0 {
+						images = append(images, url[idx+len(";base64,"):])
+					}
+				}
+			}
+		} else {
+			text.WriteString(content.String())
+		}
+		item, _ = sjson.SetBytes(item, "content", text.String())
+		if len(images) > 0 {
+			item, _ = sjson.SetBytes(item, "images", images)
+		}
+		if reasoning := msg.Get("reasoning_content"); reasoning.Exists() && reasoning.String() != "" {
+			item, _ = sjson.SetBytes(item, "thinking", reasoning.String())
+		}
+		for i, call := range msg.Get("tool_calls").Array() {
+			prefix := fmt.Sprintf("tool_calls.%d.function.", i)
+			item, _ = sjson.SetBytes(item, prefix+"name", call.Get("function.name").String())
+			args := call.Get("function.arguments").String()
+			if !gjson.Valid(args) || !gjson.Parse(args).IsObject() {
+				args = `{}`
+			}
+			item, _ = sjson.SetRawBytes(item, prefix+"arguments", []byte(args))
+		}
+		if role == "tool" {
+			if name := msg.Get("name").String(); name != "" {
+				item, _ = sjson.SetBytes(item, "tool_name", name)
+			}
+		}
+		out, _ = sjson.SetRawBytes(out, "-1", item)
+	}
+	return out
+}
+
+// ollamaFinishReason maps Ollama's done_reason onto OpenAI finish reasons.
+func ollamaFinishReason(doneReason string, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	if doneReason == "length" {
+		return "length"
+	}
+	return "stop"
+}
+
+func ollamaUsage(root gjson.Result) []byte {
+	prompt := root.Get("prompt_eval_count").Int()
+	completion := root.Get("eval_count").Int()
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "prompt_tokens", prompt)
+	out, _ = sjson.SetBytes(out, "completion_tokens", completion)
+	out, _ = sjson.SetBytes(out, "total_tokens", prompt+completion)
+	return out
+}
+
+func ollamaCompletionID() string {
+	return fmt.Sprintf("chatcmpl-ollama-%d", time.Now().UnixNano())
+}
+
+// convertOllamaResponseToOpenAI maps a non-streaming /api/chat or /api/generate response
+// onto an OpenAI chat completion.
+func convertOllamaResponseToOpenAI(data []byte, model string) []byte {
+	root := gjson.ParseBytes(data)
+	out := []byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":""}}]}`)
+	out, _ = sjson.SetBytes(out, "id", ollamaCompletionID())
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	out, _ = sjson.SetBytes(out, "model", model)
+
+	content := root.Get("message.content").String()
+	if !root.Get("message").Exists() {
+		content = root.Get("response").String()
+	}
+	out, _ = sjson.SetBytes(out, "choices.0.message.content", content)
+	thinking := root.Get("message.thinking").String()
+	if thinking == "" {
+		thinking = root.Get("thinking").String()
+	}
+	if thinking != "" {
+		out, _ = sjson.SetBytes(out, "choices.0.message.reasoning_content", thinking)
+	}
+	calls := root.Get("message.tool_calls").Array()
+	for i, call := range calls {
+		prefix := fmt.Sprintf("choices.0.message.tool_calls.%d.", i)
+		out, _ = sjson.SetBytes(out, prefix+"id", fmt.Sprintf("call_%d", i))
+		out, _ = sjson.SetBytes(out, prefix+"type", "function")
+		out, _ = sjson.SetBytes(out, prefix+"function.name", call.Get("function.name").String())
+		out, _ = sjson.SetBytes(out, prefix+"function.arguments", ollamaArguments(call))
+	}
+	out, _ = sjson.SetBytes(out, "choices.0.finish_reason", ollamaFinishReason(root.Get("done_reason").String(), len(calls) > 0))
+	out, _ = sjson.SetRawBytes(out, "usage", ollamaUsage(root))
+	return out
+}
+
+func ollamaArguments(call gjson.Result) string {
+	args := call.Get("function.arguments")
+	if !args.Exists() {
+		return "{}"
+	}
+	if args.Type == gjson.String {
+		return args.String()
+	}
+	return args.Raw
+}
+
+// ollamaStreamState converts Ollama NDJSON stream objects into OpenAI SSE lines.
+type ollamaStreamState struct {
+	id        string
+	model     string
+	created   int64
+	started   bool
+	toolCalls int
+}
+
+func newOllamaStreamState(model string) *ollamaStreamState {
+	return &ollamaStreamState{id: ollamaCompletionID(), model: model, created: time.Now().Unix()}
+}
+
+func (s *ollamaStreamState) chunk(delta []byte, finishReason string) []byte {
+	out := []byte(`{"object":"chat.completion.chunk","choices":[{"index":0}]}`)
+	out, _ = sjson.SetBytes(out, "id", s.id)
+	out, _ = sjson.SetBytes(out, "created", s.created)
+	out, _ = sjson.SetBytes(out, "model", s.model)
+	out, _ = sjson.SetRawBytes(out, "choices.0.delta", delta)
+	if finishReason != "" {
+		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", finishReason)
+	} else {
+		out, _ = sjson.SetRawBytes(out, "choices.0.finish_reason", []byte("null"))
+	}
+	return out
+}
+
+func (s *ollamaStreamState) convert(line []byte) [][]byte {
+	root := gjson.ParseBytes(line)
+	var out [][]byte
+
+	delta := []byte(`{}`)
+	if !s.started {
+		delta, _ = sjson.SetBytes(delta, "role", "assistant")
+	}
+	hasDelta := false
+	content := root.Get("message.content").String()
+	if !root.Get("message").Exists() {
+		content = root.Get("response").String()
+	}
+	if content != "" {
+		delta, _ = sjson.SetBytes(delta, "content", content)
+		hasDelta = true
+	}
+	thinking := root.Get("message.thinking").String()
+	if thinking == "" {
+		thinking = root.Get("thinking").String()
+	}
+	if thinking != "" {
+		delta, _ = sjson.SetBytes(delta, "reasoning_content", thinking)
+		hasDelta = true
+	}
+	for i, call := range root.Get("message.tool_calls").Array() {
+		prefix := fmt.Sprintf("tool_calls.%d.", i)
+		delta, _ = sjson.SetBytes(delta, prefix+"index", s.toolCalls)
+		delta, _ = sjson.SetBytes(delta, prefix+"id", fmt.Sprintf("call_%d", s.toolCalls))
+		delta, _ = sjson.SetBytes(delta, prefix+"type", "function")
+		delta, _ = sjson.SetBytes(delta, prefix+"function.name", call.Get("function.name").String())
+		delta, _ = sjson.SetBytes(delta, prefix+"function.arguments", ollamaArguments(call))
+		s.toolCalls++
+		hasDelta = true
+	}
+	if hasDelta {
+		s.started = true
+		out = append(out, append([]byte("data: "), s.chunk(delta, "")...))
+	}
+
+	if root.Get("done").Bool() {
+		final := s.chunk([]byte(`{}`), ollamaFinishReason(root.Get("done_reason").String(), s.toolCalls > 0))
+		final, _ = sjson.SetRawBytes(final, "usage", ollamaUsage(root))
+		out = append(out, append([]byte("data: "), final...))
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToOllama(t *testing.T) {
+	input := []byte(`{"model":"llama3","max_tokens":64,"stop":"END","temperature":0.2,"response_format":{"type":"json_object"},
+		"messages":[{"role":"developer","content":"be brief"},
+		{"role":"user","content":[{"type":"text","text":"what is this"},{"type":"image_url","image_url":{"url":"data:image/png;base64,QUJD"}}]},
+		{"role":"assistant","content":"","tool_calls":[{"id":"c1","type":"function","function":{"name":"lookup","arguments":"{\"q\":\"x\"}"}}]},
+		{"role":"tool","tool_call_id":"c1","name":"lookup","content":"42"}]}`)
+	path, out := convertOpenAIRequestToOllama(input, "llama3.1:8b", true)
+
+	if path != ollamaChatPath {
+		t.Fatalf("path = %q, want %q", path, ollamaChatPath)
+	}
+	checks := map[string]string{
+		"model":                                 "llama3.1:8b",
+		"stream":                                "true",
+		"format":                                "json",
+		"options.num_predict":                   "64",
+		"options.stop.0":                        "END",
+		"options.temperature":                   "0.2",
+		"messages.0.role":                       "system",
+		"messages.1.content":                    "what is this",
+		"messages.1.images.0":                   "QUJD",
+		"messages.2.tool_calls.0.function.name": "lookup",
+		"messages.2.tool_calls.0.function.arguments.q": "x",
+		"messages.3.role":      "tool",
+		"messages.3.tool_name": "lookup",
+	}
+	for path, want := range checks {
+		if got := gjson.GetBytes(out, path).String(); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestConvertOpenAIRequestToOllama_Prompt(t *testing.T) {
+	path, out := convertOpenAIRequestToOllama([]byte(`{"model":"llama3","prompt":"hello"}`), "", false)
+	if path != ollamaGeneratePath {
+		t.Fatalf("path = %q, want %q", path, ollamaGeneratePath)
+	}
+	if got := gjson.GetBytes(out, "prompt").String(); got != "hello" {
+		t.Fatalf("prompt = %q, want %q", got, "hello")
+	}
+}
+
+func TestConvertOllamaResponseToOpenAI(t *testing.T) {
+	input := []byte(`{"model":"llama3","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"lookup","arguments":{"q":"x"}}}]},"done":true,"done_reason":"stop","prompt_eval_count":10,"eval_count":5}`)
+	out := convertOllamaResponseToOpenAI(input, "llama3")
+
+	if got := gjson.GetBytes(out, "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", got)
+	}
+	if got := gjson.GetBytes(out, "choices.0.message.tool_calls.0.function.arguments").String(); got != `{"q":"x"}` {
+		t.Fatalf("arguments = %q", got)
+	}
+	if got := gjson.GetBytes(out, "usage.total_tokens").Int(); got != 15 {
+		t.Fatalf("total_tokens = %d, want 15", got)
+	}
+}
+
+func TestOllamaStreamStateConvert(t *testing.T) {
+	state := newOllamaStreamState("llama3")
+	first := state.convert([]byte(`{"message":{"role":"assistant","content":"Hi"},"done":false}`))
+	if len(first) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(first))
+	}
+	payload := bytes.TrimPrefix(first[0], []byte("data: "))
+	if got := gjson.GetBytes(payload, "choices.0.delta.role").String(); got != "assistant" {
+		t.Fatalf("first delta role = %q", got)
+	}
+	if got := gjson.GetBytes(payload, "choices.0.delta.content").String(); got != "Hi" {
+		t.Fatalf("first delta content = %q", got)
+	}
+
+	last := state.convert([]byte(`{"message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":3,"eval_count":7}`))
+	if len(last) != 1 {
+		t.Fatalf("expected 1 final chunk, got %d", len(last))
+	}
+	payload = bytes.TrimPrefix(last[0], []byte("data: "))
+	if got := gjson.GetBytes(payload, "choices.0.finish_reason").String(); got != "length" {
+		t.Fatalf("finish_reason = %q, want length", got)
+	}
+	if got := gjson.GetBytes(payload, "usage.completion_tokens").Int(); got != 7 {
+		t.Fatalf("completion_tokens = %d, want 7", got)
+	}
+}
+
+func TestFetchOllamaModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != ollamaTagsPath {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		_, _ = w.Write([]byte(`{"models":[{"name":"llama3.1:8b"},{"name":"qwen2.5-coder:7b"}]}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "ollama-1", Provider: "ollama", Attributes: map[string]string{"base_url": server.URL, "api_key": "secret"}}
+	models := FetchOllamaModels(context.Background(), auth, nil)
+	if len(models) != 2 || models[0].ID != "llama3.1:8b" || models[1].ID != "qwen2.5-coder:7b" {
+		t.Fatalf("unexpected models: %+v", models)
+	}
+}
+
+func TestConvertOpenAIRequestToOllama_FIM(t *testing.T) {
+	payload := buildFIMPayload([]byte(`{"model":"qwen2.5-coder","messages":[{"role":"user","content":"x"}],"max_tokens":16}`), "def f():\n", "\nreturn 1")
+	path, out := convertOpenAIRequestToOllama(payload, "", false)
+	if path != ollamaGeneratePath {
+		t.Fatalf("path = %q, want %q", path, ollamaGeneratePath)
+	}
+	if got := gjson.GetBytes(out, "suffix").String(); got != "\nreturn 1" {
+		t.Fatalf("suffix = %q", got)
+	}
+	if got := gjson.GetBytes(out, "options.num_predict").Int(); got != 16 {
+		t.Fatalf("num_predict = %d", got)
+	}
+}
+
+func TestConvertEmbedResponsesToOpenAI(t *testing.T) {
+	out := convertOllamaEmbedResponseToOpenAI([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":7}`), "nomic")
+	if got := gjson.GetBytes(out, "data.1.embedding.1").Float(); got != 0.4 {
+		t.Fatalf("ollama embedding = %v in %s", got, out)
+	}
+	if gjson.GetBytes(out, "data.1.index").Int() != 1 || gjson.GetBytes(out, "usage.prompt_tokens").Int() != 7 {
+		t.Fatalf("unexpected ollama response: %s", out)
+	}
+
+	out = convertCohereEmbedResponseToOpenAI([]byte(`{"embeddings":{"float":[[1,0]]},"meta":{"billed_units":{"input_tokens":3}}}`), "embed-v4.0")
+	if gjson.GetBytes(out, "data.0.embedding.0").Int() != 1 || gjson.GetBytes(out, "model").String() != "embed-v4.0" {
+		t.Fatalf("unexpected cohere response: %s", out)
+	}
+}
@@ -1,12 +1,12 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,12 +16,15 @@ import (
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
 // OpenAICompatExecutor implements a stateless executor for OpenAI-compatible providers.
 // It performs request/response translation and executes against the provider base URL
 // using per-auth credentials (API key) and per-auth HTTP transport (proxy) from context.
+// Providers with a dedicated config section (Groq, xAI, OpenRouter, Fireworks, NVIDIA NIM, Moonshot, Zhipu GLM, Cerebras) run on it
+// through the hooks in compatPresets.
 type OpenAICompatExecutor struct {
 	provider string
 	cfg      *config.Config
@@ -41,13 +44,12 @@ func (e *OpenAICompatExecutor) PrepareRequest(req *http.Request, auth *cliproxya
 		return nil
 	}
 	_, apiKey := e.resolveCredentials(auth)
-	if strings.TrimSpace(apiKey) != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-	}
+	e.setAuthHeader(req, auth, apiKey)
 	var attrs map[string]string
 	if auth != nil {
 		attrs = auth.Attributes
 	}
+	setUpstreamRequestID(req)
 	util.ApplyCustomHeadersFromAttrs(req, attrs)
 	return nil
 }
@@ -91,28 +93,34 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	if modelOverride != "" {
 		translated = e.overrideModel(translated, modelOverride)
 	}
-	translated = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", translated, originalTranslated)
+	translated = applyPayloadConfigWithRoot(ctx, e.cfg, req.Model, to.String(), "", translated, originalTranslated)
 	allowCompat := e.allowCompatReasoningEffort(req.Model, auth)
 	translated = ApplyReasoningEffortMetadata(translated, req.Metadata, req.Model, "reasoning_effort", allowCompat)
 	translated = NormalizeThinkingConfig(translated, req.Model, allowCompat)
 	if errValidate := ValidateThinkingConfig(translated, req.Model); errValidate != nil {
 		return resp, errValidate
 	}
+	translated = e.applyPreset(translated, req.Model, auth, opts.Stream)
+	translated = e.applyQuirks(translated, auth)
 
-	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
+	url := strings.TrimSuffix(expandBaseURL(baseURL, auth, gjson.GetBytes(translated, "model").String()), "/") + "/chat/completions"
+	requestBody := translated
+	fimURL, fimBody, nativeFIM := e.nativeFIMRequest(auth, baseURL, translated, opts.Metadata)
+	if nativeFIM {
+		url, requestBody = fimURL, fimBody
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(requestBody))
 	if err != nil {
 		return resp, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	if apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
-	}
+	e.setAuthHeader(httpReq, auth, apiKey)
 	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 	var attrs map[string]string
 	if auth != nil {
 		attrs = auth.Attributes
 	}
+	setUpstreamRequestID(httpReq)
 	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -124,7 +132,7 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 		URL:       url,
 		Method:    http.MethodPost,
 		Headers:   httpReq.Header.Clone(),
-		Body:      translated,
+		Body:      requestBody,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
 		AuthLabel: authLabel,
@@ -133,7 +141,9 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	})
 
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	started := time.Now()
 	httpResp, err := httpClient.Do(httpReq)
+	observeBaseURL(auth, baseURL, started, httpResp, err)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
 		return resp, err
@@ -144,11 +154,12 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 		}
 	}()
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	reportQuotaHeaders(ctx, httpResp.Header)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
 		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = newUpstreamStatusErr(httpResp, b)
 		return resp, err
 	}
 	body, err := io.ReadAll(httpResp.Body)
@@ -158,6 +169,9 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	}
 	appendAPIResponseChunk(ctx, e.cfg, body)
 	reporter.publish(ctx, parseOpenAIUsage(body))
+	if nativeFIM {
+		body = convertFIMResponseToChat(body)
+	}
 	// Ensure we at least record the request even if upstream doesn't return usage
 	reporter.ensurePublished(ctx)
 	// Translate response back to source format when needed
@@ -188,28 +202,35 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	if modelOverride != "" {
 		translated = e.overrideModel(translated, modelOverride)
 	}
-	translated = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", translated, originalTranslated)
+	translated = applyPayloadConfigWithRoot(ctx, e.cfg, req.Model, to.String(), "", translated, originalTranslated)
 	allowCompat := e.allowCompatReasoningEffort(req.Model, auth)
 	translated = ApplyReasoningEffortMetadata(translated, req.Metadata, req.Model, "reasoning_effort", allowCompat)
 	translated = NormalizeThinkingConfig(translated, req.Model, allowCompat)
 	if errValidate := ValidateThinkingConfig(translated, req.Model); errValidate != nil {
 		return nil, errValidate
 	}
+	translated = e.applyPreset(translated, req.Model, auth, true)
+	translated = applyStreamUsageOption(translated, opts)
+	translated = e.applyQuirks(translated, auth)
 
-	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
+	url := strings.TrimSuffix(expandBaseURL(baseURL, auth, gjson.GetBytes(translated, "model").String()), "/") + "/chat/completions"
+	requestBody := translated
+	fimURL, fimBody, nativeFIM := e.nativeFIMRequest(auth, baseURL, translated, opts.Metadata)
+	if nativeFIM {
+		url, requestBody = fimURL, fimBody
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(requestBody))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	if apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
-	}
+	e.setAuthHeader(httpReq, auth, apiKey)
 	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 	var attrs map[string]string
 	if auth != nil {
 		attrs = auth.Attributes
 	}
+	setUpstreamRequestID(httpReq)
 	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
 	httpReq.Header.Set("Accept", "text/event-stream")
 	httpReq.Header.Set("Cache-Control", "no-cache")
@@ -223,7 +244,7 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		URL:       url,
 		Method:    http.MethodPost,
 		Headers:   httpReq.Header.Clone(),
-		Body:      translated,
+		Body:      requestBody,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
 		AuthLabel: authLabel,
@@ -232,12 +253,15 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	})
 
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	started := time.Now()
 	httpResp, err := httpClient.Do(httpReq)
+	observeBaseURL(auth, baseURL, started, httpResp, err)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
 		return nil, err
 	}
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	reportQuotaHeaders(ctx, httpResp.Header)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
@@ -245,10 +269,10 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("openai compat executor: close response body error: %v", errClose)
 		}
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = newUpstreamStatusErr(httpResp, b)
 		return nil, err
 	}
-	out := make(chan cliproxyexecutor.StreamChunk)
+	out := newStreamChannel(opts)
 	stream = out
 	go func() {
 		defer close(out)
@@ -257,9 +281,8 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 				log.Errorf("openai compat executor: close response body error: %v", errClose)
 			}
 		}()
-		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, 52_428_800) // 50MB
-		var param any
+		scanner := newStreamScanner(httpResp.Body, e.Identifier(), streamLineLimit(e.cfg, e.Identifier(), opts))
+		stream := newStreamTranslator(ctx, opts, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translated)
 		for scanner.Scan() {
 			line := scanner.Bytes()
 			appendAPIResponseChunk(ctx, e.cfg, line)
@@ -273,10 +296,13 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 			if !bytes.HasPrefix(line, []byte("data:")) {
 				continue
 			}
+			if nativeFIM {
+				line = convertFIMStreamLineToChat(line)
+			}
 
 			// OpenAI-compatible streams are SSE: lines typically prefixed with "data: ".
 			// Pass through translator; it yields one or more chunks for the target schema.
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translated, bytes.Clone(line), &param)
+			chunks := stream.Translate(bytes.Clone(line))
 			for i := range chunks {
 				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
 			}
@@ -315,7 +341,99 @@ func (e *OpenAICompatExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 
 	usageJSON := buildOpenAIUsageJSON(count)
 	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
-	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+	return cliproxyexecutor.Response{Payload: markApproximateCount([]byte(translatedUsage))}, nil
+}
+
+// Rerank implements cliproxyauth.RerankExecutor for providers exposing a Jina/Cohere
+// compatible /rerank endpoint (Jina, vLLM, TEI and similar servers).
+func (e *OpenAICompatExecutor) Rerank(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	model := req.Model
+	if upstream := e.resolveUpstreamModel(req.Model, auth); upstream != "" {
+		model = upstream
+	}
+	body, err := e.postJSON(ctx, auth, "/rerank", buildUpstreamRerankPayload(req.Payload, model))
+	if err != nil {
+		return resp, err
+	}
+	reporter.ensurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: body}, nil
+}
+
+// Embed implements cliproxyauth.EmbeddingExecutor using the OpenAI /embeddings endpoint.
+func (e *OpenAICompatExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	payload := bytes.Clone(req.Payload)
+	if upstream := e.resolveUpstreamModel(req.Model, auth); upstream != "" {
+		payload = e.overrideModel(payload, upstream)
+	}
+	body, err := e.postJSON(ctx, auth, "/embeddings", payload)
+	if err != nil {
+		return resp, err
+	}
+	reporter.publish(ctx, parseOpenAIUsage(body))
+	reporter.ensurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: body}, nil
+}
+
+// postJSON sends a JSON request to path under the provider base URL and returns the body.
+func (e *OpenAICompatExecutor) postJSON(ctx context.Context, auth *cliproxyauth.Auth, path string, payload []byte) ([]byte, error) {
+	baseURL, _ := e.resolveCredentials(auth)
+	if baseURL == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+	}
+	url := strings.TrimSuffix(expandBaseURL(baseURL, auth, gjson.GetBytes(payload, "model").String()), "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      payload,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	started := time.Now()
+	httpResp, err := httpClient.Do(httpReq)
+	observeBaseURL(auth, baseURL, started, httpResp, err)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	reportQuotaHeaders(ctx, httpResp.Header)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("openai compat executor: close response body error: %v", errClose)
+		}
+		return nil, newUpstreamStatusErr(httpResp, b)
+	}
+	return readUpstreamBody(ctx, e.cfg, httpResp, "openai compat")
 }
 
 // Refresh is a no-op for API-key based compatibility providers.
@@ -330,9 +448,14 @@ func (e *OpenAICompatExecutor) resolveCredentials(auth *cliproxyauth.Auth) (base
 		return "", ""
 	}
 	if auth.Attributes != nil {
-		baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+		baseURL = selectBaseURL(auth)
 		apiKey = strings.TrimSpace(auth.Attributes["api_key"])
 	}
+	if baseURL == "" {
+		if spec, ok := config.ProviderKeySpecFor(e.provider); ok {
+			baseURL = spec.DefaultBaseURL
+		}
+	}
 	return
 }
 
@@ -340,26 +463,33 @@ func (e *OpenAICompatExecutor) resolveUpstreamModel(alias string, auth *cliproxy
 	if alias == "" || auth == nil || e.cfg == nil {
 		return ""
 	}
-	compat := e.resolveCompatConfig(auth)
-	if compat == nil {
-		return ""
-	}
-	for i := range compat.Models {
-		model := compat.Models[i]
+	upstream := ""
+	for _, model := range e.configuredModels(auth) {
 		if model.Alias != "" {
 			if strings.EqualFold(model.Alias, alias) {
+				upstream = alias
 				if model.Name != "" {
-					return model.Name
+					upstream = model.Name
 				}
-				return alias
+				break
 			}
 			continue
 		}
 		if strings.EqualFold(model.Name, alias) {
-			return model.Name
+			upstream = model.Name
+			break
 		}
 	}
-	return ""
+	if rewrite := e.preset().upstreamModel; rewrite != nil {
+		name := upstream
+		if name == "" {
+			name = alias
+		}
+		if rewritten := rewrite(name); rewritten != alias {
+			upstream = rewritten
+		}
+	}
+	return upstream
 }
 
 func (e *OpenAICompatExecutor) allowCompatReasoningEffort(model string, auth *cliproxyauth.Auth) bool {
@@ -367,12 +497,10 @@ func (e *OpenAICompatExecutor) allowCompatReasoningEffort(model string, auth *cl
 	if trimmed == "" || e == nil || e.cfg == nil {
 		return false
 	}
-	compat := e.resolveCompatConfig(auth)
-	if compat == nil || len(compat.Models) == 0 {
-		return false
+	if e.preset().reasoningEffort {
+		return true
 	}
-	for i := range compat.Models {
-		entry := compat.Models[i]
+	for _, entry := range e.configuredModels(auth) {
 		if strings.EqualFold(strings.TrimSpace(entry.Alias), trimmed) {
 			return true
 		}
@@ -383,6 +511,87 @@ func (e *OpenAICompatExecutor) allowCompatReasoningEffort(model string, auth *cl
 	return false
 }
 
+// configuredModels returns the model names and aliases configured for auth, from its
+// openai-compatibility entry or, for presets, its provider-key entry.
+func (e *OpenAICompatExecutor) configuredModels(auth *cliproxyauth.Auth) []config.ProviderModel {
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		models := make([]config.ProviderModel, 0, len(compat.Models))
+		for i := range compat.Models {
+			models = append(models, config.ProviderModel{Name: compat.Models[i].Name, Alias: compat.Models[i].Alias})
+		}
+		return models
+	}
+	if auth == nil || auth.Attributes == nil || e.cfg == nil {
+		return nil
+	}
+	if _, isPreset := compatPresets[e.provider]; !isPreset {
+		return nil
+	}
+	if entry := e.cfg.FindProviderKey(e.provider, auth.Attributes["api_key"], auth.Attributes["base_url"]); entry != nil {
+		return entry.Models
+	}
+	return nil
+}
+
+// preset returns the preset hooks of the executor's provider; the zero value when the
+// provider is a plain openai-compatibility entry.
+func (e *OpenAICompatExecutor) preset() compatPreset {
+	return compatPresets[e.provider]
+}
+
+// applyPreset runs the provider preset on a translated request. Presets always ask for
+// the trailing usage chunk on streams so token accounting works.
+func (e *OpenAICompatExecutor) applyPreset(translated []byte, model string, auth *cliproxyauth.Auth, stream bool) []byte {
+	preset, ok := compatPresets[e.provider]
+	if !ok {
+		return translated
+	}
+	if preset.preparePayload != nil {
+		upstream := model
+		if override := e.resolveUpstreamModel(model, auth); override != "" {
+			upstream = override
+		}
+		translated = preset.preparePayload(translated, upstream)
+	}
+	if stream {
+		translated, _ = sjson.SetBytes(translated, "stream_options.include_usage", true)
+	}
+	return translated
+}
+
+// bearerToken returns the credential sent for apiKey, signed by the preset when the
+// provider does not take raw keys.
+func (e *OpenAICompatExecutor) bearerToken(apiKey string) string {
+	if sign := e.preset().authorization; sign != nil {
+		return sign(apiKey)
+	}
+	return apiKey
+}
+
+// setAuthHeader sends apiKey in the header named by the provider's auth-header template,
+// or as a bearer token.
+func (e *OpenAICompatExecutor) setAuthHeader(req *http.Request, auth *cliproxyauth.Auth, apiKey string) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return
+	}
+	if compat := e.resolveCompatConfig(auth); compat != nil && compat.AuthHeader != "" {
+		if name, value, err := config.AuthHeader(compat.AuthHeader, apiKey); err == nil {
+			req.Header.Set(name, value)
+			return
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+e.bearerToken(apiKey))
+}
+
+// applyQuirks runs the quirks of the provider's openai-compatibility entry on a request.
+func (e *OpenAICompatExecutor) applyQuirks(translated []byte, auth *cliproxyauth.Auth) []byte {
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		return applyCompatQuirks(translated, compat.Quirks)
+	}
+	return translated
+}
+
 func (e *OpenAICompatExecutor) resolveCompatConfig(auth *cliproxyauth.Auth) *config.OpenAICompatibility {
 	if auth == nil || e.cfg == nil {
 		return nil
@@ -410,6 +619,25 @@ func (e *OpenAICompatExecutor) resolveCompatConfig(auth *cliproxyauth.Auth) *con
 	return nil
 }
 
+// nativeFIMRequest builds a native fill-in-the-middle request when the execution carries
+// a FIM suffix and the provider exposes a FIM endpoint. Otherwise the chat emulation
+// prepared by the completions handler is sent unchanged.
+func (e *OpenAICompatExecutor) nativeFIMRequest(auth *cliproxyauth.Auth, baseURL string, translated []byte, metadata map[string]any) (string, []byte, bool) {
+	prompt, suffix, ok := util.FIMFromMetadata(metadata)
+	if !ok {
+		return "", nil, false
+	}
+	configured := ""
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		configured = compat.FIM
+	}
+	mode := resolveFIMMode(configured, baseURL)
+	if mode == "" {
+		return "", nil, false
+	}
+	return fimEndpoint(mode, baseURL), buildFIMPayload(translated, prompt, suffix), true
+}
+
 func (e *OpenAICompatExecutor) overrideModel(payload []byte, model string) []byte {
 	if len(payload) == 0 || model == "" {
 		return payload
@@ -432,3 +660,61 @@ func (e statusErr) Error() string {
 }
 func (e statusErr) StatusCode() int            { return e.code }
 func (e statusErr) RetryAfter() *time.Duration { return e.retryAfter }
+
+// newUpstreamStatusErr builds the error for a non-2xx upstream response. 429 responses
+// carry the provider's retry hint, from the headers or a Google RetryInfo body, so the
+// auth manager cools the credential down for exactly that long.
+func newUpstreamStatusErr(resp *http.Response, body []byte) statusErr {
+	err := statusErr{code: resp.StatusCode, msg: string(body)}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if wait, ok := retryAfterFromHeader(resp.Header, time.Now()); ok {
+			err.retryAfter = &wait
+		} else if delay, errParse := parseRetryDelay(body); errParse == nil && delay != nil {
+			err.retryAfter = delay
+		}
+	}
+	return err
+}
+
+// retryAfterFromHeader derives the wait for a rate-limited response from Retry-After
+// (delta-seconds or HTTP date) or, failing that, from the OpenAI-style
+// x-ratelimit-reset-requests/-tokens headers or the spent windows quotaExhaustedFromHeader
+// understands.
+func retryAfterFromHeader(header http.Header, now time.Time) (time.Duration, bool) {
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if secs, err := strconv.Atoi(value); err == nil {
+			if secs > 0 {
+				return time.Duration(secs) * time.Second, true
+			}
+		} else if at, err := http.ParseTime(value); err == nil && at.After(now) {
+			return at.Sub(now), true
+		}
+	}
+	var wait time.Duration
+	for _, kind := range []string{"requests", "tokens"} {
+		if reset, ok := parseRateLimitReset(header.Get("x-ratelimit-reset-" + kind)); ok && reset > wait {
+			wait = reset
+		}
+	}
+	if wait > 0 {
+		return wait, true
+	}
+	return quotaExhaustedFromHeader(header, now)
+}
+
+// parseRateLimitReset parses x-ratelimit-reset values such as "2m59.56s", "7.66s" or
+// "120ms", and bare seconds such as "11.38".
+func parseRateLimitReset(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		value += "s"
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
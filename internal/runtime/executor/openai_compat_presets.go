@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// compatPreset adapts OpenAICompatExecutor to a provider that has its own config
+// section (see config.ProviderKeySpec) but otherwise speaks the OpenAI chat API.
+// Every hook is optional.
+type compatPreset struct {
+	// reasoningEffort forwards reasoning_effort for every model, not only configured ones.
+	reasoningEffort bool
+	// upstreamModel rewrites the model name sent upstream.
+	upstreamModel func(model string) string
+	// preparePayload adjusts the translated request after thinking normalization.
+	preparePayload func(payload []byte, model string) []byte
+	// authorization turns the configured API key into the bearer credential.
+	authorization func(apiKey string) string
+}
+
+// compatPresets lists the providers served by OpenAICompatExecutor through presets.
+var compatPresets = map[string]compatPreset{
+	"groq":       {},
+	"xai":        {preparePayload: prepareXAIPayload},
+	"openrouter": {reasoningEffort: true},
+	"fireworks":  {upstreamModel: fireworksModelPath, preparePayload: prepareFireworksPayload},
+	"nvidia":     {reasoningEffort: true, preparePayload: prepareNVIDIAPayload},
+	"moonshot":   {preparePayload: prepareMoonshotPayload},
+	"zhipu":      {reasoningEffort: true, preparePayload: prepareZhipuPayload, authorization: zhipuAuthorization},
+	"cerebras":   {},
+}
+
+// fetchCompatModelList GETs the /models listing of a preset provider and returns the
+// raw body, or nil on failure.
+func fetchCompatModelList(ctx context.Context, provider string, auth *cliproxyauth.Auth, cfg *config.Config) []byte {
+	exec := NewOpenAICompatExecutor(provider, cfg)
+	baseURL, _ := exec.resolveCredentials(auth)
+	return exec.fetchModelList(ctx, auth, strings.TrimSuffix(baseURL, "/")+"/models")
+}
+
+// fetchModelList GETs a model listing at url with the credentials of auth and returns the
+// raw body, or nil on failure.
+func (e *OpenAICompatExecutor) fetchModelList(ctx context.Context, auth *cliproxyauth.Auth, url string) []byte {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil
+	}
+	httpResp, err := e.HttpRequest(ctx, auth, httpReq)
+	if err != nil {
+		log.Debugf("%s executor: list models failed: %v", e.provider, err)
+		return nil
+	}
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errClose := httpResp.Body.Close(); errClose != nil {
+		log.Errorf("%s executor: close response body error: %v", e.provider, errClose)
+	}
+	if errRead != nil || httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		log.Debugf("%s executor: list models returned status %d", e.provider, httpResp.StatusCode)
+		return nil
+	}
+	return data
+}
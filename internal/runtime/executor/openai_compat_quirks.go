@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyCompatQuirks adjusts a translated OpenAI chat request for a provider that rejects
+// parts of the API (see config.CompatQuirks).
+func applyCompatQuirks(payload []byte, quirks config.CompatQuirks) []byte {
+	if quirks.NoStreamOptions {
+		payload, _ = sjson.DeleteBytes(payload, "stream_options")
+	}
+	if quirks.LegacyMaxTokens {
+		if limit := gjson.GetBytes(payload, "max_completion_tokens"); limit.Exists() {
+			if !gjson.GetBytes(payload, "max_tokens").Exists() {
+				payload, _ = sjson.SetBytes(payload, "max_tokens", limit.Value())
+			}
+			payload, _ = sjson.DeleteBytes(payload, "max_completion_tokens")
+		}
+	}
+	if quirks.SystemAsUser {
+		for i, message := range gjson.GetBytes(payload, "messages").Array() {
+			switch message.Get("role").String() {
+			case "system", "developer":
+				payload, _ = sjson.SetBytes(payload, fmt.Sprintf("messages.%d.role", i), "user")
+			}
+		}
+	}
+	if quirks.NoReasoningEffort {
+		payload, _ = sjson.DeleteBytes(payload, "reasoning_effort")
+	}
+	return payload
+}
+
+// FetchCompatModels lists the models of an openai-compatibility credential from the
+// models-url of its entry. Listings in the OpenAI shape ({"data":[{"id":...}]}), as a
+// bare array, or under "models" are accepted.
+func FetchCompatModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	if auth == nil {
+		return nil
+	}
+	exec := NewOpenAICompatExecutor(strings.ToLower(auth.Provider), cfg)
+	compat := exec.resolveCompatConfig(auth)
+	if compat == nil || compat.ModelsURL == "" {
+		return nil
+	}
+	url := compat.ModelsURL
+	if !strings.Contains(url, "://") {
+		baseURL, _ := exec.resolveCredentials(auth)
+		url = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(url, "/")
+	}
+	data := exec.fetchModelList(ctx, auth, url)
+	if data == nil {
+		return nil
+	}
+
+	listing := gjson.ParseBytes(data)
+	items := listing.Array()
+	if !listing.IsArray() {
+		items = listing.Get("data").Array()
+		if len(items) == 0 {
+			items = listing.Get("models").Array()
+		}
+	}
+	now := time.Now().Unix()
+	models := make([]*registry.ModelInfo, 0, len(items))
+	for _, item := range items {
+		id := item.String()
+		if item.IsObject() {
+			id = item.Get("id").String()
+			if id == "" {
+				id = item.Get("name").String()
+			}
+		}
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		models = append(models, &registry.ModelInfo{
+			ID:            id,
+			Object:        "model",
+			Created:       now,
+			OwnedBy:       compat.Name,
+			Type:          "openai-compatibility",
+			DisplayName:   id,
+			ContextLength: int(item.Get("context_length").Int()),
+		})
+	}
+	return models
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyCompatQuirks(t *testing.T) {
+	payload := []byte(`{"max_completion_tokens":64,"reasoning_effort":"low","stream_options":{"include_usage":true},"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hi"}]}`)
+	out := applyCompatQuirks(payload, config.CompatQuirks{NoStreamOptions: true, LegacyMaxTokens: true, SystemAsUser: true, NoReasoningEffort: true})
+	if gjson.GetBytes(out, "stream_options").Exists() || gjson.GetBytes(out, "reasoning_effort").Exists() || gjson.GetBytes(out, "max_completion_tokens").Exists() {
+		t.Fatalf("quirks left rejected fields: %s", out)
+	}
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 64 {
+		t.Fatalf("max_tokens = %d, want 64", got)
+	}
+	if got := gjson.GetBytes(out, "messages.0.role").String(); got != "user" {
+		t.Fatalf("system role = %q, want user", got)
+	}
+	if got := string(applyCompatQuirks(payload, config.CompatQuirks{})); got != string(payload) {
+		t.Fatalf("no quirks must leave the payload unchanged, got %s", got)
+	}
+}
+
+func TestOpenAICompatExecutorDefinitionFields(t *testing.T) {
+	var gotKey, gotAuthorization string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/catalog":
+			_, _ = io.WriteString(w, `{"models":[{"name":"acme-large"},{"name":"acme-small"}]}`)
+		default:
+			gotKey, gotAuthorization = r.Header.Get("X-Api-Key"), r.Header.Get("Authorization")
+			gotBody, _ = io.ReadAll(r.Body)
+			_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{
+		Name:       "acme",
+		BaseURL:    server.URL + "/v1",
+		AuthHeader: "x-api-key: {api-key}",
+		ModelsURL:  "catalog",
+		Quirks:     config.CompatQuirks{LegacyMaxTokens: true},
+	}}}
+	auth := &cliproxyauth.Auth{Provider: "acme", Attributes: map[string]string{"base_url": server.URL + "/v1", "api_key": "secret", "compat_name": "acme"}}
+
+	models := FetchCompatModels(context.Background(), auth, cfg)
+	if len(models) != 2 || models[0].ID != "acme-large" {
+		t.Fatalf("models = %+v", models)
+	}
+
+	exec := NewOpenAICompatExecutor("acme", cfg)
+	_, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "acme-large",
+		Payload: []byte(`{"model":"acme-large","max_completion_tokens":32,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotKey != "secret" || gotAuthorization != "" {
+		t.Fatalf("x-api-key = %q, Authorization = %q", gotKey, gotAuthorization)
+	}
+	if got := gjson.GetBytes(gotBody, "max_tokens").Int(); got != 32 {
+		t.Fatalf("upstream body = %s, want max_tokens", gotBody)
+	}
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// FetchOpenRouterModels lists OpenRouter's model catalog, including per-token pricing.
+func FetchOpenRouterModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	data := fetchCompatModelList(ctx, "openrouter", auth, cfg)
+	if data == nil {
+		return nil
+	}
+	return parseOpenRouterModels(data)
+}
+
+func parseOpenRouterModels(data []byte) []*registry.ModelInfo {
+	var models []*registry.ModelInfo
+	for _, item := range gjson.GetBytes(data, "data").Array() {
+		id := strings.TrimSpace(item.Get("id").String())
+		if id == "" {
+			continue
+		}
+		ownedBy := "openrouter"
+		if idx := strings.Index(id, "/"); idx > 0 {
+			ownedBy = id[:idx]
+		}
+		info := &registry.ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             item.Get("created").Int(),
+			OwnedBy:             ownedBy,
+			Type:                "openrouter",
+			DisplayName:         item.Get("name").String(),
+			Description:         item.Get("description").String(),
+			ContextLength:       int(item.Get("context_length").Int()),
+			MaxCompletionTokens: int(item.Get("top_provider.max_completion_tokens").Int()),
+		}
+		for _, param := range item.Get("supported_parameters").Array() {
+			info.SupportedParameters = append(info.SupportedParameters, param.String())
+		}
+		if pricing := item.Get("pricing"); pricing.IsObject() {
+			info.Pricing = &registry.ModelPricing{
+				Prompt:     pricing.Get("prompt").String(),
+				Completion: pricing.Get("completion").String(),
+				Request:    pricing.Get("request").String(),
+				Image:      pricing.Get("image").String(),
+			}
+		}
+		models = append(models, info)
+	}
+	return models
+}
@@ -0,0 +1,44 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestFetchOpenRouterModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-or" {
+			t.Errorf("Authorization = %q", got)
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"anthropic/claude-sonnet-4","name":"Anthropic: Claude Sonnet 4","created":1747930371,"context_length":200000,
+			 "top_provider":{"max_completion_tokens":64000},"supported_parameters":["tools","reasoning"],
+			 "pricing":{"prompt":"0.000003","completion":"0.000015","request":"0","image":"0.0048"}},
+			{"id":""}]}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "or-1", Provider: "openrouter", Attributes: map[string]string{"base_url": server.URL, "api_key": "sk-or"}}
+	models := FetchOpenRouterModels(context.Background(), auth, nil)
+	if len(models) != 1 {
+		t.Fatalf("models = %d, want 1", len(models))
+	}
+	m := models[0]
+	if m.ID != "anthropic/claude-sonnet-4" || m.OwnedBy != "anthropic" || m.ContextLength != 200000 || m.MaxCompletionTokens != 64000 {
+		t.Fatalf("unexpected model: %+v", m)
+	}
+	if m.Pricing == nil || m.Pricing.Prompt != "0.000003" || m.Pricing.Completion != "0.000015" {
+		t.Fatalf("unexpected pricing: %+v", m.Pricing)
+	}
+	if len(m.SupportedParameters) != 2 {
+		t.Fatalf("supported parameters = %v", m.SupportedParameters)
+	}
+}
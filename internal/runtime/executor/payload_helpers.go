@@ -1,12 +1,17 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/expr"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -104,11 +109,15 @@ func ApplyReasoningEffortMetadata(payload []byte, metadata map[string]any, model
 	return payload
 }
 
+// payloadBytesPerToken approximates the token count conditions see from the payload size.
+const payloadBytesPerToken = 4
+
 // applyPayloadConfigWithRoot behaves like applyPayloadConfig but treats all parameter
 // paths as relative to the provided root path (for example, "request" for Gemini CLI)
 // and restricts matches to the given protocol when supplied. Defaults are checked
-// against the original payload when provided.
-func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload, original []byte) []byte {
+// against the original payload when provided. Rules with a when condition are matched
+// against the request attributes of ctx.
+func applyPayloadConfigWithRoot(ctx context.Context, cfg *config.Config, model, protocol, root string, payload, original []byte) []byte {
 	if cfg == nil || len(payload) == 0 {
 		return payload
 	}
@@ -125,11 +134,13 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	if len(source) == 0 {
 		source = payload
 	}
+	env := expr.RequestEnv(model, len(source)/payloadBytesPerToken, inflight.FromContext(ctx).Tenant(), time.Now())
+	env["protocol"] = protocol
 	appliedDefaults := make(map[string]struct{})
 	// Apply default rules: first write wins per field across all matching rules.
 	for i := range rules.Default {
 		rule := &rules.Default[i]
-		if !payloadRuleMatchesModel(rule, model, protocol) {
+		if !payloadRuleMatchesModel(rule, model, protocol) || !payloadRuleConditionMatches(rule, env) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -154,7 +165,7 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	// Apply override rules: last write wins per field across all matching rules.
 	for i := range rules.Override {
 		rule := &rules.Override[i]
-		if !payloadRuleMatchesModel(rule, model, protocol) {
+		if !payloadRuleMatchesModel(rule, model, protocol) || !payloadRuleConditionMatches(rule, env) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -194,6 +205,17 @@ func payloadRuleMatchesModel(rule *config.PayloadRule, model, protocol string) b
 	return false
 }
 
+// payloadRuleConditionMatches evaluates the when condition of rule against the request
+// attributes env. A condition that fails to evaluate does not match.
+func payloadRuleConditionMatches(rule *config.PayloadRule, env map[string]any) bool {
+	matched, err := expr.Match(rule.When, env)
+	if err != nil {
+		log.Debugf("payload: skipping rule with when %q: %v", rule.When, err)
+		return false
+	}
+	return matched
+}
+
 // buildPayloadPath combines an optional root path with a relative parameter path.
 // When root is empty, the parameter path is used as-is. When root is non-empty,
 // the parameter path is treated as relative to root.
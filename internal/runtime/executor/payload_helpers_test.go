@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPayloadConfigWithRoot_When(t *testing.T) {
+	cfg := &config.Config{Payload: config.PayloadConfig{Override: []config.PayloadRule{
+		{
+			Models: []config.PayloadModelRule{{Name: "gpt-*"}},
+			When:   `tokens > 100 && protocol == "openai"`,
+			Params: map[string]any{"max_tokens": 4096},
+		},
+		{
+			Models: []config.PayloadModelRule{{Name: "*"}},
+			When:   `tenant == "team-a"`,
+			Params: map[string]any{"metadata.team": "a"},
+		},
+	}}}
+
+	small := []byte(`{"model":"gpt-5"}`)
+	out := applyPayloadConfigWithRoot(context.Background(), cfg, "gpt-5", "openai", "", small, nil)
+	if gjson.GetBytes(out, "max_tokens").Exists() || gjson.GetBytes(out, "metadata.team").Exists() {
+		t.Fatalf("conditions must not match a small anonymous request: %s", out)
+	}
+
+	large := []byte(`{"model":"gpt-5","prompt":"` + strings.Repeat("x", 500) + `"}`)
+	if got := gjson.GetBytes(applyPayloadConfigWithRoot(context.Background(), cfg, "gpt-5", "openai", "", large, nil), "max_tokens").Int(); got != 4096 {
+		t.Fatalf("max_tokens = %d, want 4096 for a large prompt", got)
+	}
+	if out = applyPayloadConfigWithRoot(context.Background(), cfg, "gpt-5", "claude", "", large, nil); gjson.GetBytes(out, "max_tokens").Exists() {
+		t.Fatalf("protocol condition must not match claude: %s", out)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, _ = inflight.Start(ctx, cancel, "req-1", "openai", "", "team-a")
+	if got := gjson.GetBytes(applyPayloadConfigWithRoot(ctx, cfg, "gpt-5", "openai", "", small, nil), "metadata.team").String(); got != "a" {
+		t.Fatalf("metadata.team = %q, want the tenant rule applied", got)
+	}
+}
@@ -37,6 +37,12 @@ var (
 // Returns:
 //   - *http.Client: An HTTP client with configured proxy or transport
 func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
+	client := withUpstreamErrorCapture(withBandwidthAccounting(withUpstreamTracing(cachedProxyAwareHTTPClient(ctx, cfg, auth, timeout), auth), auth))
+	return withRedirectPolicy(client, cfg, auth)
+}
+
+// cachedProxyAwareHTTPClient resolves the shared client for the effective proxy URL.
+func cachedProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
 	// Priority 1: Use auth.ProxyURL if configured
 	var proxyURL string
 	if auth != nil {
@@ -48,8 +54,14 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		proxyURL = strings.TrimSpace(cfg.ProxyURL)
 	}
 
-	// Build cache key from proxy URL (empty string for no proxy)
+	// Build cache key from proxy URL (empty string for no proxy). Clients dialing through
+	// the custom upstream dialer are cached separately from plain ones.
+	dialer := configureUpstreamDialer(cfg)
+	customDial := dialer.enabled()
 	cacheKey := proxyURL
+	if customDial {
+		cacheKey += "|dial"
+	}
 
 	// Check cache first
 	httpClientCacheMutex.RLock()
@@ -76,6 +88,9 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	if proxyURL != "" {
 		transport := buildProxyTransport(proxyURL)
 		if transport != nil {
+			if customDial {
+				applyUpstreamDialer(transport, dialer, proxyURL)
+			}
 			httpClient.Transport = transport
 			// Cache the client
 			httpClientCacheMutex.Lock()
@@ -90,6 +105,8 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	// Priority 3: Use RoundTripper from context (typically from RoundTripperFor)
 	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
 		httpClient.Transport = rt
+	} else if customDial {
+		httpClient.Transport = dialer.newTransport()
 	}
 
 	// Cache the client for no-proxy case
@@ -153,3 +170,32 @@ func buildProxyTransport(proxyURL string) *http.Transport {
 
 	return transport
 }
+
+// applyUpstreamDialer routes the proxy connection itself through the upstream dialer so
+// DNS caching and address family preference also apply when reaching the proxy.
+func applyUpstreamDialer(transport *http.Transport, dialer *upstreamDialer, proxyURL string) {
+	if transport == nil || dialer == nil {
+		return
+	}
+	parsedURL, errParse := url.Parse(proxyURL)
+	if errParse != nil {
+		return
+	}
+	if parsedURL.Scheme == "socks5" {
+		var proxyAuth *proxy.Auth
+		if parsedURL.User != nil {
+			username := parsedURL.User.Username()
+			password, _ := parsedURL.User.Password()
+			proxyAuth = &proxy.Auth{User: username, Password: password}
+		}
+		socksDialer, errSOCKS5 := proxy.SOCKS5("tcp", parsedURL.Host, proxyAuth, dialer)
+		if errSOCKS5 != nil {
+			return
+		}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return socksDialer.Dial(network, addr)
+		}
+		return
+	}
+	transport.DialContext = dialer.DialContext
+}
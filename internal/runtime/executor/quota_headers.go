@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// anthropicRateLimitWindows are the budgets Anthropic reports in its
+// anthropic-ratelimit-<window>-remaining/-reset response headers.
+var anthropicRateLimitWindows = []string{"requests", "tokens", "input-tokens", "output-tokens"}
+
+// quotaExhaustedFromHeader returns how long until the budgets a response reports spent
+// reset: the OpenAI-style x-ratelimit-remaining-requests/-tokens windows, Cerebras'
+// per-day/per-minute windows and Anthropic's anthropic-ratelimit-* windows. The longest
+// reset wins, since the credential is unusable until every spent budget is back.
+func quotaExhaustedFromHeader(header http.Header, now time.Time) (time.Duration, bool) {
+	if len(header) == 0 {
+		return 0, false
+	}
+	wait, _ := rateLimitWindowsExhausted(header, "requests", "tokens")
+	if reset, ok := rateLimitWindowsExhausted(header, cerebrasRateLimitWindows...); ok && reset > wait {
+		wait = reset
+	}
+	if reset, ok := anthropicWindowsExhausted(header, now); ok && reset > wait {
+		wait = reset
+	}
+	return wait, wait > 0
+}
+
+// anthropicWindowsExhausted returns the time until the latest reset among the spent
+// anthropic-ratelimit-* windows. Anthropic reports resets as RFC 3339 timestamps.
+func anthropicWindowsExhausted(header http.Header, now time.Time) (time.Duration, bool) {
+	var wait time.Duration
+	for _, window := range anthropicRateLimitWindows {
+		remaining := strings.TrimSpace(header.Get("anthropic-ratelimit-" + window + "-remaining"))
+		if remaining == "" {
+			continue
+		}
+		if n, err := strconv.ParseFloat(remaining, 64); err != nil || n > 0 {
+			continue
+		}
+		reset, err := time.Parse(time.RFC3339, strings.TrimSpace(header.Get("anthropic-ratelimit-"+window+"-reset")))
+		if err != nil {
+			continue
+		}
+		if d := reset.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait, wait > 0
+}
+
+// reportQuotaHeaders takes the credential out of rotation until its budget resets when
+// the upstream response reports it spent, so the next request goes to another credential
+// instead of drawing a 429. Executors call it with the headers of every upstream response.
+func reportQuotaHeaders(ctx context.Context, header http.Header) {
+	if wait, exhausted := quotaExhaustedFromHeader(header, time.Now()); exhausted {
+		cliproxyauth.ReportQuotaExhausted(ctx, wait)
+	}
+}
@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestQuotaExhaustedFromHeader_Anthropic(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	header := http.Header{}
+	header.Set("anthropic-ratelimit-requests-remaining", "12")
+	header.Set("anthropic-ratelimit-requests-reset", now.Add(5*time.Second).Format(time.RFC3339))
+	header.Set("anthropic-ratelimit-input-tokens-remaining", "0")
+	header.Set("anthropic-ratelimit-input-tokens-reset", now.Add(40*time.Second).Format(time.RFC3339))
+	wait, exhausted := quotaExhaustedFromHeader(header, now)
+	if !exhausted || wait != 40*time.Second {
+		t.Fatalf("wait = %s, exhausted = %t; want the input token reset", wait, exhausted)
+	}
+	if got, ok := retryAfterFromHeader(header, now); !ok || got != wait {
+		t.Fatalf("429 retry hint = %s, %t; want %s", got, ok, wait)
+	}
+
+	header.Set("anthropic-ratelimit-input-tokens-remaining", "80000")
+	if _, exhausted = quotaExhaustedFromHeader(header, now); exhausted {
+		t.Fatal("a response with remaining budget must not report exhaustion")
+	}
+}
+
+func TestNewUpstreamStatusErr_RetryInfoBody(t *testing.T) {
+	body := []byte(`{"error":{"code":429,"details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"17s"}]}}`)
+	err := newUpstreamStatusErr(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}, body)
+	if err.RetryAfter() == nil || *err.RetryAfter() != 17*time.Second {
+		t.Fatalf("retry after = %v, want 17s from the RetryInfo body", err.RetryAfter())
+	}
+	err = newUpstreamStatusErr(&http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}}, body)
+	if err.RetryAfter() != nil {
+		t.Fatal("only 429 responses carry a retry hint")
+	}
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// Upstream redirect policies accepted by network.redirects.
+const (
+	redirectPolicyFollow = "follow"
+	redirectPolicyFail   = "fail"
+)
+
+// maxUpstreamRedirects bounds redirect chains under the follow policy, matching Go's default.
+const maxUpstreamRedirects = 10
+
+// redirectPolicy resolves the redirect policy for provider: the per-provider entry, then
+// the global one. Empty keeps Go's default behavior.
+func redirectPolicy(cfg *config.Config, provider string) string {
+	if cfg == nil {
+		return ""
+	}
+	policy := cfg.Network.Redirects
+	if override, ok := cfg.Network.RedirectsPerProvider[provider]; ok && strings.TrimSpace(override) != "" {
+		policy = override
+	}
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	switch policy {
+	case "", redirectPolicyFollow, redirectPolicyFail:
+		return policy
+	default:
+		log.Warnf("unknown redirect policy %q for provider %s, using default", policy, provider)
+		return ""
+	}
+}
+
+// withRedirectPolicy returns a copy of client that handles upstream redirects as configured
+// for the provider of auth. The transport is shared, so connection reuse is unaffected.
+func withRedirectPolicy(client *http.Client, cfg *config.Config, auth *cliproxyauth.Auth) *http.Client {
+	if client == nil {
+		return client
+	}
+	provider := ""
+	if auth != nil {
+		provider = auth.Provider
+	}
+	var check func(*http.Request, []*http.Request) error
+	switch redirectPolicy(cfg, provider) {
+	case redirectPolicyFollow:
+		check = followRedirectWithCredentials
+	case redirectPolicyFail:
+		check = failOnRedirect(provider)
+	default:
+		return client
+	}
+	out := *client
+	out.CheckRedirect = check
+	return &out
+}
+
+// followRedirectWithCredentials replays the original request on the redirect target:
+// every original header, including credentials Go strips on cross-host redirects, and
+// the original method and body unless the upstream answered 303 See Other.
+func followRedirectWithCredentials(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxUpstreamRedirects {
+		return fmt.Errorf("upstream stopped after %d redirects", maxUpstreamRedirects)
+	}
+	original := via[0]
+	req.Header = original.Header.Clone()
+	if req.Response != nil && req.Response.StatusCode != http.StatusSeeOther && req.Method != original.Method {
+		if original.GetBody == nil {
+			return fmt.Errorf("upstream redirected %s to %s and the request body cannot be replayed", original.URL.Redacted(), req.URL.Redacted())
+		}
+		body, err := original.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Method = original.Method
+		req.Body = body
+		req.GetBody = original.GetBody
+		req.ContentLength = original.ContentLength
+	}
+	log.Debugf("following upstream redirect %s -> %s", via[len(via)-1].URL.Redacted(), req.URL.Redacted())
+	return nil
+}
+
+// failOnRedirect aborts redirected upstream requests with an error naming the new location.
+func failOnRedirect(provider string) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		status := 0
+		if req.Response != nil {
+			status = req.Response.StatusCode
+		}
+		return fmt.Errorf("upstream redirected to %s (HTTP %d); update the %s base-url or set network.redirects to %q", req.URL.Redacted(), status, provider, redirectPolicyFollow)
+	}
+}
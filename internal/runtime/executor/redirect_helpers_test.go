@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestWithRedirectPolicy(t *testing.T) {
+	var gotMethod, gotAuth, gotBody string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotAuth, gotBody = r.Method, r.Header.Get("Authorization"), string(body)
+	}))
+	defer target.Close()
+	// Redirect to a different host name so Go's default policy would strip credentials.
+	location := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, location+"/v1/messages", http.StatusFound)
+	}))
+	defer relay.Close()
+
+	send := func(cfg *config.Config) (*http.Response, error) {
+		gotMethod, gotAuth, gotBody = "", "", ""
+		client := withRedirectPolicy(&http.Client{}, cfg, &cliproxyauth.Auth{Provider: "claude"})
+		req, _ := http.NewRequest(http.MethodPost, relay.URL+"/v1/messages", bytes.NewReader([]byte(`{"a":1}`)))
+		req.Header.Set("Authorization", "Bearer secret")
+		return client.Do(req)
+	}
+
+	cfg := &config.Config{Network: config.NetworkConfig{Redirects: "fail", RedirectsPerProvider: map[string]string{"claude": "follow"}}}
+	resp, err := send(cfg)
+	if err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	_ = resp.Body.Close()
+	if gotMethod != http.MethodPost || gotAuth != "Bearer secret" || gotBody != `{"a":1}` {
+		t.Fatalf("follow must replay the request, got %s auth=%q body=%q", gotMethod, gotAuth, gotBody)
+	}
+
+	_, err = send(&config.Config{Network: config.NetworkConfig{Redirects: "fail"}})
+	if err == nil || !strings.Contains(err.Error(), "upstream redirected to") {
+		t.Fatalf("fail must reject the redirect, got %v", err)
+	}
+	if gotMethod != "" {
+		t.Fatal("fail must not reach the redirect target")
+	}
+
+	if client := withRedirectPolicy(&http.Client{}, &config.Config{}, nil); client.CheckRedirect != nil {
+		t.Fatal("no policy must keep Go's default redirect handling")
+	}
+}
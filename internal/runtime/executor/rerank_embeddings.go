@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// readUpstreamBody reads and closes a successful upstream response, recording it in the
+// request log.
+func readUpstreamBody(ctx context.Context, cfg *config.Config, httpResp *http.Response, provider string) ([]byte, error) {
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close response body error: %v", provider, errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	appendAPIResponseChunk(ctx, cfg, body)
+	return body, nil
+}
+
+// rerankDocumentText returns the text of a rerank document given either as a plain
+// string or as an object with a "text" field.
+func rerankDocumentText(doc gjson.Result) string {
+	if doc.Type == gjson.String {
+		return doc.String()
+	}
+	if text := doc.Get("text"); text.Exists() {
+		return text.String()
+	}
+	return doc.Raw
+}
+
+// buildUpstreamRerankPayload builds a Cohere/Jina style rerank request for model. Documents
+// are flattened to strings, which both APIs accept.
+func buildUpstreamRerankPayload(payload []byte, model string) []byte {
+	root := gjson.ParseBytes(payload)
+	docs := make([]string, 0)
+	root.Get("documents").ForEach(func(_, doc gjson.Result) bool {
+		docs = append(docs, rerankDocumentText(doc))
+		return true
+	})
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "query", root.Get("query").String())
+	out, _ = sjson.SetBytes(out, "documents", docs)
+	if topN := root.Get("top_n"); topN.Exists() {
+		out, _ = sjson.SetBytes(out, "top_n", topN.Int())
+	}
+	return out
+}
+
+// embeddingInputs returns the OpenAI embeddings "input" field as a list of strings.
+func embeddingInputs(payload []byte) []string {
+	input := gjson.GetBytes(payload, "input")
+	if !input.IsArray() {
+		return []string{input.String()}
+	}
+	out := make([]string, 0, len(input.Array()))
+	input.ForEach(func(_, item gjson.Result) bool {
+		out = append(out, item.String())
+		return true
+	})
+	return out
+}
+
+// buildOpenAIEmbeddingsResponse renders vectors in the OpenAI embeddings response shape.
+func buildOpenAIEmbeddingsResponse(vectors gjson.Result, model string, promptTokens int64) []byte {
+	out := []byte(`{"object":"list","data":[]}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	vectors.ForEach(func(key, vector gjson.Result) bool {
+		item := []byte(`{"object":"embedding"}`)
+		item, _ = sjson.SetBytes(item, "index", key.Int())
+		item, _ = sjson.SetRawBytes(item, "embedding", []byte(vector.Raw))
+		out, _ = sjson.SetRawBytes(out, "data.-1", item)
+		return true
+	})
+	out, _ = sjson.SetBytes(out, "usage.prompt_tokens", promptTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", promptTokens)
+	return out
+}
+
+// convertCohereEmbedResponseToOpenAI maps a Cohere v2 embed response onto the OpenAI shape.
+func convertCohereEmbedResponseToOpenAI(body []byte, model string) []byte {
+	root := gjson.ParseBytes(body)
+	return buildOpenAIEmbeddingsResponse(root.Get("embeddings.float"), model, root.Get("meta.billed_units.input_tokens").Int())
+}
+
+// convertOllamaEmbedResponseToOpenAI maps an Ollama /api/embed response onto the OpenAI shape.
+func convertOllamaEmbedResponseToOpenAI(body []byte, model string) []byte {
+	root := gjson.ParseBytes(body)
+	return buildOpenAIEmbeddingsResponse(root.Get("embeddings"), model, root.Get("prompt_eval_count").Int())
+}
+
+// buildGeminiEmbedPayload builds a batchEmbedContents request embedding each input with
+// model. OpenAI's dimensions maps to outputDimensionality.
+func buildGeminiEmbedPayload(payload []byte, model string) []byte {
+	out := []byte(`{"requests":[]}`)
+	dimensions := gjson.GetBytes(payload, "dimensions")
+	for _, text := range embeddingInputs(payload) {
+		item := []byte(`{}`)
+		item, _ = sjson.SetBytes(item, "model", "models/"+model)
+		item, _ = sjson.SetBytes(item, "content.parts.0.text", text)
+		if dimensions.Exists() && dimensions.Int() > 0 {
+			item, _ = sjson.SetBytes(item, "outputDimensionality", dimensions.Int())
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", item)
+	}
+	return out
+}
+
+// convertGeminiEmbedResponseToOpenAI maps a Gemini batchEmbedContents response onto the
+// OpenAI shape. Gemini reports no token usage for embeddings.
+func convertGeminiEmbedResponseToOpenAI(body []byte, model string) []byte {
+	return buildOpenAIEmbeddingsResponse(gjson.GetBytes(body, "embeddings.#.values"), model, 0)
+}
@@ -0,0 +1,32 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildGeminiEmbedPayload(t *testing.T) {
+	out := buildGeminiEmbedPayload([]byte(`{"input":["a","b"],"dimensions":256}`), "gemini-embedding-001")
+	requests := gjson.GetBytes(out, "requests").Array()
+	if len(requests) != 2 {
+		t.Fatalf("expected one request per input: %s", out)
+	}
+	if requests[1].Get("model").String() != "models/gemini-embedding-001" || requests[1].Get("content.parts.0.text").String() != "b" {
+		t.Fatalf("second request = %s", requests[1].Raw)
+	}
+	if requests[0].Get("outputDimensionality").Int() != 256 {
+		t.Fatalf("dimensions not mapped: %s", requests[0].Raw)
+	}
+}
+
+func TestConvertGeminiEmbedResponseToOpenAI(t *testing.T) {
+	out := convertGeminiEmbedResponseToOpenAI([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3]}]}`), "gemini-embedding-001")
+	data := gjson.GetBytes(out, "data").Array()
+	if len(data) != 2 || data[1].Get("index").Int() != 1 || data[1].Get("embedding.0").Float() != 0.3 {
+		t.Fatalf("unexpected response: %s", out)
+	}
+	if gjson.GetBytes(out, "model").String() != "gemini-embedding-001" {
+		t.Fatalf("model not set: %s", out)
+	}
+}
@@ -0,0 +1,217 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+// newStreamChannel creates the chunk channel of a streaming call, buffered as the
+// caller's stream tuning asks.
+func newStreamChannel(opts cliproxyexecutor.Options) chan cliproxyexecutor.StreamChunk {
+	return make(chan cliproxyexecutor.StreamChunk, opts.StreamTuning.ChannelBuffer())
+}
+
+// streamTranslator translates the events of one upstream stream to the source format, or
+// passes them through untouched when the caller asked for raw pass-through. The bare
+// "[DONE]" markers executors feed translators to flush them are not upstream events and
+// are dropped then.
+type streamTranslator struct {
+	raw        bool
+	translator *sdktranslator.StreamTranslator
+}
+
+// newStreamTranslator returns the translator for one upstream stream in format to, sent to
+// a client of format from.
+func newStreamTranslator(ctx context.Context, opts cliproxyexecutor.Options, to, from sdktranslator.Format, model string, originalRequest, translatedRequest []byte) *streamTranslator {
+	return &streamTranslator{
+		raw:        opts.StreamTuning.RawPassthrough,
+		translator: sdktranslator.NewStreamTranslator(ctx, to, from, model, originalRequest, translatedRequest),
+	}
+}
+
+// Translate translates the next upstream stream event.
+func (t *streamTranslator) Translate(event []byte) []string {
+	if t.raw {
+		if bytes.Equal(event, []byte("[DONE]")) {
+			return nil
+		}
+		return []string{string(event)}
+	}
+	return t.translator.Translate(event)
+}
+
+// Flush feeds the translator the "[DONE]" marker that ends the stream.
+func (t *streamTranslator) Flush() []string {
+	return t.Translate([]byte("[DONE]"))
+}
+
+// applyStreamUsageOption sets stream_options.include_usage on an OpenAI chat request
+// when the caller's stream tuning decides it; otherwise body is returned unchanged.
+func applyStreamUsageOption(body []byte, opts cliproxyexecutor.Options) []byte {
+	if opts.StreamTuning.IncludeUsage == nil {
+		return body
+	}
+	if *opts.StreamTuning.IncludeUsage {
+		body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
+		return body
+	}
+	body, _ = sjson.DeleteBytes(body, "stream_options.include_usage")
+	return body
+}
+
+// streamLineLimit resolves the maximum upstream stream line size for provider: the
+// caller's stream tuning, then the provider override, then the global streaming setting,
+// then streamScannerBuffer.
+func streamLineLimit(cfg *config.Config, provider string, opts cliproxyexecutor.Options) int {
+	limit := streamScannerBuffer
+	if cfg != nil {
+		if cfg.Streaming.MaxLineBytes > 0 {
+			limit = cfg.Streaming.MaxLineBytes
+		}
+		if perProvider := cfg.Streaming.MaxLineBytesPerProvider[provider]; perProvider > 0 {
+			limit = perProvider
+		}
+	}
+	return opts.StreamTuning.ChunkLimit(limit)
+}
+
+// streamScannerReadSize is the read buffer of a streamScanner; longer lines are read in
+// pieces of this size.
+const streamScannerReadSize = 64 * 1024
+
+// streamScanner splits an upstream stream into lines like a bufio.Scanner with
+// bufio.ScanLines, but a line over the limit does not end the stream with
+// bufio.ErrTooLong: it is read through in chunks, dropped with a warning, and scanning
+// resumes at the next line.
+type streamScanner struct {
+	reader   *bufio.Reader
+	provider string
+	limit    int
+	line     []byte
+	err      error
+}
+
+// newStreamScanner returns a line scanner over body for provider, with lines capped at limit bytes.
+func newStreamScanner(body io.Reader, provider string, limit int) *streamScanner {
+	return &streamScanner{reader: bufio.NewReaderSize(body, streamScannerReadSize), provider: provider, limit: limit}
+}
+
+// Scan advances to the next line, which is then available through Bytes. It returns
+// false at the end of the stream or on a read error, reported by Err.
+func (s *streamScanner) Scan() bool {
+	for s.err == nil {
+		s.line = s.line[:0]
+		size, oversized := 0, false
+		for {
+			chunk, err := s.reader.ReadSlice('\n')
+			size += len(chunk)
+			if size > s.limit {
+				oversized = true
+			} else {
+				s.line = append(s.line, chunk...)
+			}
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					s.err = err
+					return false
+				}
+				s.err = io.EOF
+				if size == 0 {
+					return false
+				}
+			}
+			break
+		}
+		if oversized {
+			log.Warnf("%s executor: skipped a %d byte stream line over the %d byte limit", s.provider, size, s.limit)
+			continue
+		}
+		s.line = bytes.TrimSuffix(bytes.TrimSuffix(s.line, []byte("\n")), []byte("\r"))
+		return true
+	}
+	return false
+}
+
+// Bytes returns the current line without its line ending. The slice is reused by the
+// next call to Scan.
+func (s *streamScanner) Bytes() []byte { return s.line }
+
+// Err returns the first read error other than io.EOF.
+func (s *streamScanner) Err() error {
+	if errors.Is(s.err, io.EOF) {
+		return nil
+	}
+	return s.err
+}
+
+// streamBytePassthrough reports whether provider's streams are configured to be
+// forwarded byte for byte.
+func streamBytePassthrough(cfg *config.Config, provider string) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, name := range cfg.Streaming.BytePassthroughProviders {
+		if strings.EqualFold(strings.TrimSpace(name), provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardStreamBytes copies body to out as it arrives, without splitting or rejoining
+// lines, so the client receives the exact upstream framing. Every complete line is also
+// handed to observe, for usage accounting; lines over limit are not observed. It returns
+// the first read error other than io.EOF.
+func forwardStreamBytes(ctx context.Context, cfg *config.Config, body io.Reader, out chan<- cliproxyexecutor.StreamChunk, limit int, observe func(line []byte)) error {
+	buf := make([]byte, streamScannerReadSize)
+	var pending []byte
+	skipping := false
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			chunk := bytes.Clone(buf[:n])
+			appendAPIResponseChunk(ctx, cfg, chunk)
+			out <- cliproxyexecutor.StreamChunk{Payload: chunk}
+			for data := chunk; len(data) > 0; {
+				idx := bytes.IndexByte(data, '\n')
+				if idx < 0 {
+					if !skipping {
+						pending = append(pending, data...)
+						if len(pending) > limit {
+							pending, skipping = pending[:0], true
+						}
+					}
+					break
+				}
+				if !skipping && len(pending)+idx <= limit {
+					pending = append(pending, data[:idx]...)
+					observe(bytes.TrimSuffix(pending, []byte("\r")))
+				}
+				pending, skipping = pending[:0], false
+				data = data[idx+1:]
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if len(pending) > 0 && !skipping {
+					observe(pending)
+				}
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestStreamTranslator_RawPassthrough(t *testing.T) {
+	opts := cliproxyexecutor.Options{StreamTuning: cliproxyexecutor.StreamOptions{RawPassthrough: true}}
+	stream := newStreamTranslator(context.Background(), opts, sdktranslator.FromString("openai"), sdktranslator.FromString("claude"), "m", nil, nil)
+	event := []byte(`data: {"choices":[]}`)
+	got := stream.Translate(event)
+	if len(got) != 1 || got[0] != string(event) {
+		t.Fatalf("raw event must pass through, got %q", got)
+	}
+	if got = stream.Flush(); got != nil {
+		t.Fatalf("synthetic [DONE] must be dropped, got %q", got)
+	}
+}
+
+func TestApplyStreamUsageOption(t *testing.T) {
+	body := []byte(`{"stream":true,"stream_options":{"include_usage":true}}`)
+	if out := applyStreamUsageOption(body, cliproxyexecutor.Options{}); string(out) != string(body) {
+		t.Fatalf("unset option must keep the body, got %s", out)
+	}
+	off := false
+	out := applyStreamUsageOption(body, cliproxyexecutor.Options{StreamTuning: cliproxyexecutor.StreamOptions{IncludeUsage: &off}})
+	if gjson.GetBytes(out, "stream_options.include_usage").Exists() {
+		t.Fatalf("include_usage must be removed: %s", out)
+	}
+	on := true
+	out = applyStreamUsageOption([]byte(`{}`), cliproxyexecutor.Options{StreamTuning: cliproxyexecutor.StreamOptions{IncludeUsage: &on}})
+	if !gjson.GetBytes(out, "stream_options.include_usage").Bool() {
+		t.Fatalf("include_usage must be set: %s", out)
+	}
+}
+
+func TestNewStreamChannel_Buffer(t *testing.T) {
+	if c := newStreamChannel(cliproxyexecutor.Options{}); cap(c) != 0 {
+		t.Fatalf("default channel must be unbuffered, cap %d", cap(c))
+	}
+	if c := newStreamChannel(cliproxyexecutor.Options{StreamTuning: cliproxyexecutor.StreamOptions{BufferSize: 8}}); cap(c) != 8 {
+		t.Fatalf("cap = %d, want 8", cap(c))
+	}
+}
+
+func TestStreamScanner_SkipsOversizedLines(t *testing.T) {
+	body := "data: one\r\n" + "data: " + strings.Repeat("x", 200) + "\n\ndata: two"
+	scanner := newStreamScanner(strings.NewReader(body), "test", 64)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, string(scanner.Bytes()))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	want := []string{"data: one", "", "data: two"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestStreamLineLimit(t *testing.T) {
+	cfg := &config.Config{}
+	if got := streamLineLimit(cfg, "gemini", cliproxyexecutor.Options{}); got != streamScannerBuffer {
+		t.Fatalf("default = %d", got)
+	}
+	cfg.Streaming.MaxLineBytes = 1024
+	cfg.Streaming.MaxLineBytesPerProvider = map[string]int{"gemini": 2048}
+	if got := streamLineLimit(cfg, "claude", cliproxyexecutor.Options{}); got != 1024 {
+		t.Fatalf("global = %d", got)
+	}
+	if got := streamLineLimit(cfg, "gemini", cliproxyexecutor.Options{}); got != 2048 {
+		t.Fatalf("per provider = %d", got)
+	}
+	opts := cliproxyexecutor.Options{StreamTuning: cliproxyexecutor.StreamOptions{MaxChunkSize: 512}}
+	if got := streamLineLimit(cfg, "gemini", opts); got != 512 {
+		t.Fatalf("stream tuning = %d", got)
+	}
+}
+
+func TestForwardStreamBytes_PreservesFraming(t *testing.T) {
+	body := "event: message_start\r\ndata: {\"type\":\"message_start\"}\r\n\r\n: comment\n" + strings.Repeat("y", 100) + "\ndata: tail"
+	out := make(chan cliproxyexecutor.StreamChunk, len(body))
+	var observed []string
+	err := forwardStreamBytes(context.Background(), nil, iotest.OneByteReader(strings.NewReader(body)), out, 64, func(line []byte) {
+		observed = append(observed, string(line))
+	})
+	close(out)
+	if err != nil {
+		t.Fatalf("forwardStreamBytes: %v", err)
+	}
+	var forwarded strings.Builder
+	for chunk := range out {
+		forwarded.Write(chunk.Payload)
+	}
+	if forwarded.String() != body {
+		t.Fatalf("forwarded bytes differ:\n%q\n%q", forwarded.String(), body)
+	}
+	want := []string{"event: message_start", `data: {"type":"message_start"}`, "", ": comment", "data: tail"}
+	if strings.Join(observed, "|") != strings.Join(want, "|") {
+		t.Fatalf("observed = %q, want %q", observed, want)
+	}
+}
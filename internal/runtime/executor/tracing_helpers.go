@@ -0,0 +1,20 @@
+package executor
+
+import (
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// withUpstreamTracing wraps the client transport so each upstream call gets a client span
+// under the executor attempt, carrying the trace context to providers listed in
+// tracing.propagate-to.
+func withUpstreamTracing(client *http.Client, auth *cliproxyauth.Auth) *http.Client {
+	if client == nil || auth == nil {
+		return client
+	}
+	wrapped := *client
+	wrapped.Transport = tracing.Transport(client.Transport, auth.Provider)
+	return &wrapped
+}
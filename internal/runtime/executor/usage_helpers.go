@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/tidwall/gjson"
@@ -22,6 +24,7 @@ type usageReporter struct {
 	authIndex   string
 	apiKey      string
 	source      string
+	seed        *int64
 	requestedAt time.Time
 	once        sync.Once
 }
@@ -34,6 +37,7 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		requestedAt: time.Now(),
 		apiKey:      apiKey,
 		source:      resolveUsageSource(auth, apiKey),
+		seed:        seedFromContext(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -83,6 +87,9 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 			RequestedAt: r.requestedAt,
 			Failed:      failed,
 			Detail:      detail,
+			Seed:        r.seed,
+			RequestID:   logging.GetRequestID(ctx),
+			Latency:     time.Since(r.requestedAt),
 		})
 	})
 }
@@ -106,6 +113,9 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 			RequestedAt: r.requestedAt,
 			Failed:      false,
 			Detail:      usage.Detail{},
+			Seed:        r.seed,
+			RequestID:   logging.GetRequestID(ctx),
+			Latency:     time.Since(r.requestedAt),
 		})
 	})
 }
@@ -131,6 +141,23 @@ func apiKeyFromContext(ctx context.Context) string {
 	return ""
 }
 
+// seedFromContext returns the sampling seed the handler stored for the request.
+func seedFromContext(ctx context.Context) *int64 {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	if v, exists := ginCtx.Get(util.RequestSeedContextKey); exists {
+		if seed, okSeed := v.(int64); okSeed {
+			return &seed
+		}
+	}
+	return nil
+}
+
 func resolveUsageSource(auth *cliproxyauth.Auth, ctxAPIKey string) string {
 	if auth != nil {
 		provider := strings.TrimSpace(auth.Provider)
@@ -236,6 +263,33 @@ func parseOpenAIStreamUsage(line []byte) (usage.Detail, bool) {
 	return detail, true
 }
 
+// parseCohereUsage reads token counts from a Cohere v2 usage object, preferring model
+// token counts over billed units.
+func parseCohereUsage(usageNode gjson.Result) usage.Detail {
+	tokens := usageNode.Get("tokens")
+	if !tokens.Exists() {
+		tokens = usageNode.Get("billed_units")
+	}
+	detail := usage.Detail{
+		InputTokens:  tokens.Get("input_tokens").Int(),
+		OutputTokens: tokens.Get("output_tokens").Int(),
+	}
+	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	return detail
+}
+
+func parseCohereStreamUsage(line []byte) (usage.Detail, bool) {
+	payload := jsonPayload(line)
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return usage.Detail{}, false
+	}
+	usageNode := gjson.GetBytes(payload, "delta.usage")
+	if !usageNode.Exists() {
+		return usage.Detail{}, false
+	}
+	return parseCohereUsage(usageNode), true
+}
+
 func parseClaudeUsage(data []byte) usage.Detail {
 	usageNode := gjson.ParseBytes(data).Get("usage")
 	if !usageNode.Exists() {
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// prepareXAIPayload adapts an OpenAI chat request to xAI's reasoning and image rules.
+func prepareXAIPayload(payload []byte, model string) []byte {
+	return normalizeXAIImageParts(normalizeXAIReasoningEffort(payload, model))
+}
+
+// normalizeXAIReasoningEffort keeps reasoning_effort only for models that accept it,
+// mapping OpenAI levels onto the model's supported set. xAI rejects the field on models
+// that always reason (grok-4) or never do.
+func normalizeXAIReasoningEffort(payload []byte, model string) []byte {
+	effort := gjson.GetBytes(payload, "reasoning_effort")
+	if !effort.Exists() {
+		return payload
+	}
+	levels := util.GetModelThinkingLevels(model)
+	if len(levels) == 0 {
+		if info := registry.LookupStaticModelInfo(model); info != nil && info.Thinking != nil {
+			levels = info.Thinking.Levels
+		}
+	}
+	if len(levels) == 0 {
+		payload, _ = sjson.DeleteBytes(payload, "reasoning_effort")
+		return payload
+	}
+	level := strings.ToLower(strings.TrimSpace(effort.String()))
+	candidates := []string{level}
+	switch level {
+	case "minimal":
+		candidates = append(candidates, "low")
+	case "medium", "xhigh":
+		candidates = append(candidates, "high")
+	}
+	for _, candidate := range candidates {
+		for _, supported := range levels {
+			if strings.EqualFold(supported, candidate) {
+				payload, _ = sjson.SetBytes(payload, "reasoning_effort", supported)
+				return payload
+			}
+		}
+	}
+	payload, _ = sjson.DeleteBytes(payload, "reasoning_effort")
+	return payload
+}
+
+// normalizeXAIImageParts rewrites image_url content parts into the object form xAI
+// requires and drops detail values it does not support.
+func normalizeXAIImageParts(payload []byte) []byte {
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return payload
+	}
+	for i, msg := range messages.Array() {
+		content := msg.Get("content")
+		if !content.IsArray() {
+			continue
+		}
+		for j, part := range content.Array() {
+			if part.Get("type").String() != "image_url" {
+				continue
+			}
+			path := fmt.Sprintf("messages.%d.content.%d.image_url", i, j)
+			image := part.Get("image_url")
+			if image.Type == gjson.String {
+				payload, _ = sjson.SetBytes(payload, path, map[string]string{"url": image.String()})
+				continue
+			}
+			if detail := image.Get("detail"); detail.Exists() {
+				switch strings.ToLower(detail.String()) {
+				case "auto", "low", "high":
+				default:
+					payload, _ = sjson.DeleteBytes(payload, path+".detail")
+				}
+			}
+		}
+	}
+	return payload
+}
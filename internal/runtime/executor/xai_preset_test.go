@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeXAIReasoningEffort(t *testing.T) {
+	cases := []struct {
+		model  string
+		effort string
+		want   string
+	}{
+		{model: "grok-3-mini", effort: "high", want: "high"},
+		{model: "grok-3-mini", effort: "medium", want: "high"},
+		{model: "grok-3-mini", effort: "minimal", want: "low"},
+		{model: "grok-4", effort: "high", want: ""},
+	}
+	for _, tc := range cases {
+		out := normalizeXAIReasoningEffort([]byte(`{"reasoning_effort":"`+tc.effort+`"}`), tc.model)
+		if got := gjson.GetBytes(out, "reasoning_effort").String(); got != tc.want {
+			t.Errorf("%s/%s: reasoning_effort = %q, want %q", tc.model, tc.effort, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeXAIImageParts(t *testing.T) {
+	input := []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"what is this"},
+		{"type":"image_url","image_url":"https://example.com/a.png"},
+		{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,QUJD","detail":"original"}}]}]}`)
+	out := normalizeXAIImageParts(input)
+
+	if got := gjson.GetBytes(out, "messages.0.content.1.image_url.url").String(); got != "https://example.com/a.png" {
+		t.Fatalf("string image_url not converted: %s", out)
+	}
+	if gjson.GetBytes(out, "messages.0.content.2.image_url.detail").Exists() {
+		t.Fatalf("unsupported detail must be dropped: %s", out)
+	}
+	if got := gjson.GetBytes(out, "messages.0.content.2.image_url.url").String(); got != "data:image/jpeg;base64,QUJD" {
+		t.Fatalf("image url = %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// zhipuTokenTTL is the lifetime of a signed Zhipu API token.
+const zhipuTokenTTL = 30 * time.Minute
+
+// zhipuMaxTemperature is the upper bound of Zhipu's temperature range.
+const zhipuMaxTemperature = 1.0
+
+// zhipuAuthorization signs a Zhipu "id.secret" API key into the short-lived HS256 token
+// open.bigmodel.cn expects as the bearer credential. Keys without a secret part are sent
+// as is.
+func zhipuAuthorization(apiKey string) string {
+	id, secret, ok := strings.Cut(apiKey, ".")
+	if !ok || id == "" || secret == "" {
+		return apiKey
+	}
+	return signZhipuToken(id, secret, time.Now())
+}
+
+// signZhipuToken builds the JWT for key id, signed with secret. Zhipu takes millisecond
+// timestamps and a non-standard sign_type header.
+func signZhipuToken(id, secret string, now time.Time) string {
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "sign_type": "SIGN"})
+	claims, _ := json.Marshal(map[string]any{
+		"api_key":   id,
+		"exp":       now.Add(zhipuTokenTTL).UnixMilli(),
+		"timestamp": now.UnixMilli(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// prepareZhipuPayload adapts an OpenAI chat request to GLM's thinking switch and
+// temperature range.
+func prepareZhipuPayload(payload []byte, model string) []byte {
+	if temp := gjson.GetBytes(payload, "temperature"); temp.Exists() && temp.Float() > zhipuMaxTemperature {
+		payload, _ = sjson.SetBytes(payload, "temperature", zhipuMaxTemperature)
+	}
+	effort := gjson.GetBytes(payload, "reasoning_effort")
+	if !effort.Exists() {
+		return payload
+	}
+	payload, _ = sjson.DeleteBytes(payload, "reasoning_effort")
+	if !zhipuHybridReasoning(model) || gjson.GetBytes(payload, "thinking").Exists() {
+		return payload
+	}
+	mode := "enabled"
+	switch strings.ToLower(strings.TrimSpace(effort.String())) {
+	case "none", "minimal":
+		mode = "disabled"
+	}
+	payload, _ = sjson.SetBytes(payload, "thinking.type", mode)
+	return payload
+}
+
+// zhipuHybridReasoning reports whether model toggles reasoning through the thinking
+// object (GLM-4.5 and later); older glm-4 models reject it.
+func zhipuHybridReasoning(model string) bool {
+	model = strings.ToLower(model)
+	return strings.HasPrefix(model, "glm-4.5") || strings.HasPrefix(model, "glm-4.6")
+}
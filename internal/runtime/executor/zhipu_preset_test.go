@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestSignZhipuToken(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	token := signZhipuToken("key-id", "secret", now)
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token must have three parts: %q", token)
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	if gjson.GetBytes(header, "sign_type").String() != "SIGN" || gjson.GetBytes(header, "alg").String() != "HS256" {
+		t.Fatalf("header = %s", header)
+	}
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if gjson.GetBytes(claims, "api_key").String() != "key-id" || gjson.GetBytes(claims, "timestamp").Int() != now.UnixMilli() {
+		t.Fatalf("claims = %s", claims)
+	}
+	if exp := gjson.GetBytes(claims, "exp").Int(); exp != now.Add(zhipuTokenTTL).UnixMilli() {
+		t.Fatalf("exp = %d", exp)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if parts[2] != base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Fatal("signature mismatch")
+	}
+
+	if got := zhipuAuthorization("plain-key"); got != "plain-key" {
+		t.Fatalf("keys without a secret must pass through, got %q", got)
+	}
+}
+
+func TestPrepareZhipuPayload(t *testing.T) {
+	out := prepareZhipuPayload([]byte(`{"temperature":1.5,"reasoning_effort":"none"}`), "glm-4.5-air")
+	if gjson.GetBytes(out, "reasoning_effort").Exists() || gjson.GetBytes(out, "thinking.type").String() != "disabled" {
+		t.Fatalf("reasoning_effort must become the thinking switch: %s", out)
+	}
+	if gjson.GetBytes(out, "temperature").Float() != zhipuMaxTemperature {
+		t.Fatalf("temperature must be clamped: %s", out)
+	}
+
+	out = prepareZhipuPayload([]byte(`{"reasoning_effort":"high"}`), "glm-4-plus")
+	if gjson.GetBytes(out, "reasoning_effort").Exists() || gjson.GetBytes(out, "thinking").Exists() {
+		t.Fatalf("glm-4 models take no thinking switch: %s", out)
+	}
+}
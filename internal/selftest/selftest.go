@@ -0,0 +1,249 @@
+// Package selftest runs canned requests end to end through an auth manager and the
+// translation stack against an in-process mock provider. No request leaves the process,
+// so the result tells whether this build can route and translate, independent of any
+// upstream credential.
+package selftest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// mockProvider is the provider key of the mock executor. It is only registered with the
+// private manager of a run, never with the live one.
+const mockProvider = "selftest"
+
+// expectedText is the assistant text of every canned provider response.
+const expectedText = "selftest ok"
+
+// caseTimeout bounds a single case, so a stuck translator cannot hang the endpoint.
+const caseTimeout = 5 * time.Second
+
+// CaseResult is the outcome of one canned request.
+type CaseResult struct {
+	Name       string  `json:"name"`
+	Passed     bool    `json:"passed"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of a self-test run.
+type Report struct {
+	Passed     bool         `json:"passed"`
+	DurationMS float64      `json:"duration_ms"`
+	Cases      []CaseResult `json:"cases"`
+}
+
+type testCase struct {
+	name    string
+	format  string
+	stream  bool
+	request string
+	// check validates the client-format output, joined into one buffer for streams.
+	check func(out []byte) error
+}
+
+var cases = []testCase{
+	{
+		name:    "openai-chat",
+		format:  "openai",
+		request: `{"model":"selftest","messages":[{"role":"user","content":"ping"}]}`,
+		check:   expectPath("choices.0.message.content"),
+	},
+	{
+		name:    "openai-chat-stream",
+		format:  "openai",
+		stream:  true,
+		request: `{"model":"selftest","stream":true,"messages":[{"role":"user","content":"ping"}]}`,
+		check:   expectContains("chat.completion.chunk"),
+	},
+	{
+		name:    "openai-responses-stream",
+		format:  "openai-response",
+		stream:  true,
+		request: `{"model":"selftest","stream":true,"input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"ping"}]}]}`,
+		check:   expectContains("response.completed"),
+	},
+	{
+		name:    "gemini",
+		format:  "gemini",
+		request: `{"contents":[{"role":"user","parts":[{"text":"ping"}]}]}`,
+		check:   expectPath("candidates.0.content.parts.0.text"),
+	},
+}
+
+// Run executes every case and reports pass/fail with timings.
+func Run(ctx context.Context) Report {
+	start := time.Now()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(mockExecutor{})
+	if _, err := manager.Register(ctx, &coreauth.Auth{ID: "selftest", Provider: mockProvider, Status: coreauth.StatusActive}); err != nil {
+		return Report{Cases: []CaseResult{{Name: "setup", Error: err.Error()}}}
+	}
+
+	report := Report{Passed: true, Cases: make([]CaseResult, 0, len(cases))}
+	for _, tc := range cases {
+		caseStart := time.Now()
+		err := runCase(ctx, manager, tc)
+		result := CaseResult{Name: tc.name, Passed: err == nil, DurationMS: milliseconds(time.Since(caseStart))}
+		if err != nil {
+			result.Error = err.Error()
+			report.Passed = false
+		}
+		report.Cases = append(report.Cases, result)
+	}
+	report.DurationMS = milliseconds(time.Since(start))
+	return report
+}
+
+func runCase(ctx context.Context, manager *coreauth.Manager, tc testCase) error {
+	ctx, cancel := context.WithTimeout(ctx, caseTimeout)
+	defer cancel()
+	req := cliproxyexecutor.Request{Payload: []byte(tc.request), Format: sdktranslator.FromString(tc.format)}
+	opts := cliproxyexecutor.Options{Stream: tc.stream, OriginalRequest: []byte(tc.request), SourceFormat: req.Format}
+	if !tc.stream {
+		resp, err := manager.Execute(ctx, []string{mockProvider}, req, opts)
+		if err != nil {
+			return err
+		}
+		return tc.check(resp.Payload)
+	}
+	chunks, err := manager.ExecuteStream(ctx, []string{mockProvider}, req, opts)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return chunk.Err
+		}
+		out.Write(chunk.Payload)
+		out.WriteByte('\n')
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return tc.check(out.Bytes())
+}
+
+// expectPath checks that a non-streaming response carries the canned text at path.
+func expectPath(path string) func([]byte) error {
+	return func(out []byte) error {
+		if got := gjson.GetBytes(out, path).String(); got != expectedText {
+			return fmt.Errorf("%s = %q, want %q", path, got, expectedText)
+		}
+		return nil
+	}
+}
+
+// expectContains checks that a stream carries the canned text and the given marker.
+func expectContains(marker string) func([]byte) error {
+	return func(out []byte) error {
+		if !bytes.Contains(out, []byte(expectedText)) {
+			return fmt.Errorf("stream is missing the response text")
+		}
+		if !bytes.Contains(out, []byte(marker)) {
+			return fmt.Errorf("stream is missing %q", marker)
+		}
+		return nil
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// mockStream is the canned Claude Messages API answer. Like the Claude executor, the mock
+// streams from upstream for every non-Claude client and translates the whole stream for
+// non-streaming requests.
+const mockStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_selftest","type":"message","role":"assistant","model":"selftest","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":5,"output_tokens":0}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"selftest ok"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":2}}
+
+event: message_stop
+data: {"type":"message_stop"}
+`
+
+// mockExecutor speaks the Claude Messages format and translates like the Claude executor:
+// the client request into Claude, the canned Claude answer back into the client format.
+type mockExecutor struct{}
+
+func (mockExecutor) Identifier() string { return mockProvider }
+
+func (mockExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from, to := opts.SourceFormat, sdktranslator.FromString("claude")
+	body, err := translateRequest(from, to, req, true)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, "selftest", opts.OriginalRequest, body, []byte(mockStream), &param)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+func (mockExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	from, to := opts.SourceFormat, sdktranslator.FromString("claude")
+	body, err := translateRequest(from, to, req, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		stream := sdktranslator.NewStreamTranslator(ctx, to, from, "selftest", opts.OriginalRequest, body)
+		scanner := bufio.NewScanner(strings.NewReader(mockStream))
+		for scanner.Scan() {
+			chunks := stream.Translate(bytes.Clone(scanner.Bytes()))
+			for _, chunk := range chunks {
+				select {
+				case out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// translateRequest translates the client request and checks it is a usable Claude request.
+func translateRequest(from, to sdktranslator.Format, req cliproxyexecutor.Request, stream bool) ([]byte, error) {
+	body := sdktranslator.TranslateRequest(from, to, "selftest", bytes.Clone(req.Payload), stream)
+	if len(gjson.GetBytes(body, "messages").Array()) == 0 {
+		return nil, fmt.Errorf("translating %s to %s produced no messages", from, to)
+	}
+	return body, nil
+}
+
+func (mockExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (mockExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, fmt.Errorf("selftest: token counting is not supported")
+}
+
+func (mockExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("selftest: the mock provider makes no HTTP requests")
+}
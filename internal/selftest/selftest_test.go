@@ -0,0 +1,20 @@
+package selftest
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+)
+
+func TestRun(t *testing.T) {
+	report := Run(context.Background())
+	for _, result := range report.Cases {
+		if !result.Passed {
+			t.Errorf("%s: %s", result.Name, result.Error)
+		}
+	}
+	if !report.Passed || len(report.Cases) != len(cases) {
+		t.Fatalf("report = %+v", report)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/transport"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 // GitTokenStore persists token records and auth metadata using git as the backing storage.
@@ -313,6 +314,7 @@ func (s *GitTokenStore) List(_ context.Context) ([]*cliproxyauth.Auth, error) {
 		}
 		auth, err := s.readAuthFile(path, dir)
 		if err != nil {
+			log.WithError(err).Warnf("git token store: skip auth %s", path)
 			return nil
 		}
 		if auth != nil {
@@ -417,6 +419,10 @@ func (s *GitTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 	if err = json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("unmarshal auth json: %w", err)
 	}
+	// Upgrades stay in memory and reach the remote store with the next save.
+	if _, err = cliproxyauth.LoadMetadata(metadata); err != nil {
+		return nil, err
+	}
 	provider, _ := metadata["type"].(string)
 	if provider == "" {
 		provider = "unknown"
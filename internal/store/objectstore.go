@@ -564,6 +564,10 @@ func (s *ObjectTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Aut
 	if err = json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("unmarshal auth json: %w", err)
 	}
+	// Upgrades stay in memory and reach the remote store with the next save.
+	if _, err = cliproxyauth.LoadMetadata(metadata); err != nil {
+		return nil, err
+	}
 	provider := strings.TrimSpace(valueAsString(metadata["type"]))
 	if provider == "" {
 		provider = "unknown"
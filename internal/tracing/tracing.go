@@ -0,0 +1,293 @@
+// Package tracing instruments the request pipeline with OpenTelemetry spans and exports
+// them over OTLP/HTTP. Apply installs the configured tracer provider and can be called
+// again on every configuration reload; while tracing is disabled the helpers are no-ops
+// that cost little more than a context lookup.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName identifies the spans of this module.
+const instrumentationName = "github.com/router-for-me/CLIProxyAPI/v6"
+
+// defaultServiceName is reported as service.name when the config sets none.
+const defaultServiceName = "cli-proxy-api"
+
+// shutdownTimeout bounds how long a replaced provider may take to flush its spans.
+const shutdownTimeout = 10 * time.Second
+
+// propagator carries W3C trace context and baggage across process boundaries.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+var (
+	mu       sync.Mutex
+	applied  config.TracingConfig
+	provider *sdktrace.TracerProvider
+
+	// enabled is set while a recording provider is installed.
+	enabled atomic.Bool
+	// tracer holds the tracerBox of the installed provider.
+	tracer atomic.Value
+	// propagateTo holds the lowercased providers upstream requests carry trace context to.
+	propagateTo atomic.Value
+)
+
+// tracerBox gives the tracers stored in tracer one concrete type, as atomic.Value requires.
+type tracerBox struct{ trace.Tracer }
+
+func init() {
+	tracer.Store(tracerBox{noop.NewTracerProvider().Tracer(instrumentationName)})
+	propagateTo.Store([]string(nil))
+}
+
+// Apply installs the tracer provider described by cfg, replacing and flushing the previous
+// one. It does nothing when cfg is unchanged.
+func Apply(cfg config.TracingConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if reflect.DeepEqual(cfg, applied) {
+		return nil
+	}
+
+	var next *sdktrace.TracerProvider
+	if cfg.Enabled {
+		var err error
+		if next, err = newProvider(cfg); err != nil {
+			return err
+		}
+	}
+
+	previous := provider
+	provider, applied = next, cfg
+	propagators := make([]string, 0, len(cfg.PropagateTo))
+	for _, name := range cfg.PropagateTo {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			propagators = append(propagators, name)
+		}
+	}
+	propagateTo.Store(propagators)
+	if next != nil {
+		tracer.Store(tracerBox{next.Tracer(instrumentationName)})
+		otel.SetTracerProvider(next)
+		otel.SetTextMapPropagator(propagator)
+		enabled.Store(true)
+		log.Infof("tracing: exporting spans over OTLP/HTTP to %s", describeEndpoint(cfg.Endpoint))
+	} else {
+		enabled.Store(false)
+		tracer.Store(tracerBox{noop.NewTracerProvider().Tracer(instrumentationName)})
+	}
+	if previous != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if err := previous.Shutdown(ctx); err != nil {
+				log.Warnf("tracing: flushing the previous exporter: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Shutdown flushes the pending spans and stops exporting.
+func Shutdown(ctx context.Context) error {
+	mu.Lock()
+	current := provider
+	provider, applied = nil, config.TracingConfig{}
+	enabled.Store(false)
+	tracer.Store(tracerBox{noop.NewTracerProvider().Tracer(instrumentationName)})
+	mu.Unlock()
+	if current == nil {
+		return nil
+	}
+	return current.Shutdown(ctx)
+}
+
+func newProvider(cfg config.TracingConfig) (*sdktrace.TracerProvider, error) {
+	var opts []otlptracehttp.Option
+	if endpoint := strings.TrimSpace(cfg.Endpoint); endpoint != "" {
+		if strings.Contains(endpoint, "://") {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		} else {
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+		}
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: create OTLP exporter: %w", err)
+	}
+
+	serviceName := strings.TrimSpace(cfg.ServiceName)
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	), nil
+}
+
+func describeEndpoint(endpoint string) string {
+	if endpoint = strings.TrimSpace(endpoint); endpoint == "" {
+		return "the OTEL_EXPORTER_OTLP_* endpoint (default localhost:4318)"
+	}
+	return endpoint
+}
+
+// Enabled reports whether spans are being recorded and exported.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracer.Load().(tracerBox).Start(ctx, name, opts...)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// WithSpanFrom returns ctx carrying the span of from, unless ctx already has one. Handlers
+// use it to parent work on a context detached from the inbound request.
+func WithSpanFrom(ctx, from context.Context) context.Context {
+	if ctx == nil || from == nil || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	if span := trace.SpanFromContext(from); span.SpanContext().IsValid() {
+		return trace.ContextWithSpan(ctx, span)
+	}
+	return ctx
+}
+
+// Middleware starts a server span for each request, continuing the trace of an incoming
+// traceparent header. Handlers find the span on the request context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled() {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+			))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	}
+}
+
+// Transport wraps base so each upstream request of provider gets a client span and, when
+// provider is listed in propagate-to, carries the trace context.
+func Transport(base http.RoundTripper, provider string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, provider: strings.ToLower(strings.TrimSpace(provider))}
+}
+
+type transport struct {
+	base     http.RoundTripper
+	provider string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Enabled() {
+		return t.base.RoundTrip(req)
+	}
+	ctx, span := Start(req.Context(), req.Method+" "+req.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+			attribute.String("cliproxy.provider", t.provider),
+		))
+	if t.propagates() {
+		req = req.Clone(ctx)
+		propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	} else {
+		req = req.WithContext(ctx)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	span.End()
+	return resp, nil
+}
+
+// propagates reports whether upstream requests of the provider carry trace context.
+func (t *transport) propagates() bool {
+	for _, name := range propagateTo.Load().([]string) {
+		if name == "*" || name == t.provider {
+			return true
+		}
+	}
+	return false
+}
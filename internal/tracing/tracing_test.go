@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// useRecorder records spans in memory in place of the OTLP exporter.
+func useRecorder(t *testing.T, propagate ...string) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer.Store(tracerBox{tp.Tracer(instrumentationName)})
+	propagateTo.Store(propagate)
+	enabled.Store(true)
+	t.Cleanup(func() {
+		enabled.Store(false)
+		propagateTo.Store([]string(nil))
+		tracer.Store(tracerBox{noop.NewTracerProvider().Tracer(instrumentationName)})
+	})
+	return recorder
+}
+
+func TestPipelineSpans(t *testing.T) {
+	recorder := useRecorder(t, "my-vllm")
+	var upstreamParents []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamParents = append(upstreamParents, r.Header.Get("traceparent"))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		for _, provider := range []string{"my-vllm", "claude"} {
+			client := &http.Client{Transport: Transport(nil, provider)}
+			req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, upstream.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Errorf("upstream call: %v", err)
+				continue
+			}
+			_ = resp.Body.Close()
+		}
+		c.Status(http.StatusOK)
+	})
+
+	const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("traceparent", incoming)
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want a server span and two client spans", len(spans))
+	}
+	server := spans[2]
+	if server.Name() != "POST /v1/chat/completions" || server.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("last span = %s (%s), want the server span", server.Name(), server.SpanKind())
+	}
+	if got := server.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("server span trace = %s, want the incoming trace", got)
+	}
+	for _, client := range spans[:2] {
+		if client.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Fatalf("client span %s is not a child of the server span", client.Name())
+		}
+	}
+
+	if len(upstreamParents) != 2 {
+		t.Fatalf("upstream saw %d requests, want 2", len(upstreamParents))
+	}
+	want := "00-" + server.SpanContext().TraceID().String() + "-" + spans[0].SpanContext().SpanID().String() + "-01"
+	if upstreamParents[0] != want {
+		t.Fatalf("traceparent sent to my-vllm = %q, want %q", upstreamParents[0], want)
+	}
+	if upstreamParents[1] != "" {
+		t.Fatalf("traceparent sent to claude = %q, want none", upstreamParents[1])
+	}
+}
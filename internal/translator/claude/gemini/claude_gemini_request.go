@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -228,10 +229,18 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 						return true
 					}
 
-					// Image content (inline_data) conversion to Claude Code format
-					if inlineData := part.Get("inline_data"); inlineData.Exists() {
+					// Image content (inline data) conversion to Claude Code format
+					inlineData := part.Get("inlineData")
+					if !inlineData.Exists() {
+						inlineData = part.Get("inline_data")
+					}
+					if inlineData.Exists() {
 						imageContent := `{"type":"image","source":{"type":"base64","media_type":"","data":""}}`
-						if mimeType := inlineData.Get("mime_type"); mimeType.Exists() {
+						mimeType := inlineData.Get("mimeType")
+						if !mimeType.Exists() {
+							mimeType = inlineData.Get("mime_type")
+						}
+						if mimeType.Exists() {
 							imageContent, _ = sjson.Set(imageContent, "source.media_type", mimeType.String())
 						}
 						if data := inlineData.Get("data"); data.Exists() {
@@ -241,13 +250,37 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 						return true
 					}
 
-					// File data conversion to text content with file info
-					if fileData := part.Get("file_data"); fileData.Exists() {
-						// For file data, we'll convert to text content with file info
+					fileData := part.Get("fileData")
+					if !fileData.Exists() {
+						fileData = part.Get("file_data")
+					}
+					if fileData.Exists() {
+						fileURI := fileData.Get("fileUri").String()
+						if fileURI == "" {
+							fileURI = fileData.Get("file_uri").String()
+						}
+						mimeType := fileData.Get("mimeType").String()
+						if mimeType == "" {
+							mimeType = fileData.Get("mime_type").String()
+						}
+
+						// Remote images become url image sources Claude fetches itself.
+						isImage := strings.HasPrefix(mimeType, "image/")
+						if mimeType == "" {
+							isImage = strings.HasPrefix(misc.MimeTypeFromURL(fileURI, ""), "image/")
+						}
+						if isImage && misc.IsRemoteURL(fileURI) {
+							imageContent := `{"type":"image","source":{"type":"url","url":""}}`
+							imageContent, _ = sjson.Set(imageContent, "source.url", fileURI)
+							msg, _ = sjson.SetRaw(msg, "content.-1", imageContent)
+							return true
+						}
+
+						// Other file data is converted to text content with file info
 						textContent := `{"type":"text","text":""}`
-						fileInfo := "File: " + fileData.Get("file_uri").String()
-						if mimeType := fileData.Get("mime_type"); mimeType.Exists() {
-							fileInfo += " (Type: " + mimeType.String() + ")"
+						fileInfo := "File: " + fileURI
+						if mimeType != "" {
+							fileInfo += " (Type: " + mimeType + ")"
 						}
 						textContent, _ = sjson.Set(textContent, "text", fileInfo)
 						msg, _ = sjson.SetRaw(msg, "content.-1", textContent)
@@ -15,11 +15,16 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
+// structuredOutputToolName is the tool an OpenAI json_schema response_format is sent to
+// Claude as.
+const structuredOutputToolName = "structured_output"
+
 var (
 	user    = ""
 	account = ""
@@ -169,19 +174,15 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 						case "image_url":
 							// Convert OpenAI image format to Claude Code format
 							imageURL := part.Get("image_url.url").String()
-							if strings.HasPrefix(imageURL, "data:") {
-								// Extract base64 data and media type from data URL
-								parts := strings.Split(imageURL, ",")
-								if len(parts) == 2 {
-									mediaTypePart := strings.Split(parts[0], ";")[0]
-									mediaType := strings.TrimPrefix(mediaTypePart, "data:")
-									data := parts[1]
-
-									imagePart := `{"type":"image","source":{"type":"base64","media_type":"","data":""}}`
-									imagePart, _ = sjson.Set(imagePart, "source.media_type", mediaType)
-									imagePart, _ = sjson.Set(imagePart, "source.data", data)
-									msg, _ = sjson.SetRaw(msg, "content.-1", imagePart)
-								}
+							if mediaType, data, ok := misc.ParseDataURL(imageURL); ok {
+								imagePart := `{"type":"image","source":{"type":"base64","media_type":"","data":""}}`
+								imagePart, _ = sjson.Set(imagePart, "source.media_type", mediaType)
+								imagePart, _ = sjson.Set(imagePart, "source.data", data)
+								msg, _ = sjson.SetRaw(msg, "content.-1", imagePart)
+							} else if misc.IsRemoteURL(imageURL) {
+								imagePart := `{"type":"image","source":{"type":"url","url":""}}`
+								imagePart, _ = sjson.Set(imagePart, "source.url", imageURL)
+								msg, _ = sjson.SetRaw(msg, "content.-1", imagePart)
 							}
 						}
 						return true
@@ -294,5 +295,23 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
+	// Structured output: Claude has no response_format, so a json_schema is offered as a
+	// tool whose input is the answer. The tool is forced unless the client brings tools of
+	// its own; the response translator turns the call back into message content.
+	if format := root.Get("response_format"); format.Get("type").String() == "json_schema" {
+		if schema := format.Get("json_schema.schema"); schema.IsObject() {
+			hasTools := gjson.Get(out, "tools").IsArray()
+			tool := `{"name":"","description":"Give your final answer by calling this tool; its input is the answer.","input_schema":{}}`
+			tool, _ = sjson.Set(tool, "name", structuredOutputToolName)
+			tool, _ = sjson.SetRaw(tool, "input_schema", schema.Raw)
+			out, _ = sjson.SetRaw(out, "tools.-1", tool)
+			if !hasTools {
+				toolChoiceJSON := `{"type":"tool","name":""}`
+				toolChoiceJSON, _ = sjson.Set(toolChoiceJSON, "name", structuredOutputToolName)
+				out, _ = sjson.SetRaw(out, "tool_choice", toolChoiceJSON)
+			}
+		}
+	}
+
 	return []byte(out)
 }
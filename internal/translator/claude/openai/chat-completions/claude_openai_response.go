@@ -20,13 +20,57 @@ var (
 	dataTag = []byte("data:")
 )
 
-// ConvertAnthropicResponseToOpenAIParams holds parameters for response conversion
+// ConvertAnthropicResponseToOpenAIParams holds the state of one Claude stream being
+// converted to OpenAI chunks. Claude numbers content blocks across text, thinking and
+// tool use, while OpenAI numbers tool calls on their own, so the state maps each open
+// block to what it streams as.
 type ConvertAnthropicResponseToOpenAIParams struct {
 	CreatedAt    int64
 	ResponseID   string
 	FinishReason string
-	// Tool calls accumulator for streaming
-	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// Blocks holds the open content blocks of the current message by Claude block index.
+	Blocks map[int]*ContentBlockState
+	// ToolCalls is the number of tool calls streamed so far, which is the OpenAI
+	// tool_calls index of the next one.
+	ToolCalls int
+	// RoleSent is set once the assistant role was sent with the first message_start.
+	RoleSent bool
+	// StructuredOutput is set once the structured output tool call was sent as content.
+	StructuredOutput bool
+	// chunkTemplate caches the chunk template with the model, id and creation time
+	// already set, so deltas do not rebuild it; rebuilt when message_start changes them.
+	chunkTemplate string
+}
+
+// ContentBlockState is an open Claude content block of a stream.
+type ContentBlockState struct {
+	// Type is the Claude block type, e.g. "text", "thinking" or "tool_use".
+	Type string
+	// ToolCallIndex is the OpenAI tool_calls index of a tool_use block.
+	ToolCallIndex int
+	// Structured marks the structured output tool, whose input is sent as content.
+	Structured bool
+	// ArgumentsSent is set once a tool_use block streamed part of its input.
+	ArgumentsSent bool
+}
+
+// chunkTemplateFor returns the streaming chunk template for the current message.
+func (p *ConvertAnthropicResponseToOpenAIParams) chunkTemplateFor(modelName string) string {
+	if p.chunkTemplate != "" {
+		return p.chunkTemplate
+	}
+	template := `{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{},"finish_reason":null}]}`
+	if modelName != "" {
+		template, _ = sjson.Set(template, "model", modelName)
+	}
+	if p.ResponseID != "" {
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+	}
+	if p.CreatedAt > 0 {
+		template, _ = sjson.Set(template, "created", p.CreatedAt)
+	}
+	p.chunkTemplate = template
+	return template
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -38,8 +82,8 @@ type ToolCallAccumulator struct {
 
 // ConvertClaudeResponseToOpenAI converts Claude Code streaming response format to OpenAI Chat Completions format.
 // This function processes various Claude Code event types and transforms them into OpenAI-compatible JSON responses.
-// It handles text content, tool calls, reasoning content, and usage metadata, outputting responses that match
-// the OpenAI API format. The function supports incremental updates for streaming responses.
+// Text and reasoning deltas are forwarded as they arrive; tool calls are announced when their block starts and
+// their arguments streamed as input deltas, numbered by their position among the tool calls of the response.
 //
 // Parameters:
 //   - ctx: The context for the request, used for cancellation and timeout handling
@@ -55,12 +99,9 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		param = &localParam
 	}
 	if *param == nil {
-		*param = &ConvertAnthropicResponseToOpenAIParams{
-			CreatedAt:    0,
-			ResponseID:   "",
-			FinishReason: "",
-		}
+		*param = &ConvertAnthropicResponseToOpenAIParams{}
 	}
+	p := (*param).(*ConvertAnthropicResponseToOpenAIParams)
 
 	if !bytes.HasPrefix(rawJSON, dataTag) {
 		return []string{}
@@ -68,178 +109,170 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 	rawJSON = bytes.TrimSpace(rawJSON[5:])
 
 	root := gjson.ParseBytes(rawJSON)
-	eventType := root.Get("type").String()
-
-	// Base OpenAI streaming response template
-	template := `{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{},"finish_reason":null}]}`
+	index := int(root.Get("index").Int())
 
-	// Set model
-	if modelName != "" {
-		template, _ = sjson.Set(template, "model", modelName)
+	switch root.Get("type").String() {
+	case "message_start":
+		return p.messageStart(modelName, root.Get("message"))
+	case "content_block_start":
+		return p.contentBlockStart(modelName, originalRequestRawJSON, index, root.Get("content_block"))
+	case "content_block_delta":
+		return p.contentBlockDelta(modelName, index, root.Get("delta"))
+	case "content_block_stop":
+		return p.contentBlockStop(modelName, index)
+	case "message_delta":
+		return p.messageDelta(modelName, root)
+	case "error":
+		// Error event - format and return error response
+		if errorData := root.Get("error"); errorData.Exists() {
+			errorJSON := `{"error":{"message":"","type":""}}`
+			errorJSON, _ = sjson.Set(errorJSON, "error.message", errorData.Get("message").String())
+			errorJSON, _ = sjson.Set(errorJSON, "error.type", errorData.Get("type").String())
+			return []string{errorJSON}
+		}
+		return []string{}
+	default:
+		// message_stop, ping and unknown events need no output
+		return []string{}
 	}
+}
 
-	// Set response ID and creation time
-	if (*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID != "" {
-		template, _ = sjson.Set(template, "id", (*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID)
+// messageStart takes the id of a new message. Blocks are numbered per message, so open
+// blocks of a previous message are dropped; tool call numbering continues.
+func (p *ConvertAnthropicResponseToOpenAIParams) messageStart(modelName string, message gjson.Result) []string {
+	if !message.Exists() {
+		return []string{}
 	}
-	if (*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt > 0 {
-		template, _ = sjson.Set(template, "created", (*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt)
+	p.ResponseID = message.Get("id").String()
+	p.CreatedAt = time.Now().Unix()
+	p.chunkTemplate = ""
+	p.Blocks = make(map[int]*ContentBlockState)
+	if p.RoleSent {
+		return []string{}
 	}
+	p.RoleSent = true
+	template, _ := sjson.Set(p.chunkTemplateFor(modelName), "choices.0.delta.role", "assistant")
+	return []string{template}
+}
 
-	switch eventType {
-	case "message_start":
-		// Initialize response with message metadata when a new message begins
-		if message := root.Get("message"); message.Exists() {
-			(*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID = message.Get("id").String()
-			(*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt = time.Now().Unix()
-
-			template, _ = sjson.Set(template, "id", (*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID)
-			template, _ = sjson.Set(template, "model", modelName)
-			template, _ = sjson.Set(template, "created", (*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt)
-
-			// Set initial role to assistant for the response
-			template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
+// contentBlockStart opens a block. A tool call is announced with its id and name at once.
+func (p *ConvertAnthropicResponseToOpenAIParams) contentBlockStart(modelName string, originalRequestRawJSON []byte, index int, contentBlock gjson.Result) []string {
+	if !contentBlock.Exists() {
+		return []string{}
+	}
+	if p.Blocks == nil {
+		p.Blocks = make(map[int]*ContentBlockState)
+	}
+	block := &ContentBlockState{Type: contentBlock.Get("type").String()}
+	p.Blocks[index] = block
 
-			// Initialize tool calls accumulator for tracking tool call progress
-			if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
-			}
+	switch block.Type {
+	case "text":
+		if text := contentBlock.Get("text").String(); text != "" {
+			return p.contentChunk(modelName, "content", text)
 		}
+	case "tool_use":
+		name := contentBlock.Get("name").String()
+		if isStructuredOutputCall(originalRequestRawJSON, name) {
+			block.Structured = true
+			p.StructuredOutput = true
+			return []string{}
+		}
+		block.ToolCallIndex = p.ToolCalls
+		p.ToolCalls++
+		template := p.chunkTemplateFor(modelName)
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.index", block.ToolCallIndex)
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.id", contentBlock.Get("id").String())
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.type", "function")
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.name", name)
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.arguments", "")
 		return []string{template}
+	}
+	return []string{}
+}
 
-	case "content_block_start":
-		// Start of a content block (text, tool use, or reasoning)
-		if contentBlock := root.Get("content_block"); contentBlock.Exists() {
-			blockType := contentBlock.Get("type").String()
-
-			if blockType == "tool_use" {
-				// Start of tool call - initialize accumulator to track arguments
-				toolCallID := contentBlock.Get("id").String()
-				toolName := contentBlock.Get("name").String()
-				index := int(root.Get("index").Int())
-
-				if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
-					(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
-				}
-
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index] = &ToolCallAccumulator{
-					ID:   toolCallID,
-					Name: toolName,
-				}
-
-				// Don't output anything yet - wait for complete tool call
-				return []string{}
-			}
+// contentBlockDelta forwards text, reasoning and tool input deltas.
+func (p *ConvertAnthropicResponseToOpenAIParams) contentBlockDelta(modelName string, index int, delta gjson.Result) []string {
+	switch delta.Get("type").String() {
+	case "text_delta":
+		if text := delta.Get("text"); text.Exists() {
+			return p.contentChunk(modelName, "content", text.String())
 		}
-		return []string{}
-
-	case "content_block_delta":
-		// Handle content delta (text, tool use arguments, or reasoning content)
-		hasContent := false
-		if delta := root.Get("delta"); delta.Exists() {
-			deltaType := delta.Get("type").String()
-
-			switch deltaType {
-			case "text_delta":
-				// Text content delta - send incremental text updates
-				if text := delta.Get("text"); text.Exists() {
-					template, _ = sjson.Set(template, "choices.0.delta.content", text.String())
-					hasContent = true
-				}
-			case "thinking_delta":
-				// Accumulate reasoning/thinking content
-				if thinking := delta.Get("thinking"); thinking.Exists() {
-					template, _ = sjson.Set(template, "choices.0.delta.reasoning_content", thinking.String())
-					hasContent = true
-				}
-			case "input_json_delta":
-				// Tool use input delta - accumulate arguments for tool calls
-				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
-					index := int(root.Get("index").Int())
-					if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
-						if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
-							accumulator.Arguments.WriteString(partialJSON.String())
-						}
-					}
-				}
-				// Don't output anything yet - wait for complete tool call
-				return []string{}
-			}
+	case "thinking_delta":
+		if thinking := delta.Get("thinking"); thinking.Exists() {
+			return p.contentChunk(modelName, "reasoning_content", thinking.String())
 		}
-		if hasContent {
-			return []string{template}
-		} else {
+	case "input_json_delta":
+		block := p.Blocks[index]
+		partialJSON := delta.Get("partial_json").String()
+		if block == nil || block.Type != "tool_use" || partialJSON == "" {
 			return []string{}
 		}
-
-	case "content_block_stop":
-		// End of content block - output complete tool call if it's a tool_use block
-		index := int(root.Get("index").Int())
-		if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
-			if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
-				// Build complete tool call with accumulated arguments
-				arguments := accumulator.Arguments.String()
-				if arguments == "" {
-					arguments = "{}"
-				}
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.index", index)
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.id", accumulator.ID)
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.type", "function")
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.name", accumulator.Name)
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.arguments", arguments)
-
-				// Clean up the accumulator for this index
-				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
-
-				return []string{template}
-			}
+		block.ArgumentsSent = true
+		if block.Structured {
+			return p.contentChunk(modelName, "content", partialJSON)
 		}
-		return []string{}
+		return p.argumentsChunk(modelName, block.ToolCallIndex, partialJSON)
+	}
+	return []string{}
+}
 
-	case "message_delta":
-		// Handle message-level changes including stop reason and usage
-		if delta := root.Get("delta"); delta.Exists() {
-			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
-				template, _ = sjson.Set(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
-			}
-		}
+// contentBlockStop closes a block. A tool call without input gets empty arguments.
+func (p *ConvertAnthropicResponseToOpenAIParams) contentBlockStop(modelName string, index int) []string {
+	block := p.Blocks[index]
+	delete(p.Blocks, index)
+	if block == nil || block.Type != "tool_use" || block.ArgumentsSent {
+		return []string{}
+	}
+	if block.Structured {
+		return p.contentChunk(modelName, "content", "{}")
+	}
+	return p.argumentsChunk(modelName, block.ToolCallIndex, "{}")
+}
 
-		// Handle usage information for token counts
-		if usage := root.Get("usage"); usage.Exists() {
-			inputTokens := usage.Get("input_tokens").Int()
-			outputTokens := usage.Get("output_tokens").Int()
-			cacheReadInputTokens := usage.Get("cache_read_input_tokens").Int()
-			cacheCreationInputTokens := usage.Get("cache_creation_input_tokens").Int()
-			template, _ = sjson.Set(template, "usage.prompt_tokens", inputTokens+cacheCreationInputTokens)
-			template, _ = sjson.Set(template, "usage.completion_tokens", outputTokens)
-			template, _ = sjson.Set(template, "usage.total_tokens", inputTokens+outputTokens)
-			template, _ = sjson.Set(template, "usage.prompt_tokens_details.cached_tokens", cacheReadInputTokens)
+// messageDelta sends the finish reason and usage of the message.
+func (p *ConvertAnthropicResponseToOpenAIParams) messageDelta(modelName string, root gjson.Result) []string {
+	template := p.chunkTemplateFor(modelName)
+	if stopReason := root.Get("delta.stop_reason"); stopReason.Exists() {
+		p.FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+		if p.StructuredOutput && stopReason.String() == "tool_use" && p.ToolCalls == 0 {
+			p.FinishReason = "stop"
 		}
-		return []string{template}
+		template, _ = sjson.Set(template, "choices.0.finish_reason", p.FinishReason)
+	}
 
-	case "message_stop":
-		// Final message event - no additional output needed
-		return []string{}
+	// Handle usage information for token counts
+	if usage := root.Get("usage"); usage.Exists() {
+		inputTokens := usage.Get("input_tokens").Int()
+		outputTokens := usage.Get("output_tokens").Int()
+		cacheReadInputTokens := usage.Get("cache_read_input_tokens").Int()
+		cacheCreationInputTokens := usage.Get("cache_creation_input_tokens").Int()
+		template, _ = sjson.Set(template, "usage.prompt_tokens", inputTokens+cacheCreationInputTokens)
+		template, _ = sjson.Set(template, "usage.completion_tokens", outputTokens)
+		template, _ = sjson.Set(template, "usage.total_tokens", inputTokens+outputTokens)
+		template, _ = sjson.Set(template, "usage.prompt_tokens_details.cached_tokens", cacheReadInputTokens)
+	}
+	return []string{template}
+}
 
-	case "ping":
-		// Ping events for keeping connection alive - no output needed
-		return []string{}
+// contentChunk returns a chunk setting the delta field to text.
+func (p *ConvertAnthropicResponseToOpenAIParams) contentChunk(modelName, field, text string) []string {
+	template, _ := sjson.Set(p.chunkTemplateFor(modelName), "choices.0.delta."+field, text)
+	return []string{template}
+}
 
-	case "error":
-		// Error event - format and return error response
-		if errorData := root.Get("error"); errorData.Exists() {
-			errorJSON := `{"error":{"message":"","type":""}}`
-			errorJSON, _ = sjson.Set(errorJSON, "error.message", errorData.Get("message").String())
-			errorJSON, _ = sjson.Set(errorJSON, "error.type", errorData.Get("type").String())
-			return []string{errorJSON}
-		}
-		return []string{}
+// argumentsChunk returns a chunk appending arguments to the tool call at toolCallIndex.
+func (p *ConvertAnthropicResponseToOpenAIParams) argumentsChunk(modelName string, toolCallIndex int, arguments string) []string {
+	template := p.chunkTemplateFor(modelName)
+	template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.index", toolCallIndex)
+	template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.arguments", arguments)
+	return []string{template}
+}
 
-	default:
-		// Unknown event type - ignore
-		return []string{}
-	}
+// isStructuredOutputCall reports whether a tool call named name carries the answer to a
+// json_schema response_format of the original OpenAI request.
+func isStructuredOutputCall(originalRequestRawJSON []byte, name string) bool {
+	return name == structuredOutputToolName && gjson.GetBytes(originalRequestRawJSON, "response_format.type").String() == "json_schema"
 }
 
 // mapAnthropicStopReasonToOpenAI maps Anthropic stop reasons to OpenAI stop reasons
@@ -394,6 +427,17 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		out, _ = sjson.Set(out, "choices.0.message.reasoning", reasoningContent)
 	}
 
+	// The structured output tool call is the answer itself and replaces any text around it
+	for index, accumulator := range toolCallsAccumulator {
+		if isStructuredOutputCall(originalRequestRawJSON, accumulator.Name) {
+			out, _ = sjson.Set(out, "choices.0.message.content", accumulator.Arguments.String())
+			delete(toolCallsAccumulator, index)
+			if stopReason == "tool_use" {
+				stopReason = "end_turn"
+			}
+		}
+	}
+
 	// Set tool calls if any were accumulated during processing
 	if len(toolCallsAccumulator) > 0 {
 		toolCallsCount := 0
@@ -102,9 +102,9 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 				hasContent = true
 			}
 
-			appendImageContent := func(dataURL string) {
+			appendImageContent := func(imageURL string) {
 				message, _ = sjson.Set(message, fmt.Sprintf("content.%d.type", contentIndex), "input_image")
-				message, _ = sjson.Set(message, fmt.Sprintf("content.%d.image_url", contentIndex), dataURL)
+				message, _ = sjson.Set(message, fmt.Sprintf("content.%d.image_url", contentIndex), imageURL)
 				contentIndex++
 				hasContent = true
 			}
@@ -136,6 +136,8 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 								}
 								dataURL := fmt.Sprintf("data:%s;base64,%s", mediaType, data)
 								appendImageContent(dataURL)
+							} else if url := sourceResult.Get("url").String(); sourceResult.Get("type").String() == "url" && url != "" {
+								appendImageContent(url)
 							}
 						}
 					case "tool_use":
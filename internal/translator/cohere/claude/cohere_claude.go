@@ -0,0 +1,48 @@
+// Package claude provides translation between the Anthropic Messages API and the Cohere
+// v2 chat API. Both directions go through the OpenAI Chat Completions translators so the
+// Cohere mapping for messages, tools and citations lives in one place.
+package claude
+
+import (
+	"context"
+
+	cohereopenai "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/cohere/openai/chat-completions"
+	openaiclaude "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/claude"
+)
+
+// convertCohereResponseToClaudeParams keeps the state of both translation stages.
+type convertCohereResponseToClaudeParams struct {
+	cohere any
+	claude any
+}
+
+// ConvertClaudeRequestToCohere converts an Anthropic Messages request into a Cohere v2
+// chat request.
+func ConvertClaudeRequestToCohere(modelName string, inputRawJSON []byte, stream bool) []byte {
+	openAIRequest := openaiclaude.ConvertClaudeRequestToOpenAI(modelName, inputRawJSON, stream)
+	return cohereopenai.ConvertOpenAIRequestToCohere(modelName, openAIRequest, stream)
+}
+
+// ConvertCohereResponseToClaude converts Cohere v2 streaming events to Anthropic SSE events.
+func ConvertCohereResponseToClaude(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+	if *param == nil {
+		*param = &convertCohereResponseToClaudeParams{}
+	}
+	p := (*param).(*convertCohereResponseToClaudeParams)
+
+	var results []string
+	for _, chunk := range cohereopenai.ConvertCohereResponseToOpenAI(ctx, modelName, originalRequestRawJSON, requestRawJSON, rawJSON, &p.cohere) {
+		results = append(results, openaiclaude.ConvertOpenAIResponseToClaude(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte("data: "+chunk), &p.claude)...)
+	}
+	if cohereopenai.StreamFinished(rawJSON) {
+		results = append(results, openaiclaude.ConvertOpenAIResponseToClaude(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte("data: [DONE]"), &p.claude)...)
+	}
+	return results
+}
+
+// ConvertCohereResponseToClaudeNonStream converts a Cohere v2 chat response to an
+// Anthropic Messages response.
+func ConvertCohereResponseToClaudeNonStream(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
+	openAIResponse := cohereopenai.ConvertCohereResponseToOpenAINonStream(ctx, modelName, originalRequestRawJSON, requestRawJSON, rawJSON, nil)
+	return openaiclaude.ConvertOpenAIResponseToClaudeNonStream(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte(openAIResponse), nil)
+}
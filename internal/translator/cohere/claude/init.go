@@ -0,0 +1,21 @@
+package claude
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	openaiclaude "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/claude"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Claude,
+		Cohere,
+		ConvertClaudeRequestToCohere,
+		interfaces.TranslateResponse{
+			Stream:     ConvertCohereResponseToClaude,
+			NonStream:  ConvertCohereResponseToClaudeNonStream,
+			TokenCount: openaiclaude.ClaudeTokenCount,
+		},
+	)
+}
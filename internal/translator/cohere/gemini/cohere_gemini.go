@@ -0,0 +1,45 @@
+// Package gemini provides translation between the Gemini generateContent API and the
+// Cohere v2 chat API. Both directions go through the OpenAI Chat Completions translators
+// so the Cohere mapping for messages, tools and citations lives in one place.
+package gemini
+
+import (
+	"context"
+
+	cohereopenai "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/cohere/openai/chat-completions"
+	openaigemini "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/gemini"
+)
+
+// convertCohereResponseToGeminiParams keeps the state of both translation stages.
+type convertCohereResponseToGeminiParams struct {
+	cohere any
+	gemini any
+}
+
+// ConvertGeminiRequestToCohere converts a Gemini generateContent request into a Cohere v2
+// chat request.
+func ConvertGeminiRequestToCohere(modelName string, inputRawJSON []byte, stream bool) []byte {
+	openAIRequest := openaigemini.ConvertGeminiRequestToOpenAI(modelName, inputRawJSON, stream)
+	return cohereopenai.ConvertOpenAIRequestToCohere(modelName, openAIRequest, stream)
+}
+
+// ConvertCohereResponseToGemini converts Cohere v2 streaming events to Gemini stream chunks.
+func ConvertCohereResponseToGemini(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+	if *param == nil {
+		*param = &convertCohereResponseToGeminiParams{}
+	}
+	p := (*param).(*convertCohereResponseToGeminiParams)
+
+	var results []string
+	for _, chunk := range cohereopenai.ConvertCohereResponseToOpenAI(ctx, modelName, originalRequestRawJSON, requestRawJSON, rawJSON, &p.cohere) {
+		results = append(results, openaigemini.ConvertOpenAIResponseToGemini(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte(chunk), &p.gemini)...)
+	}
+	return results
+}
+
+// ConvertCohereResponseToGeminiNonStream converts a Cohere v2 chat response to a Gemini
+// generateContent response.
+func ConvertCohereResponseToGeminiNonStream(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
+	openAIResponse := cohereopenai.ConvertCohereResponseToOpenAINonStream(ctx, modelName, originalRequestRawJSON, requestRawJSON, rawJSON, nil)
+	return openaigemini.ConvertOpenAIResponseToGeminiNonStream(ctx, modelName, originalRequestRawJSON, requestRawJSON, []byte(openAIResponse), nil)
+}
@@ -0,0 +1,21 @@
+package gemini
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	openaigemini "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Gemini,
+		Cohere,
+		ConvertGeminiRequestToCohere,
+		interfaces.TranslateResponse{
+			Stream:     ConvertCohereResponseToGemini,
+			NonStream:  ConvertCohereResponseToGeminiNonStream,
+			TokenCount: openaigemini.GeminiTokenCount,
+		},
+	)
+}
@@ -0,0 +1,223 @@
+// Package chat_completions provides request translation from OpenAI Chat Completions to
+// the Cohere v2 chat API. It maps messages, tool declarations, tool calls and tool results,
+// and generation parameters onto Cohere's schema, passing Cohere-only RAG fields through.
+package chat_completions
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIRequestToCohere converts an OpenAI Chat Completions request into a Cohere
+// v2 chat request.
+//
+// Parameters:
+//   - modelName: The name of the model to use for the request
+//   - inputRawJSON: The raw JSON request data from the OpenAI API
+//   - stream: A boolean indicating if the request is for a streaming response
+//
+// Returns:
+//   - []byte: The transformed request data in Cohere v2 chat format
+func ConvertOpenAIRequestToCohere(modelName string, inputRawJSON []byte, stream bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+	out := `{"model":"","messages":[]}`
+	out, _ = sjson.Set(out, "model", modelName)
+	if stream {
+		out, _ = sjson.Set(out, "stream", true)
+	}
+
+	if messages := root.Get("messages"); messages.IsArray() {
+		for _, msg := range messages.Array() {
+			if converted, ok := convertOpenAIMessageToCohere(msg); ok {
+				out, _ = sjson.SetRaw(out, "messages.-1", converted)
+			}
+		}
+	}
+
+	if tools := root.Get("tools"); tools.IsArray() {
+		for _, tool := range tools.Array() {
+			if tool.Get("type").String() != "function" {
+				continue
+			}
+			fn := tool.Get("function")
+			name := fn.Get("name").String()
+			if name == "" {
+				continue
+			}
+			cohereTool := `{"type":"function","function":{"name":""}}`
+			cohereTool, _ = sjson.Set(cohereTool, "function.name", name)
+			if desc := fn.Get("description"); desc.Exists() {
+				cohereTool, _ = sjson.Set(cohereTool, "function.description", desc.String())
+			}
+			if params := fn.Get("parameters"); params.Exists() && params.IsObject() {
+				cohereTool, _ = sjson.SetRaw(cohereTool, "function.parameters", params.Raw)
+			} else {
+				cohereTool, _ = sjson.SetRaw(cohereTool, "function.parameters", `{"type":"object","properties":{}}`)
+			}
+			out, _ = sjson.SetRaw(out, "tools.-1", cohereTool)
+		}
+	}
+
+	// Cohere only distinguishes "must call a tool" from "must not call a tool"; a named
+	// tool choice is approximated by REQUIRED.
+	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
+		switch {
+		case toolChoice.Type == gjson.String && toolChoice.String() == "required":
+			out, _ = sjson.Set(out, "tool_choice", "REQUIRED")
+		case toolChoice.Type == gjson.String && toolChoice.String() == "none":
+			out, _ = sjson.Set(out, "tool_choice", "NONE")
+		case toolChoice.IsObject():
+			out, _ = sjson.Set(out, "tool_choice", "REQUIRED")
+		}
+	}
+
+	if maxTokens := root.Get("max_completion_tokens"); maxTokens.Exists() {
+		out, _ = sjson.Set(out, "max_tokens", maxTokens.Int())
+	} else if maxTokens = root.Get("max_tokens"); maxTokens.Exists() {
+		out, _ = sjson.Set(out, "max_tokens", maxTokens.Int())
+	}
+	if temperature := root.Get("temperature"); temperature.Exists() {
+		out, _ = sjson.Set(out, "temperature", temperature.Float())
+	}
+	if topP := root.Get("top_p"); topP.Exists() {
+		out, _ = sjson.Set(out, "p", topP.Float())
+	}
+	if topK := root.Get("top_k"); topK.Exists() {
+		out, _ = sjson.Set(out, "k", topK.Int())
+	}
+	if seed := root.Get("seed"); seed.Exists() {
+		out, _ = sjson.Set(out, "seed", seed.Int())
+	}
+	if penalty := root.Get("frequency_penalty"); penalty.Exists() {
+		out, _ = sjson.Set(out, "frequency_penalty", penalty.Float())
+	}
+	if penalty := root.Get("presence_penalty"); penalty.Exists() {
+		out, _ = sjson.Set(out, "presence_penalty", penalty.Float())
+	}
+	if stop := root.Get("stop"); stop.Exists() {
+		if stop.IsArray() {
+			for _, s := range stop.Array() {
+				out, _ = sjson.Set(out, "stop_sequences.-1", s.String())
+			}
+		} else if stop.String() != "" {
+			out, _ = sjson.Set(out, "stop_sequences.-1", stop.String())
+		}
+	}
+
+	if format := root.Get("response_format"); format.Exists() {
+		switch format.Get("type").String() {
+		case "json_object":
+			out, _ = sjson.SetRaw(out, "response_format", `{"type":"json_object"}`)
+		case "json_schema":
+			out, _ = sjson.SetRaw(out, "response_format", `{"type":"json_object"}`)
+			if schema := format.Get("json_schema.schema"); schema.Exists() {
+				out, _ = sjson.SetRaw(out, "response_format.json_schema", schema.Raw)
+			}
+		}
+	}
+
+	// Grounding documents and citation options have no OpenAI equivalent; clients that
+	// know about Cohere can send them alongside the OpenAI fields.
+	for _, key := range []string{"documents", "citation_options", "safety_mode"} {
+		if value := root.Get(key); value.Exists() {
+			out, _ = sjson.SetRaw(out, key, value.Raw)
+		}
+	}
+
+	return []byte(out)
+}
+
+// convertOpenAIMessageToCohere maps a single OpenAI chat message onto a Cohere message.
+func convertOpenAIMessageToCohere(msg gjson.Result) (string, bool) {
+	role := msg.Get("role").String()
+	content := msg.Get("content")
+	switch role {
+	case "system", "developer":
+		text := openAIContentText(content)
+		if text == "" {
+			return "", false
+		}
+		out := `{"role":"system","content":""}`
+		out, _ = sjson.Set(out, "content", text)
+		return out, true
+
+	case "user":
+		out := `{"role":"user","content":""}`
+		if !content.IsArray() {
+			out, _ = sjson.Set(out, "content", content.String())
+			return out, true
+		}
+		out, _ = sjson.SetRaw(out, "content", `[]`)
+		for _, part := range content.Array() {
+			switch part.Get("type").String() {
+			case "text":
+				item := `{"type":"text","text":""}`
+				item, _ = sjson.Set(item, "text", part.Get("text").String())
+				out, _ = sjson.SetRaw(out, "content.-1", item)
+			case "image_url":
+				url := part.Get("image_url.url").String()
+				if url == "" {
+					url = part.Get("image_url").String()
+				}
+				if url == "" {
+					continue
+				}
+				item := `{"type":"image_url","image_url":{"url":""}}`
+				item, _ = sjson.Set(item, "image_url.url", url)
+				out, _ = sjson.SetRaw(out, "content.-1", item)
+			}
+		}
+		return out, true
+
+	case "assistant":
+		out := `{"role":"assistant"}`
+		text := openAIContentText(content)
+		toolCalls := msg.Get("tool_calls")
+		if toolCalls.IsArray() && len(toolCalls.Array()) > 0 {
+			// Cohere carries the text preceding tool calls as the tool plan.
+			if text != "" {
+				out, _ = sjson.Set(out, "tool_plan", text)
+			}
+			for _, call := range toolCalls.Array() {
+				item := `{"id":"","type":"function","function":{"name":"","arguments":""}}`
+				item, _ = sjson.Set(item, "id", call.Get("id").String())
+				item, _ = sjson.Set(item, "function.name", call.Get("function.name").String())
+				args := call.Get("function.arguments").String()
+				if args == "" {
+					args = "{}"
+				}
+				item, _ = sjson.Set(item, "function.arguments", args)
+				out, _ = sjson.SetRaw(out, "tool_calls.-1", item)
+			}
+			return out, true
+		}
+		out, _ = sjson.Set(out, "content", text)
+		return out, true
+
+	case "tool":
+		out := `{"role":"tool","tool_call_id":"","content":[]}`
+		out, _ = sjson.Set(out, "tool_call_id", msg.Get("tool_call_id").String())
+		// Tool results are sent as documents so Cohere can cite them.
+		document := `{"type":"document","document":{"data":""}}`
+		document, _ = sjson.Set(document, "document.data", openAIContentText(content))
+		out, _ = sjson.SetRaw(out, "content.-1", document)
+		return out, true
+	}
+	return "", false
+}
+
+// openAIContentText flattens OpenAI string or text-part content into plain text.
+func openAIContentText(content gjson.Result) string {
+	if !content.IsArray() {
+		return content.String()
+	}
+	var sb strings.Builder
+	for _, part := range content.Array() {
+		if part.Get("type").String() == "text" {
+			sb.WriteString(part.Get("text").String())
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,221 @@
+// Package chat_completions provides response translation from the Cohere v2 chat API to
+// OpenAI Chat Completions. Streaming events are converted chunk by chunk; citations are
+// preserved under a "citations" field on the message (or delta) since OpenAI has no
+// equivalent for document-grounded spans.
+package chat_completions
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+var (
+	dataTag = []byte("data:")
+)
+
+// ConvertCohereResponseToOpenAIParams holds state across streaming chunks.
+type ConvertCohereResponseToOpenAIParams struct {
+	ResponseID string
+	CreatedAt  int64
+}
+
+// ConvertCohereResponseToOpenAI converts Cohere v2 streaming events to OpenAI Chat
+// Completions chunks.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - rawJSON: A single SSE line from the Cohere API
+//   - param: A pointer to a parameter object for maintaining state between calls
+//
+// Returns:
+//   - []string: A slice of OpenAI-compatible JSON chunks
+func ConvertCohereResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+	if *param == nil {
+		*param = &ConvertCohereResponseToOpenAIParams{}
+	}
+	p := (*param).(*ConvertCohereResponseToOpenAIParams)
+
+	if !bytes.HasPrefix(rawJSON, dataTag) {
+		return []string{}
+	}
+	rawJSON = bytes.TrimSpace(rawJSON[5:])
+	root := gjson.ParseBytes(rawJSON)
+
+	template := `{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{},"finish_reason":null}]}`
+	if p.CreatedAt == 0 {
+		p.CreatedAt = time.Now().Unix()
+	}
+	template, _ = sjson.Set(template, "model", modelName)
+	template, _ = sjson.Set(template, "created", p.CreatedAt)
+
+	switch root.Get("type").String() {
+	case "message-start":
+		p.ResponseID = root.Get("id").String()
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+		template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
+		return []string{template}
+
+	case "content-delta":
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+		content := root.Get("delta.message.content")
+		if text := content.Get("text"); text.Exists() && text.String() != "" {
+			template, _ = sjson.Set(template, "choices.0.delta.content", text.String())
+			return []string{template}
+		}
+		if thinking := content.Get("thinking"); thinking.Exists() && thinking.String() != "" {
+			template, _ = sjson.Set(template, "choices.0.delta.reasoning_content", thinking.String())
+			return []string{template}
+		}
+		return []string{}
+
+	case "tool-plan-delta":
+		plan := root.Get("delta.message.tool_plan").String()
+		if plan == "" {
+			return []string{}
+		}
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+		template, _ = sjson.Set(template, "choices.0.delta.reasoning_content", plan)
+		return []string{template}
+
+	case "tool-call-start":
+		call := root.Get("delta.message.tool_calls")
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.index", root.Get("index").Int())
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.id", call.Get("id").String())
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.type", "function")
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.name", call.Get("function.name").String())
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.arguments", call.Get("function.arguments").String())
+		return []string{template}
+
+	case "tool-call-delta":
+		args := root.Get("delta.message.tool_calls.function.arguments").String()
+		if args == "" {
+			return []string{}
+		}
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.index", root.Get("index").Int())
+		template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.arguments", args)
+		return []string{template}
+
+	case "citation-start":
+		citation := root.Get("delta.message.citations")
+		if !citation.Exists() {
+			return []string{}
+		}
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+		template, _ = sjson.SetRaw(template, "choices.0.delta.citations", `[]`)
+		template, _ = sjson.SetRaw(template, "choices.0.delta.citations.-1", citation.Raw)
+		return []string{template}
+
+	case "message-end":
+		template, _ = sjson.Set(template, "id", p.ResponseID)
+		template, _ = sjson.Set(template, "choices.0.finish_reason", mapCohereFinishReasonToOpenAI(root.Get("delta.finish_reason").String()))
+		if usage := root.Get("delta.usage"); usage.Exists() {
+			template = setOpenAIUsageFromCohere(template, usage)
+		}
+		return []string{template}
+	}
+	return []string{}
+}
+
+// ConvertCohereResponseToOpenAINonStream converts a Cohere v2 chat response to an OpenAI
+// Chat Completions response.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - rawJSON: The raw JSON response from the Cohere API
+//   - param: Unused for non-streaming conversion
+//
+// Returns:
+//   - string: An OpenAI-compatible JSON response
+func ConvertCohereResponseToOpenAINonStream(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
+	root := gjson.ParseBytes(rawJSON)
+	out := `{"id":"","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}]}`
+	out, _ = sjson.Set(out, "id", root.Get("id").String())
+	out, _ = sjson.Set(out, "created", time.Now().Unix())
+	out, _ = sjson.Set(out, "model", modelName)
+
+	message := root.Get("message")
+	var text, reasoning strings.Builder
+	for _, part := range message.Get("content").Array() {
+		switch part.Get("type").String() {
+		case "text":
+			text.WriteString(part.Get("text").String())
+		case "thinking":
+			reasoning.WriteString(part.Get("thinking").String())
+		}
+	}
+	if plan := message.Get("tool_plan").String(); plan != "" {
+		reasoning.WriteString(plan)
+	}
+	out, _ = sjson.Set(out, "choices.0.message.content", text.String())
+	if reasoning.Len() > 0 {
+		out, _ = sjson.Set(out, "choices.0.message.reasoning_content", reasoning.String())
+	}
+
+	for _, call := range message.Get("tool_calls").Array() {
+		item := `{"id":"","type":"function","function":{"name":"","arguments":""}}`
+		item, _ = sjson.Set(item, "id", call.Get("id").String())
+		item, _ = sjson.Set(item, "function.name", call.Get("function.name").String())
+		args := call.Get("function.arguments").String()
+		if args == "" {
+			args = "{}"
+		}
+		item, _ = sjson.Set(item, "function.arguments", args)
+		out, _ = sjson.SetRaw(out, "choices.0.message.tool_calls.-1", item)
+	}
+
+	if citations := message.Get("citations"); citations.IsArray() && len(citations.Array()) > 0 {
+		out, _ = sjson.SetRaw(out, "choices.0.message.citations", citations.Raw)
+	}
+
+	out, _ = sjson.Set(out, "choices.0.finish_reason", mapCohereFinishReasonToOpenAI(root.Get("finish_reason").String()))
+	if usage := root.Get("usage"); usage.Exists() {
+		out = setOpenAIUsageFromCohere(out, usage)
+	}
+	return out
+}
+
+// StreamFinished reports whether a Cohere SSE line carries the final message-end event.
+func StreamFinished(rawJSON []byte) bool {
+	if !bytes.HasPrefix(rawJSON, dataTag) {
+		return false
+	}
+	return gjson.GetBytes(bytes.TrimSpace(rawJSON[5:]), "type").String() == "message-end"
+}
+
+// setOpenAIUsageFromCohere writes OpenAI usage fields from a Cohere usage object,
+// preferring model token counts over billed units.
+func setOpenAIUsageFromCohere(out string, usage gjson.Result) string {
+	tokens := usage.Get("tokens")
+	if !tokens.Exists() {
+		tokens = usage.Get("billed_units")
+	}
+	input := tokens.Get("input_tokens").Int()
+	output := tokens.Get("output_tokens").Int()
+	out, _ = sjson.Set(out, "usage.prompt_tokens", input)
+	out, _ = sjson.Set(out, "usage.completion_tokens", output)
+	out, _ = sjson.Set(out, "usage.total_tokens", input+output)
+	return out
+}
+
+// mapCohereFinishReasonToOpenAI maps Cohere finish reasons to OpenAI finish reasons.
+func mapCohereFinishReasonToOpenAI(reason string) string {
+	switch strings.ToUpper(reason) {
+	case "MAX_TOKENS":
+		return "length"
+	case "TOOL_CALL":
+		return "tool_calls"
+	case "ERROR_TOXIC":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
@@ -0,0 +1,153 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToCohere_ToolRoundTrip(t *testing.T) {
+	input := []byte(`{
+		"model": "command-a",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [{"type": "text", "text": "Weather?"}, {"type": "image_url", "image_url": {"url": "https://example.com/a.png"}}]},
+			{"role": "assistant", "content": "Checking.", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Paris\"}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "sunny"}
+		],
+		"tools": [{"type": "function", "function": {"name": "weather", "description": "Look up weather", "parameters": {"type": "object"}}}],
+		"tool_choice": "required",
+		"max_tokens": 256,
+		"top_p": 0.9,
+		"stop": "END",
+		"documents": [{"id": "doc1", "data": {"text": "x"}}]
+	}`)
+
+	out := gjson.ParseBytes(ConvertOpenAIRequestToCohere("command-a-03-2025", input, true))
+
+	if got := out.Get("model").String(); got != "command-a-03-2025" {
+		t.Fatalf("model = %q", got)
+	}
+	if !out.Get("stream").Bool() {
+		t.Fatalf("expected stream=true")
+	}
+	if got := out.Get("messages.0.role").String(); got != "system" {
+		t.Fatalf("messages.0.role = %q", got)
+	}
+	if got := out.Get("messages.1.content.1.image_url.url").String(); got != "https://example.com/a.png" {
+		t.Fatalf("image url = %q", got)
+	}
+	assistant := out.Get("messages.2")
+	if got := assistant.Get("tool_plan").String(); got != "Checking." {
+		t.Fatalf("tool_plan = %q", got)
+	}
+	if got := assistant.Get("tool_calls.0.function.name").String(); got != "weather" {
+		t.Fatalf("tool call name = %q", got)
+	}
+	tool := out.Get("messages.3")
+	if tool.Get("role").String() != "tool" || tool.Get("tool_call_id").String() != "call_1" {
+		t.Fatalf("unexpected tool message: %s", tool.Raw)
+	}
+	if got := tool.Get("content.0.document.data").String(); got != "sunny" {
+		t.Fatalf("tool document = %q", got)
+	}
+	if got := out.Get("tools.0.function.name").String(); got != "weather" {
+		t.Fatalf("tools.0 name = %q", got)
+	}
+	if got := out.Get("tool_choice").String(); got != "REQUIRED" {
+		t.Fatalf("tool_choice = %q", got)
+	}
+	if out.Get("max_tokens").Int() != 256 || out.Get("p").Float() != 0.9 {
+		t.Fatalf("generation params not mapped: %s", out.Raw)
+	}
+	if got := out.Get("stop_sequences.0").String(); got != "END" {
+		t.Fatalf("stop_sequences = %q", got)
+	}
+	if got := out.Get("documents.0.id").String(); got != "doc1" {
+		t.Fatalf("documents not passed through: %s", out.Raw)
+	}
+}
+
+func TestConvertCohereResponseToOpenAINonStream(t *testing.T) {
+	raw := []byte(`{
+		"id": "resp_1",
+		"finish_reason": "TOOL_CALL",
+		"message": {
+			"role": "assistant",
+			"tool_plan": "I will look it up.",
+			"content": [{"type": "text", "text": "Paris is sunny."}],
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Paris\"}"}}],
+			"citations": [{"start": 0, "end": 5, "text": "Paris", "sources": [{"type": "tool", "id": "call_1:0"}]}]
+		},
+		"usage": {"billed_units": {"input_tokens": 1, "output_tokens": 1}, "tokens": {"input_tokens": 10, "output_tokens": 4}}
+	}`)
+
+	out := gjson.Parse(ConvertCohereResponseToOpenAINonStream(context.Background(), "command-a-03-2025", nil, nil, raw, nil))
+
+	if got := out.Get("choices.0.message.content").String(); got != "Paris is sunny." {
+		t.Fatalf("content = %q", got)
+	}
+	if got := out.Get("choices.0.message.reasoning_content").String(); got != "I will look it up." {
+		t.Fatalf("reasoning_content = %q", got)
+	}
+	if got := out.Get("choices.0.message.tool_calls.0.id").String(); got != "call_1" {
+		t.Fatalf("tool call id = %q", got)
+	}
+	if got := out.Get("choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q", got)
+	}
+	if got := out.Get("choices.0.message.citations.0.sources.0.id").String(); got != "call_1:0" {
+		t.Fatalf("citations not preserved: %s", out.Raw)
+	}
+	if out.Get("usage.prompt_tokens").Int() != 10 || out.Get("usage.total_tokens").Int() != 14 {
+		t.Fatalf("usage = %s", out.Get("usage").Raw)
+	}
+}
+
+func TestConvertCohereResponseToOpenAI_Stream(t *testing.T) {
+	lines := []string{
+		`event: message-start`,
+		`data: {"type":"message-start","id":"resp_2","delta":{"message":{"role":"assistant"}}}`,
+		`data: {"type":"content-delta","index":0,"delta":{"message":{"content":{"text":"Hi"}}}}`,
+		`data: {"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"call_9","type":"function","function":{"name":"lookup","arguments":""}}}}}`,
+		`data: {"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":"{\"q\":1}"}}}}}`,
+		`data: {"type":"citation-start","index":0,"delta":{"message":{"citations":{"start":0,"end":2,"text":"Hi","sources":[]}}}}`,
+		`data: {"type":"message-end","delta":{"finish_reason":"COMPLETE","usage":{"tokens":{"input_tokens":3,"output_tokens":2}}}}`,
+	}
+
+	var param any
+	var chunks []gjson.Result
+	for _, line := range lines {
+		for _, chunk := range ConvertCohereResponseToOpenAI(context.Background(), "command-r", nil, nil, []byte(line), &param) {
+			chunks = append(chunks, gjson.Parse(chunk))
+		}
+	}
+	if len(chunks) != 6 {
+		t.Fatalf("expected 6 chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if chunk.Get("id").String() != "resp_2" {
+			t.Fatalf("chunk missing response id: %s", chunk.Raw)
+		}
+	}
+	if got := chunks[1].Get("choices.0.delta.content").String(); got != "Hi" {
+		t.Fatalf("content delta = %q", got)
+	}
+	if got := chunks[2].Get("choices.0.delta.tool_calls.0.function.name").String(); got != "lookup" {
+		t.Fatalf("tool call start = %s", chunks[2].Raw)
+	}
+	if got := chunks[3].Get("choices.0.delta.tool_calls.0.function.arguments").String(); got != `{"q":1}` {
+		t.Fatalf("tool call arguments = %q", got)
+	}
+	if got := chunks[4].Get("choices.0.delta.citations.0.text").String(); got != "Hi" {
+		t.Fatalf("citation = %s", chunks[4].Raw)
+	}
+	last := chunks[5]
+	if last.Get("choices.0.finish_reason").String() != "stop" || last.Get("usage.total_tokens").Int() != 5 {
+		t.Fatalf("final chunk = %s", last.Raw)
+	}
+	if !StreamFinished([]byte(lines[len(lines)-1])) {
+		t.Fatalf("expected message-end to finish the stream")
+	}
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// defaultImageMimeType is used for remote images whose URL does not reveal their type.
+const defaultImageMimeType = "image/jpeg"
+
+// ImagePartFromURL converts an OpenAI image URL into a Gemini part: base64 data URLs become
+// inlineData and http(s) URLs become fileData. It reports false for anything else.
+func ImagePartFromURL(imageURL string) (string, bool) {
+	if mimeType, data, ok := misc.ParseDataURL(imageURL); ok {
+		part := `{"inlineData":{"mime_type":"","data":""}}`
+		part, _ = sjson.Set(part, "inlineData.mime_type", mimeType)
+		part, _ = sjson.Set(part, "inlineData.data", data)
+		return part, true
+	}
+	if misc.IsRemoteURL(imageURL) {
+		part := `{"fileData":{"mimeType":"","fileUri":""}}`
+		part, _ = sjson.Set(part, "fileData.mimeType", misc.MimeTypeFromURL(imageURL, defaultImageMimeType))
+		part, _ = sjson.Set(part, "fileData.fileUri", imageURL)
+		return part, true
+	}
+	return "", false
+}
+
+// ImagePartFromClaudeSource converts the source of a Claude image block into a Gemini part:
+// base64 sources become inlineData and url sources become fileData. It reports false for
+// sources it cannot represent.
+func ImagePartFromClaudeSource(source gjson.Result) (string, bool) {
+	switch source.Get("type").String() {
+	case "base64":
+		data := source.Get("data").String()
+		if data == "" {
+			return "", false
+		}
+		mimeType := source.Get("media_type").String()
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		part := `{"inlineData":{"mime_type":"","data":""}}`
+		part, _ = sjson.Set(part, "inlineData.mime_type", mimeType)
+		part, _ = sjson.Set(part, "inlineData.data", data)
+		return part, true
+	case "url":
+		return ImagePartFromURL(source.Get("url").String())
+	}
+	return "", false
+}
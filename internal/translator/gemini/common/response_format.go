@@ -0,0 +1,24 @@
+package common
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ApplyOpenAIResponseFormat maps the response_format of an OpenAI request onto the Gemini
+// generation config at path (e.g. "generationConfig" or "request.generationConfig"):
+// json_object asks for a JSON response, and json_schema also passes its schema as
+// responseJsonSchema, which accepts standard JSON Schema.
+func ApplyOpenAIResponseFormat(out, rawJSON []byte, path string) []byte {
+	format := gjson.GetBytes(rawJSON, "response_format")
+	switch format.Get("type").String() {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, path+".responseMimeType", "application/json")
+	case "json_schema":
+		out, _ = sjson.SetBytes(out, path+".responseMimeType", "application/json")
+		if schema := format.Get("json_schema.schema"); schema.IsObject() {
+			out, _ = sjson.SetRawBytes(out, path+".responseJsonSchema", []byte(schema.Raw))
+		}
+	}
+	return out
+}
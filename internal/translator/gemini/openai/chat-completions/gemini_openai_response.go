@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -22,6 +23,29 @@ import (
 type convertGeminiResponseToOpenAIChatParams struct {
 	UnixTimestamp int64
 	FunctionIndex int
+	// headerKey and header cache the chunk template with model, creation time and
+	// response ID set; they rarely change within a stream.
+	headerKey string
+	header    string
+}
+
+// chunkHeader returns the streaming chunk template for the given model version,
+// creation time and response ID, reusing the previous chunk's template when they match.
+func (p *convertGeminiResponseToOpenAIChatParams) chunkHeader(modelVersion gjson.Result, created int64, responseID gjson.Result) string {
+	key := modelVersion.Raw + "|" + strconv.FormatInt(created, 10) + "|" + responseID.Raw
+	if p.header != "" && key == p.headerKey {
+		return p.header
+	}
+	template := `{"id":"","object":"chat.completion.chunk","created":12345,"model":"model","choices":[{"index":0,"delta":{"role":null,"content":null,"reasoning_content":null,"tool_calls":null},"finish_reason":null,"native_finish_reason":null}]}`
+	if modelVersion.Exists() {
+		template, _ = sjson.Set(template, "model", modelVersion.String())
+	}
+	template, _ = sjson.Set(template, "created", created)
+	if responseID.Exists() {
+		template, _ = sjson.Set(template, "id", responseID.String())
+	}
+	p.headerKey, p.header = key, template
+	return template
 }
 
 // functionCallIDCounter provides a process-wide unique counter for function call identifiers.
@@ -57,63 +81,72 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 		return []string{}
 	}
 
-	// Initialize the OpenAI SSE template.
-	template := `{"id":"","object":"chat.completion.chunk","created":12345,"model":"model","choices":[{"index":0,"delta":{"role":null,"content":null,"reasoning_content":null,"tool_calls":null},"finish_reason":null,"native_finish_reason":null}]}`
-
-	// Extract and set the model version.
-	if modelVersionResult := gjson.GetBytes(rawJSON, "modelVersion"); modelVersionResult.Exists() {
-		template, _ = sjson.Set(template, "model", modelVersionResult.String())
-	}
+	// Read the top-level fields in a single pass over the chunk.
+	var modelVersionResult, createTimeResult, responseIDResult, candidatesResult, usageResult gjson.Result
+	gjson.ParseBytes(rawJSON).ForEach(func(key, value gjson.Result) bool {
+		switch key.String() {
+		case "modelVersion":
+			modelVersionResult = value
+		case "createTime":
+			createTimeResult = value
+		case "responseId":
+			responseIDResult = value
+		case "candidates":
+			candidatesResult = value
+		case "usageMetadata":
+			usageResult = value
+		}
+		return true
+	})
+	candidateResult := candidatesResult.Get("0")
 
-	// Extract and set the creation timestamp.
-	if createTimeResult := gjson.GetBytes(rawJSON, "createTime"); createTimeResult.Exists() {
+	// Extract the creation timestamp.
+	if createTimeResult.Exists() {
 		t, err := time.Parse(time.RFC3339Nano, createTimeResult.String())
 		if err == nil {
 			(*param).(*convertGeminiResponseToOpenAIChatParams).UnixTimestamp = t.Unix()
 		}
-		template, _ = sjson.Set(template, "created", (*param).(*convertGeminiResponseToOpenAIChatParams).UnixTimestamp)
-	} else {
-		template, _ = sjson.Set(template, "created", (*param).(*convertGeminiResponseToOpenAIChatParams).UnixTimestamp)
 	}
 
-	// Extract and set the response ID.
-	if responseIDResult := gjson.GetBytes(rawJSON, "responseId"); responseIDResult.Exists() {
-		template, _ = sjson.Set(template, "id", responseIDResult.String())
-	}
+	// Initialize the OpenAI SSE template with the model version, creation time and response ID.
+	template := (*param).(*convertGeminiResponseToOpenAIChatParams).chunkHeader(modelVersionResult, (*param).(*convertGeminiResponseToOpenAIChatParams).UnixTimestamp, responseIDResult)
 
 	// Extract and set the finish reason.
-	if finishReasonResult := gjson.GetBytes(rawJSON, "candidates.0.finishReason"); finishReasonResult.Exists() {
+	if finishReasonResult := candidateResult.Get("finishReason"); finishReasonResult.Exists() {
 		template, _ = sjson.Set(template, "choices.0.finish_reason", strings.ToLower(finishReasonResult.String()))
 		template, _ = sjson.Set(template, "choices.0.native_finish_reason", strings.ToLower(finishReasonResult.String()))
 	}
 
-	// Extract and set usage metadata (token counts).
-	if usageResult := gjson.GetBytes(rawJSON, "usageMetadata"); usageResult.Exists() {
+	// Extract and set usage metadata (token counts). The usage object is built on its own
+	// and inserted once, rather than edited field by field inside the chunk.
+	if usageResult.Exists() {
+		usage := `{}`
 		cachedTokenCount := usageResult.Get("cachedContentTokenCount").Int()
 		if candidatesTokenCountResult := usageResult.Get("candidatesTokenCount"); candidatesTokenCountResult.Exists() {
-			template, _ = sjson.Set(template, "usage.completion_tokens", candidatesTokenCountResult.Int())
+			usage, _ = sjson.Set(usage, "completion_tokens", candidatesTokenCountResult.Int())
 		}
 		if totalTokenCountResult := usageResult.Get("totalTokenCount"); totalTokenCountResult.Exists() {
-			template, _ = sjson.Set(template, "usage.total_tokens", totalTokenCountResult.Int())
+			usage, _ = sjson.Set(usage, "total_tokens", totalTokenCountResult.Int())
 		}
 		promptTokenCount := usageResult.Get("promptTokenCount").Int() - cachedTokenCount
 		thoughtsTokenCount := usageResult.Get("thoughtsTokenCount").Int()
-		template, _ = sjson.Set(template, "usage.prompt_tokens", promptTokenCount+thoughtsTokenCount)
+		usage, _ = sjson.Set(usage, "prompt_tokens", promptTokenCount+thoughtsTokenCount)
 		if thoughtsTokenCount > 0 {
-			template, _ = sjson.Set(template, "usage.completion_tokens_details.reasoning_tokens", thoughtsTokenCount)
+			usage, _ = sjson.Set(usage, "completion_tokens_details.reasoning_tokens", thoughtsTokenCount)
 		}
 		// Include cached token count if present (indicates prompt caching is working)
 		if cachedTokenCount > 0 {
-			var err error
-			template, err = sjson.Set(template, "usage.prompt_tokens_details.cached_tokens", cachedTokenCount)
-			if err != nil {
-				log.Warnf("gemini openai response: failed to set cached_tokens in streaming: %v", err)
-			}
+			usage, _ = sjson.Set(usage, "prompt_tokens_details.cached_tokens", cachedTokenCount)
+		}
+		var err error
+		template, err = sjson.SetRaw(template, "usage", usage)
+		if err != nil {
+			log.Warnf("gemini openai response: failed to set usage in streaming: %v", err)
 		}
 	}
 
 	// Process the main content part of the response.
-	partsResult := gjson.GetBytes(rawJSON, "candidates.0.content.parts")
+	partsResult := candidateResult.Get("content.parts")
 	hasFunctionCall := false
 	if partsResult.IsArray() {
 		partResults := partsResult.Array()
@@ -0,0 +1,91 @@
+package translator
+
+import (
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const imagePartsOpenAIRequest = `{"model":"m","messages":[{"role":"user","content":[
+	{"type":"text","text":"Compare these."},
+	{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgo="}},
+	{"type":"image_url","image_url":{"url":"https://example.com/cat.webp?size=large"}}]}]}`
+
+func TestImageParts_OpenAIToGemini(t *testing.T) {
+	for _, target := range []sdktranslator.Format{sdktranslator.FormatGemini, sdktranslator.FormatGeminiCLI, sdktranslator.FormatAntigravity} {
+		out := sdktranslator.TranslateRequest(sdktranslator.FormatOpenAI, target, "gemini-2.5-pro", []byte(imagePartsOpenAIRequest), false)
+		parts := gjson.GetBytes(out, "contents.0.parts")
+		if target != sdktranslator.FormatGemini {
+			parts = gjson.GetBytes(out, "request.contents.0.parts")
+		}
+		if parts.Get("1.inlineData.mime_type").String() != "image/png" || parts.Get("1.inlineData.data").String() != "iVBORw0KGgo=" {
+			t.Fatalf("%s inline image = %s", target, parts.Raw)
+		}
+		if parts.Get("2.fileData.mimeType").String() != "image/webp" || parts.Get("2.fileData.fileUri").String() != "https://example.com/cat.webp?size=large" {
+			t.Fatalf("%s remote image = %s", target, parts.Raw)
+		}
+	}
+}
+
+func TestImageParts_OpenAIToClaude(t *testing.T) {
+	out := sdktranslator.TranslateRequest(sdktranslator.FormatOpenAI, sdktranslator.FormatClaude, "claude-sonnet-4-5", []byte(imagePartsOpenAIRequest), false)
+	content := gjson.GetBytes(out, "messages.0.content")
+	if content.Get("1.source.type").String() != "base64" || content.Get("1.source.media_type").String() != "image/png" || content.Get("1.source.data").String() != "iVBORw0KGgo=" {
+		t.Fatalf("inline image = %s", content.Raw)
+	}
+	if content.Get("2.source.type").String() != "url" || content.Get("2.source.url").String() != "https://example.com/cat.webp?size=large" {
+		t.Fatalf("remote image = %s", content.Raw)
+	}
+}
+
+func TestImageParts_ClaudeToGemini(t *testing.T) {
+	request := `{"model":"m","max_tokens":64,"messages":[{"role":"user","content":[
+		{"type":"image","source":{"type":"base64","media_type":"image/jpeg","data":"/9j/4AAQ"}},
+		{"type":"image","source":{"type":"url","url":"https://example.com/dog.png"}},
+		{"type":"text","text":"Which is cuter?"}]}]}`
+	for _, target := range []sdktranslator.Format{sdktranslator.FormatGemini, sdktranslator.FormatGeminiCLI, sdktranslator.FormatAntigravity} {
+		out := sdktranslator.TranslateRequest(sdktranslator.FormatClaude, target, "gemini-2.5-pro", []byte(request), false)
+		parts := gjson.GetBytes(out, "contents.0.parts")
+		if target != sdktranslator.FormatGemini {
+			parts = gjson.GetBytes(out, "request.contents.0.parts")
+		}
+		if parts.Get("0.inlineData.mime_type").String() != "image/jpeg" || parts.Get("0.inlineData.data").String() != "/9j/4AAQ" {
+			t.Fatalf("%s inline image = %s", target, parts.Raw)
+		}
+		if parts.Get("1.fileData.mimeType").String() != "image/png" || parts.Get("1.fileData.fileUri").String() != "https://example.com/dog.png" {
+			t.Fatalf("%s remote image = %s", target, parts.Raw)
+		}
+	}
+}
+
+func TestImageParts_GeminiToOpenAIAndClaude(t *testing.T) {
+	request := `{"contents":[{"role":"user","parts":[
+		{"inline_data":{"mime_type":"image/png","data":"iVBORw0KGgo="}},
+		{"fileData":{"mimeType":"image/jpeg","fileUri":"https://example.com/bird.jpg"}},
+		{"text":"What are these?"}]}]}`
+
+	out := sdktranslator.TranslateRequest(sdktranslator.FormatGemini, sdktranslator.FormatOpenAI, "m", []byte(request), false)
+	content := gjson.GetBytes(out, "messages.0.content")
+	if content.Get("0.image_url.url").String() != "data:image/png;base64,iVBORw0KGgo=" || content.Get("1.image_url.url").String() != "https://example.com/bird.jpg" {
+		t.Fatalf("openai content = %s", content.Raw)
+	}
+
+	out = sdktranslator.TranslateRequest(sdktranslator.FormatGemini, sdktranslator.FormatClaude, "claude-sonnet-4-5", []byte(request), false)
+	content = gjson.GetBytes(out, "messages.0.content")
+	if content.Get("0.source.type").String() != "base64" || content.Get("0.source.media_type").String() != "image/png" {
+		t.Fatalf("claude inline image = %s", content.Raw)
+	}
+	if content.Get("1.source.type").String() != "url" || content.Get("1.source.url").String() != "https://example.com/bird.jpg" {
+		t.Fatalf("claude remote image = %s", content.Raw)
+	}
+}
+
+func TestImageParts_ClaudeToCodex(t *testing.T) {
+	request := `{"model":"m","max_tokens":64,"messages":[{"role":"user","content":[
+		{"type":"image","source":{"type":"url","url":"https://example.com/dog.png"}}]}]}`
+	out := sdktranslator.TranslateRequest(sdktranslator.FormatClaude, sdktranslator.FormatCodex, "gpt-5", []byte(request), false)
+	if got := gjson.GetBytes(out, "input.0.content.0.image_url").String(); got != "https://example.com/dog.png" {
+		t.Fatalf("codex input = %s", gjson.GetBytes(out, "input").Raw)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/responses"
 
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/claude"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/cohere"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/gemini"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/gemini-cli"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/chat-completions"
@@ -36,4 +37,8 @@ import (
 
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/claude"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/openai"
+
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/cohere/claude"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/cohere/gemini"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/cohere/openai/chat-completions"
 )
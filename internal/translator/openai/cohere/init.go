@@ -0,0 +1,68 @@
+package cohere
+
+import (
+	"context"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// viaOpenAITargets are the backend formats Cohere clients reach through the OpenAI Chat
+// Completions translators. Cohere backends need no translation.
+var viaOpenAITargets = []string{Claude, Gemini, GeminiCLI, Codex, Antigravity, Kiro}
+
+func init() {
+	translator.Register(
+		Cohere,
+		OpenAI,
+		ConvertCohereRequestToOpenAI,
+		interfaces.TranslateResponse{
+			Stream:    ConvertOpenAIResponseToCohere,
+			NonStream: ConvertOpenAIResponseToCohereNonStream,
+		},
+	)
+	for _, target := range viaOpenAITargets {
+		request, response := viaOpenAI(sdktranslator.FromString(target))
+		translator.Register(Cohere, target, request, response)
+	}
+}
+
+// viaOpenAIParams keeps the state of both stages of a translated stream.
+type viaOpenAIParams struct {
+	openAIRequest []byte
+	target        any
+	cohere        any
+}
+
+// viaOpenAI composes the Cohere<->OpenAI translators with the registered OpenAI<->target
+// translators. The target translators are looked up per call, so registration order does
+// not matter.
+func viaOpenAI(target sdktranslator.Format) (interfaces.TranslateRequestFunc, interfaces.TranslateResponse) {
+	openAI := sdktranslator.FormatOpenAI
+	request := func(modelName string, rawJSON []byte, stream bool) []byte {
+		openAIRequest := ConvertCohereRequestToOpenAI(modelName, rawJSON, stream)
+		return sdktranslator.TranslateRequest(openAI, target, modelName, openAIRequest, stream)
+	}
+	response := interfaces.TranslateResponse{
+		Stream: func(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+			if *param == nil {
+				*param = &viaOpenAIParams{openAIRequest: ConvertCohereRequestToOpenAI(modelName, originalRequestRawJSON, true)}
+			}
+			p := (*param).(*viaOpenAIParams)
+			var results []string
+			for _, chunk := range sdktranslator.TranslateStream(ctx, target, openAI, modelName, p.openAIRequest, requestRawJSON, rawJSON, &p.target) {
+				results = append(results, ConvertOpenAIResponseToCohere(ctx, modelName, originalRequestRawJSON, p.openAIRequest, []byte(chunk), &p.cohere)...)
+			}
+			return results
+		},
+		NonStream: func(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
+			openAIRequest := ConvertCohereRequestToOpenAI(modelName, originalRequestRawJSON, false)
+			var targetParam any
+			openAIResponse := sdktranslator.TranslateNonStream(ctx, target, openAI, modelName, openAIRequest, requestRawJSON, rawJSON, &targetParam)
+			return ConvertOpenAIResponseToCohereNonStream(ctx, modelName, originalRequestRawJSON, openAIRequest, []byte(openAIResponse), nil)
+		},
+	}
+	return request, response
+}
@@ -0,0 +1,204 @@
+// Package cohere provides translation from the Cohere v2 chat API, as an inbound client
+// format, to OpenAI Chat Completions. Other backends are reached through OpenAI Chat
+// Completions as well (see init.go), so the Cohere mapping lives in one place.
+package cohere
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertCohereRequestToOpenAI converts a Cohere v2 chat request into an OpenAI Chat
+// Completions request.
+//
+// Parameters:
+//   - modelName: The name of the model to use for the request
+//   - inputRawJSON: The raw JSON request data from the Cohere API
+//   - stream: A boolean indicating if the request is for a streaming response
+//
+// Returns:
+//   - []byte: The transformed request data in OpenAI Chat Completions format
+func ConvertCohereRequestToOpenAI(modelName string, inputRawJSON []byte, stream bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+	out := `{"model":"","messages":[]}`
+	out, _ = sjson.Set(out, "model", modelName)
+	if stream {
+		out, _ = sjson.Set(out, "stream", true)
+		out, _ = sjson.Set(out, "stream_options.include_usage", true)
+	}
+
+	// Grounding documents have no OpenAI equivalent; they are handed to the model as a
+	// system message so answers can still draw on them.
+	if documents := cohereDocumentsText(root.Get("documents")); documents != "" {
+		msg := `{"role":"system","content":""}`
+		msg, _ = sjson.Set(msg, "content", "Use the following documents when answering.\n\n"+documents)
+		out, _ = sjson.SetRaw(out, "messages.-1", msg)
+	}
+
+	for _, msg := range root.Get("messages").Array() {
+		if converted, ok := convertCohereMessageToOpenAI(msg); ok {
+			out, _ = sjson.SetRaw(out, "messages.-1", converted)
+		}
+	}
+
+	for _, tool := range root.Get("tools").Array() {
+		fn := tool.Get("function")
+		if name := fn.Get("name").String(); name == "" {
+			continue
+		}
+		openAITool := `{"type":"function","function":{}}`
+		openAITool, _ = sjson.SetRaw(openAITool, "function", fn.Raw)
+		out, _ = sjson.SetRaw(out, "tools.-1", openAITool)
+	}
+
+	switch strings.ToUpper(root.Get("tool_choice").String()) {
+	case "REQUIRED":
+		out, _ = sjson.Set(out, "tool_choice", "required")
+	case "NONE":
+		out, _ = sjson.Set(out, "tool_choice", "none")
+	}
+
+	if maxTokens := root.Get("max_tokens"); maxTokens.Exists() {
+		out, _ = sjson.Set(out, "max_tokens", maxTokens.Int())
+	}
+	if temperature := root.Get("temperature"); temperature.Exists() {
+		out, _ = sjson.Set(out, "temperature", temperature.Float())
+	}
+	if topP := root.Get("p"); topP.Exists() {
+		out, _ = sjson.Set(out, "top_p", topP.Float())
+	}
+	if topK := root.Get("k"); topK.Exists() {
+		out, _ = sjson.Set(out, "top_k", topK.Int())
+	}
+	if seed := root.Get("seed"); seed.Exists() {
+		out, _ = sjson.Set(out, "seed", seed.Int())
+	}
+	if penalty := root.Get("frequency_penalty"); penalty.Exists() {
+		out, _ = sjson.Set(out, "frequency_penalty", penalty.Float())
+	}
+	if penalty := root.Get("presence_penalty"); penalty.Exists() {
+		out, _ = sjson.Set(out, "presence_penalty", penalty.Float())
+	}
+	if stop := root.Get("stop_sequences"); stop.IsArray() && len(stop.Array()) > 0 {
+		out, _ = sjson.SetRaw(out, "stop", stop.Raw)
+	}
+
+	if format := root.Get("response_format"); format.Get("type").String() == "json_object" {
+		if schema := format.Get("json_schema"); schema.IsObject() {
+			out, _ = sjson.SetRaw(out, "response_format", `{"type":"json_schema","json_schema":{"name":"response"}}`)
+			out, _ = sjson.SetRaw(out, "response_format.json_schema.schema", schema.Raw)
+		} else {
+			out, _ = sjson.SetRaw(out, "response_format", `{"type":"json_object"}`)
+		}
+	}
+
+	if root.Get("thinking.type").String() == "enabled" {
+		out, _ = sjson.Set(out, "reasoning_effort", "medium")
+	}
+
+	return []byte(out)
+}
+
+// convertCohereMessageToOpenAI maps a single Cohere message onto an OpenAI chat message.
+func convertCohereMessageToOpenAI(msg gjson.Result) (string, bool) {
+	content := msg.Get("content")
+	switch role := msg.Get("role").String(); role {
+	case "system":
+		out := `{"role":"system","content":""}`
+		out, _ = sjson.Set(out, "content", cohereContentText(content))
+		return out, true
+
+	case "user":
+		out := `{"role":"user","content":""}`
+		if !content.IsArray() {
+			out, _ = sjson.Set(out, "content", content.String())
+			return out, true
+		}
+		out, _ = sjson.SetRaw(out, "content", `[]`)
+		for _, part := range content.Array() {
+			switch part.Get("type").String() {
+			case "text":
+				item := `{"type":"text","text":""}`
+				item, _ = sjson.Set(item, "text", part.Get("text").String())
+				out, _ = sjson.SetRaw(out, "content.-1", item)
+			case "image_url":
+				item := `{"type":"image_url","image_url":{"url":""}}`
+				item, _ = sjson.Set(item, "image_url.url", part.Get("image_url.url").String())
+				out, _ = sjson.SetRaw(out, "content.-1", item)
+			}
+		}
+		return out, true
+
+	case "assistant":
+		out := `{"role":"assistant","content":""}`
+		text := cohereContentText(content)
+		if text == "" {
+			// The tool plan is the text Cohere models write before calling tools.
+			text = msg.Get("tool_plan").String()
+		}
+		out, _ = sjson.Set(out, "content", text)
+		for _, call := range msg.Get("tool_calls").Array() {
+			item := `{"id":"","type":"function","function":{"name":"","arguments":""}}`
+			item, _ = sjson.Set(item, "id", call.Get("id").String())
+			item, _ = sjson.Set(item, "function.name", call.Get("function.name").String())
+			args := call.Get("function.arguments").String()
+			if args == "" {
+				args = "{}"
+			}
+			item, _ = sjson.Set(item, "function.arguments", args)
+			out, _ = sjson.SetRaw(out, "tool_calls.-1", item)
+		}
+		return out, true
+
+	case "tool":
+		out := `{"role":"tool","tool_call_id":"","content":""}`
+		out, _ = sjson.Set(out, "tool_call_id", msg.Get("tool_call_id").String())
+		out, _ = sjson.Set(out, "content", cohereContentText(content))
+		return out, true
+	}
+	return "", false
+}
+
+// cohereContentText flattens Cohere string content, text parts and document parts into
+// plain text.
+func cohereContentText(content gjson.Result) string {
+	if !content.IsArray() {
+		return content.String()
+	}
+	var parts []string
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "text":
+			parts = append(parts, part.Get("text").String())
+		case "document":
+			parts = append(parts, cohereDocumentData(part.Get("document")))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// cohereDocumentsText renders the top-level documents of a request, one per paragraph.
+func cohereDocumentsText(documents gjson.Result) string {
+	var parts []string
+	for _, document := range documents.Array() {
+		if text := cohereDocumentData(document); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// cohereDocumentData returns the text of a document: a plain string, or the data object
+// of a structured document as JSON.
+func cohereDocumentData(document gjson.Result) string {
+	if document.Type == gjson.String {
+		return document.String()
+	}
+	data := document.Get("data")
+	if data.Type == gjson.String {
+		return data.String()
+	}
+	return data.Raw
+}
@@ -0,0 +1,231 @@
+package cohere
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+var dataTag = []byte("data:")
+
+// ConvertOpenAIResponseToCohereParams holds state across streaming chunks.
+type ConvertOpenAIResponseToCohereParams struct {
+	started bool
+	// contentIndex and contentType describe the open content block; contentIndex counts
+	// content blocks, -1 before the first.
+	contentIndex int
+	contentType  string
+	contentOpen  bool
+	// toolIndex is the OpenAI index of the open tool call, -1 when none is open.
+	toolIndex int64
+	// finishReason holds the Cohere finish reason until usage is known: OpenAI may
+	// report usage in a chunk of its own after the finish reason.
+	finishReason string
+	ended        bool
+}
+
+// ConvertOpenAIResponseToCohere converts OpenAI Chat Completions chunks to Cohere v2
+// streaming events, each returned as an SSE "data:" line.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - rawJSON: A single OpenAI chunk, with or without the "data:" prefix
+//   - param: A pointer to a parameter object for maintaining state between calls
+//
+// Returns:
+//   - []string: A slice of Cohere SSE data lines
+func ConvertOpenAIResponseToCohere(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+	if *param == nil {
+		*param = &ConvertOpenAIResponseToCohereParams{contentIndex: -1, toolIndex: -1}
+	}
+	p := (*param).(*ConvertOpenAIResponseToCohereParams)
+
+	rawJSON = bytes.TrimSpace(rawJSON)
+	if bytes.HasPrefix(rawJSON, dataTag) {
+		rawJSON = bytes.TrimSpace(rawJSON[len(dataTag):])
+	}
+	if len(rawJSON) == 0 || p.ended {
+		return []string{}
+	}
+	if bytes.Equal(rawJSON, []byte("[DONE]")) {
+		if p.finishReason == "" {
+			return []string{}
+		}
+		return []string{"data: " + p.messageEnd(gjson.Result{})}
+	}
+	root := gjson.ParseBytes(rawJSON)
+
+	var events []string
+	if !p.started {
+		p.started = true
+		start := `{"type":"message-start","id":"","delta":{"message":{"role":"assistant","content":[],"tool_plan":"","tool_calls":[],"citations":[]}}}`
+		start, _ = sjson.Set(start, "id", root.Get("id").String())
+		events = append(events, start)
+	}
+
+	for _, choice := range root.Get("choices").Array() {
+		delta := choice.Get("delta")
+		if reasoning := delta.Get("reasoning_content").String(); reasoning != "" {
+			events = append(events, p.contentDelta("thinking", reasoning)...)
+		}
+		if text := delta.Get("content").String(); text != "" {
+			events = append(events, p.contentDelta("text", text)...)
+		}
+		for _, call := range delta.Get("tool_calls").Array() {
+			index := call.Get("index").Int()
+			if index != p.toolIndex {
+				events = append(events, p.closeContent()...)
+				events = append(events, p.closeToolCall()...)
+				p.toolIndex = index
+				start := `{"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"","type":"function","function":{"name":"","arguments":""}}}}}`
+				start, _ = sjson.Set(start, "index", index)
+				start, _ = sjson.Set(start, "delta.message.tool_calls.id", call.Get("id").String())
+				start, _ = sjson.Set(start, "delta.message.tool_calls.function.name", call.Get("function.name").String())
+				start, _ = sjson.Set(start, "delta.message.tool_calls.function.arguments", call.Get("function.arguments").String())
+				events = append(events, start)
+				continue
+			}
+			if args := call.Get("function.arguments").String(); args != "" {
+				item := `{"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":""}}}}}`
+				item, _ = sjson.Set(item, "index", index)
+				item, _ = sjson.Set(item, "delta.message.tool_calls.function.arguments", args)
+				events = append(events, item)
+			}
+		}
+
+		if finish := choice.Get("finish_reason").String(); finish != "" {
+			events = append(events, p.closeContent()...)
+			events = append(events, p.closeToolCall()...)
+			p.finishReason = mapOpenAIFinishReasonToCohere(finish)
+		}
+	}
+	if usage := root.Get("usage"); usage.IsObject() && p.finishReason != "" {
+		events = append(events, p.messageEnd(usage))
+	}
+
+	for i := range events {
+		events[i] = "data: " + events[i]
+	}
+	return events
+}
+
+func (p *ConvertOpenAIResponseToCohereParams) messageEnd(usage gjson.Result) string {
+	p.ended = true
+	end := `{"type":"message-end","delta":{"finish_reason":""}}`
+	end, _ = sjson.Set(end, "delta.finish_reason", p.finishReason)
+	if usage.IsObject() {
+		end = setCohereUsage(end, "delta.usage", usage)
+	}
+	return end
+}
+
+func (p *ConvertOpenAIResponseToCohereParams) contentDelta(kind, text string) []string {
+	var events []string
+	if !p.contentOpen || p.contentType != kind {
+		events = append(events, p.closeContent()...)
+		events = append(events, p.closeToolCall()...)
+		p.contentIndex++
+		p.contentType = kind
+		p.contentOpen = true
+		start := fmt.Sprintf(`{"type":"content-start","index":%d,"delta":{"message":{"content":{"type":"","%s":""}}}}`, p.contentIndex, kind)
+		start, _ = sjson.Set(start, "delta.message.content.type", kind)
+		events = append(events, start)
+	}
+	item := fmt.Sprintf(`{"type":"content-delta","index":%d,"delta":{"message":{"content":{"%s":""}}}}`, p.contentIndex, kind)
+	item, _ = sjson.Set(item, "delta.message.content."+kind, text)
+	return append(events, item)
+}
+
+func (p *ConvertOpenAIResponseToCohereParams) closeContent() []string {
+	if !p.contentOpen {
+		return nil
+	}
+	p.contentOpen = false
+	return []string{fmt.Sprintf(`{"type":"content-end","index":%d}`, p.contentIndex)}
+}
+
+func (p *ConvertOpenAIResponseToCohereParams) closeToolCall() []string {
+	if p.toolIndex < 0 {
+		return nil
+	}
+	index := p.toolIndex
+	p.toolIndex = -1
+	return []string{fmt.Sprintf(`{"type":"tool-call-end","index":%d}`, index)}
+}
+
+// ConvertOpenAIResponseToCohereNonStream converts an OpenAI Chat Completions response to
+// a Cohere v2 chat response.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - rawJSON: The raw JSON response from the OpenAI API
+//   - param: Unused for non-streaming conversion
+//
+// Returns:
+//   - string: A Cohere v2 chat JSON response
+func ConvertOpenAIResponseToCohereNonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
+	root := gjson.ParseBytes(rawJSON)
+	out := `{"id":"","finish_reason":"COMPLETE","message":{"role":"assistant","content":[]}}`
+	out, _ = sjson.Set(out, "id", root.Get("id").String())
+
+	message := root.Get("choices.0.message")
+	if reasoning := message.Get("reasoning_content").String(); reasoning != "" {
+		item := `{"type":"thinking","thinking":""}`
+		item, _ = sjson.Set(item, "thinking", reasoning)
+		out, _ = sjson.SetRaw(out, "message.content.-1", item)
+	}
+	text := message.Get("content").String()
+	calls := message.Get("tool_calls").Array()
+	switch {
+	case len(calls) > 0 && text != "":
+		// Cohere carries the text preceding tool calls as the tool plan.
+		out, _ = sjson.Set(out, "message.tool_plan", text)
+	case text != "":
+		item := `{"type":"text","text":""}`
+		item, _ = sjson.Set(item, "text", text)
+		out, _ = sjson.SetRaw(out, "message.content.-1", item)
+	}
+	for _, call := range calls {
+		item := `{"id":"","type":"function","function":{"name":"","arguments":""}}`
+		item, _ = sjson.Set(item, "id", call.Get("id").String())
+		item, _ = sjson.Set(item, "function.name", call.Get("function.name").String())
+		item, _ = sjson.Set(item, "function.arguments", call.Get("function.arguments").String())
+		out, _ = sjson.SetRaw(out, "message.tool_calls.-1", item)
+	}
+
+	out, _ = sjson.Set(out, "finish_reason", mapOpenAIFinishReasonToCohere(root.Get("choices.0.finish_reason").String()))
+	if usage := root.Get("usage"); usage.IsObject() {
+		out = setCohereUsage(out, "usage", usage)
+	}
+	return out
+}
+
+// setCohereUsage writes a Cohere usage object at path from OpenAI usage fields.
+func setCohereUsage(out, path string, usage gjson.Result) string {
+	input, output := usage.Get("prompt_tokens").Int(), usage.Get("completion_tokens").Int()
+	for _, key := range []string{"billed_units", "tokens"} {
+		out, _ = sjson.Set(out, path+"."+key+".input_tokens", input)
+		out, _ = sjson.Set(out, path+"."+key+".output_tokens", output)
+	}
+	return out
+}
+
+// mapOpenAIFinishReasonToCohere maps OpenAI finish reasons to Cohere finish reasons.
+func mapOpenAIFinishReasonToCohere(reason string) string {
+	switch strings.ToLower(reason) {
+	case "length":
+		return "MAX_TOKENS"
+	case "tool_calls", "function_call":
+		return "TOOL_CALL"
+	case "content_filter":
+		return "ERROR_TOXIC"
+	default:
+		return "COMPLETE"
+	}
+}
@@ -0,0 +1,96 @@
+package cohere
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertCohereRequestToOpenAI(t *testing.T) {
+	raw := []byte(`{
+		"model":"command-r",
+		"documents":[{"data":{"title":"Paris","snippet":"Sunny"}}],
+		"messages":[
+			{"role":"system","content":"be brief"},
+			{"role":"user","content":[{"type":"text","text":"weather?"}]},
+			{"role":"assistant","tool_plan":"I will look it up.","tool_calls":[{"id":"tc_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},
+			{"role":"tool","tool_call_id":"tc_1","content":[{"type":"document","document":{"data":"clear"}}]}
+		],
+		"tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object"}}}],
+		"tool_choice":"REQUIRED",
+		"p":0.9,
+		"k":40,
+		"stop_sequences":["END"],
+		"response_format":{"type":"json_object","json_schema":{"type":"object"}}
+	}`)
+	req := gjson.ParseBytes(ConvertCohereRequestToOpenAI("command-r", raw, true))
+
+	if !req.Get("stream").Bool() || !req.Get("stream_options.include_usage").Bool() {
+		t.Fatalf("stream settings = %s", req.Raw)
+	}
+	messages := req.Get("messages").Array()
+	if len(messages) != 5 {
+		t.Fatalf("messages = %s", req.Get("messages").Raw)
+	}
+	if messages[0].Get("role").String() != "system" || !strings.Contains(messages[0].Get("content").String(), "Sunny") {
+		t.Fatalf("documents message = %s", messages[0].Raw)
+	}
+	if messages[3].Get("content").String() != "I will look it up." || messages[3].Get("tool_calls.0.function.name").String() != "get_weather" {
+		t.Fatalf("assistant message = %s", messages[3].Raw)
+	}
+	if messages[4].Get("tool_call_id").String() != "tc_1" || messages[4].Get("content").String() != "clear" {
+		t.Fatalf("tool message = %s", messages[4].Raw)
+	}
+	if req.Get("tool_choice").String() != "required" || req.Get("top_p").Float() != 0.9 || req.Get("top_k").Int() != 40 || req.Get("stop.0").String() != "END" {
+		t.Fatalf("settings = %s", req.Raw)
+	}
+	if req.Get("response_format.type").String() != "json_schema" || req.Get("response_format.json_schema.schema.type").String() != "object" {
+		t.Fatalf("response_format = %s", req.Get("response_format").Raw)
+	}
+}
+
+func TestConvertOpenAIResponseToCohere(t *testing.T) {
+	var param any
+	var events []gjson.Result
+	for _, chunk := range []string{
+		`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"}}]}`,
+		`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"tc_1","function":{"name":"f","arguments":"{\"x\":"}}]}}]}`,
+		`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]},"finish_reason":"tool_calls"}]}`,
+		`data: {"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":4}}`,
+		`data: [DONE]`,
+	} {
+		for _, line := range ConvertOpenAIResponseToCohere(context.Background(), "m", nil, nil, []byte(chunk), &param) {
+			if !strings.HasPrefix(line, "data: ") {
+				t.Fatalf("event without data prefix: %q", line)
+			}
+			events = append(events, gjson.Parse(strings.TrimPrefix(line, "data: ")))
+		}
+	}
+
+	want := []string{"message-start", "content-start", "content-delta", "content-end", "tool-call-start", "tool-call-delta", "tool-call-end", "message-end"}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.Get("type").String() != want[i] {
+			t.Fatalf("event %d = %s, want %s", i, e.Get("type").String(), want[i])
+		}
+	}
+	end := events[len(events)-1]
+	if end.Get("delta.finish_reason").String() != "TOOL_CALL" || end.Get("delta.usage.tokens.output_tokens").Int() != 4 {
+		t.Fatalf("message-end = %s", end.Raw)
+	}
+}
+
+func TestConvertOpenAIResponseToCohereNonStream(t *testing.T) {
+	completion := []byte(`{"id":"chatcmpl-2","choices":[{"message":{"role":"assistant","content":"Let me check.","tool_calls":[{"id":"tc_2","type":"function","function":{"name":"get_weather","arguments":"{}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`)
+	out := gjson.Parse(ConvertOpenAIResponseToCohereNonStream(context.Background(), "m", nil, nil, completion, nil))
+	if out.Get("message.tool_plan").String() != "Let me check." || out.Get("message.tool_calls.0.id").String() != "tc_2" {
+		t.Fatalf("message = %s", out.Get("message").Raw)
+	}
+	if out.Get("finish_reason").String() != "TOOL_CALL" || out.Get("usage.billed_units.input_tokens").Int() != 10 {
+		t.Fatalf("response = %s", out.Raw)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -65,6 +66,11 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 			out, _ = sjson.Set(out, "top_k", topK.Int())
 		}
 
+		// Seed
+		if seed := genConfig.Get("seed"); seed.Exists() {
+			out, _ = sjson.Set(out, "seed", seed.Int())
+		}
+
 		// Stop sequences
 		if stopSequences := genConfig.Get("stopSequences"); stopSequences.Exists() && stopSequences.IsArray() {
 			var stops []string
@@ -77,6 +83,17 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 			}
 		}
 
+		// Structured output: responseMimeType/responseJsonSchema -> response_format
+		if schema := genConfig.Get("responseJsonSchema"); schema.IsObject() {
+			out, _ = sjson.SetRaw(out, "response_format", `{"type":"json_schema","json_schema":{"name":"response"}}`)
+			out, _ = sjson.SetRaw(out, "response_format.json_schema.schema", schema.Raw)
+		} else if schema := genConfig.Get("responseSchema"); schema.IsObject() {
+			out, _ = sjson.SetRaw(out, "response_format", `{"type":"json_schema","json_schema":{"name":"response"}}`)
+			out, _ = sjson.SetRaw(out, "response_format.json_schema.schema", lowerSchemaTypes(schema.Raw))
+		} else if genConfig.Get("responseMimeType").String() == "application/json" {
+			out, _ = sjson.SetRaw(out, "response_format", `{"type":"json_object"}`)
+		}
+
 		// Convert thinkingBudget to reasoning_effort
 		// Always perform conversion to support allowCompat models that may not be in registry
 		if thinkingConfig := genConfig.Get("thinkingConfig"); thinkingConfig.Exists() && thinkingConfig.IsObject() {
@@ -93,7 +110,7 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	out, _ = sjson.Set(out, "stream", stream)
 
 	// Process contents (Gemini messages) -> OpenAI messages
-	var toolCallIDs []string // Track tool call IDs for matching with tool results
+	var pendingToolCalls sdktranslator.PendingToolCalls // Tool calls awaiting their results
 
 	// System instruction -> OpenAI system message
 	// Gemini may provide `systemInstruction` or `system_instruction`; support both keys.
@@ -116,15 +133,8 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 					hasContent = true
 				}
 
-				// Handle inline data (e.g., images)
-				if inlineData := part.Get("inlineData"); inlineData.Exists() {
-					mimeType := inlineData.Get("mimeType").String()
-					if mimeType == "" {
-						mimeType = "application/octet-stream"
-					}
-					data := inlineData.Get("data").String()
-					imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
-
+				// Handle inline and file data (e.g., images)
+				if imageURL, ok := geminiPartImageURL(part); ok {
 					contentPart := `{"type":"image_url","image_url":{"url":""}}`
 					contentPart, _ = sjson.Set(contentPart, "image_url.url", imageURL)
 					msg, _ = sjson.SetRaw(msg, "content.-1", contentPart)
@@ -171,17 +181,10 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 						contentPartsCount++
 					}
 
-					// Handle inline data (e.g., images)
-					if inlineData := part.Get("inlineData"); inlineData.Exists() {
+					// Handle inline and file data (e.g., images)
+					if imageURL, ok := geminiPartImageURL(part); ok {
 						onlyTextContent = false
 
-						mimeType := inlineData.Get("mimeType").String()
-						if mimeType == "" {
-							mimeType = "application/octet-stream"
-						}
-						data := inlineData.Get("data").String()
-						imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
-
 						contentPart := `{"type":"image_url","image_url":{"url":""}}`
 						contentPart, _ = sjson.Set(contentPart, "image_url.url", imageURL)
 						contentWrapper, _ = sjson.SetRaw(contentWrapper, "arr.-1", contentPart)
@@ -190,8 +193,11 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 
 					// Handle function calls (Gemini) -> tool calls (OpenAI)
 					if functionCall := part.Get("functionCall"); functionCall.Exists() {
-						toolCallID := genToolCallID()
-						toolCallIDs = append(toolCallIDs, toolCallID)
+						toolCallID := functionCall.Get("id").String()
+						if toolCallID == "" {
+							toolCallID = genToolCallID()
+						}
+						pendingToolCalls.Add(toolCallID, functionCall.Get("name").String())
 
 						toolCall := `{"id":"","type":"function","function":{"name":"","arguments":""}}`
 						toolCall, _ = sjson.Set(toolCall, "id", toolCallID)
@@ -222,16 +228,13 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 							}
 						}
 
-						// Try to match with previous tool call ID
-						_ = functionResponse.Get("name").String() // functionName not used for now
-						if len(toolCallIDs) > 0 {
-							// Use the last tool call ID (simple matching by function name)
-							// In a real implementation, you might want more sophisticated matching
-							toolMsg, _ = sjson.Set(toolMsg, "tool_call_id", toolCallIDs[len(toolCallIDs)-1])
-						} else {
+						// Pair the response with the call it answers
+						toolCallID, ok := pendingToolCalls.Match(functionResponse.Get("id").String(), functionResponse.Get("name").String())
+						if !ok {
 							// Generate a tool call ID if none available
-							toolMsg, _ = sjson.Set(toolMsg, "tool_call_id", genToolCallID())
+							toolCallID = genToolCallID()
 						}
+						toolMsg, _ = sjson.Set(toolMsg, "tool_call_id", toolCallID)
 
 						out, _ = sjson.SetRaw(out, "messages.-1", toolMsg)
 					}
@@ -300,3 +303,62 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 
 	return []byte(out)
 }
+
+// lowerSchemaTypes rewrites the upper-case types of a Gemini responseSchema ("OBJECT",
+// "STRING", ...) to the lower-case names JSON Schema uses.
+func lowerSchemaTypes(schema string) string {
+	var walk func(path string, node gjson.Result)
+	walk = func(path string, node gjson.Result) {
+		if !node.IsObject() && !node.IsArray() {
+			return
+		}
+		node.ForEach(func(key, value gjson.Result) bool {
+			childPath := strings.ReplaceAll(key.String(), ".", `\.`)
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			if node.IsObject() && key.String() == "type" && value.Type == gjson.String {
+				schema, _ = sjson.Set(schema, childPath, strings.ToLower(value.String()))
+				return true
+			}
+			walk(childPath, value)
+			return true
+		})
+	}
+	walk("", gjson.Parse(schema))
+	return schema
+}
+
+// geminiPartImageURL returns the OpenAI image URL for a Gemini part carrying inline or file
+// data: a base64 data URL for inline data and the file URI for file data. Both the
+// camelCase and snake_case spellings Gemini accepts are recognized.
+func geminiPartImageURL(part gjson.Result) (string, bool) {
+	inlineData := part.Get("inlineData")
+	if !inlineData.Exists() {
+		inlineData = part.Get("inline_data")
+	}
+	if inlineData.Exists() {
+		mimeType := inlineData.Get("mimeType").String()
+		if mimeType == "" {
+			mimeType = inlineData.Get("mime_type").String()
+		}
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		return fmt.Sprintf("data:%s;base64,%s", mimeType, inlineData.Get("data").String()), true
+	}
+	fileData := part.Get("fileData")
+	if !fileData.Exists() {
+		fileData = part.Get("file_data")
+	}
+	if fileData.Exists() {
+		uri := fileData.Get("fileUri").String()
+		if uri == "" {
+			uri = fileData.Get("file_uri").String()
+		}
+		if uri != "" {
+			return uri, true
+		}
+	}
+	return "", false
+}
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -211,8 +212,16 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 
 				// If we have accumulated tool calls, output them now
 				if len((*param).(*ConvertOpenAIResponseToGeminiParams).ToolCallsAccumulator) > 0 {
+					accumulators := (*param).(*ConvertOpenAIResponseToGeminiParams).ToolCallsAccumulator
+					indexes := make([]int, 0, len(accumulators))
+					for index := range accumulators {
+						indexes = append(indexes, index)
+					}
+					// Emit parallel calls in the order the model made them
+					sort.Ints(indexes)
 					partIndex := 0
-					for _, accumulator := range (*param).(*ConvertOpenAIResponseToGeminiParams).ToolCallsAccumulator {
+					for _, index := range indexes {
+						accumulator := accumulators[index]
 						namePath := fmt.Sprintf("candidates.0.content.parts.%d.functionCall.name", partIndex)
 						argsPath := fmt.Sprintf("candidates.0.content.parts.%d.functionCall.args", partIndex)
 						template, _ = sjson.Set(template, namePath, accumulator.Name)
@@ -0,0 +1,78 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestClaudeToOpenAIStream_MultiBlock(t *testing.T) {
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Need weather."}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Checking."}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_a","name":"get_weather","input":{}}}`,
+		`data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":""}}`,
+		`data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`data: {"type":"content_block_stop","index":2}`,
+		`data: {"type":"content_block_start","index":3,"content_block":{"type":"tool_use","id":"toolu_b","name":"get_time","input":{}}}`,
+		`data: {"type":"content_block_stop","index":3}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}`,
+		`data: {"type":"message_stop"}`,
+	}
+	request := []byte(`{"model":"m","messages":[{"role":"user","content":"Weather and time in Paris?"}],"stream":true}`)
+	stream := sdktranslator.NewStreamTranslator(context.Background(), sdktranslator.FormatClaude, sdktranslator.FormatOpenAI, "m", request, request)
+
+	type toolCall struct{ id, name, arguments string }
+	var calls []*toolCall
+	var roles int
+	var reasoning, content, finishReason string
+	for _, event := range events {
+		for _, chunk := range stream.Translate([]byte(event)) {
+			delta := gjson.Get(chunk, "choices.0.delta")
+			if delta.Get("role").Exists() {
+				roles++
+			}
+			reasoning += delta.Get("reasoning_content").String()
+			content += delta.Get("content").String()
+			for _, call := range delta.Get("tool_calls").Array() {
+				index := int(call.Get("index").Int())
+				if index == len(calls) {
+					calls = append(calls, &toolCall{})
+				}
+				if index >= len(calls) {
+					t.Fatalf("tool call index %d skips ahead of %d calls: %s", index, len(calls), chunk)
+				}
+				if id := call.Get("id").String(); id != "" {
+					calls[index].id = id
+					calls[index].name = call.Get("function.name").String()
+				}
+				calls[index].arguments += call.Get("function.arguments").String()
+			}
+			if reason := gjson.Get(chunk, "choices.0.finish_reason").String(); reason != "" {
+				finishReason = reason
+			}
+		}
+	}
+
+	if roles != 1 || reasoning != "Need weather." || content != "Checking." || finishReason != "tool_calls" {
+		t.Fatalf("roles = %d, reasoning = %q, content = %q, finish_reason = %q", roles, reasoning, content, finishReason)
+	}
+	want := []toolCall{{"toolu_a", "get_weather", `{"city":"Paris"}`}, {"toolu_b", "get_time", "{}"}}
+	if len(calls) != len(want) {
+		t.Fatalf("tool calls = %d, want %d", len(calls), len(want))
+	}
+	for i, call := range calls {
+		if *call != want[i] {
+			t.Fatalf("tool call %d = %+v, want %+v", i, *call, want[i])
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const structuredOutputRequest = `{"model":"m","messages":[{"role":"user","content":"Weather in Paris?"}],
+	"response_format":{"type":"json_schema","json_schema":{"name":"weather","strict":true,
+	"schema":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}}}`
+
+func TestStructuredOutput_OpenAIToGemini(t *testing.T) {
+	for _, target := range []sdktranslator.Format{sdktranslator.FormatGemini, sdktranslator.FormatGeminiCLI} {
+		out := sdktranslator.TranslateRequest(sdktranslator.FormatOpenAI, target, "gemini-2.5-pro", []byte(structuredOutputRequest), false)
+		config := gjson.GetBytes(out, "generationConfig")
+		if target == sdktranslator.FormatGeminiCLI {
+			config = gjson.GetBytes(out, "request.generationConfig")
+		}
+		if config.Get("responseMimeType").String() != "application/json" || config.Get("responseJsonSchema.required.0").String() != "city" {
+			t.Fatalf("%s generationConfig = %s", target, config.Raw)
+		}
+	}
+}
+
+func TestStructuredOutput_GeminiToOpenAI(t *testing.T) {
+	request := `{"contents":[{"role":"user","parts":[{"text":"hi"}]}],"generationConfig":{"responseMimeType":"application/json",
+		"responseSchema":{"type":"OBJECT","properties":{"city":{"type":"STRING"}}}}}`
+	out := sdktranslator.TranslateRequest(sdktranslator.FormatGemini, sdktranslator.FormatOpenAI, "gpt-4o", []byte(request), false)
+	format := gjson.GetBytes(out, "response_format")
+	if format.Get("type").String() != "json_schema" || format.Get("json_schema.schema.type").String() != "object" || format.Get("json_schema.schema.properties.city.type").String() != "string" {
+		t.Fatalf("response_format = %s", format.Raw)
+	}
+
+	request = `{"contents":[{"role":"user","parts":[{"text":"hi"}]}],"generationConfig":{"responseMimeType":"application/json"}}`
+	out = sdktranslator.TranslateRequest(sdktranslator.FormatGemini, sdktranslator.FormatOpenAI, "gpt-4o", []byte(request), false)
+	if got := gjson.GetBytes(out, "response_format.type").String(); got != "json_object" {
+		t.Fatalf("response_format.type = %q, want json_object", got)
+	}
+}
+
+func TestStructuredOutput_OpenAIToClaude(t *testing.T) {
+	out := sdktranslator.TranslateRequest(sdktranslator.FormatOpenAI, sdktranslator.FormatClaude, "claude-sonnet-4-5", []byte(structuredOutputRequest), false)
+	if gjson.GetBytes(out, "tools.0.name").String() != "structured_output" || gjson.GetBytes(out, "tools.0.input_schema.required.0").String() != "city" {
+		t.Fatalf("tools = %s", gjson.GetBytes(out, "tools").Raw)
+	}
+	if gjson.GetBytes(out, "tool_choice.name").String() != "structured_output" {
+		t.Fatalf("tool_choice = %s", gjson.GetBytes(out, "tool_choice").Raw)
+	}
+
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`,
+		`data: {"type":"message_stop"}`,
+	}
+	var body string
+	for _, event := range events {
+		body += event + "\n"
+	}
+	resp := gjson.Parse(sdktranslator.TranslateNonStream(context.Background(), sdktranslator.FormatClaude, sdktranslator.FormatOpenAI, "m", []byte(structuredOutputRequest), out, []byte(body), nil))
+	if resp.Get("choices.0.message.content").String() != `{"city":"Paris"}` || resp.Get("choices.0.message.tool_calls").Exists() || resp.Get("choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("non-stream response = %s", resp.Raw)
+	}
+
+	var param any
+	var content, finishReason string
+	for _, event := range events {
+		for _, chunk := range sdktranslator.TranslateStream(context.Background(), sdktranslator.FormatClaude, sdktranslator.FormatOpenAI, "m", []byte(structuredOutputRequest), out, []byte(event), &param) {
+			choice := gjson.Get(chunk, "choices.0")
+			if choice.Get("delta.tool_calls").Exists() {
+				t.Fatalf("structured output must not be streamed as a tool call: %s", chunk)
+			}
+			content += choice.Get("delta.content").String()
+			if reason := choice.Get("finish_reason").String(); reason != "" {
+				finishReason = reason
+			}
+		}
+	}
+	if content != `{"city":"Paris"}` || finishReason != "stop" {
+		t.Fatalf("stream content = %q, finish_reason = %q", content, finishReason)
+	}
+}
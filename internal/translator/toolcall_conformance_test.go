@@ -0,0 +1,365 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// The tool-call conformance harness replays golden fixtures from testdata/toolcalls through
+// every registered client/provider pair and checks that parallel tool calls keep their
+// order, names, arguments and ids, and that tool results stay paired with their calls:
+//
+//   - request_<client>.json: a client request whose history holds two parallel tool calls
+//     and their results.
+//   - response_<provider>.json: the provider's reply with two parallel tool calls, both as
+//     a non-streaming body and as the stream events its executor hands to translators,
+//     arguments split across deltas where the format streams them.
+//   - expected.json: the calls and results every fixture encodes.
+
+var (
+	toolCallClients   = []sdktranslator.Format{sdktranslator.FormatOpenAI, sdktranslator.FormatClaude, sdktranslator.FormatGemini, sdktranslator.FormatOpenAIResponse}
+	toolCallProviders = []sdktranslator.Format{sdktranslator.FormatOpenAI, sdktranslator.FormatClaude, sdktranslator.FormatGemini, sdktranslator.FormatCodex}
+)
+
+type conformanceCall struct {
+	ID        string
+	Name      string
+	Arguments any
+}
+
+type conformanceResult struct {
+	ID      string
+	Name    string
+	Content string
+}
+
+type conformanceExpected struct {
+	Calls []struct {
+		ID        string          `json:"id"`
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"calls"`
+	Results []string `json:"results"`
+}
+
+// conformanceResponse holds what a provider's executor hands to the response translators.
+// NonStream is a JSON body, or a JSON string holding raw text, e.g. the SSE body Claude
+// returns to non-streaming requests the executor sends as streams.
+type conformanceResponse struct {
+	NonStream json.RawMessage `json:"non_stream"`
+	Stream    []string        `json:"stream"`
+}
+
+func (r conformanceResponse) nonStreamBody() []byte {
+	var text string
+	if json.Unmarshal(r.NonStream, &text) == nil {
+		return []byte(text)
+	}
+	return r.NonStream
+}
+
+// carriesToolCallIDs reports whether a format identifies tool calls by id. Gemini pairs
+// calls and results by name and order, and its ids are optional.
+func carriesToolCallIDs(format sdktranslator.Format) bool {
+	return format != sdktranslator.FormatGemini
+}
+
+func TestToolCallConformance(t *testing.T) {
+	var expected conformanceExpected
+	readFixture(t, "expected.json", &expected)
+
+	for _, client := range toolCallClients {
+		request := readRawFixture(t, "request_"+string(client)+".json")
+		for _, provider := range toolCallProviders {
+			if client == provider || !sdktranslator.HasResponseTransformer(client, provider) {
+				continue
+			}
+			var response conformanceResponse
+			readFixture(t, "response_"+string(provider)+".json", &response)
+			client, provider := client, provider
+
+			t.Run(string(client)+"->"+string(provider), func(t *testing.T) {
+				translated := sdktranslator.TranslateRequest(client, provider, "test-model", request, false)
+
+				t.Run("request", func(t *testing.T) {
+					calls, results := conformanceRequestToolCalls(provider, translated)
+					checkCalls(t, expected, calls, carriesToolCallIDs(client) && carriesToolCallIDs(provider), carriesToolCallIDs(provider))
+					if len(results) != len(expected.Results) {
+						t.Fatalf("got %d tool results, want %d: %s", len(results), len(expected.Results), translated)
+					}
+					for i, result := range results {
+						if !strings.Contains(result.Content, expected.Results[i]) {
+							t.Errorf("result %d = %q, want it to contain %q", i, result.Content, expected.Results[i])
+						}
+						if carriesToolCallIDs(provider) && result.ID != calls[i].ID {
+							t.Errorf("result %d is paired with %q, want %q", i, result.ID, calls[i].ID)
+						}
+						if !carriesToolCallIDs(provider) && result.Name != calls[i].Name {
+							t.Errorf("result %d is paired with %q, want %q", i, result.Name, calls[i].Name)
+						}
+					}
+				})
+
+				wantIDs := carriesToolCallIDs(client) && carriesToolCallIDs(provider)
+				t.Run("non-stream", func(t *testing.T) {
+					var param any
+					out := sdktranslator.TranslateNonStream(context.Background(), provider, client, "test-model", request, translated, response.nonStreamBody(), &param)
+					checkCalls(t, expected, conformanceResponseToolCalls(client, []string{out}), wantIDs, carriesToolCallIDs(client))
+				})
+
+				t.Run("stream", func(t *testing.T) {
+					// Streaming clients say so in the body, except Gemini's, which use a
+					// separate endpoint.
+					streamRequest := request
+					if client != sdktranslator.FormatGemini {
+						streamRequest, _ = sjson.SetBytes(request, "stream", true)
+					}
+					streamTranslated := sdktranslator.TranslateRequest(client, provider, "test-model", streamRequest, true)
+					var param any
+					var out []string
+					for _, chunk := range response.Stream {
+						out = append(out, sdktranslator.TranslateStream(context.Background(), provider, client, "test-model", streamRequest, streamTranslated, []byte(chunk), &param)...)
+					}
+					checkCalls(t, expected, conformanceResponseToolCalls(client, out), wantIDs, carriesToolCallIDs(client))
+				})
+			})
+		}
+	}
+}
+
+// checkCalls compares calls with the expected ones in order. exactIDs requires the fixture
+// ids to survive; requireIDs only requires distinct, non-empty ids.
+func checkCalls(t *testing.T, expected conformanceExpected, calls []conformanceCall, exactIDs, requireIDs bool) {
+	t.Helper()
+	if len(calls) != len(expected.Calls) {
+		t.Fatalf("got %d tool calls, want %d: %+v", len(calls), len(expected.Calls), calls)
+	}
+	seen := make(map[string]bool)
+	for i, call := range calls {
+		want := expected.Calls[i]
+		var wantArgs any
+		_ = json.Unmarshal(want.Arguments, &wantArgs)
+		if call.Name != want.Name || !reflect.DeepEqual(call.Arguments, wantArgs) {
+			t.Errorf("call %d = %s(%v), want %s(%s)", i, call.Name, call.Arguments, want.Name, want.Arguments)
+		}
+		switch {
+		case exactIDs && call.ID != want.ID:
+			t.Errorf("call %d id = %q, want %q", i, call.ID, want.ID)
+		case requireIDs && (call.ID == "" || seen[call.ID]):
+			t.Errorf("call %d id %q is empty or not unique", i, call.ID)
+		}
+		seen[call.ID] = true
+	}
+}
+
+// conformanceRequestToolCalls extracts the tool calls and results of a provider request.
+func conformanceRequestToolCalls(format sdktranslator.Format, raw []byte) ([]conformanceCall, []conformanceResult) {
+	root := gjson.ParseBytes(raw)
+	var calls []conformanceCall
+	var results []conformanceResult
+	switch format {
+	case sdktranslator.FormatOpenAI:
+		for _, msg := range root.Get("messages").Array() {
+			for _, call := range msg.Get("tool_calls").Array() {
+				calls = append(calls, conformanceCall{ID: call.Get("id").String(), Name: call.Get("function.name").String(), Arguments: parseArguments(call.Get("function.arguments"))})
+			}
+			if msg.Get("role").String() == "tool" {
+				results = append(results, conformanceResult{ID: msg.Get("tool_call_id").String(), Content: msg.Get("content").Raw})
+			}
+		}
+	case sdktranslator.FormatClaude:
+		for _, msg := range root.Get("messages").Array() {
+			for _, part := range msg.Get("content").Array() {
+				switch part.Get("type").String() {
+				case "tool_use":
+					calls = append(calls, conformanceCall{ID: part.Get("id").String(), Name: part.Get("name").String(), Arguments: parseArguments(part.Get("input"))})
+				case "tool_result":
+					results = append(results, conformanceResult{ID: part.Get("tool_use_id").String(), Content: part.Get("content").Raw})
+				}
+			}
+		}
+	case sdktranslator.FormatGemini:
+		for _, content := range root.Get("contents").Array() {
+			for _, part := range content.Get("parts").Array() {
+				if call := part.Get("functionCall"); call.Exists() {
+					calls = append(calls, conformanceCall{ID: call.Get("id").String(), Name: call.Get("name").String(), Arguments: parseArguments(call.Get("args"))})
+				}
+				if result := part.Get("functionResponse"); result.Exists() {
+					results = append(results, conformanceResult{ID: result.Get("id").String(), Name: result.Get("name").String(), Content: result.Get("response").Raw})
+				}
+			}
+		}
+	case sdktranslator.FormatCodex:
+		for _, item := range root.Get("input").Array() {
+			switch item.Get("type").String() {
+			case "function_call":
+				calls = append(calls, conformanceCall{ID: item.Get("call_id").String(), Name: item.Get("name").String(), Arguments: parseArguments(item.Get("arguments"))})
+			case "function_call_output":
+				results = append(results, conformanceResult{ID: item.Get("call_id").String(), Content: item.Get("output").Raw})
+			}
+		}
+	}
+	return calls, results
+}
+
+// conformanceResponseToolCalls reassembles the tool calls of a client response, either a
+// single non-streaming body or the events of a stream.
+func conformanceResponseToolCalls(format sdktranslator.Format, out []string) []conformanceCall {
+	type pending struct {
+		call      conformanceCall
+		arguments strings.Builder
+		final     gjson.Result
+	}
+	var order []int64
+	byIndex := make(map[int64]*pending)
+	entry := func(index int64) *pending {
+		if p, ok := byIndex[index]; ok {
+			return p
+		}
+		p := &pending{}
+		byIndex[index] = p
+		order = append(order, index)
+		return p
+	}
+	var calls []conformanceCall
+
+	for _, event := range conformanceEvents(out) {
+		switch format {
+		case sdktranslator.FormatOpenAI:
+			if message := event.Get("choices.0.message"); message.Exists() {
+				for _, call := range message.Get("tool_calls").Array() {
+					calls = append(calls, conformanceCall{ID: call.Get("id").String(), Name: call.Get("function.name").String(), Arguments: parseArguments(call.Get("function.arguments"))})
+				}
+			}
+			for _, call := range event.Get("choices.0.delta.tool_calls").Array() {
+				p := entry(call.Get("index").Int())
+				if id := call.Get("id").String(); id != "" {
+					p.call.ID = id
+				}
+				if name := call.Get("function.name").String(); name != "" {
+					p.call.Name = name
+				}
+				p.arguments.WriteString(call.Get("function.arguments").String())
+			}
+		case sdktranslator.FormatClaude:
+			for _, part := range event.Get("content").Array() {
+				if part.Get("type").String() == "tool_use" {
+					calls = append(calls, conformanceCall{ID: part.Get("id").String(), Name: part.Get("name").String(), Arguments: parseArguments(part.Get("input"))})
+				}
+			}
+			switch event.Get("type").String() {
+			case "content_block_start":
+				if block := event.Get("content_block"); block.Get("type").String() == "tool_use" {
+					p := entry(event.Get("index").Int())
+					p.call.ID, p.call.Name = block.Get("id").String(), block.Get("name").String()
+				}
+			case "content_block_delta":
+				if event.Get("delta.type").String() == "input_json_delta" {
+					entry(event.Get("index").Int()).arguments.WriteString(event.Get("delta.partial_json").String())
+				}
+			}
+		case sdktranslator.FormatGemini:
+			for _, part := range event.Get("candidates.0.content.parts").Array() {
+				if call := part.Get("functionCall"); call.Exists() {
+					calls = append(calls, conformanceCall{ID: call.Get("id").String(), Name: call.Get("name").String(), Arguments: parseArguments(call.Get("args"))})
+				}
+			}
+		case sdktranslator.FormatOpenAIResponse:
+			if event.Get("object").String() == "response" {
+				for _, item := range event.Get("output").Array() {
+					if item.Get("type").String() == "function_call" {
+						calls = append(calls, conformanceCall{ID: item.Get("call_id").String(), Name: item.Get("name").String(), Arguments: parseArguments(item.Get("arguments"))})
+					}
+				}
+			}
+			switch event.Get("type").String() {
+			case "response.output_item.added":
+				if item := event.Get("item"); item.Get("type").String() == "function_call" {
+					p := entry(event.Get("output_index").Int())
+					p.call.ID, p.call.Name = item.Get("call_id").String(), item.Get("name").String()
+				}
+			case "response.function_call_arguments.delta":
+				entry(event.Get("output_index").Int()).arguments.WriteString(event.Get("delta").String())
+			case "response.output_item.done":
+				if item := event.Get("item"); item.Get("type").String() == "function_call" {
+					entry(event.Get("output_index").Int()).final = item.Get("arguments")
+				}
+			}
+		}
+	}
+
+	for _, index := range order {
+		p := byIndex[index]
+		if p.call.Name == "" {
+			continue
+		}
+		arguments := p.arguments.String()
+		if arguments == "" && p.final.Exists() {
+			arguments = p.final.String()
+		}
+		p.call.Arguments = parseArguments(gjson.Result{Type: gjson.String, Str: arguments})
+		calls = append(calls, p.call)
+	}
+	return calls
+}
+
+// conformanceEvents parses translator output: SSE lines with "data:" payloads, or bare
+// JSON documents.
+func conformanceEvents(out []string) []gjson.Result {
+	var events []gjson.Result
+	for _, chunk := range out {
+		chunk = strings.TrimSpace(chunk)
+		if gjson.Valid(chunk) {
+			events = append(events, gjson.Parse(chunk))
+			continue
+		}
+		for _, line := range strings.Split(chunk, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			if payload := strings.TrimSpace(line[len("data:"):]); gjson.Valid(payload) {
+				events = append(events, gjson.Parse(payload))
+			}
+		}
+	}
+	return events
+}
+
+// parseArguments decodes tool arguments given as a JSON string or as a JSON value.
+func parseArguments(value gjson.Result) any {
+	raw := value.Raw
+	if value.Type == gjson.String {
+		raw = value.String()
+	}
+	var out any
+	if json.Unmarshal([]byte(raw), &out) != nil {
+		return raw
+	}
+	return out
+}
+
+func readRawFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "toolcalls", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func readFixture(t *testing.T, name string, v any) {
+	t.Helper()
+	if err := json.Unmarshal(readRawFixture(t, name), v); err != nil {
+		t.Fatalf("parse fixture %s: %v", name, err)
+	}
+}
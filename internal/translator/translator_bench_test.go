@@ -0,0 +1,102 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// streamBenchmarks are representative upstream streams for the most used
+// provider/client pairs. Each benchmark iteration translates one full stream.
+var streamBenchmarks = []struct {
+	name     string
+	provider sdktranslator.Format
+	client   sdktranslator.Format
+	request  string
+	chunks   []string
+}{
+	{
+		name:     "claude-to-openai",
+		provider: sdktranslator.FormatClaude,
+		client:   sdktranslator.FormatOpenAI,
+		request:  `{"model":"claude-sonnet-4-5","stream":true,"messages":[{"role":"user","content":"hi"}]}`,
+		chunks: append(append([]string{
+			`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","usage":{"input_tokens":12,"output_tokens":1}}}`,
+			`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		}, repeatChunk(`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello there, this is a streamed token."}}`, 64)...),
+			`data: {"type":"content_block_stop","index":0}`,
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":256}}`,
+			`data: {"type":"message_stop"}`,
+		),
+	},
+	{
+		name:     "gemini-to-openai",
+		provider: sdktranslator.FormatGemini,
+		client:   sdktranslator.FormatOpenAI,
+		request:  `{"model":"gemini-2.5-flash","stream":true,"messages":[{"role":"user","content":"hi"}]}`,
+		chunks: append(repeatChunk(`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hello there, this is a streamed token."}]},"index":0}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":4,"totalTokenCount":16},"modelVersion":"gemini-2.5-flash","responseId":"resp-1"}`, 64),
+			`data: {"candidates":[{"content":{"role":"model","parts":[{"text":""}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":256,"totalTokenCount":268},"modelVersion":"gemini-2.5-flash","responseId":"resp-1"}`,
+		),
+	},
+	{
+		name:     "openai-to-claude",
+		provider: sdktranslator.FormatOpenAI,
+		client:   sdktranslator.FormatClaude,
+		request:  `{"model":"gpt-4o","stream":true,"max_tokens":128,"messages":[{"role":"user","content":"hi"}]}`,
+		chunks: append(repeatChunk(`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Hello there, this is a streamed token."},"finish_reason":null}]}`, 64),
+			`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":256,"total_tokens":268}}`,
+			`data: [DONE]`,
+		),
+	},
+}
+
+func repeatChunk(chunk string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = chunk
+	}
+	return out
+}
+
+func BenchmarkTranslateStream(b *testing.B) {
+	ctx := context.Background()
+	for _, bench := range streamBenchmarks {
+		request := []byte(bench.request)
+		chunks := make([][]byte, len(bench.chunks))
+		for i, chunk := range bench.chunks {
+			chunks[i] = []byte(chunk)
+		}
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var param any
+				for _, chunk := range chunks {
+					sdktranslator.TranslateStream(ctx, bench.provider, bench.client, "bench-model", request, request, chunk, &param)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamBenchmarksTranslate(t *testing.T) {
+	ctx := context.Background()
+	for _, bench := range streamBenchmarks[:2] {
+		var param any
+		var last string
+		for _, chunk := range bench.chunks {
+			for _, out := range sdktranslator.TranslateStream(ctx, bench.provider, bench.client, "bench-model", []byte(bench.request), []byte(bench.request), []byte(chunk), &param) {
+				if gjson.Get(out, "choices.0.delta.content").String() != "" {
+					last = out
+				}
+			}
+		}
+		if gjson.Get(last, "choices.0.delta.content").String() != "Hello there, this is a streamed token." {
+			t.Fatalf("%s: unexpected delta %s", bench.name, last)
+		}
+		if id := gjson.Get(last, "id").String(); id != "msg_1" && id != "resp-1" {
+			t.Fatalf("%s: cached chunk header lost the response id: %s", bench.name, last)
+		}
+	}
+}
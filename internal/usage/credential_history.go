@@ -0,0 +1,100 @@
+package usage
+
+import "time"
+
+// CredentialHistoryMinutes is the number of one-minute buckets retained per credential.
+const CredentialHistoryMinutes = 60
+
+// HistoryBucket holds the request outcomes of one credential within a single minute.
+type HistoryBucket struct {
+	Minute  time.Time `json:"minute"`
+	Success int64     `json:"success"`
+	Failure int64     `json:"failure"`
+}
+
+// CredentialHistory is the rolling per-minute history of a single credential.
+type CredentialHistory struct {
+	Provider string          `json:"provider,omitempty"`
+	Buckets  []HistoryBucket `json:"buckets"`
+}
+
+// credentialRing is a fixed ring of minute buckets indexed by unix minute.
+type credentialRing struct {
+	provider string
+	buckets  [CredentialHistoryMinutes]HistoryBucket
+}
+
+func (r *credentialRing) add(at time.Time, success bool) {
+	minute := at.UTC().Truncate(time.Minute)
+	bucket := &r.buckets[minuteSlot(minute)]
+	if !bucket.Minute.Equal(minute) {
+		*bucket = HistoryBucket{Minute: minute}
+	}
+	if success {
+		bucket.Success++
+	} else {
+		bucket.Failure++
+	}
+}
+
+// series returns the last minutes buckets ending at now, oldest first, with empty
+// minutes filled in so clients can plot them directly.
+func (r *credentialRing) series(now time.Time, minutes int) ([]HistoryBucket, bool) {
+	end := now.UTC().Truncate(time.Minute)
+	out := make([]HistoryBucket, minutes)
+	active := false
+	for i := 0; i < minutes; i++ {
+		minute := end.Add(-time.Duration(minutes-1-i) * time.Minute)
+		out[i] = HistoryBucket{Minute: minute}
+		if bucket := r.buckets[minuteSlot(minute)]; bucket.Minute.Equal(minute) {
+			out[i] = bucket
+			active = active || bucket.Success+bucket.Failure > 0
+		}
+	}
+	return out, active
+}
+
+func minuteSlot(minute time.Time) int {
+	return int((minute.Unix() / 60) % CredentialHistoryMinutes)
+}
+
+// recordCredentialHistory adds one outcome to the credential's ring. Callers hold s.mu.
+func (s *RequestStatistics) recordCredentialHistory(credential, provider string, at time.Time, success bool) {
+	if credential == "" {
+		return
+	}
+	ring, ok := s.credentialHistory[credential]
+	if !ok {
+		ring = &credentialRing{}
+		s.credentialHistory[credential] = ring
+	}
+	if provider != "" {
+		ring.provider = provider
+	}
+	ring.add(at, success)
+}
+
+// CredentialHistory returns success/error counts per credential in one-minute buckets
+// covering the last minutes minutes, oldest first. Credentials without any request in
+// the window are omitted. minutes is clamped to CredentialHistoryMinutes.
+func (s *RequestStatistics) CredentialHistory(now time.Time, minutes int) map[string]CredentialHistory {
+	result := make(map[string]CredentialHistory)
+	if s == nil {
+		return result
+	}
+	if minutes <= 0 || minutes > CredentialHistoryMinutes {
+		minutes = CredentialHistoryMinutes
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for key, ring := range s.credentialHistory {
+		buckets, active := ring.series(now, minutes)
+		if !active {
+			continue
+		}
+		result[key] = CredentialHistory{Provider: ring.provider, Buckets: buckets}
+	}
+	return result
+}
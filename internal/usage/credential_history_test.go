@@ -0,0 +1,38 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestCredentialHistoryBuckets(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Date(2026, 1, 2, 10, 30, 15, 0, time.UTC)
+	stats.Record(context.Background(), coreusage.Record{Provider: "claude", AuthIndex: "a1", RequestedAt: now})
+	stats.Record(context.Background(), coreusage.Record{Provider: "claude", AuthIndex: "a1", RequestedAt: now, Failed: true})
+	stats.Record(context.Background(), coreusage.Record{Provider: "claude", AuthIndex: "a1", RequestedAt: now.Add(-2 * time.Minute)})
+	// Older than the retained window; its slot is reused and must not leak into the series.
+	stats.Record(context.Background(), coreusage.Record{Provider: "gemini", AuthIndex: "b1", RequestedAt: now.Add(-2 * time.Hour)})
+
+	history := stats.CredentialHistory(now, 5)
+	if _, ok := history["b1"]; ok {
+		t.Fatal("credential without requests in the window must be omitted")
+	}
+	entry, ok := history["a1"]
+	if !ok || entry.Provider != "claude" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if len(entry.Buckets) != 5 {
+		t.Fatalf("buckets = %d, want 5", len(entry.Buckets))
+	}
+	last := entry.Buckets[4]
+	if !last.Minute.Equal(now.Truncate(time.Minute)) || last.Success != 1 || last.Failure != 1 {
+		t.Fatalf("last bucket = %+v", last)
+	}
+	if entry.Buckets[2].Success != 1 || entry.Buckets[3].Success+entry.Buckets[3].Failure != 0 {
+		t.Fatalf("unexpected buckets: %+v", entry.Buckets)
+	}
+}
@@ -5,6 +5,9 @@ package usage
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"sync"
@@ -12,6 +15,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 )
 
@@ -50,6 +54,22 @@ func (p *LoggerPlugin) HandleUsage(ctx context.Context, record coreusage.Record)
 	p.stats.Record(ctx, record)
 }
 
+// HandleBandwidth implements coreusage.BandwidthPlugin.
+// It aggregates upstream byte counts per provider, credential, and client API key.
+//
+// Parameters:
+//   - ctx: The context for the bandwidth record
+//   - record: The bandwidth record to aggregate
+func (p *LoggerPlugin) HandleBandwidth(ctx context.Context, record coreusage.BandwidthRecord) {
+	if !statisticsEnabled.Load() {
+		return
+	}
+	if p == nil || p.stats == nil {
+		return
+	}
+	p.stats.RecordBandwidth(ctx, record)
+}
+
 // SetStatisticsEnabled toggles whether in-memory statistics are recorded.
 func SetStatisticsEnabled(enabled bool) { statisticsEnabled.Store(enabled) }
 
@@ -71,6 +91,31 @@ type RequestStatistics struct {
 	requestsByHour map[int]int64
 	tokensByDay    map[string]int64
 	tokensByHour   map[int]int64
+
+	bandwidth BandwidthTotals
+	// bandwidth breakdowns keyed by provider, auth index, and tenant (see TenantKey).
+	// Each holds at most maxBandwidthKeys entries.
+	bandwidthByProvider   map[string]*BandwidthTotals
+	bandwidthByCredential map[string]*BandwidthTotals
+	bandwidthByTenant     map[string]*BandwidthTotals
+
+	// credentialHistory keeps rolling per-minute outcomes keyed by auth index.
+	credentialHistory map[string]*credentialRing
+
+	// moderation aggregates moderation requests, which are kept out of the chat totals.
+	moderation ModerationTotals
+
+	// origin identifies this store in the snapshots it exports.
+	origin string
+	// imported holds, per origin, the highest bandwidth and moderation totals merged from
+	// its snapshots, so re-importing an export, or a later one, only adds what is new.
+	imported map[string]*importedAggregates
+}
+
+// importedAggregates are the aggregate totals merged from the snapshots of one origin.
+type importedAggregates struct {
+	bandwidth  BandwidthSnapshot
+	moderation ModerationTotals
 }
 
 // apiStats holds aggregated metrics for a single API key.
@@ -94,6 +139,9 @@ type RequestDetail struct {
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	Seed      *int64     `json:"seed,omitempty"`
+	// RequestID is the proxy request id, as used in request logs and X-Request-Id.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -105,8 +153,47 @@ type TokenStats struct {
 	TotalTokens     int64 `json:"total_tokens"`
 }
 
+// BandwidthTotals aggregates upstream bytes for one dimension of the bandwidth report.
+type BandwidthTotals struct {
+	Requests      int64 `json:"requests"`
+	BytesSent     int64 `json:"bytes_sent"`
+	BytesReceived int64 `json:"bytes_received"`
+	// MaxRequestBytes is the largest sent+received total seen for a single request.
+	MaxRequestBytes int64 `json:"max_request_bytes"`
+}
+
+func (b *BandwidthTotals) add(sent, received int64) {
+	b.Requests++
+	b.BytesSent += sent
+	b.BytesReceived += received
+	if total := sent + received; total > b.MaxRequestBytes {
+		b.MaxRequestBytes = total
+	}
+}
+
+// ModerationTotals aggregates moderation traffic separately from chat token usage.
+type ModerationTotals struct {
+	Requests     int64            `json:"requests"`
+	FailureCount int64            `json:"failure_count"`
+	TotalTokens  int64            `json:"total_tokens"`
+	TokensByDay  map[string]int64 `json:"tokens_by_day"`
+	Models       map[string]int64 `json:"models"`
+}
+
+// BandwidthSnapshot summarises upstream bytes by provider, credential, and tenant.
+type BandwidthSnapshot struct {
+	BandwidthTotals
+	Providers   map[string]BandwidthTotals `json:"providers"`
+	Credentials map[string]BandwidthTotals `json:"credentials"`
+	Tenants     map[string]BandwidthTotals `json:"tenants"`
+}
+
 // StatisticsSnapshot represents an immutable view of the aggregated metrics.
 type StatisticsSnapshot struct {
+	// Origin identifies the store that exported the snapshot; its aggregates are merged
+	// idempotently per origin.
+	Origin string `json:"origin,omitempty"`
+
 	TotalRequests int64 `json:"total_requests"`
 	SuccessCount  int64 `json:"success_count"`
 	FailureCount  int64 `json:"failure_count"`
@@ -118,6 +205,10 @@ type StatisticsSnapshot struct {
 	RequestsByHour map[string]int64 `json:"requests_by_hour"`
 	TokensByDay    map[string]int64 `json:"tokens_by_day"`
 	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
+
+	Bandwidth BandwidthSnapshot `json:"bandwidth"`
+
+	Moderation ModerationTotals `json:"moderation"`
 }
 
 // APISnapshot summarises metrics for a single API key.
@@ -147,42 +238,158 @@ func NewRequestStatistics() *RequestStatistics {
 		requestsByHour: make(map[int]int64),
 		tokensByDay:    make(map[string]int64),
 		tokensByHour:   make(map[int]int64),
+
+		bandwidthByProvider:   make(map[string]*BandwidthTotals),
+		bandwidthByCredential: make(map[string]*BandwidthTotals),
+		bandwidthByTenant:     make(map[string]*BandwidthTotals),
+
+		credentialHistory: make(map[string]*credentialRing),
+
+		origin:   newStatisticsOrigin(),
+		imported: make(map[string]*importedAggregates),
 	}
 }
 
-// Record ingests a new usage record and updates the aggregates.
-func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record) {
+// newStatisticsOrigin returns a random identifier for a statistics store.
+func newStatisticsOrigin() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// maxBandwidthKeys bounds each bandwidth breakdown; traffic for further keys is
+// aggregated under bandwidthOverflowKey.
+const maxBandwidthKeys = 1024
+
+const bandwidthOverflowKey = "other"
+
+// RecordBandwidth ingests upstream byte counts for a single request.
+func (s *RequestStatistics) RecordBandwidth(ctx context.Context, record coreusage.BandwidthRecord) {
 	if s == nil {
 		return
 	}
 	if !statisticsEnabled.Load() {
 		return
 	}
-	timestamp := record.RequestedAt
-	if timestamp.IsZero() {
-		timestamp = time.Now()
+	sent, received := record.BytesSent, record.BytesReceived
+	if sent < 0 {
+		sent = 0
 	}
-	detail := normaliseDetail(record.Detail)
-	totalTokens := detail.TotalTokens
-	statsKey := record.APIKey
-	if statsKey == "" {
-		statsKey = resolveAPIIdentifier(ctx, record)
+	if received < 0 {
+		received = 0
 	}
-	failed := record.Failed
-	if !failed {
-		failed = !resolveSuccess(ctx)
+	provider := record.Provider
+	if provider == "" {
+		provider = "unknown"
 	}
-	success := !failed
-	modelName := record.Model
-	if modelName == "" {
-		modelName = "unknown"
+	credential := record.AuthIndex
+	if credential == "" {
+		credential = record.AuthID
+	}
+	tenant := TenantKey(record.APIKey)
+	if tenant == "" {
+		tenant = resolveAPIIdentifier(ctx, coreusage.Record{Provider: record.Provider})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bandwidth.add(sent, received)
+	addBandwidth(s.bandwidthByProvider, provider, sent, received)
+	if credential != "" {
+		addBandwidth(s.bandwidthByCredential, credential, sent, received)
+	}
+	addBandwidth(s.bandwidthByTenant, tenant, sent, received)
+}
+
+// TenantKey identifies a client API key in reports without exposing it:
+// the masked key plus a short hash that keeps keys with the same mask apart.
+func TenantKey(apiKey string) string {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return util.HideAPIKey(apiKey) + "#" + hex.EncodeToString(sum[:4])
+}
+
+func addBandwidth(m map[string]*BandwidthTotals, key string, sent, received int64) {
+	bandwidthEntry(m, key).add(sent, received)
+}
+
+// bandwidthEntry returns the totals for key, creating them unless the map is full, in
+// which case the overflow entry is returned.
+func bandwidthEntry(m map[string]*BandwidthTotals, key string) *BandwidthTotals {
+	if totals, ok := m[key]; ok {
+		return totals
+	}
+	if len(m) >= maxBandwidthKeys {
+		key = bandwidthOverflowKey
+		if totals, ok := m[key]; ok {
+			return totals
+		}
 	}
+	totals := &BandwidthTotals{}
+	m[key] = totals
+	return totals
+}
+
+// merge adds other's counters to b.
+func (b *BandwidthTotals) merge(other BandwidthTotals) {
+	b.Requests += other.Requests
+	b.BytesSent += other.BytesSent
+	b.BytesReceived += other.BytesReceived
+	if other.MaxRequestBytes > b.MaxRequestBytes {
+		b.MaxRequestBytes = other.MaxRequestBytes
+	}
+}
+
+func mergeBandwidth(m map[string]*BandwidthTotals, other map[string]BandwidthTotals) {
+	for key, totals := range other {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		bandwidthEntry(m, key).merge(totals)
+	}
+}
+
+func copyBandwidth(m map[string]*BandwidthTotals) map[string]BandwidthTotals {
+	out := make(map[string]BandwidthTotals, len(m))
+	for k, v := range m {
+		if v != nil {
+			out[k] = *v
+		}
+	}
+	return out
+}
+
+// Record ingests a new usage record and updates the aggregates.
+func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record) {
+	if s == nil {
+		return
+	}
+	if !statisticsEnabled.Load() {
+		return
+	}
+	statsKey, modelName, detail := resolveRecord(ctx, record)
+	timestamp := detail.Timestamp
+	totalTokens := detail.Tokens.TotalTokens
+	failed := detail.Failed
+	success := !failed
 	dayKey := timestamp.Format("2006-01-02")
 	hourKey := timestamp.Hour()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if record.Category == coreusage.CategoryModeration {
+		s.recordModeration(modelName, dayKey, totalTokens, failed)
+		return
+	}
+
 	s.totalRequests++
 	if success {
 		s.successCount++
@@ -196,18 +403,85 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		stats = &apiStats{Models: make(map[string]*modelStats)}
 		s.apis[statsKey] = stats
 	}
-	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp: timestamp,
-		Source:    record.Source,
-		AuthIndex: record.AuthIndex,
-		Tokens:    detail,
-		Failed:    failed,
-	})
+	s.updateAPIStats(stats, modelName, detail)
 
 	s.requestsByDay[dayKey]++
 	s.requestsByHour[hourKey]++
 	s.tokensByDay[dayKey] += totalTokens
 	s.tokensByHour[hourKey] += totalTokens
+
+	credential := record.AuthIndex
+	if credential == "" {
+		credential = record.AuthID
+	}
+	s.recordCredentialHistory(credential, record.Provider, timestamp, success)
+}
+
+// resolveRecord returns the API the statistics file record under, its model and its
+// request detail.
+func resolveRecord(ctx context.Context, record coreusage.Record) (string, string, RequestDetail) {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	statsKey := record.APIKey
+	if statsKey == "" {
+		statsKey = resolveAPIIdentifier(ctx, record)
+	}
+	failed := record.Failed
+	if !failed {
+		failed = !resolveSuccess(ctx)
+	}
+	modelName := record.Model
+	if modelName == "" {
+		modelName = "unknown"
+	}
+	return statsKey, modelName, RequestDetail{
+		Timestamp: timestamp,
+		Source:    record.Source,
+		AuthIndex: record.AuthIndex,
+		Tokens:    normaliseDetail(record.Detail),
+		Failed:    failed,
+		Seed:      record.Seed,
+		RequestID: record.RequestID,
+	}
+}
+
+// recordModeration adds a moderation request to the moderation totals. Callers hold s.mu.
+func (s *RequestStatistics) recordModeration(model, dayKey string, tokens int64, failed bool) {
+	m := &s.moderation
+	if m.TokensByDay == nil {
+		m.TokensByDay = make(map[string]int64)
+		m.Models = make(map[string]int64)
+	}
+	m.Requests++
+	if failed {
+		m.FailureCount++
+	}
+	m.TotalTokens += tokens
+	m.TokensByDay[dayKey] += tokens
+	m.Models[model] += tokens
+}
+
+// mergeModeration adds imported moderation totals. Callers hold s.mu.
+func (s *RequestStatistics) mergeModeration(other ModerationTotals) {
+	if other.Requests == 0 && other.TotalTokens == 0 {
+		return
+	}
+	m := &s.moderation
+	if m.TokensByDay == nil {
+		m.TokensByDay = make(map[string]int64)
+		m.Models = make(map[string]int64)
+	}
+	m.Requests += other.Requests
+	m.FailureCount += other.FailureCount
+	m.TotalTokens += other.TotalTokens
+	for day, tokens := range other.TokensByDay {
+		m.TokensByDay[day] += tokens
+	}
+	for model, tokens := range other.Models {
+		m.Models[model] += tokens
+	}
 }
 
 func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
@@ -233,6 +507,7 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	result.Origin = s.origin
 	result.TotalRequests = s.totalRequests
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
@@ -279,6 +554,27 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.TokensByHour[key] = v
 	}
 
+	result.Bandwidth = BandwidthSnapshot{
+		BandwidthTotals: s.bandwidth,
+		Providers:       copyBandwidth(s.bandwidthByProvider),
+		Credentials:     copyBandwidth(s.bandwidthByCredential),
+		Tenants:         copyBandwidth(s.bandwidthByTenant),
+	}
+
+	result.Moderation = ModerationTotals{
+		Requests:     s.moderation.Requests,
+		FailureCount: s.moderation.FailureCount,
+		TotalTokens:  s.moderation.TotalTokens,
+		TokensByDay:  make(map[string]int64, len(s.moderation.TokensByDay)),
+		Models:       make(map[string]int64, len(s.moderation.Models)),
+	}
+	for k, v := range s.moderation.TokensByDay {
+		result.Moderation.TokensByDay[k] = v
+	}
+	for k, v := range s.moderation.Models {
+		result.Moderation.Models[k] = v
+	}
+
 	return result
 }
 
@@ -347,9 +643,108 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 		}
 	}
 
+	// Aggregates carry no per-request detail to deduplicate on. Snapshots that name their
+	// origin add only the growth over what was merged from that origin before, so
+	// re-importing an export, or importing a later overlapping one, never counts bytes
+	// twice. Snapshots of this store are already counted. Older exports without an origin
+	// are merged only when none of their requests were known.
+	switch {
+	case snapshot.Origin == s.origin:
+	case snapshot.Origin != "":
+		mark := s.imported[snapshot.Origin]
+		if mark == nil {
+			mark = &importedAggregates{}
+			s.imported[snapshot.Origin] = mark
+		}
+		s.mergeBandwidthSnapshot(bandwidthSnapshotGrowth(&mark.bandwidth, snapshot.Bandwidth))
+		s.mergeModeration(moderationGrowth(&mark.moderation, snapshot.Moderation))
+	case result.Skipped == 0:
+		s.mergeBandwidthSnapshot(snapshot.Bandwidth)
+		s.mergeModeration(snapshot.Moderation)
+	}
+
 	return result
 }
 
+// counterGrowth returns how far next exceeds *mark and raises *mark to it.
+func counterGrowth(mark *int64, next int64) int64 {
+	if next <= *mark {
+		return 0
+	}
+	growth := next - *mark
+	*mark = next
+	return growth
+}
+
+// bandwidthGrowth returns the part of next not yet merged according to mark, and raises
+// mark. MaxRequestBytes is passed through: merging it takes the maximum already.
+func bandwidthGrowth(mark *BandwidthTotals, next BandwidthTotals) BandwidthTotals {
+	return BandwidthTotals{
+		Requests:        counterGrowth(&mark.Requests, next.Requests),
+		BytesSent:       counterGrowth(&mark.BytesSent, next.BytesSent),
+		BytesReceived:   counterGrowth(&mark.BytesReceived, next.BytesReceived),
+		MaxRequestBytes: next.MaxRequestBytes,
+	}
+}
+
+func bandwidthMapGrowth(mark *map[string]BandwidthTotals, next map[string]BandwidthTotals) map[string]BandwidthTotals {
+	if *mark == nil {
+		*mark = make(map[string]BandwidthTotals, len(next))
+	}
+	out := make(map[string]BandwidthTotals, len(next))
+	for key, totals := range next {
+		seen := (*mark)[key]
+		out[key] = bandwidthGrowth(&seen, totals)
+		(*mark)[key] = seen
+	}
+	return out
+}
+
+// bandwidthSnapshotGrowth applies bandwidthGrowth to the totals and every breakdown.
+func bandwidthSnapshotGrowth(mark *BandwidthSnapshot, next BandwidthSnapshot) BandwidthSnapshot {
+	return BandwidthSnapshot{
+		BandwidthTotals: bandwidthGrowth(&mark.BandwidthTotals, next.BandwidthTotals),
+		Providers:       bandwidthMapGrowth(&mark.Providers, next.Providers),
+		Credentials:     bandwidthMapGrowth(&mark.Credentials, next.Credentials),
+		Tenants:         bandwidthMapGrowth(&mark.Tenants, next.Tenants),
+	}
+}
+
+func counterMapGrowth(mark *map[string]int64, next map[string]int64) map[string]int64 {
+	if *mark == nil {
+		*mark = make(map[string]int64, len(next))
+	}
+	out := make(map[string]int64, len(next))
+	for key, value := range next {
+		seen := (*mark)[key]
+		if growth := counterGrowth(&seen, value); growth > 0 {
+			out[key] = growth
+		}
+		(*mark)[key] = seen
+	}
+	return out
+}
+
+// moderationGrowth returns the part of next not yet merged according to mark, and raises
+// mark.
+func moderationGrowth(mark *ModerationTotals, next ModerationTotals) ModerationTotals {
+	return ModerationTotals{
+		Requests:     counterGrowth(&mark.Requests, next.Requests),
+		FailureCount: counterGrowth(&mark.FailureCount, next.FailureCount),
+		TotalTokens:  counterGrowth(&mark.TotalTokens, next.TotalTokens),
+		TokensByDay:  counterMapGrowth(&mark.TokensByDay, next.TokensByDay),
+		Models:       counterMapGrowth(&mark.Models, next.Models),
+	}
+}
+
+// mergeBandwidthSnapshot adds imported bandwidth totals. Callers hold s.mu.
+func (s *RequestStatistics) mergeBandwidthSnapshot(snapshot BandwidthSnapshot) {
+	s.bandwidth.merge(snapshot.BandwidthTotals)
+	mergeBandwidth(s.bandwidthByProvider, snapshot.Providers)
+	mergeBandwidth(s.bandwidthByCredential, snapshot.Credentials)
+	mergeBandwidth(s.bandwidthByTenant, snapshot.Tenants)
+}
+
 func (s *RequestStatistics) recordImported(apiName, modelName string, stats *apiStats, detail RequestDetail) {
 	totalTokens := detail.Tokens.TotalTokens
 	if totalTokens < 0 {
@@ -0,0 +1,149 @@
+package usage
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRecordBandwidthMasksTenants(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.RecordBandwidth(context.Background(), coreusage.BandwidthRecord{Provider: "claude", AuthIndex: "a1", APIKey: "sk-tenant-secret-1", BytesSent: 100, BytesReceived: 400})
+	stats.RecordBandwidth(context.Background(), coreusage.BandwidthRecord{Provider: "claude", AuthIndex: "a1", APIKey: "sk-tenant-secret-1", BytesSent: 50, BytesReceived: 50})
+
+	bw := stats.Snapshot().Bandwidth
+	if bw.Requests != 2 || bw.BytesSent != 150 || bw.BytesReceived != 450 || bw.MaxRequestBytes != 500 {
+		t.Fatalf("totals = %+v", bw.BandwidthTotals)
+	}
+	if got := bw.Providers["claude"]; got.Requests != 2 {
+		t.Fatalf("provider totals = %+v", got)
+	}
+	if got := bw.Credentials["a1"]; got.BytesReceived != 450 {
+		t.Fatalf("credential totals = %+v", got)
+	}
+	if len(bw.Tenants) != 1 {
+		t.Fatalf("tenants = %+v", bw.Tenants)
+	}
+	for tenant, totals := range bw.Tenants {
+		if strings.Contains(tenant, "sk-tenant-secret-1") {
+			t.Fatalf("tenant key exposes the API key: %q", tenant)
+		}
+		if totals.Requests != 2 {
+			t.Fatalf("tenant totals = %+v", totals)
+		}
+	}
+}
+
+func TestRecordBandwidthBoundsKeys(t *testing.T) {
+	stats := NewRequestStatistics()
+	for i := 0; i < maxBandwidthKeys+10; i++ {
+		stats.RecordBandwidth(context.Background(), coreusage.BandwidthRecord{Provider: "p", AuthIndex: "cred-" + strconv.Itoa(i), BytesSent: 1})
+	}
+	credentials := stats.Snapshot().Bandwidth.Credentials
+	if len(credentials) != maxBandwidthKeys+1 {
+		t.Fatalf("credentials = %d, want %d", len(credentials), maxBandwidthKeys+1)
+	}
+	if got := credentials[bandwidthOverflowKey].Requests; got != 10 {
+		t.Fatalf("overflow requests = %d, want 10", got)
+	}
+}
+
+func TestMergeSnapshotIncludesBandwidth(t *testing.T) {
+	source := NewRequestStatistics()
+	source.Record(context.Background(), coreusage.Record{Provider: "claude", Model: "m", APIKey: "k", AuthIndex: "a1", RequestedAt: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)})
+	source.RecordBandwidth(context.Background(), coreusage.BandwidthRecord{Provider: "claude", AuthIndex: "a1", APIKey: "k", BytesSent: 10, BytesReceived: 90})
+	exported := source.Snapshot()
+
+	target := NewRequestStatistics()
+	target.RecordBandwidth(context.Background(), coreusage.BandwidthRecord{Provider: "claude", AuthIndex: "a1", BytesSent: 5, BytesReceived: 5})
+	if result := target.MergeSnapshot(exported); result.Added != 1 {
+		t.Fatalf("merge result = %+v", result)
+	}
+	bw := target.Snapshot().Bandwidth
+	if bw.Requests != 2 || bw.BytesSent != 15 || bw.BytesReceived != 95 || bw.MaxRequestBytes != 100 {
+		t.Fatalf("merged totals = %+v", bw.BandwidthTotals)
+	}
+	if got := bw.Credentials["a1"]; got.Requests != 2 {
+		t.Fatalf("merged credential totals = %+v", got)
+	}
+
+	// Re-importing the same export adds no requests and must not double the bytes.
+	if result := target.MergeSnapshot(exported); result.Added != 0 {
+		t.Fatalf("second merge result = %+v", result)
+	}
+	if got := target.Snapshot().Bandwidth.BytesSent; got != 15 {
+		t.Fatalf("bytes sent after re-import = %d, want 15", got)
+	}
+}
+
+func TestMergeSnapshotBandwidthPartialOverlap(t *testing.T) {
+	source := NewRequestStatistics()
+	first := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	source.Record(context.Background(), coreusage.Record{Provider: "claude", Model: "m", APIKey: "k", AuthIndex: "a1", RequestedAt: first})
+	source.RecordBandwidth(context.Background(), coreusage.BandwidthRecord{Provider: "claude", AuthIndex: "a1", APIKey: "k", BytesSent: 10, BytesReceived: 90})
+	earlier := source.Snapshot()
+	source.Record(context.Background(), coreusage.Record{Provider: "claude", Model: "m", APIKey: "k", AuthIndex: "a1", RequestedAt: first.Add(time.Minute)})
+	source.RecordBandwidth(context.Background(), coreusage.BandwidthRecord{Provider: "claude", AuthIndex: "a1", APIKey: "k", BytesSent: 20, BytesReceived: 30})
+	later := source.Snapshot()
+
+	target := NewRequestStatistics()
+	target.MergeSnapshot(earlier)
+	// The later export repeats the first request and adds one; only its new bytes count.
+	if result := target.MergeSnapshot(later); result.Added != 1 || result.Skipped != 1 {
+		t.Fatalf("merge result = %+v", result)
+	}
+	target.MergeSnapshot(earlier)
+	bw := target.Snapshot().Bandwidth
+	if bw.Requests != 2 || bw.BytesSent != 30 || bw.BytesReceived != 120 {
+		t.Fatalf("totals after overlapping imports = %+v, want the source's", bw.BandwidthTotals)
+	}
+	if got := bw.Tenants[TenantKey("k")]; got.BytesSent != 30 {
+		t.Fatalf("tenant totals = %+v", got)
+	}
+
+	// A store's own export is already counted.
+	before := target.Snapshot().Bandwidth.BytesSent
+	target.MergeSnapshot(target.Snapshot())
+	if got := target.Snapshot().Bandwidth.BytesSent; got != before {
+		t.Fatalf("merging its own export changed bytes sent from %d to %d", before, got)
+	}
+}
+
+func TestModerationUsageSeparatedFromChat(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	stats.Record(context.Background(), coreusage.Record{Provider: "openai", Model: "gpt-4o", APIKey: "k", RequestedAt: now, Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5}})
+	stats.Record(context.Background(), coreusage.Record{Provider: "moderation", Model: "omni-moderation-latest", APIKey: "k", RequestedAt: now, Category: coreusage.CategoryModeration, Detail: coreusage.Detail{InputTokens: 7}})
+
+	snapshot := stats.Snapshot()
+	if snapshot.TotalRequests != 1 || snapshot.TotalTokens != 15 {
+		t.Fatalf("chat totals include moderation: requests=%d tokens=%d", snapshot.TotalRequests, snapshot.TotalTokens)
+	}
+	if _, ok := snapshot.APIs["k"].Models["omni-moderation-latest"]; ok {
+		t.Fatal("moderation model must not appear in chat API stats")
+	}
+	mod := snapshot.Moderation
+	if mod.Requests != 1 || mod.TotalTokens != 7 || mod.Models["omni-moderation-latest"] != 7 || mod.TokensByDay["2026-01-02"] != 7 {
+		t.Fatalf("moderation totals = %+v", mod)
+	}
+}
+
+func TestMergeSnapshotIncludesModeration(t *testing.T) {
+	source := NewRequestStatistics()
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	source.Record(context.Background(), coreusage.Record{Provider: "openai", Model: "gpt-4o", APIKey: "k", RequestedAt: now})
+	source.Record(context.Background(), coreusage.Record{Provider: "moderation", Model: "omni-moderation-latest", APIKey: "k", RequestedAt: now, Category: coreusage.CategoryModeration, Detail: coreusage.Detail{InputTokens: 7}})
+	exported := source.Snapshot()
+
+	target := NewRequestStatistics()
+	target.MergeSnapshot(exported)
+	target.MergeSnapshot(exported)
+	mod := target.Snapshot().Moderation
+	if mod.Requests != 1 || mod.TotalTokens != 7 || mod.Models["omni-moderation-latest"] != 7 || mod.TokensByDay["2026-01-02"] != 7 {
+		t.Fatalf("merged moderation totals = %+v", mod)
+	}
+}
@@ -0,0 +1,156 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultUsageDatabase is the database file used when usage-persistence.path is empty.
+const defaultUsageDatabase = "usage.db"
+
+// defaultUsageRetention is how long request records are kept when retention-days is unset.
+const defaultUsageRetention = 30 * 24 * time.Hour
+
+// persistTimeout bounds a single write; the request context is usually done by then.
+const persistTimeout = 5 * time.Second
+
+var (
+	persistenceMu        sync.RWMutex
+	persistentStore      Store
+	persistenceRetention = defaultUsageRetention
+	// persistentTarget identifies the open store: driver, database and instance ID.
+	persistentTarget string
+	// persistentName names the open store in logs without its credentials.
+	persistentName string
+)
+
+func init() {
+	coreusage.RegisterPlugin(coreusage.PluginFunc(persistUsage))
+	janitor.Register("usage-database", time.Hour, func(_ *config.Config, now time.Time) (int, error) {
+		persistenceMu.RLock()
+		store, retention := persistentStore, persistenceRetention
+		persistenceMu.RUnlock()
+		if store == nil {
+			return 0, nil
+		}
+		return store.Prune(context.Background(), now.Add(-retention))
+	})
+}
+
+// PersistentStore returns the open usage store, or nil when persistence is disabled.
+func PersistentStore() Store {
+	persistenceMu.RLock()
+	defer persistenceMu.RUnlock()
+	return persistentStore
+}
+
+// ConfigurePersistence opens, switches or closes the usage store per cfg. configDir anchors
+// relative SQLite paths when WRITABLE_PATH is unset. A newly opened store has the records
+// this instance wrote within the retention window merged into the in-memory statistics.
+func ConfigurePersistence(cfg config.UsagePersistenceConfig, configDir string) error {
+	retention := defaultUsageRetention
+	if cfg.RetentionDays > 0 {
+		retention = time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	}
+	driver, instance := storeDriver(cfg), usageInstanceID(cfg)
+	target, name := "", driver
+	if cfg.Enabled {
+		database := strings.TrimSpace(cfg.DSN)
+		if driver == "sqlite" {
+			database = resolveUsageDatabase(cfg.Path, configDir)
+			name = database
+		}
+		target = driver + "|" + database + "|" + instance
+	}
+
+	persistenceMu.Lock()
+	defer persistenceMu.Unlock()
+	persistenceRetention = retention
+	if target == persistentTarget {
+		return nil
+	}
+
+	var next Store
+	if target != "" {
+		var err error
+		if next, err = openStore(cfg, configDir, instance); err != nil {
+			return err
+		}
+		snapshot, err := next.Load(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			_ = next.Close()
+			return fmt.Errorf("usage: restore statistics from %s: %w", name, err)
+		}
+		restored := defaultRequestStatistics.MergeSnapshot(snapshot)
+		log.Infof("usage: persisting statistics to %s as instance %s (restored %d requests)", name, instance, restored.Added)
+	}
+	current, currentName := persistentStore, persistentName
+	persistentStore, persistentTarget, persistentName = next, target, name
+	if current != nil {
+		if err := current.Close(); err != nil {
+			log.Warnf("usage: closing %s: %v", currentName, err)
+		}
+	}
+	return nil
+}
+
+// usageInstanceID returns the configured instance ID, else the hostname.
+func usageInstanceID(cfg config.UsagePersistenceConfig) string {
+	if id := strings.TrimSpace(cfg.InstanceID); id != "" {
+		return id
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "default"
+}
+
+// resolveUsageDatabase returns the absolute database path for the configured one.
+func resolveUsageDatabase(path, configDir string) string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = defaultUsageDatabase
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	base := util.WritablePath()
+	if base == "" {
+		base = configDir
+	}
+	return filepath.Join(base, path)
+}
+
+// persistUsage writes a usage record to the usage store. Like the in-memory statistics
+// it drops records while statistics are disabled; moderation traffic is not persisted.
+func persistUsage(ctx context.Context, record coreusage.Record) {
+	if !statisticsEnabled.Load() || record.Category == coreusage.CategoryModeration {
+		return
+	}
+	persistenceMu.RLock()
+	defer persistenceMu.RUnlock()
+	if persistentStore == nil {
+		return
+	}
+	apiName, modelName, detail := resolveRecord(ctx, record)
+	provider := record.Provider
+	if provider == "" {
+		provider = "unknown"
+	}
+	writeCtx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+	if err := persistentStore.Insert(writeCtx, apiName, provider, modelName, detail); err != nil {
+		log.Warnf("usage: persisting request to %s: %v", persistentName, err)
+	}
+}
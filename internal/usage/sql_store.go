@@ -0,0 +1,446 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	// modernc.org/sqlite is a pure Go driver, so release builds keep CGO_ENABLED=0.
+	_ "modernc.org/sqlite"
+)
+
+// sqlDialect holds what differs between the SQL databases a SQLStore runs on. Queries are
+// written with ? placeholders and rebound for databases that number them.
+type sqlDialect struct {
+	// driver is the database/sql driver name.
+	driver string
+	// schema creates the request log and the daily rollups. Requests are kept for the
+	// retention window; rollups are kept indefinitely.
+	schema []string
+	// upsertDaily adds one request to its daily rollup row.
+	upsertDaily string
+	// numbered rebinds ? placeholders to $1, $2, ...
+	numbered bool
+}
+
+// dailyColumns are the columns of a usage_daily insert, in placeholder order.
+const dailyColumns = `(day, provider, model, auth_index, requests, failures,
+	input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens)
+	VALUES (?, ?, ?, ?, 1, ?, ?, ?, ?, ?, ?)`
+
+// upsertDailyOnConflict is the usage_daily upsert of SQLite and Postgres.
+const upsertDailyOnConflict = `INSERT INTO usage_daily ` + dailyColumns + `
+	ON CONFLICT (day, provider, model, auth_index) DO UPDATE SET
+		requests = usage_daily.requests + 1,
+		failures = usage_daily.failures + excluded.failures,
+		input_tokens = usage_daily.input_tokens + excluded.input_tokens,
+		output_tokens = usage_daily.output_tokens + excluded.output_tokens,
+		reasoning_tokens = usage_daily.reasoning_tokens + excluded.reasoning_tokens,
+		cached_tokens = usage_daily.cached_tokens + excluded.cached_tokens,
+		total_tokens = usage_daily.total_tokens + excluded.total_tokens`
+
+var sqliteDialect = sqlDialect{
+	driver: "sqlite",
+	schema: []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA busy_timeout = 5000",
+		`CREATE TABLE IF NOT EXISTS usage_requests (
+			id               INTEGER PRIMARY KEY AUTOINCREMENT,
+			instance         TEXT    NOT NULL DEFAULT '',
+			requested_at     INTEGER NOT NULL,
+			api              TEXT    NOT NULL,
+			provider         TEXT    NOT NULL,
+			model            TEXT    NOT NULL,
+			source           TEXT    NOT NULL DEFAULT '',
+			auth_index       TEXT    NOT NULL DEFAULT '',
+			request_id       TEXT    NOT NULL DEFAULT '',
+			seed             INTEGER,
+			failed           INTEGER NOT NULL,
+			input_tokens     INTEGER NOT NULL,
+			output_tokens    INTEGER NOT NULL,
+			reasoning_tokens INTEGER NOT NULL,
+			cached_tokens    INTEGER NOT NULL,
+			total_tokens     INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS usage_requests_requested_at ON usage_requests (requested_at)`,
+		`CREATE TABLE IF NOT EXISTS usage_daily (
+			day              TEXT    NOT NULL,
+			provider         TEXT    NOT NULL,
+			model            TEXT    NOT NULL,
+			auth_index       TEXT    NOT NULL,
+			requests         INTEGER NOT NULL,
+			failures         INTEGER NOT NULL,
+			input_tokens     INTEGER NOT NULL,
+			output_tokens    INTEGER NOT NULL,
+			reasoning_tokens INTEGER NOT NULL,
+			cached_tokens    INTEGER NOT NULL,
+			total_tokens     INTEGER NOT NULL,
+			PRIMARY KEY (day, provider, model, auth_index)
+		)`,
+	},
+	upsertDaily: upsertDailyOnConflict,
+}
+
+var postgresDialect = sqlDialect{
+	driver: "pgx",
+	schema: []string{
+		`CREATE TABLE IF NOT EXISTS usage_requests (
+			id               BIGSERIAL PRIMARY KEY,
+			instance         TEXT     NOT NULL DEFAULT '',
+			requested_at     BIGINT   NOT NULL,
+			api              TEXT     NOT NULL,
+			provider         TEXT     NOT NULL,
+			model            TEXT     NOT NULL,
+			source           TEXT     NOT NULL DEFAULT '',
+			auth_index       TEXT     NOT NULL DEFAULT '',
+			request_id       TEXT     NOT NULL DEFAULT '',
+			seed             BIGINT,
+			failed           SMALLINT NOT NULL,
+			input_tokens     BIGINT   NOT NULL,
+			output_tokens    BIGINT   NOT NULL,
+			reasoning_tokens BIGINT   NOT NULL,
+			cached_tokens    BIGINT   NOT NULL,
+			total_tokens     BIGINT   NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS usage_requests_requested_at ON usage_requests (requested_at)`,
+		`CREATE TABLE IF NOT EXISTS usage_daily (
+			day              TEXT   NOT NULL,
+			provider         TEXT   NOT NULL,
+			model            TEXT   NOT NULL,
+			auth_index       TEXT   NOT NULL,
+			requests         BIGINT NOT NULL,
+			failures         BIGINT NOT NULL,
+			input_tokens     BIGINT NOT NULL,
+			output_tokens    BIGINT NOT NULL,
+			reasoning_tokens BIGINT NOT NULL,
+			cached_tokens    BIGINT NOT NULL,
+			total_tokens     BIGINT NOT NULL,
+			PRIMARY KEY (day, provider, model, auth_index)
+		)`,
+	},
+	upsertDaily: upsertDailyOnConflict,
+	numbered:    true,
+}
+
+var mysqlDialect = sqlDialect{
+	driver: "mysql",
+	schema: []string{
+		`CREATE TABLE IF NOT EXISTS usage_requests (
+			id               BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			instance         VARCHAR(128) NOT NULL DEFAULT '',
+			requested_at     BIGINT       NOT NULL,
+			api              VARCHAR(255) NOT NULL,
+			provider         VARCHAR(128) NOT NULL,
+			model            VARCHAR(255) NOT NULL,
+			source           VARCHAR(255) NOT NULL DEFAULT '',
+			auth_index       VARCHAR(64)  NOT NULL DEFAULT '',
+			request_id       VARCHAR(128) NOT NULL DEFAULT '',
+			seed             BIGINT,
+			failed           TINYINT      NOT NULL,
+			input_tokens     BIGINT       NOT NULL,
+			output_tokens    BIGINT       NOT NULL,
+			reasoning_tokens BIGINT       NOT NULL,
+			cached_tokens    BIGINT       NOT NULL,
+			total_tokens     BIGINT       NOT NULL,
+			INDEX usage_requests_requested_at (requested_at)
+		)`,
+		`CREATE TABLE IF NOT EXISTS usage_daily (
+			day              CHAR(10)     NOT NULL,
+			provider         VARCHAR(128) NOT NULL,
+			model            VARCHAR(255) NOT NULL,
+			auth_index       VARCHAR(64)  NOT NULL,
+			requests         BIGINT       NOT NULL,
+			failures         BIGINT       NOT NULL,
+			input_tokens     BIGINT       NOT NULL,
+			output_tokens    BIGINT       NOT NULL,
+			reasoning_tokens BIGINT       NOT NULL,
+			cached_tokens    BIGINT       NOT NULL,
+			total_tokens     BIGINT       NOT NULL,
+			PRIMARY KEY (day, provider, model, auth_index)
+		)`,
+	},
+	upsertDaily: `INSERT INTO usage_daily ` + dailyColumns + `
+		ON DUPLICATE KEY UPDATE
+			requests = requests + 1,
+			failures = failures + VALUES(failures),
+			input_tokens = input_tokens + VALUES(input_tokens),
+			output_tokens = output_tokens + VALUES(output_tokens),
+			reasoning_tokens = reasoning_tokens + VALUES(reasoning_tokens),
+			cached_tokens = cached_tokens + VALUES(cached_tokens),
+			total_tokens = total_tokens + VALUES(total_tokens)`,
+}
+
+func init() {
+	RegisterStore("sqlite", func(cfg config.UsagePersistenceConfig, configDir, instance string) (Store, error) {
+		return asStore(OpenSQLiteStore(resolveUsageDatabase(cfg.Path, configDir), instance))
+	})
+	RegisterStore("postgres", func(cfg config.UsagePersistenceConfig, _, instance string) (Store, error) {
+		return asStore(OpenPostgresStore(cfg.DSN, instance))
+	})
+	RegisterStore("mysql", func(cfg config.UsagePersistenceConfig, _, instance string) (Store, error) {
+		return asStore(OpenMySQLStore(cfg.DSN, instance))
+	})
+}
+
+// asStore returns store as a Store, keeping a failed open from yielding a non-nil
+// interface around a nil pointer.
+func asStore(store *SQLStore, err error) (Store, error) {
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// SQLStore is the Store of the SQL databases: SQLite, Postgres and MySQL.
+type SQLStore struct {
+	db       *sql.DB
+	dialect  sqlDialect
+	instance string
+}
+
+// OpenSQLiteStore opens the SQLite database at path, creating the file and schema as needed.
+func OpenSQLiteStore(path, instance string) (*SQLStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("usage: create database directory: %w", err)
+		}
+	}
+	return openSQLStore(sqliteDialect, path, instance)
+}
+
+// OpenPostgresStore connects to the Postgres database at dsn and creates the schema as needed.
+func OpenPostgresStore(dsn, instance string) (*SQLStore, error) {
+	return openSQLStore(postgresDialect, dsn, instance)
+}
+
+// OpenMySQLStore connects to the MySQL database at dsn and creates the schema as needed.
+func OpenMySQLStore(dsn, instance string) (*SQLStore, error) {
+	return openSQLStore(mysqlDialect, dsn, instance)
+}
+
+func openSQLStore(dialect sqlDialect, dataSource, instance string) (*SQLStore, error) {
+	dataSource = strings.TrimSpace(dataSource)
+	if dataSource == "" {
+		return nil, fmt.Errorf("usage: %s store: data source is required", dialect.driver)
+	}
+	db, err := sql.Open(dialect.driver, dataSource)
+	if err != nil {
+		return nil, fmt.Errorf("usage: open %s database: %w", dialect.driver, err)
+	}
+	if dialect.driver == "sqlite" {
+		// A single connection serialises writers, which SQLite allows one at a time anyway,
+		// and keeps the per-connection busy_timeout in effect.
+		db.SetMaxOpenConns(1)
+	}
+	for _, stmt := range dialect.schema {
+		if _, err = db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("usage: initialise %s database: %w", dialect.driver, err)
+		}
+	}
+	return &SQLStore{db: db, dialect: dialect, instance: instance}, nil
+}
+
+// Close closes the database.
+func (s *SQLStore) Close() error { return s.db.Close() }
+
+// rebind rewrites the ? placeholders of query for the dialect.
+func (s *SQLStore) rebind(query string) string {
+	if !s.dialect.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Insert implements Store.
+func (s *SQLStore) Insert(ctx context.Context, apiName, provider, model string, detail RequestDetail) error {
+	tokens := normaliseTokenStats(detail.Tokens)
+	failed := 0
+	if detail.Failed {
+		failed = 1
+	}
+	var seed sql.NullInt64
+	if detail.Seed != nil {
+		seed = sql.NullInt64{Int64: *detail.Seed, Valid: true}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO usage_requests
+		(instance, requested_at, api, provider, model, source, auth_index, request_id, seed, failed,
+		 input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		s.instance, detail.Timestamp.UnixNano(), apiName, provider, model, detail.Source, detail.AuthIndex, detail.RequestID, seed, failed,
+		tokens.InputTokens, tokens.OutputTokens, tokens.ReasoningTokens, tokens.CachedTokens, tokens.TotalTokens,
+	); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, s.rebind(s.dialect.upsertDaily),
+		detail.Timestamp.Format("2006-01-02"), provider, model, detail.AuthIndex, failed,
+		tokens.InputTokens, tokens.OutputTokens, tokens.ReasoningTokens, tokens.CachedTokens, tokens.TotalTokens,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Load implements Store.
+func (s *SQLStore) Load(ctx context.Context, since time.Time) (StatisticsSnapshot, error) {
+	snapshot := StatisticsSnapshot{APIs: make(map[string]APISnapshot)}
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT requested_at, api, model, source, auth_index, request_id, seed, failed,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens
+		FROM usage_requests WHERE instance = ? AND requested_at >= ? ORDER BY requested_at`), s.instance, since.UnixNano())
+	if err != nil {
+		return snapshot, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var (
+			requestedAt int64
+			apiName     string
+			model       string
+			seed        sql.NullInt64
+			failed      int
+			detail      RequestDetail
+		)
+		if err = rows.Scan(&requestedAt, &apiName, &model, &detail.Source, &detail.AuthIndex, &detail.RequestID, &seed, &failed,
+			&detail.Tokens.InputTokens, &detail.Tokens.OutputTokens, &detail.Tokens.ReasoningTokens, &detail.Tokens.CachedTokens, &detail.Tokens.TotalTokens,
+		); err != nil {
+			return snapshot, err
+		}
+		detail.Timestamp = time.Unix(0, requestedAt)
+		detail.Failed = failed != 0
+		if seed.Valid {
+			value := seed.Int64
+			detail.Seed = &value
+		}
+		api, ok := snapshot.APIs[apiName]
+		if !ok {
+			api = APISnapshot{Models: make(map[string]ModelSnapshot)}
+		}
+		modelSnapshot := api.Models[model]
+		modelSnapshot.Details = append(modelSnapshot.Details, detail)
+		api.Models[model] = modelSnapshot
+		snapshot.APIs[apiName] = api
+	}
+	return snapshot, rows.Err()
+}
+
+// Prune implements Store.
+func (s *SQLStore) Prune(ctx context.Context, before time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM usage_requests WHERE requested_at < ?`), before.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// Rollups implements Store.
+func (s *SQLStore) Rollups(ctx context.Context, q RollupQuery) ([]UsageRollup, error) {
+	groupBy := make([]string, 0, len(rollupColumns))
+	for _, column := range rollupColumns {
+		if len(q.GroupBy) == 0 || containsFold(q.GroupBy, column) {
+			groupBy = append(groupBy, column)
+		}
+	}
+	for _, column := range q.GroupBy {
+		if !containsFold(rollupColumns, column) {
+			return nil, fmt.Errorf("usage: cannot group rollups by %q", column)
+		}
+	}
+
+	var where []string
+	var args []any
+	for _, filter := range []struct{ clause, value string }{
+		{"day >= ?", q.From},
+		{"day <= ?", q.To},
+		{"provider = ?", q.Provider},
+		{"model = ?", q.Model},
+		{"auth_index = ?", q.AuthIndex},
+	} {
+		if value := strings.TrimSpace(filter.value); value != "" {
+			where = append(where, filter.clause)
+			args = append(args, value)
+		}
+	}
+
+	query := "SELECT "
+	if len(groupBy) > 0 {
+		query += strings.Join(groupBy, ", ") + ", "
+	}
+	query += `SUM(requests), SUM(failures), SUM(input_tokens), SUM(output_tokens),
+		SUM(reasoning_tokens), SUM(cached_tokens), SUM(total_tokens) FROM usage_daily`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if len(groupBy) > 0 {
+		query += " GROUP BY " + strings.Join(groupBy, ", ") + " ORDER BY " + strings.Join(groupBy, ", ")
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []UsageRollup
+	for rows.Next() {
+		var rollup UsageRollup
+		fields := map[string]*string{"day": &rollup.Day, "provider": &rollup.Provider, "model": &rollup.Model, "auth_index": &rollup.AuthIndex}
+		dest := make([]any, 0, len(groupBy)+7)
+		for _, column := range groupBy {
+			dest = append(dest, fields[column])
+		}
+		// Postgres and MySQL sum BIGINT columns as NUMERIC/DECIMAL, which database/sql
+		// converts to int64 on scan.
+		var requests, failures, input, output, reasoning, cached, total sql.NullInt64
+		dest = append(dest, &requests, &failures, &input, &output, &reasoning, &cached, &total)
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if !requests.Valid {
+			// An ungrouped query over no rows yields a single row of NULL sums.
+			continue
+		}
+		rollup.Requests, rollup.Failures = requests.Int64, failures.Int64
+		rollup.Tokens = TokenStats{
+			InputTokens:     input.Int64,
+			OutputTokens:    output.Int64,
+			ReasoningTokens: reasoning.Int64,
+			CachedTokens:    cached.Int64,
+			TotalTokens:     total.Int64,
+		}
+		out = append(out, rollup)
+	}
+	return out, rows.Err()
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), target) {
+			return true
+		}
+	}
+	return false
+}
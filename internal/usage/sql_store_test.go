@@ -0,0 +1,130 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLStore_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.db")
+	store, err := OpenSQLiteStore(path, "node-a")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	ctx := context.Background()
+	day := time.Date(2026, 3, 4, 10, 0, 0, 0, time.Local)
+	seed := int64(7)
+	inserts := []struct {
+		provider, model, authIndex string
+		at                         time.Time
+		failed                     bool
+	}{
+		{"codex", "gpt-5", "1", day, false},
+		{"codex", "gpt-5", "1", day.Add(time.Minute), true},
+		{"codex", "gpt-5", "2", day.Add(2 * time.Minute), false},
+		{"claude", "claude-sonnet-4", "3", day.Add(24 * time.Hour), false},
+	}
+	for _, in := range inserts {
+		detail := RequestDetail{Timestamp: in.at, AuthIndex: in.authIndex, Failed: in.failed, Seed: &seed, Tokens: TokenStats{InputTokens: 10, OutputTokens: 5}}
+		if err = store.Insert(ctx, "key-1", in.provider, in.model, detail); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if err = store.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if store, err = OpenSQLiteStore(path, "node-a"); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	snapshot, err := store.Load(ctx, day.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	stats := NewRequestStatistics()
+	if merged := stats.MergeSnapshot(snapshot); merged.Added != 4 {
+		t.Fatalf("restored %d requests, want 4", merged.Added)
+	}
+	restored := stats.Snapshot()
+	if restored.TotalRequests != 4 || restored.FailureCount != 1 || restored.TotalTokens != 60 {
+		t.Fatalf("restored totals = %d requests, %d failures, %d tokens", restored.TotalRequests, restored.FailureCount, restored.TotalTokens)
+	}
+	if details := restored.APIs["key-1"].Models["gpt-5"].Details; len(details) != 3 || details[0].Seed == nil || *details[0].Seed != seed {
+		t.Fatalf("restored gpt-5 details = %+v", details)
+	}
+
+	byProvider, err := store.Rollups(ctx, RollupQuery{GroupBy: []string{"provider"}})
+	if err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+	if len(byProvider) != 2 || byProvider[1].Provider != "codex" || byProvider[1].Requests != 3 || byProvider[1].Failures != 1 || byProvider[1].Tokens.TotalTokens != 45 {
+		t.Fatalf("rollups by provider = %+v", byProvider)
+	}
+	perAuth, err := store.Rollups(ctx, RollupQuery{From: "2026-03-04", To: "2026-03-04", Model: "gpt-5"})
+	if err != nil {
+		t.Fatalf("rollups: %v", err)
+	}
+	if len(perAuth) != 2 || perAuth[0].AuthIndex != "1" || perAuth[0].Requests != 2 || perAuth[0].Day != "2026-03-04" {
+		t.Fatalf("rollups per auth = %+v", perAuth)
+	}
+	if _, err = store.Rollups(ctx, RollupQuery{GroupBy: []string{"api_key"}}); err == nil {
+		t.Fatal("grouping by an unknown dimension must fail")
+	}
+
+	pruned, err := store.Prune(ctx, day.Add(12*time.Hour))
+	if err != nil || pruned != 3 {
+		t.Fatalf("pruned %d, %v; want the 3 requests of the first day", pruned, err)
+	}
+	if all, _ := store.Rollups(ctx, RollupQuery{GroupBy: []string{}}); len(all) != 3 {
+		t.Fatalf("pruning must keep rollups, got %+v", all)
+	}
+}
+
+func TestSQLStore_SharedAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.db")
+	ctx := context.Background()
+	now := time.Now()
+	for i, instance := range []string{"node-a", "node-b", "node-b"} {
+		store, err := OpenSQLiteStore(path, instance)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		detail := RequestDetail{Timestamp: now.Add(time.Duration(i) * time.Second), AuthIndex: "1", Tokens: TokenStats{TotalTokens: 100}}
+		if err = store.Insert(ctx, "key-1", "codex", "gpt-5", detail); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		_ = store.Close()
+	}
+
+	store, err := OpenSQLiteStore(path, "node-b")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	snapshot, err := store.Load(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if details := snapshot.APIs["key-1"].Models["gpt-5"].Details; len(details) != 2 {
+		t.Fatalf("node-b restored %d requests, want only its own 2", len(details))
+	}
+	rollups, err := store.Rollups(ctx, RollupQuery{GroupBy: []string{"provider"}})
+	if err != nil || len(rollups) != 1 || rollups[0].Requests != 3 || rollups[0].Tokens.TotalTokens != 300 {
+		t.Fatalf("rollups = %+v, %v; want the requests of every instance", rollups, err)
+	}
+}
+
+func TestSQLStore_RebindNumbersPlaceholders(t *testing.T) {
+	store := &SQLStore{dialect: postgresDialect}
+	if got := store.rebind("day >= ? AND model = ?"); got != "day >= $1 AND model = $2" {
+		t.Fatalf("rebind = %q", got)
+	}
+	store.dialect = mysqlDialect
+	if got := store.rebind("day >= ?"); got != "day >= ?" {
+		t.Fatalf("rebind = %q, want ? placeholders kept", got)
+	}
+}
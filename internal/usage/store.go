@@ -0,0 +1,109 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// Store persists usage records and serves their daily rollups. Several proxy instances may
+// share one store; each tags its records with its instance ID.
+type Store interface {
+	// Insert stores one request of apiName against provider and model and adds it to the
+	// daily rollup.
+	Insert(ctx context.Context, apiName, provider, model string, detail RequestDetail) error
+	// Load returns the requests this instance stored since the given time as a snapshot
+	// that RequestStatistics.MergeSnapshot accepts.
+	Load(ctx context.Context, since time.Time) (StatisticsSnapshot, error)
+	// Prune deletes the requests stored before the given time and returns how many it
+	// removed. Rollups are left untouched.
+	Prune(ctx context.Context, before time.Time) (int, error)
+	// Rollups returns the daily rollups matching q, ordered by the grouped dimensions.
+	Rollups(ctx context.Context, q RollupQuery) ([]UsageRollup, error)
+	// Close releases the store.
+	Close() error
+}
+
+// StoreOpener opens the store of a usage-persistence driver. configDir anchors relative
+// paths when WRITABLE_PATH is unset; instance is the resolved instance ID.
+type StoreOpener func(cfg config.UsagePersistenceConfig, configDir, instance string) (Store, error)
+
+var (
+	storeOpenersMu sync.RWMutex
+	storeOpeners   = map[string]StoreOpener{}
+)
+
+// RegisterStore makes a usage store available under driver, replacing any earlier one.
+func RegisterStore(driver string, open StoreOpener) {
+	driver = strings.ToLower(strings.TrimSpace(driver))
+	if driver == "" || open == nil {
+		return
+	}
+	storeOpenersMu.Lock()
+	storeOpeners[driver] = open
+	storeOpenersMu.Unlock()
+}
+
+// openStore opens the store of the configured driver.
+func openStore(cfg config.UsagePersistenceConfig, configDir, instance string) (Store, error) {
+	driver := storeDriver(cfg)
+	storeOpenersMu.RLock()
+	open, ok := storeOpeners[driver]
+	storeOpenersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("usage: unknown persistence driver %q (available: %s)", driver, strings.Join(storeDrivers(), ", "))
+	}
+	return open(cfg, configDir, instance)
+}
+
+// storeDriver returns the normalised driver name of cfg; empty means sqlite.
+func storeDriver(cfg config.UsagePersistenceConfig) string {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	if driver == "" {
+		return "sqlite"
+	}
+	return driver
+}
+
+func storeDrivers() []string {
+	storeOpenersMu.RLock()
+	defer storeOpenersMu.RUnlock()
+	names := make([]string, 0, len(storeOpeners))
+	for name := range storeOpeners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rollupColumns are the dimensions rollups can be grouped and filtered by.
+var rollupColumns = []string{"day", "provider", "model", "auth_index"}
+
+// RollupQuery selects daily rollups. Empty fields do not filter.
+type RollupQuery struct {
+	// From and To bound the day range, inclusive, as YYYY-MM-DD.
+	From string
+	To   string
+	// GroupBy lists the dimensions to aggregate by, from day, provider, model and
+	// auth_index. Empty groups by all of them.
+	GroupBy   []string
+	Provider  string
+	Model     string
+	AuthIndex string
+}
+
+// UsageRollup aggregates the requests of one group. Dimensions not grouped by are empty.
+type UsageRollup struct {
+	Day       string     `json:"day,omitempty"`
+	Provider  string     `json:"provider,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	AuthIndex string     `json:"auth_index,omitempty"`
+	Requests  int64      `json:"requests"`
+	Failures  int64      `json:"failures"`
+	Tokens    TokenStats `json:"tokens"`
+}
@@ -0,0 +1,41 @@
+package util
+
+import "strings"
+
+const (
+	// FIMPromptMetadataKey carries the code before the cursor for fill-in-the-middle requests.
+	FIMPromptMetadataKey = "fim_prompt"
+	// FIMSuffixMetadataKey carries the code after the cursor for fill-in-the-middle requests.
+	FIMSuffixMetadataKey = "fim_suffix"
+	// FIMLanguageMetadataKey carries the optional language hint for fill-in-the-middle requests.
+	FIMLanguageMetadataKey = "fim_language"
+)
+
+// FIMFromMetadata extracts the fill-in-the-middle prompt and suffix from execution metadata.
+// The boolean result is false when the request is not a FIM request.
+func FIMFromMetadata(metadata map[string]any) (prompt, suffix string, ok bool) {
+	if len(metadata) == 0 {
+		return "", "", false
+	}
+	suffix, _ = metadata[FIMSuffixMetadataKey].(string)
+	if suffix == "" {
+		return "", "", false
+	}
+	prompt, _ = metadata[FIMPromptMetadataKey].(string)
+	return prompt, suffix, true
+}
+
+// BuildFIMEmulationMessages renders a fill-in-the-middle request as chat messages for
+// providers without a native FIM endpoint. The model is asked to return only the code
+// that belongs between prompt and suffix.
+func BuildFIMEmulationMessages(prompt, suffix, language string) (system, user string) {
+	var sb strings.Builder
+	sb.WriteString("You are a code completion engine. Output only the code that belongs at <FILL_ME>, ")
+	sb.WriteString("without repeating the surrounding code, explanations or markdown fences.")
+	if lang := strings.TrimSpace(language); lang != "" {
+		sb.WriteString(" The code is written in ")
+		sb.WriteString(lang)
+		sb.WriteString(".")
+	}
+	return sb.String(), prompt + "<FILL_ME>" + suffix
+}
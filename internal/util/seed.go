@@ -0,0 +1,21 @@
+package util
+
+import "github.com/tidwall/gjson"
+
+// RequestSeedContextKey is the gin context key holding the sampling seed of the request,
+// so usage records can report it.
+const RequestSeedContextKey = "request_seed"
+
+// seedPaths are the locations of the sampling seed in the supported request schemas:
+// OpenAI and Cohere, Gemini, Gemini CLI and Ollama.
+var seedPaths = []string{"seed", "generationConfig.seed", "request.generationConfig.seed", "options.seed"}
+
+// RequestSeed returns the sampling seed of a request payload in any supported schema.
+func RequestSeed(rawJSON []byte) (int64, bool) {
+	for _, path := range seedPaths {
+		if seed := gjson.GetBytes(rawJSON, path); seed.Exists() && seed.Type == gjson.Number {
+			return seed.Int(), true
+		}
+	}
+	return 0, false
+}
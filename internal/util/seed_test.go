@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestRequestSeed(t *testing.T) {
+	cases := []struct {
+		body string
+		want int64
+		ok   bool
+	}{
+		{`{"seed":42}`, 42, true},
+		{`{"generationConfig":{"seed":7}}`, 7, true},
+		{`{"request":{"generationConfig":{"seed":9}}}`, 9, true},
+		{`{"options":{"seed":3}}`, 3, true},
+		{`{"seed":"42"}`, 0, false},
+		{`{"temperature":0}`, 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := RequestSeed([]byte(tc.body))
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("RequestSeed(%s) = %d, %v; want %d, %v", tc.body, got, ok, tc.want, tc.ok)
+		}
+	}
+}
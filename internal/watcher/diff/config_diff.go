@@ -81,6 +81,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !equalStringSet(o.BaseURLs, n.BaseURLs) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].base-urls: updated (%d -> %d entries)", i, len(o.BaseURLs), len(n.BaseURLs)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -116,6 +119,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !equalStringSet(o.BaseURLs, n.BaseURLs) {
+				changes = append(changes, fmt.Sprintf("claude[%d].base-urls: updated (%d -> %d entries)", i, len(o.BaseURLs), len(n.BaseURLs)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -151,6 +157,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !equalStringSet(o.BaseURLs, n.BaseURLs) {
+				changes = append(changes, fmt.Sprintf("codex[%d].base-urls: updated (%d -> %d entries)", i, len(o.BaseURLs), len(n.BaseURLs)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -176,6 +185,13 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Provider-key sections: Groq, xAI, OpenRouter, Cohere, Fireworks, NVIDIA, Moonshot, Zhipu, Cerebras, Ollama (do not print key material)
+	oldSections := oldCfg.ProviderKeySections()
+	newSections := newCfg.ProviderKeySections()
+	for i := range newSections {
+		changes = append(changes, diffProviderKeys(newSections[i].ProviderKeySpec, oldSections[i].Keys, newSections[i].Keys)...)
+	}
+
 	// AmpCode settings (redacted where needed)
 	oldAmpURL := strings.TrimSpace(oldCfg.AmpCode.UpstreamURL)
 	newAmpURL := strings.TrimSpace(newCfg.AmpCode.UpstreamURL)
@@ -367,3 +383,28 @@ func equalUpstreamAPIKeys(a, b []config.AmpUpstreamAPIKeyEntry) bool {
 	}
 	return true
 }
+
+// diffProviderKeys describes changes within one provider-key section.
+func diffProviderKeys(spec config.ProviderKeySpec, oldKeys, newKeys []config.ProviderKey) []string {
+	if len(oldKeys) != len(newKeys) {
+		return []string{fmt.Sprintf("%s count: %d -> %d", spec.Section, len(oldKeys), len(newKeys))}
+	}
+	var changes []string
+	for i := range oldKeys {
+		o := oldKeys[i]
+		n := newKeys[i]
+		if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+			changes = append(changes, fmt.Sprintf("%s[%d].base-url: %s -> %s", spec.Provider, i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+		}
+		if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+			changes = append(changes, fmt.Sprintf("%s[%d].proxy-url: %s -> %s", spec.Provider, i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+		}
+		if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+			changes = append(changes, fmt.Sprintf("%s[%d].api-key: updated", spec.Provider, i))
+		}
+		if ComputeProviderModelsHash(o.Models) != ComputeProviderModelsHash(n.Models) {
+			changes = append(changes, fmt.Sprintf("%s[%d].models: updated (%d -> %d entries)", spec.Provider, i, len(o.Models), len(n.Models)))
+		}
+	}
+	return changes
+}
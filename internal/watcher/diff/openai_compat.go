@@ -75,6 +75,24 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if strings.TrimSpace(oldEntry.BaseURL) != strings.TrimSpace(newEntry.BaseURL) {
+		details = append(details, "base-url updated")
+	}
+	if !equalStringSet(oldEntry.BaseURLs, newEntry.BaseURLs) {
+		details = append(details, fmt.Sprintf("base-urls %d -> %d", len(oldEntry.BaseURLs), len(newEntry.BaseURLs)))
+	}
+	if !strings.EqualFold(strings.TrimSpace(oldEntry.FIM), strings.TrimSpace(newEntry.FIM)) {
+		details = append(details, fmt.Sprintf("fim %q -> %q", oldEntry.FIM, newEntry.FIM))
+	}
+	if strings.TrimSpace(oldEntry.AuthHeader) != strings.TrimSpace(newEntry.AuthHeader) {
+		details = append(details, "auth-header updated")
+	}
+	if strings.TrimSpace(oldEntry.ModelsURL) != strings.TrimSpace(newEntry.ModelsURL) {
+		details = append(details, "models-url updated")
+	}
+	if oldEntry.Quirks != newEntry.Quirks {
+		details = append(details, "quirks updated")
+	}
 	if len(details) == 0 {
 		return ""
 	}
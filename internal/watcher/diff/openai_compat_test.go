@@ -43,6 +43,14 @@ func TestDiffOpenAICompatibility(t *testing.T) {
 	expectContains(t, changes, "provider updated: provider-a (api-keys 1 -> 2, models 1 -> 2, headers updated)")
 }
 
+func TestDiffOpenAICompatibility_BaseURLs(t *testing.T) {
+	oldList := []config.OpenAICompatibility{{Name: "p", BaseURL: "https://a.example.com"}}
+	newList := []config.OpenAICompatibility{{Name: "p", BaseURL: "https://a.example.com", BaseURLs: []string{"https://b.example.com"}}}
+
+	changes := DiffOpenAICompatibility(oldList, newList)
+	expectContains(t, changes, "provider updated: p (base-urls 0 -> 1)")
+}
+
 func TestDiffOpenAICompatibility_RemovedAndUnchanged(t *testing.T) {
 	oldList := []config.OpenAICompatibility{
 		{
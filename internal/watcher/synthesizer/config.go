@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
@@ -35,6 +36,10 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeCodexKeys(ctx)...)
 	// Kiro (AWS CodeWhisperer)
 	out = append(out, s.synthesizeKiroKeys(ctx)...)
+	// Provider keys: Groq, xAI, OpenRouter, Cohere, Fireworks AI, NVIDIA NIM, Moonshot, Zhipu GLM, Cerebras, Ollama
+	for _, section := range ctx.Config.ProviderKeySections() {
+		out = append(out, s.synthesizeProviderKeys(ctx, section)...)
+	}
 	// OpenAI-compat
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
@@ -67,9 +72,16 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*corea
 		if entry.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(entry.Priority)
 		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
+		if entry.Canary {
+			attrs["canary"] = "true"
+		}
 		if base != "" {
 			attrs["base_url"] = base
 		}
+		addBaseURLsToAttrs(base, entry.BaseURLs, attrs)
 		if hash := diff.ComputeGeminiModelsHash(entry.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
@@ -114,9 +126,16 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if ck.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(ck.Priority)
 		}
+		if ck.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(ck.MaxConcurrency)
+		}
+		if ck.Canary {
+			attrs["canary"] = "true"
+		}
 		if base != "" {
 			attrs["base_url"] = base
 		}
+		addBaseURLsToAttrs(base, ck.BaseURLs, attrs)
 		if hash := diff.ComputeClaudeModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
@@ -161,9 +180,16 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 		if ck.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(ck.Priority)
 		}
+		if ck.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(ck.MaxConcurrency)
+		}
+		if ck.Canary {
+			attrs["canary"] = "true"
+		}
 		if ck.BaseURL != "" {
 			attrs["base_url"] = ck.BaseURL
 		}
+		addBaseURLsToAttrs(ck.BaseURL, ck.BaseURLs, attrs)
 		if hash := diff.ComputeCodexModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
@@ -186,6 +212,69 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 	return out
 }
 
+// synthesizeProviderKeys creates Auth entries for one provider-key section. Entries of
+// server-keyed providers (Ollama) are identified by base URL and may omit the api-key.
+func (s *ConfigSynthesizer) synthesizeProviderKeys(ctx *SynthesisContext, section config.ProviderKeySection) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(section.Keys))
+	for i := range section.Keys {
+		pk := section.Keys[i]
+		key := strings.TrimSpace(pk.APIKey)
+		base := strings.TrimSpace(pk.BaseURL)
+		var id, token, label string
+		if section.ServerKeyed {
+			if base == "" {
+				continue
+			}
+			id, token = idGen.Next(section.Provider+":server", base, key)
+			label = section.Provider
+		} else {
+			if key == "" {
+				continue
+			}
+			id, token = idGen.Next(section.Provider+":apikey", key, pk.BaseURL)
+			label = section.Provider + "-apikey"
+		}
+		attrs := map[string]string{
+			"source": fmt.Sprintf("config:%s[%s]", section.Provider, token),
+		}
+		if key != "" {
+			attrs["api_key"] = key
+		}
+		if base != "" {
+			attrs["base_url"] = base
+		}
+		if pk.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(pk.Priority)
+		}
+		if pk.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(pk.MaxConcurrency)
+		}
+		if hash := diff.ComputeProviderModelsHash(pk.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(pk.Headers, attrs)
+		proxyURL := strings.TrimSpace(pk.ProxyURL)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   section.Provider,
+			Label:      label,
+			Prefix:     strings.TrimSpace(pk.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   proxyURL,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, pk.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
 // synthesizeOpenAICompat creates Auth entries for OpenAI-compatible providers.
 func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*coreauth.Auth {
 	cfg := ctx.Config
@@ -219,12 +308,22 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			if compat.MaxConcurrency > 0 {
+				attrs["max_concurrency"] = strconv.Itoa(compat.MaxConcurrency)
+			}
 			if key != "" {
 				attrs["api_key"] = key
 			}
+			if entry.Canary {
+				attrs["canary"] = "true"
+			}
+			addBaseURLsToAttrs(base, compat.BaseURLs, attrs)
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			if compat.ModelsURL != "" {
+				attrs["models_url"] = compat.ModelsURL
+			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
@@ -253,9 +352,16 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			if compat.MaxConcurrency > 0 {
+				attrs["max_concurrency"] = strconv.Itoa(compat.MaxConcurrency)
+			}
+			addBaseURLsToAttrs(base, compat.BaseURLs, attrs)
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			if compat.ModelsURL != "" {
+				attrs["models_url"] = compat.ModelsURL
+			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
@@ -298,6 +404,9 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 		if compat.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(compat.Priority)
 		}
+		if compat.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(compat.MaxConcurrency)
+		}
 		if key != "" {
 			attrs["api_key"] = key
 		}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/geminicli"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 // FileSynthesizer generates Auth entries from OAuth JSON files.
@@ -54,6 +55,17 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 		if errUnmarshal := json.Unmarshal(data, &metadata); errUnmarshal != nil {
 			continue
 		}
+		if _, errSchema := coreauth.UpgradeMetadata(metadata); errSchema != nil {
+			log.WithError(errSchema).Warnf("skipping auth file %s", name)
+			continue
+		}
+		if errSchema := coreauth.ValidateMetadata(metadata); errSchema != nil {
+			// Files without a type are skipped below; incomplete credentials still load so
+			// the failure surfaces on refresh, but the missing fields are reported now.
+			if _, hasType := metadata["type"].(string); hasType {
+				log.WithError(errSchema).Warnf("auth file %s is incomplete", name)
+			}
+		}
 		t, _ := metadata["type"].(string)
 		if t == "" {
 			continue
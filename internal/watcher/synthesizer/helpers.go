@@ -108,3 +108,31 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addBaseURLsToAttrs records multi-region endpoints as a comma-separated base_urls attribute.
+// The primary base URL is always listed first; duplicates are dropped.
+func addBaseURLsToAttrs(primary string, extra []string, attrs map[string]string) {
+	if len(extra) == 0 || attrs == nil {
+		return
+	}
+	seen := make(map[string]struct{}, len(extra)+1)
+	urls := make([]string, 0, len(extra)+1)
+	for _, raw := range append([]string{primary}, extra...) {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			continue
+		}
+		if _, ok := seen[trimmed]; ok {
+			continue
+		}
+		seen[trimmed] = struct{}{}
+		urls = append(urls, trimmed)
+	}
+	if len(urls) < 2 {
+		return
+	}
+	if attrs["base_url"] == "" {
+		attrs["base_url"] = urls[0]
+	}
+	attrs["base_urls"] = strings.Join(urls, ",")
+}
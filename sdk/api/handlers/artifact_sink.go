@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/artifact"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArtifactHeader names the server-side artifact a response should also be written to.
+// It is honoured only when streaming.artifact-dir is configured.
+const ArtifactHeader = "X-Artifact-Name"
+
+// artifactSink copies a response into an artifact. A nil sink is a no-op.
+type artifactSink struct {
+	name   string
+	writer *artifact.Writer
+}
+
+// openArtifactSink starts the artifact requested by the request behind ctx, or returns
+// nil when none was requested or artifacts are disabled.
+func openArtifactSink(cfg *config.SDKConfig, ctx context.Context) *artifactSink {
+	if cfg == nil || ctx == nil {
+		return nil
+	}
+	dir := strings.TrimSpace(cfg.Streaming.ArtifactDir)
+	if dir == "" {
+		return nil
+	}
+	c, _ := ctx.Value("gin").(*gin.Context)
+	if c == nil || c.Request == nil {
+		return nil
+	}
+	name := strings.TrimSpace(c.GetHeader(ArtifactHeader))
+	if name == "" {
+		return nil
+	}
+	writer, err := artifact.Create(dir, name)
+	if err != nil {
+		log.Warnf("artifact %q: %v", name, err)
+		return nil
+	}
+	return &artifactSink{name: name, writer: writer}
+}
+
+// write appends one response chunk, separating chunks with a newline.
+func (s *artifactSink) write(chunk []byte) {
+	if s == nil || len(chunk) == 0 {
+		return
+	}
+	if chunk[len(chunk)-1] != '\n' {
+		chunk = append(chunk[:len(chunk):len(chunk)], '\n')
+	}
+	if err := s.writer.Write(chunk); err != nil {
+		log.Warnf("artifact %q: write failed: %v", s.name, err)
+	}
+}
+
+// finish publishes the artifact, or leaves it partial when the response failed.
+func (s *artifactSink) finish(failed bool) {
+	if s == nil {
+		return
+	}
+	var err error
+	if failed {
+		err = s.writer.Abort()
+	} else {
+		err = s.writer.Commit()
+	}
+	if err != nil {
+		log.Warnf("artifact %q: %v", s.name, err)
+	}
+}
+
+// detachFromClient keeps ctx's values but not its cancellation, so an upstream call
+// feeding an artifact outlives the client connection. The gin context is replaced by a
+// copy because gin recycles the original once the handler returns.
+func detachFromClient(ctx context.Context) context.Context {
+	detached := context.WithoutCancel(ctx)
+	if c, ok := ctx.Value("gin").(*gin.Context); ok && c != nil {
+		detached = context.WithValue(detached, "gin", c.Copy())
+	}
+	return detached
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/artifact"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// pausedStreamExecutor streams "first", waits for release, then streams "second".
+type pausedStreamExecutor struct {
+	failOnceStreamExecutor
+	release chan struct{}
+}
+
+func (e *pausedStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		ch <- coreexecutor.StreamChunk{Payload: []byte("first")}
+		<-e.release
+		if ctx.Err() != nil {
+			return
+		}
+		ch <- coreexecutor.StreamChunk{Payload: []byte("second")}
+	}()
+	return ch, nil
+}
+
+func TestExecuteStreamWithAuthManager_ArtifactOutlivesClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &pausedStreamExecutor{release: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "artifact-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "artifact-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	dir := t.TempDir()
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{ArtifactDir: dir},
+	}, manager)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Request.Header.Set(ArtifactHeader, "job")
+	clientCtx, disconnect := context.WithCancel(context.WithValue(context.Background(), "gin", c))
+
+	dataChan, _ := handler.ExecuteStreamWithAuthManager(clientCtx, "openai", "artifact-model", []byte(`{"model":"artifact-model"}`), "")
+	if got := string(<-dataChan); got != "first" {
+		t.Fatalf("first chunk = %q", got)
+	}
+	disconnect()
+	close(executor.release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		path, info, err := artifact.Path(dir, "job")
+		if err == nil && info.Complete {
+			data, _ := os.ReadFile(path)
+			if string(data) != "first\nsecond\n" {
+				t.Fatalf("artifact = %q", data)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("artifact not completed: %+v %v", info, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,382 @@
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// convertConverseRequest converts a Converse request for modelID into an OpenAI chat
+// completions request.
+func convertConverseRequest(modelID string, rawJSON []byte, stream bool) ([]byte, error) {
+	if strings.TrimSpace(modelID) == "" {
+		return nil, fmt.Errorf("modelId is required")
+	}
+	root := gjson.ParseBytes(rawJSON)
+
+	messages := make([]map[string]any, 0)
+	var system []string
+	for _, block := range root.Get("system").Array() {
+		if text := block.Get("text").String(); text != "" {
+			system = append(system, text)
+		}
+	}
+	if len(system) > 0 {
+		messages = append(messages, map[string]any{"role": "system", "content": strings.Join(system, "\n\n")})
+	}
+
+	for i, msg := range root.Get("messages").Array() {
+		var converted []map[string]any
+		var err error
+		switch role := msg.Get("role").String(); role {
+		case "user":
+			converted, err = convertUserMessage(msg.Get("content"))
+		case "assistant":
+			converted, err = convertAssistantMessage(msg.Get("content"))
+		default:
+			err = fmt.Errorf("unsupported role %q", role)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("messages.%d: %w", i, err)
+		}
+		messages = append(messages, converted...)
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("messages are required")
+	}
+
+	req := map[string]any{
+		"model":    modelID,
+		"messages": messages,
+		"stream":   stream,
+	}
+	if stream {
+		req["stream_options"] = map[string]any{"include_usage": true}
+	}
+
+	inference := root.Get("inferenceConfig")
+	if v := inference.Get("maxTokens"); v.Type == gjson.Number {
+		req["max_tokens"] = v.Int()
+	}
+	if v := inference.Get("temperature"); v.Type == gjson.Number {
+		req["temperature"] = json.RawMessage(v.Raw)
+	}
+	if v := inference.Get("topP"); v.Type == gjson.Number {
+		req["top_p"] = json.RawMessage(v.Raw)
+	}
+	if v := inference.Get("stopSequences"); v.IsArray() && len(v.Array()) > 0 {
+		req["stop"] = json.RawMessage(v.Raw)
+	}
+
+	var tools []map[string]any
+	for _, tool := range root.Get("toolConfig.tools").Array() {
+		spec := tool.Get("toolSpec")
+		if !spec.Exists() {
+			continue
+		}
+		function := map[string]any{"name": spec.Get("name").String()}
+		if description := spec.Get("description").String(); description != "" {
+			function["description"] = description
+		}
+		if schema := spec.Get("inputSchema.json"); schema.IsObject() {
+			function["parameters"] = json.RawMessage(schema.Raw)
+		}
+		tools = append(tools, map[string]any{"type": "function", "function": function})
+	}
+	if len(tools) > 0 {
+		req["tools"] = tools
+		choice := root.Get("toolConfig.toolChoice")
+		switch {
+		case choice.Get("any").Exists():
+			req["tool_choice"] = "required"
+		case choice.Get("tool.name").Exists():
+			req["tool_choice"] = map[string]any{"type": "function", "function": map[string]any{"name": choice.Get("tool.name").String()}}
+		case choice.Get("auto").Exists():
+			req["tool_choice"] = "auto"
+		}
+	}
+	return json.Marshal(req)
+}
+
+// convertUserMessage converts a user message. Tool results become OpenAI tool messages,
+// placed first so they directly follow the assistant message that called the tools.
+func convertUserMessage(content gjson.Result) ([]map[string]any, error) {
+	var out []map[string]any
+	var parts []map[string]any
+	for _, block := range content.Array() {
+		switch {
+		case block.Get("text").Exists():
+			parts = append(parts, map[string]any{"type": "text", "text": block.Get("text").String()})
+		case block.Get("image").Exists():
+			image := block.Get("image")
+			data := image.Get("source.bytes").String()
+			if data == "" {
+				return nil, fmt.Errorf("only inline image bytes are supported")
+			}
+			parts = append(parts, map[string]any{
+				"type":      "image_url",
+				"image_url": map[string]any{"url": "data:image/" + image.Get("format").String() + ";base64," + data},
+			})
+		case block.Get("toolResult").Exists():
+			result := block.Get("toolResult")
+			out = append(out, map[string]any{
+				"role":         "tool",
+				"tool_call_id": result.Get("toolUseId").String(),
+				"content":      toolResultText(result),
+			})
+		case block.Get("cachePoint").Exists():
+			// Prompt caching hints have no OpenAI equivalent.
+		default:
+			return nil, fmt.Errorf("unsupported content block %s", firstKey(block))
+		}
+	}
+	if len(parts) > 0 {
+		if len(parts) == 1 && parts[0]["type"] == "text" {
+			out = append(out, map[string]any{"role": "user", "content": parts[0]["text"]})
+		} else {
+			out = append(out, map[string]any{"role": "user", "content": parts})
+		}
+	}
+	return out, nil
+}
+
+// convertAssistantMessage converts an assistant message. Reasoning blocks are dropped:
+// they are only meaningful to the model that produced them.
+func convertAssistantMessage(content gjson.Result) ([]map[string]any, error) {
+	var text strings.Builder
+	var calls []map[string]any
+	for _, block := range content.Array() {
+		switch {
+		case block.Get("text").Exists():
+			text.WriteString(block.Get("text").String())
+		case block.Get("toolUse").Exists():
+			use := block.Get("toolUse")
+			arguments := use.Get("input").Raw
+			if arguments == "" {
+				arguments = "{}"
+			}
+			calls = append(calls, map[string]any{
+				"id":       use.Get("toolUseId").String(),
+				"type":     "function",
+				"function": map[string]any{"name": use.Get("name").String(), "arguments": arguments},
+			})
+		case block.Get("reasoningContent").Exists(), block.Get("cachePoint").Exists():
+		default:
+			return nil, fmt.Errorf("unsupported content block %s", firstKey(block))
+		}
+	}
+	msg := map[string]any{"role": "assistant", "content": text.String()}
+	if len(calls) > 0 {
+		msg["tool_calls"] = calls
+	}
+	return []map[string]any{msg}, nil
+}
+
+// toolResultText flattens the content of a toolResult block into the string content of
+// an OpenAI tool message.
+func toolResultText(result gjson.Result) string {
+	var parts []string
+	for _, block := range result.Get("content").Array() {
+		switch {
+		case block.Get("text").Exists():
+			parts = append(parts, block.Get("text").String())
+		case block.Get("json").Exists():
+			parts = append(parts, block.Get("json").Raw)
+		}
+	}
+	text := strings.Join(parts, "\n")
+	if result.Get("status").String() == "error" {
+		text = "Error: " + text
+	}
+	return text
+}
+
+func firstKey(block gjson.Result) string {
+	key := "?"
+	block.ForEach(func(k, _ gjson.Result) bool {
+		key = k.String()
+		return false
+	})
+	return key
+}
+
+// stopReason maps an OpenAI finish reason to a Converse stop reason.
+func stopReason(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "content_filtered"
+	default:
+		return "end_turn"
+	}
+}
+
+// toolInput parses OpenAI tool call arguments into the object Converse expects.
+func toolInput(arguments string) json.RawMessage {
+	arguments = strings.TrimSpace(arguments)
+	if gjson.Valid(arguments) && gjson.Parse(arguments).IsObject() {
+		return json.RawMessage(arguments)
+	}
+	return json.RawMessage("{}")
+}
+
+func converseUsage(usage gjson.Result) map[string]any {
+	input, output := usage.Get("prompt_tokens").Int(), usage.Get("completion_tokens").Int()
+	return map[string]any{"inputTokens": input, "outputTokens": output, "totalTokens": input + output}
+}
+
+// convertConverseResponse converts a non-streaming OpenAI chat completion into a Converse
+// response.
+func convertConverseResponse(completion []byte, latencyMS int64) []byte {
+	root := gjson.ParseBytes(completion)
+	message := root.Get("choices.0.message")
+	content := make([]map[string]any, 0)
+	if reasoning := message.Get("reasoning_content").String(); reasoning != "" {
+		content = append(content, map[string]any{"reasoningContent": map[string]any{"reasoningText": map[string]any{"text": reasoning}}})
+	}
+	if text := message.Get("content").String(); text != "" {
+		content = append(content, map[string]any{"text": text})
+	}
+	for _, call := range message.Get("tool_calls").Array() {
+		content = append(content, map[string]any{"toolUse": map[string]any{
+			"toolUseId": call.Get("id").String(),
+			"name":      call.Get("function.name").String(),
+			"input":     toolInput(call.Get("function.arguments").String()),
+		}})
+	}
+	out, _ := json.Marshal(map[string]any{
+		"output":     map[string]any{"message": map[string]any{"role": "assistant", "content": content}},
+		"stopReason": stopReason(root.Get("choices.0.finish_reason").String()),
+		"usage":      converseUsage(root.Get("usage")),
+		"metrics":    map[string]any{"latencyMs": latencyMS},
+	})
+	return out
+}
+
+// streamEvent is one ConverseStream event before event-stream framing.
+type streamEvent struct {
+	eventType string
+	payload   []byte
+}
+
+// blockKind is the type of the content block a stream is currently writing.
+type blockKind int
+
+const (
+	blockNone blockKind = iota
+	blockText
+	blockReasoning
+	blockTool
+)
+
+// streamConverter turns OpenAI chat completion chunks into ConverseStream events. Each
+// change of content type closes the open block and starts the next index.
+type streamConverter struct {
+	started      bool
+	open         blockKind
+	index        int
+	toolIndex    int64
+	finishReason string
+	usage        gjson.Result
+}
+
+func newStreamConverter() *streamConverter {
+	return &streamConverter{index: -1, toolIndex: -1}
+}
+
+func event(eventType string, payload map[string]any) streamEvent {
+	b, _ := json.Marshal(payload)
+	return streamEvent{eventType: eventType, payload: b}
+}
+
+// convert returns the events for one OpenAI chunk.
+func (s *streamConverter) convert(chunk []byte) []streamEvent {
+	root := gjson.ParseBytes(chunk)
+	var events []streamEvent
+	if !s.started {
+		s.started = true
+		events = append(events, event("messageStart", map[string]any{"role": "assistant"}))
+	}
+	if usage := root.Get("usage"); usage.IsObject() {
+		s.usage = usage
+	}
+	for _, choice := range root.Get("choices").Array() {
+		if reason := choice.Get("finish_reason").String(); reason != "" {
+			s.finishReason = reason
+		}
+		delta := choice.Get("delta")
+		if reasoning := delta.Get("reasoning_content").String(); reasoning != "" {
+			events = append(events, s.openBlock(blockReasoning)...)
+			events = append(events, s.delta(map[string]any{"reasoningContent": map[string]any{"text": reasoning}}))
+		}
+		if text := delta.Get("content").String(); text != "" {
+			events = append(events, s.openBlock(blockText)...)
+			events = append(events, s.delta(map[string]any{"text": text}))
+		}
+		for _, call := range delta.Get("tool_calls").Array() {
+			if index := call.Get("index").Int(); s.open != blockTool || index != s.toolIndex {
+				s.toolIndex = index
+				events = append(events, s.closeBlock()...)
+				s.index++
+				s.open = blockTool
+				events = append(events, event("contentBlockStart", map[string]any{
+					"contentBlockIndex": s.index,
+					"start": map[string]any{"toolUse": map[string]any{
+						"toolUseId": call.Get("id").String(),
+						"name":      call.Get("function.name").String(),
+					}},
+				}))
+			}
+			if arguments := call.Get("function.arguments").String(); arguments != "" {
+				events = append(events, s.delta(map[string]any{"toolUse": map[string]any{"input": arguments}}))
+			}
+		}
+	}
+	return events
+}
+
+func (s *streamConverter) openBlock(kind blockKind) []streamEvent {
+	if s.open == kind {
+		return nil
+	}
+	events := s.closeBlock()
+	s.index++
+	s.open = kind
+	return events
+}
+
+func (s *streamConverter) closeBlock() []streamEvent {
+	if s.open == blockNone {
+		return nil
+	}
+	s.open = blockNone
+	return []streamEvent{event("contentBlockStop", map[string]any{"contentBlockIndex": s.index})}
+}
+
+func (s *streamConverter) delta(delta map[string]any) streamEvent {
+	return event("contentBlockDelta", map[string]any{"contentBlockIndex": s.index, "delta": delta})
+}
+
+// finish returns the closing events. truncated reports a stream cut at the configured
+// output limit.
+func (s *streamConverter) finish(truncated bool, latencyMS int64) []streamEvent {
+	var events []streamEvent
+	if !s.started {
+		s.started = true
+		events = append(events, event("messageStart", map[string]any{"role": "assistant"}))
+	}
+	events = append(events, s.closeBlock()...)
+	if truncated {
+		s.finishReason = "length"
+	}
+	events = append(events,
+		event("messageStop", map[string]any{"stopReason": stopReason(s.finishReason)}),
+		event("metadata", map[string]any{"usage": converseUsage(s.usage), "metrics": map[string]any{"latencyMs": latencyMS}}),
+	)
+	return events
+}
@@ -0,0 +1,114 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertConverseRequest(t *testing.T) {
+	raw := []byte(`{
+		"system":[{"text":"be brief"}],
+		"messages":[
+			{"role":"user","content":[{"text":"weather in Paris?"}]},
+			{"role":"assistant","content":[{"toolUse":{"toolUseId":"tu_1","name":"get_weather","input":{"city":"Paris"}}}]},
+			{"role":"user","content":[{"toolResult":{"toolUseId":"tu_1","content":[{"json":{"sky":"clear"}}]}},{"text":"thanks"}]}
+		],
+		"inferenceConfig":{"maxTokens":256,"temperature":0.3,"stopSequences":["END"]},
+		"toolConfig":{"tools":[{"toolSpec":{"name":"get_weather","inputSchema":{"json":{"type":"object"}}}}],"toolChoice":{"any":{}}}
+	}`)
+	out, err := convertConverseRequest("claude-sonnet-4", raw, true)
+	if err != nil {
+		t.Fatalf("convertConverseRequest: %v", err)
+	}
+	req := gjson.ParseBytes(out)
+	if req.Get("model").String() != "claude-sonnet-4" || !req.Get("stream").Bool() || !req.Get("stream_options.include_usage").Bool() {
+		t.Fatalf("request = %s", out)
+	}
+	if req.Get("messages.0.role").String() != "system" || req.Get("messages.1.content").String() != "weather in Paris?" {
+		t.Fatalf("leading messages = %s", req.Get("messages").Raw)
+	}
+	if req.Get("messages.2.tool_calls.0.function.arguments").String() != `{"city":"Paris"}` {
+		t.Fatalf("tool call = %s", req.Get("messages.2").Raw)
+	}
+	if req.Get("messages.3.role").String() != "tool" || req.Get("messages.3.tool_call_id").String() != "tu_1" || req.Get("messages.4.content").String() != "thanks" {
+		t.Fatalf("tool result must precede the user text: %s", req.Get("messages").Raw)
+	}
+	if req.Get("max_tokens").Int() != 256 || req.Get("stop.0").String() != "END" || req.Get("tool_choice").String() != "required" {
+		t.Fatalf("settings = %s", out)
+	}
+	if req.Get("tools.0.function.parameters.type").String() != "object" {
+		t.Fatalf("tools = %s", req.Get("tools").Raw)
+	}
+
+	if _, err = convertConverseRequest("m", []byte(`{"messages":[{"role":"user","content":[{"video":{}}]}]}`), false); err == nil {
+		t.Fatal("unsupported blocks must be rejected")
+	}
+}
+
+func TestConvertConverseResponse(t *testing.T) {
+	completion := []byte(`{"choices":[{"message":{"role":"assistant","content":"Let me check.","tool_calls":[{"id":"tu_2","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Oslo\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`)
+	out := gjson.ParseBytes(convertConverseResponse(completion, 12))
+	if out.Get("output.message.content.0.text").String() != "Let me check." || out.Get("output.message.content.1.toolUse.input.city").String() != "Oslo" {
+		t.Fatalf("content = %s", out.Get("output").Raw)
+	}
+	if out.Get("stopReason").String() != "tool_use" || out.Get("usage.totalTokens").Int() != 15 || out.Get("metrics.latencyMs").Int() != 12 {
+		t.Fatalf("response = %s", out.Raw)
+	}
+}
+
+func TestStreamConverter(t *testing.T) {
+	s := newStreamConverter()
+	var events []streamEvent
+	for _, chunk := range []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"tu_1","function":{"name":"f","arguments":"{\"x\":"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":4}}`,
+	} {
+		events = append(events, s.convert([]byte(chunk))...)
+	}
+	events = append(events, s.finish(false, 5)...)
+
+	want := []string{"messageStart", "contentBlockDelta", "contentBlockStop", "contentBlockStart", "contentBlockDelta", "contentBlockDelta", "contentBlockStop", "messageStop", "metadata"}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.eventType != want[i] {
+			t.Fatalf("event %d = %s, want %s", i, e.eventType, want[i])
+		}
+	}
+	if gjson.GetBytes(events[3].payload, "contentBlockIndex").Int() != 1 || gjson.GetBytes(events[3].payload, "start.toolUse.name").String() != "f" {
+		t.Fatalf("tool block start = %s", events[3].payload)
+	}
+	if gjson.GetBytes(events[7].payload, "stopReason").String() != "tool_use" || gjson.GetBytes(events[8].payload, "usage.outputTokens").Int() != 4 {
+		t.Fatalf("closing events = %s %s", events[7].payload, events[8].payload)
+	}
+}
+
+func TestEncodeEventStreamMessage(t *testing.T) {
+	payload := []byte(`{"role":"assistant"}`)
+	msg := encodeEvent(streamEvent{eventType: "messageStart", payload: payload})
+
+	total := binary.BigEndian.Uint32(msg[0:4])
+	headersLen := binary.BigEndian.Uint32(msg[4:8])
+	if int(total) != len(msg) {
+		t.Fatalf("total length = %d, message is %d bytes", total, len(msg))
+	}
+	if binary.BigEndian.Uint32(msg[8:12]) != crc32.ChecksumIEEE(msg[:8]) {
+		t.Fatal("prelude CRC mismatch")
+	}
+	if binary.BigEndian.Uint32(msg[len(msg)-4:]) != crc32.ChecksumIEEE(msg[:len(msg)-4]) {
+		t.Fatal("message CRC mismatch")
+	}
+	headers := msg[12 : 12+headersLen]
+	if nameLen := int(headers[0]); string(headers[1:1+nameLen]) != ":event-type" {
+		t.Fatalf("first header = %q", headers[1:1+nameLen])
+	}
+	if got := msg[12+headersLen : len(msg)-4]; string(got) != string(payload) {
+		t.Fatalf("payload = %s", got)
+	}
+}
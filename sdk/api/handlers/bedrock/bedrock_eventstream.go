@@ -0,0 +1,56 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// eventStreamContentType is the media type of AWS event-stream responses.
+const eventStreamContentType = "application/vnd.amazon.eventstream"
+
+// eventStreamHeaderString is the header value type of UTF-8 strings.
+const eventStreamHeaderString = 7
+
+// encodeEventStreamMessage frames one AWS event-stream message:
+//
+//	total length (4) | headers length (4) | prelude CRC (4) | headers | payload | message CRC (4)
+//
+// Every header is a string; name and value lengths are bounded by the fixed header names
+// and event types used here.
+func encodeEventStreamMessage(headers [][2]string, payload []byte) []byte {
+	var encoded []byte
+	for _, header := range headers {
+		encoded = append(encoded, byte(len(header[0])))
+		encoded = append(encoded, header[0]...)
+		encoded = append(encoded, eventStreamHeaderString)
+		encoded = binary.BigEndian.AppendUint16(encoded, uint16(len(header[1])))
+		encoded = append(encoded, header[1]...)
+	}
+	total := 12 + len(encoded) + len(payload) + 4
+	msg := make([]byte, 0, total)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(total))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(encoded)))
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+	msg = append(msg, encoded...)
+	msg = append(msg, payload...)
+	return binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+}
+
+// encodeEvent frames a ConverseStream event.
+func encodeEvent(e streamEvent) []byte {
+	return encodeEventStreamMessage([][2]string{
+		{":event-type", e.eventType},
+		{":content-type", "application/json"},
+		{":message-type", "event"},
+	}, e.payload)
+}
+
+// encodeException frames a stream exception, which ends a ConverseStream response that
+// fails after the first event was sent.
+func encodeException(exceptionType string, payload []byte) []byte {
+	return encodeEventStreamMessage([][2]string{
+		{":exception-type", exceptionType},
+		{":content-type", "application/json"},
+		{":message-type", "exception"},
+	}, payload)
+}
@@ -0,0 +1,234 @@
+// Package bedrock provides HTTP handlers for the Bedrock Runtime Converse API, so agents
+// written against Bedrock SDKs can point their endpoint at the proxy and be served by any
+// provider. Requests are converted to OpenAI chat completions and executed through the
+// OpenAI translators; responses are converted back into Converse responses or, for
+// ConverseStream, AWS event-stream framed events.
+//
+// Clients authenticate with a proxy API key sent as a bearer token, the way Bedrock API
+// keys are sent; SigV4 signatures are not verified.
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// BedrockAPIHandler contains the handlers for the Bedrock Runtime Converse endpoints.
+type BedrockAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewBedrockAPIHandler creates a new Bedrock API handlers instance.
+func NewBedrockAPIHandler(apiHandlers *handlers.BaseAPIHandler) *BedrockAPIHandler {
+	return &BedrockAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation. Converted requests
+// are OpenAI chat completions, so they are translated from the OpenAI format.
+func (h *BedrockAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns a list of models supported by this handler.
+func (h *BedrockAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// ModelHandler handles POST /model/{modelId}/converse and /model/{modelId}/converse-stream.
+// The model id is taken from everything before the operation, so percent-encoded ARNs
+// containing slashes are supported.
+func (h *BedrockAPIHandler) ModelHandler(c *gin.Context) {
+	action := strings.TrimPrefix(c.Param("action"), "/")
+	idx := strings.LastIndex(action, "/")
+	if idx <= 0 {
+		h.writeError(c, http.StatusNotFound, "unknown operation")
+		return
+	}
+	modelID, operation := action[:idx], action[idx+1:]
+	var stream bool
+	switch operation {
+	case "converse":
+	case "converse-stream":
+		stream = true
+	default:
+		h.writeError(c, http.StatusNotFound, fmt.Sprintf("unsupported operation %q", operation))
+		return
+	}
+
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	openaiJSON, err := convertConverseRequest(modelID, rawJSON, stream)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if stream {
+		h.handleStreamingResponse(c, modelID, openaiJSON)
+		return
+	}
+
+	start := time.Now()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelID, openaiJSON, "")
+	stopKeepAlive()
+	if errMsg != nil {
+		h.writeErrorMessage(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", convertConverseResponse(resp, time.Since(start).Milliseconds()))
+	cliCancel()
+}
+
+func (h *BedrockAPIHandler) handleStreamingResponse(c *gin.Context, modelID string, openaiJSON []byte) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		h.writeError(c, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	start := time.Now()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelID, openaiJSON, "")
+	converter := newStreamConverter()
+	writeEvents := func(events []streamEvent) {
+		for _, e := range events {
+			_, _ = c.Writer.Write(encodeEvent(e))
+		}
+	}
+
+	// Peek at the first chunk to determine success or failure before setting headers
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cliCancel(c.Request.Context().Err())
+			return
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			h.writeErrorMessage(c, errMsg)
+			if errMsg != nil {
+				cliCancel(errMsg.Error)
+			} else {
+				cliCancel(nil)
+			}
+			return
+		case chunk, ok := <-dataChan:
+			c.Header("Content-Type", eventStreamContentType)
+			if !ok {
+				writeEvents(converter.finish(false, time.Since(start).Milliseconds()))
+				flusher.Flush()
+				cliCancel(nil)
+				return
+			}
+			writeEvents(converter.convert(chunk))
+			flusher.Flush()
+
+			// Event-stream framing has no heartbeat message.
+			noKeepAlive := time.Duration(0)
+			h.ForwardStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, handlers.StreamForwardOptions{
+				KeepAliveInterval: &noKeepAlive,
+				WriteChunk: func(chunk []byte) {
+					writeEvents(converter.convert(chunk))
+				},
+				WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
+					if errMsg == nil {
+						return
+					}
+					exception := errorType(statusOf(errMsg))
+					_, _ = c.Writer.Write(encodeException(strings.ToLower(exception[:1])+exception[1:], errorBody(errorText(errMsg))))
+				},
+				WriteDone: func() {
+					writeEvents(converter.finish(false, time.Since(start).Milliseconds()))
+				},
+				WriteTruncated: func() {
+					writeEvents(converter.finish(true, time.Since(start).Milliseconds()))
+				},
+				WrittenBytes: int64(len(chunk)),
+			})
+			return
+		}
+	}
+}
+
+// errorType returns the Bedrock exception name for an HTTP status.
+func errorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return "AccessDeniedException"
+	case status == http.StatusNotFound:
+		return "ResourceNotFoundException"
+	case status == http.StatusTooManyRequests:
+		return "ThrottlingException"
+	case status == http.StatusServiceUnavailable:
+		return "ServiceUnavailableException"
+	case status >= 400 && status < 500:
+		return "ValidationException"
+	default:
+		return "InternalServerException"
+	}
+}
+
+func statusOf(errMsg *interfaces.ErrorMessage) int {
+	if errMsg != nil && errMsg.StatusCode > 0 {
+		return errMsg.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// writeErrorMessage writes an execution error the way Bedrock reports errors.
+func (h *BedrockAPIHandler) writeErrorMessage(c *gin.Context, errMsg *interfaces.ErrorMessage) {
+	h.writeError(c, statusOf(errMsg), errorText(errMsg))
+}
+
+// writeError writes a Bedrock error: the exception name in x-amzn-ErrorType and a
+// {"message": "..."} body. With error-format unified it writes the proxy envelope instead.
+func (h *BedrockAPIHandler) writeError(c *gin.Context, status int, message string) {
+	if handlers.UnifiedErrorsEnabled(h.Cfg) {
+		c.Data(status, "application/json", h.ErrorResponseBody(c, status, message))
+		return
+	}
+	c.Header("x-amzn-ErrorType", errorType(status))
+	c.Data(status, "application/json", errorBody(message))
+}
+
+func errorBody(message string) []byte {
+	body, _ := json.Marshal(gin.H{"message": message})
+	return body
+}
+
+// errorText returns the message of an execution error, unwrapping JSON error bodies
+// returned by providers.
+func errorText(errMsg *interfaces.ErrorMessage) string {
+	if errMsg == nil || errMsg.Error == nil {
+		return http.StatusText(http.StatusInternalServerError)
+	}
+	text := strings.TrimSpace(errMsg.Error.Error())
+	if gjson.Valid(text) {
+		for _, path := range []string{"error.message", "error", "message"} {
+			if v := gjson.Get(text, path); v.Type == gjson.String && v.String() != "" {
+				return v.String()
+			}
+		}
+	}
+	return text
+}
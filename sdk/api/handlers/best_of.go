@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// BestOfHeader lists, comma separated, the models a request is sent to in parallel
+	// besides the requested one.
+	BestOfHeader = "X-Best-Of"
+
+	// BestOfModeHeader selects what a best-of request returns: BestOfModeFastest (the
+	// default) or BestOfModeAll.
+	BestOfModeHeader = "X-Best-Of-Mode"
+
+	// BestOfModelHeader names the model that answered a fastest-mode request.
+	BestOfModelHeader = "X-Best-Of-Model"
+)
+
+const (
+	// BestOfModeFastest returns the first successful response unchanged and cancels the
+	// other requests.
+	BestOfModeFastest = "fastest"
+
+	// BestOfModeAll waits for every model and returns all responses in one payload.
+	BestOfModeAll = "all"
+)
+
+// bestOfRequest is a validated best-of request.
+type bestOfRequest struct {
+	models []string
+	mode   string
+}
+
+// bestOfResult is the outcome of sending a best-of request to one model.
+type bestOfResult struct {
+	index   int
+	resp    []byte
+	errMsg  *interfaces.ErrorMessage
+	latency time.Duration
+}
+
+// bestOfFromContext reads the best-of headers of the request behind ctx. It reports false
+// when best-of is disabled or not requested, and an error for invalid headers.
+func bestOfFromContext(cfg *config.SDKConfig, ctx context.Context, modelName string) (bestOfRequest, bool, *interfaces.ErrorMessage) {
+	if cfg == nil || !cfg.BestOf.Enabled || ctx == nil {
+		return bestOfRequest{}, false, nil
+	}
+	c, _ := ctx.Value("gin").(*gin.Context)
+	if c == nil || c.Request == nil {
+		return bestOfRequest{}, false, nil
+	}
+	header := strings.TrimSpace(c.GetHeader(BestOfHeader))
+	if header == "" {
+		return bestOfRequest{}, false, nil
+	}
+
+	models := []string{modelName}
+	seen := map[string]bool{modelName: true}
+	for _, model := range strings.Split(header, ",") {
+		model = strings.TrimSpace(model)
+		if model == "" || seen[model] {
+			continue
+		}
+		seen[model] = true
+		models = append(models, model)
+	}
+	maxModels := cfg.BestOf.MaxModels
+	if maxModels <= 0 {
+		maxModels = config.DefaultBestOfMaxModels
+	}
+	if len(models) > maxModels {
+		return bestOfRequest{}, true, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("%s names %d models, at most %d are allowed", BestOfHeader, len(models), maxModels)}
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(c.GetHeader(BestOfModeHeader)))
+	switch mode {
+	case "":
+		mode = BestOfModeFastest
+	case BestOfModeFastest, BestOfModeAll:
+	default:
+		return bestOfRequest{}, true, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("unknown %s %q, expected %q or %q", BestOfModeHeader, mode, BestOfModeFastest, BestOfModeAll)}
+	}
+	return bestOfRequest{models: models, mode: mode}, true, nil
+}
+
+// executeWithBestOf runs a non-streaming request as a best-of request when the client
+// asked for one.
+func (h *BaseAPIHandler) executeWithBestOf(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	bestOf, requested, errMsg := bestOfFromContext(h.Cfg, ctx, modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if !requested || len(bestOf.models) < 2 {
+		return h.executeWithLocalePolicy(ctx, handlerType, modelName, rawJSON, alt)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan bestOfResult, len(bestOf.models))
+	for i, model := range bestOf.models {
+		go func(index int, model string) {
+			start := time.Now()
+			resp, errMsg := h.executeWithLocalePolicy(runCtx, handlerType, model, rawJSON, alt)
+			results <- bestOfResult{index: index, resp: resp, errMsg: errMsg, latency: time.Since(start)}
+		}(i, model)
+	}
+
+	collected := make([]bestOfResult, len(bestOf.models))
+	for range bestOf.models {
+		result := <-results
+		collected[result.index] = result
+		if bestOf.mode == BestOfModeFastest && result.errMsg == nil {
+			if c, ok := ctx.Value("gin").(*gin.Context); ok && c != nil {
+				c.Header(BestOfModelHeader, bestOf.models[result.index])
+			}
+			return result.resp, nil
+		}
+	}
+
+	if bestOf.mode == BestOfModeFastest {
+		// Every model failed; report the requested model's error.
+		return nil, collected[0].errMsg
+	}
+	for _, result := range collected {
+		if result.errMsg == nil {
+			return buildBestOfPayload(bestOf, collected), nil
+		}
+	}
+	return nil, collected[0].errMsg
+}
+
+// buildBestOfPayload combines the responses of an all-mode request. Responses keep the
+// client's API format; failed models carry their status and error message instead.
+func buildBestOfPayload(bestOf bestOfRequest, results []bestOfResult) []byte {
+	out := `{"object":"best_of","mode":"","results":[]}`
+	out, _ = sjson.Set(out, "mode", bestOf.mode)
+	for i, result := range results {
+		item := `{"model":"","status":200,"latency_ms":0}`
+		item, _ = sjson.Set(item, "model", bestOf.models[i])
+		item, _ = sjson.Set(item, "latency_ms", result.latency.Milliseconds())
+		if result.errMsg != nil {
+			status := result.errMsg.StatusCode
+			if status <= 0 {
+				status = http.StatusInternalServerError
+			}
+			errText := ""
+			if result.errMsg.Error != nil {
+				errText = result.errMsg.Error.Error()
+			}
+			item, _ = sjson.Set(item, "status", status)
+			item, _ = sjson.Set(item, "error", proxyErrorMessage(status, errText))
+		} else if gjson.ValidBytes(result.resp) {
+			item, _ = sjson.SetRaw(item, "response", string(result.resp))
+		} else {
+			item, _ = sjson.Set(item, "response", string(result.resp))
+		}
+		out, _ = sjson.SetRaw(out, "results.-1", item)
+	}
+	return []byte(out)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// bestOfExecutor answers per model: "best-of-slow" after a delay, "best-of-broken" with an
+// error and any other model at once.
+type bestOfExecutor struct {
+	failOnceStreamExecutor
+}
+
+func (e *bestOfExecutor) Identifier() string { return "best-of-test" }
+
+func (e *bestOfExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	switch req.Model {
+	case "best-of-broken":
+		return coreexecutor.Response{}, &coreauth.Error{Code: "rate_limited", Message: "slow down", HTTPStatus: http.StatusTooManyRequests}
+	case "best-of-slow":
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return coreexecutor.Response{}, ctx.Err()
+		}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"model":` + jsonString(req.Model) + `}`)}, nil
+}
+
+func newBestOfHandler(t *testing.T, cfg *sdkconfig.SDKConfig) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&bestOfExecutor{})
+	auth := &coreauth.Auth{ID: "best-of-auth", Provider: "best-of-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "best-of-fast"}, {ID: "best-of-slow"}, {ID: "best-of-broken"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(cfg, manager)
+}
+
+func bestOfContext(headers map[string]string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	for k, v := range headers {
+		c.Request.Header.Set(k, v)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestExecuteWithBestOf_Fastest(t *testing.T) {
+	handler := newBestOfHandler(t, &sdkconfig.SDKConfig{BestOf: sdkconfig.BestOfConfig{Enabled: true}})
+	ctx := bestOfContext(map[string]string{BestOfHeader: "best-of-broken, best-of-fast"})
+
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "best-of-slow", []byte(`{}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "model").String(); got != "best-of-fast" {
+		t.Fatalf("response from %q, want the fastest model", got)
+	}
+	c := ctx.Value("gin").(*gin.Context)
+	if got := c.Writer.Header().Get(BestOfModelHeader); got != "best-of-fast" {
+		t.Fatalf("%s = %q", BestOfModelHeader, got)
+	}
+}
+
+func TestExecuteWithBestOf_All(t *testing.T) {
+	handler := newBestOfHandler(t, &sdkconfig.SDKConfig{BestOf: sdkconfig.BestOfConfig{Enabled: true}})
+	ctx := bestOfContext(map[string]string{BestOfHeader: "best-of-slow,best-of-broken", BestOfModeHeader: "all"})
+
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "best-of-fast", []byte(`{}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	results := gjson.GetBytes(resp, "results").Array()
+	if len(results) != 3 {
+		t.Fatalf("results = %s", resp)
+	}
+	for i, model := range []string{"best-of-fast", "best-of-slow"} {
+		if results[i].Get("model").String() != model || results[i].Get("response.model").String() != model {
+			t.Fatalf("result %d = %s", i, results[i].Raw)
+		}
+	}
+	if results[2].Get("status").Int() != http.StatusTooManyRequests || results[2].Get("error").String() == "" {
+		t.Fatalf("failed result = %s", results[2].Raw)
+	}
+}
+
+func TestExecuteWithBestOf_Validation(t *testing.T) {
+	handler := newBestOfHandler(t, &sdkconfig.SDKConfig{BestOf: sdkconfig.BestOfConfig{Enabled: true, MaxModels: 2}})
+
+	ctx := bestOfContext(map[string]string{BestOfHeader: "best-of-slow,best-of-broken"})
+	if _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "best-of-fast", []byte(`{}`), ""); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("too many models must be rejected, got %+v", errMsg)
+	}
+	ctx = bestOfContext(map[string]string{BestOfHeader: "best-of-slow", BestOfModeHeader: "cheapest"})
+	if _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "best-of-fast", []byte(`{}`), ""); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown mode must be rejected, got %+v", errMsg)
+	}
+	ctx = bestOfContext(map[string]string{BestOfHeader: "best-of-slow"})
+	_, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "best-of-fast", []byte(`{}`), "")
+	if errMsg := <-errChan; errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("streaming best-of must be rejected, got %+v", errMsg)
+	}
+}
+
+func TestExecuteWithBestOf_DisabledIgnoresHeader(t *testing.T) {
+	handler := newBestOfHandler(t, &sdkconfig.SDKConfig{})
+	ctx := bestOfContext(map[string]string{BestOfHeader: "best-of-fast"})
+
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "best-of-slow", []byte(`{}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "model").String(); got != "best-of-slow" {
+		t.Fatalf("response from %q, want only the requested model", got)
+	}
+}
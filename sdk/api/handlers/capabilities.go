@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CapabilitiesHeader lets a client declare, comma separated, which optional parts of the
+// OpenAI chat completions output it handles. Without the header the output is left as
+// the translators produce it; with it, the parts the client does not name are tailored
+// away.
+const CapabilitiesHeader = "X-CLIProxy-Capabilities"
+
+const (
+	// CapabilityReasoning covers reasoning deltas and reasoning fields of messages.
+	// Without it they are removed.
+	CapabilityReasoning = "reasoning"
+
+	// CapabilityToolCallStreaming covers tool calls streamed in pieces. Without it each
+	// tool call is sent whole, in one chunk before its choice finishes.
+	CapabilityToolCallStreaming = "tool-call-streaming"
+
+	// CapabilityMultiChoice covers responses with several choices. Without it n is
+	// removed from requests and only the first choice is returned.
+	CapabilityMultiChoice = "multi-choice"
+
+	// CapabilityUsageChunks covers usage reported in stream chunks. Without it usage is
+	// removed from streamed chunks; non-streaming responses keep theirs.
+	CapabilityUsageChunks = "usage-chunks"
+)
+
+// reasoningFields are the message and delta fields carrying reasoning.
+var reasoningFields = []string{"reasoning_content", "reasoning", "reasoning_details"}
+
+// clientCapabilities is what a client declared in CapabilitiesHeader.
+type clientCapabilities struct {
+	reasoning         bool
+	toolCallStreaming bool
+	multiChoice       bool
+	usageChunks       bool
+}
+
+// capabilitiesFromContext reads CapabilitiesHeader of the request behind ctx. It reports
+// false when the header is absent or the handler's output is not tailored.
+func capabilitiesFromContext(ctx context.Context, handlerType string) (clientCapabilities, bool) {
+	if ctx == nil || handlerType != constant.OpenAI {
+		return clientCapabilities{}, false
+	}
+	c, _ := ctx.Value("gin").(*gin.Context)
+	if c == nil || c.Request == nil {
+		return clientCapabilities{}, false
+	}
+	values := c.Request.Header.Values(CapabilitiesHeader)
+	if len(values) == 0 {
+		return clientCapabilities{}, false
+	}
+	var caps clientCapabilities
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case CapabilityReasoning:
+				caps.reasoning = true
+			case CapabilityToolCallStreaming:
+				caps.toolCallStreaming = true
+			case CapabilityMultiChoice:
+				caps.multiChoice = true
+			case CapabilityUsageChunks:
+				caps.usageChunks = true
+			}
+		}
+	}
+	return caps, true
+}
+
+// tailorRequest removes request options asking for output the client cannot handle.
+func (caps clientCapabilities) tailorRequest(rawJSON []byte) []byte {
+	if !caps.multiChoice && gjson.GetBytes(rawJSON, "n").Exists() {
+		rawJSON, _ = sjson.DeleteBytes(rawJSON, "n")
+	}
+	return rawJSON
+}
+
+// tailorResponse tailors a non-streaming chat completion.
+func (caps clientCapabilities) tailorResponse(resp []byte) []byte {
+	choices := gjson.GetBytes(resp, "choices")
+	if !choices.IsArray() || (caps.reasoning && caps.multiChoice) {
+		return resp
+	}
+	out := "[]"
+	for _, choice := range choices.Array() {
+		if !caps.multiChoice && choice.Get("index").Int() != 0 {
+			continue
+		}
+		raw := choice.Raw
+		if !caps.reasoning {
+			for _, field := range reasoningFields {
+				raw, _ = sjson.Delete(raw, "message."+field)
+			}
+		}
+		out, _ = sjson.SetRaw(out, "-1", raw)
+	}
+	tailored, err := sjson.SetRawBytes(resp, "choices", []byte(out))
+	if err != nil {
+		return resp
+	}
+	return tailored
+}
+
+// tailorStream tailors the chunks of a streamed chat completion. The returned channel
+// closes after data does; chunks are dropped once ctx is done.
+func (caps clientCapabilities) tailorStream(ctx context.Context, data <-chan []byte) <-chan []byte {
+	out := make(chan []byte)
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	go func() {
+		defer close(out)
+		stream := &capabilityStream{caps: caps}
+		send := func(chunks [][]byte) bool {
+			for _, chunk := range chunks {
+				select {
+				case out <- chunk:
+				case <-done:
+					return false
+				}
+			}
+			return true
+		}
+		for chunk := range data {
+			if !send(stream.process(chunk)) {
+				for range data {
+				}
+				return
+			}
+		}
+		send(stream.finish())
+	}()
+	return out
+}
+
+// capabilityStream tailors one streamed chat completion.
+type capabilityStream struct {
+	caps clientCapabilities
+
+	// toolCalls holds the tool calls being assembled, by choice and tool call index.
+	toolCalls map[int]map[int]*bufferedToolCall
+	// template is the last chunk seen, used to build the chunks of assembled tool calls.
+	template []byte
+}
+
+// bufferedToolCall is a tool call assembled from its streamed pieces.
+type bufferedToolCall struct {
+	id, callType, name string
+	arguments          strings.Builder
+}
+
+// process returns the chunks to send in place of chunk.
+func (s *capabilityStream) process(chunk []byte) [][]byte {
+	choices := gjson.GetBytes(chunk, "choices")
+	if !choices.IsArray() {
+		return [][]byte{chunk}
+	}
+	s.template = chunk
+
+	var before [][]byte
+	modified := false
+	out := "[]"
+	for _, choice := range choices.Array() {
+		index := int(choice.Get("index").Int())
+		if !s.caps.multiChoice && index != 0 {
+			modified = true
+			continue
+		}
+		raw := choice.Raw
+		changed := false
+		if !s.caps.reasoning {
+			for _, field := range reasoningFields {
+				if choice.Get("delta." + field).Exists() {
+					raw, _ = sjson.Delete(raw, "delta."+field)
+					changed = true
+				}
+			}
+		}
+		if !s.caps.toolCallStreaming {
+			if calls := choice.Get("delta.tool_calls"); calls.Exists() {
+				s.buffer(index, calls)
+				raw, _ = sjson.Delete(raw, "delta.tool_calls")
+				changed = true
+			}
+			if choice.Get("finish_reason").String() != "" {
+				if toolChunk := s.flushToolCalls(index); toolChunk != nil {
+					before = append(before, toolChunk)
+				}
+			}
+		}
+		if changed {
+			modified = true
+			// A choice left with nothing to say is dropped.
+			if isEmptyChoice(gjson.Parse(raw)) {
+				continue
+			}
+		}
+		out, _ = sjson.SetRaw(out, "-1", raw)
+	}
+
+	tailored := chunk
+	if modified {
+		tailored, _ = sjson.SetRawBytes(chunk, "choices", []byte(out))
+	}
+	if !s.caps.usageChunks && gjson.GetBytes(tailored, "usage").Exists() {
+		tailored, _ = sjson.DeleteBytes(tailored, "usage")
+		modified = true
+	}
+	if modified && len(gjson.GetBytes(tailored, "choices").Array()) == 0 {
+		return before
+	}
+	return append(before, tailored)
+}
+
+// finish returns the tool calls of choices the stream ended without finishing.
+func (s *capabilityStream) finish() [][]byte {
+	indexes := make([]int, 0, len(s.toolCalls))
+	for index := range s.toolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	var out [][]byte
+	for _, index := range indexes {
+		if chunk := s.flushToolCalls(index); chunk != nil {
+			out = append(out, chunk)
+		}
+	}
+	return out
+}
+
+// buffer adds the streamed tool call pieces of a choice.
+func (s *capabilityStream) buffer(choiceIndex int, calls gjson.Result) {
+	if s.toolCalls == nil {
+		s.toolCalls = make(map[int]map[int]*bufferedToolCall)
+	}
+	byIndex := s.toolCalls[choiceIndex]
+	if byIndex == nil {
+		byIndex = make(map[int]*bufferedToolCall)
+		s.toolCalls[choiceIndex] = byIndex
+	}
+	for _, call := range calls.Array() {
+		index := int(call.Get("index").Int())
+		buffered := byIndex[index]
+		if buffered == nil {
+			buffered = &bufferedToolCall{callType: "function"}
+			byIndex[index] = buffered
+		}
+		if id := call.Get("id").String(); id != "" {
+			buffered.id = id
+		}
+		if callType := call.Get("type").String(); callType != "" {
+			buffered.callType = callType
+		}
+		if name := call.Get("function.name").String(); name != "" {
+			buffered.name = name
+		}
+		buffered.arguments.WriteString(call.Get("function.arguments").String())
+	}
+}
+
+// flushToolCalls builds the chunk carrying the assembled tool calls of a choice, or nil
+// when it has none.
+func (s *capabilityStream) flushToolCalls(choiceIndex int) []byte {
+	byIndex := s.toolCalls[choiceIndex]
+	delete(s.toolCalls, choiceIndex)
+	if len(byIndex) == 0 || s.template == nil {
+		return nil
+	}
+	indexes := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	calls := "[]"
+	for _, index := range indexes {
+		buffered := byIndex[index]
+		call := `{"index":0,"id":"","type":"function","function":{"name":"","arguments":""}}`
+		call, _ = sjson.Set(call, "index", index)
+		call, _ = sjson.Set(call, "id", buffered.id)
+		call, _ = sjson.Set(call, "type", buffered.callType)
+		call, _ = sjson.Set(call, "function.name", buffered.name)
+		call, _ = sjson.Set(call, "function.arguments", buffered.arguments.String())
+		calls, _ = sjson.SetRaw(calls, "-1", call)
+	}
+	choice := `{"index":0,"delta":{},"finish_reason":null}`
+	choice, _ = sjson.Set(choice, "index", choiceIndex)
+	choice, _ = sjson.SetRaw(choice, "delta.tool_calls", calls)
+	out, _ := sjson.DeleteBytes(s.template, "usage")
+	out, _ = sjson.SetRawBytes(out, "choices", []byte("["+choice+"]"))
+	return out
+}
+
+// isEmptyChoice reports whether a streamed choice carries no delta, finish reason or
+// logprobs.
+func isEmptyChoice(choice gjson.Result) bool {
+	if choice.Get("finish_reason").String() != "" || choice.Get("logprobs").IsObject() {
+		return false
+	}
+	delta := choice.Get("delta")
+	empty := true
+	delta.ForEach(func(_, value gjson.Result) bool {
+		if value.Type != gjson.Null {
+			empty = false
+		}
+		return empty
+	})
+	return empty
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+func capabilitiesContext(header string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if header != "" {
+		c.Request.Header.Set(CapabilitiesHeader, header)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestCapabilitiesFromContext(t *testing.T) {
+	if _, ok := capabilitiesFromContext(capabilitiesContext(""), "openai"); ok {
+		t.Fatal("no header must leave the output untouched")
+	}
+	if _, ok := capabilitiesFromContext(capabilitiesContext("reasoning"), "claude"); ok {
+		t.Fatal("only OpenAI chat output is tailored")
+	}
+	caps, ok := capabilitiesFromContext(capabilitiesContext(" Reasoning, usage-chunks, future-thing"), "openai")
+	if !ok || caps != (clientCapabilities{reasoning: true, usageChunks: true}) {
+		t.Fatalf("caps = %+v, %v", caps, ok)
+	}
+}
+
+func TestCapabilityStream_Tailors(t *testing.T) {
+	stream := &capabilityStream{}
+	chunks := []string{
+		`{"id":"c1","choices":[{"index":0,"delta":{"role":"assistant"},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"reasoning_content":"hmm"},"finish_reason":null},{"index":1,"delta":{"content":"other"},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"f","arguments":""}}]},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"a\":"}}]},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"c1","choices":[],"usage":{"total_tokens":3}}`,
+	}
+	var out []string
+	for _, chunk := range chunks {
+		for _, tailored := range stream.process([]byte(chunk)) {
+			out = append(out, string(tailored))
+		}
+	}
+	for _, tailored := range stream.finish() {
+		out = append(out, string(tailored))
+	}
+
+	want := []string{
+		`{"id":"c1","choices":[{"index":0,"delta":{"role":"assistant"},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"f","arguments":"{\"a\":1}"}}]},"finish_reason":null}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+	}
+	if len(out) != len(want) {
+		t.Fatalf("chunks = %q", out)
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Fatalf("chunk %d = %s, want %s", i, out[i], want[i])
+		}
+	}
+}
+
+func TestCapabilityStream_KeepsDeclared(t *testing.T) {
+	stream := &capabilityStream{caps: clientCapabilities{reasoning: true, toolCallStreaming: true, multiChoice: true, usageChunks: true}}
+	for _, chunk := range []string{
+		`{"choices":[{"index":0,"delta":{"reasoning_content":"hmm"}},{"index":1,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{"}}]}}]}`,
+		`{"choices":[],"usage":{"total_tokens":3}}`,
+	} {
+		if out := stream.process([]byte(chunk)); len(out) != 1 || string(out[0]) != chunk {
+			t.Fatalf("declared capabilities must pass through, got %q", out)
+		}
+	}
+}
+
+func TestCapabilities_RequestAndResponse(t *testing.T) {
+	caps := clientCapabilities{usageChunks: true}
+	if out := caps.tailorRequest([]byte(`{"model":"m","n":3}`)); gjson.GetBytes(out, "n").Exists() {
+		t.Fatalf("n must be removed without multi-choice: %s", out)
+	}
+	resp := caps.tailorResponse([]byte(`{"choices":[{"index":0,"message":{"content":"a","reasoning_content":"r"}},{"index":1,"message":{"content":"b"}}],"usage":{"total_tokens":3}}`))
+	if got := gjson.GetBytes(resp, "choices.#").Int(); got != 1 || gjson.GetBytes(resp, "choices.0.message.reasoning_content").Exists() || !gjson.GetBytes(resp, "usage").Exists() {
+		t.Fatalf("response = %s", resp)
+	}
+}
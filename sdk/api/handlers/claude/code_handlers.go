@@ -10,7 +10,6 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,6 +21,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // ClaudeCodeAPIHandler contains the handlers for Claude API endpoints.
@@ -78,7 +78,7 @@ func (h *ClaudeCodeAPIHandler) ClaudeMessages(c *gin.Context) {
 
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
-	if !streamResult.Exists() || streamResult.Type == gjson.False {
+	if !streamResult.Exists() || streamResult.Type == gjson.False || handlers.StreamAggregationRequested(c) {
 		h.handleNonStreamingResponse(c, rawJSON)
 	} else {
 		h.handleStreamingResponse(c, rawJSON)
@@ -114,7 +114,7 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 
 	resp, errMsg := h.ExecuteCountWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 	if errMsg != nil {
-		h.WriteErrorResponse(c, errMsg)
+		h.WriteClaudeErrorResponse(c, errMsg)
 		cliCancel(errMsg.Error)
 		return
 	}
@@ -153,7 +153,7 @@ func (h *ClaudeCodeAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSO
 	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 	stopKeepAlive()
 	if errMsg != nil {
-		h.WriteErrorResponse(c, errMsg)
+		h.WriteClaudeErrorResponse(c, errMsg)
 		cliCancel(errMsg.Error)
 		return
 	}
@@ -231,7 +231,7 @@ func (h *ClaudeCodeAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON [
 				continue
 			}
 			// Upstream failed immediately. Return proper error status and JSON.
-			h.WriteErrorResponse(c, errMsg)
+			h.WriteClaudeErrorResponse(c, errMsg)
 			if errMsg != nil {
 				cliCancel(errMsg.Error)
 			} else {
@@ -251,24 +251,27 @@ func (h *ClaudeCodeAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON [
 			setSSEHeaders()
 
 			// Write the first chunk
+			var state claudeStreamState
 			if len(chunk) > 0 {
+				state.observe(chunk)
 				_, _ = c.Writer.Write(chunk)
 				flusher.Flush()
 			}
 
 			// Continue streaming the rest
-			h.forwardClaudeStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.forwardClaudeStream(c, flusher, &state, int64(len(chunk)), func(err error) { cliCancel(err) }, dataChan, errChan)
 			return
 		}
 	}
 }
 
-func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.Flusher, state *claudeStreamState, written int64, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
 			if len(chunk) == 0 {
 				return
 			}
+			state.observe(chunk)
 			_, _ = c.Writer.Write(chunk)
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
@@ -281,28 +284,76 @@ func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.
 			}
 			c.Status(status)
 
-			errorBytes, _ := json.Marshal(h.toClaudeError(errMsg))
+			errorBytes := h.ClaudeErrorResponseBody(c, status, errMsg.Error.Error())
 			_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", errorBytes)
 		},
+		WriteTruncated: func() {
+			_, _ = c.Writer.Write(state.truncation())
+		},
+		WrittenBytes: written,
 	})
 }
 
-type claudeErrorDetail struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
+// claudeStreamState follows the Messages SSE events written to the client so a stream
+// cut at max-output-bytes can be ended the way Claude ends one: by stopping the open
+// content block, reporting usage in message_delta, then message_stop.
+type claudeStreamState struct {
+	blockOpen  bool
+	blockIndex int64
+	usage      []byte
 }
 
-type claudeErrorResponse struct {
-	Type  string            `json:"type"`
-	Error claudeErrorDetail `json:"error"`
+func (s *claudeStreamState) observe(chunk []byte) {
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		event := gjson.ParseBytes(bytes.TrimSpace(line[len("data:"):]))
+		switch event.Get("type").String() {
+		case "message_start":
+			s.mergeUsage(event.Get("message.usage"))
+		case "content_block_start":
+			s.blockOpen = true
+			s.blockIndex = event.Get("index").Int()
+		case "content_block_stop":
+			s.blockOpen = false
+		case "message_delta":
+			s.mergeUsage(event.Get("usage"))
+		}
+	}
 }
 
-func (h *ClaudeCodeAPIHandler) toClaudeError(msg *interfaces.ErrorMessage) claudeErrorResponse {
-	return claudeErrorResponse{
-		Type: "error",
-		Error: claudeErrorDetail{
-			Type:    "api_error",
-			Message: msg.Error.Error(),
-		},
+// mergeUsage keeps the latest value of every usage counter reported so far.
+func (s *claudeStreamState) mergeUsage(usage gjson.Result) {
+	if !usage.IsObject() {
+		return
+	}
+	if s.usage == nil {
+		s.usage = []byte(`{}`)
+	}
+	usage.ForEach(func(key, value gjson.Result) bool {
+		if value.Type == gjson.Number {
+			s.usage, _ = sjson.SetRawBytes(s.usage, key.String(), []byte(value.Raw))
+		}
+		return true
+	})
+}
+
+// truncation returns the events that end the stream with stop_reason max_tokens.
+func (s *claudeStreamState) truncation() []byte {
+	var out bytes.Buffer
+	if s.blockOpen {
+		stop, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", s.blockIndex)
+		fmt.Fprintf(&out, "event: content_block_stop\ndata: %s\n\n", stop)
+		s.blockOpen = false
+	}
+	usage := s.usage
+	if usage == nil {
+		usage = []byte(`{"output_tokens":0}`)
 	}
+	delta, _ := sjson.SetRawBytes([]byte(`{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null}}`), "usage", usage)
+	fmt.Fprintf(&out, "event: message_delta\ndata: %s\n\n", delta)
+	out.WriteString("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	return out.Bytes()
 }
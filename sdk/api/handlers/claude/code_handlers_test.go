@@ -0,0 +1,31 @@
+package claude
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClaudeStreamStateTruncationClosesOpenBlock(t *testing.T) {
+	var state claudeStreamState
+	state.observe([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":42,\"cache_read_input_tokens\":7,\"output_tokens\":1}}}\n\n"))
+	state.observe([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\nevent: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"hi\"}}\n\n"))
+
+	out := string(state.truncation())
+	stop := strings.Index(out, `{"type":"content_block_stop","index":1}`)
+	delta := strings.Index(out, `"stop_reason":"max_tokens"`)
+	end := strings.Index(out, `{"type":"message_stop"}`)
+	if stop < 0 || delta < stop || end < delta {
+		t.Fatalf("expected content_block_stop, message_delta, message_stop in order:\n%s", out)
+	}
+	if !strings.Contains(out, `"usage":{"input_tokens":42,"cache_read_input_tokens":7,"output_tokens":1}`) {
+		t.Fatalf("message_delta must carry the observed usage:\n%s", out)
+	}
+}
+
+func TestClaudeStreamStateTruncationAfterClosedBlock(t *testing.T) {
+	var state claudeStreamState
+	state.observe([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0}\n\nevent: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"))
+	if out := string(state.truncation()); strings.Contains(out, "content_block_stop") {
+		t.Fatalf("closed block must not be stopped again:\n%s", out)
+	}
+}
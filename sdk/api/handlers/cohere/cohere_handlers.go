@@ -0,0 +1,210 @@
+// Package cohere provides HTTP handlers for the Cohere v2 chat API, so clients built on
+// Cohere SDKs can be served by any proxied provider. Requests keep the Cohere format and
+// are translated per backend by the translators registered for the Cohere source format.
+package cohere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// CohereAPIHandler contains the handlers for the Cohere v2 endpoints.
+type CohereAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewCohereAPIHandler creates a new Cohere API handlers instance.
+func NewCohereAPIHandler(apiHandlers *handlers.BaseAPIHandler) *CohereAPIHandler {
+	return &CohereAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *CohereAPIHandler) HandlerType() string {
+	return Cohere
+}
+
+// Models returns a list of models supported by this handler.
+func (h *CohereAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// Chat handles POST /v2/chat.
+func (h *CohereAPIHandler) Chat(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if modelName == "" {
+		h.writeError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	if gjson.GetBytes(rawJSON, "stream").Bool() {
+		h.handleStreamingResponse(c, modelName, rawJSON)
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
+	stopKeepAlive()
+	if errMsg != nil {
+		h.writeErrorMessage(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func (h *CohereAPIHandler) handleStreamingResponse(c *gin.Context, modelName string, rawJSON []byte) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		h.writeError(c, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
+	setSSEHeaders := func() {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("Access-Control-Allow-Origin", "*")
+	}
+	var state streamState
+	writeChunk := func(chunk []byte) {
+		if len(bytes.TrimSpace(chunk)) == 0 {
+			return
+		}
+		state.observe(chunk)
+		_, _ = c.Writer.Write(chunk)
+		_, _ = c.Writer.Write([]byte("\n\n"))
+	}
+
+	// Peek at the first chunk to determine success or failure before setting headers
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cliCancel(c.Request.Context().Err())
+			return
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			h.writeErrorMessage(c, errMsg)
+			if errMsg != nil {
+				cliCancel(errMsg.Error)
+			} else {
+				cliCancel(nil)
+			}
+			return
+		case chunk, ok := <-dataChan:
+			setSSEHeaders()
+			if !ok {
+				_, _ = c.Writer.Write(state.end("COMPLETE"))
+				flusher.Flush()
+				cliCancel(nil)
+				return
+			}
+			writeChunk(chunk)
+			flusher.Flush()
+
+			h.ForwardStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, handlers.StreamForwardOptions{
+				WriteChunk: writeChunk,
+				WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
+					if errMsg == nil {
+						return
+					}
+					_, _ = c.Writer.Write(state.end("ERROR"))
+				},
+				WriteDone: func() {
+					_, _ = c.Writer.Write(state.end("COMPLETE"))
+				},
+				WriteTruncated: func() {
+					_, _ = c.Writer.Write(state.end("MAX_TOKENS"))
+				},
+				WrittenBytes: int64(len(chunk)),
+			})
+			return
+		}
+	}
+}
+
+// streamState records whether the stream already carried its message-end event, so a
+// stream that stops without one still ends the way Cohere clients expect.
+type streamState struct {
+	ended bool
+}
+
+func (s *streamState) observe(chunk []byte) {
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		if gjson.GetBytes(bytes.TrimSpace(line[len("data:"):]), "type").String() == "message-end" {
+			s.ended = true
+		}
+	}
+}
+
+// end returns a message-end event with finishReason, or nothing once one was sent.
+func (s *streamState) end(finishReason string) []byte {
+	if s.ended {
+		return nil
+	}
+	s.ended = true
+	return []byte(fmt.Sprintf("data: {\"type\":\"message-end\",\"delta\":{\"finish_reason\":%q}}\n\n", finishReason))
+}
+
+// writeErrorMessage writes an execution error in Cohere's {"message": "..."} shape.
+func (h *CohereAPIHandler) writeErrorMessage(c *gin.Context, errMsg *interfaces.ErrorMessage) {
+	status := http.StatusInternalServerError
+	if errMsg != nil && errMsg.StatusCode > 0 {
+		status = errMsg.StatusCode
+	}
+	h.writeError(c, status, errorText(errMsg))
+}
+
+func (h *CohereAPIHandler) writeError(c *gin.Context, status int, message string) {
+	if handlers.UnifiedErrorsEnabled(h.Cfg) {
+		c.Data(status, "application/json", h.ErrorResponseBody(c, status, message))
+		return
+	}
+	body, _ := json.Marshal(gin.H{"message": message})
+	c.Data(status, "application/json", body)
+}
+
+// errorText returns the message of an execution error, unwrapping JSON error bodies
+// returned by providers.
+func errorText(errMsg *interfaces.ErrorMessage) string {
+	if errMsg == nil || errMsg.Error == nil {
+		return http.StatusText(http.StatusInternalServerError)
+	}
+	text := strings.TrimSpace(errMsg.Error.Error())
+	if gjson.Valid(text) {
+		for _, path := range []string{"error.message", "message", "error"} {
+			if v := gjson.Get(text, path); v.Type == gjson.String && v.String() != "" {
+				return v.String()
+			}
+		}
+	}
+	return text
+}
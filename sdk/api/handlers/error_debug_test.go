@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestWriteErrorResponse_DebugIncludesUpstreamError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{DebugErrorKeys: []string{"debug-key"}}, nil)
+
+	for _, tc := range []struct {
+		apiKey    string
+		wantDebug bool
+	}{
+		{apiKey: "debug-key", wantDebug: true},
+		{apiKey: "other-key", wantDebug: false},
+	} {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		c.Set("apiKey", tc.apiKey)
+		c.Set(interfaces.UpstreamErrorContextKey, interfaces.UpstreamError{
+			StatusCode: http.StatusBadRequest,
+			Body:       []byte(`{"type":"error","error":{"message":"prompt is too long"}}`),
+		})
+
+		h.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New("bad request")})
+
+		body := recorder.Body.Bytes()
+		if got := gjson.GetBytes(body, "debug").Exists(); got != tc.wantDebug {
+			t.Fatalf("key %s: debug present = %v, body %s", tc.apiKey, got, body)
+		}
+		if !tc.wantDebug {
+			continue
+		}
+		if got := gjson.GetBytes(body, "debug.upstream_status").Int(); got != http.StatusBadRequest {
+			t.Fatalf("unexpected upstream status %d", got)
+		}
+		if got := gjson.GetBytes(body, "debug.upstream_body.error.message").String(); got != "prompt is too long" {
+			t.Fatalf("unexpected upstream body %s", body)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ErrorFormatUnified is the error-format value selecting the proxy error envelope.
+const ErrorFormatUnified = "unified"
+
+// inflightGinKey is the Gin context key holding the in-flight record of the request, which
+// knows the provider it was last sent to.
+const inflightGinKey = "INFLIGHT_REQUEST"
+
+// ProxyErrorEnvelope is the error body returned for every API format when error-format
+// is unified.
+type ProxyErrorEnvelope struct {
+	Error ProxyErrorDetail `json:"error"`
+}
+
+// ProxyErrorDetail describes a failed request independently of the client's API format.
+type ProxyErrorDetail struct {
+	// Code is a stable, machine-readable error code, e.g. "rate_limit_exceeded".
+	Code string `json:"code"`
+
+	// Message is the error message, unwrapped from provider JSON error bodies.
+	Message string `json:"message"`
+
+	// Status is the HTTP status of the response.
+	Status int `json:"status"`
+
+	// Provider is the provider that returned the error, or "" when the request failed
+	// before reaching one.
+	Provider string `json:"provider"`
+
+	// RequestID is the proxy request id, as used in request logs.
+	RequestID string `json:"request_id"`
+
+	// Retryable reports whether sending the same request again may succeed.
+	Retryable bool `json:"retryable"`
+}
+
+// UnifiedErrorsEnabled reports whether errors are returned in the proxy error envelope.
+func UnifiedErrorsEnabled(cfg *config.SDKConfig) bool {
+	return cfg != nil && strings.EqualFold(strings.TrimSpace(cfg.ErrorFormat), ErrorFormatUnified)
+}
+
+// ErrorResponseBody returns the error body for status and errText: the proxy error envelope
+// when error-format is unified, otherwise the OpenAI-compatible body of BuildErrorResponseBody.
+func (h *BaseAPIHandler) ErrorResponseBody(c *gin.Context, status int, errText string) []byte {
+	if !UnifiedErrorsEnabled(h.Cfg) {
+		return withRequestID(BuildErrorResponseBody(status, errText), logging.GetGinRequestID(c))
+	}
+	return BuildProxyErrorBody(status, errText, requestProvider(c), logging.GetGinRequestID(c))
+}
+
+// ClaudeErrorResponseBody is ErrorResponseBody for Claude Messages API clients: the proxy
+// error envelope when error-format is unified, otherwise the Anthropic error body of
+// BuildClaudeErrorBody.
+func (h *BaseAPIHandler) ClaudeErrorResponseBody(c *gin.Context, status int, errText string) []byte {
+	if !UnifiedErrorsEnabled(h.Cfg) {
+		return withRequestID(BuildClaudeErrorBody(status, errText), logging.GetGinRequestID(c))
+	}
+	return BuildProxyErrorBody(status, errText, requestProvider(c), logging.GetGinRequestID(c))
+}
+
+// withRequestID adds the request id as the top-level request_id of an error body, as the
+// Claude API does, unless the body already has one.
+func withRequestID(body []byte, requestID string) []byte {
+	if requestID == "" || !gjson.ValidBytes(body) || !gjson.ParseBytes(body).IsObject() || gjson.GetBytes(body, "request_id").Exists() {
+		return body
+	}
+	updated, err := sjson.SetBytes(body, "request_id", requestID)
+	if err != nil {
+		return body
+	}
+	return updated
+}
+
+// BuildClaudeErrorBody builds an Anthropic error body. Bodies already in that format, as
+// returned by Claude upstreams, are kept; errors of other providers are rewrapped so Claude
+// clients can read them.
+func BuildClaudeErrorBody(status int, errText string) []byte {
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	text := strings.TrimSpace(errText)
+	if gjson.Valid(text) && gjson.Get(text, "type").String() == "error" && gjson.Get(text, "error.type").Type == gjson.String {
+		return []byte(text)
+	}
+	payload, err := json.Marshal(claudeErrorEnvelope{
+		Type: "error",
+		Error: claudeErrorDetail{
+			Type:    claudeErrorType(status),
+			Message: proxyErrorMessage(status, errText),
+		},
+	})
+	if err != nil {
+		return BuildErrorResponseBody(status, errText)
+	}
+	return payload
+}
+
+// claudeErrorEnvelope is the error body of the Claude Messages API.
+type claudeErrorEnvelope struct {
+	Type  string            `json:"type"`
+	Error claudeErrorDetail `json:"error"`
+}
+
+type claudeErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// claudeErrorType returns the Anthropic error type for an HTTP status.
+func claudeErrorType(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusPaymentRequired:
+		return "billing_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusGatewayTimeout:
+		return "timeout_error"
+	case 529:
+		return "overloaded_error"
+	}
+	if status >= http.StatusInternalServerError {
+		return "api_error"
+	}
+	return "invalid_request_error"
+}
+
+// BuildProxyErrorBody builds the unified proxy error envelope.
+func BuildProxyErrorBody(status int, errText, provider, requestID string) []byte {
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	errType, code := errorTypeAndCode(status)
+	if code == "" {
+		code = errType
+	}
+	payload, err := json.Marshal(ProxyErrorEnvelope{Error: ProxyErrorDetail{
+		Code:      code,
+		Message:   proxyErrorMessage(status, errText),
+		Status:    status,
+		Provider:  provider,
+		RequestID: requestID,
+		Retryable: retryableStatus(status),
+	}})
+	if err != nil {
+		return BuildErrorResponseBody(status, errText)
+	}
+	return payload
+}
+
+// proxyErrorMessage returns the message of errText, unwrapping provider JSON error bodies.
+func proxyErrorMessage(status int, errText string) string {
+	text := strings.TrimSpace(errText)
+	if text == "" {
+		return http.StatusText(status)
+	}
+	if gjson.Valid(text) {
+		for _, path := range []string{"error.message", "message", "error", "detail"} {
+			if v := gjson.Get(text, path); v.Type == gjson.String && v.String() != "" {
+				return v.String()
+			}
+		}
+	}
+	return text
+}
+
+// retryableStatus reports whether a request that failed with status may succeed when
+// sent again unchanged.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// requestProvider returns the provider the request behind c was last sent to.
+func requestProvider(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	value, exists := c.Get(inflightGinKey)
+	if !exists {
+		return ""
+	}
+	request, _ := value.(*inflight.Request)
+	return request.Provider()
+}
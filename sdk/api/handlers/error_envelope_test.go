@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestWriteErrorResponse_UnifiedEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ErrorFormat: "unified"}, nil)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	logging.SetGinRequestID(c, "a1b2c3d4")
+	ctx, cancel := h.GetContextWithCancel(nil, c, context.Background())
+	defer cancel()
+
+	upstreamBody := `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`
+	h.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New(upstreamBody)})
+	body := gjson.ParseBytes(recorder.Body.Bytes())
+	if recorder.Code != http.StatusTooManyRequests || body.Get("error.code").String() != "rate_limit_exceeded" || body.Get("error.message").String() != "slow down" {
+		t.Fatalf("body = %s", body.Raw)
+	}
+	if body.Get("error.request_id").String() != "a1b2c3d4" || !body.Get("error.retryable").Bool() || body.Get("error.provider").String() != "" {
+		t.Fatalf("body = %s", body.Raw)
+	}
+
+	// Once a credential was used, the envelope names its provider.
+	inflight.FromContext(ctx).SetCredential("claude", "auth-1")
+	out := gjson.ParseBytes(h.ErrorResponseBody(c, http.StatusBadRequest, "bad request"))
+	if out.Get("error.provider").String() != "claude" || out.Get("error.code").String() != "invalid_request_error" || out.Get("error.retryable").Bool() {
+		t.Fatalf("body = %s", out.Raw)
+	}
+}
+
+func TestErrorResponseBody_NativeByDefault(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	body := h.ErrorResponseBody(nil, http.StatusBadGateway, "upstream down")
+	if gjson.GetBytes(body, "error.type").String() != "server_error" || gjson.GetBytes(body, "error.retryable").Exists() {
+		t.Fatalf("body = %s", body)
+	}
+}
+
+func TestWriteClaudeErrorResponse_RewrapsOtherProviders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	geminiBody := `{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`
+	h.WriteClaudeErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New(geminiBody)})
+	body := gjson.ParseBytes(recorder.Body.Bytes())
+	if recorder.Code != http.StatusTooManyRequests || body.Get("type").String() != "error" ||
+		body.Get("error.type").String() != "rate_limit_error" || body.Get("error.message").String() != "Resource has been exhausted" {
+		t.Fatalf("body = %s", body.Raw)
+	}
+
+	claudeBody := `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`
+	if got := string(BuildClaudeErrorBody(529, claudeBody)); got != claudeBody {
+		t.Fatalf("Claude error body = %s, want it unchanged", got)
+	}
+	if got := gjson.GetBytes(BuildClaudeErrorBody(http.StatusBadRequest, "unknown provider for model x"), "error.type").String(); got != "invalid_request_error" {
+		t.Fatalf("error.type = %q", got)
+	}
+}
+
+func TestErrorResponseBody_NativeCarriesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	logging.SetGinRequestID(c, "client-trace-1")
+
+	if got := gjson.GetBytes(h.ErrorResponseBody(c, http.StatusBadGateway, "upstream down"), "request_id").String(); got != "client-trace-1" {
+		t.Fatalf("OpenAI error request_id = %q", got)
+	}
+	upstream := `{"type":"error","error":{"type":"overloaded_error","message":"busy"},"request_id":"req_upstream"}`
+	if got := gjson.GetBytes(h.ClaudeErrorResponseBody(c, 529, upstream), "request_id").String(); got != "req_upstream" {
+		t.Fatalf("upstream request_id must be kept, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/fairqueue"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// acquireUpstreamSlot waits for the fair-queuing slot of the request behind ctx and
+// returns the function releasing it. Without fair queuing it returns at once.
+func (h *BaseAPIHandler) acquireUpstreamSlot(ctx context.Context) (func(), *interfaces.ErrorMessage) {
+	cfg := fairQueuingConfig(h.Cfg)
+	fairqueue.Default.SetCapacity(cfg.MaxConcurrent)
+	fairqueue.Default.SetMaxQueue(cfg.MaxQueueDepth)
+	if cfg.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+	c, _ := ctx.Value("gin").(*gin.Context)
+	apiKey := apiKeyFromGin(c)
+	maxWait := time.Duration(cfg.MaxWaitSeconds) * time.Second
+	if maxWait <= 0 {
+		maxWait = config.DefaultFairQueueMaxWaitSeconds * time.Second
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+	release, err := fairqueue.Default.Acquire(waitCtx, apiKey, usage.TenantKey(apiKey), fairQueueWeight(cfg, apiKey), cfg.Priorities[apiKey])
+	if err == nil {
+		return release, nil
+	}
+	var reason string
+	switch {
+	case errors.Is(err, fairqueue.ErrQueueTimeout):
+		reason = "upstream capacity is saturated, retry later"
+	case errors.Is(err, fairqueue.ErrQueueFull):
+		reason = "too many requests are waiting for upstream capacity, retry later"
+	default:
+		return nil, upstreamErrorMessage(err)
+	}
+	addon := http.Header{}
+	addon.Set("Retry-After", strconv.Itoa(int(maxWait/time.Second)))
+	return nil, &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: errors.New(reason), Addon: addon}
+}
+
+// fairQueuingConfig returns the fair-queuing settings of cfg, zero when cfg is nil.
+func fairQueuingConfig(cfg *config.SDKConfig) config.FairQueuingConfig {
+	if cfg == nil {
+		return config.FairQueuingConfig{}
+	}
+	return cfg.FairQueuing
+}
+
+// fairQueueWeight returns the share weight of apiKey.
+func fairQueueWeight(cfg config.FairQueuingConfig, apiKey string) float64 {
+	if weight, ok := cfg.Weights[apiKey]; ok && weight > 0 {
+		return weight
+	}
+	if cfg.DefaultWeight > 0 {
+		return cfg.DefaultWeight
+	}
+	return 1
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// requestRoute is a set of providers and the model a request is sent to them as.
+type requestRoute struct {
+	providers []string
+	model     string
+	metadata  map[string]any
+}
+
+// requestRoutes returns the routes tried for modelName, in order: the steps of its fallback
+// chain whose provider serves the step's model, or else every provider of modelName.
+func (h *BaseAPIHandler) requestRoutes(modelName string) ([]requestRoute, *interfaces.ErrorMessage) {
+	chain := fallbackChainFor(h.Cfg, modelName)
+	if chain == nil {
+		providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+		if errMsg != nil {
+			return nil, errMsg
+		}
+		return []requestRoute{{providers: providers, model: normalizedModel, metadata: metadata}}, nil
+	}
+
+	var routes []requestRoute
+	var firstErr *interfaces.ErrorMessage
+	for _, step := range chain.Chain {
+		stepModel := strings.TrimSpace(step.Model)
+		if stepModel == "" {
+			stepModel = modelName
+		}
+		providers, normalizedModel, metadata, errMsg := h.getRequestDetails(stepModel)
+		if errMsg != nil {
+			if firstErr == nil {
+				firstErr = errMsg
+			}
+			continue
+		}
+		if provider := strings.ToLower(strings.TrimSpace(step.Provider)); provider != "" {
+			providers = keepProvider(providers, provider)
+			if len(providers) == 0 {
+				continue
+			}
+		}
+		routes = append(routes, requestRoute{providers: providers, model: normalizedModel, metadata: metadata})
+	}
+	if len(routes) == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("no provider of the fallback chain for model %s serves it", modelName)}
+	}
+	return routes, nil
+}
+
+// fallbackChainFor returns the fallback chain configured for modelName, or nil.
+func fallbackChainFor(cfg *config.SDKConfig, modelName string) *config.FallbackChain {
+	if cfg == nil {
+		return nil
+	}
+	modelName = strings.TrimSpace(modelName)
+	for i := range cfg.FallbackChains {
+		chain := &cfg.FallbackChains[i]
+		if len(chain.Chain) > 0 && strings.EqualFold(strings.TrimSpace(chain.Model), modelName) {
+			return chain
+		}
+	}
+	return nil
+}
+
+// keepProvider returns the entries of providers equal to provider.
+func keepProvider(providers []string, provider string) []string {
+	var out []string
+	for _, p := range providers {
+		if strings.EqualFold(p, provider) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// routeRequest builds the executor request sending rawJSON to route.
+func routeRequest(ctx context.Context, handlerType string, route requestRoute, rawJSON []byte, alt string, stream bool) (coreexecutor.Request, coreexecutor.Options) {
+	req := coreexecutor.Request{
+		Model:   route.model,
+		Payload: cloneBytes(rawJSON),
+	}
+	if cloned := cloneMetadata(route.metadata); cloned != nil {
+		req.Metadata = cloned
+	}
+	opts := coreexecutor.Options{
+		Stream:          stream,
+		Alt:             alt,
+		OriginalRequest: cloneBytes(rawJSON),
+		SourceFormat:    sdktranslator.FromString(handlerType),
+	}
+	opts.Metadata = mergeMetadata(cloneMetadata(route.metadata), requestExecutionMetadata(ctx))
+	return req, opts
+}
+
+// fallbackEligible reports whether a route that failed with err is followed by the next
+// route of a fallback chain: on rate limits, server errors and timeouts, but not when the
+// client went away or the request itself was rejected.
+func fallbackEligible(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	status := statusFromError(err)
+	switch {
+	case status == 0:
+		return true
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// logFallback records that a fallback chain step failed and the next one is tried.
+func logFallback(ctx context.Context, alias, model string, providers []string, err error) {
+	log.WithContext(ctx).Warnf("fallback chain %s: %s on %v failed (%v), trying the next step", alias, model, providers, err)
+}
+
+// ExplainRoute explains how a request for modelName described by q would be routed,
+// without executing it: for each route it would be tried on, in order, which credentials
+// could serve it and why the others cannot. The routes are those of requestRoutes, so model
+// routes and fallback chains apply as they do for real requests.
+func (h *BaseAPIHandler) ExplainRoute(ctx context.Context, modelName string, q coreauth.RouteQuery) ([]coreauth.RouteExplanation, *interfaces.ErrorMessage) {
+	if h.AuthManager == nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: errors.New("core auth manager unavailable")}
+	}
+	routes, errMsg := h.requestRoutes(modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	explanations := make([]coreauth.RouteExplanation, 0, len(routes))
+	for _, route := range routes {
+		q.Model = route.model
+		explanations = append(explanations, h.AuthManager.ExplainRoute(ctx, route.providers, q))
+	}
+	return explanations, nil
+}
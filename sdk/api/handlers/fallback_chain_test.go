@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// chainExecutor answers every request as provider id, or fails with status when it is set.
+type chainExecutor struct {
+	failOnceStreamExecutor
+	id     string
+	status int
+}
+
+func (e *chainExecutor) Identifier() string { return e.id }
+
+func (e *chainExecutor) response(req coreexecutor.Request) ([]byte, error) {
+	if e.status != 0 {
+		return nil, &coreauth.Error{Code: "upstream", Message: "upstream failed", HTTPStatus: e.status}
+	}
+	return []byte(`{"provider":` + jsonString(e.id) + `,"model":` + jsonString(req.Model) + `}`), nil
+}
+
+func (e *chainExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	payload, err := e.response(req)
+	return coreexecutor.Response{Payload: payload}, err
+}
+
+func (e *chainExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	payload, err := e.response(req)
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	ch <- coreexecutor.StreamChunk{Payload: payload, Err: err}
+	close(ch)
+	return ch, nil
+}
+
+func newChainHandler(t *testing.T, firstStatus int) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	executors := []*chainExecutor{{id: "chain-first", status: firstStatus}, {id: "chain-second"}}
+	for _, executor := range executors {
+		manager.RegisterExecutor(executor)
+		auth := &coreauth.Auth{ID: executor.id + "-auth", Provider: executor.id, Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "chain-alias"}, {ID: "chain-backup"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	}
+	cfg := &sdkconfig.SDKConfig{FallbackChains: []sdkconfig.FallbackChain{{
+		Model: "chain-alias",
+		Chain: []sdkconfig.FallbackStep{
+			{Provider: "chain-first"},
+			{Provider: "chain-missing"},
+			{Provider: "chain-second", Model: "chain-backup"},
+		},
+	}}}
+	return NewBaseAPIHandlers(cfg, manager)
+}
+
+func TestFallbackChain_NextStepOnRateLimit(t *testing.T) {
+	handler := newChainHandler(t, http.StatusTooManyRequests)
+
+	resp, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "chain-alias", []byte(`{}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "provider").String(); got != "chain-second" {
+		t.Fatalf("served by %q, want the second step", got)
+	}
+	if got := gjson.GetBytes(resp, "model").String(); got != "chain-backup" {
+		t.Fatalf("model = %q, want the step's model", got)
+	}
+
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "chain-alias", []byte(`{}`), "")
+	var streamed []byte
+	for chunk := range dataChan {
+		streamed = append(streamed, chunk...)
+	}
+	for errMsg := range errChan {
+		if errMsg != nil {
+			t.Fatalf("unexpected stream error: %v", errMsg.Error)
+		}
+	}
+	if got := gjson.GetBytes(streamed, "provider").String(); got != "chain-second" {
+		t.Fatalf("stream served by %q, want the second step", got)
+	}
+}
+
+func TestFallbackChain_ClientErrorIsReturned(t *testing.T) {
+	handler := newChainHandler(t, http.StatusBadRequest)
+
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "chain-alias", []byte(`{}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("a rejected request must not fall through, got %+v", errMsg)
+	}
+}
+
+func TestExplainRoute_ListsFallbackChainSteps(t *testing.T) {
+	handler := newChainHandler(t, 0)
+
+	routes, errMsg := handler.ExplainRoute(context.Background(), "chain-alias", coreauth.RouteQuery{})
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	// The chain-missing step has no provider serving it and is left out, as when executing.
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, want 2", len(routes))
+	}
+	if routes[0].Model != "chain-alias" || len(routes[0].Selectable) != 1 || routes[0].Selectable[0] != "chain-first-auth" {
+		t.Fatalf("first route = %+v", routes[0])
+	}
+	if routes[1].Model != "chain-backup" || len(routes[1].Selectable) != 1 || routes[1].Selectable[0] != "chain-second-auth" {
+		t.Fatalf("second route = %+v", routes[1])
+	}
+}
+
+func TestFallbackEligible(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, true},
+		{&coreauth.Error{HTTPStatus: http.StatusTooManyRequests}, true},
+		{&coreauth.Error{HTTPStatus: http.StatusBadGateway}, true},
+		{&coreauth.Error{HTTPStatus: http.StatusUnauthorized}, false},
+	}
+	for _, tc := range cases {
+		if got := fallbackEligible(tc.err); got != tc.want {
+			t.Errorf("fallbackEligible(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
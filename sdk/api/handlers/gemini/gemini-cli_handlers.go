@@ -181,6 +181,9 @@ func (h *GeminiCLIAPIHandler) handleInternalGenerateContent(c *gin.Context, rawJ
 	cliCancel()
 }
 
+// geminiCLITruncatedChunk closes a stream that hit the configured byte cap.
+const geminiCLITruncatedChunk = `{"response":{"candidates":[{"content":{"role":"model","parts":[]},"finishReason":"MAX_TOKENS","index":0}]}}`
+
 func (h *GeminiCLIAPIHandler) forwardCLIStream(c *gin.Context, flusher http.Flusher, alt string, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
 	var keepAliveInterval *time.Duration
 	if alt != "" {
@@ -188,6 +191,7 @@ func (h *GeminiCLIAPIHandler) forwardCLIStream(c *gin.Context, flusher http.Flus
 		keepAliveInterval = &disabled
 	}
 
+	var array geminiJSONArrayState
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		KeepAliveInterval: keepAliveInterval,
 		WriteChunk: func(chunk []byte) {
@@ -203,6 +207,7 @@ func (h *GeminiCLIAPIHandler) forwardCLIStream(c *gin.Context, flusher http.Flus
 				_, _ = c.Writer.Write(chunk)
 				_, _ = c.Writer.Write([]byte("\n\n"))
 			} else {
+				array.observe(chunk)
 				_, _ = c.Writer.Write(chunk)
 			}
 		},
@@ -218,12 +223,19 @@ func (h *GeminiCLIAPIHandler) forwardCLIStream(c *gin.Context, flusher http.Flus
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := h.ErrorResponseBody(c, status, errText)
 			if alt == "" {
 				_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", string(body))
 			} else {
 				_, _ = c.Writer.Write(body)
 			}
 		},
+		WriteTruncated: func() {
+			if alt == "" {
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", geminiCLITruncatedChunk)
+				return
+			}
+			_, _ = c.Writer.Write(array.terminate([]byte(geminiCLITruncatedChunk)))
+		},
 	})
 }
@@ -6,6 +6,7 @@
 package gemini
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
@@ -17,6 +18,8 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // GeminiAPIHandler contains the handlers for Gemini API endpoints.
@@ -285,17 +288,19 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 			}
 
 			// Write first chunk
+			var array geminiJSONArrayState
 			if alt == "" {
 				_, _ = c.Writer.Write([]byte("data: "))
 				_, _ = c.Writer.Write(chunk)
 				_, _ = c.Writer.Write([]byte("\n\n"))
 			} else {
+				array.observe(chunk)
 				_, _ = c.Writer.Write(chunk)
 			}
 			flusher.Flush()
 
 			// Continue
-			h.forwardGeminiStream(c, flusher, alt, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.forwardGeminiStream(c, flusher, alt, &array, int64(len(chunk)), func(err error) { cliCancel(err) }, dataChan, errChan)
 			return
 		}
 	}
@@ -348,13 +353,57 @@ func (h *GeminiAPIHandler) handleGenerateContent(c *gin.Context, modelName strin
 	cliCancel()
 }
 
-func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flusher, alt string, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+// geminiTruncatedChunk closes an SSE stream that hit the configured byte cap.
+const geminiTruncatedChunk = `{"candidates":[{"content":{"role":"model","parts":[]},"finishReason":"MAX_TOKENS","index":0}]}`
+
+// geminiJSONArrayState follows the bytes written to a non-SSE (alt) stream, which the
+// upstream frames as a JSON array, so a truncated stream can still be closed validly.
+type geminiJSONArrayState struct {
+	opened bool
+	last   byte
+}
+
+func (a *geminiJSONArrayState) observe(chunk []byte) {
+	trimmed := bytes.TrimSpace(chunk)
+	if len(trimmed) == 0 {
+		return
+	}
+	if a.last == 0 && trimmed[0] == '[' {
+		a.opened = true
+	}
+	a.last = trimmed[len(trimmed)-1]
+}
+
+// terminate returns element as the final entry of the stream, closing the array when
+// one was opened and is still open.
+func (a *geminiJSONArrayState) terminate(element []byte) []byte {
+	if !a.opened {
+		return append([]byte("\n"), element...)
+	}
+	if a.last == ']' {
+		return nil
+	}
+	out := make([]byte, 0, len(element)+2)
+	if a.last != '[' && a.last != ',' {
+		out = append(out, ',')
+	}
+	out = append(out, element...)
+	return append(out, ']')
+}
+
+func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flusher, alt string, array *geminiJSONArrayState, written int64, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
 	var keepAliveInterval *time.Duration
 	if alt != "" {
 		disabled := time.Duration(0)
 		keepAliveInterval = &disabled
 	}
 
+	// Only SSE chunks are standalone JSON objects the sanitizer can rewrite.
+	var textCodec *handlers.StreamTextCodec
+	if alt == "" {
+		textCodec = &geminiStreamTextCodec
+	}
+
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		KeepAliveInterval: keepAliveInterval,
 		WriteChunk: func(chunk []byte) {
@@ -363,6 +412,7 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 				_, _ = c.Writer.Write(chunk)
 				_, _ = c.Writer.Write([]byte("\n\n"))
 			} else {
+				array.observe(chunk)
 				_, _ = c.Writer.Write(chunk)
 			}
 		},
@@ -378,12 +428,82 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := h.ErrorResponseBody(c, status, errText)
 			if alt == "" {
 				_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", string(body))
 			} else {
 				_, _ = c.Writer.Write(body)
 			}
 		},
+		WriteTruncated: func() {
+			if alt == "" {
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", geminiTruncatedChunk)
+				return
+			}
+			_, _ = c.Writer.Write(array.terminate([]byte(geminiTruncatedChunk)))
+		},
+		TextCodec:    textCodec,
+		WrittenBytes: written,
 	})
 }
+
+// geminiStreamTextCodec reads and writes model text in generateContent stream chunks for
+// the output sanitizer.
+var geminiStreamTextCodec = handlers.StreamTextCodec{
+	Texts: func(chunk []byte) map[int]string {
+		var texts map[int]string
+		gjson.GetBytes(chunk, "candidates").ForEach(func(position, candidate gjson.Result) bool {
+			var text strings.Builder
+			candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+				if !part.Get("thought").Bool() {
+					text.WriteString(part.Get("text").String())
+				}
+				return true
+			})
+			if text.Len() > 0 {
+				if texts == nil {
+					texts = make(map[int]string)
+				}
+				texts[geminiCandidateIndex(position, candidate)] = text.String()
+			}
+			return true
+		})
+		return texts
+	},
+	Finished: func(chunk []byte) []int {
+		var finished []int
+		gjson.GetBytes(chunk, "candidates").ForEach(func(position, candidate gjson.Result) bool {
+			if candidate.Get("finishReason").String() != "" {
+				finished = append(finished, geminiCandidateIndex(position, candidate))
+			}
+			return true
+		})
+		return finished
+	},
+	EncodeText: func(template []byte, index int, text string) []byte {
+		candidate := []byte(`{"content":{"role":"model","parts":[]}}`)
+		candidate, err := sjson.SetBytes(candidate, "content.parts.0.text", text)
+		if err == nil {
+			candidate, err = sjson.SetBytes(candidate, "index", index)
+		}
+		if err != nil {
+			return nil
+		}
+		out, err := sjson.DeleteBytes(template, "usageMetadata")
+		if err == nil {
+			out, err = sjson.SetRawBytes(out, "candidates", append(append([]byte{'['}, candidate...), ']'))
+		}
+		if err != nil {
+			return nil
+		}
+		return out
+	},
+}
+
+// geminiCandidateIndex returns a candidate's index, which Gemini omits for the first one.
+func geminiCandidateIndex(position, candidate gjson.Result) int {
+	if index := candidate.Get("index"); index.Exists() {
+		return int(index.Int())
+	}
+	return int(position.Int())
+}
@@ -0,0 +1,26 @@
+package gemini
+
+import "testing"
+
+func TestGeminiJSONArrayStateTerminate(t *testing.T) {
+	element := []byte(`{"x":1}`)
+	cases := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{name: "open array", chunks: []string{"[{\"a\":1}", ",\r\n{\"a\":2}"}, want: `,{"x":1}]`},
+		{name: "pending separator", chunks: []string{"[{\"a\":1}", ",\n"}, want: `{"x":1}]`},
+		{name: "closed array", chunks: []string{"[{\"a\":1}]"}, want: ""},
+		{name: "bare objects", chunks: []string{"{\"a\":1}"}, want: "\n{\"x\":1}"},
+	}
+	for _, tc := range cases {
+		var state geminiJSONArrayState
+		for _, chunk := range tc.chunks {
+			state.observe([]byte(chunk))
+		}
+		if got := string(state.terminate(element)); got != tc.want {
+			t.Errorf("%s: terminate = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -5,6 +5,8 @@ package handlers
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -14,13 +16,18 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 	"golang.org/x/net/context"
 )
 
@@ -66,8 +73,23 @@ func BuildErrorResponseBody(status int, errText string) []byte {
 		return []byte(trimmed)
 	}
 
-	errType := "invalid_request_error"
-	var code string
+	errType, code := errorTypeAndCode(status)
+	payload, err := json.Marshal(ErrorResponse{
+		Error: ErrorDetail{
+			Message: errText,
+			Type:    errType,
+			Code:    code,
+		},
+	})
+	if err != nil {
+		return []byte(fmt.Sprintf(`{"error":{"message":%q,"type":"server_error","code":"internal_server_error"}}`, errText))
+	}
+	return payload
+}
+
+// errorTypeAndCode returns the OpenAI error type and code for an HTTP status.
+func errorTypeAndCode(status int) (errType, code string) {
+	errType = "invalid_request_error"
 	switch status {
 	case http.StatusUnauthorized:
 		errType = "authentication_error"
@@ -87,18 +109,7 @@ func BuildErrorResponseBody(status int, errText string) []byte {
 			code = "internal_server_error"
 		}
 	}
-
-	payload, err := json.Marshal(ErrorResponse{
-		Error: ErrorDetail{
-			Message: errText,
-			Type:    errType,
-			Code:    code,
-		},
-	})
-	if err != nil {
-		return []byte(fmt.Sprintf(`{"error":{"message":%q,"type":"server_error","code":"internal_server_error"}}`, errText))
-	}
-	return payload
+	return errType, code
 }
 
 // StreamingKeepAliveInterval returns the SSE keep-alive interval for this server.
@@ -139,19 +150,167 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamingMaxOutputBytes returns the streamed output cap for the given client API key.
+// Returning 0 disables the cap.
+func StreamingMaxOutputBytes(cfg *config.SDKConfig, apiKey string) int64 {
+	if cfg == nil {
+		return 0
+	}
+	limit := cfg.Streaming.MaxOutputBytes
+	if apiKey != "" {
+		if override, ok := cfg.Streaming.MaxOutputBytesPerKey[apiKey]; ok {
+			limit = override
+		}
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// StreamingSanitizeOutput reports whether streamed output for the given client API key
+// should pass through the output sanitizer.
+func StreamingSanitizeOutput(cfg *config.SDKConfig, apiKey string) bool {
+	if cfg == nil {
+		return false
+	}
+	if apiKey != "" {
+		if override, ok := cfg.Streaming.SanitizeOutputPerKey[apiKey]; ok {
+			return override
+		}
+	}
+	return cfg.Streaming.SanitizeOutput
+}
+
+// ErrorDebugEnabled reports whether error responses for the client API key should carry
+// the untranslated upstream error under "debug".
+func ErrorDebugEnabled(cfg *config.SDKConfig, apiKey string) bool {
+	if cfg == nil || apiKey == "" {
+		return false
+	}
+	for _, key := range cfg.DebugErrorKeys {
+		if key == apiKey {
+			return true
+		}
+	}
+	return false
+}
+
+// trackInflight registers the request behind ctx for the in-flight request listing, keyed
+// by its request id. cancel must cancel ctx.
+func trackInflight(ctx context.Context, cancel context.CancelFunc, handler interfaces.APIHandler, c *gin.Context) context.Context {
+	id := logging.GetRequestID(ctx)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	var handlerType, client string
+	if handler != nil {
+		handlerType = handler.HandlerType()
+	}
+	if c != nil && c.Request != nil {
+		client = c.ClientIP()
+	}
+	ctx, request := inflight.Start(ctx, cancel, id, handlerType, client, usage.TenantKey(apiKeyFromGin(c)))
+	if c != nil {
+		c.Set(inflightGinKey, request)
+	}
+	return ctx
+}
+
+// apiKeyFromGin returns the authenticated client API key, or "" when absent.
+func apiKeyFromGin(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	if v, exists := c.Get("apiKey"); exists {
+		if key, ok := v.(string); ok {
+			return key
+		}
+	}
+	return ""
+}
+
+// ClientKeyOwner identifies the client API key of the request as the owner of server-side
+// resources such as jobs and stored responses: the hex SHA-256 of the key, or "" when the
+// request is not authenticated.
+func ClientKeyOwner(c *gin.Context) string {
+	key := apiKeyFromGin(c)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// attachUpstreamErrorDebug adds the raw upstream status and body recorded for this request
+// to a JSON object error body. Other bodies are returned unchanged.
+func attachUpstreamErrorDebug(c *gin.Context, body []byte) []byte {
+	value, exists := c.Get(interfaces.UpstreamErrorContextKey)
+	if !exists {
+		return body
+	}
+	upstream, ok := value.(interfaces.UpstreamError)
+	if !ok || !gjson.ParseBytes(body).IsObject() {
+		return body
+	}
+	out, err := sjson.SetBytes(body, "debug.upstream_status", upstream.StatusCode)
+	if err != nil {
+		return body
+	}
+	trimmed := bytes.TrimSpace(upstream.Body)
+	if len(trimmed) > 0 && json.Valid(trimmed) {
+		out, err = sjson.SetRawBytes(out, "debug.upstream_body", trimmed)
+	} else {
+		out, err = sjson.SetBytes(out, "debug.upstream_body", string(upstream.Body))
+	}
+	if err != nil {
+		return body
+	}
+	return out
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
 	key := ""
+	var ginCtx *gin.Context
 	if ctx != nil {
-		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		if c, ok := ctx.Value("gin").(*gin.Context); ok && c != nil && c.Request != nil {
+			ginCtx = c
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
 		}
 	}
 	if key == "" {
 		key = uuid.NewString()
 	}
-	return map[string]any{idempotencyKeyMetadataKey: key}
+	meta := map[string]any{idempotencyKeyMetadataKey: key}
+	if ginCtx != nil {
+		if session := strings.TrimSpace(ginCtx.GetHeader("X-Session-ID")); session != "" {
+			meta[coreexecutor.SessionIDMetadataKey] = session
+		}
+	}
+	// Fill-in-the-middle hints are stashed on the gin context by the completions handler
+	// so executors with a native FIM endpoint can bypass the chat emulation.
+	if ginCtx != nil {
+		for _, k := range []string{util.FIMPromptMetadataKey, util.FIMSuffixMetadataKey, util.FIMLanguageMetadataKey} {
+			if v := ginCtx.GetString(k); v != "" {
+				meta[k] = v
+			}
+		}
+	}
+	return meta
+}
+
+// stashRequestSeed stores the sampling seed of rawJSON on the gin context, where usage
+// reporting picks it up.
+func stashRequestSeed(ctx context.Context, rawJSON []byte) {
+	seed, ok := util.RequestSeed(rawJSON)
+	if !ok || ctx == nil {
+		return
+	}
+	if c, okGin := ctx.Value("gin").(*gin.Context); okGin && c != nil {
+		c.Set(util.RequestSeedContextKey, seed)
+	}
 }
 
 func mergeMetadata(base, overlay map[string]any) map[string]any {
@@ -255,6 +414,9 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil {
+		parentCtx = tracing.WithSpanFrom(parentCtx, requestCtx)
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
 		go func() {
@@ -265,6 +427,7 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			}
 		}()
 	}
+	newCtx = trackInflight(newCtx, cancel, handler, c)
 	newCtx = context.WithValue(newCtx, "gin", c)
 	newCtx = context.WithValue(newCtx, "handler", handler)
 	return newCtx, func(params ...interface{}) {
@@ -380,11 +543,94 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	inflight.FromContext(ctx).SetModel(modelName)
+	caps, tailored := capabilitiesFromContext(ctx, handlerType)
+	if tailored {
+		rawJSON = caps.tailorRequest(rawJSON)
+	}
+	sink := openArtifactSink(h.Cfg, ctx)
+	execCtx := ctx
+	if sink != nil {
+		// The artifact must be complete even if the client goes away, so the upstream call
+		// no longer follows the request's cancellation.
+		execCtx = detachFromClient(ctx)
+	}
+	release, errMsg := h.acquireUpstreamSlot(execCtx)
+	if errMsg != nil {
+		sink.finish(true)
+		return nil, errMsg
+	}
+	resp, errMsg := h.executeWithBestOf(execCtx, handlerType, modelName, rawJSON, alt)
+	release()
+	if sink != nil {
+		sink.write(resp)
+		sink.finish(errMsg != nil)
+	}
+	if tailored && errMsg == nil {
+		resp = caps.tailorResponse(resp)
+	}
+	return resp, errMsg
+}
+
+func (h *BaseAPIHandler) executeWithLocalePolicy(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	policy, hasPolicy := localePolicyFromContext(h.Cfg, ctx)
+	if !hasPolicy {
+		return h.executeWithJSONMode(ctx, handlerType, modelName, rawJSON, alt)
+	}
+	rawJSON = injectSystemInstruction(handlerType, rawJSON, localeInstruction(policy))
+	resp, errMsg := h.executeWithJSONMode(ctx, handlerType, modelName, rawJSON, alt)
+	if errMsg != nil || !policy.Verify || localeMatches(policy, responseText(handlerType, resp)) {
+		return resp, errMsg
+	}
+	logLocaleMismatch(policy, modelName, false)
+	retryJSON := injectSystemInstruction(handlerType, rawJSON, localeRetryInstruction(policy))
+	retry, retryErr := h.executeWithJSONMode(ctx, handlerType, modelName, retryJSON, alt)
+	if retryErr != nil {
+		return resp, nil
+	}
+	if !localeMatches(policy, responseText(handlerType, retry)) {
+		logLocaleMismatch(policy, modelName, true)
+	}
+	return retry, nil
+}
+
+func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	routes, errMsg := h.requestRoutes(modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	stashRequestSeed(ctx, rawJSON)
+	var err error
+	for i, route := range routes {
+		var resp coreexecutor.Response
+		if h.aggregatesStream(ctx, handlerType, route.providers) {
+			resp, err = h.executeAggregated(ctx, handlerType, route, rawJSON, alt)
+		} else {
+			req, opts := routeRequest(ctx, handlerType, route, rawJSON, alt, false)
+			resp, err = h.AuthManager.Execute(ctx, route.providers, req, opts)
+		}
+		if err == nil {
+			return cloneBytes(resp.Payload), nil
+		}
+		if i+1 < len(routes) && fallbackEligible(err) {
+			logFallback(ctx, modelName, route.model, route.providers, err)
+			continue
+		}
+		break
+	}
+	return nil, upstreamErrorMessage(err)
+}
+
+// ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
+// This path is the only supported execution route.
+func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	inflight.FromContext(ctx).SetModel(modelName)
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
 	reqMeta := requestExecutionMetadata(ctx)
+	stashRequestSeed(ctx, rawJSON)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
 		Payload: cloneBytes(rawJSON),
@@ -399,7 +645,7 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
-	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -418,14 +664,22 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	return cloneBytes(resp.Payload), nil
 }
 
-// ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
-// This path is the only supported execution route.
-func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+// ExecuteRerankWithAuthManager routes a rerank request to providers with a native rerank endpoint.
+func (h *BaseAPIHandler) ExecuteRerankWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	return h.executeCapabilityWithAuthManager(ctx, handlerType, modelName, rawJSON, h.AuthManager.ExecuteRerank)
+}
+
+// ExecuteEmbedWithAuthManager routes an embeddings request to providers with an embeddings endpoint.
+func (h *BaseAPIHandler) ExecuteEmbedWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	return h.executeCapabilityWithAuthManager(ctx, handlerType, modelName, rawJSON, h.AuthManager.ExecuteEmbed)
+}
+
+func (h *BaseAPIHandler) executeCapabilityWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, execute func(context.Context, []string, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error)) ([]byte, *interfaces.ErrorMessage) {
+	inflight.FromContext(ctx).SetModel(modelName)
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
-	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
 		Payload: cloneBytes(rawJSON),
@@ -434,13 +688,11 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		req.Metadata = cloned
 	}
 	opts := coreexecutor.Options{
-		Stream:          false,
-		Alt:             alt,
 		OriginalRequest: cloneBytes(rawJSON),
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
-	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
-	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
+	opts.Metadata = mergeMetadata(cloneMetadata(metadata), requestExecutionMetadata(ctx))
+	resp, err := execute(ctx, providers, req, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -448,13 +700,7 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 				status = code
 			}
 		}
-		var addon http.Header
-		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
-			if hdr := he.Headers(); hdr != nil {
-				addon = hdr.Clone()
-			}
-		}
-		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err}
 	}
 	return cloneBytes(resp.Payload), nil
 }
@@ -462,44 +708,81 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	inflight.FromContext(ctx).SetModel(modelName)
+	if _, requested, _ := bestOfFromContext(h.Cfg, ctx, modelName); requested {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("%s is not supported for streaming requests", BestOfHeader)}
+		close(errChan)
+		return nil, errChan
+	}
+	caps, tailored := capabilitiesFromContext(ctx, handlerType)
+	if tailored {
+		rawJSON = caps.tailorRequest(rawJSON)
+	}
+	if policy, ok := localePolicyFromContext(h.Cfg, ctx); ok {
+		rawJSON = injectSystemInstruction(handlerType, rawJSON, localeInstruction(policy))
+	}
+	// Streamed chunks cannot be taken back, so emulated JSON mode is instructed but not
+	// validated here.
+	if mode, emulate := h.jsonModeFor(handlerType, modelName, rawJSON); emulate {
+		rawJSON = injectSystemInstruction(handlerType, rawJSON, jsonModeSystemText(mode, rawJSON))
+	}
+	routes, errMsg := h.requestRoutes(modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
 		close(errChan)
 		return nil, errChan
 	}
-	reqMeta := requestExecutionMetadata(ctx)
-	req := coreexecutor.Request{
-		Model:   normalizedModel,
-		Payload: cloneBytes(rawJSON),
+	stashRequestSeed(ctx, rawJSON)
+	// With an artifact the upstream stream runs to completion even after the client
+	// disconnects; chunks then go to the artifact only.
+	sink := openArtifactSink(h.Cfg, ctx)
+	execCtx := ctx
+	if sink != nil {
+		execCtx = detachFromClient(ctx)
 	}
-	if cloned := cloneMetadata(metadata); cloned != nil {
-		req.Metadata = cloned
+	// routeIndex is the fallback chain step being streamed from.
+	routeIndex := 0
+	var providers []string
+	var req coreexecutor.Request
+	var opts coreexecutor.Options
+	// startRoute starts streaming from routes[index].
+	startRoute := func(index int) (<-chan coreexecutor.StreamChunk, error) {
+		routeIndex = index
+		providers = routes[index].providers
+		req, opts = routeRequest(ctx, handlerType, routes[index], rawJSON, alt, true)
+		return h.AuthManager.ExecuteStream(execCtx, providers, req, opts)
 	}
-	opts := coreexecutor.Options{
-		Stream:          true,
-		Alt:             alt,
-		OriginalRequest: cloneBytes(rawJSON),
-		SourceFormat:    sdktranslator.FromString(handlerType),
+	// nextRoute moves to the next step of a fallback chain after err, before any output.
+	nextRoute := func(err error) (<-chan coreexecutor.StreamChunk, error) {
+		for routeIndex+1 < len(routes) && fallbackEligible(err) {
+			logFallback(ctx, modelName, req.Model, providers, err)
+			chunks, errStart := startRoute(routeIndex + 1)
+			if errStart == nil {
+				return chunks, nil
+			}
+			err = errStart
+		}
+		return nil, err
+	}
+	release, errMsg := h.acquireUpstreamSlot(execCtx)
+	if errMsg != nil {
+		sink.finish(true)
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
+	chunks, err := startRoute(0)
+	if err != nil {
+		chunks, err = nextRoute(err)
 	}
-	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
-	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
+		release()
+		sink.finish(true)
 		errChan := make(chan *interfaces.ErrorMessage, 1)
-		status := http.StatusInternalServerError
-		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
-			if code := se.StatusCode(); code > 0 {
-				status = code
-			}
-		}
-		var addon http.Header
-		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
-			if hdr := he.Headers(); hdr != nil {
-				addon = hdr.Clone()
-			}
-		}
-		errChan <- &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+		errChan <- upstreamErrorMessage(err)
 		close(errChan)
 		return nil, errChan
 	}
@@ -508,6 +791,15 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	go func() {
 		defer close(dataChan)
 		defer close(errChan)
+		defer release()
+		streamFailed := false
+		defer func() { sink.finish(streamFailed) }()
+		// clientDone is nil once the client is gone, so chunks are no longer forwarded.
+		var clientDone <-chan struct{}
+		if ctx != nil {
+			clientDone = ctx.Done()
+		}
+		clientGone := false
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
@@ -531,14 +823,14 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 			for {
 				var chunk coreexecutor.StreamChunk
 				var ok bool
-				if ctx != nil {
-					select {
-					case <-ctx.Done():
+				select {
+				case <-clientDone:
+					if sink == nil {
 						return
-					case chunk, ok = <-chunks:
 					}
-				} else {
-					chunk, ok = <-chunks
+					clientGone, clientDone = true, nil
+					continue
+				case chunk, ok = <-chunks:
 				}
 				if !ok {
 					return
@@ -550,13 +842,20 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					if !sentPayload {
 						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
 							bootstrapRetries++
-							retryChunks, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							retryChunks, retryErr := h.AuthManager.ExecuteStream(execCtx, providers, req, opts)
 							if retryErr == nil {
 								chunks = retryChunks
 								continue outer
 							}
 							streamErr = retryErr
 						}
+						fallbackChunks, fallbackErr := nextRoute(streamErr)
+						if fallbackErr == nil {
+							bootstrapRetries = 0
+							chunks = fallbackChunks
+							continue outer
+						}
+						streamErr = fallbackErr
 					}
 
 					status := http.StatusInternalServerError
@@ -571,19 +870,52 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							addon = hdr.Clone()
 						}
 					}
-					errChan <- &interfaces.ErrorMessage{StatusCode: status, Error: streamErr, Addon: addon}
+					streamFailed = true
+					if !clientGone {
+						errChan <- &interfaces.ErrorMessage{StatusCode: status, Error: streamErr, Addon: addon}
+					}
 					return
 				}
 				if len(chunk.Payload) > 0 {
 					sentPayload = true
-					dataChan <- cloneBytes(chunk.Payload)
+					sink.write(chunk.Payload)
+					if clientGone {
+						continue
+					}
+					if sink == nil {
+						dataChan <- cloneBytes(chunk.Payload)
+						continue
+					}
+					select {
+					case dataChan <- cloneBytes(chunk.Payload):
+					case <-clientDone:
+						clientGone, clientDone = true, nil
+					}
 				}
 			}
 		}
 	}()
+	if tailored {
+		return caps.tailorStream(ctx, dataChan), errChan
+	}
 	return dataChan, errChan
 }
 
+// upstreamErrorMessage converts an execution error, keeping its status and headers.
+func upstreamErrorMessage(err error) *interfaces.ErrorMessage {
+	status := http.StatusInternalServerError
+	if code := statusFromError(err); code > 0 {
+		status = code
+	}
+	var addon http.Header
+	if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
+		if hdr := he.Headers(); hdr != nil {
+			addon = hdr.Clone()
+		}
+	}
+	return &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+}
+
 func statusFromError(err error) int {
 	if err == nil {
 		return 0
@@ -597,8 +929,11 @@ func statusFromError(err error) int {
 }
 
 func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
+	// Model routes rewrite pattern-matched names before anything else.
+	routedModel, routeProvider := applyModelRoute(h.Cfg, modelName)
+
 	// Resolve "auto" model to an actual available model first
-	resolvedModelName := util.ResolveAutoModel(modelName)
+	resolvedModelName := util.ResolveAutoModel(routedModel)
 
 	// Normalize the model name to handle dynamic thinking suffixes before determining the provider.
 	normalizedModel, metadata = normalizeModelMetadata(resolvedModelName)
@@ -622,6 +957,11 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	if len(providers) == 0 {
 		return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("unknown provider for model %s", modelName)}
 	}
+	if routeProvider != "" {
+		if providers = keepProvider(providers, routeProvider); len(providers) == 0 {
+			return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model route sends %s to provider %s, which does not serve %s", modelName, routeProvider, routedModel)}
+		}
+	}
 
 	// If it's a dynamic model, the normalizedModel was already set to extractedModelName.
 	// If it's a non-dynamic model, normalizedModel was set by normalizeModelMetadata.
@@ -656,6 +996,16 @@ func cloneMetadata(src map[string]any) map[string]any {
 
 // WriteErrorResponse writes an error message to the response writer using the HTTP status embedded in the message.
 func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.ErrorMessage) {
+	h.writeErrorResponse(c, msg, h.ErrorResponseBody)
+}
+
+// WriteClaudeErrorResponse is WriteErrorResponse for Claude Messages API clients, which
+// expect errors in the Anthropic error format whichever provider served the request.
+func (h *BaseAPIHandler) WriteClaudeErrorResponse(c *gin.Context, msg *interfaces.ErrorMessage) {
+	h.writeErrorResponse(c, msg, h.ClaudeErrorResponseBody)
+}
+
+func (h *BaseAPIHandler) writeErrorResponse(c *gin.Context, msg *interfaces.ErrorMessage, buildBody func(*gin.Context, int, string) []byte) {
 	status := http.StatusInternalServerError
 	if msg != nil && msg.StatusCode > 0 {
 		status = msg.StatusCode
@@ -679,7 +1029,10 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		}
 	}
 
-	body := BuildErrorResponseBody(status, errText)
+	body := buildBody(c, status, errText)
+	if ErrorDebugEnabled(h.Cfg, apiKeyFromGin(c)) {
+		body = attachUpstreamErrorDebug(c, body)
+	}
 	// Append first to preserve upstream response logs, then drop duplicate payloads if already recorded.
 	var previous []byte
 	if existing, exists := c.Get("API_RESPONSE"); exists {
@@ -0,0 +1,444 @@
+// Package jobs provides the asynchronous job API. A client submits a generation request
+// with POST /v1/jobs and gets a job id back at once; the proxy runs the request
+// server-side, persists the job as it progresses and keeps the final response. Clients
+// poll GET /v1/jobs/{id}, or pass a webhook_url to have the finished job posted to them.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// JobSignatureHeader carries the HMAC-SHA256 signature of a webhook delivery when
+// jobs.webhook-secret is set.
+const JobSignatureHeader = "X-Job-Signature"
+
+// webhookAttempts is how many times a webhook delivery is tried.
+const webhookAttempts = 3
+
+// webhookTimeout bounds a single webhook delivery.
+const webhookTimeout = 30 * time.Second
+
+// webhookBackoff is the delay before the first retry of a webhook delivery; it doubles
+// with every further retry.
+var webhookBackoff = 2 * time.Second
+
+// defaultMaxRunningPerKey caps the jobs in progress per client key when
+// jobs.max-running-per-key is not set.
+const defaultMaxRunningPerKey = 16
+
+// jobFormats are the API formats a job request may be written in.
+var jobFormats = map[string]bool{OpenAI: true, Claude: true, OpenaiResponse: true}
+
+// runningJob is a job executing in this process.
+type runningJob struct {
+	cancel    context.CancelFunc
+	cancelled bool
+	// owner is the Owner of the job, counted against jobs.max-running-per-key.
+	owner string
+}
+
+var (
+	runningMu sync.Mutex
+	running   = make(map[string]*runningJob)
+)
+
+// JobsAPIHandler contains the handlers for the job API.
+type JobsAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewJobsAPIHandler creates a new job API handlers instance.
+func NewJobsAPIHandler(apiHandlers *handlers.BaseAPIHandler) *JobsAPIHandler {
+	return &JobsAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *JobsAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns a list of models supported by this handler.
+func (h *JobsAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// CreateJob handles POST /v1/jobs. The body names the API format of the request ("openai"
+// chat completions by default, "claude" messages or "openai-response"), the request
+// itself and optionally a webhook_url:
+//
+//	{"format":"openai","request":{"model":"...","messages":[...]},"webhook_url":"https://..."}
+//
+// The request is always run without streaming. The job is answered with 202 Accepted, or
+// 429 when the client key already has jobs.max-running-per-key jobs in progress. Webhooks
+// may not target loopback, private or link-local addresses unless
+// jobs.webhook-allowed-hosts lists them.
+func (h *JobsAPIHandler) CreateJob(c *gin.Context) {
+	store := storeFor(h.Cfg)
+	if store == nil {
+		h.writeError(c, http.StatusNotFound, "the job API is not enabled")
+		return
+	}
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	body := gjson.ParseBytes(rawJSON)
+	if !body.IsObject() {
+		h.writeError(c, http.StatusBadRequest, "the request body must be a JSON object")
+		return
+	}
+	format := strings.TrimSpace(body.Get("format").String())
+	if format == "" {
+		format = OpenAI
+	}
+	if !jobFormats[format] {
+		h.writeError(c, http.StatusBadRequest, fmt.Sprintf("unsupported format %q, expected %q, %q or %q", format, OpenAI, Claude, OpenaiResponse))
+		return
+	}
+	request := body.Get("request")
+	if !request.IsObject() {
+		h.writeError(c, http.StatusBadRequest, "request must be a JSON object")
+		return
+	}
+	model := strings.TrimSpace(request.Get("model").String())
+	if model == "" {
+		h.writeError(c, http.StatusBadRequest, "request.model is required")
+		return
+	}
+	requestJSON, _ := sjson.SetBytes([]byte(request.Raw), "stream", false)
+
+	now := time.Now()
+	job := &Job{
+		ID:        "job_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Object:    "job",
+		Status:    StatusQueued,
+		Format:    format,
+		Model:     model,
+		Request:   requestJSON,
+		Owner:     handlers.ClientKeyOwner(c),
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(jobTTL(h.Cfg)).Unix(),
+	}
+	if webhookURL := strings.TrimSpace(body.Get("webhook_url").String()); webhookURL != "" {
+		if errURL := newWebhookGuard(h.Cfg).checkURL(webhookURL); errURL != nil {
+			h.writeError(c, http.StatusBadRequest, errURL.Error())
+			return
+		}
+		job.Webhook = &JobWebhook{URL: webhookURL, Status: WebhookPending}
+	}
+
+	// The job outlives this request: run it on a copy of the gin context whose request
+	// is not cancelled when the client disconnects.
+	cp := c.Copy()
+	cp.Request = c.Request.Clone(context.WithoutCancel(c.Request.Context()))
+	ctx, cancel := h.GetContextWithCancel(h, cp, cp.Request.Context())
+	limit := maxRunningPerKey(h.Cfg)
+	runningMu.Lock()
+	if runningFor(job.Owner) >= limit {
+		runningMu.Unlock()
+		cancel()
+		h.writeError(c, http.StatusTooManyRequests, fmt.Sprintf("this API key already has %d jobs in progress", limit))
+		return
+	}
+	running[job.ID] = &runningJob{cancel: func() { cancel() }, owner: job.Owner}
+	runningMu.Unlock()
+	if err = store.Put(c.Request.Context(), job); err != nil {
+		runningMu.Lock()
+		delete(running, job.ID)
+		runningMu.Unlock()
+		cancel()
+		h.writeError(c, http.StatusInternalServerError, fmt.Sprintf("failed to store job: %v", err))
+		return
+	}
+	go h.run(ctx, cancel, store, job.clone())
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJob handles GET /v1/jobs/:id. A job that was still in progress when the proxy
+// stopped is reported as failed.
+func (h *JobsAPIHandler) GetJob(c *gin.Context) {
+	id := c.Param("id")
+	store := storeFor(h.Cfg)
+	if store == nil {
+		h.writeNotFound(c, id)
+		return
+	}
+	job, ok := h.ownedJob(c, store, id)
+	if !ok {
+		return
+	}
+	if !job.Final() && !isRunning(id) {
+		job.Status = StatusFailed
+		job.Error = &JobError{Status: http.StatusInternalServerError, Message: "the job was interrupted by a proxy restart"}
+		job.CompletedAt = time.Now().Unix()
+		if errPut := store.Put(c.Request.Context(), job); errPut != nil {
+			log.Warnf("jobs: failed to store interrupted job %s: %v", id, errPut)
+		}
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob handles POST /v1/jobs/:id/cancel. A job in progress is stopped and kept with
+// status cancelled once its request has returned; finished jobs are left unchanged. The
+// job is returned as it stands.
+func (h *JobsAPIHandler) CancelJob(c *gin.Context) {
+	id := c.Param("id")
+	store := storeFor(h.Cfg)
+	if store == nil {
+		h.writeNotFound(c, id)
+		return
+	}
+	if _, ok := h.ownedJob(c, store, id); !ok {
+		return
+	}
+	runningMu.Lock()
+	if job, ok := running[id]; ok {
+		job.cancelled = true
+		job.cancel()
+	}
+	runningMu.Unlock()
+	h.GetJob(c)
+}
+
+// DeleteJob handles DELETE /v1/jobs/:id, stopping the job if it is in progress and
+// removing it.
+func (h *JobsAPIHandler) DeleteJob(c *gin.Context) {
+	id := c.Param("id")
+	store := storeFor(h.Cfg)
+	if store == nil {
+		h.writeNotFound(c, id)
+		return
+	}
+	if _, ok := h.ownedJob(c, store, id); !ok {
+		return
+	}
+	runningMu.Lock()
+	if job, wasRunning := running[id]; wasRunning {
+		delete(running, id)
+		job.cancel()
+	}
+	runningMu.Unlock()
+	if _, err := store.Delete(c.Request.Context(), id); err != nil {
+		h.writeError(c, http.StatusInternalServerError, fmt.Sprintf("failed to delete job: %v", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "job.deleted", "deleted": true})
+}
+
+// ownedJob returns the job id when it belongs to the client key of the request, writing
+// the error response otherwise. Jobs of other keys are reported as not found so their ids
+// cannot be probed.
+func (h *JobsAPIHandler) ownedJob(c *gin.Context, store Store, id string) (*Job, bool) {
+	job, err := store.Get(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, http.StatusInternalServerError, fmt.Sprintf("failed to read job: %v", err))
+		return nil, false
+	}
+	if job == nil || job.Owner != handlers.ClientKeyOwner(c) {
+		h.writeNotFound(c, id)
+		return nil, false
+	}
+	return job, true
+}
+
+// run executes job and records its outcome, then delivers it to the webhook.
+func (h *JobsAPIHandler) run(ctx context.Context, cancel handlers.APIHandlerCancelFunc, store Store, job *Job) {
+	job.Status = StatusRunning
+	job.StartedAt = time.Now().Unix()
+	if !persistActive(store, job, false) {
+		cancel()
+		return
+	}
+
+	resp, errMsg := h.ExecuteWithAuthManager(ctx, job.Format, job.Model, job.Request, "")
+	job.CompletedAt = time.Now().Unix()
+	switch {
+	case errMsg == nil:
+		job.Status = StatusCompleted
+		if gjson.ValidBytes(resp) {
+			job.Result = resp
+		} else {
+			job.Result, _ = json.Marshal(string(resp))
+		}
+	case ctx.Err() != nil:
+		job.Status = StatusCancelled
+	default:
+		job.Status = StatusFailed
+		job.Error = jobError(errMsg)
+	}
+	if errMsg != nil {
+		cancel(errMsg.Error)
+	} else {
+		cancel(resp)
+	}
+	if !persistActive(store, job, true) {
+		return
+	}
+	if job.Webhook != nil {
+		h.deliverWebhook(store, job)
+	}
+}
+
+// persistActive stores job unless it was deleted while running. A job cancelled by the
+// client is recorded as cancelled whatever the outcome of its request. final removes the
+// job from the running jobs.
+func persistActive(store Store, job *Job, final bool) bool {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	entry, ok := running[job.ID]
+	if !ok {
+		return false
+	}
+	if final {
+		delete(running, job.ID)
+		if entry.cancelled {
+			job.Status = StatusCancelled
+			job.Result = nil
+			job.Error = nil
+		}
+	}
+	if err := store.Put(context.Background(), job); err != nil {
+		log.Errorf("jobs: failed to store job %s: %v", job.ID, err)
+	}
+	return true
+}
+
+func isRunning(id string) bool {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	_, ok := running[id]
+	return ok
+}
+
+// runningFor counts the jobs of owner in progress. runningMu must be held.
+func runningFor(owner string) int {
+	n := 0
+	for _, job := range running {
+		if job.owner == owner {
+			n++
+		}
+	}
+	return n
+}
+
+// maxRunningPerKey is the cap on jobs in progress per client key under cfg.
+func maxRunningPerKey(cfg *config.SDKConfig) int {
+	if cfg != nil && cfg.Jobs.MaxRunningPerKey > 0 {
+		return cfg.Jobs.MaxRunningPerKey
+	}
+	return defaultMaxRunningPerKey
+}
+
+func jobError(errMsg *interfaces.ErrorMessage) *JobError {
+	status := errMsg.StatusCode
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	message := http.StatusText(status)
+	if errMsg.Error != nil {
+		message = errMsg.Error.Error()
+	}
+	return &JobError{Status: status, Message: message}
+}
+
+// deliverWebhook posts the finished job to its webhook, retrying failed deliveries, and
+// records the delivery status on the stored job.
+func (h *JobsAPIHandler) deliverWebhook(store Store, job *Job) {
+	guard := newWebhookGuard(h.Cfg)
+	client, viaProxy := webhookClient(h.Cfg, guard, job.Webhook.URL)
+	backoff := webhookBackoff
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		job.Webhook.Attempts = attempt
+		var err error
+		if viaProxy {
+			err = guard.checkResolved(context.Background(), job.Webhook.URL)
+		}
+		if err == nil {
+			err = h.postWebhook(client, job)
+		}
+		if err == nil {
+			job.Webhook.Status = WebhookDelivered
+			job.Webhook.LastError = ""
+			break
+		}
+		job.Webhook.Status = WebhookFailed
+		job.Webhook.LastError = err.Error()
+		log.Warnf("jobs: webhook delivery %d for job %s failed: %v", attempt, job.ID, err)
+	}
+
+	// Keep the delivery status unless the job was deleted meanwhile.
+	if stored, err := store.Get(context.Background(), job.ID); err == nil && stored != nil {
+		stored.Webhook = job.Webhook
+		if errPut := store.Put(context.Background(), stored); errPut != nil {
+			log.Errorf("jobs: failed to store job %s: %v", job.ID, errPut)
+		}
+	}
+}
+
+func (h *JobsAPIHandler) postWebhook(client *http.Client, job *Job) error {
+	delivery := job.clone()
+	delivery.Webhook.Status = WebhookPending
+	delivery.Webhook.LastError = ""
+	body, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, job.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := h.Cfg.Jobs.WebhookSecret; secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set(JobSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (h *JobsAPIHandler) writeNotFound(c *gin.Context, id string) {
+	h.writeError(c, http.StatusNotFound, fmt.Sprintf("no job found with id %q", id))
+}
+
+func (h *JobsAPIHandler) writeError(c *gin.Context, status int, message string) {
+	h.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: status, Error: fmt.Errorf("%s", message)})
+}
@@ -0,0 +1,336 @@
+package jobs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// jobsExecutor answers "jobs-slow" only once its context is cancelled, "jobs-broken" with
+// an error and any other model at once.
+type jobsExecutor struct{}
+
+func (e *jobsExecutor) Identifier() string { return "jobs-test" }
+
+func (e *jobsExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	switch req.Model {
+	case "jobs-broken":
+		return coreexecutor.Response{}, &coreauth.Error{Code: "rate_limited", Message: "slow down", HTTPStatus: http.StatusTooManyRequests}
+	case "jobs-slow":
+		<-ctx.Done()
+		return coreexecutor.Response{}, ctx.Err()
+	}
+	return coreexecutor.Response{Payload: []byte(`{"model":"` + req.Model + `","stream":` + gjson.GetBytes(req.Payload, "stream").Raw + `}`)}, nil
+}
+
+func (e *jobsExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *jobsExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *jobsExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *jobsExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newJobsRouter(t *testing.T, cfg *sdkconfig.SDKConfig) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&jobsExecutor{})
+	auth := &coreauth.Auth{ID: "jobs-auth", Provider: "jobs-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "jobs-fast"}, {ID: "jobs-slow"}, {ID: "jobs-broken"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewJobsAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	// Stand in for the auth middleware: the test key travels in a header.
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+		}
+	})
+	router.POST("/v1/jobs", h.CreateJob)
+	router.GET("/v1/jobs/:id", h.GetJob)
+	router.POST("/v1/jobs/:id/cancel", h.CancelJob)
+	router.DELETE("/v1/jobs/:id", h.DeleteJob)
+	return router
+}
+
+func useMemoryStore(t *testing.T) {
+	t.Helper()
+	SetStore(newMemoryStore())
+	t.Cleanup(func() { SetStore(nil) })
+}
+
+func doJSON(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	return doJSONAs(router, "", method, path, body)
+}
+
+func doJSONAs(router *gin.Engine, key, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("X-Test-Key", key)
+	}
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// waitForStatus polls the job until it reaches status.
+func waitForStatus(t *testing.T, router *gin.Engine, id, status string) gjson.Result {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := doJSON(router, http.MethodGet, "/v1/jobs/"+id, "")
+		job := gjson.Parse(rec.Body.String())
+		if job.Get("status").String() == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not reach %s: %s", id, status, rec.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobs_CompletesAndDeliversWebhook(t *testing.T) {
+	useMemoryStore(t)
+	delivered := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		delivered <- r
+		bodies <- body
+	}))
+	defer webhook.Close()
+	router := newJobsRouter(t, &sdkconfig.SDKConfig{Jobs: sdkconfig.JobsConfig{WebhookSecret: "secret", WebhookAllowedHosts: []string{"127.0.0.1"}}})
+
+	rec := doJSON(router, http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-fast","stream":true,"messages":[]},"webhook_url":"`+webhook.URL+`"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("create status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	id := gjson.Get(rec.Body.String(), "id").String()
+	if !strings.HasPrefix(id, "job_") || gjson.Get(rec.Body.String(), "format").String() != "openai" {
+		t.Fatalf("created job = %s", rec.Body.String())
+	}
+
+	job := waitForStatus(t, router, id, StatusCompleted)
+	if job.Get("result.model").String() != "jobs-fast" || job.Get("result.stream").Type != gjson.False {
+		t.Fatalf("result = %s", job.Get("result").Raw)
+	}
+	if job.Get("request").Exists() {
+		t.Fatalf("the request must not be returned: %s", job.Raw)
+	}
+
+	select {
+	case req := <-delivered:
+		body := <-bodies
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if got, want := req.Header.Get(JobSignatureHeader), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+			t.Fatalf("%s = %q, want %q", JobSignatureHeader, got, want)
+		}
+		if gjson.GetBytes(body, "id").String() != id || gjson.GetBytes(body, "status").String() != StatusCompleted {
+			t.Fatalf("webhook body = %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for gjson.Get(doJSON(router, http.MethodGet, "/v1/jobs/"+id, "").Body.String(), "webhook.status").String() != WebhookDelivered {
+		if time.Now().After(deadline) {
+			t.Fatal("webhook delivery was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobs_FailedRequest(t *testing.T) {
+	useMemoryStore(t)
+	router := newJobsRouter(t, &sdkconfig.SDKConfig{})
+
+	rec := doJSON(router, http.MethodPost, "/v1/jobs", `{"format":"claude","request":{"model":"jobs-broken","messages":[]}}`)
+	job := waitForStatus(t, router, gjson.Get(rec.Body.String(), "id").String(), StatusFailed)
+	if job.Get("error.status").Int() != http.StatusTooManyRequests || job.Get("error.message").String() == "" || job.Get("result").Exists() {
+		t.Fatalf("failed job = %s", job.Raw)
+	}
+}
+
+func TestJobs_CancelAndDelete(t *testing.T) {
+	useMemoryStore(t)
+	router := newJobsRouter(t, &sdkconfig.SDKConfig{})
+
+	rec := doJSON(router, http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-slow","messages":[]}}`)
+	id := gjson.Get(rec.Body.String(), "id").String()
+	waitForStatus(t, router, id, StatusRunning)
+	if rec = doJSON(router, http.MethodPost, "/v1/jobs/"+id+"/cancel", ""); rec.Code != http.StatusOK {
+		t.Fatalf("cancel status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	waitForStatus(t, router, id, StatusCancelled)
+
+	if rec = doJSON(router, http.MethodDelete, "/v1/jobs/"+id, ""); rec.Code != http.StatusOK || !gjson.Get(rec.Body.String(), "deleted").Bool() {
+		t.Fatalf("delete status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if rec = doJSON(router, http.MethodGet, "/v1/jobs/"+id, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("deleted job status = %d", rec.Code)
+	}
+}
+
+func TestJobs_HiddenFromOtherKeys(t *testing.T) {
+	useMemoryStore(t)
+	router := newJobsRouter(t, &sdkconfig.SDKConfig{})
+
+	rec := doJSONAs(router, "key-a", http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-slow","messages":[]}}`)
+	id := gjson.Get(rec.Body.String(), "id").String()
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/v1/jobs/" + id},
+		{http.MethodPost, "/v1/jobs/" + id + "/cancel"},
+		{http.MethodDelete, "/v1/jobs/" + id},
+	} {
+		for _, key := range []string{"key-b", ""} {
+			if rec = doJSONAs(router, key, req.method, req.path, ""); rec.Code != http.StatusNotFound {
+				t.Fatalf("%s %s as %q: status = %d, want 404", req.method, req.path, key, rec.Code)
+			}
+		}
+	}
+	if got := gjson.Get(doJSONAs(router, "key-a", http.MethodGet, "/v1/jobs/"+id, "").Body.String(), "status").String(); got == StatusCancelled || got == "" {
+		t.Fatalf("the owner's job was touched by another key: status %q", got)
+	}
+	if rec = doJSONAs(router, "key-a", http.MethodDelete, "/v1/jobs/"+id, ""); rec.Code != http.StatusOK {
+		t.Fatalf("owner delete status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestJobs_InterruptedJobIsFailed(t *testing.T) {
+	useMemoryStore(t)
+	router := newJobsRouter(t, &sdkconfig.SDKConfig{})
+	store := storeFor(nil)
+	job := &Job{ID: "job_interrupted", Object: "job", Status: StatusRunning, Format: "openai", Model: "jobs-fast", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	if err := store.Put(context.Background(), job); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	got := gjson.Parse(doJSON(router, http.MethodGet, "/v1/jobs/job_interrupted", "").Body.String())
+	if got.Get("status").String() != StatusFailed || !strings.Contains(got.Get("error.message").String(), "restart") {
+		t.Fatalf("interrupted job = %s", got.Raw)
+	}
+}
+
+func TestJobs_Validation(t *testing.T) {
+	router := newJobsRouter(t, &sdkconfig.SDKConfig{})
+	if rec := doJSON(router, http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-fast"}}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled job API status = %d", rec.Code)
+	}
+
+	useMemoryStore(t)
+	for _, body := range []string{
+		`{"format":"gemini","request":{"model":"jobs-fast"}}`,
+		`{"request":{"messages":[]}}`,
+		`{"request":"hello"}`,
+		`{"request":{"model":"jobs-fast"},"webhook_url":"ftp://example.com"}`,
+		`{"request":{"model":"jobs-fast"},"webhook_url":"http://127.0.0.1:8080/hook"}`,
+		`{"request":{"model":"jobs-fast"},"webhook_url":"http://localhost/hook"}`,
+		`{"request":{"model":"jobs-fast"},"webhook_url":"http://169.254.169.254/latest/meta-data"}`,
+		`{"request":{"model":"jobs-fast"},"webhook_url":"http://[::1]/hook"}`,
+	} {
+		if rec := doJSON(router, http.MethodPost, "/v1/jobs", body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, body = %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestJobs_RunningCapPerKey(t *testing.T) {
+	useMemoryStore(t)
+	router := newJobsRouter(t, &sdkconfig.SDKConfig{Jobs: sdkconfig.JobsConfig{MaxRunningPerKey: 1}})
+
+	first := doJSONAs(router, "key-a", http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-slow","messages":[]}}`)
+	id := gjson.Get(first.Body.String(), "id").String()
+	if rec := doJSONAs(router, "key-a", http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-slow","messages":[]}}`); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second job status = %d, want 429", rec.Code)
+	}
+	if rec := doJSONAs(router, "key-b", http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-fast","messages":[]}}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("other key status = %d, want 202", rec.Code)
+	}
+	doJSONAs(router, "key-a", http.MethodDelete, "/v1/jobs/"+id, "")
+	if rec := doJSONAs(router, "key-a", http.MethodPost, "/v1/jobs", `{"request":{"model":"jobs-fast","messages":[]}}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("status after the first job ended = %d, want 202", rec.Code)
+	}
+}
+
+func TestWebhookGuard_RejectsInternalTargetsAtDial(t *testing.T) {
+	guard := newWebhookGuard(&sdkconfig.SDKConfig{Jobs: sdkconfig.JobsConfig{WebhookAllowedHosts: []string{"10.1.0.0/16", "hooks.internal"}}})
+	for address, allowed := range map[string]bool{
+		"93.184.216.34:443":     true,
+		"10.1.2.3:80":           true,
+		"10.2.0.1:80":           false,
+		"127.0.0.1:80":          false,
+		"169.254.169.254:80":    false,
+		"100.64.0.1:80":         false,
+		"[fd00::1]:443":         false,
+		"[2606:4700::1]:443":    true,
+		"[::ffff:127.0.0.1]:80": false,
+	} {
+		if err := guard.control("tcp", address, nil); (err == nil) != allowed {
+			t.Fatalf("control(%s) = %v, want allowed %v", address, err, allowed)
+		}
+	}
+	if err := guard.checkURL("http://hooks.internal/x"); err != nil {
+		t.Fatalf("allowlisted host rejected: %v", err)
+	}
+
+	// A host name that resolves to loopback is caught when connecting.
+	webhook := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer webhook.Close()
+	target := strings.Replace(webhook.URL, "127.0.0.1", "localhost", 1)
+	client, _ := webhookClient(&sdkconfig.SDKConfig{}, newWebhookGuard(nil), target)
+	if _, err := client.Post(target, "application/json", nil); err == nil || !strings.Contains(err.Error(), "loopback") {
+		t.Fatalf("delivery to a loopback address = %v, want it rejected", err)
+	}
+}
+
+func TestFileStore_RoundTripAndPurge(t *testing.T) {
+	store := &fileStore{dir: t.TempDir()}
+	ctx := context.Background()
+	job := &Job{ID: "job_abc", Object: "job", Status: StatusQueued, Request: []byte(`{"model":"m"}`), Owner: "owner", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	if err := store.Put(ctx, job); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	got, err := store.Get(ctx, "job_abc")
+	if err != nil || got == nil || string(got.Request) != `{"model":"m"}` || got.Owner != "owner" || got.Status != StatusQueued {
+		t.Fatalf("get = %+v, %v", got, err)
+	}
+	if got, _ = store.Get(ctx, "../job_abc"); got != nil {
+		t.Fatal("unsafe ids must not be read")
+	}
+	if removed := store.purgeExpired(time.Now().Add(2 * time.Hour)); removed != 1 {
+		t.Fatalf("purged %d jobs, want 1", removed)
+	}
+	if got, _ = store.Get(ctx, "job_abc"); got != nil {
+		t.Fatal("expired job is still stored")
+	}
+}
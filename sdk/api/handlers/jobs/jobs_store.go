@@ -0,0 +1,348 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// jobStoreCleanup is the default janitor schedule for expired jobs.
+const jobStoreCleanup = 10 * time.Minute
+
+// defaultJobTTL is how long jobs are kept when jobs.ttl-seconds is not set.
+const defaultJobTTL = 24 * time.Hour
+
+// Job statuses. Queued and running jobs are in progress; the others are final.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Webhook delivery statuses.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// Job is an asynchronous generation and its outcome, as returned by the job API.
+type Job struct {
+	ID     string `json:"id"`
+	Object string `json:"object"`
+	Status string `json:"status"`
+
+	// Format is the API format of Request and Result, e.g. "openai" or "claude".
+	Format string `json:"format"`
+	Model  string `json:"model"`
+
+	// Request is the generation request as submitted. It is kept so jobs can be run from
+	// the store, but not returned to clients.
+	Request json.RawMessage `json:"-"`
+
+	// Owner identifies the client API key that created the job (see
+	// handlers.ClientKeyOwner). Other keys are told the job does not exist.
+	Owner string `json:"-"`
+
+	// Result is the response body of a completed job, in the job's format.
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *JobError       `json:"error,omitempty"`
+
+	Webhook *JobWebhook `json:"webhook,omitempty"`
+
+	CreatedAt   int64 `json:"created_at"`
+	StartedAt   int64 `json:"started_at,omitempty"`
+	CompletedAt int64 `json:"completed_at,omitempty"`
+	ExpiresAt   int64 `json:"expires_at"`
+}
+
+// JobError describes why a job failed.
+type JobError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// JobWebhook tracks the delivery of a finished job to the client's webhook.
+type JobWebhook struct {
+	URL       string `json:"url"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// storedJob is the persisted form of a job, which also keeps the request and owner.
+type storedJob struct {
+	*Job
+	Request json.RawMessage `json:"request"`
+	Owner   string          `json:"owner,omitempty"`
+}
+
+// Final reports whether the job has finished.
+func (j *Job) Final() bool {
+	return j.Status != StatusQueued && j.Status != StatusRunning
+}
+
+// expired reports whether the job is past its retention at now.
+func (j *Job) expired(now time.Time) bool {
+	return j.ExpiresAt > 0 && now.Unix() > j.ExpiresAt
+}
+
+// clone returns a copy of the job that shares no mutable state with it.
+func (j *Job) clone() *Job {
+	out := *j
+	if j.Error != nil {
+		jobError := *j.Error
+		out.Error = &jobError
+	}
+	if j.Webhook != nil {
+		webhook := *j.Webhook
+		out.Webhook = &webhook
+	}
+	return &out
+}
+
+// Store persists jobs. Get returns nil without error for unknown or expired ids.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, id string) (*Job, error)
+	Put(ctx context.Context, job *Job) error
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+var (
+	storeMu            sync.Mutex
+	customStore        Store
+	configuredStore    Store
+	configuredStoreKey string
+)
+
+func init() {
+	janitor.Register("jobs-store", jobStoreCleanup, func(_ *config.Config, now time.Time) (int, error) {
+		storeMu.Lock()
+		store := configuredStore
+		storeMu.Unlock()
+		if sweeper, ok := store.(interface{ purgeExpired(time.Time) int }); ok {
+			return sweeper.purgeExpired(now), nil
+		}
+		return 0, nil
+	})
+}
+
+// SetStore installs store, e.g. a database-backed one, as the backend of the job API in
+// place of the one selected by jobs.store. A custom store enables the job API regardless
+// of the config. nil restores the configured store.
+func SetStore(store Store) {
+	storeMu.Lock()
+	customStore = store
+	storeMu.Unlock()
+}
+
+// storeFor returns the store selected by cfg, or nil when the job API is disabled. The
+// configured store is kept across reloads that do not change its kind or directory.
+func storeFor(cfg *config.SDKConfig) Store {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if customStore != nil {
+		return customStore
+	}
+	if cfg == nil {
+		return nil
+	}
+	kind := strings.ToLower(strings.TrimSpace(cfg.Jobs.Store))
+	dir := strings.TrimSpace(cfg.Jobs.StoreDir)
+	key := kind + "|" + dir
+	if key == configuredStoreKey {
+		return configuredStore
+	}
+	var store Store
+	switch kind {
+	case "memory":
+		store = newMemoryStore()
+	case "file":
+		resolved, err := util.ResolveAuthDir(dir)
+		if err != nil || resolved == "" {
+			log.Errorf("jobs store: invalid store-dir %q: %v", dir, err)
+			return nil
+		}
+		store = &fileStore{dir: resolved}
+	}
+	configuredStore, configuredStoreKey = store, key
+	return store
+}
+
+// jobTTL is how long jobs are kept under cfg.
+func jobTTL(cfg *config.SDKConfig) time.Duration {
+	if cfg != nil && cfg.Jobs.TTLSeconds > 0 {
+		return time.Duration(cfg.Jobs.TTLSeconds) * time.Second
+	}
+	return defaultJobTTL
+}
+
+// memoryStore keeps jobs in process memory.
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{jobs: make(map[string]*Job)}
+}
+
+func (s *memoryStore) Get(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	if job.expired(time.Now()) {
+		delete(s.jobs, id)
+		return nil, nil
+	}
+	return job.clone(), nil
+}
+
+func (s *memoryStore) Put(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	s.jobs[job.ID] = job.clone()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.jobs[id]
+	delete(s.jobs, id)
+	return ok, nil
+}
+
+func (s *memoryStore) purgeExpired(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for id, job := range s.jobs {
+		if job.expired(now) {
+			delete(s.jobs, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// safeJobID matches the ids the job API hands out, which are used as file names.
+var safeJobID = regexp.MustCompile(`^job_[A-Za-z0-9]{1,64}$`)
+
+// fileStore keeps each job in its own JSON file, so jobs survive restarts.
+type fileStore struct {
+	dir string
+}
+
+func (s *fileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *fileStore) Get(_ context.Context, id string) (*Job, error) {
+	if !safeJobID.MatchString(id) {
+		return nil, nil
+	}
+	job, err := s.read(s.path(id))
+	if err != nil || job == nil {
+		return nil, err
+	}
+	if job.expired(time.Now()) {
+		_ = os.Remove(s.path(id))
+		return nil, nil
+	}
+	return job, nil
+}
+
+func (s *fileStore) read(path string) (*Job, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored := storedJob{Job: &Job{}}
+	if err = json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	stored.Job.Request = stored.Request
+	stored.Job.Owner = stored.Owner
+	return stored.Job, nil
+}
+
+func (s *fileStore) Put(_ context.Context, job *Job) error {
+	if !safeJobID.MatchString(job.ID) {
+		return errors.New("invalid job id")
+	}
+	data, err := json.Marshal(storedJob{Job: job, Request: job.Request, Owner: job.Owner})
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".job-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(job.ID))
+}
+
+func (s *fileStore) Delete(_ context.Context, id string) (bool, error) {
+	if !safeJobID.MatchString(id) {
+		return false, nil
+	}
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *fileStore) purgeExpired(now time.Time) int {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		job, errRead := s.read(path)
+		if errRead != nil || job == nil || !job.expired(now) {
+			continue
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// webhookDialTimeout bounds connecting to a webhook target.
+const webhookDialTimeout = 10 * time.Second
+
+// errWebhookTarget rejects webhook targets on loopback, private or link-local addresses.
+var errWebhookTarget = errors.New("webhook_url must not point at a loopback, private or link-local address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which net.IP does not
+// classify as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// webhookGuard decides which hosts webhook deliveries may reach. Public addresses are
+// always allowed; internal ones only when jobs.webhook-allowed-hosts lists them.
+type webhookGuard struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+}
+
+func newWebhookGuard(cfg *config.SDKConfig) webhookGuard {
+	guard := webhookGuard{hosts: make(map[string]bool)}
+	if cfg == nil {
+		return guard
+	}
+	for _, entry := range cfg.Jobs.WebhookAllowedHosts {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			guard.nets = append(guard.nets, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			guard.nets = append(guard.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		guard.hosts[entry] = true
+	}
+	return guard
+}
+
+// hostAllowed reports whether host is listed by name and may resolve anywhere.
+func (g webhookGuard) hostAllowed(host string) bool {
+	return g.hosts[strings.ToLower(host)]
+}
+
+// ipAllowed reports whether a delivery may connect to ip.
+func (g webhookGuard) ipAllowed(ip net.IP) bool {
+	for _, network := range g.nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// checkURL validates a webhook URL when the job is created. Host names are only checked
+// against the obvious loopback names here; their addresses are checked when delivering.
+func (g webhookGuard) checkURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return errors.New("webhook_url must be an absolute http or https URL")
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if g.hostAllowed(host) {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !g.ipAllowed(ip) {
+			return errWebhookTarget
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errWebhookTarget
+	}
+	return nil
+}
+
+// control rejects connections to disallowed addresses once the target is resolved, so a
+// host name cannot be pointed at an internal address after the job was accepted.
+func (g webhookGuard) control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !g.ipAllowed(ip) {
+		return fmt.Errorf("%w: %s", errWebhookTarget, host)
+	}
+	return nil
+}
+
+// checkResolved resolves the host of raw and rejects it when any address is disallowed.
+// It guards deliveries made through the configured proxy, which resolves the target
+// itself.
+func (g webhookGuard) checkResolved(ctx context.Context, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	host := parsed.Hostname()
+	if g.hostAllowed(host) {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !g.ipAllowed(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", errWebhookTarget, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// webhookClient returns the client that delivers to rawURL and whether it goes through
+// the configured proxy, in which case the caller checks the target with checkResolved.
+// Redirects are not followed, since they could lead to a disallowed target.
+func webhookClient(cfg *config.SDKConfig, guard webhookGuard, rawURL string) (*http.Client, bool) {
+	client := util.SetProxy(cfg, &http.Client{Timeout: webhookTimeout})
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	if client.Transport != nil {
+		return client, true
+	}
+	dialer := &net.Dialer{Timeout: webhookDialTimeout}
+	if parsed, err := url.Parse(rawURL); err != nil || !guard.hostAllowed(parsed.Hostname()) {
+		dialer.Control = guard.control
+	}
+	client.Transport = &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookDialTimeout}
+	return client, false
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// jsonModeMaxRetries bounds the re-asks after an emulated JSON-mode reply fails
+// validation.
+const jsonModeMaxRetries = 2
+
+// jsonModeEmulatedProviders maps the providers whose request path cannot express an
+// OpenAI response_format to the formats emulated for them with a system instruction and
+// checked locally. Claude receives json_schema as a forced tool call and the Gemini
+// translators map both formats to responseMimeType/responseJsonSchema.
+var jsonModeEmulatedProviders = map[string][]string{
+	"claude":      {"json_object"},
+	"kiro":        {"json_object", "json_schema"},
+	"antigravity": {"json_object", "json_schema"},
+}
+
+// jsonModeInstruction is the system text injected for emulated JSON mode.
+const jsonModeInstruction = "Respond only with a single valid JSON object. Do not add any text before or after it and do not wrap it in a code block."
+
+// jsonModeFor returns the response_format type of an OpenAI chat request ("json_object"
+// or "json_schema") when it must be emulated for the providers serving modelName.
+func (h *BaseAPIHandler) jsonModeFor(handlerType, modelName string, rawJSON []byte) (string, bool) {
+	if handlerType != constant.OpenAI {
+		return "", false
+	}
+	mode := gjson.GetBytes(rawJSON, "response_format.type").String()
+	if mode != "json_object" && mode != "json_schema" {
+		return "", false
+	}
+	providers, _, _, errMsg := h.getRequestDetails(modelName)
+	if errMsg != nil {
+		return "", false
+	}
+	for _, provider := range providers {
+		if slices.Contains(jsonModeEmulatedProviders[provider], mode) {
+			return mode, true
+		}
+	}
+	return "", false
+}
+
+// strictJSONSchema returns the schema of an OpenAI chat request whose json_schema
+// response_format is marked strict; replies to it are validated against the schema.
+func strictJSONSchema(handlerType string, rawJSON []byte) (string, bool) {
+	if handlerType != constant.OpenAI {
+		return "", false
+	}
+	format := gjson.GetBytes(rawJSON, "response_format")
+	if format.Get("type").String() != "json_schema" || !format.Get("json_schema.strict").Bool() {
+		return "", false
+	}
+	schema := format.Get("json_schema.schema")
+	if !schema.IsObject() {
+		return "", false
+	}
+	return schema.Raw, true
+}
+
+// jsonModeSystemText returns the instruction for mode, including the schema the
+// client supplied for json_schema.
+func jsonModeSystemText(mode string, rawJSON []byte) string {
+	if mode != "json_schema" {
+		return jsonModeInstruction
+	}
+	schema := gjson.GetBytes(rawJSON, "response_format.json_schema.schema")
+	if !schema.Exists() {
+		return jsonModeInstruction
+	}
+	return jsonModeInstruction + " The object must conform to this JSON Schema:\n" + schema.Raw
+}
+
+// executeWithJSONMode runs a non-streaming request, emulating JSON mode when the routed
+// provider lacks it and validating replies to strict json_schema formats: every choice
+// must hold a JSON object matching the schema, otherwise the model is re-asked up to
+// jsonModeMaxRetries times before the request fails with json_output_invalid.
+func (h *BaseAPIHandler) executeWithJSONMode(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	mode, emulate := h.jsonModeFor(handlerType, modelName, rawJSON)
+	schema, strict := strictJSONSchema(handlerType, rawJSON)
+	if !emulate && !strict {
+		return h.executeWithAuthManager(ctx, handlerType, modelName, rawJSON, alt)
+	}
+	request := rawJSON
+	if emulate {
+		request = injectSystemInstruction(handlerType, rawJSON, jsonModeSystemText(mode, rawJSON))
+	}
+	maxRetries := jsonModeMaxRetries
+	if h.Cfg != nil && h.Cfg.StructuredOutput.DisableReask {
+		maxRetries = 0
+	}
+	var problem string
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		resp, errMsg := h.executeWithAuthManager(ctx, handlerType, modelName, request, alt)
+		if errMsg != nil {
+			return nil, errMsg
+		}
+		var reply string
+		resp, reply, problem = normalizeJSONModeResponse(resp, schema)
+		if problem == "" {
+			return resp, nil
+		}
+		log.WithFields(log.Fields{"model": modelName, "attempt": attempt + 1}).Debugf("json mode: reply is not valid JSON: %s", problem)
+		request = appendJSONModeCorrection(request, reply, problem)
+	}
+	log.WithField("model", modelName).Warnf("json mode: no valid JSON after %d attempts", maxRetries+1)
+	return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: jsonModeError(problem)}
+}
+
+// normalizeJSONModeResponse checks that every choice of an OpenAI chat response holds a
+// JSON object, matching schema when one is given, and rewrites each content to the bare
+// JSON, stripping code fences. It returns the first invalid reply and what is wrong with
+// it, or an empty problem.
+func normalizeJSONModeResponse(resp []byte, schema string) ([]byte, string, string) {
+	choices := gjson.GetBytes(resp, "choices")
+	if !choices.IsArray() || len(choices.Array()) == 0 {
+		return resp, "", "the response has no choices"
+	}
+	out := resp
+	for i, choice := range choices.Array() {
+		content := choice.Get("message.content").String()
+		normalized, problem := parseJSONObject(content)
+		if problem == "" && schema != "" {
+			if violation := validateJSONSchema(schema, normalized); violation != "" {
+				problem = "the reply does not match the JSON Schema: " + violation
+			}
+		}
+		if problem != "" {
+			return resp, content, problem
+		}
+		out, _ = sjson.SetBytes(out, fmt.Sprintf("choices.%d.message.content", i), normalized)
+	}
+	return out, "", ""
+}
+
+// parseJSONObject extracts a JSON object from text, tolerating surrounding whitespace and
+// a Markdown code fence.
+func parseJSONObject(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+			text = text[newline+1:]
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	if text == "" {
+		return "", "the reply is empty"
+	}
+	if !json.Valid([]byte(text)) {
+		return "", "the reply is not valid JSON"
+	}
+	if !gjson.Parse(text).IsObject() {
+		return "", "the reply is JSON but not an object"
+	}
+	return text, ""
+}
+
+// appendJSONModeCorrection adds the invalid reply and a correction to the conversation.
+func appendJSONModeCorrection(rawJSON []byte, reply, problem string) []byte {
+	out, err := sjson.SetBytes(rawJSON, "messages.-1", map[string]string{"role": "assistant", "content": reply})
+	if err != nil {
+		return rawJSON
+	}
+	correction := "Your previous reply was rejected: " + problem + ". " + jsonModeInstruction
+	if next, errSet := sjson.SetBytes(out, "messages.-1", map[string]string{"role": "user", "content": correction}); errSet == nil {
+		out = next
+	}
+	return out
+}
+
+// jsonModeError is the structured error returned when no attempt produced valid JSON.
+func jsonModeError(problem string) error {
+	body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{
+		Message: "the model did not produce valid JSON for response_format: " + problem,
+		Type:    "server_error",
+		Code:    "json_output_invalid",
+	}})
+	return fmt.Errorf("%s", body)
+}
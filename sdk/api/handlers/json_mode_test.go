@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// scriptedClaudeExecutor answers successive calls with the scripted assistant replies.
+type scriptedClaudeExecutor struct {
+	failOnceStreamExecutor
+	mu       sync.Mutex
+	replies  []string
+	payloads [][]byte
+}
+
+func (e *scriptedClaudeExecutor) Identifier() string { return "claude" }
+
+func (e *scriptedClaudeExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.payloads = append(e.payloads, req.Payload)
+	reply := e.replies[0]
+	if len(e.replies) > 1 {
+		e.replies = e.replies[1:]
+	}
+	body := `{"choices":[{"index":0,"message":{"role":"assistant","content":` + jsonString(reply) + `}}]}`
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func jsonString(s string) string {
+	out, _ := json.Marshal(s)
+	return string(out)
+}
+
+func newJSONModeHandler(t *testing.T, replies ...string) (*BaseAPIHandler, *scriptedClaudeExecutor) {
+	t.Helper()
+	executor := &scriptedClaudeExecutor{replies: replies}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "json-mode-auth", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "json-mode-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager), executor
+}
+
+const jsonModeRequest = `{"model":"json-mode-model","response_format":{"type":"json_object"},"messages":[{"role":"user","content":"hi"}]}`
+
+func TestExecuteWithJSONMode_RetriesUntilValid(t *testing.T) {
+	handler, executor := newJSONModeHandler(t, "Sure! here you go", "```json\n{\"ok\":true}\n```")
+	resp, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "json-mode-model", []byte(jsonModeRequest), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != `{"ok":true}` {
+		t.Fatalf("content = %q, want the bare JSON", got)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("calls = %d, want 2", len(executor.payloads))
+	}
+	if first := gjson.GetBytes(executor.payloads[0], "messages.0.role").String(); first != "system" {
+		t.Fatalf("JSON instruction must be injected as a system message, got %s", executor.payloads[0])
+	}
+	retry := gjson.GetBytes(executor.payloads[1], "messages").Array()
+	if len(retry) != 4 || retry[2].Get("content").String() != "Sure! here you go" || retry[3].Get("role").String() != "user" {
+		t.Fatalf("retry must carry the rejected reply and a correction: %s", executor.payloads[1])
+	}
+}
+
+func TestExecuteWithJSONMode_FailsWithStructuredError(t *testing.T) {
+	handler, executor := newJSONModeHandler(t, "[1, 2]")
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "json-mode-model", []byte(jsonModeRequest), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected a 502 error, got %+v", errMsg)
+	}
+	if code := gjson.Get(errMsg.Error.Error(), "error.code").String(); code != "json_output_invalid" {
+		t.Fatalf("error = %s", errMsg.Error)
+	}
+	if len(executor.payloads) != jsonModeMaxRetries+1 {
+		t.Fatalf("calls = %d, want %d", len(executor.payloads), jsonModeMaxRetries+1)
+	}
+}
+
+const strictSchemaRequest = `{"model":"json-mode-model","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"n","strict":true,"schema":{"type":"object","properties":{"n":{"type":"integer"}},"required":["n"],"additionalProperties":false}}}}`
+
+func TestExecuteWithJSONMode_ValidatesStrictSchema(t *testing.T) {
+	handler, executor := newJSONModeHandler(t, `{"n":"one"}`, `{"n":1}`)
+	resp, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "json-mode-model", []byte(strictSchemaRequest), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != `{"n":1}` {
+		t.Fatalf("content = %q", got)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("calls = %d, want 2", len(executor.payloads))
+	}
+	if first := gjson.GetBytes(executor.payloads[0], "messages.0.role").String(); first == "system" {
+		t.Fatalf("json_schema is native for claude and must not be emulated: %s", executor.payloads[0])
+	}
+	if correction := gjson.GetBytes(executor.payloads[1], "messages.2.content").String(); !strings.Contains(correction, "$.n must be of type integer") {
+		t.Fatalf("correction = %q", correction)
+	}
+}
+
+func TestExecuteWithJSONMode_DisableReask(t *testing.T) {
+	handler, executor := newJSONModeHandler(t, `{"n":"one"}`)
+	handler.Cfg.StructuredOutput.DisableReask = true
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "json-mode-model", []byte(strictSchemaRequest), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected a 502 error, got %+v", errMsg)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("calls = %d, want 1", len(executor.payloads))
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// validateJSONSchema checks value against schema and describes the first violation, or
+// returns "" when value conforms. It covers the JSON Schema keywords structured output
+// schemas use: type, enum, const, properties, required, additionalProperties, items,
+// anyOf/oneOf/allOf, local $ref and the basic string, number and array bounds.
+func validateJSONSchema(schema, value string) string {
+	root := gjson.Parse(schema)
+	return schemaValidator{root: root}.validate(root, gjson.Parse(value), "$", 0)
+}
+
+// maxSchemaDepth bounds $ref resolution so recursive schemas cannot loop forever.
+const maxSchemaDepth = 64
+
+type schemaValidator struct {
+	root gjson.Result
+}
+
+func (v schemaValidator) validate(schema, value gjson.Result, path string, depth int) string {
+	if depth > maxSchemaDepth {
+		return ""
+	}
+	switch schema.Type {
+	case gjson.True:
+		return ""
+	case gjson.False:
+		return fmt.Sprintf("%s is not allowed", path)
+	}
+	if !schema.IsObject() {
+		return ""
+	}
+	if ref := schema.Get(`\$ref`).String(); ref != "" {
+		target, ok := v.resolve(ref)
+		if !ok {
+			return ""
+		}
+		return v.validate(target, value, path, depth+1)
+	}
+
+	if types := schema.Get("type"); types.Exists() && !matchesSchemaType(types, value) {
+		return fmt.Sprintf("%s must be of type %s", path, schemaTypeNames(types))
+	}
+	if enum := schema.Get("enum"); enum.IsArray() {
+		found := false
+		for _, option := range enum.Array() {
+			if jsonEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%s must be one of %s", path, enum.Raw)
+		}
+	}
+	if constant := schema.Get("const"); constant.Exists() && !jsonEqual(constant, value) {
+		return fmt.Sprintf("%s must be %s", path, constant.Raw)
+	}
+
+	for _, sub := range schema.Get("allOf").Array() {
+		if problem := v.validate(sub, value, path, depth+1); problem != "" {
+			return problem
+		}
+	}
+	if anyOf := schema.Get("anyOf"); anyOf.IsArray() && v.countMatches(anyOf, value, path, depth) == 0 {
+		return fmt.Sprintf("%s must match a schema of anyOf", path)
+	}
+	if oneOf := schema.Get("oneOf"); oneOf.IsArray() {
+		if matches := v.countMatches(oneOf, value, path, depth); matches != 1 {
+			return fmt.Sprintf("%s must match exactly one schema of oneOf, matches %d", path, matches)
+		}
+	}
+
+	switch {
+	case value.IsObject():
+		return v.validateObject(schema, value, path, depth)
+	case value.IsArray():
+		return v.validateArray(schema, value, path, depth)
+	case value.Type == gjson.String:
+		return validateString(schema, value.String(), path)
+	case value.Type == gjson.Number:
+		return validateNumber(schema, value.Float(), path)
+	}
+	return ""
+}
+
+// countMatches returns how many of schemas value conforms to.
+func (v schemaValidator) countMatches(schemas, value gjson.Result, path string, depth int) int {
+	matches := 0
+	for _, sub := range schemas.Array() {
+		if v.validate(sub, value, path, depth+1) == "" {
+			matches++
+		}
+	}
+	return matches
+}
+
+func (v schemaValidator) validateObject(schema, value gjson.Result, path string, depth int) string {
+	properties := schema.Get("properties")
+	for _, name := range schema.Get("required").Array() {
+		if !value.Get(gjson.Escape(name.String())).Exists() {
+			return fmt.Sprintf("%s is missing required property %q", path, name.String())
+		}
+	}
+	additional := schema.Get("additionalProperties")
+	var problem string
+	value.ForEach(func(key, item gjson.Result) bool {
+		itemPath := path + "." + key.String()
+		if propertySchema := properties.Get(gjson.Escape(key.String())); propertySchema.Exists() {
+			problem = v.validate(propertySchema, item, itemPath, depth+1)
+		} else if additional.Exists() {
+			if additional.Type == gjson.False {
+				problem = fmt.Sprintf("%s has unexpected property %q", path, key.String())
+			} else {
+				problem = v.validate(additional, item, itemPath, depth+1)
+			}
+		}
+		return problem == ""
+	})
+	return problem
+}
+
+func (v schemaValidator) validateArray(schema, value gjson.Result, path string, depth int) string {
+	items := value.Array()
+	if minItems := schema.Get("minItems"); minItems.Exists() && int64(len(items)) < minItems.Int() {
+		return fmt.Sprintf("%s must have at least %d items", path, minItems.Int())
+	}
+	if maxItems := schema.Get("maxItems"); maxItems.Exists() && int64(len(items)) > maxItems.Int() {
+		return fmt.Sprintf("%s must have at most %d items", path, maxItems.Int())
+	}
+	if itemSchema := schema.Get("items"); itemSchema.Exists() {
+		for i, item := range items {
+			if problem := v.validate(itemSchema, item, fmt.Sprintf("%s[%d]", path, i), depth+1); problem != "" {
+				return problem
+			}
+		}
+	}
+	return ""
+}
+
+func validateString(schema gjson.Result, value, path string) string {
+	length := int64(utf8.RuneCountInString(value))
+	if minLength := schema.Get("minLength"); minLength.Exists() && length < minLength.Int() {
+		return fmt.Sprintf("%s must be at least %d characters", path, minLength.Int())
+	}
+	if maxLength := schema.Get("maxLength"); maxLength.Exists() && length > maxLength.Int() {
+		return fmt.Sprintf("%s must be at most %d characters", path, maxLength.Int())
+	}
+	if pattern := schema.Get("pattern").String(); pattern != "" {
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+			return fmt.Sprintf("%s must match the pattern %s", path, pattern)
+		}
+	}
+	return ""
+}
+
+func validateNumber(schema gjson.Result, value float64, path string) string {
+	if minimum := schema.Get("minimum"); minimum.Exists() && value < minimum.Float() {
+		return fmt.Sprintf("%s must be >= %s", path, minimum.Raw)
+	}
+	if maximum := schema.Get("maximum"); maximum.Exists() && value > maximum.Float() {
+		return fmt.Sprintf("%s must be <= %s", path, maximum.Raw)
+	}
+	if minimum := schema.Get("exclusiveMinimum"); minimum.Type == gjson.Number && value <= minimum.Float() {
+		return fmt.Sprintf("%s must be > %s", path, minimum.Raw)
+	}
+	if maximum := schema.Get("exclusiveMaximum"); maximum.Type == gjson.Number && value >= maximum.Float() {
+		return fmt.Sprintf("%s must be < %s", path, maximum.Raw)
+	}
+	return ""
+}
+
+// resolve looks up a local reference such as "#/$defs/item".
+func (v schemaValidator) resolve(ref string) (gjson.Result, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return gjson.Result{}, false
+	}
+	segments := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		segments[i] = gjson.Escape(segment)
+	}
+	target := v.root.Get(strings.Join(segments, "."))
+	return target, target.Exists()
+}
+
+// matchesSchemaType reports whether value has the type, or one of the types, of a
+// schema's type keyword.
+func matchesSchemaType(types, value gjson.Result) bool {
+	if types.IsArray() {
+		for _, t := range types.Array() {
+			if matchesSchemaType(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	switch types.String() {
+	case "object":
+		return value.IsObject()
+	case "array":
+		return value.IsArray()
+	case "string":
+		return value.Type == gjson.String
+	case "number":
+		return value.Type == gjson.Number
+	case "integer":
+		return value.Type == gjson.Number && value.Float() == float64(int64(value.Float()))
+	case "boolean":
+		return value.Type == gjson.True || value.Type == gjson.False
+	case "null":
+		return value.Type == gjson.Null
+	}
+	return true
+}
+
+func schemaTypeNames(types gjson.Result) string {
+	if !types.IsArray() {
+		return types.String()
+	}
+	names := make([]string, 0, len(types.Array()))
+	for _, t := range types.Array() {
+		names = append(names, t.String())
+	}
+	return strings.Join(names, " or ")
+}
+
+// jsonEqual compares two JSON values structurally.
+func jsonEqual(a, b gjson.Result) bool {
+	var left, right any
+	if json.Unmarshal([]byte(a.Raw), &left) != nil || json.Unmarshal([]byte(b.Raw), &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import "testing"
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := `{
+		"type":"object",
+		"properties":{
+			"name":{"type":"string","minLength":1},
+			"tags":{"type":"array","items":{"$ref":"#/$defs/tag"},"maxItems":2},
+			"kind":{"enum":["a","b"]},
+			"score":{"anyOf":[{"type":"number","minimum":0},{"type":"null"}]}
+		},
+		"required":["name"],
+		"additionalProperties":false,
+		"$defs":{"tag":{"type":"string","pattern":"^[a-z]+$"}}
+	}`
+	for value, want := range map[string]string{
+		`{"name":"x","tags":["a","b"],"kind":"a","score":null}`: "",
+		`{"name":"x","score":1.5}`:                              "",
+		`{"tags":[]}`:                                           `$ is missing required property "name"`,
+		`{"name":""}`:                                           "$.name must be at least 1 characters",
+		`{"name":"x","tags":["A"]}`:                             "$.tags[0] must match the pattern ^[a-z]+$",
+		`{"name":"x","tags":["a","b","c"]}`:                     "$.tags must have at most 2 items",
+		`{"name":"x","kind":"c"}`:                               `$.kind must be one of ["a","b"]`,
+		`{"name":"x","score":-1}`:                               "$.score must match a schema of anyOf",
+		`{"name":"x","extra":1}`:                                `$ has unexpected property "extra"`,
+		`{"name":1}`:                                            "$.name must be of type string",
+	} {
+		if got := validateJSONSchema(schema, value); got != want {
+			t.Errorf("validateJSONSchema(%s) = %q, want %q", value, got, want)
+		}
+	}
+}
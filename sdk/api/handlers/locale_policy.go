@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// minLocaleCheckLetters is the fewest letters a reply must contain before the language
+// heuristic gives a verdict; shorter replies are always accepted.
+const minLocaleCheckLetters = 20
+
+// localeLanguage describes a language the locale heuristic can recognise.
+type localeLanguage struct {
+	name    string
+	matches func(text string) bool
+}
+
+// vietnameseLetters are lower-case letters that occur in Vietnamese but not in English.
+const vietnameseLetters = "ăắằẳẵặâấầẩẫậđêếềểễệôốồổỗộơớờởỡợưứừửữựàáảãạèéẻẽẹìíỉĩịòóỏõọùúủũụỳýỷỹỵ"
+
+var localeLanguages = map[string]localeLanguage{
+	"en": {name: "English", matches: func(text string) bool {
+		return scriptShare(text, unicode.Latin) >= 0.9 && asciiLetterShare(text) >= 0.95
+	}},
+	"vi": {name: "Vietnamese", matches: func(text string) bool { return markedWordShare(text, vietnameseLetters) >= 0.2 }},
+	"ja": {name: "Japanese", matches: func(text string) bool {
+		return scriptShare(text, unicode.Hiragana, unicode.Katakana, unicode.Han) >= 0.5 && scriptShare(text, unicode.Hiragana, unicode.Katakana) > 0
+	}},
+	"zh": {name: "Chinese", matches: func(text string) bool { return scriptShare(text, unicode.Han) >= 0.5 }},
+	"ko": {name: "Korean", matches: func(text string) bool { return scriptShare(text, unicode.Hangul) >= 0.5 }},
+	"ru": {name: "Russian", matches: func(text string) bool { return scriptShare(text, unicode.Cyrillic) >= 0.5 }},
+	"uk": {name: "Ukrainian", matches: func(text string) bool { return scriptShare(text, unicode.Cyrillic) >= 0.5 }},
+	"ar": {name: "Arabic", matches: func(text string) bool { return scriptShare(text, unicode.Arabic) >= 0.5 }},
+	"he": {name: "Hebrew", matches: func(text string) bool { return scriptShare(text, unicode.Hebrew) >= 0.5 }},
+	"th": {name: "Thai", matches: func(text string) bool { return scriptShare(text, unicode.Thai) >= 0.5 }},
+	"hi": {name: "Hindi", matches: func(text string) bool { return scriptShare(text, unicode.Devanagari) >= 0.5 }},
+	"el": {name: "Greek", matches: func(text string) bool { return scriptShare(text, unicode.Greek) >= 0.5 }},
+}
+
+var codeFencePattern = regexp.MustCompile("(?s)```.*?(```|$)")
+
+// localePolicyFor returns the locale policy configured for the client API key, if any.
+func localePolicyFor(cfg *config.SDKConfig, apiKey string) (config.LocalePolicy, bool) {
+	if cfg == nil || apiKey == "" {
+		return config.LocalePolicy{}, false
+	}
+	policy, ok := cfg.LocalePolicies[apiKey]
+	if !ok || strings.TrimSpace(policy.Language) == "" {
+		return config.LocalePolicy{}, false
+	}
+	return policy, true
+}
+
+// localePolicyFromContext resolves the locale policy for the client behind ctx.
+func localePolicyFromContext(cfg *config.SDKConfig, ctx context.Context) (config.LocalePolicy, bool) {
+	if ctx == nil {
+		return config.LocalePolicy{}, false
+	}
+	c, _ := ctx.Value("gin").(*gin.Context)
+	return localePolicyFor(cfg, apiKeyFromGin(c))
+}
+
+// lookupLocaleLanguage resolves a language code or English name. The second result is
+// false for languages the heuristic does not know, in which case the raw value is used
+// as the display name.
+func lookupLocaleLanguage(language string) (localeLanguage, bool) {
+	key := strings.ToLower(strings.TrimSpace(language))
+	if idx := strings.IndexAny(key, "-_"); idx > 0 {
+		key = key[:idx]
+	}
+	if lang, ok := localeLanguages[key]; ok {
+		return lang, true
+	}
+	for _, lang := range localeLanguages {
+		if strings.EqualFold(lang.name, key) {
+			return lang, true
+		}
+	}
+	return localeLanguage{name: strings.TrimSpace(language)}, false
+}
+
+// localeInstruction returns the system text injected for policy.
+func localeInstruction(policy config.LocalePolicy) string {
+	if instruction := strings.TrimSpace(policy.Instruction); instruction != "" {
+		return instruction
+	}
+	lang, _ := lookupLocaleLanguage(policy.Language)
+	return "Always respond in " + lang.name + "."
+}
+
+// localeRetryInstruction is the stricter text added when a reply failed verification.
+func localeRetryInstruction(policy config.LocalePolicy) string {
+	lang, _ := lookupLocaleLanguage(policy.Language)
+	return "Respond only in " + lang.name + ", even if the user writes in another language. Do not answer in any other language."
+}
+
+// injectSystemInstruction adds instruction to the system prompt of a request in the
+// given handler format. Unknown formats are returned unchanged.
+func injectSystemInstruction(handlerType string, rawJSON []byte, instruction string) []byte {
+	switch handlerType {
+	case constant.OpenAI:
+		messages := gjson.GetBytes(rawJSON, "messages")
+		if !messages.IsArray() {
+			return rawJSON
+		}
+		system, _ := json.Marshal(map[string]string{"role": "system", "content": instruction})
+		items := []string{string(system)}
+		for _, msg := range messages.Array() {
+			items = append(items, msg.Raw)
+		}
+		out, err := sjson.SetRawBytes(rawJSON, "messages", []byte("["+strings.Join(items, ",")+"]"))
+		if err != nil {
+			return rawJSON
+		}
+		return out
+	case constant.OpenaiResponse:
+		return appendSystemText(rawJSON, "instructions", instruction)
+	case constant.Claude:
+		if system := gjson.GetBytes(rawJSON, "system"); system.IsArray() {
+			out, err := sjson.SetBytes(rawJSON, "system.-1", map[string]string{"type": "text", "text": instruction})
+			if err != nil {
+				return rawJSON
+			}
+			return out
+		}
+		return appendSystemText(rawJSON, "system", instruction)
+	case constant.Gemini, constant.GeminiCLI:
+		prefix := ""
+		if handlerType == constant.GeminiCLI {
+			prefix = "request."
+		}
+		path := prefix + "systemInstruction"
+		if gjson.GetBytes(rawJSON, prefix+"system_instruction").Exists() {
+			path = prefix + "system_instruction"
+		}
+		out, err := sjson.SetBytes(rawJSON, path+".parts.-1", map[string]string{"text": instruction})
+		if err != nil {
+			return rawJSON
+		}
+		return out
+	}
+	return rawJSON
+}
+
+// appendSystemText appends instruction to a string system field, separated by a blank line.
+func appendSystemText(rawJSON []byte, path, instruction string) []byte {
+	text := instruction
+	if existing := strings.TrimSpace(gjson.GetBytes(rawJSON, path).String()); existing != "" {
+		text = existing + "\n\n" + instruction
+	}
+	out, err := sjson.SetBytes(rawJSON, path, text)
+	if err != nil {
+		return rawJSON
+	}
+	return out
+}
+
+// responseText concatenates the assistant text of a non-streaming response in the given
+// handler format.
+func responseText(handlerType string, resp []byte) string {
+	var paths []string
+	switch handlerType {
+	case constant.OpenAI:
+		paths = []string{"choices.#.message.content"}
+	case constant.OpenaiResponse:
+		paths = []string{"output.#.content.#.text"}
+	case constant.Claude:
+		paths = []string{"content.#.text"}
+	case constant.Gemini:
+		paths = []string{"candidates.0.content.parts.#.text"}
+	case constant.GeminiCLI:
+		paths = []string{"response.candidates.0.content.parts.#.text"}
+	}
+	var b strings.Builder
+	for _, path := range paths {
+		collectStrings(gjson.GetBytes(resp, path), &b)
+	}
+	return b.String()
+}
+
+func collectStrings(value gjson.Result, b *strings.Builder) {
+	if value.IsArray() {
+		value.ForEach(func(_, item gjson.Result) bool {
+			collectStrings(item, b)
+			return true
+		})
+		return
+	}
+	if value.Type == gjson.String {
+		b.WriteString(value.Str)
+		b.WriteByte('\n')
+	}
+}
+
+// localeMatches reports whether text appears to be written in the policy language.
+// Code blocks are ignored, and replies too short to judge or in languages the
+// heuristic does not know are accepted.
+func localeMatches(policy config.LocalePolicy, text string) bool {
+	lang, known := lookupLocaleLanguage(policy.Language)
+	if !known {
+		return true
+	}
+	text = codeFencePattern.ReplaceAllString(text, " ")
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minLocaleCheckLetters {
+		return true
+	}
+	return lang.matches(text)
+}
+
+// scriptShare returns the fraction of letters in text that belong to any of tables.
+func scriptShare(text string, tables ...*unicode.RangeTable) float64 {
+	letters, inScript := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.In(r, tables...) {
+			inScript++
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+	return float64(inScript) / float64(letters)
+}
+
+// asciiLetterShare returns the fraction of letters in text that are plain ASCII.
+func asciiLetterShare(text string) float64 {
+	letters, ascii := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if r < unicode.MaxASCII {
+			ascii++
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+	return float64(ascii) / float64(letters)
+}
+
+// markedWordShare returns the fraction of words containing at least one of marks.
+func markedWordShare(text, marks string) float64 {
+	words, marked := 0, 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		words++
+		if strings.ContainsAny(strings.ToLower(word), marks) {
+			marked++
+		}
+	}
+	if words == 0 {
+		return 0
+	}
+	return float64(marked) / float64(words)
+}
+
+// logLocaleMismatch records a reply that failed locale verification.
+func logLocaleMismatch(policy config.LocalePolicy, modelName string, retried bool) {
+	entry := log.WithFields(log.Fields{"language": policy.Language, "model": modelName})
+	if retried {
+		entry.Warn("locale policy: reply still not in the required language after re-ask")
+		return
+	}
+	entry.Debug("locale policy: reply not in the required language, re-asking once")
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestLocaleMatches_Vietnamese(t *testing.T) {
+	policy := config.LocalePolicy{Language: "vi"}
+	if !localeMatches(policy, "Xin chào, tôi có thể giúp gì cho bạn hôm nay? Hãy cho tôi biết nhé.") {
+		t.Fatal("expected Vietnamese reply to match")
+	}
+	if localeMatches(policy, "Hello, how can I help you today? Let me know what you need.") {
+		t.Fatal("expected English reply not to match Vietnamese")
+	}
+}
+
+func TestLocaleMatches_IgnoresCodeAndShortReplies(t *testing.T) {
+	policy := config.LocalePolicy{Language: "Japanese"}
+	if !localeMatches(policy, "OK") {
+		t.Fatal("expected short reply to be accepted")
+	}
+	reply := "これはサンプルのコードです。ご確認ください。\n```go\nfunc main() { fmt.Println(\"hello world from the program\") }\n```"
+	if !localeMatches(policy, reply) {
+		t.Fatal("expected code block to be ignored")
+	}
+	if !localeMatches(config.LocalePolicy{Language: "tlh"}, "This reply is in English but the language is unknown.") {
+		t.Fatal("expected unknown language to be accepted")
+	}
+}
+
+func TestInjectSystemInstruction(t *testing.T) {
+	out := injectSystemInstruction(constant.OpenAI, []byte(`{"messages":[{"role":"user","content":"hi"}]}`), "Always respond in Vietnamese.")
+	if got := gjson.GetBytes(out, "messages.0.role").String(); got != "system" {
+		t.Fatalf("expected leading system message, got %s", out)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content").String(); got != "hi" {
+		t.Fatalf("expected user message preserved, got %s", out)
+	}
+
+	out = injectSystemInstruction(constant.Claude, []byte(`{"system":"Be brief.","messages":[]}`), "Always respond in Vietnamese.")
+	if got := gjson.GetBytes(out, "system").String(); got != "Be brief.\n\nAlways respond in Vietnamese." {
+		t.Fatalf("unexpected claude system: %q", got)
+	}
+
+	out = injectSystemInstruction(constant.Gemini, []byte(`{"contents":[]}`), "Always respond in Vietnamese.")
+	if got := gjson.GetBytes(out, "systemInstruction.parts.0.text").String(); got != "Always respond in Vietnamese." {
+		t.Fatalf("unexpected gemini system instruction: %s", out)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// modelRouteRegexps caches the compiled Match expressions of regex model routes. A nil
+// entry marks an invalid expression, which is logged once and never matches.
+var modelRouteRegexps sync.Map
+
+// applyModelRoute returns the model modelName is requested as and the provider it is
+// restricted to, per the first model route matching it. Without a match it returns
+// modelName unchanged and no provider.
+func applyModelRoute(cfg *config.SDKConfig, modelName string) (string, string) {
+	if cfg == nil {
+		return modelName, ""
+	}
+	name := strings.TrimSpace(modelName)
+	for _, route := range cfg.ModelRoutes {
+		pattern := strings.TrimSpace(route.Match)
+		if pattern == "" {
+			continue
+		}
+		target := strings.TrimSpace(route.Model)
+		if route.Regex {
+			re := modelRouteRegexp(pattern)
+			if re == nil {
+				continue
+			}
+			match := re.FindStringSubmatchIndex(name)
+			if match == nil {
+				continue
+			}
+			if target != "" {
+				target = string(re.ExpandString(nil, target, name, match))
+			}
+		} else if !matchModelRoutePattern(strings.ToLower(pattern), strings.ToLower(name)) {
+			continue
+		}
+		if target == "" {
+			target = modelName
+		}
+		return target, strings.ToLower(strings.TrimSpace(route.Provider))
+	}
+	return modelName, ""
+}
+
+// modelRouteRegexp returns the compiled expression of pattern, or nil when it is invalid.
+func modelRouteRegexp(pattern string) *regexp.Regexp {
+	if cached, ok := modelRouteRegexps.Load(pattern); ok {
+		re, _ := cached.(*regexp.Regexp)
+		return re
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		log.Warnf("model-routes: skipping invalid regex %q: %v", pattern, err)
+		re = nil
+	}
+	modelRouteRegexps.Store(pattern, re)
+	return re
+}
+
+// matchModelRoutePattern reports whether name matches pattern, where '*' matches any run
+// of characters.
+func matchModelRoutePattern(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(name, part)
+		if idx < 0 {
+			return false
+		}
+		name = name[idx+len(part):]
+	}
+	return strings.HasSuffix(name, parts[len(parts)-1])
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyModelRoute(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{ModelRoutes: []sdkconfig.ModelRoute{
+		{Match: "(", Regex: true, Provider: "broken"},
+		{Match: "GPT-4*", Provider: "Codex", Model: "gpt-5"},
+		{Match: "^claude-(.+)-thinking$", Regex: true, Provider: "bolt", Model: "claude-$1"},
+		{Match: "gpt-*", Provider: "openrouter"},
+	}}
+	cases := []struct {
+		model, wantModel, wantProvider string
+	}{
+		{"gpt-4.1", "gpt-5", "codex"},
+		{"claude-sonnet-4-thinking", "claude-sonnet-4", "bolt"},
+		{"gpt-5-mini", "gpt-5-mini", "openrouter"},
+		{"gemini-2.5-pro", "gemini-2.5-pro", ""},
+	}
+	for _, tc := range cases {
+		model, provider := applyModelRoute(cfg, tc.model)
+		if model != tc.wantModel || provider != tc.wantProvider {
+			t.Errorf("applyModelRoute(%q) = %q, %q; want %q, %q", tc.model, model, provider, tc.wantModel, tc.wantProvider)
+		}
+	}
+}
+
+func TestModelRoute_RoutesToProvider(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, id := range []string{"route-a", "route-b"} {
+		manager.RegisterExecutor(&chainExecutor{id: id})
+		auth := &coreauth.Auth{ID: id + "-auth", Provider: id, Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "route-target"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	}
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ModelRoutes: []sdkconfig.ModelRoute{
+		{Match: "route-family-*", Provider: "route-b", Model: "route-target"},
+		{Match: "route-elsewhere-*", Provider: "route-missing", Model: "route-target"},
+	}}, manager)
+
+	for i := 0; i < 4; i++ {
+		resp, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "route-family-large", []byte(`{}`), "")
+		if errMsg != nil {
+			t.Fatalf("unexpected error: %v", errMsg.Error)
+		}
+		if provider, model := gjson.GetBytes(resp, "provider").String(), gjson.GetBytes(resp, "model").String(); provider != "route-b" || model != "route-target" {
+			t.Fatalf("served by %s as %s, want route-b as route-target", provider, model)
+		}
+	}
+
+	if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "route-elsewhere-x", []byte(`{}`), ""); errMsg == nil {
+		t.Fatal("a route to a provider that does not serve the model must fail")
+	}
+}
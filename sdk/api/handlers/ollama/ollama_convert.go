@@ -0,0 +1,352 @@
+package ollama
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+// endpoint distinguishes the two generation endpoints, which share a wire format except
+// for where the generated text goes: message.content for /api/chat, response for
+// /api/generate.
+type endpoint int
+
+const (
+	endpointChat endpoint = iota
+	endpointGenerate
+)
+
+// ollamaStreamEnabled reports whether the request streams. Unlike OpenAI, Ollama streams
+// unless the client sends "stream": false.
+func ollamaStreamEnabled(root gjson.Result) bool {
+	return root.Get("stream").Type != gjson.False
+}
+
+// ollamaModel normalizes an Ollama model reference: Ollama clients append the implicit
+// ":latest" tag to untagged names, which no proxied provider knows about.
+func ollamaModel(root gjson.Result) string {
+	return strings.TrimSuffix(strings.TrimSpace(root.Get("model").String()), ":latest")
+}
+
+// convertChatRequest converts an /api/chat request into an OpenAI chat completions request.
+func convertChatRequest(rawJSON []byte) ([]byte, error) {
+	root := gjson.ParseBytes(rawJSON)
+	model := ollamaModel(root)
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	messages := make([]map[string]any, 0)
+	// Ollama tool results carry no call id; pair them with the pending calls in order.
+	var pending []string
+	for i, msg := range root.Get("messages").Array() {
+		role := msg.Get("role").String()
+		out := map[string]any{"role": role}
+		out["content"] = ollamaContent(msg.Get("content").String(), msg.Get("images"))
+		switch role {
+		case "assistant":
+			var calls []map[string]any
+			for j, call := range msg.Get("tool_calls").Array() {
+				id := call.Get("id").String()
+				if id == "" {
+					id = fmt.Sprintf("call_%d_%d", i, j)
+				}
+				arguments := call.Get("function.arguments").Raw
+				if call.Get("function.arguments").Type == gjson.String {
+					arguments = call.Get("function.arguments").String()
+				}
+				if arguments == "" {
+					arguments = "{}"
+				}
+				calls = append(calls, map[string]any{
+					"id":   id,
+					"type": "function",
+					"function": map[string]any{
+						"name":      call.Get("function.name").String(),
+						"arguments": arguments,
+					},
+				})
+				pending = append(pending, id)
+			}
+			if len(calls) > 0 {
+				out["tool_calls"] = calls
+			}
+		case "tool":
+			id := msg.Get("tool_call_id").String()
+			if id == "" && len(pending) > 0 {
+				id = pending[0]
+			}
+			for k, p := range pending {
+				if p == id {
+					pending = append(pending[:k], pending[k+1:]...)
+					break
+				}
+			}
+			out["tool_call_id"] = id
+		}
+		messages = append(messages, out)
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("messages are required")
+	}
+
+	req := map[string]any{
+		"model":    model,
+		"messages": messages,
+	}
+	if tools := root.Get("tools"); tools.IsArray() && len(tools.Array()) > 0 {
+		req["tools"] = json.RawMessage(tools.Raw)
+	}
+	applyGenerationSettings(req, root)
+	return json.Marshal(req)
+}
+
+// convertGenerateRequest converts an /api/generate request into an OpenAI chat completions
+// request. A suffix turns the request into a fill-in-the-middle prompt, rendered the same
+// way as a suffixed /v1/completions request.
+func convertGenerateRequest(rawJSON []byte) ([]byte, error) {
+	root := gjson.ParseBytes(rawJSON)
+	model := ollamaModel(root)
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	prompt := root.Get("prompt").String()
+	system := root.Get("system").String()
+	if suffix := root.Get("suffix").String(); suffix != "" {
+		system, prompt = util.BuildFIMEmulationMessages(prompt, suffix, "")
+	}
+	messages := make([]map[string]any, 0, 2)
+	if system != "" {
+		messages = append(messages, map[string]any{"role": "system", "content": system})
+	}
+	messages = append(messages, map[string]any{"role": "user", "content": ollamaContent(prompt, root.Get("images"))})
+
+	req := map[string]any{
+		"model":    model,
+		"messages": messages,
+	}
+	applyGenerationSettings(req, root)
+	return json.Marshal(req)
+}
+
+// ollamaContent returns text as plain content, or as OpenAI content parts when the message
+// carries base64 images.
+func ollamaContent(text string, images gjson.Result) any {
+	if !images.IsArray() || len(images.Array()) == 0 {
+		return text
+	}
+	parts := make([]map[string]any, 0, len(images.Array())+1)
+	if text != "" {
+		parts = append(parts, map[string]any{"type": "text", "text": text})
+	}
+	for _, image := range images.Array() {
+		data := image.String()
+		parts = append(parts, map[string]any{
+			"type":      "image_url",
+			"image_url": map[string]any{"url": "data:" + imageMIMEType(data) + ";base64," + data},
+		})
+	}
+	return parts
+}
+
+// imageMIMEType sniffs the type of a base64 image; Ollama sends bare base64 without one.
+func imageMIMEType(data string) string {
+	head := data
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	decoded, err := base64.StdEncoding.DecodeString(head[:len(head)/4*4])
+	if err != nil {
+		return "image/png"
+	}
+	if mime := http.DetectContentType(decoded); strings.HasPrefix(mime, "image/") {
+		return mime
+	}
+	return "image/png"
+}
+
+// applyGenerationSettings maps the stream flag, options, format and think fields shared
+// by both endpoints onto the OpenAI request.
+func applyGenerationSettings(req map[string]any, root gjson.Result) {
+	stream := ollamaStreamEnabled(root)
+	req["stream"] = stream
+	if stream {
+		req["stream_options"] = map[string]any{"include_usage": true}
+	}
+
+	options := root.Get("options")
+	for ollamaKey, openaiKey := range map[string]string{
+		"temperature":       "temperature",
+		"top_p":             "top_p",
+		"seed":              "seed",
+		"frequency_penalty": "frequency_penalty",
+		"presence_penalty":  "presence_penalty",
+	} {
+		if v := options.Get(ollamaKey); v.Type == gjson.Number {
+			req[openaiKey] = json.RawMessage(v.Raw)
+		}
+	}
+	// num_predict -1 (infinite) and -2 (fill context) leave the limit to the provider.
+	if v := options.Get("num_predict"); v.Type == gjson.Number && v.Int() > 0 {
+		req["max_tokens"] = v.Int()
+	}
+	if stop := options.Get("stop"); stop.IsArray() || stop.Type == gjson.String {
+		req["stop"] = json.RawMessage(stop.Raw)
+	}
+
+	switch format := root.Get("format"); {
+	case format.Type == gjson.String && format.String() == "json":
+		req["response_format"] = map[string]any{"type": "json_object"}
+	case format.IsObject():
+		req["response_format"] = map[string]any{
+			"type":        "json_schema",
+			"json_schema": map[string]any{"name": "response", "schema": json.RawMessage(format.Raw)},
+		}
+	}
+
+	if root.Get("think").Type == gjson.True {
+		req["reasoning_effort"] = "medium"
+	}
+}
+
+// convertResponse converts a non-streaming OpenAI chat completion into the final Ollama
+// response object of ep.
+func convertResponse(ep endpoint, model string, completion []byte, elapsed time.Duration) []byte {
+	root := gjson.ParseBytes(completion)
+	message := root.Get("choices.0.message")
+	var calls []toolCall
+	for _, call := range message.Get("tool_calls").Array() {
+		calls = append(calls, toolCall{name: call.Get("function.name").String(), arguments: call.Get("function.arguments").String()})
+	}
+	out := ollamaChunk(ep, model, message.Get("content").String(), message.Get("reasoning_content").String(), calls)
+	finishOllamaChunk(out, root.Get("choices.0.finish_reason").String(), root.Get("usage.prompt_tokens").Int(), root.Get("usage.completion_tokens").Int(), elapsed)
+	b, _ := json.Marshal(out)
+	return b
+}
+
+type toolCall struct {
+	name      string
+	arguments string
+}
+
+// ollamaChunk builds a response object carrying the given text, thinking and tool calls.
+// Ollama sends tool call arguments as an object rather than a JSON string.
+func ollamaChunk(ep endpoint, model, content, thinking string, calls []toolCall) map[string]any {
+	out := map[string]any{
+		"model":      model,
+		"created_at": time.Now().UTC().Format(time.RFC3339Nano),
+		"done":       false,
+	}
+	if ep == endpointGenerate {
+		out["response"] = content
+		if thinking != "" {
+			out["thinking"] = thinking
+		}
+		return out
+	}
+	message := map[string]any{"role": "assistant", "content": content}
+	if thinking != "" {
+		message["thinking"] = thinking
+	}
+	if len(calls) > 0 {
+		toolCalls := make([]map[string]any, 0, len(calls))
+		for _, call := range calls {
+			arguments := json.RawMessage("{}")
+			if args := strings.TrimSpace(call.arguments); gjson.Valid(args) && gjson.Parse(args).IsObject() {
+				arguments = json.RawMessage(args)
+			}
+			toolCalls = append(toolCalls, map[string]any{"function": map[string]any{"name": call.name, "arguments": arguments}})
+		}
+		message["tool_calls"] = toolCalls
+	}
+	out["message"] = message
+	return out
+}
+
+// finishOllamaChunk marks out as the final object of a response, with the stop reason,
+// token counts and timing Ollama reports there.
+func finishOllamaChunk(out map[string]any, finishReason string, promptTokens, completionTokens int64, elapsed time.Duration) {
+	doneReason := "stop"
+	if finishReason == "length" {
+		doneReason = "length"
+	}
+	out["done"] = true
+	out["done_reason"] = doneReason
+	out["total_duration"] = elapsed.Nanoseconds()
+	out["prompt_eval_count"] = promptTokens
+	out["eval_count"] = completionTokens
+}
+
+// streamConverter turns OpenAI chat completion chunks into Ollama NDJSON lines. Text is
+// forwarded as it arrives; tool calls are collected and sent in one object before the
+// final one, since Ollama never streams partial arguments.
+type streamConverter struct {
+	ep               endpoint
+	model            string
+	start            time.Time
+	calls            []toolCall
+	finishReason     string
+	promptTokens     int64
+	completionTokens int64
+}
+
+func newStreamConverter(ep endpoint, model string) *streamConverter {
+	return &streamConverter{ep: ep, model: model, start: time.Now()}
+}
+
+// convert returns the NDJSON lines for one OpenAI chunk.
+func (s *streamConverter) convert(chunk []byte) [][]byte {
+	root := gjson.ParseBytes(chunk)
+	if usage := root.Get("usage"); usage.IsObject() {
+		s.promptTokens = usage.Get("prompt_tokens").Int()
+		s.completionTokens = usage.Get("completion_tokens").Int()
+	}
+	var lines [][]byte
+	for _, choice := range root.Get("choices").Array() {
+		if reason := choice.Get("finish_reason").String(); reason != "" {
+			s.finishReason = reason
+		}
+		delta := choice.Get("delta")
+		for _, call := range delta.Get("tool_calls").Array() {
+			index := int(call.Get("index").Int())
+			for len(s.calls) <= index {
+				s.calls = append(s.calls, toolCall{})
+			}
+			if name := call.Get("function.name").String(); name != "" {
+				s.calls[index].name = name
+			}
+			s.calls[index].arguments += call.Get("function.arguments").String()
+		}
+		content, thinking := delta.Get("content").String(), delta.Get("reasoning_content").String()
+		if content == "" && thinking == "" {
+			continue
+		}
+		b, _ := json.Marshal(ollamaChunk(s.ep, s.model, content, thinking, nil))
+		lines = append(lines, b)
+	}
+	return lines
+}
+
+// finish returns the remaining lines: collected tool calls, then the final object.
+// truncated reports a stream cut at the configured output limit.
+func (s *streamConverter) finish(truncated bool) [][]byte {
+	var lines [][]byte
+	if len(s.calls) > 0 && s.ep == endpointChat {
+		b, _ := json.Marshal(ollamaChunk(s.ep, s.model, "", "", s.calls))
+		lines = append(lines, b)
+	}
+	if truncated {
+		s.finishReason = "length"
+	}
+	final := ollamaChunk(s.ep, s.model, "", "", nil)
+	finishOllamaChunk(final, s.finishReason, s.promptTokens, s.completionTokens, time.Since(s.start))
+	b, _ := json.Marshal(final)
+	return append(lines, b)
+}
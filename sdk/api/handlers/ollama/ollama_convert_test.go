@@ -0,0 +1,107 @@
+package ollama
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertChatRequest(t *testing.T) {
+	raw := []byte(`{
+		"model":"claude-sonnet-4:latest",
+		"messages":[
+			{"role":"system","content":"be brief"},
+			{"role":"user","content":"weather?","images":["iVBORw0KGgoAAAANSUhEUgAAAAEAAAAB"]},
+			{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},
+			{"role":"tool","content":"sunny"}
+		],
+		"options":{"temperature":0.2,"num_predict":128,"stop":["\n\n"]},
+		"format":"json"
+	}`)
+	out, err := convertChatRequest(raw)
+	if err != nil {
+		t.Fatalf("convertChatRequest: %v", err)
+	}
+	req := gjson.ParseBytes(out)
+	if req.Get("model").String() != "claude-sonnet-4" || !req.Get("stream").Bool() {
+		t.Fatalf("model and default streaming = %s", out)
+	}
+	if req.Get("messages.1.content.1.image_url.url").String() != "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAAB" {
+		t.Fatalf("image part = %s", req.Get("messages.1.content").Raw)
+	}
+	callID := req.Get("messages.2.tool_calls.0.id").String()
+	if callID == "" || req.Get("messages.2.tool_calls.0.function.arguments").String() != `{"city":"Paris"}` {
+		t.Fatalf("tool call = %s", req.Get("messages.2").Raw)
+	}
+	if req.Get("messages.3.tool_call_id").String() != callID {
+		t.Fatalf("tool result must answer the pending call: %s", req.Get("messages.3").Raw)
+	}
+	if req.Get("temperature").Float() != 0.2 || req.Get("max_tokens").Int() != 128 || req.Get("stop.0").String() != "\n\n" {
+		t.Fatalf("options = %s", out)
+	}
+	if req.Get("response_format.type").String() != "json_object" {
+		t.Fatalf("format = %s", req.Get("response_format").Raw)
+	}
+
+	if _, err = convertChatRequest([]byte(`{"messages":[]}`)); err == nil {
+		t.Fatal("requests without a model must be rejected")
+	}
+}
+
+func TestConvertGenerateRequest(t *testing.T) {
+	out, err := convertGenerateRequest([]byte(`{"model":"gpt-5","prompt":"def add(a, b):","suffix":"\nprint(add(1, 2))","stream":false}`))
+	if err != nil {
+		t.Fatalf("convertGenerateRequest: %v", err)
+	}
+	req := gjson.ParseBytes(out)
+	if req.Get("stream").Bool() || req.Get("stream_options").Exists() {
+		t.Fatalf("stream:false must disable streaming: %s", out)
+	}
+	if req.Get("messages.0.role").String() != "system" || !bytes.Contains([]byte(req.Get("messages.1.content").String()), []byte("<FILL_ME>")) {
+		t.Fatalf("suffix must render a fill-in-the-middle prompt: %s", out)
+	}
+}
+
+func TestConvertResponse(t *testing.T) {
+	completion := []byte(`{"choices":[{"message":{"role":"assistant","content":"hi","tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{\"x\":1}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":7,"completion_tokens":3}}`)
+	chat := gjson.ParseBytes(convertResponse(endpointChat, "m", completion, 0))
+	if chat.Get("message.content").String() != "hi" || chat.Get("message.tool_calls.0.function.arguments.x").Int() != 1 {
+		t.Fatalf("chat response = %s", chat.Raw)
+	}
+	if !chat.Get("done").Bool() || chat.Get("done_reason").String() != "stop" || chat.Get("prompt_eval_count").Int() != 7 || chat.Get("eval_count").Int() != 3 {
+		t.Fatalf("final fields = %s", chat.Raw)
+	}
+	generate := gjson.ParseBytes(convertResponse(endpointGenerate, "m", completion, 0))
+	if generate.Get("response").String() != "hi" || generate.Get("message").Exists() {
+		t.Fatalf("generate response = %s", generate.Raw)
+	}
+}
+
+func TestStreamConverter(t *testing.T) {
+	s := newStreamConverter(endpointChat, "m")
+	var lines [][]byte
+	for _, chunk := range []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"f","arguments":"{\"x\""}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":":1}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":4,"completion_tokens":2}}`,
+	} {
+		lines = append(lines, s.convert([]byte(chunk))...)
+	}
+	lines = append(lines, s.finish(false)...)
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines: %s", len(lines), bytes.Join(lines, []byte("\n")))
+	}
+	if gjson.GetBytes(lines[1], "message.content").String() != "lo" || gjson.GetBytes(lines[1], "done").Bool() {
+		t.Fatalf("text line = %s", lines[1])
+	}
+	if gjson.GetBytes(lines[2], "message.tool_calls.0.function.arguments.x").Int() != 1 {
+		t.Fatalf("tool call line = %s", lines[2])
+	}
+	final := gjson.ParseBytes(lines[3])
+	if !final.Get("done").Bool() || final.Get("eval_count").Int() != 2 || final.Get("prompt_eval_count").Int() != 4 {
+		t.Fatalf("final line = %s", lines[3])
+	}
+}
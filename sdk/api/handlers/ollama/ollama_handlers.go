@@ -0,0 +1,248 @@
+// Package ollama provides HTTP handlers that speak Ollama's wire format, so tools that
+// only know Ollama (e.g. many local chat UIs) can use any proxied provider. Requests are
+// converted to OpenAI chat completions and executed through the OpenAI translators, which
+// reach every provider; responses are converted back into Ollama's NDJSON stream or final
+// response object.
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// OllamaAPIHandler contains the handlers for the Ollama-compatible endpoints.
+type OllamaAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOllamaAPIHandler creates a new Ollama API handlers instance.
+func NewOllamaAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OllamaAPIHandler {
+	return &OllamaAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation. Converted requests
+// are OpenAI chat completions, so they are translated from the OpenAI format.
+func (h *OllamaAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns a list of models supported by this handler.
+func (h *OllamaAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// Tags handles GET /api/tags, listing the available models as local Ollama models.
+// Size and digest have no meaning for proxied models and are left empty.
+func (h *OllamaAPIHandler) Tags(c *gin.Context) {
+	models := make([]gin.H, 0)
+	for _, model := range h.Models() {
+		id, _ := model["id"].(string)
+		if id == "" {
+			continue
+		}
+		modifiedAt := time.Unix(0, 0).UTC()
+		if created, ok := model["created"].(int64); ok && created > 0 {
+			modifiedAt = time.Unix(created, 0).UTC()
+		}
+		family, _ := model["owned_by"].(string)
+		models = append(models, gin.H{
+			"name":        id,
+			"model":       id,
+			"modified_at": modifiedAt.Format(time.RFC3339),
+			"size":        0,
+			"digest":      "",
+			"details": gin.H{
+				"format":   "",
+				"family":   family,
+				"families": []string{family},
+			},
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"models": models})
+}
+
+// Chat handles POST /api/chat.
+func (h *OllamaAPIHandler) Chat(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	openaiJSON, err := convertChatRequest(rawJSON)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.generate(c, endpointChat, rawJSON, openaiJSON)
+}
+
+// Generate handles POST /api/generate.
+func (h *OllamaAPIHandler) Generate(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	// Ollama clients send an empty prompt to preload a model; there is nothing to load.
+	if root.Get("prompt").String() == "" && root.Get("suffix").String() == "" && !root.Get("images").IsArray() {
+		out := ollamaChunk(endpointGenerate, root.Get("model").String(), "", "", nil)
+		out["done"] = true
+		out["done_reason"] = "load"
+		c.JSON(http.StatusOK, out)
+		return
+	}
+	openaiJSON, err := convertGenerateRequest(rawJSON)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.generate(c, endpointGenerate, rawJSON, openaiJSON)
+}
+
+func (h *OllamaAPIHandler) generate(c *gin.Context, ep endpoint, rawJSON, openaiJSON []byte) {
+	model := gjson.GetBytes(rawJSON, "model").String()
+	if ollamaStreamEnabled(gjson.ParseBytes(rawJSON)) {
+		h.handleStreamingResponse(c, ep, model, openaiJSON)
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	start := time.Now()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), gjson.GetBytes(openaiJSON, "model").String(), openaiJSON, "")
+	stopKeepAlive()
+	if errMsg != nil {
+		h.writeErrorMessage(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(convertResponse(ep, model, resp, time.Since(start)))
+	cliCancel()
+}
+
+func (h *OllamaAPIHandler) handleStreamingResponse(c *gin.Context, ep endpoint, model string, openaiJSON []byte) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		h.writeError(c, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), gjson.GetBytes(openaiJSON, "model").String(), openaiJSON, "")
+	converter := newStreamConverter(ep, model)
+	writeLines := func(lines [][]byte) {
+		for _, line := range lines {
+			_, _ = c.Writer.Write(line)
+			_, _ = c.Writer.Write([]byte("\n"))
+		}
+	}
+
+	// Peek at the first chunk to determine success or failure before setting headers
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cliCancel(c.Request.Context().Err())
+			return
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			h.writeErrorMessage(c, errMsg)
+			if errMsg != nil {
+				cliCancel(errMsg.Error)
+			} else {
+				cliCancel(nil)
+			}
+			return
+		case chunk, ok := <-dataChan:
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Header("Cache-Control", "no-cache")
+			if !ok {
+				writeLines(converter.finish(false))
+				flusher.Flush()
+				cliCancel(nil)
+				return
+			}
+			writeLines(converter.convert(chunk))
+			flusher.Flush()
+
+			// NDJSON has no comment syntax, so heartbeats would corrupt the stream.
+			noKeepAlive := time.Duration(0)
+			h.ForwardStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, handlers.StreamForwardOptions{
+				KeepAliveInterval: &noKeepAlive,
+				WriteChunk: func(chunk []byte) {
+					writeLines(converter.convert(chunk))
+				},
+				WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
+					if errMsg == nil {
+						return
+					}
+					writeLines([][]byte{errorBody(errorText(errMsg))})
+				},
+				WriteDone: func() {
+					writeLines(converter.finish(false))
+				},
+				WriteTruncated: func() {
+					writeLines(converter.finish(true))
+				},
+				WrittenBytes: int64(len(chunk)),
+			})
+			return
+		}
+	}
+}
+
+// writeErrorMessage writes an execution error in Ollama's {"error": "..."} shape.
+func (h *OllamaAPIHandler) writeErrorMessage(c *gin.Context, errMsg *interfaces.ErrorMessage) {
+	status := http.StatusInternalServerError
+	if errMsg != nil && errMsg.StatusCode > 0 {
+		status = errMsg.StatusCode
+	}
+	h.writeError(c, status, errorText(errMsg))
+}
+
+func (h *OllamaAPIHandler) writeError(c *gin.Context, status int, message string) {
+	if handlers.UnifiedErrorsEnabled(h.Cfg) {
+		c.Data(status, "application/json", h.ErrorResponseBody(c, status, message))
+		return
+	}
+	c.Data(status, "application/json", errorBody(message))
+}
+
+func errorBody(message string) []byte {
+	body, _ := json.Marshal(gin.H{"error": message})
+	return body
+}
+
+// errorText returns the message of an execution error, unwrapping JSON error bodies
+// returned by providers.
+func errorText(errMsg *interfaces.ErrorMessage) string {
+	if errMsg == nil || errMsg.Error == nil {
+		return http.StatusText(http.StatusInternalServerError)
+	}
+	text := strings.TrimSpace(errMsg.Error.Error())
+	if gjson.Valid(text) {
+		for _, path := range []string{"error.message", "error", "message"} {
+			if v := gjson.Get(text, path); v.Type == gjson.String && v.String() != "" {
+				return v.String()
+			}
+		}
+	}
+	return text
+}
@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Embeddings handles the /v1/embeddings endpoint using the OpenAI request shape:
+// {"model", "input", "dimensions", "encoding_format"}. Requests share the credential
+// pool of the model's providers; each executor maps the request onto its native
+// embeddings API and answers in the OpenAI response shape.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Embeddings(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	if msg := validateEmbeddingsRequest(root); msg != "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: msg,
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	modelName := root.Get("model").String()
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteEmbedWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	if root.Get("encoding_format").String() == "base64" {
+		resp = encodeEmbeddingsBase64(resp)
+	}
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// validateEmbeddingsRequest returns a client-facing message for malformed embeddings requests.
+func validateEmbeddingsRequest(root gjson.Result) string {
+	input := root.Get("input")
+	switch {
+	case root.Get("model").String() == "":
+		return "model is required"
+	case !input.Exists() || (input.Type == gjson.String && input.String() == ""):
+		return "input is required"
+	case input.IsArray() && len(input.Array()) == 0:
+		return "input must be a non-empty string or array"
+	}
+	switch format := root.Get("encoding_format").String(); format {
+	case "", "float", "base64":
+	default:
+		return fmt.Sprintf("unsupported encoding_format %q", format)
+	}
+	return ""
+}
+
+// encodeEmbeddingsBase64 rewrites float array embeddings as base64 little-endian float32
+// buffers, the encoding OpenAI returns for encoding_format "base64". Embeddings the
+// upstream already encoded are left unchanged.
+func encodeEmbeddingsBase64(resp []byte) []byte {
+	gjson.GetBytes(resp, "data").ForEach(func(key, item gjson.Result) bool {
+		embedding := item.Get("embedding")
+		if !embedding.IsArray() {
+			return true
+		}
+		values := embedding.Array()
+		buf := make([]byte, 4*len(values))
+		for i, value := range values {
+			binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(value.Float())))
+		}
+		resp, _ = sjson.SetBytes(resp, fmt.Sprintf("data.%d.embedding", key.Int()), base64.StdEncoding.EncodeToString(buf))
+		return true
+	})
+	return resp
+}
@@ -0,0 +1,42 @@
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestValidateEmbeddingsRequest(t *testing.T) {
+	cases := map[string]bool{
+		`{"model":"m","input":"hello"}`:                              true,
+		`{"model":"m","input":["a","b"],"encoding_format":"base64"}`: true,
+		`{"input":"hello"}`:                                          false,
+		`{"model":"m","input":""}`:                                   false,
+		`{"model":"m","input":[]}`:                                   false,
+		`{"model":"m","input":"a","encoding_format":"int8"}`:         false,
+	}
+	for raw, valid := range cases {
+		if got := validateEmbeddingsRequest(gjson.Parse(raw)) == ""; got != valid {
+			t.Errorf("validate(%s) valid = %v, want %v", raw, got, valid)
+		}
+	}
+}
+
+func TestEncodeEmbeddingsBase64(t *testing.T) {
+	resp := []byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.5,-1]},{"object":"embedding","index":1,"embedding":"AAAAPw=="}]}`)
+	out := encodeEmbeddingsBase64(resp)
+
+	raw, err := base64.StdEncoding.DecodeString(gjson.GetBytes(out, "data.0.embedding").String())
+	if err != nil || len(raw) != 8 {
+		t.Fatalf("first embedding not base64 float32: %s", out)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])); got != -1 {
+		t.Fatalf("second component = %v", got)
+	}
+	if gjson.GetBytes(out, "data.1.embedding").String() != "AAAAPw==" {
+		t.Fatalf("encoded embeddings must be left unchanged: %s", out)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	responsesconverter "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/responses"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -120,7 +121,7 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 		stream = gjson.GetBytes(rawJSON, "stream").Bool()
 	}
 
-	if stream {
+	if stream && !handlers.StreamAggregationRequested(c) {
 		h.handleStreamingResponse(c, rawJSON)
 	} else {
 		h.handleNonStreamingResponse(c, rawJSON)
@@ -163,6 +164,14 @@ func (h *OpenAIAPIHandler) Completions(c *gin.Context) {
 		return
 	}
 
+	// Fill-in-the-middle requests carry a suffix; expose prefix and suffix to executors
+	// so providers with a native FIM endpoint can serve them directly.
+	if suffix := gjson.GetBytes(rawJSON, "suffix").String(); suffix != "" {
+		c.Set(util.FIMPromptMetadataKey, gjson.GetBytes(rawJSON, "prompt").String())
+		c.Set(util.FIMSuffixMetadataKey, suffix)
+		c.Set(util.FIMLanguageMetadataKey, gjson.GetBytes(rawJSON, "language").String())
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	if streamResult.Type == gjson.True {
@@ -175,6 +184,8 @@ func (h *OpenAIAPIHandler) Completions(c *gin.Context) {
 
 // convertCompletionsRequestToChatCompletions converts OpenAI completions API request to chat completions format.
 // This allows the completions endpoint to use the existing chat completions infrastructure.
+// Requests with a suffix are rendered as a fill-in-the-middle prompt for providers without
+// a native FIM endpoint.
 //
 // Parameters:
 //   - rawJSON: The raw JSON bytes of the completions request
@@ -198,8 +209,15 @@ func convertCompletionsRequestToChatCompletions(rawJSON []byte) []byte {
 		out, _ = sjson.Set(out, "model", model.String())
 	}
 
-	// Set the prompt as user message content
-	out, _ = sjson.Set(out, "messages.0.content", prompt)
+	if suffix := root.Get("suffix").String(); suffix != "" {
+		system, user := util.BuildFIMEmulationMessages(root.Get("prompt").String(), suffix, root.Get("language").String())
+		out, _ = sjson.Set(out, "messages.0.content", user)
+		out, _ = sjson.SetRaw(out, "messages", `[{"role":"system","content":""},`+gjson.Get(out, "messages.0").Raw+`]`)
+		out, _ = sjson.Set(out, "messages.0.content", system)
+	} else {
+		// Set the prompt as user message content
+		out, _ = sjson.Set(out, "messages.0.content", prompt)
+	}
 
 	// Copy other parameters from completions to chat completions
 	if maxTokens := root.Get("max_tokens"); maxTokens.Exists() {
@@ -503,7 +521,7 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			flusher.Flush()
 
 			// Continue streaming the rest
-			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, chatCompletionsTruncatedChunk, int64(len(chunk)))
 			return
 		}
 	}
@@ -640,12 +658,19 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			h.handleStreamResult(c, flusher, func(err error) {
 				stop()
 				cliCancel(err)
-			}, convertedChan, errChan)
+			}, convertedChan, errChan, completionsTruncatedChunk, int64(len(converted)))
 			return
 		}
 	}
 }
-func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+
+// Terminal chunks emitted when the streamed output hits the configured byte cap.
+const (
+	chatCompletionsTruncatedChunk = `{"object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"length"}]}`
+	completionsTruncatedChunk     = `{"object":"text_completion","choices":[{"index":0,"text":"","finish_reason":"length"}]}`
+)
+
+func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, truncatedChunk string, written int64) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
 			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
@@ -662,11 +687,70 @@ func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flush
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := h.ErrorResponseBody(c, status, errText)
 			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(body))
 		},
 		WriteDone: func() {
 			_, _ = fmt.Fprint(c.Writer, "data: [DONE]\n\n")
 		},
+		WriteTruncated: func() {
+			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", truncatedChunk)
+			_, _ = fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+		},
+		TextCodec:    &openAIStreamTextCodec,
+		WrittenBytes: written,
 	})
 }
+
+// openAIStreamTextCodec reads and writes assistant text in chat completion and legacy
+// completion stream chunks for the output sanitizer.
+var openAIStreamTextCodec = handlers.StreamTextCodec{
+	Texts: func(chunk []byte) map[int]string {
+		var texts map[int]string
+		gjson.GetBytes(chunk, "choices").ForEach(func(_, choice gjson.Result) bool {
+			text := choice.Get("delta.content")
+			if !text.Exists() {
+				text = choice.Get("text")
+			}
+			if text.String() != "" {
+				if texts == nil {
+					texts = make(map[int]string)
+				}
+				texts[int(choice.Get("index").Int())] = text.String()
+			}
+			return true
+		})
+		return texts
+	},
+	Finished: func(chunk []byte) []int {
+		var finished []int
+		gjson.GetBytes(chunk, "choices").ForEach(func(_, choice gjson.Result) bool {
+			if choice.Get("finish_reason").String() != "" {
+				finished = append(finished, int(choice.Get("index").Int()))
+			}
+			return true
+		})
+		return finished
+	},
+	EncodeText: func(template []byte, index int, text string) []byte {
+		choice := []byte(`{"finish_reason":null}`)
+		choice, _ = sjson.SetBytes(choice, "index", index)
+		var err error
+		if gjson.GetBytes(template, "choices.0.delta").Exists() {
+			choice, err = sjson.SetBytes(choice, "delta.content", text)
+		} else {
+			choice, err = sjson.SetBytes(choice, "text", text)
+		}
+		if err != nil {
+			return nil
+		}
+		out, err := sjson.DeleteBytes(template, "usage")
+		if err == nil {
+			out, err = sjson.SetRawBytes(out, "choices", append(append([]byte{'['}, choice...), ']'))
+		}
+		if err != nil {
+			return nil
+		}
+		return out
+	},
+}
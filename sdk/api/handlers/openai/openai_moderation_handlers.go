@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/moderation"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// Moderations handles the /v1/moderations endpoint by forwarding the request to the
+// moderation backend configured under "moderation" (OpenAI by default).
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Moderations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.GetBytes(rawJSON, "input").Exists() {
+		msg := "input is required"
+		if err != nil {
+			msg = fmt.Sprintf("Invalid request: %v", err)
+		}
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: msg,
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	client := moderation.NewClient(h.Cfg)
+	if !client.Enabled() {
+		c.JSON(http.StatusNotImplemented, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: moderation.ErrNotConfigured.Error(),
+				Type:    "server_error",
+			},
+		})
+		return
+	}
+
+	// The gin context carries the client API key used to attribute moderation usage.
+	ctx := context.WithValue(c.Request.Context(), "gin", c)
+	body, err := client.Moderate(ctx, rawJSON)
+	if err != nil {
+		var statusErr *moderation.StatusError
+		if errors.As(err, &statusErr) {
+			c.Data(statusErr.Code, "application/json", statusErr.Body)
+			return
+		}
+		c.JSON(http.StatusBadGateway, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: err.Error(),
+				Type:    "server_error",
+			},
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", body)
+}
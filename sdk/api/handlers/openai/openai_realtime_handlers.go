@@ -0,0 +1,407 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// realtimeMaxMessageBytes bounds a single client event.
+	realtimeMaxMessageBytes = 4 << 20
+	// realtimeWriteTimeout bounds writing a single server event.
+	realtimeWriteTimeout = 10 * time.Second
+)
+
+// realtimeUpgrader accepts connections from any origin; clients authenticate with their
+// API key like on every other /v1 route.
+var realtimeUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(*http.Request) bool {
+		return true
+	},
+}
+
+// Realtime handles the /v1/realtime WebSocket endpoint. It speaks the text subset of the
+// OpenAI Realtime API (session.update, conversation.item.create, response.create and
+// response.cancel) and runs every response as a streaming chat completion, so any
+// provider serving the model can back a realtime session. Audio events are rejected.
+//
+// Parameters:
+//   - c: The Gin context of the upgrade request; the model comes from the model query
+//     parameter
+func (h *OpenAIAPIHandler) Realtime(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "model query parameter is required",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	conn, err := realtimeUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Debugf("realtime: upgrade failed: %v", err)
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	conn.SetReadLimit(realtimeMaxMessageBytes)
+
+	s := &realtimeSession{
+		h:       h,
+		c:       c,
+		conn:    conn,
+		session: realtimeSessionConfig{ID: "sess_" + uuid.NewString(), Model: model},
+	}
+	s.send(gin.H{"type": "session.created", "session": s.sessionObject()})
+	for {
+		_, msg, errRead := conn.ReadMessage()
+		if errRead != nil {
+			break
+		}
+		s.handle(msg)
+	}
+	s.cancelResponse()
+}
+
+// realtimeSessionConfig holds the session settings a client can change with session.update.
+type realtimeSessionConfig struct {
+	ID           string
+	Model        string
+	Instructions string
+	Temperature  *float64
+	// MaxOutputTokens is 0 for "inf".
+	MaxOutputTokens int64
+}
+
+// realtimeSession is the state of one /v1/realtime connection.
+type realtimeSession struct {
+	h    *OpenAIAPIHandler
+	c    *gin.Context
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	session  realtimeSessionConfig
+	history  []json.RawMessage
+	lastItem string
+	// cancel stops the in-flight response; nil when none is running.
+	cancel context.CancelFunc
+}
+
+// handle dispatches one client event.
+func (s *realtimeSession) handle(msg []byte) {
+	event := gjson.ParseBytes(msg)
+	eventID := event.Get("event_id").String()
+	switch eventType := event.Get("type").String(); eventType {
+	case "session.update":
+		s.mu.Lock()
+		applyRealtimeSessionUpdate(&s.session, event.Get("session"))
+		s.mu.Unlock()
+		s.send(gin.H{"type": "session.updated", "session": s.sessionObject()})
+	case "conversation.item.create":
+		s.createItem(event.Get("item"), eventID)
+	case "response.create":
+		s.createResponse(event.Get("response"), eventID)
+	case "response.cancel":
+		s.cancelResponse()
+	case "input_audio_buffer.append", "input_audio_buffer.commit", "input_audio_buffer.clear":
+		s.sendError("unsupported_modality", "audio input is not supported; send text conversation items", eventID)
+	default:
+		s.sendError("unknown_event", fmt.Sprintf("unsupported event type %q", eventType), eventID)
+	}
+}
+
+// applyRealtimeSessionUpdate applies the supported fields of a session.update payload.
+func applyRealtimeSessionUpdate(cfg *realtimeSessionConfig, update gjson.Result) {
+	if v := update.Get("instructions"); v.Exists() {
+		cfg.Instructions = v.String()
+	}
+	if v := update.Get("temperature"); v.Exists() {
+		temperature := v.Float()
+		cfg.Temperature = &temperature
+	}
+	if v := update.Get("max_response_output_tokens"); v.Exists() {
+		cfg.MaxOutputTokens = 0
+		if v.Type == gjson.Number {
+			cfg.MaxOutputTokens = v.Int()
+		}
+	}
+}
+
+// sessionObject renders the session for session.created and session.updated.
+func (s *realtimeSession) sessionObject() gin.H {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := gin.H{
+		"id":                         s.session.ID,
+		"object":                     "realtime.session",
+		"model":                      s.session.Model,
+		"modalities":                 []string{"text"},
+		"instructions":               s.session.Instructions,
+		"max_response_output_tokens": "inf",
+	}
+	if s.session.Temperature != nil {
+		out["temperature"] = *s.session.Temperature
+	}
+	if s.session.MaxOutputTokens > 0 {
+		out["max_response_output_tokens"] = s.session.MaxOutputTokens
+	}
+	return out
+}
+
+// createItem appends a message item to the conversation.
+func (s *realtimeSession) createItem(item gjson.Result, eventID string) {
+	message, err := realtimeMessage(item)
+	if err != nil {
+		s.sendError("invalid_item", err.Error(), eventID)
+		return
+	}
+	itemID := item.Get("id").String()
+	if itemID == "" {
+		itemID = "item_" + uuid.NewString()
+	}
+	s.mu.Lock()
+	previous := s.lastItem
+	s.history = append(s.history, message)
+	s.lastItem = itemID
+	s.mu.Unlock()
+
+	created := json.RawMessage(item.Raw)
+	created, _ = sjson.SetBytes(created, "id", itemID)
+	created, _ = sjson.SetBytes(created, "object", "realtime.item")
+	created, _ = sjson.SetBytes(created, "status", "completed")
+	s.send(gin.H{"type": "conversation.item.created", "previous_item_id": previous, "item": created})
+}
+
+// realtimeMessage converts a realtime message item into a chat completion message.
+func realtimeMessage(item gjson.Result) (json.RawMessage, error) {
+	if itemType := item.Get("type").String(); itemType != "message" {
+		return nil, fmt.Errorf("unsupported item type %q; only text messages are supported", itemType)
+	}
+	role := item.Get("role").String()
+	switch role {
+	case "user", "system", "assistant":
+	default:
+		return nil, fmt.Errorf("unsupported role %q", role)
+	}
+	var text strings.Builder
+	for _, part := range item.Get("content").Array() {
+		switch part.Get("type").String() {
+		case "input_text", "text":
+			text.WriteString(part.Get("text").String())
+		default:
+			return nil, fmt.Errorf("unsupported content type %q; only text is supported", part.Get("type").String())
+		}
+	}
+	message, _ := sjson.SetBytes([]byte(`{}`), "role", role)
+	message, _ = sjson.SetBytes(message, "content", text.String())
+	return message, nil
+}
+
+// realtimeChatRequest builds the streaming chat completion request of a response from
+// the session settings, the response overrides and the conversation so far.
+func realtimeChatRequest(cfg realtimeSessionConfig, overrides gjson.Result, history []json.RawMessage) []byte {
+	instructions := cfg.Instructions
+	if v := overrides.Get("instructions"); v.Exists() {
+		instructions = v.String()
+	}
+	messages := make([]json.RawMessage, 0, len(history)+1)
+	if instructions != "" {
+		system, _ := sjson.SetBytes([]byte(`{"role":"system"}`), "content", instructions)
+		messages = append(messages, system)
+	}
+	messages = append(messages, history...)
+	encoded, _ := json.Marshal(messages)
+
+	out := []byte(`{"stream":true,"stream_options":{"include_usage":true}}`)
+	out, _ = sjson.SetBytes(out, "model", cfg.Model)
+	out, _ = sjson.SetRawBytes(out, "messages", encoded)
+	if v := overrides.Get("temperature"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "temperature", v.Float())
+	} else if cfg.Temperature != nil {
+		out, _ = sjson.SetBytes(out, "temperature", *cfg.Temperature)
+	}
+	maxTokens := cfg.MaxOutputTokens
+	if v := overrides.Get("max_output_tokens"); v.Exists() {
+		maxTokens = 0
+		if v.Type == gjson.Number {
+			maxTokens = v.Int()
+		}
+	}
+	if maxTokens > 0 {
+		out, _ = sjson.SetBytes(out, "max_tokens", maxTokens)
+	}
+	return out
+}
+
+// createResponse starts a response over the conversation. Only one response runs at a time.
+func (s *realtimeSession) createResponse(overrides gjson.Result, eventID string) {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		s.sendError("conversation_already_has_active_response", "a response is already in progress", eventID)
+		return
+	}
+	rawJSON := realtimeChatRequest(s.session, overrides, s.history)
+	model := s.session.Model
+	ctx, cliCancel := s.h.GetContextWithCancel(s.h, s.c, context.Background())
+	done := make(chan struct{})
+	s.cancel = func() {
+		cliCancel()
+		<-done
+	}
+	s.mu.Unlock()
+
+	go func() {
+		s.runResponse(ctx, model, rawJSON)
+		cliCancel()
+		s.mu.Lock()
+		s.cancel = nil
+		s.mu.Unlock()
+		close(done)
+	}()
+}
+
+// cancelResponse stops the in-flight response, if any, and waits for it to finish.
+func (s *realtimeSession) cancelResponse() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// runResponse streams one chat completion and reports it as realtime response events.
+func (s *realtimeSession) runResponse(ctx context.Context, model string, rawJSON []byte) {
+	responseID := "resp_" + uuid.NewString()
+	itemID := "item_" + uuid.NewString()
+	s.send(gin.H{"type": "response.created", "response": gin.H{"id": responseID, "object": "realtime.response", "status": "in_progress", "output": []any{}}})
+	s.send(gin.H{"type": "response.output_item.added", "response_id": responseID, "output_index": 0, "item": realtimeAssistantItem(itemID, "in_progress", nil)})
+	s.send(gin.H{"type": "response.content_part.added", "response_id": responseID, "item_id": itemID, "output_index": 0, "content_index": 0, "part": gin.H{"type": "text", "text": ""}})
+
+	data, errs := s.h.ExecuteStreamWithAuthManager(ctx, s.h.HandlerType(), model, rawJSON, "")
+	var text strings.Builder
+	var usage gjson.Result
+	var failure *interfaces.ErrorMessage
+	for data != nil || errs != nil {
+		select {
+		case chunk, ok := <-data:
+			if !ok {
+				data = nil
+				continue
+			}
+			payload := bytes.TrimSpace(chunk)
+			payload = bytes.TrimSpace(bytes.TrimPrefix(payload, []byte("data:")))
+			if len(payload) == 0 || bytes.Equal(payload, []byte("[DONE]")) {
+				continue
+			}
+			if delta := gjson.GetBytes(payload, "choices.0.delta.content").String(); delta != "" {
+				text.WriteString(delta)
+				s.send(gin.H{"type": "response.text.delta", "response_id": responseID, "item_id": itemID, "output_index": 0, "content_index": 0, "delta": delta})
+			}
+			if u := gjson.GetBytes(payload, "usage"); u.IsObject() {
+				usage = u
+			}
+		case errMsg, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if errMsg != nil {
+				failure = errMsg
+			}
+		}
+	}
+
+	status := "completed"
+	switch {
+	case failure != nil:
+		status = "failed"
+		message := "upstream request failed"
+		if failure.Error != nil {
+			message = failure.Error.Error()
+		}
+		s.sendError("upstream_error", message, "")
+	case ctx.Err() != nil:
+		status = "cancelled"
+	}
+	full := text.String()
+	part := gin.H{"type": "text", "text": full}
+	s.send(gin.H{"type": "response.text.done", "response_id": responseID, "item_id": itemID, "output_index": 0, "content_index": 0, "text": full})
+	s.send(gin.H{"type": "response.content_part.done", "response_id": responseID, "item_id": itemID, "output_index": 0, "content_index": 0, "part": part})
+	itemStatus := "completed"
+	if status != "completed" {
+		itemStatus = "incomplete"
+	}
+	item := realtimeAssistantItem(itemID, itemStatus, []any{part})
+	s.send(gin.H{"type": "response.output_item.done", "response_id": responseID, "output_index": 0, "item": item})
+	response := gin.H{"id": responseID, "object": "realtime.response", "status": status, "output": []any{item}}
+	if usage.Exists() {
+		response["usage"] = gin.H{
+			"input_tokens":  usage.Get("prompt_tokens").Int(),
+			"output_tokens": usage.Get("completion_tokens").Int(),
+			"total_tokens":  usage.Get("total_tokens").Int(),
+		}
+	}
+	s.send(gin.H{"type": "response.done", "response": response})
+
+	if full != "" {
+		message, _ := sjson.SetBytes([]byte(`{"role":"assistant"}`), "content", full)
+		s.mu.Lock()
+		s.history = append(s.history, message)
+		s.lastItem = itemID
+		s.mu.Unlock()
+	}
+}
+
+func realtimeAssistantItem(itemID, status string, content []any) gin.H {
+	if content == nil {
+		content = []any{}
+	}
+	return gin.H{"id": itemID, "object": "realtime.item", "type": "message", "status": status, "role": "assistant", "content": content}
+}
+
+// sendError reports a failure as a realtime error event.
+func (s *realtimeSession) sendError(code, message, eventID string) {
+	detail := gin.H{"type": "invalid_request_error", "code": code, "message": message}
+	if code == "upstream_error" {
+		detail["type"] = "server_error"
+	}
+	if eventID != "" {
+		detail["event_id"] = eventID
+	}
+	s.send(gin.H{"type": "error", "error": detail})
+}
+
+// send writes one server event, assigning its event id.
+func (s *realtimeSession) send(event gin.H) {
+	event["event_id"] = "event_" + uuid.NewString()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+	if err := s.conn.WriteJSON(event); err != nil {
+		log.Debugf("realtime: write %v failed: %v", event["type"], err)
+	}
+}
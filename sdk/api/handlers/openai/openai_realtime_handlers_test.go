@@ -0,0 +1,47 @@
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestRealtimeMessage(t *testing.T) {
+	message, err := realtimeMessage(gjson.Parse(`{"type":"message","role":"user","content":[{"type":"input_text","text":"hello "},{"type":"input_text","text":"there"}]}`))
+	if err != nil {
+		t.Fatalf("realtimeMessage: %v", err)
+	}
+	if gjson.GetBytes(message, "role").String() != "user" || gjson.GetBytes(message, "content").String() != "hello there" {
+		t.Fatalf("message = %s", message)
+	}
+	if _, err = realtimeMessage(gjson.Parse(`{"type":"message","role":"user","content":[{"type":"input_audio","audio":"AAAA"}]}`)); err == nil {
+		t.Fatal("audio content must be rejected")
+	}
+	if _, err = realtimeMessage(gjson.Parse(`{"type":"function_call_output","output":"{}"}`)); err == nil {
+		t.Fatal("non-message items must be rejected")
+	}
+}
+
+func TestRealtimeChatRequest(t *testing.T) {
+	var cfg realtimeSessionConfig
+	cfg.Model = "claude-sonnet-4"
+	applyRealtimeSessionUpdate(&cfg, gjson.Parse(`{"instructions":"be brief","temperature":0.6,"max_response_output_tokens":256}`))
+	history := []json.RawMessage{json.RawMessage(`{"role":"user","content":"hi"}`)}
+
+	out := gjson.ParseBytes(realtimeChatRequest(cfg, gjson.Result{}, history))
+	if out.Get("model").String() != "claude-sonnet-4" || !out.Get("stream").Bool() {
+		t.Fatalf("request = %s", out.Raw)
+	}
+	if out.Get("messages.0.role").String() != "system" || out.Get("messages.0.content").String() != "be brief" || out.Get("messages.1.content").String() != "hi" {
+		t.Fatalf("messages = %s", out.Get("messages").Raw)
+	}
+	if out.Get("temperature").Float() != 0.6 || out.Get("max_tokens").Int() != 256 {
+		t.Fatalf("sampling settings = %s", out.Raw)
+	}
+
+	out = gjson.ParseBytes(realtimeChatRequest(cfg, gjson.Parse(`{"instructions":"","max_output_tokens":"inf"}`), history))
+	if out.Get("messages.0.role").String() != "user" || out.Get("max_tokens").Exists() {
+		t.Fatalf("response overrides must win: %s", out.Raw)
+	}
+}
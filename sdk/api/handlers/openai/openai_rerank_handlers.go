@@ -0,0 +1,208 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// rerankResult is a single scored document in a rerank response.
+type rerankResult struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
+// Rerank handles the /v1/rerank endpoint using the Cohere/Jina request shape:
+// {"model", "query", "documents", "top_n", "return_documents"}.
+// Requests are served by providers with a native rerank endpoint; when none is
+// available for the model, the query and documents are embedded with the same model
+// and ranked locally by cosine similarity.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Rerank(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	modelName := root.Get("model").String()
+	if msg := validateRerankRequest(root); msg != "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: msg,
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteRerankWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON)
+	if errMsg != nil && rerankFallbackAllowed(errMsg) {
+		resp, errMsg = h.rerankWithEmbeddings(cliCtx, modelName, root)
+	}
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(finalizeRerankResponse(resp, root))
+	cliCancel()
+}
+
+// validateRerankRequest returns a client-facing message for malformed rerank requests.
+func validateRerankRequest(root gjson.Result) string {
+	switch {
+	case root.Get("model").String() == "":
+		return "model is required"
+	case root.Get("query").String() == "":
+		return "query is required"
+	case !root.Get("documents").IsArray() || len(root.Get("documents").Array()) == 0:
+		return "documents must be a non-empty array"
+	}
+	return ""
+}
+
+// rerankFallbackAllowed reports whether a native rerank failure should be retried
+// through the embeddings fallback: either no provider supports rerank for the model,
+// or the upstream does not expose a rerank endpoint.
+func rerankFallbackAllowed(errMsg *interfaces.ErrorMessage) bool {
+	switch errMsg.StatusCode {
+	case http.StatusNotImplemented, http.StatusNotFound, http.StatusMethodNotAllowed:
+		return true
+	}
+	return false
+}
+
+// rerankWithEmbeddings scores documents by cosine similarity between the embedding of
+// the query and the embedding of each document.
+func (h *OpenAIAPIHandler) rerankWithEmbeddings(ctx context.Context, modelName string, root gjson.Result) ([]byte, *interfaces.ErrorMessage) {
+	inputs := []string{root.Get("query").String()}
+	root.Get("documents").ForEach(func(_, doc gjson.Result) bool {
+		inputs = append(inputs, rerankDocumentText(doc))
+		return true
+	})
+	payload := []byte(`{}`)
+	payload, _ = sjson.SetBytes(payload, "model", modelName)
+	payload, _ = sjson.SetBytes(payload, "input", inputs)
+	resp, errMsg := h.ExecuteEmbedWithAuthManager(ctx, h.HandlerType(), modelName, payload)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+
+	vectors := make([][]float64, len(inputs))
+	gjson.GetBytes(resp, "data").ForEach(func(key, item gjson.Result) bool {
+		idx := int(key.Int())
+		if index := item.Get("index"); index.Exists() {
+			idx = int(index.Int())
+		}
+		if idx < 0 || idx >= len(vectors) {
+			return true
+		}
+		values := item.Get("embedding").Array()
+		vector := make([]float64, len(values))
+		for i := range values {
+			vector[i] = values[i].Float()
+		}
+		vectors[idx] = vector
+		return true
+	})
+	if len(vectors[0]) == 0 {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("embedding fallback returned no query vector for model %s", modelName)}
+	}
+
+	out := []byte(`{"results":[]}`)
+	for i := 1; i < len(vectors); i++ {
+		out, _ = sjson.SetBytes(out, "results.-1", rerankResult{Index: i - 1, RelevanceScore: cosineSimilarity(vectors[0], vectors[i])})
+	}
+	if usage := gjson.GetBytes(resp, "usage"); usage.Exists() {
+		out, _ = sjson.SetRawBytes(out, "usage", []byte(usage.Raw))
+	}
+	return out, nil
+}
+
+// finalizeRerankResponse normalizes a rerank response: results are sorted by score,
+// truncated to top_n and, when requested, carry the original document text.
+func finalizeRerankResponse(resp []byte, root gjson.Result) []byte {
+	parsed := gjson.ParseBytes(resp)
+	results := make([]rerankResult, 0)
+	parsed.Get("results").ForEach(func(_, item gjson.Result) bool {
+		results = append(results, rerankResult{Index: int(item.Get("index").Int()), RelevanceScore: item.Get("relevance_score").Float()})
+		return true
+	})
+	sort.SliceStable(results, func(i, j int) bool { return results[i].RelevanceScore > results[j].RelevanceScore })
+	if topN := int(root.Get("top_n").Int()); topN > 0 && topN < len(results) {
+		results = results[:topN]
+	}
+
+	documents := root.Get("documents").Array()
+	returnDocuments := root.Get("return_documents").Bool()
+	out := []byte(`{"results":[]}`)
+	id := parsed.Get("id").String()
+	if id == "" {
+		id = uuid.NewString()
+	}
+	out, _ = sjson.SetBytes(out, "id", id)
+	out, _ = sjson.SetBytes(out, "model", root.Get("model").String())
+	for _, result := range results {
+		item := []byte(`{}`)
+		item, _ = sjson.SetBytes(item, "index", result.Index)
+		item, _ = sjson.SetBytes(item, "relevance_score", result.RelevanceScore)
+		if returnDocuments && result.Index >= 0 && result.Index < len(documents) {
+			item, _ = sjson.SetBytes(item, "document.text", rerankDocumentText(documents[result.Index]))
+		}
+		out, _ = sjson.SetRawBytes(out, "results.-1", item)
+	}
+	for _, key := range []string{"meta", "usage"} {
+		if value := parsed.Get(key); value.Exists() {
+			out, _ = sjson.SetRawBytes(out, key, []byte(value.Raw))
+		}
+	}
+	return out
+}
+
+// rerankDocumentText returns the text of a document given as a string or {"text": ...}.
+func rerankDocumentText(doc gjson.Result) string {
+	if doc.Type == gjson.String {
+		return doc.String()
+	}
+	if text := doc.Get("text"); text.Exists() {
+		return text.String()
+	}
+	return doc.Raw
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 when either
+// vector is empty or the dimensions differ.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,43 @@
+package openai
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestFinalizeRerankResponse(t *testing.T) {
+	request := gjson.Parse(`{"model":"rerank-v3.5","query":"q","documents":["a",{"text":"b"},"c"],"top_n":2,"return_documents":true}`)
+	upstream := []byte(`{"id":"r1","results":[{"index":0,"relevance_score":0.1},{"index":1,"relevance_score":0.9},{"index":2,"relevance_score":0.5}],"meta":{"billed_units":{"search_units":1}}}`)
+
+	out := gjson.ParseBytes(finalizeRerankResponse(upstream, request))
+	if out.Get("id").String() != "r1" || out.Get("model").String() != "rerank-v3.5" {
+		t.Fatalf("unexpected envelope: %s", out.Raw)
+	}
+	results := out.Get("results").Array()
+	if len(results) != 2 {
+		t.Fatalf("expected top_n results, got %d", len(results))
+	}
+	if results[0].Get("index").Int() != 1 || results[0].Get("document.text").String() != "b" {
+		t.Fatalf("first result = %s", results[0].Raw)
+	}
+	if results[1].Get("index").Int() != 2 {
+		t.Fatalf("second result = %s", results[1].Raw)
+	}
+	if out.Get("meta.billed_units.search_units").Int() != 1 {
+		t.Fatalf("meta not preserved: %s", out.Raw)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := cosineSimilarity([]float64{1, 0}, []float64{1, 0}); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("identical vectors = %v", got)
+	}
+	if got := cosineSimilarity([]float64{1, 0}, []float64{0, 1}); math.Abs(got) > 1e-9 {
+		t.Fatalf("orthogonal vectors = %v", got)
+	}
+	if got := cosineSimilarity([]float64{1, 0}, []float64{1}); got != 0 {
+		t.Fatalf("mismatched dimensions = %v", got)
+	}
+}
@@ -9,6 +9,7 @@ package openai
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -81,9 +82,41 @@ func (h *OpenAIResponsesAPIHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	// Expand stateless resume tokens into explicit conversation history.
+	if codec := newResponseStateCodec(h.Cfg); codec != nil {
+		rawJSON, err = codec.restore(rawJSON)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: err.Error(),
+					Type:    "invalid_request_error",
+				},
+			})
+			return
+		}
+	}
+
+	// Expand a server-side stored previous response into explicit conversation history.
+	if store := responseStoreFor(h.Cfg); store != nil {
+		rawJSON, err = chainStoredResponse(c.Request.Context(), store, handlers.ClientKeyOwner(c), rawJSON)
+		if err != nil {
+			status, errType := http.StatusInternalServerError, "server_error"
+			if errors.Is(err, errPreviousResponseNotFound) {
+				status, errType = http.StatusBadRequest, "invalid_request_error"
+			}
+			c.JSON(status, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: err.Error(),
+					Type:    errType,
+				},
+			})
+			return
+		}
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
-	if streamResult.Type == gjson.True {
+	if streamResult.Type == gjson.True && !handlers.StreamAggregationRequested(c) {
 		h.handleStreamingResponse(c, rawJSON)
 	} else {
 		h.handleNonStreamingResponse(c, rawJSON)
@@ -112,6 +145,10 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, r
 		cliCancel(errMsg.Error)
 		return
 	}
+	if codec := newResponseStateCodec(h.Cfg); codec != nil {
+		resp = codec.issue(rawJSON, resp, "id")
+	}
+	newResponseRecorder(h.Cfg, handlers.ClientKeyOwner(c), rawJSON).record(c.Request.Context(), resp)
 	_, _ = c.Writer.Write(resp)
 	cliCancel()
 }
@@ -140,6 +177,8 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
 	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
+	codec := newResponseStateCodec(h.Cfg)
+	recorder := newResponseRecorder(h.Cfg, handlers.ClientKeyOwner(c), rawJSON)
 
 	setSSEHeaders := func() {
 		c.Header("Content-Type", "text/event-stream")
@@ -180,6 +219,10 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 
 			// Success! Set headers.
 			setSSEHeaders()
+			if codec != nil {
+				chunk = codec.issueStreamChunk(rawJSON, chunk)
+			}
+			recorder.recordStreamChunk(c.Request.Context(), chunk)
 
 			// Write first chunk logic (matching forwardResponsesStream)
 			if bytes.HasPrefix(chunk, []byte("event:")) {
@@ -190,15 +233,22 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 			flusher.Flush()
 
 			// Continue
-			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.forwardResponsesStream(c, flusher, int64(len(chunk)), func(err error) { cliCancel(err) }, dataChan, errChan, codec, recorder, rawJSON)
 			return
 		}
 	}
 }
 
-func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+// responsesTruncatedEvent closes a Responses stream that hit the configured byte cap.
+const responsesTruncatedEvent = `{"type":"response.incomplete","response":{"object":"response","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}}`
+
+func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, written int64, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, codec *responseStateCodec, recorder *responseRecorder, rawJSON []byte) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
+			if codec != nil {
+				chunk = codec.issueStreamChunk(rawJSON, chunk)
+			}
+			recorder.recordStreamChunk(c.Request.Context(), chunk)
 			if bytes.HasPrefix(chunk, []byte("event:")) {
 				_, _ = c.Writer.Write([]byte("\n"))
 			}
@@ -217,11 +267,15 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flush
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := h.ErrorResponseBody(c, status, errText)
 			_, _ = fmt.Fprintf(c.Writer, "\nevent: error\ndata: %s\n\n", string(body))
 		},
 		WriteDone: func() {
 			_, _ = c.Writer.Write([]byte("\n"))
 		},
+		WriteTruncated: func() {
+			_, _ = fmt.Fprintf(c.Writer, "\nevent: response.incomplete\ndata: %s\n\n", responsesTruncatedEvent)
+		},
+		WrittenBytes: written,
 	})
 }
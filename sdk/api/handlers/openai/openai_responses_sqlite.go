@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	// modernc.org/sqlite is a pure Go driver, so release builds keep CGO_ENABLED=0.
+	_ "modernc.org/sqlite"
+)
+
+// responsesDatabase is the file name of the SQLite store inside responses-state.store-dir.
+const responsesDatabase = "responses.db"
+
+var sqliteResponseSchema = []string{
+	"PRAGMA journal_mode = WAL",
+	"PRAGMA busy_timeout = 5000",
+	`CREATE TABLE IF NOT EXISTS responses (
+		id         TEXT    PRIMARY KEY,
+		owner      TEXT    NOT NULL DEFAULT '',
+		response   BLOB    NOT NULL,
+		items      BLOB    NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS responses_expires_at ON responses (expires_at)`,
+}
+
+// sqliteResponseStore keeps stored responses in a SQLite database, so they survive
+// restarts without a file per response.
+type sqliteResponseStore struct {
+	db *sql.DB
+}
+
+// openSQLiteResponseStore opens the database at path, creating the file and schema as
+// needed.
+func openSQLiteResponseStore(path string) (*sqliteResponseStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("responses store: create database directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("responses store: open database: %w", err)
+	}
+	// A single connection serialises writers and keeps busy_timeout in effect.
+	db.SetMaxOpenConns(1)
+	for _, stmt := range sqliteResponseSchema {
+		if _, err = db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("responses store: initialise database: %w", err)
+		}
+	}
+	return &sqliteResponseStore{db: db}, nil
+}
+
+func (s *sqliteResponseStore) Get(ctx context.Context, id string) (*StoredResponse, error) {
+	var (
+		stored             StoredResponse
+		response, items    []byte
+		createdAt, expires int64
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT id, owner, response, items, created_at, expires_at
+		FROM responses WHERE id = ?`, id).Scan(&stored.ID, &stored.Owner, &response, &items, &createdAt, &expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored.Response, stored.Items = json.RawMessage(response), json.RawMessage(items)
+	stored.CreatedAt = time.Unix(0, createdAt)
+	if expires > 0 {
+		stored.ExpiresAt = time.Unix(0, expires)
+	}
+	if stored.expired(time.Now()) {
+		_, _ = s.Delete(ctx, id)
+		return nil, nil
+	}
+	return &stored, nil
+}
+
+func (s *sqliteResponseStore) Put(ctx context.Context, resp *StoredResponse) error {
+	var expires int64
+	if !resp.ExpiresAt.IsZero() {
+		expires = resp.ExpiresAt.UnixNano()
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO responses (id, owner, response, items, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			owner = excluded.owner,
+			response = excluded.response,
+			items = excluded.items,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at`,
+		resp.ID, resp.Owner, []byte(resp.Response), []byte(resp.Items), resp.CreatedAt.UnixNano(), expires)
+	return err
+}
+
+func (s *sqliteResponseStore) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM responses WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (s *sqliteResponseStore) purgeExpired(now time.Time) int {
+	result, err := s.db.Exec(`DELETE FROM responses WHERE expires_at > 0 AND expires_at < ?`, now.UnixNano())
+	if err != nil {
+		return 0
+	}
+	n, _ := result.RowsAffected()
+	return int(n)
+}
+
+// Close closes the database.
+func (s *sqliteResponseStore) Close() error { return s.db.Close() }
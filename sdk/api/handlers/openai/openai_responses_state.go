@@ -0,0 +1,249 @@
+package openai
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// resumeTokenPrefix marks response ids that carry encrypted conversation state.
+const resumeTokenPrefix = "resp_st_"
+
+const defaultResumeTokenTTL = 24 * time.Hour
+
+var errInvalidResumeToken = errors.New("previous_response_id is invalid or expired")
+
+// resumeTokenClaims is the plaintext sealed inside a resume token.
+type resumeTokenClaims struct {
+	IssuedAt  int64           `json:"iat"`
+	ExpiresAt int64           `json:"exp"`
+	Items     json.RawMessage `json:"items"`
+}
+
+// responseStateCodec seals and opens stateless resume tokens using AES-GCM.
+type responseStateCodec struct {
+	aead cipher.AEAD
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// newResponseStateCodec returns a codec for the configured secret, or nil when
+// stateless tokens are disabled. Config loading rejects an enabled codec without a
+// secret, so an empty secret here only comes from SDK callers building SDKConfig by hand.
+func newResponseStateCodec(cfg *config.SDKConfig) *responseStateCodec {
+	if cfg == nil || !cfg.ResponsesState.StatelessTokens {
+		return nil
+	}
+	secret := strings.TrimSpace(cfg.ResponsesState.Secret)
+	if secret == "" {
+		return nil
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil
+	}
+	ttl := defaultResumeTokenTTL
+	if cfg.ResponsesState.TTLSeconds > 0 {
+		ttl = time.Duration(cfg.ResponsesState.TTLSeconds) * time.Second
+	}
+	return &responseStateCodec{aead: aead, ttl: ttl, now: time.Now}
+}
+
+// seal encrypts the conversation items into a resume token.
+func (c *responseStateCodec) seal(items []byte) (string, error) {
+	now := c.now()
+	plain, err := json.Marshal(resumeTokenClaims{
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(c.ttl).Unix(),
+		Items:     items,
+	})
+	if err != nil {
+		return "", err
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err = zw.Write(plain); err != nil {
+		return "", err
+	}
+	if err = zw.Close(); err != nil {
+		return "", err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, compressed.Bytes(), []byte(resumeTokenPrefix))
+	return resumeTokenPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open authenticates and decrypts a resume token, returning its claims.
+func (c *responseStateCodec) open(token string) (*resumeTokenClaims, error) {
+	if !strings.HasPrefix(token, resumeTokenPrefix) {
+		return nil, errInvalidResumeToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, resumeTokenPrefix))
+	if err != nil || len(raw) < c.aead.NonceSize() {
+		return nil, errInvalidResumeToken
+	}
+	nonce, ciphertext := raw[:c.aead.NonceSize()], raw[c.aead.NonceSize():]
+	compressed, err := c.aead.Open(nil, nonce, ciphertext, []byte(resumeTokenPrefix))
+	if err != nil {
+		return nil, errInvalidResumeToken
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, errInvalidResumeToken
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, errInvalidResumeToken
+	}
+	var claims resumeTokenClaims
+	if err = json.Unmarshal(plain, &claims); err != nil {
+		return nil, errInvalidResumeToken
+	}
+	if c.now().Unix() > claims.ExpiresAt {
+		return nil, errInvalidResumeToken
+	}
+	return &claims, nil
+}
+
+// restore expands a resume token in previous_response_id into explicit input items.
+// Requests without a resume token are returned unchanged.
+func (c *responseStateCodec) restore(rawJSON []byte) ([]byte, error) {
+	prev := gjson.GetBytes(rawJSON, "previous_response_id").String()
+	if !strings.HasPrefix(prev, resumeTokenPrefix) {
+		return rawJSON, nil
+	}
+	claims, err := c.open(prev)
+	if err != nil {
+		return nil, err
+	}
+	return continueConversation(rawJSON, claims.Items)
+}
+
+// continueConversation prepends the earlier conversation items to the request input and
+// drops previous_response_id. As with OpenAI, the instructions of earlier responses are
+// not carried over; each request supplies its own.
+func continueConversation(rawJSON, items []byte) ([]byte, error) {
+	earlier := gjson.ParseBytes(items).Array()
+	merged := make([]json.RawMessage, 0, len(earlier)+1)
+	for _, item := range earlier {
+		merged = append(merged, json.RawMessage(item.Raw))
+	}
+	merged = append(merged, responseInputItems(rawJSON)...)
+	input, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	out, err := sjson.SetRawBytes(rawJSON, "input", input)
+	if err != nil {
+		return nil, err
+	}
+	return sjson.DeleteBytes(out, "previous_response_id")
+}
+
+// issue replaces the response id with a resume token covering the request input plus the
+// response output. The response is returned unchanged on failure.
+func (c *responseStateCodec) issue(requestJSON, response []byte, idPath string) []byte {
+	encoded, err := conversationItems(requestJSON, gjson.GetBytes(response, strings.TrimSuffix(idPath, "id")+"output"))
+	if err != nil {
+		return response
+	}
+	token, err := c.seal(encoded)
+	if err != nil {
+		return response
+	}
+	updated, err := sjson.SetBytes(response, idPath, token)
+	if err != nil {
+		return response
+	}
+	return updated
+}
+
+// issueStreamChunk rewrites the response id of a response.completed event chunk. The
+// resume token only exists once the output is complete, so the upstream id is withheld
+// from the events before it (response.created, response.in_progress) and from failed or
+// incomplete responses, where clients could not use it as previous_response_id anyway.
+// Chunks without a response object pass through untouched.
+func (c *responseStateCodec) issueStreamChunk(requestJSON, chunk []byte) []byte {
+	if !bytes.Contains(chunk, []byte(`"response"`)) {
+		return chunk
+	}
+	lines := bytes.Split(chunk, []byte("\n"))
+	for i, line := range lines {
+		if payload := completedEventPayload(line); payload != nil {
+			lines[i] = append([]byte("data: "), c.issue(requestJSON, payload, "response.id")...)
+			continue
+		}
+		payload, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok || !gjson.GetBytes(payload, "response.id").Exists() {
+			continue
+		}
+		if withheld, err := sjson.DeleteBytes(bytes.TrimSpace(payload), "response.id"); err == nil {
+			lines[i] = append([]byte("data: "), withheld...)
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// completedEventPayload returns the JSON payload of a response.completed data line, or
+// nil for any other line.
+func completedEventPayload(line []byte) []byte {
+	payload, ok := bytes.CutPrefix(line, []byte("data:"))
+	if !ok {
+		return nil
+	}
+	payload = bytes.TrimSpace(payload)
+	if gjson.GetBytes(payload, "type").String() != "response.completed" {
+		return nil
+	}
+	return payload
+}
+
+// conversationItems encodes the request input items followed by the response output
+// items: the whole conversation a response concludes.
+func conversationItems(requestJSON []byte, output gjson.Result) ([]byte, error) {
+	items := responseInputItems(requestJSON)
+	for _, item := range output.Array() {
+		items = append(items, json.RawMessage(item.Raw))
+	}
+	return json.Marshal(items)
+}
+
+// responseInputItems normalizes the Responses API input field into a list of items.
+func responseInputItems(rawJSON []byte) []json.RawMessage {
+	input := gjson.GetBytes(rawJSON, "input")
+	switch {
+	case input.IsArray():
+		items := make([]json.RawMessage, 0, len(input.Array()))
+		for _, item := range input.Array() {
+			items = append(items, json.RawMessage(item.Raw))
+		}
+		return items
+	case input.Type == gjson.String:
+		msg := fmt.Sprintf(`{"type":"message","role":"user","content":%s}`, input.Raw)
+		return []json.RawMessage{json.RawMessage(msg)}
+	default:
+		return nil
+	}
+}
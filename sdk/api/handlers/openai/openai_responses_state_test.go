@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newTestStateCodec(t *testing.T) *responseStateCodec {
+	t.Helper()
+	codec := newResponseStateCodec(&config.SDKConfig{
+		ResponsesState: config.ResponsesStateConfig{StatelessTokens: true, Secret: "test-secret"},
+	})
+	if codec == nil {
+		t.Fatal("expected codec to be enabled")
+	}
+	return codec
+}
+
+func TestResponseStateCodec_RoundTrip(t *testing.T) {
+	codec := newTestStateCodec(t)
+
+	request := []byte(`{"model":"gpt-5","input":"hello"}`)
+	response := []byte(`{"id":"resp_1","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"hi"}]}]}`)
+
+	issued := codec.issue(request, response, "id")
+	token := gjson.GetBytes(issued, "id").String()
+	if !strings.HasPrefix(token, resumeTokenPrefix) {
+		t.Fatalf("expected resume token id, got %q", token)
+	}
+
+	next := []byte(`{"model":"gpt-5","previous_response_id":"` + token + `","input":"again"}`)
+	restored, err := codec.restore(next)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if gjson.GetBytes(restored, "previous_response_id").Exists() {
+		t.Fatal("expected previous_response_id to be removed")
+	}
+	input := gjson.GetBytes(restored, "input").Array()
+	if len(input) != 3 {
+		t.Fatalf("expected 3 input items, got %d: %s", len(input), restored)
+	}
+	if got := input[0].Get("content").String(); got != "hello" {
+		t.Fatalf("unexpected first item content %q", got)
+	}
+	if got := input[1].Get("role").String(); got != "assistant" {
+		t.Fatalf("unexpected second item role %q", got)
+	}
+}
+
+func TestResponseStateCodec_RejectsTamperedAndExpired(t *testing.T) {
+	codec := newTestStateCodec(t)
+	token, err := codec.seal([]byte(`[]`))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	tampered := token[:len(token)-2] + "AA"
+	if _, err = codec.open(tampered); err == nil {
+		t.Fatal("expected tampered token to be rejected")
+	}
+
+	other := newResponseStateCodec(&config.SDKConfig{
+		ResponsesState: config.ResponsesStateConfig{StatelessTokens: true, Secret: "other-secret"},
+	})
+	if _, err = other.open(token); err == nil {
+		t.Fatal("expected token sealed with another secret to be rejected")
+	}
+
+	codec.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	if _, err = codec.open(token); err == nil {
+		t.Fatal("expected expired token to be rejected")
+	}
+}
+
+func TestResponseStateCodec_IssueStreamChunk(t *testing.T) {
+	codec := newTestStateCodec(t)
+	request := []byte(`{"input":[{"type":"message","role":"user","content":"hi"}]}`)
+	chunk := []byte("event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}")
+
+	out := codec.issueStreamChunk(request, chunk)
+	lines := strings.Split(string(out), "\n")
+	if len(lines) != 2 || lines[0] != "event: response.completed" {
+		t.Fatalf("unexpected chunk layout: %q", out)
+	}
+	id := gjson.Get(strings.TrimPrefix(lines[1], "data: "), "response.id").String()
+	if !strings.HasPrefix(id, resumeTokenPrefix) {
+		t.Fatalf("expected resume token id, got %q", id)
+	}
+
+	delta := []byte("event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\"}")
+	if got := codec.issueStreamChunk(request, delta); string(got) != string(delta) {
+		t.Fatalf("expected non-terminal chunk to pass through, got %q", got)
+	}
+}
+
+func TestResponseStateCodec_WithholdsUpstreamIDBeforeCompletion(t *testing.T) {
+	codec := newTestStateCodec(t)
+	request := []byte(`{"input":"hi"}`)
+	for _, event := range []string{"response.created", "response.in_progress", "response.failed"} {
+		chunk := []byte("event: " + event + "\ndata: {\"type\":\"" + event + "\",\"response\":{\"id\":\"resp_upstream\",\"status\":\"in_progress\"}}")
+		out := codec.issueStreamChunk(request, chunk)
+		if strings.Contains(string(out), "resp_upstream") {
+			t.Fatalf("%s leaks the upstream id: %s", event, out)
+		}
+		if !strings.Contains(string(out), `"status":"in_progress"`) {
+			t.Fatalf("%s lost the rest of the response: %s", event, out)
+		}
+	}
+}
+
+func TestResponseStateCodec_DoesNotCarryInstructions(t *testing.T) {
+	codec := newTestStateCodec(t)
+	request := []byte(`{"instructions":"be terse","input":"hello"}`)
+	issued := codec.issue(request, []byte(`{"id":"resp_1","output":[]}`), "id")
+	token := gjson.GetBytes(issued, "id").String()
+
+	restored, err := codec.restore([]byte(`{"previous_response_id":"` + token + `","input":"again"}`))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if got := gjson.GetBytes(restored, "instructions"); got.Exists() {
+		t.Fatalf("instructions = %s, want none carried over", got.Raw)
+	}
+}
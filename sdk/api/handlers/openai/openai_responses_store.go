@@ -0,0 +1,452 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// responseStoreCleanup is the default janitor schedule for expired stored responses.
+const responseStoreCleanup = 10 * time.Minute
+
+var errPreviousResponseNotFound = errors.New("previous response not found")
+
+// StoredResponse is a Responses API response kept server-side, together with the
+// conversation it concludes so later requests can chain onto it.
+type StoredResponse struct {
+	ID string `json:"id"`
+	// Response is the response object as returned to the client.
+	Response json.RawMessage `json:"response"`
+	// Items are the conversation input items followed by the response output items.
+	Items json.RawMessage `json:"items"`
+	// Owner identifies the client API key that created the response (see
+	// handlers.ClientKeyOwner). Other keys can neither read nor chain onto it.
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// expired reports whether the response is past its retention at now.
+func (r *StoredResponse) expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
+
+// ResponseStore persists stored responses. Get returns nil without error for unknown or
+// expired ids. Implementations must be safe for concurrent use.
+type ResponseStore interface {
+	Get(ctx context.Context, id string) (*StoredResponse, error)
+	Put(ctx context.Context, resp *StoredResponse) error
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+var (
+	responseStoreMu     sync.Mutex
+	customResponseStore ResponseStore
+	configuredStore     ResponseStore
+	configuredStoreKey  string
+)
+
+func init() {
+	janitor.Register("responses-store", responseStoreCleanup, func(_ *config.Config, now time.Time) (int, error) {
+		responseStoreMu.Lock()
+		store := configuredStore
+		responseStoreMu.Unlock()
+		if sweeper, ok := store.(interface{ purgeExpired(time.Time) int }); ok {
+			return sweeper.purgeExpired(now), nil
+		}
+		return 0, nil
+	})
+}
+
+// SetResponseStore installs store, e.g. a database-backed one, as the backend of
+// server-side response storage in place of the one selected by responses-state.store.
+// A custom store enables storage regardless of the config. nil restores the configured
+// store.
+func SetResponseStore(store ResponseStore) {
+	responseStoreMu.Lock()
+	customResponseStore = store
+	responseStoreMu.Unlock()
+}
+
+// responseStoreFor returns the store selected by cfg, or nil when storage is disabled.
+// The configured store is kept across reloads that do not change its kind or directory;
+// a replaced store is closed.
+func responseStoreFor(cfg *config.SDKConfig) ResponseStore {
+	responseStoreMu.Lock()
+	defer responseStoreMu.Unlock()
+	if customResponseStore != nil {
+		return customResponseStore
+	}
+	if cfg == nil {
+		return nil
+	}
+	kind := strings.ToLower(strings.TrimSpace(cfg.ResponsesState.Store))
+	dir := strings.TrimSpace(cfg.ResponsesState.StoreDir)
+	key := kind + "|" + dir
+	if key == configuredStoreKey {
+		return configuredStore
+	}
+	var store ResponseStore
+	switch kind {
+	case "memory":
+		store = newMemoryResponseStore()
+	case "file", "sqlite":
+		resolved, err := util.ResolveAuthDir(dir)
+		if err != nil || resolved == "" {
+			log.Errorf("responses store: invalid store-dir %q: %v", dir, err)
+			return nil
+		}
+		if kind == "file" {
+			store = &fileResponseStore{dir: resolved}
+			break
+		}
+		db, err := openSQLiteResponseStore(filepath.Join(resolved, responsesDatabase))
+		if err != nil {
+			log.Errorf("%v", err)
+			return nil
+		}
+		store = db
+	}
+	if closer, ok := configuredStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Warnf("responses store: closing the previous store: %v", err)
+		}
+	}
+	configuredStore, configuredStoreKey = store, key
+	return store
+}
+
+// responseStoreTTL is how long stored responses are kept under cfg.
+func responseStoreTTL(cfg *config.SDKConfig) time.Duration {
+	if cfg != nil && cfg.ResponsesState.TTLSeconds > 0 {
+		return time.Duration(cfg.ResponsesState.TTLSeconds) * time.Second
+	}
+	return defaultResumeTokenTTL
+}
+
+// chainStoredResponse expands a stored previous_response_id into explicit input items.
+// Requests without previous_response_id are returned unchanged; unknown ids, and those of
+// responses owned by another client key, fail with errPreviousResponseNotFound, since no
+// upstream could resolve them either.
+func chainStoredResponse(ctx context.Context, store ResponseStore, owner string, rawJSON []byte) ([]byte, error) {
+	prev := gjson.GetBytes(rawJSON, "previous_response_id").String()
+	if prev == "" {
+		return rawJSON, nil
+	}
+	stored, err := store.Get(ctx, prev)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Owner != owner {
+		return nil, fmt.Errorf("%w: %s", errPreviousResponseNotFound, prev)
+	}
+	return continueConversation(rawJSON, stored.Items)
+}
+
+// responseRecorder saves the responses of one request to the store.
+type responseRecorder struct {
+	store   ResponseStore
+	ttl     time.Duration
+	owner   string
+	request []byte
+}
+
+// newResponseRecorder returns a recorder for the request of owner, or nil when storage is
+// disabled or the request sets store:false.
+func newResponseRecorder(cfg *config.SDKConfig, owner string, rawJSON []byte) *responseRecorder {
+	if gjson.GetBytes(rawJSON, "store").Type == gjson.False {
+		return nil
+	}
+	store := responseStoreFor(cfg)
+	if store == nil {
+		return nil
+	}
+	return &responseRecorder{store: store, ttl: responseStoreTTL(cfg), owner: owner, request: rawJSON}
+}
+
+// record stores a completed response object under its id.
+func (r *responseRecorder) record(ctx context.Context, response []byte) {
+	if r == nil {
+		return
+	}
+	id := gjson.GetBytes(response, "id").String()
+	if id == "" {
+		return
+	}
+	items, err := conversationItems(r.request, gjson.GetBytes(response, "output"))
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	stored := &StoredResponse{
+		ID:        id,
+		Response:  json.RawMessage(bytes.Clone(response)),
+		Items:     items,
+		Owner:     r.owner,
+		CreatedAt: now,
+		ExpiresAt: now.Add(r.ttl),
+	}
+	if err = r.store.Put(ctx, stored); err != nil {
+		log.Warnf("responses store: save %s: %v", id, err)
+	}
+}
+
+// recordStreamChunk stores the response carried by a response.completed event chunk.
+func (r *responseRecorder) recordStreamChunk(ctx context.Context, chunk []byte) {
+	if r == nil || !bytes.Contains(chunk, []byte("response.completed")) {
+		return
+	}
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		if payload := completedEventPayload(line); payload != nil {
+			r.record(ctx, []byte(gjson.GetBytes(payload, "response").Raw))
+		}
+	}
+}
+
+// GetResponse handles GET /v1/responses/:id, returning a response kept by the
+// server-side store.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	id := c.Param("id")
+	store := responseStoreFor(h.Cfg)
+	if store == nil {
+		writeResponseNotFound(c, id)
+		return
+	}
+	stored, ok := ownedResponse(c, store, id)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "application/json", stored.Response)
+}
+
+// DeleteResponse handles DELETE /v1/responses/:id, removing a stored response.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	id := c.Param("id")
+	store := responseStoreFor(h.Cfg)
+	if store == nil {
+		writeResponseNotFound(c, id)
+		return
+	}
+	if _, ok := ownedResponse(c, store, id); !ok {
+		return
+	}
+	deleted, err := store.Delete(c.Request.Context(), id)
+	if err != nil {
+		writeResponseStoreError(c, err)
+		return
+	}
+	if !deleted {
+		writeResponseNotFound(c, id)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "response.deleted", "deleted": true})
+}
+
+// ownedResponse returns the stored response id when it belongs to the client key of the
+// request, writing the error response otherwise. Responses of other keys are reported as
+// not found.
+func ownedResponse(c *gin.Context, store ResponseStore, id string) (*StoredResponse, bool) {
+	stored, err := store.Get(c.Request.Context(), id)
+	if err != nil {
+		writeResponseStoreError(c, err)
+		return nil, false
+	}
+	if stored == nil || stored.Owner != handlers.ClientKeyOwner(c) {
+		writeResponseNotFound(c, id)
+		return nil, false
+	}
+	return stored, true
+}
+
+func writeResponseNotFound(c *gin.Context, id string) {
+	c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: fmt.Sprintf("Response with id '%s' not found.", id),
+			Type:    "invalid_request_error",
+		},
+	})
+}
+
+func writeResponseStoreError(c *gin.Context, err error) {
+	c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: fmt.Sprintf("responses store: %v", err),
+			Type:    "server_error",
+		},
+	})
+}
+
+// memoryResponseStore keeps stored responses in process memory.
+type memoryResponseStore struct {
+	mu        sync.Mutex
+	responses map[string]*StoredResponse
+}
+
+func newMemoryResponseStore() *memoryResponseStore {
+	return &memoryResponseStore{responses: make(map[string]*StoredResponse)}
+}
+
+func (s *memoryResponseStore) Get(_ context.Context, id string) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.responses[id]
+	if !ok {
+		return nil, nil
+	}
+	if stored.expired(time.Now()) {
+		delete(s.responses, id)
+		return nil, nil
+	}
+	return stored, nil
+}
+
+func (s *memoryResponseStore) Put(_ context.Context, resp *StoredResponse) error {
+	s.mu.Lock()
+	s.responses[resp.ID] = resp
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryResponseStore) Delete(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.responses[id]
+	delete(s.responses, id)
+	return ok, nil
+}
+
+func (s *memoryResponseStore) purgeExpired(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for id, stored := range s.responses {
+		if stored.expired(now) {
+			delete(s.responses, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// safeResponseID matches ids usable as file names as is.
+var safeResponseID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// fileResponseStore keeps each stored response in its own JSON file, so stored
+// responses survive restarts.
+type fileResponseStore struct {
+	dir string
+}
+
+// path returns the file of id. Ids that are not safe file names are hashed.
+func (s *fileResponseStore) path(id string) string {
+	name := id
+	if !safeResponseID.MatchString(id) {
+		sum := sha256.Sum256([]byte(id))
+		name = hex.EncodeToString(sum[:])
+	}
+	return filepath.Join(s.dir, name+".json")
+}
+
+func (s *fileResponseStore) Get(_ context.Context, id string) (*StoredResponse, error) {
+	stored, err := s.read(s.path(id))
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	if stored.ID != id {
+		return nil, nil
+	}
+	if stored.expired(time.Now()) {
+		_ = os.Remove(s.path(id))
+		return nil, nil
+	}
+	return stored, nil
+}
+
+func (s *fileResponseStore) read(path string) (*StoredResponse, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored StoredResponse
+	if err = json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (s *fileResponseStore) Put(_ context.Context, resp *StoredResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".response-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(resp.ID))
+}
+
+func (s *fileResponseStore) Delete(_ context.Context, id string) (bool, error) {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *fileResponseStore) purgeExpired(now time.Time) int {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		stored, errRead := s.read(path)
+		if errRead != nil || stored == nil || !stored.expired(now) {
+			continue
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
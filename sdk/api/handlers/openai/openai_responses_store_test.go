@@ -0,0 +1,86 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestResponseStore_RecordAndChain(t *testing.T) {
+	ctx := context.Background()
+	for _, kind := range []string{"memory", "file", "sqlite"} {
+		t.Run(kind, func(t *testing.T) {
+			cfg := &config.SDKConfig{ResponsesState: config.ResponsesStateConfig{Store: kind, StoreDir: t.TempDir()}}
+			request := []byte(`{"model":"claude-sonnet-4","instructions":"be brief","input":"hello"}`)
+			chunk := []byte("event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[{\"type\":\"message\",\"role\":\"assistant\",\"content\":[{\"type\":\"output_text\",\"text\":\"hi\"}]}]}}\n")
+			newResponseRecorder(cfg, "owner-a", request).recordStreamChunk(ctx, chunk)
+
+			store := responseStoreFor(cfg)
+			stored, err := store.Get(ctx, "resp_1")
+			if err != nil || stored == nil {
+				t.Fatalf("Get: %v, %v", stored, err)
+			}
+			if gjson.GetBytes(stored.Response, "output.0.role").String() != "assistant" {
+				t.Fatalf("stored response = %s", stored.Response)
+			}
+
+			next, err := chainStoredResponse(ctx, store, "owner-a", []byte(`{"previous_response_id":"resp_1","input":"again"}`))
+			if err != nil {
+				t.Fatalf("chain: %v", err)
+			}
+			if n := len(gjson.GetBytes(next, "input").Array()); n != 3 || gjson.GetBytes(next, "instructions").Exists() {
+				t.Fatalf("chained request = %s", next)
+			}
+			if _, err = chainStoredResponse(ctx, store, "owner-a", []byte(`{"previous_response_id":"resp_missing"}`)); !errors.Is(err, errPreviousResponseNotFound) {
+				t.Fatalf("unknown ids must fail, got %v", err)
+			}
+			if _, err = chainStoredResponse(ctx, store, "owner-b", []byte(`{"previous_response_id":"resp_1"}`)); !errors.Is(err, errPreviousResponseNotFound) {
+				t.Fatalf("another key must not chain onto resp_1, got %v", err)
+			}
+
+			if deleted, _ := store.Delete(ctx, "resp_1"); !deleted {
+				t.Fatal("Delete must report the stored response")
+			}
+			if stored, _ = store.Get(ctx, "resp_1"); stored != nil {
+				t.Fatal("deleted response must be gone")
+			}
+		})
+	}
+}
+
+func TestNewResponseRecorder_HonorsStoreFalse(t *testing.T) {
+	cfg := &config.SDKConfig{ResponsesState: config.ResponsesStateConfig{Store: "memory"}}
+	if newResponseRecorder(cfg, "", []byte(`{"store":false}`)) != nil {
+		t.Fatal("store:false requests must not be recorded")
+	}
+	if newResponseRecorder(&config.SDKConfig{}, "", []byte(`{"store":true}`)) != nil {
+		t.Fatal("nothing is recorded without a configured store")
+	}
+}
+
+func TestSQLiteResponseStore_PurgesExpired(t *testing.T) {
+	ctx := context.Background()
+	store, err := openSQLiteResponseStore(t.TempDir() + "/responses.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+	now := time.Now()
+	for id, expires := range map[string]time.Time{"resp_old": now.Add(-time.Minute), "resp_new": now.Add(time.Hour), "resp_kept": {}} {
+		if err = store.Put(ctx, &StoredResponse{ID: id, Response: []byte(`{}`), Items: []byte(`[]`), CreatedAt: now, ExpiresAt: expires}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if removed := store.purgeExpired(now); removed != 1 {
+		t.Fatalf("purgeExpired removed %d, want 1", removed)
+	}
+	for _, id := range []string{"resp_new", "resp_kept"} {
+		if stored, _ := store.Get(ctx, id); stored == nil {
+			t.Fatalf("%s must be kept", id)
+		}
+	}
+}
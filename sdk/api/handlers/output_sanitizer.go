@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"sort"
+)
+
+// codeFenceClose is appended when a stream ends inside an open code fence.
+const codeFenceClose = "\n```\n"
+
+// StreamTextCodec lets the output sanitizer read and synthesize assistant text in a
+// handler's wire format. Chunks are the payloads passed to WriteChunk; a chunk may carry
+// several choices (OpenAI n > 1, Gemini candidateCount > 1), identified by their index.
+type StreamTextCodec struct {
+	// Texts returns the assistant text carried by chunk, keyed by choice index. Choices
+	// without text may be omitted.
+	Texts func(chunk []byte) map[int]string
+
+	// Finished returns the indexes of the choices that chunk ends (e.g. those carrying a
+	// finish reason), so any repair text must be written before it.
+	Finished func(chunk []byte) []int
+
+	// EncodeText builds a chunk carrying text for choice index alone, modelled on
+	// template, a chunk seen earlier in the same stream. Returning nil skips the repair.
+	EncodeText func(template []byte, index int, text string) []byte
+}
+
+// outputSanitizer repairs unterminated code fences and a resent first chunk in a single
+// streamed response.
+type outputSanitizer struct {
+	codec   StreamTextCodec
+	choices map[int]*sanitizedChoice
+
+	// lastText is the previous chunk that carried text, used to spot resent chunks.
+	lastText []byte
+}
+
+// sanitizedChoice is the fence and duplicate state of one choice.
+type sanitizedChoice struct {
+	textChunks int
+	template   []byte
+
+	backticks int
+	inFence   bool
+	closed    bool
+}
+
+func newOutputSanitizer(codec StreamTextCodec) *outputSanitizer {
+	if codec.Texts == nil || codec.EncodeText == nil {
+		return nil
+	}
+	return &outputSanitizer{codec: codec, choices: make(map[int]*sanitizedChoice)}
+}
+
+func (s *outputSanitizer) choice(index int) *sanitizedChoice {
+	state := s.choices[index]
+	if state == nil {
+		state = &sanitizedChoice{}
+		s.choices[index] = state
+	}
+	return state
+}
+
+// process returns the chunks to write in place of chunk.
+func (s *outputSanitizer) process(chunk []byte) [][]byte {
+	texts := s.codec.Texts(chunk)
+	if len(texts) > 0 && s.isResentFirstChunk(chunk, texts) {
+		return nil
+	}
+
+	for index, text := range texts {
+		if text == "" {
+			continue
+		}
+		state := s.choice(index)
+		state.textChunks++
+		state.template = bytes.Clone(chunk)
+		state.observe(text)
+	}
+	if len(texts) > 0 {
+		s.lastText = bytes.Clone(chunk)
+	}
+
+	if s.codec.Finished == nil {
+		return [][]byte{chunk}
+	}
+	finished := s.codec.Finished(chunk)
+	if len(finished) == 0 {
+		return [][]byte{chunk}
+	}
+	sort.Ints(finished)
+	out := make([][]byte, 0, len(finished)+1)
+	for _, index := range finished {
+		template := chunk
+		if state := s.choices[index]; state != nil && state.template != nil {
+			template = state.template
+		}
+		if repair := s.repair(index, template); repair != nil {
+			out = append(out, repair)
+		}
+	}
+	return append(out, chunk)
+}
+
+// isResentFirstChunk reports whether chunk is a byte-identical resend (same id, index
+// and text) of the opening text chunk of every choice it carries. Some upstreams replay
+// their first chunk; a later repeat of the same token is legitimate output.
+func (s *outputSanitizer) isResentFirstChunk(chunk []byte, texts map[int]string) bool {
+	if s.lastText == nil || !bytes.Equal(chunk, s.lastText) {
+		return false
+	}
+	for index, text := range texts {
+		if text == "" {
+			continue
+		}
+		if state := s.choices[index]; state == nil || state.textChunks != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// finish returns repair chunks to write before the stream's terminal marker, one per
+// choice still inside a code fence.
+func (s *outputSanitizer) finish() [][]byte {
+	indexes := make([]int, 0, len(s.choices))
+	for index := range s.choices {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	var out [][]byte
+	for _, index := range indexes {
+		if repair := s.repair(index, s.choices[index].template); repair != nil {
+			out = append(out, repair)
+		}
+	}
+	return out
+}
+
+func (s *outputSanitizer) repair(index int, template []byte) []byte {
+	state := s.choices[index]
+	if state == nil {
+		return nil
+	}
+	state.flushBackticks()
+	if state.closed || !state.inFence || template == nil {
+		return nil
+	}
+	state.closed = true
+	state.inFence = false
+	return s.codec.EncodeText(template, index, codeFenceClose)
+}
+
+// observe tracks code fence state; a run of three or more backticks toggles the fence.
+func (c *sanitizedChoice) observe(text string) {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '`' {
+			c.backticks++
+			continue
+		}
+		c.flushBackticks()
+	}
+}
+
+func (c *sanitizedChoice) flushBackticks() {
+	if c.backticks >= 3 {
+		c.inFence = !c.inFence
+	}
+	c.backticks = 0
+}
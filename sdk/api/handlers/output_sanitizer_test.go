@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testTextCodec treats chunks as "[final:]text" for choice 0, or "N|[final:]text" for
+// choice N, in sanitizer tests.
+var testTextCodec = StreamTextCodec{
+	Texts: func(chunk []byte) map[int]string {
+		index, text := splitTestChunk(chunk)
+		if text == "" {
+			return nil
+		}
+		return map[int]string{index: text}
+	},
+	Finished: func(chunk []byte) []int {
+		index, _ := splitTestChunk(chunk)
+		if !strings.Contains(string(chunk), "final:") {
+			return nil
+		}
+		return []int{index}
+	},
+	EncodeText: func(_ []byte, index int, text string) []byte {
+		if index == 0 {
+			return []byte(text)
+		}
+		return []byte(strconv.Itoa(index) + "|" + text)
+	},
+}
+
+func splitTestChunk(chunk []byte) (int, string) {
+	s := string(chunk)
+	index := 0
+	if i := strings.Index(s, "|"); i > 0 {
+		index, _ = strconv.Atoi(s[:i])
+		s = s[i+1:]
+	}
+	return index, strings.TrimPrefix(s, "final:")
+}
+
+func collect(s *outputSanitizer, chunks ...string) []string {
+	var out []string
+	for _, chunk := range chunks {
+		for _, written := range s.process([]byte(chunk)) {
+			out = append(out, string(written))
+		}
+	}
+	for _, repair := range s.finish() {
+		out = append(out, string(repair))
+	}
+	return out
+}
+
+func TestOutputSanitizer_ClosesUnterminatedFence(t *testing.T) {
+	got := collect(newOutputSanitizer(testTextCodec), "Here:\n`", "``go\nfmt.Println()")
+	if last := got[len(got)-1]; last != codeFenceClose {
+		t.Fatalf("expected closing fence, got %q", got)
+	}
+}
+
+func TestOutputSanitizer_ClosesFenceBeforeFinalChunk(t *testing.T) {
+	got := collect(newOutputSanitizer(testTextCodec), "```\ncode", "final:")
+	want := []string{"```\ncode", codeFenceClose, "final:"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestOutputSanitizer_LeavesBalancedFences(t *testing.T) {
+	got := collect(newOutputSanitizer(testTextCodec), "```\ncode\n", "```\ndone")
+	if len(got) != 2 {
+		t.Fatalf("expected no repair, got %q", got)
+	}
+}
+
+func TestOutputSanitizer_DropsResentFirstChunk(t *testing.T) {
+	got := collect(newOutputSanitizer(testTextCodec), "Hello", "Hello", " world", " world")
+	want := []string{"Hello", " world", " world"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestOutputSanitizer_KeepsRepeatOfOtherChoice(t *testing.T) {
+	// The same token from another choice is a different chunk, not a resend.
+	got := collect(newOutputSanitizer(testTextCodec), "Hello", "1|Hello", "1|Hello")
+	want := []string{"Hello", "1|Hello"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestOutputSanitizer_RepairsEveryChoice(t *testing.T) {
+	got := collect(newOutputSanitizer(testTextCodec), "```\na", "1|```\nb", "1|final:", "```\nc")
+	want := []string{"```\na", "1|```\nb", "1|" + codeFenceClose, "1|final:", "```\nc"}
+	if strings.Join(got, "~") != strings.Join(want, "~") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StreamAggregateHeader asks the proxy to consume the upstream stream and answer with a
+// single JSON response, for clients that cannot read server-sent events. It applies to
+// streaming requests too.
+const StreamAggregateHeader = "X-Stream-Aggregate"
+
+// streamAggregators builds the non-streaming response of a client format from the
+// payloads of its stream events.
+var streamAggregators = map[string]func(events []gjson.Result) ([]byte, error){
+	constant.OpenAI:         aggregateOpenAIChat,
+	constant.OpenaiResponse: aggregateOpenAIResponses,
+	constant.Claude:         aggregateClaudeMessages,
+	constant.Gemini:         aggregateGemini,
+}
+
+// StreamAggregationRequested reports whether the request asks for an aggregated JSON
+// response with StreamAggregateHeader.
+func StreamAggregationRequested(c *gin.Context) bool {
+	if c == nil || c.Request == nil {
+		return false
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(c.GetHeader(StreamAggregateHeader)))
+	return err == nil && enabled
+}
+
+// aggregatesStream reports whether a non-streaming request in handlerType is served by
+// streaming from providers and aggregating the events: when the client asked for it, or
+// when every provider is listed in streaming.stream-only-providers.
+func (h *BaseAPIHandler) aggregatesStream(ctx context.Context, handlerType string, providers []string) bool {
+	if streamAggregators[handlerType] == nil {
+		return false
+	}
+	if c, _ := ctx.Value("gin").(*gin.Context); StreamAggregationRequested(c) {
+		return true
+	}
+	if h.Cfg == nil || len(h.Cfg.Streaming.StreamOnlyProviders) == 0 || len(providers) == 0 {
+		return false
+	}
+	for _, provider := range providers {
+		if !slices.ContainsFunc(h.Cfg.Streaming.StreamOnlyProviders, func(name string) bool {
+			return strings.EqualFold(strings.TrimSpace(name), provider)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// executeAggregated streams route and returns the events aggregated into the
+// non-streaming response of handlerType.
+func (h *BaseAPIHandler) executeAggregated(ctx context.Context, handlerType string, route requestRoute, rawJSON []byte, alt string) (coreexecutor.Response, error) {
+	if handlerType != constant.Gemini {
+		rawJSON, _ = sjson.SetBytes(rawJSON, "stream", true)
+	}
+	if handlerType == constant.OpenAI {
+		rawJSON, _ = sjson.SetBytes(rawJSON, "stream_options.include_usage", true)
+	}
+	req, opts := routeRequest(ctx, handlerType, route, rawJSON, alt, true)
+	chunks, err := h.AuthManager.ExecuteStream(ctx, route.providers, req, opts)
+	if err != nil {
+		return coreexecutor.Response{}, err
+	}
+	var events []gjson.Result
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			for range chunks {
+			}
+			return coreexecutor.Response{}, chunk.Err
+		}
+		events = append(events, streamEventPayloads(chunk.Payload)...)
+	}
+	payload, err := streamAggregators[handlerType](events)
+	if err != nil {
+		return coreexecutor.Response{}, &aggregateError{msg: err.Error()}
+	}
+	return coreexecutor.Response{Payload: payload}, nil
+}
+
+// aggregateError reports a stream that ended without a usable response.
+type aggregateError struct{ msg string }
+
+func (e *aggregateError) Error() string   { return "stream aggregation: " + e.msg }
+func (e *aggregateError) StatusCode() int { return http.StatusBadGateway }
+
+// streamEventPayloads returns the JSON payloads of a stream chunk: the chunk itself when
+// it is bare JSON, otherwise the data lines of its server-sent events.
+func streamEventPayloads(chunk []byte) []gjson.Result {
+	chunk = bytes.TrimSpace(chunk)
+	if gjson.ValidBytes(chunk) {
+		return []gjson.Result{gjson.ParseBytes(chunk)}
+	}
+	var out []gjson.Result
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if gjson.ValidBytes(data) {
+			out = append(out, gjson.ParseBytes(data))
+		}
+	}
+	return out
+}
+
+// aggregateOpenAIChat merges chat.completion.chunk events into a chat.completion.
+func aggregateOpenAIChat(events []gjson.Result) ([]byte, error) {
+	out := []byte(`{"object":"chat.completion","choices":[]}`)
+	type choiceState struct {
+		content, reasoning strings.Builder
+		role, finish       string
+		toolCalls          []byte
+	}
+	var choices []*choiceState
+	seen := false
+	for _, event := range events {
+		if event.Get("object").String() != "chat.completion.chunk" {
+			continue
+		}
+		seen = true
+		for _, key := range []string{"id", "created", "model", "system_fingerprint"} {
+			if value := event.Get(key); value.Exists() && value.Type != gjson.Null {
+				out, _ = sjson.SetRawBytes(out, key, []byte(value.Raw))
+			}
+		}
+		if usage := event.Get("usage"); usage.IsObject() {
+			out, _ = sjson.SetRawBytes(out, "usage", []byte(usage.Raw))
+		}
+		for _, choice := range event.Get("choices").Array() {
+			index := int(choice.Get("index").Int())
+			for len(choices) <= index {
+				choices = append(choices, &choiceState{role: "assistant", toolCalls: []byte(`[]`)})
+			}
+			state := choices[index]
+			delta := choice.Get("delta")
+			if role := delta.Get("role").String(); role != "" {
+				state.role = role
+			}
+			state.content.WriteString(delta.Get("content").String())
+			state.reasoning.WriteString(delta.Get("reasoning_content").String())
+			for _, call := range delta.Get("tool_calls").Array() {
+				state.toolCalls = mergeToolCallDelta(state.toolCalls, call)
+			}
+			if finish := choice.Get("finish_reason").String(); finish != "" {
+				state.finish = finish
+			}
+		}
+	}
+	if !seen {
+		return nil, fmt.Errorf("no chat completion chunks received")
+	}
+	for i, state := range choices {
+		choice := []byte(`{"message":{}}`)
+		choice, _ = sjson.SetBytes(choice, "index", i)
+		choice, _ = sjson.SetBytes(choice, "message.role", state.role)
+		choice, _ = sjson.SetBytes(choice, "message.content", state.content.String())
+		if state.reasoning.Len() > 0 {
+			choice, _ = sjson.SetBytes(choice, "message.reasoning_content", state.reasoning.String())
+		}
+		if gjson.GetBytes(state.toolCalls, "#").Int() > 0 {
+			choice, _ = sjson.SetRawBytes(choice, "message.tool_calls", state.toolCalls)
+		}
+		if state.finish != "" {
+			choice, _ = sjson.SetBytes(choice, "finish_reason", state.finish)
+		} else {
+			choice, _ = sjson.SetRawBytes(choice, "finish_reason", []byte("null"))
+		}
+		out, _ = sjson.SetRawBytes(out, "choices.-1", choice)
+	}
+	return out, nil
+}
+
+// mergeToolCallDelta folds one streamed tool_calls entry into calls by its index.
+func mergeToolCallDelta(calls []byte, delta gjson.Result) []byte {
+	index := delta.Get("index").Int()
+	for int64(gjson.GetBytes(calls, "#").Int()) <= index {
+		calls, _ = sjson.SetRawBytes(calls, "-1", []byte(`{"type":"function","function":{"name":"","arguments":""}}`))
+	}
+	prefix := strconv.FormatInt(index, 10)
+	if id := delta.Get("id").String(); id != "" {
+		calls, _ = sjson.SetBytes(calls, prefix+".id", id)
+	}
+	if kind := delta.Get("type").String(); kind != "" {
+		calls, _ = sjson.SetBytes(calls, prefix+".type", kind)
+	}
+	if name := delta.Get("function.name").String(); name != "" {
+		calls, _ = sjson.SetBytes(calls, prefix+".function.name", gjson.GetBytes(calls, prefix+".function.name").String()+name)
+	}
+	if arguments := delta.Get("function.arguments").String(); arguments != "" {
+		calls, _ = sjson.SetBytes(calls, prefix+".function.arguments", gjson.GetBytes(calls, prefix+".function.arguments").String()+arguments)
+	}
+	return calls
+}
+
+// aggregateOpenAIResponses returns the response carried by the final Responses event.
+func aggregateOpenAIResponses(events []gjson.Result) ([]byte, error) {
+	for i := len(events) - 1; i >= 0; i-- {
+		switch events[i].Get("type").String() {
+		case "response.completed", "response.incomplete", "response.failed":
+			if response := events[i].Get("response"); response.IsObject() {
+				return []byte(response.Raw), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("stream ended without a response.completed event")
+}
+
+// aggregateClaudeMessages rebuilds a Messages response from message_start, the content
+// block events and message_delta.
+func aggregateClaudeMessages(events []gjson.Result) ([]byte, error) {
+	var out []byte
+	var partialJSON map[int64]*strings.Builder
+	for _, event := range events {
+		switch event.Get("type").String() {
+		case "message_start":
+			out = []byte(event.Get("message").Raw)
+			out, _ = sjson.SetRawBytes(out, "content", []byte(`[]`))
+			partialJSON = make(map[int64]*strings.Builder)
+		case "content_block_start":
+			if out == nil {
+				continue
+			}
+			index := event.Get("index").Int()
+			for gjson.GetBytes(out, "content.#").Int() <= index {
+				out, _ = sjson.SetRawBytes(out, "content.-1", []byte(`{}`))
+			}
+			out, _ = sjson.SetRawBytes(out, fmt.Sprintf("content.%d", index), []byte(event.Get("content_block").Raw))
+		case "content_block_delta":
+			if out == nil {
+				continue
+			}
+			index := event.Get("index").Int()
+			path := fmt.Sprintf("content.%d.", index)
+			delta := event.Get("delta")
+			switch delta.Get("type").String() {
+			case "text_delta":
+				out, _ = sjson.SetBytes(out, path+"text", gjson.GetBytes(out, path+"text").String()+delta.Get("text").String())
+			case "thinking_delta":
+				out, _ = sjson.SetBytes(out, path+"thinking", gjson.GetBytes(out, path+"thinking").String()+delta.Get("thinking").String())
+			case "signature_delta":
+				out, _ = sjson.SetBytes(out, path+"signature", delta.Get("signature").String())
+			case "input_json_delta":
+				if partialJSON[index] == nil {
+					partialJSON[index] = &strings.Builder{}
+				}
+				partialJSON[index].WriteString(delta.Get("partial_json").String())
+			}
+		case "content_block_stop":
+			index := event.Get("index").Int()
+			if input := partialJSON[index]; out != nil && input != nil && gjson.Valid(input.String()) {
+				out, _ = sjson.SetRawBytes(out, fmt.Sprintf("content.%d.input", index), []byte(input.String()))
+			}
+		case "message_delta":
+			if out == nil {
+				continue
+			}
+			event.Get("delta").ForEach(func(key, value gjson.Result) bool {
+				out, _ = sjson.SetRawBytes(out, key.String(), []byte(value.Raw))
+				return true
+			})
+			event.Get("usage").ForEach(func(key, value gjson.Result) bool {
+				out, _ = sjson.SetRawBytes(out, "usage."+key.String(), []byte(value.Raw))
+				return true
+			})
+		}
+	}
+	if out == nil {
+		return nil, fmt.Errorf("stream ended without a message_start event")
+	}
+	return out, nil
+}
+
+// aggregateGemini merges streamed GenerateContentResponse chunks: the text parts of each
+// candidate are joined, other parts are kept in order and the last finish reason and
+// usage win.
+func aggregateGemini(events []gjson.Result) ([]byte, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no response chunks received")
+	}
+	out := []byte(`{"candidates":[]}`)
+	for _, event := range events {
+		for _, key := range []string{"usageMetadata", "modelVersion", "responseId", "promptFeedback"} {
+			if value := event.Get(key); value.Exists() {
+				out, _ = sjson.SetRawBytes(out, key, []byte(value.Raw))
+			}
+		}
+		for i, candidate := range event.Get("candidates").Array() {
+			index := int(candidate.Get("index").Int())
+			if !candidate.Get("index").Exists() {
+				index = i
+			}
+			for int(gjson.GetBytes(out, "candidates.#").Int()) <= index {
+				out, _ = sjson.SetRawBytes(out, "candidates.-1", []byte(`{"content":{"role":"model","parts":[]}}`))
+			}
+			path := fmt.Sprintf("candidates.%d.", index)
+			out, _ = sjson.SetBytes(out, path+"index", index)
+			candidate.ForEach(func(key, value gjson.Result) bool {
+				if key.String() != "content" && key.String() != "index" {
+					out, _ = sjson.SetRawBytes(out, path+key.String(), []byte(value.Raw))
+				}
+				return true
+			})
+			for _, part := range candidate.Get("content.parts").Array() {
+				count := gjson.GetBytes(out, path+"content.parts.#").Int()
+				last := gjson.GetBytes(out, fmt.Sprintf("%scontent.parts.%d", path, count-1))
+				text := part.Get("text")
+				if count > 0 && text.Exists() && len(part.Map()) == 1 && last.Get("text").Exists() && len(last.Map()) == 1 {
+					out, _ = sjson.SetBytes(out, fmt.Sprintf("%scontent.parts.%d.text", path, count-1), last.Get("text").String()+text.String())
+					continue
+				}
+				out, _ = sjson.SetRawBytes(out, path+"content.parts.-1", []byte(part.Raw))
+			}
+		}
+	}
+	return out, nil
+}
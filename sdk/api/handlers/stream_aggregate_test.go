@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// streamOnlyExecutor answers streaming requests with chat completion chunks and rejects
+// non-streaming ones.
+type streamOnlyExecutor struct {
+	failOnceStreamExecutor
+}
+
+func (e *streamOnlyExecutor) Identifier() string { return "stream-only-test" }
+
+func (e *streamOnlyExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	chunks := []string{
+		`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get","arguments":"{\"a\""}}]}}]}`,
+		`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":":1}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}`,
+	}
+	ch := make(chan coreexecutor.StreamChunk, len(chunks))
+	for _, chunk := range chunks {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestExecuteWithAuthManager_AggregatesStreamOnlyProvider(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&streamOnlyExecutor{})
+	auth := &coreauth.Auth{ID: "stream-only-auth", Provider: "stream-only-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "stream-only-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	cfg := &sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{StreamOnlyProviders: []string{"stream-only-test"}}}
+	handler := NewBaseAPIHandlers(cfg, manager)
+	resp, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "stream-only-model", []byte(`{"stream":false}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "object").String(); got != "chat.completion" {
+		t.Fatalf("object = %q in %s", got, resp)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != "Hello" {
+		t.Fatalf("content = %q", got)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.tool_calls.0.function.arguments").String(); got != `{"a":1}` {
+		t.Fatalf("tool call arguments = %q in %s", got, resp)
+	}
+	if gjson.GetBytes(resp, "choices.0.finish_reason").String() != "tool_calls" || gjson.GetBytes(resp, "usage.total_tokens").Int() != 7 {
+		t.Fatalf("finish reason or usage lost: %s", resp)
+	}
+
+	ctx := bestOfContext(map[string]string{StreamAggregateHeader: "true"})
+	handler = NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	if resp, errMsg = handler.ExecuteWithAuthManager(ctx, "openai", "stream-only-model", []byte(`{"stream":true}`), ""); errMsg != nil {
+		t.Fatalf("header request failed: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != "Hello" {
+		t.Fatalf("header request content = %q", got)
+	}
+}
+
+func TestAggregateClaudeMessages(t *testing.T) {
+	chunk := []byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\",\"content\":[],\"model\":\"claude\",\"usage\":{\"input_tokens\":5,\"output_tokens\":1}}}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi there\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"tu_1\",\"name\":\"get\",\"input\":{}}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"a\\\":\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"1}\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":1}\n\n" +
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\",\"stop_sequence\":null},\"usage\":{\"output_tokens\":9}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+
+	out, err := aggregateClaudeMessages(streamEventPayloads(chunk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gjson.GetBytes(out, "content.0.text").String() != "Hi there" || gjson.GetBytes(out, "content.1.input.a").Int() != 1 {
+		t.Fatalf("content = %s", out)
+	}
+	if gjson.GetBytes(out, "stop_reason").String() != "tool_use" || gjson.GetBytes(out, "usage.output_tokens").Int() != 9 || gjson.GetBytes(out, "usage.input_tokens").Int() != 5 {
+		t.Fatalf("stop reason or usage = %s", out)
+	}
+}
+
+func TestAggregateGeminiAndResponses(t *testing.T) {
+	gemini, err := aggregateGemini([]gjson.Result{
+		gjson.Parse(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}`),
+		gjson.Parse(`{"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"totalTokenCount":4}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gjson.GetBytes(gemini, "candidates.0.content.parts.0.text").String() != "Hello" || gjson.GetBytes(gemini, "candidates.0.finishReason").String() != "STOP" || gjson.GetBytes(gemini, "usageMetadata.totalTokenCount").Int() != 4 {
+		t.Fatalf("gemini = %s", gemini)
+	}
+
+	events := streamEventPayloads([]byte("event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"r1\",\"status\":\"in_progress\"}}\n\nevent: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"r1\",\"status\":\"completed\"}}\n\n"))
+	responses, err := aggregateOpenAIResponses(events)
+	if err != nil || gjson.GetBytes(responses, "status").String() != "completed" {
+		t.Fatalf("responses = %s, %v", responses, err)
+	}
+	if _, err = aggregateOpenAIResponses(events[:1]); err == nil {
+		t.Fatal("a stream without response.completed must fail")
+	}
+}
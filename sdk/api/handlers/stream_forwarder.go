@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	log "github.com/sirupsen/logrus"
 )
 
 type StreamForwardOptions struct {
@@ -27,6 +28,18 @@ type StreamForwardOptions struct {
 	// WriteKeepAlive optionally writes a keep-alive heartbeat. It should not flush.
 	// When nil, a standard SSE comment heartbeat is used.
 	WriteKeepAlive func()
+
+	// WriteTruncated optionally writes a graceful stop (e.g. finish_reason "length") when the
+	// configured max output bytes is reached. When nil, WriteDone is used. It should not flush.
+	WriteTruncated func()
+
+	// WrittenBytes counts the upstream bytes the handler already wrote before calling
+	// ForwardStream, typically the peeked first chunk, toward max-output-bytes.
+	WrittenBytes int64
+
+	// TextCodec optionally describes the chunk format so the output sanitizer can repair
+	// streaming artifacts when it is enabled for the client key.
+	TextCodec *StreamTextCodec
 }
 
 func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, opts StreamForwardOptions) {
@@ -61,6 +74,39 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 		keepAliveC = keepAlive.C
 	}
 
+	apiKey := apiKeyFromGin(c)
+	maxOutputBytes := StreamingMaxOutputBytes(h.Cfg, apiKey)
+	written := opts.WrittenBytes
+
+	var sanitizer *outputSanitizer
+	if opts.TextCodec != nil && StreamingSanitizeOutput(h.Cfg, apiKey) {
+		sanitizer = newOutputSanitizer(*opts.TextCodec)
+	}
+	writeRepair := func() {
+		if sanitizer == nil {
+			return
+		}
+		for _, repair := range sanitizer.finish() {
+			writeChunk(repair)
+		}
+	}
+
+	truncate := func() {
+		log.Warnf("stream output reached max-output-bytes (%d >= %d), terminating upstream", written, maxOutputBytes)
+		writeRepair()
+		if opts.WriteTruncated != nil {
+			opts.WriteTruncated()
+		} else if opts.WriteDone != nil {
+			opts.WriteDone()
+		}
+		flusher.Flush()
+		cancel(nil)
+	}
+	if maxOutputBytes > 0 && written >= maxOutputBytes {
+		truncate()
+		return
+	}
+
 	var terminalErr *interfaces.ErrorMessage
 	for {
 		select {
@@ -79,6 +125,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 					default:
 					}
 				}
+				writeRepair()
 				if terminalErr != nil {
 					if opts.WriteTerminalError != nil {
 						opts.WriteTerminalError(terminalErr)
@@ -94,7 +141,18 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 				cancel(nil)
 				return
 			}
-			writeChunk(chunk)
+			if sanitizer != nil {
+				for _, out := range sanitizer.process(chunk) {
+					writeChunk(out)
+				}
+			} else {
+				writeChunk(chunk)
+			}
+			written += int64(len(chunk))
+			if maxOutputBytes > 0 && written >= maxOutputBytes {
+				truncate()
+				return
+			}
 			flusher.Flush()
 		case errMsg, ok := <-errs:
 			if !ok {
@@ -102,6 +160,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 			}
 			if errMsg != nil {
 				terminalErr = errMsg
+				writeRepair()
 				if opts.WriteTerminalError != nil {
 					opts.WriteTerminalError(errMsg)
 					flusher.Flush()
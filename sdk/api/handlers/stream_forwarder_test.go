@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestForwardStream_TruncatesAtMaxOutputBytes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("apiKey", "tenant-a")
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{
+			MaxOutputBytes:       1 << 20,
+			MaxOutputBytesPerKey: map[string]int64{"tenant-a": 8},
+		},
+	}, nil)
+
+	data := make(chan []byte, 3)
+	data <- []byte("hello")
+	data <- []byte("world")
+	data <- []byte("never")
+	errs := make(chan *interfaces.ErrorMessage)
+
+	var cancelled bool
+	var cancelErr error
+	h.ForwardStream(c, recorder, func(err error) { cancelled, cancelErr = true, err }, data, errs, StreamForwardOptions{
+		WriteChunk:     func(chunk []byte) { _, _ = c.Writer.Write(chunk) },
+		WriteDone:      func() { _, _ = c.Writer.WriteString("[DONE]") },
+		WriteTruncated: func() { _, _ = c.Writer.WriteString("[LENGTH]") },
+	})
+
+	if !cancelled || cancelErr != nil {
+		t.Fatalf("expected clean cancel, got cancelled=%v err=%v", cancelled, cancelErr)
+	}
+	body := recorder.Body.String()
+	if body != "helloworld[LENGTH]" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if strings.Contains(body, "never") {
+		t.Fatal("expected chunks after the cap to be dropped")
+	}
+}
+
+func TestForwardStream_CountsWrittenBytes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{MaxOutputBytes: 8},
+	}, nil)
+
+	// The handler already wrote a 5-byte first chunk, so the next chunk reaches the cap.
+	data := make(chan []byte, 2)
+	data <- []byte("world")
+	data <- []byte("never")
+	h.ForwardStream(c, recorder, func(error) {}, data, make(chan *interfaces.ErrorMessage), StreamForwardOptions{
+		WriteChunk:     func(chunk []byte) { _, _ = c.Writer.Write(chunk) },
+		WriteTruncated: func() { _, _ = c.Writer.WriteString("[LENGTH]") },
+		WrittenBytes:   5,
+	})
+	if body := recorder.Body.String(); body != "world[LENGTH]" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestStreamingMaxOutputBytes(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{
+		MaxOutputBytes:       100,
+		MaxOutputBytesPerKey: map[string]int64{"unlimited": 0},
+	}}
+	if got := StreamingMaxOutputBytes(cfg, "other"); got != 100 {
+		t.Fatalf("expected global cap, got %d", got)
+	}
+	if got := StreamingMaxOutputBytes(cfg, "unlimited"); got != 0 {
+		t.Fatalf("expected per-key override to disable cap, got %d", got)
+	}
+	if got := StreamingMaxOutputBytes(nil, ""); got != 0 {
+		t.Fatalf("expected nil config to disable cap, got %d", got)
+	}
+}
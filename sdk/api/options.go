@@ -35,6 +35,11 @@ func WithLocalManagementPassword(password string) ServerOption {
 	return internalapi.WithLocalManagementPassword(password)
 }
 
+// WithReadOnly pins read-only mode on for the lifetime of the server.
+func WithReadOnly() ServerOption {
+	return internalapi.WithReadOnly()
+}
+
 // WithKeepAliveEndpoint enables a keep-alive endpoint with the provided timeout and callback.
 func WithKeepAliveEndpoint(timeout time.Duration, onTimeout func()) ServerOption {
 	return internalapi.WithKeepAliveEndpoint(timeout, onTimeout)
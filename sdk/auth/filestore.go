@@ -13,6 +13,7 @@ import (
 	"time"
 
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 // FileTokenStore persists token records and auth metadata using the filesystem as backing storage.
@@ -128,6 +129,7 @@ func (s *FileTokenStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 		}
 		auth, err := s.readAuthFile(path, dir)
 		if err != nil {
+			log.WithError(err).Warnf("auth filestore: skip auth %s", path)
 			return nil
 		}
 		if auth != nil {
@@ -180,6 +182,17 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 	if err = json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("unmarshal auth json: %w", err)
 	}
+	upgraded, err := cliproxyauth.LoadMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if upgraded {
+		if raw, errMarshal := json.Marshal(metadata); errMarshal == nil {
+			if errWrite := os.WriteFile(path, raw, 0o600); errWrite != nil {
+				log.WithError(errWrite).Warnf("auth filestore: persist upgraded %s failed", path)
+			}
+		}
+	}
 	provider, _ := metadata["type"].(string)
 	if provider == "" {
 		provider = "unknown"
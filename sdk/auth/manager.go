@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -44,8 +45,12 @@ func (m *Manager) SetStore(store coreauth.Store) {
 }
 
 // Login executes the provider login flow and persists the resulting auth record.
+// Authenticators passed to the manager take precedence over those added with Register.
 func (m *Manager) Login(ctx context.Context, provider string, cfg *config.Config, opts *LoginOptions) (*coreauth.Auth, string, error) {
 	auth, ok := m.authenticators[provider]
+	if !ok {
+		auth, ok = Lookup(provider)
+	}
 	if !ok {
 		return nil, "", fmt.Errorf("cliproxy auth: authenticator %s not registered", provider)
 	}
@@ -57,6 +62,9 @@ func (m *Manager) Login(ctx context.Context, provider string, cfg *config.Config
 	if record == nil {
 		return nil, "", fmt.Errorf("cliproxy auth: authenticator %s returned nil record", provider)
 	}
+	if record.Metadata != nil {
+		record.Metadata[coreauth.SessionStartedAtMetadataKey] = time.Now().UTC().Format(time.RFC3339)
+	}
 
 	if m.store == nil {
 		return record, "", nil
@@ -6,16 +6,23 @@ import (
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
+// builtinRefreshLeads are the refresh leads of the authenticators shipped with the SDK.
+var builtinRefreshLeads = map[string]func() Authenticator{
+	"codex":          func() Authenticator { return NewCodexAuthenticator() },
+	"claude":         func() Authenticator { return NewClaudeAuthenticator() },
+	"qwen":           func() Authenticator { return NewQwenAuthenticator() },
+	"iflow":          func() Authenticator { return NewIFlowAuthenticator() },
+	"gemini":         func() Authenticator { return NewGeminiAuthenticator() },
+	"gemini-cli":     func() Authenticator { return NewGeminiAuthenticator() },
+	"antigravity":    func() Authenticator { return NewAntigravityAuthenticator() },
+	"kiro":           func() Authenticator { return NewKiroAuthenticator() },
+	"github-copilot": func() Authenticator { return NewGitHubCopilotAuthenticator() },
+}
+
 func init() {
-	registerRefreshLead("codex", func() Authenticator { return NewCodexAuthenticator() })
-	registerRefreshLead("claude", func() Authenticator { return NewClaudeAuthenticator() })
-	registerRefreshLead("qwen", func() Authenticator { return NewQwenAuthenticator() })
-	registerRefreshLead("iflow", func() Authenticator { return NewIFlowAuthenticator() })
-	registerRefreshLead("gemini", func() Authenticator { return NewGeminiAuthenticator() })
-	registerRefreshLead("gemini-cli", func() Authenticator { return NewGeminiAuthenticator() })
-	registerRefreshLead("antigravity", func() Authenticator { return NewAntigravityAuthenticator() })
-	registerRefreshLead("kiro", func() Authenticator { return NewKiroAuthenticator() })
-	registerRefreshLead("github-copilot", func() Authenticator { return NewGitHubCopilotAuthenticator() })
+	for provider, factory := range builtinRefreshLeads {
+		registerRefreshLead(provider, factory)
+	}
 }
 
 func registerRefreshLead(provider string, factory func() Authenticator) {
@@ -30,3 +37,13 @@ func registerRefreshLead(provider string, factory func() Authenticator) {
 		return auth.RefreshLead()
 	})
 }
+
+// unregisterRefreshLead drops the refresh lead of a registered authenticator, restoring
+// the built-in one when the provider ships with the SDK.
+func unregisterRefreshLead(provider string) {
+	if factory, ok := builtinRefreshLeads[provider]; ok {
+		registerRefreshLead(provider, factory)
+		return
+	}
+	cliproxyauth.UnregisterRefreshLeadProvider(provider)
+}
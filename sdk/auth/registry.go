@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu     sync.RWMutex
+	authenticators = make(map[string]Authenticator)
+)
+
+// Register makes an authenticator available to every Manager under the given provider
+// identifier, so embedders can add login flows for providers the SDK does not ship.
+// When provider is empty the authenticator's own Provider() value is used. Registering
+// the same provider again replaces the previous authenticator.
+func Register(provider string, authenticator Authenticator) {
+	if authenticator == nil {
+		return
+	}
+	key := normalizeProvider(provider)
+	if key == "" {
+		key = normalizeProvider(authenticator.Provider())
+	}
+	if key == "" {
+		return
+	}
+	registryMu.Lock()
+	authenticators[key] = authenticator
+	registryMu.Unlock()
+	registerRefreshLead(key, func() Authenticator { return authenticator })
+}
+
+// Unregister removes a previously registered authenticator together with its refresh
+// lead; providers shipped with the SDK fall back to their built-in lead.
+func Unregister(provider string) {
+	key := normalizeProvider(provider)
+	registryMu.Lock()
+	_, ok := authenticators[key]
+	delete(authenticators, key)
+	registryMu.Unlock()
+	if ok {
+		unregisterRefreshLead(key)
+	}
+}
+
+// Lookup returns the authenticator registered for provider.
+func Lookup(provider string) (Authenticator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	a, ok := authenticators[normalizeProvider(provider)]
+	return a, ok
+}
+
+// RegisteredProviders lists the providers added through Register, sorted by name.
+func RegisteredProviders() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]string, 0, len(authenticators))
+	for provider := range authenticators {
+		out = append(out, provider)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func normalizeProvider(provider string) string {
+	return strings.ToLower(strings.TrimSpace(provider))
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type stubAuthenticator struct {
+	provider string
+	lead     time.Duration
+}
+
+func (s stubAuthenticator) Provider() string { return s.provider }
+
+func (s stubAuthenticator) Login(context.Context, *config.Config, *LoginOptions) (*coreauth.Auth, error) {
+	return nil, nil
+}
+
+func (s stubAuthenticator) RefreshLead() *time.Duration { return &s.lead }
+
+func TestUnregisterRemovesRefreshLead(t *testing.T) {
+	Register("", stubAuthenticator{provider: "Custom-Provider", lead: time.Hour})
+	if lead := coreauth.ProviderRefreshLead("custom-provider", nil); lead == nil || *lead != time.Hour {
+		t.Fatalf("registered lead = %v", lead)
+	}
+	Unregister("custom-provider")
+	if _, ok := Lookup("custom-provider"); ok {
+		t.Fatal("authenticator still registered")
+	}
+	if lead := coreauth.ProviderRefreshLead("custom-provider", nil); lead != nil {
+		t.Fatalf("lead after unregister = %v", *lead)
+	}
+}
+
+func TestUnregisterRestoresBuiltinRefreshLead(t *testing.T) {
+	builtin := coreauth.ProviderRefreshLead("claude", nil)
+	Register("claude", stubAuthenticator{provider: "claude", lead: 42 * time.Hour})
+	Unregister("claude")
+	restored := coreauth.ProviderRefreshLead("claude", nil)
+	if (builtin == nil) != (restored == nil) || (builtin != nil && *builtin != *restored) {
+		t.Fatalf("claude lead = %v, want %v", restored, builtin)
+	}
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// TokenImport carries OAuth tokens obtained outside the proxy, for scripted provisioning.
+type TokenImport struct {
+	Provider     string    `json:"provider"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	IDToken      string    `json:"id_token,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	ProjectID    string    `json:"project_id,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+}
+
+// ImportableProviders lists the providers accepted by BuildImportedAuth.
+var ImportableProviders = []string{"antigravity", "claude", "codex", "gemini", "qwen"}
+
+// BuildImportedAuth validates pre-supplied tokens and returns an auth record whose
+// metadata matches the token file the provider's interactive login would write. The
+// access token may be omitted; the record is then written as already expired so the
+// auth manager refreshes it from the refresh token before first use.
+func BuildImportedAuth(in TokenImport) (*coreauth.Auth, error) {
+	provider := normalizeProvider(in.Provider)
+	if provider == "gemini-cli" {
+		provider = "gemini"
+	}
+	supported := false
+	for _, p := range ImportableProviders {
+		if p == provider {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("cliproxy auth: token import not supported for provider %q (supported: %s)", in.Provider, strings.Join(ImportableProviders, ", "))
+	}
+
+	accessToken := strings.TrimSpace(in.AccessToken)
+	refreshToken := strings.TrimSpace(in.RefreshToken)
+	idToken := strings.TrimSpace(in.IDToken)
+	email := strings.TrimSpace(in.Email)
+	projectID := strings.TrimSpace(in.ProjectID)
+	if refreshToken == "" {
+		return nil, fmt.Errorf("cliproxy auth: refresh token is required so the credential can be renewed")
+	}
+	if strings.ContainsAny(accessToken+refreshToken+idToken, " \t\r\n") {
+		return nil, fmt.Errorf("cliproxy auth: tokens must not contain whitespace")
+	}
+
+	var accountID string
+	if provider == "codex" && idToken != "" {
+		claims, err := codex.ParseJWTToken(idToken)
+		if err != nil {
+			return nil, fmt.Errorf("cliproxy auth: invalid codex id token: %w", err)
+		}
+		if email == "" {
+			email = claims.GetUserEmail()
+		}
+		accountID = claims.CodexAuthInfo.ChatgptAccountID
+	}
+	if provider == "gemini" && projectID == "" {
+		return nil, fmt.Errorf("cliproxy auth: project id is required for gemini")
+	}
+
+	now := time.Now()
+	expired := ""
+	switch {
+	case accessToken == "":
+		expired = now.Add(-time.Minute).Format(time.RFC3339)
+	case !in.ExpiresAt.IsZero():
+		expired = in.ExpiresAt.Format(time.RFC3339)
+	}
+
+	metadata := map[string]any{"type": provider, coreauth.SchemaVersionKey: coreauth.CurrentSchemaVersion}
+	if email != "" {
+		metadata["email"] = email
+	}
+	switch provider {
+	case "gemini":
+		token := map[string]any{
+			"access_token":  accessToken,
+			"refresh_token": refreshToken,
+			"token_type":    "Bearer",
+		}
+		if expired != "" {
+			token["expiry"] = expired
+		}
+		metadata["token"] = token
+		metadata["project_id"] = projectID
+		metadata["auto"] = false
+		metadata["checked"] = false
+	default:
+		metadata["access_token"] = accessToken
+		metadata["refresh_token"] = refreshToken
+		metadata["last_refresh"] = now.Format(time.RFC3339)
+		metadata["expired"] = expired
+		switch provider {
+		case "claude", "codex":
+			metadata["id_token"] = idToken
+			if provider == "codex" {
+				metadata["account_id"] = accountID
+			}
+		case "antigravity":
+			metadata["timestamp"] = now.UnixMilli()
+			if projectID != "" {
+				metadata["project_id"] = projectID
+			}
+		}
+	}
+
+	fileName := importedTokenFileName(provider, email, projectID, refreshToken)
+	label := email
+	if label == "" {
+		label = provider
+	}
+	return &coreauth.Auth{
+		ID:       fileName,
+		Provider: provider,
+		FileName: fileName,
+		Label:    label,
+		Status:   coreauth.StatusActive,
+		Metadata: metadata,
+	}, nil
+}
+
+// importedTokenFileName mirrors the file names used by interactive logins. Without an
+// email a short digest of the refresh token keeps names stable and distinct.
+func importedTokenFileName(provider, email, projectID, refreshToken string) string {
+	if email == "" {
+		sum := sha256.Sum256([]byte(refreshToken))
+		email = hex.EncodeToString(sum[:4])
+	}
+	switch provider {
+	case "antigravity":
+		return sanitizeAntigravityFileName(email)
+	case "gemini":
+		return fmt.Sprintf("%s-%s.json", email, projectID)
+	default:
+		return fmt.Sprintf("%s-%s.json", provider, email)
+	}
+}
+
+// Import validates pre-supplied tokens and persists them like a completed login.
+func (m *Manager) Import(ctx context.Context, in TokenImport, cfg *config.Config) (*coreauth.Auth, string, error) {
+	record, err := BuildImportedAuth(in)
+	if err != nil {
+		return nil, "", err
+	}
+	if m.store == nil {
+		return record, "", nil
+	}
+	if cfg != nil {
+		if dirSetter, ok := m.store.(interface{ SetBaseDir(string) }); ok {
+			dirSetter.SetBaseDir(cfg.AuthDir)
+		}
+	}
+	savedPath, err := m.store.Save(ctx, record)
+	if err != nil {
+		return record, "", err
+	}
+	return record, savedPath, nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildImportedAuth(t *testing.T) {
+	record, err := BuildImportedAuth(TokenImport{Provider: "Claude", AccessToken: "at", RefreshToken: "rt", Email: "a@b.c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record.FileName != "claude-a@b.c.json" || record.Provider != "claude" {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+	if record.Metadata["type"] != "claude" || record.Metadata["refresh_token"] != "rt" {
+		t.Fatalf("unexpected metadata: %+v", record.Metadata)
+	}
+
+	gemini, err := BuildImportedAuth(TokenImport{Provider: "gemini", AccessToken: "at", RefreshToken: "rt", Email: "a@b.c", ProjectID: "p1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gemini.FileName != "a@b.c-p1.json" {
+		t.Fatalf("gemini file name = %q", gemini.FileName)
+	}
+	if token, ok := gemini.Metadata["token"].(map[string]any); !ok || token["refresh_token"] != "rt" {
+		t.Fatalf("gemini token = %+v", gemini.Metadata["token"])
+	}
+
+	pending, err := BuildImportedAuth(TokenImport{Provider: "qwen", RefreshToken: "rt"})
+	if err != nil {
+		t.Fatalf("refresh-token-only import: %v", err)
+	}
+	if expiry, ok := pending.ExpirationTime(); !ok || expiry.After(time.Now()) {
+		t.Fatalf("import without access token must be due for refresh, expiry = %v %v", expiry, ok)
+	}
+	pendingGemini, err := BuildImportedAuth(TokenImport{Provider: "gemini", RefreshToken: "rt", ProjectID: "p1"})
+	if err != nil {
+		t.Fatalf("gemini refresh-token-only import: %v", err)
+	}
+	if expiry, ok := pendingGemini.ExpirationTime(); !ok || expiry.After(time.Now()) {
+		t.Fatalf("gemini import without access token must be due for refresh, expiry = %v %v", expiry, ok)
+	}
+
+	invalid := []TokenImport{
+		{Provider: "unknown", RefreshToken: "rt"},
+		{Provider: "claude", AccessToken: "at"},
+		{Provider: "gemini", RefreshToken: "rt"},
+		{Provider: "codex", RefreshToken: "rt", IDToken: "not-a-jwt"},
+		{Provider: "qwen", RefreshToken: "r t"},
+	}
+	for _, in := range invalid {
+		if _, err = BuildImportedAuth(in); err == nil {
+			t.Errorf("expected error for %+v", in)
+		}
+	}
+}
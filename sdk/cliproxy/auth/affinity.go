@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// defaultAffinityTTL is how long a conversation stays pinned when the config sets no TTL.
+	defaultAffinityTTL = time.Hour
+	// defaultAffinityMaxEntries caps the conversations remembered when the config sets no cap.
+	defaultAffinityMaxEntries = 10000
+)
+
+type affinityContextKey struct{}
+
+// affinityStore maps conversation keys to the credential that served them last. The zero
+// value has affinity disabled.
+type affinityStore struct {
+	mu      sync.Mutex
+	cfg     internalconfig.AffinityConfig
+	entries map[string]affinityEntry
+}
+
+type affinityEntry struct {
+	authID  string
+	expires time.Time
+}
+
+// SetAffinity replaces the sticky selection settings. Disabling affinity forgets every pin.
+func (m *Manager) SetAffinity(cfg internalconfig.AffinityConfig) {
+	if m == nil {
+		return
+	}
+	s := &m.affinity
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg.Models = append([]string(nil), cfg.Models...)
+	s.cfg = cfg
+	if !cfg.Enabled {
+		s.entries = nil
+	}
+}
+
+// withAffinity records on ctx the conversation key of req when affinity applies to it.
+func (m *Manager) withAffinity(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) context.Context {
+	return m.withAffinityFor(ctx, inflight.FromContext(ctx).Tenant(), req, opts)
+}
+
+// withAffinityFor records on ctx the conversation key of req from tenant when affinity
+// applies to it.
+func (m *Manager) withAffinityFor(ctx context.Context, tenant string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) context.Context {
+	s := &m.affinity
+	s.mu.Lock()
+	cfg := s.cfg
+	s.mu.Unlock()
+	if !cfg.Enabled || (len(cfg.Models) > 0 && !matchesAnyModel(cfg.Models, req.Model)) {
+		return ctx
+	}
+	key := affinityKey(tenant, req, opts)
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, affinityContextKey{}, key)
+}
+
+// affinityKey identifies the conversation of req: by the client's session ID when it sent
+// one, otherwise by the prefix of the conversation. It returns "" when neither is found.
+func affinityKey(tenant string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) string {
+	identity := ""
+	if session, _ := opts.Metadata[cliproxyexecutor.SessionIDMetadataKey].(string); strings.TrimSpace(session) != "" {
+		identity = "session\x00" + strings.TrimSpace(session)
+	} else {
+		body := opts.OriginalRequest
+		if len(body) == 0 {
+			body = req.Payload
+		}
+		prefix := conversationPrefix(body)
+		if prefix == "" {
+			return ""
+		}
+		identity = "prefix\x00" + prefix
+	}
+	sum := sha256.Sum256([]byte(tenant + "\x00" + strings.ToLower(strings.TrimSpace(req.Model)) + "\x00" + identity))
+	return hex.EncodeToString(sum[:])
+}
+
+// conversationPrefix returns the part of a request that stays the same across the turns of
+// a conversation: the system prompt and the messages up to and including the first user
+// message. It understands the OpenAI chat, Responses, Claude and Gemini schemas.
+func conversationPrefix(body []byte) string {
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return ""
+	}
+	root := gjson.ParseBytes(body)
+	var b strings.Builder
+	for _, path := range []string{"system", "instructions", "systemInstruction", "system_instruction"} {
+		if v := root.Get(path); v.Exists() {
+			b.WriteString(v.Raw)
+			b.WriteByte('\x00')
+		}
+	}
+	for _, path := range []string{"messages", "input", "contents"} {
+		v := root.Get(path)
+		if !v.Exists() {
+			continue
+		}
+		if !v.IsArray() {
+			b.WriteString(v.Raw)
+			break
+		}
+		for _, message := range v.Array() {
+			b.WriteString(message.Raw)
+			b.WriteByte('\x00')
+			if role := message.Get("role").String(); role == "" || role == "user" {
+				break
+			}
+		}
+		break
+	}
+	return b.String()
+}
+
+// affinityPick returns the candidate the conversation on ctx is pinned to, if it is still
+// among candidates and not cooling down for model.
+func (m *Manager) affinityPick(ctx context.Context, candidates []*Auth, model string) *Auth {
+	key, _ := ctx.Value(affinityContextKey{}).(string)
+	if key == "" {
+		return nil
+	}
+	now := time.Now()
+	s := &m.affinity
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	s.mu.Unlock()
+	if !ok || now.After(entry.expires) {
+		return nil
+	}
+	for _, candidate := range candidates {
+		if candidate.ID != entry.authID {
+			continue
+		}
+		if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); blocked {
+			return nil
+		}
+		return candidate
+	}
+	return nil
+}
+
+// pinAffinity pins the conversation on ctx to authID.
+func (m *Manager) pinAffinity(ctx context.Context, authID string) {
+	key, _ := ctx.Value(affinityContextKey{}).(string)
+	if key == "" || authID == "" {
+		return
+	}
+	now := time.Now()
+	s := &m.affinity
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cfg.Enabled {
+		return
+	}
+	ttl := time.Duration(s.cfg.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = defaultAffinityTTL
+	}
+	maxEntries := s.cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultAffinityMaxEntries
+	}
+	if s.entries == nil {
+		s.entries = make(map[string]affinityEntry)
+	}
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= maxEntries {
+		s.evictLocked(now, maxEntries)
+	}
+	s.entries[key] = affinityEntry{authID: authID, expires: now.Add(ttl)}
+}
+
+// evictLocked drops the expired pins and, if that leaves no room, the one expiring first.
+// s.mu must be held.
+func (s *affinityStore) evictLocked(now time.Time, maxEntries int) {
+	oldestKey, oldest := "", time.Time{}
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	if oldestKey != "" && len(s.entries) >= maxEntries {
+		delete(s.entries, oldestKey)
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// recordingExecutor reports the credential serving each request.
+type recordingExecutor struct {
+	explainTestExecutor
+	served chan string
+}
+
+func (recordingExecutor) Identifier() string { return "affinity-test" }
+
+func (e recordingExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.served <- auth.ID
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestConversationPrefix(t *testing.T) {
+	first := conversationPrefix([]byte(`{"system":"be brief","messages":[{"role":"user","content":"hi"}]}`))
+	later := conversationPrefix([]byte(`{"system":"be brief","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"more"}]}`))
+	if first == "" || first != later {
+		t.Fatalf("the prefix changed between turns: %q vs %q", first, later)
+	}
+	openai := conversationPrefix([]byte(`{"messages":[{"role":"system","content":"s"},{"role":"user","content":"a"},{"role":"user","content":"b"}]}`))
+	if openai != `{"role":"system","content":"s"}`+"\x00"+`{"role":"user","content":"a"}`+"\x00" {
+		t.Fatalf("prefix = %q, want the system and first user message", openai)
+	}
+	if conversationPrefix([]byte(`{"model":"m"}`)) != "" {
+		t.Fatal("a request without messages has no prefix")
+	}
+}
+
+func TestAffinity_KeepsConversationOnOneAuth(t *testing.T) {
+	const model = "affinity-test-model"
+	exec := recordingExecutor{served: make(chan string, 1)}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(exec)
+	reg := registry.GetGlobalRegistry()
+	for _, id := range []string{"aff-a", "aff-b"} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "affinity-test"}); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		reg.RegisterClient(id, "affinity-test", []*registry.ModelInfo{{ID: model}})
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+	}
+	serve := func(body string, session string) string {
+		opts := cliproxyexecutor.Options{OriginalRequest: []byte(body)}
+		if session != "" {
+			opts.Metadata = map[string]any{cliproxyexecutor.SessionIDMetadataKey: session}
+		}
+		if _, err := m.Execute(context.Background(), []string{"affinity-test"}, cliproxyexecutor.Request{Model: model}, opts); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		return <-exec.served
+	}
+	turn := func(n int) string {
+		body := `{"messages":[{"role":"user","content":"start"}`
+		for i := 0; i < n; i++ {
+			body += `,{"role":"assistant","content":"ok"},{"role":"user","content":"next"}`
+		}
+		return body + `]}`
+	}
+
+	// Without affinity, round-robin alternates between the credentials.
+	if serve(turn(0), "") == serve(turn(1), "") {
+		t.Fatal("expected round-robin to alternate without affinity")
+	}
+
+	m.SetAffinity(internalconfig.AffinityConfig{Enabled: true})
+	pinned := serve(turn(0), "")
+	for n := 1; n < 4; n++ {
+		if got := serve(turn(n), ""); got != pinned {
+			t.Fatalf("turn %d went to %s, want %s", n, got, pinned)
+		}
+	}
+	session := serve(`{"messages":[{"role":"user","content":"one"}]}`, "s-1")
+	if got := serve(`{"messages":[{"role":"user","content":"unrelated"}]}`, "s-1"); got != session {
+		t.Fatalf("session request went to %s, want %s", got, session)
+	}
+}
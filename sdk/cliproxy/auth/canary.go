@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultCanaryEvery routes roughly 1% of eligible requests to canary credentials.
+const defaultCanaryEvery = 100
+
+const webhookTimeout = 10 * time.Second
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// CanaryAlert describes a failure observed on a canary credential.
+type CanaryAlert struct {
+	AuthID     string    `json:"auth_id"`
+	Provider   string    `json:"provider"`
+	Label      string    `json:"label,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// CanaryAlertHook is an optional Hook extension notified when a canary credential fails.
+type CanaryAlertHook interface {
+	OnCanaryAlert(ctx context.Context, alert CanaryAlert)
+}
+
+// IsCanary reports whether the auth entry is marked as a canary credential.
+func IsCanary(auth *Auth) bool {
+	if auth == nil {
+		return false
+	}
+	if auth.Attributes != nil && strings.EqualFold(strings.TrimSpace(auth.Attributes["canary"]), "true") {
+		return true
+	}
+	if auth.Metadata != nil {
+		switch v := auth.Metadata["canary"].(type) {
+		case bool:
+			return v
+		case string:
+			return strings.EqualFold(strings.TrimSpace(v), "true")
+		}
+	}
+	return false
+}
+
+// SetCanaryConfig updates canary sampling and the optional alert webhook.
+// every <= 0 uses the default of one request in 100.
+func (m *Manager) SetCanaryConfig(every int, webhookURL string) {
+	if m == nil {
+		return
+	}
+	if every <= 0 {
+		every = defaultCanaryEvery
+	}
+	m.canaryEvery.Store(int64(every))
+	m.canaryWebhook.Store(strings.TrimSpace(webhookURL))
+}
+
+// SetCanarySplits replaces the per-model canary traffic percentages.
+func (m *Manager) SetCanarySplits(splits []internalconfig.CanarySplit) {
+	if m == nil {
+		return
+	}
+	m.canarySplits.Store(append([]internalconfig.CanarySplit(nil), splits...))
+}
+
+// canarySplitFor returns the first split matching model.
+func (m *Manager) canarySplitFor(model string) (internalconfig.CanarySplit, bool) {
+	splits, _ := m.canarySplits.Load().([]internalconfig.CanarySplit)
+	for _, split := range splits {
+		if matchesAnyModel([]string{split.Model}, model) {
+			return split, true
+		}
+	}
+	return internalconfig.CanarySplit{}, false
+}
+
+// filterCanaryCandidates narrows candidates so canary credentials only see a sampled
+// share of picks: the configured percentage for models with a canary split, every Nth
+// pick otherwise. The split is made over the credentials currently available for model,
+// so a cooling-down group never starves the request and canaries still back up regular
+// credentials that are all unavailable.
+func (m *Manager) filterCanaryCandidates(candidates []*Auth, model string) []*Auth {
+	return selectCanaryPartition(candidates, model, time.Now(), m.canarySampler(model))
+}
+
+// canarySampler returns the function deciding whether a pick for model is offered the
+// canary credentials. Each call advances the sampling counters.
+func (m *Manager) canarySampler(model string) func() bool {
+	return func() bool {
+		if split, ok := m.canarySplitFor(model); ok {
+			counter, _ := m.canarySplitCounters.LoadOrStore(split.Model, new(atomic.Int64))
+			return percentSampled(counter.(*atomic.Int64).Add(1), split.Percent)
+		}
+		every := m.canaryEvery.Load()
+		if every <= 0 {
+			every = defaultCanaryEvery
+		}
+		return m.canaryCounter.Add(1)%every == 0
+	}
+}
+
+// percentSampled reports whether the nth pick belongs to the sampled percent. Picks are
+// spread evenly: exactly floor(n*percent/100) of the first n picks are sampled.
+func percentSampled(n int64, percent float64) bool {
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+	return math.Floor(float64(n)*percent/100) > math.Floor(float64(n-1)*percent/100)
+}
+
+// CanaryCounts counts the results of one group of credentials for a model. Errors only
+// include failures that reflect on the credential (see isCanaryAlertStatus), not
+// malformed requests.
+type CanaryCounts struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// CanaryModelStats compares canary and regular credentials on one model.
+type CanaryModelStats struct {
+	Model   string       `json:"model"`
+	Canary  CanaryCounts `json:"canary"`
+	Regular CanaryCounts `json:"regular"`
+}
+
+// canaryStats accumulates results per model for canary and regular credentials.
+type canaryStats struct {
+	mu     sync.Mutex
+	models map[string]*CanaryModelStats
+}
+
+func (s *canaryStats) record(model string, canary, failed bool) {
+	if model == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.models == nil {
+		s.models = make(map[string]*CanaryModelStats)
+	}
+	stats := s.models[model]
+	if stats == nil {
+		stats = &CanaryModelStats{Model: model}
+		s.models[model] = stats
+	}
+	counts := &stats.Regular
+	if canary {
+		counts = &stats.Canary
+	}
+	counts.Requests++
+	if failed {
+		counts.Errors++
+	}
+}
+
+// CanaryStats returns, for each model canary credentials have served, the request and
+// error counts of canary and regular credentials since startup, sorted by model.
+func (m *Manager) CanaryStats() []CanaryModelStats {
+	if m == nil {
+		return nil
+	}
+	s := &m.canaryStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]CanaryModelStats, 0)
+	for _, stats := range s.models {
+		if stats.Canary.Requests == 0 {
+			continue
+		}
+		entry := *stats
+		for _, counts := range []*CanaryCounts{&entry.Canary, &entry.Regular} {
+			if counts.Requests > 0 {
+				counts.ErrorRate = float64(counts.Errors) / float64(counts.Requests)
+			}
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// selectCanaryPartition returns the candidates one pick may choose from. When both
+// regular and canary credentials are available, sampled decides which group is offered;
+// otherwise candidates are returned unchanged and the selector works with what is
+// available. sampled is only consulted when there is a real choice to make.
+func selectCanaryPartition(candidates []*Auth, model string, now time.Time, sampled func() bool) []*Auth {
+	var regular, canaries []*Auth
+	for _, candidate := range candidates {
+		if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); blocked {
+			continue
+		}
+		if IsCanary(candidate) {
+			canaries = append(canaries, candidate)
+		} else {
+			regular = append(regular, candidate)
+		}
+	}
+	if len(regular) == 0 || len(canaries) == 0 {
+		return candidates
+	}
+	if sampled() {
+		return canaries
+	}
+	return regular
+}
+
+// isCanaryAlertStatus reports whether a failure with status should raise a canary
+// alert. Request-shaped client errors (400, 404, 413, 422, ...) say nothing about the
+// credential; transport failures, 5xx and auth/quota rejections do.
+func isCanaryAlertStatus(status int) bool {
+	switch {
+	case status == 0, status >= http.StatusInternalServerError:
+		return true
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusTooManyRequests:
+		return true
+	default:
+		return false
+	}
+}
+
+// alertCanary logs the failure, notifies the hook and posts to the configured webhook.
+func (m *Manager) alertCanary(ctx context.Context, alert CanaryAlert) {
+	log.WithFields(log.Fields{
+		"auth_id":  alert.AuthID,
+		"provider": alert.Provider,
+		"label":    alert.Label,
+		"model":    alert.Model,
+		"status":   alert.StatusCode,
+	}).Errorf("canary credential failed: %s", alert.Message)
+
+	if hook, ok := m.hook.(CanaryAlertHook); ok {
+		hook.OnCanaryAlert(ctx, alert)
+	}
+
+	webhook, _ := m.canaryWebhook.Load().(string)
+	postWebhook(webhook, "canary alert", alert)
+}
+
+// postWebhook sends event as a JSON POST to webhook in the background. name labels
+// failures in the log. An empty webhook is a no-op.
+func postWebhook(webhook, name string, event any) {
+	if webhook == "" {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	go func() {
+		req, errReq := http.NewRequest(http.MethodPost, webhook, bytes.NewReader(payload))
+		if errReq != nil {
+			log.Warnf("%s webhook: %v", name, errReq)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, errDo := webhookClient.Do(req)
+		if errDo != nil {
+			log.Warnf("%s webhook: %v", name, errDo)
+			return
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			log.Warnf("%s webhook returned status %d", name, resp.StatusCode)
+		}
+	}()
+}
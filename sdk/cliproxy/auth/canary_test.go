@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+type canaryRecordingHook struct {
+	NoopHook
+	mu     sync.Mutex
+	alerts []CanaryAlert
+}
+
+func (h *canaryRecordingHook) OnCanaryAlert(_ context.Context, alert CanaryAlert) {
+	h.mu.Lock()
+	h.alerts = append(h.alerts, alert)
+	h.mu.Unlock()
+}
+
+func TestFilterCanaryCandidates_SamplesCanaries(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetCanaryConfig(4, "")
+
+	regular := &Auth{ID: "regular"}
+	canary := &Auth{ID: "canary", Attributes: map[string]string{"canary": "true"}}
+	candidates := []*Auth{regular, canary}
+
+	canaryPicks := 0
+	for i := 0; i < 100; i++ {
+		filtered := m.filterCanaryCandidates(candidates, "")
+		if len(filtered) != 1 {
+			t.Fatalf("expected a single candidate group, got %d", len(filtered))
+		}
+		if filtered[0] == canary {
+			canaryPicks++
+		}
+	}
+	if canaryPicks != 25 {
+		t.Fatalf("expected 25 canary picks, got %d", canaryPicks)
+	}
+
+	only := []*Auth{canary}
+	if got := m.filterCanaryCandidates(only, ""); len(got) != 1 || got[0] != canary {
+		t.Fatal("expected canary-only candidates to be returned unchanged")
+	}
+}
+
+func TestFilterCanaryCandidates_FallsBackToAvailableGroup(t *testing.T) {
+	const model = "canary-model"
+	m := NewManager(nil, nil, nil)
+	m.SetCanaryConfig(1, "")
+
+	cooling := map[string]*ModelState{model: {Unavailable: true, NextRetryAfter: time.Now().Add(time.Hour)}}
+	regular := &Auth{ID: "regular"}
+	canary := &Auth{ID: "canary", Attributes: map[string]string{"canary": "true"}, ModelStates: cooling}
+
+	// Every pick is a canary turn, but the canary is cooling down.
+	got := m.filterCanaryCandidates([]*Auth{regular, canary}, model)
+	if _, err := getAvailableAuths(got, "claude", model, time.Now()); err != nil {
+		t.Fatalf("expected the regular credential to remain selectable: %v", err)
+	}
+
+	m.SetCanaryConfig(1000, "")
+	regular.ModelStates = cooling
+	canary.ModelStates = nil
+	got = m.filterCanaryCandidates([]*Auth{regular, canary}, model)
+	available, err := getAvailableAuths(got, "claude", model, time.Now())
+	if err != nil || len(available) != 1 || available[0] != canary {
+		t.Fatalf("expected the canary to back up cooling regular credentials, got %v, %v", available, err)
+	}
+}
+
+func TestMarkResult_CanaryFailureAlerts(t *testing.T) {
+	hook := &canaryRecordingHook{}
+	m := NewManager(nil, nil, hook)
+	if _, err := m.Register(context.Background(), &Auth{ID: "canary", Provider: "claude", Attributes: map[string]string{"canary": "true"}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	m.MarkResult(context.Background(), Result{AuthID: "canary", Provider: "claude", Success: true})
+	m.MarkResult(context.Background(), Result{
+		AuthID:   "canary",
+		Provider: "claude",
+		Error:    &Error{Message: "invalid request", HTTPStatus: 400},
+	})
+	m.MarkResult(context.Background(), Result{
+		AuthID:   "canary",
+		Provider: "claude",
+		Model:    "claude-sonnet-4",
+		Error:    &Error{Message: "token revoked", HTTPStatus: 401},
+	})
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if len(hook.alerts) != 1 {
+		t.Fatalf("expected one alert, got %d", len(hook.alerts))
+	}
+	if alert := hook.alerts[0]; alert.StatusCode != 401 || alert.Message != "token revoked" {
+		t.Fatalf("unexpected alert: %+v", alert)
+	}
+}
+
+func TestFilterCanaryCandidates_PercentSplit(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetCanaryConfig(1, "")
+	m.SetCanarySplits([]internalconfig.CanarySplit{{Model: "claude-sonnet-*", Percent: 5}, {Model: "gpt-*", Percent: 0}})
+
+	regular := &Auth{ID: "regular"}
+	canary := &Auth{ID: "canary", Attributes: map[string]string{"canary": "true"}}
+	picks := func(model string) int {
+		canaryPicks := 0
+		for i := 0; i < 200; i++ {
+			if filtered := m.filterCanaryCandidates([]*Auth{regular, canary}, model); filtered[0] == canary {
+				canaryPicks++
+			}
+		}
+		return canaryPicks
+	}
+	if got := picks("claude-sonnet-4"); got != 10 {
+		t.Fatalf("expected 5%% of 200 picks (10) for the split model, got %d", got)
+	}
+	if got := picks("gpt-5"); got != 0 {
+		t.Fatalf("a 0%% split must keep canaries out, got %d picks", got)
+	}
+	if got := picks("gemini-2.5-pro"); got != 200 {
+		t.Fatalf("models without a split use canary-every, got %d picks", got)
+	}
+}
+
+func TestCanaryStats(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	for _, auth := range []*Auth{
+		{ID: "canary", Provider: "claude", Attributes: map[string]string{"canary": "true"}},
+		{ID: "regular", Provider: "claude"},
+	} {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	const model = "claude-sonnet-4"
+	m.MarkResult(context.Background(), Result{AuthID: "canary", Provider: "claude", Model: model, Success: true})
+	m.MarkResult(context.Background(), Result{AuthID: "canary", Provider: "claude", Model: model, Error: &Error{Message: "overloaded", HTTPStatus: 529}})
+	m.MarkResult(context.Background(), Result{AuthID: "canary", Provider: "claude", Model: model, Error: &Error{Message: "bad request", HTTPStatus: 400}})
+	for i := 0; i < 4; i++ {
+		m.MarkResult(context.Background(), Result{AuthID: "regular", Provider: "claude", Model: model, Success: true})
+	}
+	m.MarkResult(context.Background(), Result{AuthID: "regular", Provider: "claude", Model: "other-model", Success: true})
+
+	stats := m.CanaryStats()
+	if len(stats) != 1 || stats[0].Model != model {
+		t.Fatalf("expected stats for the model canaries served only, got %+v", stats)
+	}
+	if c := stats[0].Canary; c.Requests != 3 || c.Errors != 1 || c.ErrorRate != 1.0/3 {
+		t.Fatalf("canary counts = %+v; a 400 must not count as an error", c)
+	}
+	if r := stats[0].Regular; r.Requests != 4 || r.Errors != 0 || r.ErrorRate != 0 {
+		t.Fatalf("regular counts = %+v", r)
+	}
+}
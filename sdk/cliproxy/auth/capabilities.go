@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// RerankExecutor is implemented by provider executors exposing a native rerank endpoint.
+// Requests and responses use the Cohere/Jina rerank shape.
+type RerankExecutor interface {
+	Rerank(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+// EmbeddingExecutor is implemented by provider executors exposing an embeddings endpoint.
+// Requests and responses use the OpenAI embeddings shape.
+type EmbeddingExecutor interface {
+	Embed(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+// ErrCodeNotSupported marks errors returned when no provider for a model offers a capability.
+const ErrCodeNotSupported = "not_supported"
+
+// capability describes an optional executor extension routed by the manager.
+type capability struct {
+	name     string
+	supports func(ProviderExecutor) bool
+	call     func(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+var rerankCapability = capability{
+	name: "rerank",
+	supports: func(executor ProviderExecutor) bool {
+		_, ok := executor.(RerankExecutor)
+		return ok
+	},
+	call: func(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return executor.(RerankExecutor).Rerank(ctx, auth, req, opts)
+	},
+}
+
+var embeddingCapability = capability{
+	name: "embeddings",
+	supports: func(executor ProviderExecutor) bool {
+		_, ok := executor.(EmbeddingExecutor)
+		return ok
+	},
+	call: func(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return executor.(EmbeddingExecutor).Embed(ctx, auth, req, opts)
+	},
+}
+
+// ExecuteRerank routes a rerank request to providers whose executor implements RerankExecutor.
+// An Error with code ErrCodeNotSupported is returned when none of the providers can rerank.
+func (m *Manager) ExecuteRerank(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return m.executeCapability(ctx, rerankCapability, providers, req, opts)
+}
+
+// ExecuteEmbed routes an embeddings request to providers whose executor implements EmbeddingExecutor.
+// An Error with code ErrCodeNotSupported is returned when none of the providers can embed.
+func (m *Manager) ExecuteEmbed(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return m.executeCapability(ctx, embeddingCapability, providers, req, opts)
+}
+
+// IsNotSupported reports whether err signals that no provider offers the requested capability.
+func IsNotSupported(err error) bool {
+	var authErr *Error
+	return errors.As(err, &authErr) && authErr.Code == ErrCodeNotSupported
+}
+
+func (m *Manager) executeCapability(ctx context.Context, c capability, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	normalized := m.providersWithCapability(m.normalizeProviders(providers), c)
+	if len(normalized) == 0 {
+		return cliproxyexecutor.Response{}, &Error{Code: ErrCodeNotSupported, Message: "no provider supports " + c.name + " for model " + req.Model, HTTPStatus: http.StatusNotImplemented}
+	}
+
+	retryTimes, maxWait := m.retrySettings()
+	attempts := retryTimes + 1
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		resp, errExec := m.executeCapabilityMixedOnce(ctx, normalized, req, opts, c)
+		if errExec == nil {
+			return resp, nil
+		}
+		lastErr = errExec
+		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, attempts, normalized, req.Model, maxWait)
+		if !shouldRetry {
+			break
+		}
+		if errWait := waitForCooldown(ctx, wait); errWait != nil {
+			return cliproxyexecutor.Response{}, errWait
+		}
+	}
+	return cliproxyexecutor.Response{}, lastErr
+}
+
+// providersWithCapability keeps the providers whose registered executor supports c.
+func (m *Manager) providersWithCapability(providers []string, c capability) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		if executor, ok := m.executors[strings.ToLower(strings.TrimSpace(provider))]; ok && c.supports(executor) {
+			out = append(out, provider)
+		}
+	}
+	return out
+}
+
+func (m *Manager) executeCapabilityMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, c capability) (cliproxyexecutor.Response, error) {
+	routeModel := req.Model
+	tried := make(map[string]struct{})
+	var lastErr error
+	for {
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		if errPick != nil {
+			if lastErr != nil {
+				return cliproxyexecutor.Response{}, lastErr
+			}
+			return cliproxyexecutor.Response{}, errPick
+		}
+
+		entry := logEntryWithRequestID(ctx)
+		debugLogAuthSelection(entry, auth, provider, req.Model)
+
+		tried[auth.ID] = struct{}{}
+		execCtx := ctx
+		if rt := m.roundTripperFor(auth); rt != nil {
+			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+		}
+		execReq := req
+		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
+		resp, errExec := c.call(execCtx, executor, auth, execReq, opts)
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		if errExec != nil {
+			result.Error = &Error{Message: errExec.Error()}
+			var se cliproxyexecutor.StatusError
+			if errors.As(errExec, &se) && se != nil {
+				result.Error.HTTPStatus = se.StatusCode()
+			}
+			if ra := retryAfterFromError(errExec); ra != nil {
+				result.RetryAfter = ra
+			}
+			m.MarkResult(execCtx, result)
+			lastErr = newProviderError(errExec, provider, auth.ID, routeModel)
+			continue
+		}
+		m.MarkResult(execCtx, result)
+		return resp, nil
+	}
+}
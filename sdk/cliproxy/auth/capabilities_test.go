@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type rerankTestExecutor struct {
+	explainTestExecutor
+	provider string
+}
+
+func (e rerankTestExecutor) Identifier() string { return e.provider }
+
+func (e rerankTestExecutor) Rerank(_ context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID + ":" + req.Model)}, nil
+}
+
+func TestExecuteRerank_RoutesToCapableProviders(t *testing.T) {
+	const model = "rerank-test-model"
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(explainTestExecutor{id: "explain-test"})
+	m.RegisterExecutor(rerankTestExecutor{provider: "rerank-test"})
+
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range []*Auth{{ID: "plain", Provider: "explain-test"}, {ID: "reranker", Provider: "rerank-test"}} {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+		reg.RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: model}})
+		id := auth.ID
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+	}
+
+	resp, err := m.ExecuteRerank(context.Background(), []string{"explain-test", "rerank-test"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("ExecuteRerank: %v", err)
+	}
+	if got := string(resp.Payload); got != "reranker:"+model {
+		t.Fatalf("payload = %q", got)
+	}
+
+	_, err = m.ExecuteRerank(context.Background(), []string{"explain-test"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if !IsNotSupported(err) {
+		t.Fatalf("expected not supported error, got %v", err)
+	}
+	if status := err.(*Error).StatusCode(); status != http.StatusNotImplemented {
+		t.Fatalf("status = %d", status)
+	}
+	if _, err = m.ExecuteEmbed(context.Background(), []string{"rerank-test"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{}); !IsNotSupported(err) {
+		t.Fatalf("expected embeddings to be unsupported, got %v", err)
+	}
+}
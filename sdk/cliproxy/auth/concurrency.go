@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// defaultConcurrencyMaxWait is how long a request waits for a free credential when the
+// concurrency config sets no wait.
+const defaultConcurrencyMaxWait = 30 * time.Second
+
+// concurrencyLimiter counts the requests in flight per credential and per provider. The
+// zero value has no limits.
+type concurrencyLimiter struct {
+	mu          sync.Mutex
+	cfg         internalconfig.ConcurrencyConfig
+	perAuth     map[string]int
+	perProvider map[string]int
+	// freed is closed, and replaced, whenever a slot is released.
+	freed chan struct{}
+}
+
+// SetConcurrency replaces the concurrency caps. Requests in flight keep their slots.
+func (m *Manager) SetConcurrency(cfg internalconfig.ConcurrencyConfig) {
+	if m == nil {
+		return
+	}
+	l := &m.concurrency
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	providers := make(map[string]int, len(cfg.Providers))
+	for provider, limit := range cfg.Providers {
+		providers[strings.ToLower(strings.TrimSpace(provider))] = limit
+	}
+	cfg.Providers = providers
+	l.cfg = cfg
+	l.notifyLocked()
+}
+
+// authLimit returns the cap of auth: its max_concurrency attribute, else the configured
+// per-auth default. 0 means unlimited. l.mu must be held.
+func (l *concurrencyLimiter) authLimit(auth *Auth) int {
+	if auth != nil && auth.Attributes != nil {
+		if raw := strings.TrimSpace(auth.Attributes["max_concurrency"]); raw != "" {
+			if limit, err := strconv.Atoi(raw); err == nil && limit > 0 {
+				return limit
+			}
+		}
+	}
+	return max(l.cfg.PerAuth, 0)
+}
+
+// fullLocked reports whether auth or its provider has no free slot. l.mu must be held.
+func (l *concurrencyLimiter) fullLocked(auth *Auth, provider string) bool {
+	if limit := l.authLimit(auth); limit > 0 && l.perAuth[auth.ID] >= limit {
+		return true
+	}
+	if limit := l.cfg.Providers[provider]; limit > 0 && l.perProvider[provider] >= limit {
+		return true
+	}
+	return false
+}
+
+// tryAcquire takes a slot on auth and its provider. The returned release function is
+// safe to call more than once.
+func (l *concurrencyLimiter) tryAcquire(auth *Auth, provider string) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fullLocked(auth, provider) {
+		return nil, false
+	}
+	if l.perAuth == nil {
+		l.perAuth = make(map[string]int)
+		l.perProvider = make(map[string]int)
+	}
+	l.perAuth[auth.ID]++
+	l.perProvider[provider]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.perAuth[auth.ID]--; l.perAuth[auth.ID] <= 0 {
+				delete(l.perAuth, auth.ID)
+			}
+			if l.perProvider[provider]--; l.perProvider[provider] <= 0 {
+				delete(l.perProvider, provider)
+			}
+			l.notifyLocked()
+		})
+	}, true
+}
+
+// freedChan returns the channel closed at the next release.
+func (l *concurrencyLimiter) freedChan() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.freed == nil {
+		l.freed = make(chan struct{})
+	}
+	return l.freed
+}
+
+// notifyLocked wakes the requests waiting for a slot. l.mu must be held.
+func (l *concurrencyLimiter) notifyLocked() {
+	if l.freed != nil {
+		close(l.freed)
+		l.freed = nil
+	}
+}
+
+// settings returns whether requests wait for a slot and for how long.
+func (l *concurrencyLimiter) settings() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	maxWait := time.Duration(l.cfg.MaxWaitSeconds) * time.Second
+	if maxWait <= 0 {
+		maxWait = defaultConcurrencyMaxWait
+	}
+	return !strings.EqualFold(strings.TrimSpace(l.cfg.OnLimit), internalconfig.ConcurrencyOnLimitReject), maxWait
+}
+
+// busyAuths adds to skip the credentials that are at their cap or whose provider is, and
+// returns how many it added.
+func (m *Manager) busyAuths(skip map[string]struct{}) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	l := &m.concurrency
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	added := 0
+	for id, auth := range m.auths {
+		if _, ok := skip[id]; ok || auth == nil {
+			continue
+		}
+		if l.fullLocked(auth, strings.ToLower(strings.TrimSpace(auth.Provider))) {
+			skip[id] = struct{}{}
+			added++
+		}
+	}
+	return added
+}
+
+// hasAvailable reports whether a credential outside tried could serve model, whether or
+// not it is at its concurrency cap. Unlike pickNextMixed it leaves the selector cursors
+// and canary counters alone. It applies the availability rules of the built-in selectors.
+func (m *Manager) hasAvailable(providers []string, model string, tried map[string]struct{}) bool {
+	providerSet := normalizeProviderSet(providers)
+	if len(providerSet) == 0 {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	modelKey := strings.TrimSpace(model)
+	_, err := getAvailableAuths(m.mixedCandidatesLocked(providerSet, modelKey, tried), "mixed", modelKey, time.Now())
+	return err == nil
+}
+
+// pickWithCapacity picks the next credential like pickNextMixed, passing over credentials
+// and providers at their concurrency cap, and reserves a slot on it. When every eligible
+// credential is busy it waits for a slot if wait is set and the config queues, and fails
+// with 429 otherwise. The returned release function frees the slot.
+func (m *Manager) pickWithCapacity(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}, wait bool) (*Auth, ProviderExecutor, string, func(), error) {
+	var deadline <-chan time.Time
+	for {
+		freed := m.concurrency.freedChan()
+		skip := make(map[string]struct{}, len(tried))
+		for id := range tried {
+			skip[id] = struct{}{}
+		}
+		busy := m.busyAuths(skip)
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, model, opts, skip)
+		if errPick == nil {
+			if release, ok := m.concurrency.tryAcquire(auth, provider); ok {
+				m.pinAffinity(ctx, auth.ID)
+				return auth, executor, provider, release, nil
+			}
+			// Another request took the last slot since busyAuths looked; pick again.
+			continue
+		}
+		if busy == 0 {
+			return nil, nil, "", nil, errPick
+		}
+		if !m.hasAvailable(providers, model, tried) {
+			// The busy credentials do not serve this request either.
+			return nil, nil, "", nil, errPick
+		}
+
+		queue, maxWait := m.concurrency.settings()
+		limited := &Error{Code: "concurrency_limited", Message: fmt.Sprintf("every credential for %s is at its concurrency limit", model), Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+		if !queue || !wait {
+			return nil, nil, "", nil, limited
+		}
+		if deadline == nil {
+			timer := time.NewTimer(maxWait)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-freed:
+		case <-deadline:
+			return nil, nil, "", nil, limited
+		case <-ctx.Done():
+			return nil, nil, "", nil, ctx.Err()
+		}
+	}
+}
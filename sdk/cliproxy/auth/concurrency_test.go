@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// blockingExecutor reports each request it starts and holds it until gate is signalled.
+type blockingExecutor struct {
+	explainTestExecutor
+	started chan string
+	gate    chan struct{}
+}
+
+func (blockingExecutor) Identifier() string { return "concurrency-test" }
+
+func (e blockingExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.started <- auth.ID
+	select {
+	case <-e.gate:
+	case <-ctx.Done():
+	}
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestConcurrencyCaps(t *testing.T) {
+	const model = "concurrency-test-model"
+	exec := blockingExecutor{started: make(chan string, 4), gate: make(chan struct{})}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(exec)
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range []*Auth{
+		{ID: "conc-a", Provider: "concurrency-test"},
+		{ID: "conc-b", Provider: "concurrency-test", Attributes: map[string]string{"max_concurrency": "1"}},
+	} {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		reg.RegisterClient(auth.ID, "concurrency-test", []*registry.ModelInfo{{ID: model}})
+		id := auth.ID
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+	}
+	m.SetConcurrency(internalconfig.ConcurrencyConfig{PerAuth: 1, OnLimit: internalconfig.ConcurrencyOnLimitReject})
+
+	execute := func() chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := m.Execute(context.Background(), []string{"concurrency-test"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+			done <- err
+		}()
+		return done
+	}
+	first, second := execute(), execute()
+	if a, b := <-exec.started, <-exec.started; a == b {
+		t.Fatalf("both requests went to %s despite its cap of 1", a)
+	}
+
+	cursor := func() int {
+		selector := m.selector.(*RoundRobinSelector)
+		selector.mu.Lock()
+		defer selector.mu.Unlock()
+		return selector.cursors["mixed:"+model]
+	}
+	before := cursor()
+	err := <-execute()
+	var limited *Error
+	if !errors.As(err, &limited) || limited.Code != "concurrency_limited" || limited.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429 concurrency_limited error in reject mode, got %v", err)
+	}
+	if after := cursor(); after != before {
+		t.Fatalf("a rejected request moved the round-robin cursor from %d to %d", before, after)
+	}
+	_, err = m.ExecuteCount(context.Background(), []string{"concurrency-test"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if !errors.As(err, &limited) || limited.Code != "concurrency_limited" {
+		t.Fatalf("expected count_tokens to respect the caps, got %v", err)
+	}
+
+	m.SetConcurrency(internalconfig.ConcurrencyConfig{PerAuth: 1, MaxWaitSeconds: 5})
+	third := execute()
+	select {
+	case id := <-exec.started:
+		t.Fatalf("a queued request started on %s before a slot was freed", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+	exec.gate <- struct{}{}
+	select {
+	case <-exec.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the queued request did not start after a slot was freed")
+	}
+	close(exec.gate)
+	for _, done := range []chan error{first, second, third} {
+		if err := <-done; err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,9 +18,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // ProviderExecutor defines the contract required by Manager to execute provider calls.
@@ -123,6 +127,36 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// Canary sampling and alerting state.
+	canaryEvery   atomic.Int64
+	canaryCounter atomic.Int64
+	canaryWebhook atomic.Value
+	// Per-model canary percentages (see SetCanarySplits), their pick counters keyed by
+	// split model, and the per-model results of canary and regular credentials.
+	canarySplits        atomic.Value
+	canarySplitCounters sync.Map
+	canaryStats         canaryStats
+
+	// Size-based provider preferences (see SetSizeRouting).
+	sizeRouting atomic.Value
+
+	// Conversation pins for sticky selection (see SetAffinity).
+	affinity affinityStore
+
+	// Stream hedging settings (see SetHedging).
+	hedging atomic.Value
+
+	// Requests in flight per credential and provider, and their caps (see SetConcurrency).
+	concurrency concurrencyLimiter
+
+	// Session lifetime limits per provider and their event webhook.
+	sessionLimits  atomic.Value
+	sessionWebhook atomic.Value
+
+	// Latest warm-up outcome per auth and model (see Warm).
+	warmupMu sync.Mutex
+	warmups  map[string]WarmupResult
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -207,6 +241,18 @@ func (m *Manager) UnregisterExecutor(provider string) {
 	m.mu.Unlock()
 }
 
+// ExecutorProviders returns the provider keys of the registered executors, sorted.
+func (m *Manager) ExecutorProviders() []string {
+	m.mu.RLock()
+	out := make([]string, 0, len(m.executors))
+	for provider := range m.executors {
+		out = append(out, provider)
+	}
+	m.mu.RUnlock()
+	sort.Strings(out)
+	return out
+}
+
 // Register inserts a new auth entry into the manager.
 func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	if auth == nil {
@@ -278,6 +324,8 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		attempts = 1
 	}
 
+	ctx = m.withSizeRoute(ctx, req)
+	ctx = m.withAffinity(ctx, req, opts)
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
 		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts)
@@ -348,6 +396,8 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		attempts = 1
 	}
 
+	ctx = m.withSizeRoute(ctx, req)
+	ctx = m.withAffinity(ctx, req, opts)
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
 		chunks, errStream := m.executeStreamMixedOnce(ctx, normalized, req, opts)
@@ -377,7 +427,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, release, errPick := m.pickWithCapacity(ctx, providers, routeModel, opts, tried, true)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -394,10 +444,14 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = withQuotaHint(execCtx)
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
+		execCtx, span := startAttemptSpan(execCtx, "cliproxy.execute", auth, provider, execReq.Model)
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		tracing.End(span, errExec)
+		release()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
@@ -409,7 +463,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
-			lastErr = errExec
+			lastErr = newProviderError(errExec, provider, auth.ID, routeModel)
 			continue
 		}
 		m.MarkResult(execCtx, result)
@@ -425,7 +479,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, release, errPick := m.pickWithCapacity(ctx, providers, routeModel, opts, tried, true)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -446,6 +500,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		release()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
@@ -457,7 +512,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
-			lastErr = errExec
+			lastErr = newProviderError(errExec, provider, auth.ID, routeModel)
 			continue
 		}
 		m.MarkResult(execCtx, result)
@@ -473,7 +528,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, release, errPick := m.pickWithCapacity(ctx, providers, routeModel, opts, tried, true)
 		if errPick != nil {
 			if lastErr != nil {
 				return nil, lastErr
@@ -481,55 +536,95 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			return nil, errPick
 		}
 
-		entry := logEntryWithRequestID(ctx)
-		debugLogAuthSelection(entry, auth, provider, req.Model)
-
-		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
-		execReq := req
-		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
-		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
-		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		attempt, errStream := m.startStreamAttempt(ctx, auth, executor, provider, release, routeModel, req, opts, tried)
 		if errStream != nil {
-			rerr := &Error{Message: errStream.Error()}
-			var se cliproxyexecutor.StatusError
-			if errors.As(errStream, &se) && se != nil {
-				rerr.HTTPStatus = se.StatusCode()
-			}
-			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
-			result.RetryAfter = retryAfterFromError(errStream)
-			m.MarkResult(execCtx, result)
 			lastErr = errStream
 			continue
 		}
-		out := make(chan cliproxyexecutor.StreamChunk)
-		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
+		if delay, ok := m.hedgeDelay(routeModel); ok {
+			attempt = hedgeStream(ctx, attempt, delay, func() (*streamAttempt, error) {
+				hedgeAuth, hedgeExecutor, hedgeProvider, hedgeRelease, errHedgePick := m.pickWithCapacity(ctx, providers, routeModel, opts, tried, false)
+				if errHedgePick != nil {
+					return nil, errHedgePick
+				}
+				return m.startStreamAttempt(ctx, hedgeAuth, hedgeExecutor, hedgeProvider, hedgeRelease, routeModel, req, opts, tried)
+			})
+		}
+
+		out := make(chan cliproxyexecutor.StreamChunk, opts.StreamTuning.ChannelBuffer())
+		go func(stream *streamAttempt) {
+			defer stream.cancel()
 			defer close(out)
+			streamCtx, streamAuth, streamProvider := stream.ctx, stream.auth, stream.provider
 			var failed bool
-			for chunk := range streamChunks {
+			var forwarded int
+			defer func() { stream.span.SetAttributes(attribute.Int("cliproxy.stream.chunks", forwarded)) }()
+			for chunk := range stream.resume() {
 				if chunk.Err != nil && !failed {
 					failed = true
+					stream.span.RecordError(chunk.Err)
+					stream.span.SetStatus(codes.Error, chunk.Err.Error())
 					rerr := &Error{Message: chunk.Err.Error()}
 					var se cliproxyexecutor.StatusError
 					if errors.As(chunk.Err, &se) && se != nil {
 						rerr.HTTPStatus = se.StatusCode()
 					}
 					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
+					chunk.Err = newProviderError(chunk.Err, streamProvider, streamAuth.ID, routeModel)
+				} else if chunk.Err == nil {
+					forwarded++
 				}
 				out <- chunk
 			}
 			if !failed {
 				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
 			}
-		}(execCtx, auth.Clone(), provider, chunks)
+		}(attempt)
 		return out, nil
 	}
 }
 
+// startStreamAttempt starts a stream for req on auth, holding the concurrency slot freed
+// by release until the stream is cancelled or ends. A stream that fails to start is
+// recorded against auth and returned as a provider error.
+func (m *Manager) startStreamAttempt(ctx context.Context, auth *Auth, executor ProviderExecutor, provider string, release func(), routeModel string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, tried map[string]struct{}) (*streamAttempt, error) {
+	entry := logEntryWithRequestID(ctx)
+	debugLogAuthSelection(entry, auth, provider, req.Model)
+
+	tried[auth.ID] = struct{}{}
+	execCtx, cancelCtx := context.WithCancel(ctx)
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+	execCtx = withQuotaHint(execCtx)
+	execReq := req
+	execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+	execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
+	execCtx, span := startAttemptSpan(execCtx, "cliproxy.execute_stream", auth, provider, execReq.Model)
+	cancel := func() {
+		cancelCtx()
+		release()
+		span.End()
+	}
+	chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+	if errStream != nil {
+		span.RecordError(errStream)
+		span.SetStatus(codes.Error, errStream.Error())
+		rerr := &Error{Message: errStream.Error()}
+		var se cliproxyexecutor.StatusError
+		if errors.As(errStream, &se) && se != nil {
+			rerr.HTTPStatus = se.StatusCode()
+		}
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
+		result.RetryAfter = retryAfterFromError(errStream)
+		m.MarkResult(execCtx, result)
+		cancel()
+		return nil, newProviderError(errStream, provider, auth.ID, routeModel)
+	}
+	return &streamAttempt{auth: auth.Clone(), provider: provider, ctx: execCtx, cancel: cancel, chunks: chunks, span: span}, nil
+}
+
 func (m *Manager) executeWithProvider(ctx context.Context, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	if provider == "" {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
@@ -555,6 +650,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = withQuotaHint(execCtx)
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
@@ -570,7 +666,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
-			lastErr = errExec
+			lastErr = newProviderError(errExec, provider, auth.ID, routeModel)
 			continue
 		}
 		m.MarkResult(execCtx, result)
@@ -618,7 +714,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
-			lastErr = errExec
+			lastErr = newProviderError(errExec, provider, auth.ID, routeModel)
 			continue
 		}
 		m.MarkResult(execCtx, result)
@@ -651,6 +747,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = withQuotaHint(execCtx)
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
@@ -664,10 +761,10 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
 			result.RetryAfter = retryAfterFromError(errStream)
 			m.MarkResult(execCtx, result)
-			lastErr = errStream
+			lastErr = newProviderError(errStream, provider, auth.ID, routeModel)
 			continue
 		}
-		out := make(chan cliproxyexecutor.StreamChunk)
+		out := make(chan cliproxyexecutor.StreamChunk, opts.StreamTuning.ChannelBuffer())
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
 			var failed bool
@@ -680,6 +777,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 						rerr.HTTPStatus = se.StatusCode()
 					}
 					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
+					chunk.Err = newProviderError(chunk.Err, streamProvider, streamAuth.ID, routeModel)
 				}
 				out <- chunk
 			}
@@ -917,10 +1015,25 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	suspendReason := ""
 	clearModelQuota := false
 	setModelQuota := false
+	var canaryAlert *CanaryAlert
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
+		m.canaryStats.record(result.Model, IsCanary(auth), !result.Success && isCanaryAlertStatus(statusCodeFromResult(result.Error)))
+		if !result.Success && IsCanary(auth) && isCanaryAlertStatus(statusCodeFromResult(result.Error)) {
+			canaryAlert = &CanaryAlert{
+				AuthID:     auth.ID,
+				Provider:   auth.Provider,
+				Label:      auth.Label,
+				Model:      result.Model,
+				StatusCode: statusCodeFromResult(result.Error),
+				Time:       now,
+			}
+			if result.Error != nil {
+				canaryAlert.Message = result.Error.Message
+			}
+		}
 
 		if result.Success {
 			if result.Model != "" {
@@ -935,6 +1048,16 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				auth.UpdatedAt = now
 				shouldResumeModel = true
 				clearModelQuota = true
+				if wait, exhausted := quotaHintFromContext(ctx); exhausted {
+					next := now.Add(wait)
+					state.Unavailable = true
+					state.NextRetryAfter = next
+					state.Quota = QuotaState{Exceeded: true, Reason: "quota", NextRecoverAt: next}
+					updateAggregatedAvailability(auth, now)
+					shouldResumeModel, clearModelQuota = false, false
+					shouldSuspendModel, setModelQuota = true, true
+					suspendReason = "quota"
+				}
 			} else {
 				clearAuthStateOnSuccess(auth, now)
 			}
@@ -1020,6 +1143,9 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	} else if shouldSuspendModel {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
+	if canaryAlert != nil {
+		m.alertCanary(ctx, *canaryAlert)
+	}
 
 	m.hook.OnResult(ctx, result)
 }
@@ -1321,6 +1447,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		m.mu.RUnlock()
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
+	candidates = m.filterCanaryCandidates(candidates, modelKey)
 	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
 	if errPick != nil {
 		m.mu.RUnlock()
@@ -1344,6 +1471,52 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 }
 
 func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
+	providerSet := normalizeProviderSet(providers)
+	if len(providerSet) == 0 {
+		return nil, nil, "", &Error{Code: "provider_not_found", Message: "no provider supplied"}
+	}
+
+	m.mu.RLock()
+	modelKey := strings.TrimSpace(model)
+	stages := m.routeStagesLocked(ctx, providerSet, modelKey, tried, m.canarySampler(modelKey))
+	if len(stages.candidates) == 0 {
+		m.mu.RUnlock()
+		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
+	}
+	selected := stages.pinned
+	if selected == nil {
+		var errPick error
+		selected, errPick = m.selector.Pick(ctx, "mixed", model, opts, stages.preferred)
+		if errPick != nil {
+			m.mu.RUnlock()
+			return nil, nil, "", errPick
+		}
+	}
+	if selected == nil {
+		m.mu.RUnlock()
+		return nil, nil, "", &Error{Code: "auth_not_found", Message: "selector returned no auth"}
+	}
+	providerKey := strings.TrimSpace(strings.ToLower(selected.Provider))
+	executor, okExecutor := m.executors[providerKey]
+	if !okExecutor {
+		m.mu.RUnlock()
+		return nil, nil, "", &Error{Code: "executor_not_found", Message: "executor not registered"}
+	}
+	authCopy := selected.Clone()
+	m.mu.RUnlock()
+	if !selected.indexAssigned {
+		m.mu.Lock()
+		if current := m.auths[authCopy.ID]; current != nil && !current.indexAssigned {
+			current.EnsureIndex()
+			authCopy = current.Clone()
+		}
+		m.mu.Unlock()
+	}
+	return authCopy, executor, providerKey, nil
+}
+
+// normalizeProviderSet returns the lowercased, non-empty providers as a set.
+func normalizeProviderSet(providers []string) map[string]struct{} {
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
 		p := strings.TrimSpace(strings.ToLower(provider))
@@ -1352,13 +1525,40 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		}
 		providerSet[p] = struct{}{}
 	}
-	if len(providerSet) == 0 {
-		return nil, nil, "", &Error{Code: "provider_not_found", Message: "no provider supplied"}
+	return providerSet
+}
+
+// routeStages records how a pick narrows down the credentials that may serve a request.
+type routeStages struct {
+	// candidates are the credentials of mixedCandidatesLocked.
+	candidates []*Auth
+	// offered are the candidates the canary split leaves.
+	offered []*Auth
+	// preferred are the offered candidates size routing leaves; the selector picks from them.
+	preferred []*Auth
+	// pinned is the preferred candidate the conversation is pinned to, which is used
+	// without consulting the selector.
+	pinned *Auth
+}
+
+// routeStagesLocked narrows down the credentials of providerSet for a request of model on
+// ctx, leaving out tried, the way pickNextMixed does before the selector picks. sampled
+// decides the canary split when there is a choice. m.mu must be held.
+func (m *Manager) routeStagesLocked(ctx context.Context, providerSet map[string]struct{}, model string, tried map[string]struct{}, sampled func() bool) routeStages {
+	stages := routeStages{candidates: m.mixedCandidatesLocked(providerSet, model, tried)}
+	if len(stages.candidates) == 0 {
+		return stages
 	}
+	stages.offered = selectCanaryPartition(stages.candidates, model, time.Now(), sampled)
+	stages.preferred = preferSizeRoute(ctx, stages.offered, model)
+	stages.pinned = m.affinityPick(ctx, stages.preferred, model)
+	return stages
+}
 
-	m.mu.RLock()
+// mixedCandidatesLocked returns the enabled credentials of providerSet that have an
+// executor and serve model, leaving out tried. m.mu must be held.
+func (m *Manager) mixedCandidatesLocked(providerSet map[string]struct{}, model string, tried map[string]struct{}) []*Auth {
 	candidates := make([]*Auth, 0, len(m.auths))
-	modelKey := strings.TrimSpace(model)
 	registryRef := registry.GetGlobalRegistry()
 	for _, candidate := range m.auths {
 		if candidate == nil || candidate.Disabled {
@@ -1377,41 +1577,12 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if _, ok := m.executors[providerKey]; !ok {
 			continue
 		}
-		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
+		if model != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, model) {
 			continue
 		}
 		candidates = append(candidates, candidate)
 	}
-	if len(candidates) == 0 {
-		m.mu.RUnlock()
-		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
-	}
-	selected, errPick := m.selector.Pick(ctx, "mixed", model, opts, candidates)
-	if errPick != nil {
-		m.mu.RUnlock()
-		return nil, nil, "", errPick
-	}
-	if selected == nil {
-		m.mu.RUnlock()
-		return nil, nil, "", &Error{Code: "auth_not_found", Message: "selector returned no auth"}
-	}
-	providerKey := strings.TrimSpace(strings.ToLower(selected.Provider))
-	executor, okExecutor := m.executors[providerKey]
-	if !okExecutor {
-		m.mu.RUnlock()
-		return nil, nil, "", &Error{Code: "executor_not_found", Message: "executor not registered"}
-	}
-	authCopy := selected.Clone()
-	m.mu.RUnlock()
-	if !selected.indexAssigned {
-		m.mu.Lock()
-		if current := m.auths[authCopy.ID]; current != nil && !current.indexAssigned {
-			current.EnsureIndex()
-			authCopy = current.Clone()
-		}
-		m.mu.Unlock()
-	}
-	return authCopy, executor, providerKey, nil
+	return candidates
 }
 
 func (m *Manager) persist(ctx context.Context, auth *Auth) error {
@@ -1476,6 +1647,9 @@ func (m *Manager) checkRefreshes(ctx context.Context) {
 	for _, a := range snapshot {
 		typ, _ := a.AccountInfo()
 		if typ != "api_key" {
+			if m.enforceSessionLimit(ctx, a, now) {
+				continue
+			}
 			if !m.shouldRefresh(a, now) {
 				continue
 			}
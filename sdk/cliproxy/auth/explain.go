@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Route decision reasons reported by ExplainRoute.
+const (
+	RouteReasonEligible         = "eligible"
+	RouteReasonDisabled         = "disabled"
+	RouteReasonNoExecutor       = "executor not registered"
+	RouteReasonModelUnsupported = "model not supported"
+	RouteReasonQuotaCooldown    = "quota cooldown"
+	RouteReasonErrorCooldown    = "error cooldown"
+	RouteReasonConcurrencyLimit = "at concurrency limit"
+	RouteReasonCanaryNotSampled = "canary (sampled traffic only)"
+	RouteReasonNotPreferred     = "not preferred by size routing"
+	RouteReasonNotPinned        = "conversation pinned to another credential"
+	RouteReasonLowerPriority    = "lower priority"
+)
+
+// RouteQuery describes the request ExplainRoute explains.
+type RouteQuery struct {
+	Model string
+	// Tokens is the estimated prompt size size routing rules compare against.
+	Tokens int
+	// Tenant is the tenant key of the client API key (see usage.TenantKey), which size
+	// routing conditions and conversation affinity see.
+	Tenant string
+	// SessionID is the client session (X-Session-ID) conversations are pinned by.
+	SessionID string
+}
+
+// RouteCandidate describes how one credential was evaluated for a hypothetical request.
+type RouteCandidate struct {
+	AuthID        string     `json:"auth_id"`
+	AuthIndex     string     `json:"auth_index,omitempty"`
+	Provider      string     `json:"provider"`
+	Label         string     `json:"label,omitempty"`
+	Priority      int        `json:"priority"`
+	Canary        bool       `json:"canary,omitempty"`
+	UpstreamModel string     `json:"upstream_model,omitempty"`
+	Eligible      bool       `json:"eligible"`
+	Reason        string     `json:"reason"`
+	RetryAt       *time.Time `json:"retry_at,omitempty"`
+}
+
+// RouteExplanation is the decision tree for routing a model to credentials.
+type RouteExplanation struct {
+	Model     string   `json:"model"`
+	Providers []string `json:"providers"`
+	// PreferredProviders are the providers a size routing rule prefers for the request.
+	PreferredProviders []string `json:"preferred_providers,omitempty"`
+	// Pinned is the credential the conversation is pinned to by affinity.
+	Pinned     string           `json:"pinned,omitempty"`
+	Candidates []RouteCandidate `json:"candidates"`
+	// Selectable lists the auth IDs the selector would rotate between for the next request.
+	Selectable []string `json:"selectable"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// ExplainRoute explains how a request described by q would be routed across providers,
+// without executing anything or advancing selector, canary or affinity state. It runs
+// the steps of a pick: concurrency caps, the credential pool, a regular (non-sampled)
+// canary turn, size routing and affinity, then the availability rules of the built-in
+// selectors; a custom selector may choose differently among the selectable credentials.
+func (m *Manager) ExplainRoute(ctx context.Context, providers []string, q RouteQuery) RouteExplanation {
+	out := RouteExplanation{Model: q.Model, Providers: m.normalizeProviders(providers)}
+	if len(out.Providers) == 0 {
+		out.Error = "no provider serves this model"
+		return out
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	providerSet := normalizeProviderSet(out.Providers)
+	modelKey := strings.TrimSpace(q.Model)
+	req := cliproxyexecutor.Request{Model: modelKey}
+	var opts cliproxyexecutor.Options
+	if session := strings.TrimSpace(q.SessionID); session != "" {
+		opts.Metadata = map[string]any{cliproxyexecutor.SessionIDMetadataKey: session}
+	}
+	ctx = m.withSizeRouteFor(ctx, modelKey, q.Tokens, q.Tenant)
+	ctx = m.withAffinityFor(ctx, q.Tenant, req, opts)
+	out.PreferredProviders, _ = ctx.Value(sizeRouteContextKey{}).([]string)
+
+	// Skip busy credentials the way pickWithCapacity does.
+	busy := make(map[string]struct{})
+	m.busyAuths(busy)
+
+	now := time.Now()
+	registryRef := registry.GetGlobalRegistry()
+	m.mu.RLock()
+	stages := m.routeStagesLocked(ctx, providerSet, modelKey, busy, func() bool { return false })
+	var selectable []*Auth
+	if stages.pinned != nil {
+		out.Pinned = stages.pinned.ID
+		selectable = []*Auth{stages.pinned}
+	} else if len(stages.preferred) > 0 {
+		selectable, _ = getAvailableAuths(stages.preferred, "mixed", modelKey, now)
+	}
+	offered, preferred, selected := authIDSet(stages.offered), authIDSet(stages.preferred), authIDSet(selectable)
+
+	limited := false
+	for _, auth := range m.auths {
+		if auth == nil {
+			continue
+		}
+		providerKey := strings.TrimSpace(strings.ToLower(auth.Provider))
+		if _, ok := providerSet[providerKey]; !ok {
+			continue
+		}
+		candidate := RouteCandidate{
+			AuthID:    auth.ID,
+			AuthIndex: auth.Index,
+			Provider:  providerKey,
+			Label:     auth.Label,
+			Priority:  authPriority(auth),
+			Canary:    IsCanary(auth),
+		}
+		if routed, _ := rewriteModelForAuth(modelKey, nil, auth); routed != "" {
+			candidate.UpstreamModel = m.resolveOAuthUpstreamModel(auth, routed)
+			if candidate.UpstreamModel == "" {
+				candidate.UpstreamModel = routed
+			}
+		}
+		_, isBusy := busy[auth.ID]
+		_, isOffered := offered[auth.ID]
+		_, isPreferred := preferred[auth.ID]
+		_, isSelected := selected[auth.ID]
+		switch blocked, reason, next := isAuthBlockedForModel(auth, modelKey, now); {
+		case auth.Disabled:
+			candidate.Reason = RouteReasonDisabled
+		case m.executors[providerKey] == nil:
+			candidate.Reason = RouteReasonNoExecutor
+		case modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(auth.ID, modelKey):
+			candidate.Reason = RouteReasonModelUnsupported
+		case blocked:
+			switch reason {
+			case blockReasonDisabled:
+				candidate.Reason = RouteReasonDisabled
+			case blockReasonCooldown:
+				candidate.Reason = RouteReasonQuotaCooldown
+			default:
+				candidate.Reason = RouteReasonErrorCooldown
+			}
+			if !next.IsZero() {
+				retryAt := next
+				candidate.RetryAt = &retryAt
+			}
+		case isBusy:
+			candidate.Reason = RouteReasonConcurrencyLimit
+			limited = true
+		case !isOffered:
+			candidate.Reason = RouteReasonCanaryNotSampled
+		case !isPreferred:
+			candidate.Reason = RouteReasonNotPreferred
+		case stages.pinned != nil && !isSelected:
+			candidate.Reason = RouteReasonNotPinned
+		case !isSelected:
+			candidate.Reason = RouteReasonLowerPriority
+		default:
+			candidate.Eligible = true
+			candidate.Reason = RouteReasonEligible
+			out.Selectable = append(out.Selectable, auth.ID)
+		}
+		out.Candidates = append(out.Candidates, candidate)
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(out.Selectable)
+	sort.SliceStable(out.Candidates, func(i, j int) bool {
+		a, b := out.Candidates[i], out.Candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.AuthID < b.AuthID
+	})
+	if len(out.Selectable) == 0 {
+		out.Error = "no auth available"
+		if limited {
+			out.Error = "every available credential is at its concurrency limit"
+		}
+	}
+	return out
+}
+
+// authIDSet returns the IDs of auths as a set.
+func authIDSet(auths []*Auth) map[string]struct{} {
+	set := make(map[string]struct{}, len(auths))
+	for _, auth := range auths {
+		set[auth.ID] = struct{}{}
+	}
+	return set
+}
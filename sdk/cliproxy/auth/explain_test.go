@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type explainTestExecutor struct{ id string }
+
+func (e explainTestExecutor) Identifier() string { return e.id }
+
+func (explainTestExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (explainTestExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (explainTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (explainTestExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (explainTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestExplainRoute_ReportsDecisionPerCredential(t *testing.T) {
+	const model = "explain-test-model"
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(explainTestExecutor{id: "explain-test"})
+
+	now := time.Now()
+	auths := []*Auth{
+		{ID: "high", Provider: "explain-test", Attributes: map[string]string{"priority": "10"}},
+		{ID: "low", Provider: "explain-test"},
+		{ID: "off", Provider: "explain-test", Disabled: true},
+		{ID: "cooling", Provider: "explain-test", Attributes: map[string]string{"priority": "10"}, ModelStates: map[string]*ModelState{
+			model: {Unavailable: true, NextRetryAfter: now.Add(time.Hour), Quota: QuotaState{Exceeded: true}},
+		}},
+		{ID: "canary", Provider: "explain-test", Attributes: map[string]string{"priority": "10", "canary": "true"}},
+		{ID: "no-model", Provider: "explain-test"},
+	}
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range auths {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+		if auth.ID != "no-model" {
+			reg.RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: model}})
+			t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+		}
+	}
+
+	explanation := m.ExplainRoute(context.Background(), []string{"Explain-Test"}, RouteQuery{Model: model})
+	want := map[string]string{
+		"high":     RouteReasonEligible,
+		"low":      RouteReasonLowerPriority,
+		"off":      RouteReasonDisabled,
+		"cooling":  RouteReasonQuotaCooldown,
+		"canary":   RouteReasonCanaryNotSampled,
+		"no-model": RouteReasonModelUnsupported,
+	}
+	if len(explanation.Candidates) != len(want) {
+		t.Fatalf("expected %d candidates, got %d", len(want), len(explanation.Candidates))
+	}
+	for _, candidate := range explanation.Candidates {
+		if candidate.Reason != want[candidate.AuthID] {
+			t.Errorf("%s: reason %q, want %q", candidate.AuthID, candidate.Reason, want[candidate.AuthID])
+		}
+	}
+	if len(explanation.Selectable) != 1 || explanation.Selectable[0] != "high" {
+		t.Fatalf("unexpected selectable set %v", explanation.Selectable)
+	}
+	if explanation.Candidates[0].AuthID != "high" {
+		t.Fatalf("expected eligible candidate first, got %s", explanation.Candidates[0].AuthID)
+	}
+}
+
+func TestExplainRoute_FollowsConcurrencySizeRoutingAndAffinity(t *testing.T) {
+	const model = "explain-steps-model"
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(explainTestExecutor{id: "explain-large"})
+	m.RegisterExecutor(explainTestExecutor{id: "explain-fast"})
+	m.SetSizeRouting([]internalconfig.SizeRoutingRule{{
+		Models:                []string{model},
+		ThresholdTokens:       100,
+		FastProviders:         []string{"explain-fast"},
+		LargeContextProviders: []string{"explain-large"},
+	}})
+	m.SetAffinity(internalconfig.AffinityConfig{Enabled: true})
+
+	reg := registry.GetGlobalRegistry()
+	auths := map[string]*Auth{}
+	for _, auth := range []*Auth{
+		{ID: "large-a", Provider: "explain-large"},
+		{ID: "large-b", Provider: "explain-large"},
+		{ID: "large-busy", Provider: "explain-large", Attributes: map[string]string{"max_concurrency": "1"}},
+		{ID: "fast", Provider: "explain-fast"},
+	} {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+		reg.RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: model}})
+		t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+		auths[auth.ID] = auth
+	}
+	if _, ok := m.concurrency.tryAcquire(auths["large-busy"], "explain-large"); !ok {
+		t.Fatal("could not take the slot of large-busy")
+	}
+	providers := []string{"explain-large", "explain-fast"}
+	reasons := func(e RouteExplanation) map[string]string {
+		out := make(map[string]string, len(e.Candidates))
+		for _, c := range e.Candidates {
+			out[c.AuthID] = c.Reason
+		}
+		return out
+	}
+
+	small := m.ExplainRoute(context.Background(), providers, RouteQuery{Model: model, Tokens: 10})
+	if got := reasons(small); got["fast"] != RouteReasonEligible || got["large-a"] != RouteReasonNotPreferred || got["large-busy"] != RouteReasonConcurrencyLimit {
+		t.Fatalf("small prompt reasons = %v", got)
+	}
+
+	// Pin the session's conversation to large-b, as a served request would.
+	session := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.SessionIDMetadataKey: "s1"}}
+	m.pinAffinity(m.withAffinityFor(context.Background(), "tenant", cliproxyexecutor.Request{Model: model}, session), "large-b")
+	large := m.ExplainRoute(context.Background(), providers, RouteQuery{Model: model, Tokens: 1000, Tenant: "tenant", SessionID: "s1"})
+	got := reasons(large)
+	if got["large-b"] != RouteReasonEligible || got["large-a"] != RouteReasonNotPinned || got["fast"] != RouteReasonNotPreferred {
+		t.Fatalf("pinned large prompt reasons = %v", got)
+	}
+	if large.Pinned != "large-b" || len(large.Selectable) != 1 || len(large.PreferredProviders) != 1 {
+		t.Fatalf("unexpected explanation %+v", large)
+	}
+}
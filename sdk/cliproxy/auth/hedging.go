@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetHedging replaces the stream hedging settings.
+func (m *Manager) SetHedging(cfg internalconfig.HedgingConfig) {
+	if m == nil {
+		return
+	}
+	cfg.Models = append([]string(nil), cfg.Models...)
+	m.hedging.Store(cfg)
+}
+
+// hedgeDelay returns how long a stream for model may go without its first chunk before a
+// second request is sent, and false when model is not hedged.
+func (m *Manager) hedgeDelay(model string) (time.Duration, bool) {
+	cfg, _ := m.hedging.Load().(internalconfig.HedgingConfig)
+	if cfg.DelayMS <= 0 {
+		return 0, false
+	}
+	if len(cfg.Models) > 0 && !matchesAnyModel(cfg.Models, model) {
+		return 0, false
+	}
+	return time.Duration(cfg.DelayMS) * time.Millisecond, true
+}
+
+// streamAttempt is a stream started on one credential.
+type streamAttempt struct {
+	auth     *Auth
+	provider string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	chunks   <-chan cliproxyexecutor.StreamChunk
+	// span traces the attempt until cancel ends it.
+	span trace.Span
+
+	// first holds the first chunk once watchFirst has read it. Whoever receives from it
+	// puts the value back, so it can be read again.
+	first chan firstChunk
+}
+
+// firstChunk is the first read from a stream; ok is false when the stream ended empty.
+type firstChunk struct {
+	chunk cliproxyexecutor.StreamChunk
+	ok    bool
+}
+
+// healthy reports whether the stream produced output rather than failing or ending empty.
+func (f firstChunk) healthy() bool {
+	return f.ok && f.chunk.Err == nil
+}
+
+// watchFirst reads the first chunk of a in the background into a.first.
+func (a *streamAttempt) watchFirst() {
+	a.first = make(chan firstChunk, 1)
+	go func() {
+		chunk, ok := <-a.chunks
+		a.first <- firstChunk{chunk: chunk, ok: ok}
+	}()
+}
+
+// resume returns the chunks of a, including a first chunk read by watchFirst.
+func (a *streamAttempt) resume() <-chan cliproxyexecutor.StreamChunk {
+	if a.first == nil {
+		return a.chunks
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		first := <-a.first
+		if !first.ok {
+			return
+		}
+		out <- first.chunk
+		for chunk := range a.chunks {
+			out <- chunk
+		}
+	}()
+	return out
+}
+
+// abandon cancels a and drains its chunks so its executor can finish.
+func (a *streamAttempt) abandon() {
+	a.cancel()
+	go func() {
+		if first := <-a.first; first.ok {
+			for range a.chunks {
+			}
+		}
+	}()
+}
+
+// hedgeStream returns primary if it produces its first chunk within delay. Otherwise it
+// starts a second stream with startHedge (on another credential) and returns whichever of
+// the two produces a healthy first chunk first, cancelling the other. A stream failing
+// before its first chunk only wins when the other fails too.
+func hedgeStream(ctx context.Context, primary *streamAttempt, delay time.Duration, startHedge func() (*streamAttempt, error)) *streamAttempt {
+	primary.watchFirst()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case first := <-primary.first:
+		primary.first <- first
+		return primary
+	case <-ctx.Done():
+		return primary
+	case <-timer.C:
+	}
+
+	entry := logEntryWithRequestID(ctx)
+	hedge, errHedge := startHedge()
+	if errHedge != nil {
+		entry.Debugf("hedging: no first token from %s after %s and no second request possible: %v", primary.provider, delay, errHedge)
+		return primary
+	}
+	entry.Debugf("hedging: no first token from %s after %s, sent a second request to %s", primary.provider, delay, hedge.provider)
+	hedge.watchFirst()
+
+	primaryFirst, hedgeFirst := primary.first, hedge.first
+	for primaryFirst != nil || hedgeFirst != nil {
+		var got, other *streamAttempt
+		var first firstChunk
+		select {
+		case first = <-primaryFirst:
+			primaryFirst = nil
+			got, other = primary, hedge
+		case first = <-hedgeFirst:
+			hedgeFirst = nil
+			got, other = hedge, primary
+		}
+		got.first <- first
+		if first.healthy() {
+			other.abandon()
+			if got == hedge {
+				entry.Debugf("hedging: %s answered first, cancelled %s", hedge.provider, primary.provider)
+			}
+			return got
+		}
+	}
+	// Both failed before their first chunk; report the original request's failure.
+	hedge.abandon()
+	return primary
+}
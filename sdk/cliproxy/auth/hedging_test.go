@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// testAttempt returns an attempt that sends payload (or fails with err) after delay.
+func testAttempt(provider string, delay time.Duration, payload string, err error) *streamAttempt {
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(chunks)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		chunks <- cliproxyexecutor.StreamChunk{Payload: []byte(payload), Err: err}
+	}()
+	return &streamAttempt{auth: &Auth{ID: provider}, provider: provider, ctx: ctx, cancel: cancel, chunks: chunks}
+}
+
+func readAll(a *streamAttempt) string {
+	var out string
+	for chunk := range a.resume() {
+		out += string(chunk.Payload)
+	}
+	return out
+}
+
+func TestHedgeStream(t *testing.T) {
+	fast := testAttempt("fast", 0, "first", nil)
+	hedged := false
+	got := hedgeStream(context.Background(), fast, time.Second, func() (*streamAttempt, error) {
+		hedged = true
+		return nil, errors.New("unexpected")
+	})
+	if got != fast || hedged || readAll(got) != "first" {
+		t.Fatalf("a stream answering within the delay must not be hedged")
+	}
+
+	slow := testAttempt("slow", time.Minute, "slow", nil)
+	got = hedgeStream(context.Background(), slow, 10*time.Millisecond, func() (*streamAttempt, error) {
+		return testAttempt("hedge", 0, "hedge", nil), nil
+	})
+	if got.provider != "hedge" || readAll(got) != "hedge" {
+		t.Fatalf("winner = %s, want the hedge", got.provider)
+	}
+	if slow.ctx.Err() == nil {
+		t.Fatal("the slower stream was not cancelled")
+	}
+
+	failing := testAttempt("failing", 20*time.Millisecond, "", errors.New("overloaded"))
+	got = hedgeStream(context.Background(), failing, 10*time.Millisecond, func() (*streamAttempt, error) {
+		return testAttempt("hedge", 50*time.Millisecond, "hedge", nil), nil
+	})
+	if got.provider != "hedge" {
+		t.Fatalf("winner = %s, want the hedge over a stream that failed first", got.provider)
+	}
+
+	got = hedgeStream(context.Background(), testAttempt("primary", 20*time.Millisecond, "", errors.New("a")), 10*time.Millisecond, func() (*streamAttempt, error) {
+		return testAttempt("hedge", 0, "", errors.New("b")), nil
+	})
+	if got.provider != "primary" {
+		t.Fatalf("winner = %s, want the primary's failure when both fail", got.provider)
+	}
+}
+
+func TestHedgeDelay(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	if _, ok := m.hedgeDelay("claude-sonnet-4"); ok {
+		t.Fatal("hedging must be off by default")
+	}
+	m.SetHedging(internalconfig.HedgingConfig{DelayMS: 250, Models: []string{"claude-*"}})
+	if delay, ok := m.hedgeDelay("claude-sonnet-4"); !ok || delay != 250*time.Millisecond {
+		t.Fatalf("hedgeDelay = %v, %v", delay, ok)
+	}
+	if _, ok := m.hedgeDelay("gpt-5"); ok {
+		t.Fatal("a model outside the list was hedged")
+	}
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Failure classes of upstream errors returned by Manager. Match them with errors.Is and
+// read the provider, credential and retry metadata with errors.As on *ProviderError.
+var (
+	// ErrRateLimited reports a request or token rate limit (HTTP 429), including the
+	// case where every credential for the model is cooling down.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrQuotaExceeded reports a spent quota or balance (HTTP 402, or a 429 naming a quota).
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrAuthInvalid reports a credential the provider rejected (HTTP 401 or 403).
+	ErrAuthInvalid = errors.New("credential rejected")
+	// ErrProviderUnavailable reports a provider outage: a 5xx response or a failed connection.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+)
+
+// ProviderError is an upstream failure together with where it happened. Its message,
+// status code and headers are those of the executor error it wraps.
+type ProviderError struct {
+	// Kind is one of ErrRateLimited, ErrQuotaExceeded, ErrAuthInvalid and
+	// ErrProviderUnavailable, or nil for other failures such as rejected requests.
+	Kind error
+	// Provider is the provider key of the credential that failed.
+	Provider string
+	// AuthID identifies the credential that failed.
+	AuthID string
+	// Model is the client-facing model name that was routed.
+	Model string
+	// HTTPStatus is the upstream status code; 0 when no response was received.
+	HTTPStatus int
+	// RetryIn is the provider's retry hint; 0 when it gave none.
+	RetryIn time.Duration
+	// Err is the executor error.
+	Err error
+}
+
+// newProviderError wraps an executor error with routing metadata. Context cancellation
+// and errors that are already ProviderErrors are returned unchanged.
+func newProviderError(err error, provider, authID, model string) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var existing *ProviderError
+	if errors.As(err, &existing) {
+		return err
+	}
+	out := &ProviderError{Provider: provider, AuthID: authID, Model: model, HTTPStatus: statusCodeFromError(err), Err: err}
+	if ra := retryAfterFromError(err); ra != nil {
+		out.RetryIn = *ra
+	}
+	out.Kind = classifyProviderFailure(out.HTTPStatus, err.Error())
+	return out
+}
+
+// classifyProviderFailure maps an upstream status code to a failure class.
+func classifyProviderFailure(status int, message string) error {
+	switch {
+	case status == 0 || status >= http.StatusInternalServerError:
+		return ErrProviderUnavailable
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuthInvalid
+	case status == http.StatusPaymentRequired:
+		return ErrQuotaExceeded
+	case status == http.StatusTooManyRequests:
+		if strings.Contains(strings.ToLower(message), "quota") {
+			return ErrQuotaExceeded
+		}
+		return ErrRateLimited
+	default:
+		return nil
+	}
+}
+
+// Error returns the message of the wrapped executor error.
+func (e *ProviderError) Error() string {
+	if e == nil || e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+// Unwrap exposes both the failure class and the executor error to errors.Is and errors.As.
+func (e *ProviderError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// StatusCode returns the upstream status code.
+func (e *ProviderError) StatusCode() int {
+	if e == nil {
+		return 0
+	}
+	return e.HTTPStatus
+}
+
+// RetryAfter returns the provider's retry hint, or nil when it gave none.
+func (e *ProviderError) RetryAfter() *time.Duration {
+	if e == nil || e.RetryIn <= 0 {
+		return nil
+	}
+	retryIn := e.RetryIn
+	return &retryIn
+}
+
+// Headers returns the response headers the executor attached to its error, if any.
+func (e *ProviderError) Headers() http.Header {
+	if e == nil {
+		return nil
+	}
+	if he, ok := e.Err.(interface{ Headers() http.Header }); ok && he != nil {
+		return he.Headers()
+	}
+	return nil
+}
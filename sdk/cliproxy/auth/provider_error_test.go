@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type upstreamTestError struct {
+	status     int
+	msg        string
+	retryAfter *time.Duration
+}
+
+func (e upstreamTestError) Error() string              { return e.msg }
+func (e upstreamTestError) StatusCode() int            { return e.status }
+func (e upstreamTestError) RetryAfter() *time.Duration { return e.retryAfter }
+
+type rateLimitedExecutor struct {
+	explainTestExecutor
+}
+
+func (rateLimitedExecutor) Identifier() string { return "ratelimit-test" }
+
+func (rateLimitedExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	wait := 30 * time.Second
+	return cliproxyexecutor.Response{}, upstreamTestError{status: http.StatusTooManyRequests, msg: "slow down", retryAfter: &wait}
+}
+
+func TestExecute_ReturnsTypedProviderError(t *testing.T) {
+	const model = "ratelimit-test-model"
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(rateLimitedExecutor{})
+	if _, err := m.Register(context.Background(), &Auth{ID: "limited", Provider: "ratelimit-test"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("limited", "ratelimit-test", []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { reg.UnregisterClient("limited") })
+
+	_, err := m.Execute(context.Background(), []string{"ratelimit-test"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	var perr *ProviderError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ProviderError, got %T", err)
+	}
+	if perr.Provider != "ratelimit-test" || perr.AuthID != "limited" || perr.Model != model || perr.RetryIn != 30*time.Second {
+		t.Fatalf("unexpected metadata: %+v", perr)
+	}
+	if err.Error() != "slow down" || perr.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("message and status must come from the executor error: %q %d", err.Error(), perr.StatusCode())
+	}
+	var upstream upstreamTestError
+	if !errors.As(err, &upstream) {
+		t.Fatal("the executor error must stay reachable through errors.As")
+	}
+}
+
+func TestClassifyProviderFailure(t *testing.T) {
+	cases := []struct {
+		status int
+		msg    string
+		want   error
+	}{
+		{http.StatusUnauthorized, "", ErrAuthInvalid},
+		{http.StatusForbidden, "", ErrAuthInvalid},
+		{http.StatusPaymentRequired, "", ErrQuotaExceeded},
+		{http.StatusTooManyRequests, "You exceeded your current quota", ErrQuotaExceeded},
+		{http.StatusTooManyRequests, "rate limit", ErrRateLimited},
+		{http.StatusBadGateway, "", ErrProviderUnavailable},
+		{0, "connection refused", ErrProviderUnavailable},
+		{http.StatusBadRequest, "bad input", nil},
+	}
+	for _, tc := range cases {
+		if got := classifyProviderFailure(tc.status, tc.msg); got != tc.want {
+			t.Errorf("classifyProviderFailure(%d, %q) = %v, want %v", tc.status, tc.msg, got, tc.want)
+		}
+	}
+	if !errors.Is(newModelCooldownError("m", "p", time.Second), ErrRateLimited) {
+		t.Error("a model cooldown must match ErrRateLimited")
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// quotaHintKey carries the *quotaHint of one execution attempt.
+type quotaHintKey struct{}
+
+// quotaHint records a budget-exhaustion report made by an executor while serving a
+// request that otherwise succeeded.
+type quotaHint struct {
+	mu         sync.Mutex
+	retryAfter time.Duration
+}
+
+func withQuotaHint(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quotaHintKey{}, &quotaHint{})
+}
+
+// ReportQuotaExhausted lets a provider executor signal that the credential serving the
+// current request has no upstream budget left until retryAfter elapses, for example
+// when rate-limit headers report zero remaining requests. The request itself still
+// succeeds; when its result is recorded the manager takes the credential out of
+// rotation for the model until then, as it does after a 429. It is a no-op outside a
+// manager execution.
+func ReportQuotaExhausted(ctx context.Context, retryAfter time.Duration) {
+	if ctx == nil || retryAfter <= 0 {
+		return
+	}
+	hint, _ := ctx.Value(quotaHintKey{}).(*quotaHint)
+	if hint == nil {
+		return
+	}
+	hint.mu.Lock()
+	if retryAfter > hint.retryAfter {
+		hint.retryAfter = retryAfter
+	}
+	hint.mu.Unlock()
+}
+
+// quotaHintFromContext returns the wait reported through ReportQuotaExhausted, if any.
+func quotaHintFromContext(ctx context.Context) (time.Duration, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	hint, _ := ctx.Value(quotaHintKey{}).(*quotaHint)
+	if hint == nil {
+		return 0, false
+	}
+	hint.mu.Lock()
+	defer hint.mu.Unlock()
+	return hint.retryAfter, hint.retryAfter > 0
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMarkResult_QuotaHintSuspendsModel(t *testing.T) {
+	const model = "quota-hint-model"
+	m := NewManager(nil, nil, nil)
+	if _, err := m.Register(context.Background(), &Auth{ID: "quota-hint", Provider: "groq"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := withQuotaHint(context.Background())
+	ReportQuotaExhausted(ctx, 10*time.Second)
+	ReportQuotaExhausted(ctx, time.Minute)
+	ReportQuotaExhausted(ctx, 5*time.Second)
+	m.MarkResult(ctx, Result{AuthID: "quota-hint", Provider: "groq", Model: model, Success: true})
+
+	auth, ok := m.GetByID("quota-hint")
+	if !ok {
+		t.Fatal("auth not found")
+	}
+	state := auth.ModelStates[model]
+	if state == nil || !state.Unavailable || !state.Quota.Exceeded {
+		t.Fatalf("expected the model to be marked over quota, got %+v", state)
+	}
+	if wait := time.Until(state.NextRetryAfter); wait < 50*time.Second || wait > time.Minute {
+		t.Fatalf("NextRetryAfter in %s, want the longest reported wait", wait)
+	}
+
+	m.MarkResult(context.Background(), Result{AuthID: "quota-hint", Provider: "groq", Model: model, Success: true})
+	auth, _ = m.GetByID("quota-hint")
+	if state = auth.ModelStates[model]; state.Unavailable {
+		t.Fatal("a later success without a hint must restore the model")
+	}
+}
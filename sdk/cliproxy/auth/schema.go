@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SchemaVersionKey is the metadata field recording the auth file schema version.
+const SchemaVersionKey = "schema_version"
+
+// CurrentSchemaVersion is the auth file schema written by this release. Files without a
+// version field predate versioning and are treated as version 1.
+const CurrentSchemaVersion = 2
+
+// SchemaError reports an auth file that cannot be loaded with the current schema.
+type SchemaError struct {
+	// Provider is the credential type declared by the file, if any.
+	Provider string
+	// Version is the schema version declared by the file.
+	Version int
+	// Missing lists required fields that are absent or empty.
+	Missing []string
+	// Message describes failures other than missing fields.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	if e == nil {
+		return ""
+	}
+	if len(e.Missing) > 0 {
+		provider := e.Provider
+		if provider == "" {
+			provider = "auth"
+		}
+		return fmt.Sprintf("auth schema: %s credential missing required field(s): %s", provider, strings.Join(e.Missing, ", "))
+	}
+	return "auth schema: " + e.Message
+}
+
+// requiredAuthFields lists, per provider, the groups of fields an auth file must carry.
+// Each group is satisfied by any one non-empty field, so alternatives such as an iFlow
+// cookie or API key can stand in for each other.
+var requiredAuthFields = map[string][][]string{
+	"antigravity":    {{"refresh_token"}},
+	"claude":         {{"refresh_token"}},
+	"codex":          {{"refresh_token"}},
+	"gemini":         {{"token"}},
+	"github-copilot": {{"access_token"}},
+	"iflow":          {{"api_key", "cookie", "refresh_token"}},
+	"kiro":           {{"access_token", "refresh_token"}},
+	"qwen":           {{"refresh_token"}},
+	"vertex":         {{"service_account", "credential_source"}, {"project_id"}},
+}
+
+// UpgradeMetadata migrates auth file metadata in place to CurrentSchemaVersion. It
+// reports whether the metadata changed so callers can persist the upgraded form. A
+// *SchemaError is returned when the version is invalid or newer than this release.
+func UpgradeMetadata(metadata map[string]any) (bool, error) {
+	if metadata == nil {
+		return false, &SchemaError{Message: "auth file is empty"}
+	}
+	version, err := metadataSchemaVersion(metadata)
+	if err != nil {
+		return false, err
+	}
+	if version > CurrentSchemaVersion {
+		provider, _ := metadata["type"].(string)
+		return false, &SchemaError{
+			Provider: provider,
+			Version:  version,
+			Message:  fmt.Sprintf("schema version %d was written by a newer release (supported: %d)", version, CurrentSchemaVersion),
+		}
+	}
+
+	changed := false
+	if version < 2 {
+		changed = upgradeMetadataV1(metadata)
+	}
+	if version != CurrentSchemaVersion {
+		metadata[SchemaVersionKey] = CurrentSchemaVersion
+		changed = true
+	}
+	return changed, nil
+}
+
+// ValidateMetadata checks that upgraded metadata declares a type and carries the fields
+// its provider requires, returning a *SchemaError listing every missing field.
+func ValidateMetadata(metadata map[string]any) error {
+	provider, _ := metadata["type"].(string)
+	if strings.TrimSpace(provider) == "" {
+		return &SchemaError{Version: CurrentSchemaVersion, Missing: []string{"type"}}
+	}
+	var missing []string
+	for _, group := range requiredAuthFields[provider] {
+		if !hasAnyField(metadata, group) {
+			missing = append(missing, strings.Join(group, " or "))
+		}
+	}
+	if len(missing) > 0 {
+		return &SchemaError{Provider: provider, Version: CurrentSchemaVersion, Missing: missing}
+	}
+	return nil
+}
+
+// LoadMetadata upgrades metadata read from an auth file and validates the result.
+func LoadMetadata(metadata map[string]any) (bool, error) {
+	upgraded, err := UpgradeMetadata(metadata)
+	if err != nil {
+		return false, err
+	}
+	return upgraded, ValidateMetadata(metadata)
+}
+
+// metadataSchemaVersion reads the declared schema version, defaulting to 1.
+func metadataSchemaVersion(metadata map[string]any) (int, error) {
+	raw, ok := metadata[SchemaVersionKey]
+	if !ok || raw == nil {
+		return 1, nil
+	}
+	switch v := raw.(type) {
+	case float64:
+		if v >= 1 && v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case int:
+		if v >= 1 {
+			return v, nil
+		}
+	}
+	return 0, &SchemaError{Message: fmt.Sprintf("invalid %s %v", SchemaVersionKey, raw)}
+}
+
+// upgradeMetadataV1 rewrites layouts written before schema versioning.
+func upgradeMetadataV1(metadata map[string]any) bool {
+	changed := false
+
+	// The Codex CLI keeps tokens under a nested "tokens" object and has no type field.
+	if tokens, ok := metadata["tokens"].(map[string]any); ok {
+		if _, hasType := metadata["type"]; !hasType {
+			metadata["type"] = "codex"
+		}
+		for _, key := range []string{"id_token", "access_token", "refresh_token", "account_id"} {
+			if value, ok := tokens[key].(string); ok && value != "" && !hasAnyField(metadata, []string{key}) {
+				metadata[key] = value
+			}
+		}
+		delete(metadata, "tokens")
+		changed = true
+	}
+
+	if raw, ok := metadata["type"].(string); ok {
+		if normalized := strings.ToLower(strings.TrimSpace(raw)); normalized != raw {
+			metadata["type"] = normalized
+			changed = true
+		}
+	}
+
+	// Early releases stored the expiry timestamp as "expire".
+	if legacy, ok := metadata["expire"]; ok {
+		if _, exists := metadata["expired"]; !exists {
+			metadata["expired"] = legacy
+		}
+		delete(metadata, "expire")
+		changed = true
+	}
+
+	// Early Gemini files kept the OAuth token fields at the top level.
+	if metadata["type"] == "gemini" {
+		if _, hasToken := metadata["token"]; !hasToken {
+			token := make(map[string]any)
+			for _, key := range []string{"access_token", "refresh_token", "token_type", "expiry"} {
+				if value, ok := metadata[key]; ok {
+					token[key] = value
+					delete(metadata, key)
+				}
+			}
+			if len(token) > 0 {
+				metadata["token"] = token
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// hasAnyField reports whether any of keys holds a non-empty value.
+func hasAnyField(metadata map[string]any, keys []string) bool {
+	for _, key := range keys {
+		switch v := metadata[key].(type) {
+		case nil:
+		case string:
+			if strings.TrimSpace(v) != "" {
+				return true
+			}
+		case map[string]any:
+			if len(v) > 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUpgradeMetadata_CodexCLILayout(t *testing.T) {
+	metadata := map[string]any{
+		"OPENAI_API_KEY": nil,
+		"tokens": map[string]any{
+			"id_token":      "id",
+			"access_token":  "access",
+			"refresh_token": "refresh",
+			"account_id":    "acct",
+		},
+		"expire": "2025-01-01T00:00:00Z",
+	}
+
+	changed, err := LoadMetadata(metadata)
+	if err != nil {
+		t.Fatalf("LoadMetadata: %v", err)
+	}
+	if !changed {
+		t.Fatalf("expected legacy metadata to be upgraded")
+	}
+	if metadata["type"] != "codex" || metadata["refresh_token"] != "refresh" || metadata["account_id"] != "acct" {
+		t.Fatalf("codex tokens not flattened: %#v", metadata)
+	}
+	if _, ok := metadata["tokens"]; ok {
+		t.Fatalf("nested tokens should be removed")
+	}
+	if metadata["expired"] != "2025-01-01T00:00:00Z" {
+		t.Fatalf("expire not renamed: %#v", metadata)
+	}
+	if metadata[SchemaVersionKey] != CurrentSchemaVersion {
+		t.Fatalf("schema version = %v", metadata[SchemaVersionKey])
+	}
+}
+
+func TestUpgradeMetadata_GeminiFlatToken(t *testing.T) {
+	metadata := map[string]any{
+		"type":          "Gemini",
+		"access_token":  "access",
+		"refresh_token": "refresh",
+		"project_id":    "proj",
+	}
+	if _, err := LoadMetadata(metadata); err != nil {
+		t.Fatalf("LoadMetadata: %v", err)
+	}
+	token, ok := metadata["token"].(map[string]any)
+	if !ok || token["refresh_token"] != "refresh" {
+		t.Fatalf("gemini token not wrapped: %#v", metadata)
+	}
+	if metadata["type"] != "gemini" {
+		t.Fatalf("type not normalized: %v", metadata["type"])
+	}
+}
+
+func TestUpgradeMetadata_CurrentVersionUnchanged(t *testing.T) {
+	metadata := map[string]any{
+		"type":           "claude",
+		"refresh_token":  "refresh",
+		"expire":         "kept",
+		SchemaVersionKey: float64(CurrentSchemaVersion),
+	}
+	changed, err := LoadMetadata(metadata)
+	if err != nil || changed {
+		t.Fatalf("changed=%v err=%v", changed, err)
+	}
+	if metadata["expire"] != "kept" {
+		t.Fatalf("current-version metadata should not be migrated")
+	}
+}
+
+func TestLoadMetadata_Errors(t *testing.T) {
+	_, err := LoadMetadata(map[string]any{"type": "vertex"})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected SchemaError, got %v", err)
+	}
+	if len(schemaErr.Missing) != 2 || !strings.Contains(err.Error(), "service_account or credential_source, project_id") {
+		t.Fatalf("unexpected missing fields: %v", err)
+	}
+
+	_, err = LoadMetadata(map[string]any{"type": "vertex", "credential_source": "adc", "project_id": "p"})
+	if err != nil {
+		t.Fatalf("vertex ADC credential should satisfy requirements: %v", err)
+	}
+
+	_, err = LoadMetadata(map[string]any{"type": "iflow", "cookie": "c"})
+	if err != nil {
+		t.Fatalf("iflow cookie should satisfy requirements: %v", err)
+	}
+
+	_, err = LoadMetadata(map[string]any{"type": "claude", "refresh_token": "r", SchemaVersionKey: float64(CurrentSchemaVersion + 1)})
+	if err == nil || !strings.Contains(err.Error(), "newer release") {
+		t.Fatalf("expected newer-version error, got %v", err)
+	}
+
+	_, err = LoadMetadata(map[string]any{"email": "a@b.c"})
+	if err == nil || !strings.Contains(err.Error(), "type") {
+		t.Fatalf("expected missing type error, got %v", err)
+	}
+}
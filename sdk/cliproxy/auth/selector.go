@@ -93,6 +93,11 @@ func (e *modelCooldownError) StatusCode() int {
 	return http.StatusTooManyRequests
 }
 
+// Is reports the cooldown as ErrRateLimited.
+func (e *modelCooldownError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
 func (e *modelCooldownError) Headers() http.Header {
 	headers := make(http.Header)
 	headers.Set("Content-Type", "application/json")
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SessionStartedAtMetadataKey records when the session of an OAuth credential began, as
+// RFC 3339. Logins set it; credentials without it are stamped when a limit first applies.
+const SessionStartedAtMetadataKey = "session_started_at"
+
+// Session limit actions.
+const (
+	// SessionActionRefresh renews the tokens and starts a new session.
+	SessionActionRefresh = "refresh"
+	// SessionActionRelogin disables the credential until it is logged in again.
+	SessionActionRelogin = "relogin"
+)
+
+// SessionLimit caps the session lifetime of one provider's credentials.
+type SessionLimit struct {
+	MaxAge time.Duration
+	Action string
+}
+
+// SessionEvent reports a credential whose session reached its provider's limit.
+type SessionEvent struct {
+	AuthID    string    `json:"auth_id"`
+	Provider  string    `json:"provider"`
+	Label     string    `json:"label,omitempty"`
+	Action    string    `json:"action"`
+	StartedAt time.Time `json:"started_at"`
+	MaxAge    string    `json:"max_age"`
+	Time      time.Time `json:"time"`
+}
+
+// SessionPolicyHook is an optional Hook extension notified when a session limit is enforced.
+type SessionPolicyHook interface {
+	OnSessionLimit(ctx context.Context, event SessionEvent)
+}
+
+// SetSessionLimits replaces the per-provider session limits and the optional event
+// webhook. Limits with a non-positive MaxAge are ignored.
+func (m *Manager) SetSessionLimits(limits map[string]SessionLimit, webhookURL string) {
+	if m == nil {
+		return
+	}
+	normalized := make(map[string]SessionLimit, len(limits))
+	for provider, limit := range limits {
+		if limit.MaxAge <= 0 {
+			continue
+		}
+		limit.Action = strings.ToLower(strings.TrimSpace(limit.Action))
+		if limit.Action != SessionActionRelogin {
+			limit.Action = SessionActionRefresh
+		}
+		normalized[strings.ToLower(strings.TrimSpace(provider))] = limit
+	}
+	m.sessionLimits.Store(normalized)
+	m.sessionWebhook.Store(strings.TrimSpace(webhookURL))
+}
+
+// sessionLimitFor returns the session limit of provider, if one is configured.
+func (m *Manager) sessionLimitFor(provider string) (SessionLimit, bool) {
+	limits, _ := m.sessionLimits.Load().(map[string]SessionLimit)
+	limit, ok := limits[strings.ToLower(provider)]
+	return limit, ok
+}
+
+// SessionStartedAt returns when the session of auth began.
+func SessionStartedAt(auth *Auth) (time.Time, bool) {
+	if auth == nil || auth.Metadata == nil {
+		return time.Time{}, false
+	}
+	raw, _ := auth.Metadata[SessionStartedAtMetadataKey].(string)
+	started, err := time.Parse(time.RFC3339, strings.TrimSpace(raw))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return started, true
+}
+
+// enforceSessionLimit applies the provider's session limit to a, a snapshot from the
+// refresh loop. It reports whether the credential was handled and must not be
+// considered for a regular refresh in this pass.
+func (m *Manager) enforceSessionLimit(ctx context.Context, a *Auth, now time.Time) bool {
+	if a == nil || a.Disabled {
+		return false
+	}
+	limit, ok := m.sessionLimitFor(a.Provider)
+	if !ok {
+		return false
+	}
+	started, ok := SessionStartedAt(a)
+	if !ok {
+		startSession(a, now)
+		_, _ = m.Update(ctx, a)
+		return false
+	}
+	if now.Sub(started) < limit.MaxAge {
+		return false
+	}
+
+	event := SessionEvent{
+		AuthID:    a.ID,
+		Provider:  a.Provider,
+		Label:     a.Label,
+		Action:    limit.Action,
+		StartedAt: started,
+		MaxAge:    limit.MaxAge.String(),
+		Time:      now,
+	}
+	switch limit.Action {
+	case SessionActionRelogin:
+		a.Disabled = true
+		a.Status = StatusDisabled
+		a.StatusMessage = "session exceeded " + limit.MaxAge.String() + "; log in again"
+		a.UpdatedAt = now
+		_, _ = m.Update(ctx, a)
+	default:
+		if m.executorFor(a.Provider) == nil || !m.markRefreshPending(a.ID, now) {
+			return true
+		}
+		go m.refreshSession(ctx, a.ID, now)
+	}
+	m.notifySessionLimit(ctx, event)
+	return true
+}
+
+// refreshSession refreshes the credential id and, when that succeeds, starts a new
+// session. A failed refresh leaves the old session in place, so the limit applies again
+// after the refresh backoff.
+func (m *Manager) refreshSession(ctx context.Context, id string, now time.Time) {
+	m.refreshAuth(ctx, id)
+	current, ok := m.GetByID(id)
+	if !ok || current.LastRefreshedAt.Before(now) {
+		return
+	}
+	startSession(current, current.LastRefreshedAt)
+	_, _ = m.Update(ctx, current)
+}
+
+// startSession stamps the session start of a.
+func startSession(a *Auth, now time.Time) {
+	if a.Metadata == nil {
+		a.Metadata = make(map[string]any)
+	}
+	a.Metadata[SessionStartedAtMetadataKey] = now.UTC().Format(time.RFC3339)
+}
+
+// notifySessionLimit logs event, notifies the hook and posts to the configured webhook.
+func (m *Manager) notifySessionLimit(ctx context.Context, event SessionEvent) {
+	log.WithFields(log.Fields{
+		"auth_id":    event.AuthID,
+		"provider":   event.Provider,
+		"label":      event.Label,
+		"started_at": event.StartedAt,
+	}).Warnf("credential session reached %s, action: %s", event.MaxAge, event.Action)
+
+	if hook, ok := m.hook.(SessionPolicyHook); ok {
+		hook.OnSessionLimit(ctx, event)
+	}
+	webhook, _ := m.sessionWebhook.Load().(string)
+	postWebhook(webhook, "session policy", event)
+}
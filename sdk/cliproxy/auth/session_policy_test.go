@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingSessionHook struct {
+	NoopHook
+	mu     sync.Mutex
+	events []SessionEvent
+}
+
+func (h *recordingSessionHook) OnSessionLimit(_ context.Context, event SessionEvent) {
+	h.mu.Lock()
+	h.events = append(h.events, event)
+	h.mu.Unlock()
+}
+
+func TestEnforceSessionLimit(t *testing.T) {
+	hook := &recordingSessionHook{}
+	m := NewManager(nil, nil, hook)
+	m.RegisterExecutor(explainTestExecutor{id: "explain-test"})
+	m.SetSessionLimits(map[string]SessionLimit{"explain-test": {MaxAge: time.Hour, Action: "relogin"}}, "")
+
+	now := time.Now()
+	expired := &Auth{ID: "old", Provider: "explain-test", Metadata: map[string]any{
+		SessionStartedAtMetadataKey: now.Add(-2 * time.Hour).Format(time.RFC3339),
+	}}
+	fresh := &Auth{ID: "new", Provider: "explain-test", Metadata: map[string]any{}}
+	for _, a := range []*Auth{expired, fresh} {
+		if _, err := m.Register(context.Background(), a); err != nil {
+			t.Fatalf("register %s: %v", a.ID, err)
+		}
+	}
+
+	for _, a := range m.snapshotAuths() {
+		m.enforceSessionLimit(context.Background(), a, now)
+	}
+
+	if got, _ := m.GetByID("old"); !got.Disabled || got.Status != StatusDisabled {
+		t.Fatalf("expired session must be disabled, got %+v", got)
+	}
+	got, _ := m.GetByID("new")
+	if got.Disabled {
+		t.Fatal("a credential within its limit must stay enabled")
+	}
+	if started, ok := SessionStartedAt(got); !ok || started.Unix() != now.Unix() {
+		t.Fatalf("unstamped credential must start its session now, got %v %v", started, ok)
+	}
+	if len(hook.events) != 1 || hook.events[0].AuthID != "old" || hook.events[0].Action != SessionActionRelogin {
+		t.Fatalf("events = %+v", hook.events)
+	}
+}
+
+func TestRefreshSession_StartsNewSession(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(explainTestExecutor{id: "explain-test"})
+	m.SetSessionLimits(map[string]SessionLimit{"explain-test": {MaxAge: time.Hour}}, "")
+
+	start := time.Now().Add(-2 * time.Hour)
+	a := &Auth{ID: "a", Provider: "explain-test", Metadata: map[string]any{SessionStartedAtMetadataKey: start.Format(time.RFC3339)}}
+	if _, err := m.Register(context.Background(), a); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	now := time.Now()
+	m.refreshSession(context.Background(), "a", now)
+
+	got, _ := m.GetByID("a")
+	if started, ok := SessionStartedAt(got); !ok || started.Before(now.Truncate(time.Second)) {
+		t.Fatalf("session must restart after a refresh, started %v", started)
+	}
+	if got.Disabled {
+		t.Fatal("refresh action must keep the credential enabled")
+	}
+}
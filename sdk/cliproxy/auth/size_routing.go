@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/expr"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultSizeThresholdTokens separates small from large prompts when a rule sets none.
+const defaultSizeThresholdTokens = 2000
+
+// bytesPerToken approximates the token count of a request from its size. Request JSON
+// carries some structural overhead, so the estimate errs on the large side.
+const bytesPerToken = 4
+
+type sizeRouteContextKey struct{}
+
+// SetSizeRouting replaces the size-based provider preferences.
+func (m *Manager) SetSizeRouting(rules []internalconfig.SizeRoutingRule) {
+	if m == nil {
+		return
+	}
+	m.sizeRouting.Store(append([]internalconfig.SizeRoutingRule(nil), rules...))
+}
+
+// withSizeRoute records on ctx the providers preferred for req, if a size routing rule
+// matches its model.
+func (m *Manager) withSizeRoute(ctx context.Context, req cliproxyexecutor.Request) context.Context {
+	return m.withSizeRouteFor(ctx, req.Model, len(req.Payload)/bytesPerToken, inflight.FromContext(ctx).Tenant())
+}
+
+// withSizeRouteFor records on ctx the providers preferred for a request of model with a
+// prompt of about tokens from tenant, if a size routing rule matches it.
+func (m *Manager) withSizeRouteFor(ctx context.Context, model string, tokens int, tenant string) context.Context {
+	rules, _ := m.sizeRouting.Load().([]internalconfig.SizeRoutingRule)
+	preferred := sizeRoutePreference(rules, expr.RequestEnv(model, tokens, tenant, time.Now()))
+	if len(preferred) == 0 {
+		return ctx
+	}
+	logEntryWithRequestID(ctx).Debugf("size routing: preferring providers %v for model %s", preferred, model)
+	return context.WithValue(ctx, sizeRouteContextKey{}, preferred)
+}
+
+// sizeRoutePreference returns the providers the first rule matching the request
+// attributes env (see expr.RequestEnv) prefers, or nil.
+func sizeRoutePreference(rules []internalconfig.SizeRoutingRule, env map[string]any) []string {
+	model, _ := env["model"].(string)
+	tokens, _ := env["tokens"].(int)
+	for _, rule := range rules {
+		if len(rule.Models) > 0 || strings.TrimSpace(rule.When) == "" {
+			if !matchesAnyModel(rule.Models, model) {
+				continue
+			}
+		}
+		if matched, err := expr.Match(rule.When, env); err != nil || !matched {
+			if err != nil {
+				log.Debugf("size routing: skipping rule with when %q: %v", rule.When, err)
+			}
+			continue
+		}
+		threshold := rule.ThresholdTokens
+		if threshold <= 0 {
+			threshold = defaultSizeThresholdTokens
+		}
+		if tokens < threshold {
+			return rule.FastProviders
+		}
+		return rule.LargeContextProviders
+	}
+	return nil
+}
+
+// preferSizeRoute narrows candidates to the preferred providers recorded on ctx while
+// one of them has a credential available for model.
+func preferSizeRoute(ctx context.Context, candidates []*Auth, model string) []*Auth {
+	preferred, _ := ctx.Value(sizeRouteContextKey{}).([]string)
+	if len(preferred) == 0 {
+		return candidates
+	}
+	now := time.Now()
+	var out []*Auth
+	for _, candidate := range candidates {
+		if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); blocked {
+			continue
+		}
+		for _, provider := range preferred {
+			if strings.EqualFold(strings.TrimSpace(provider), candidate.Provider) {
+				out = append(out, candidate)
+				break
+			}
+		}
+	}
+	if len(out) == 0 {
+		return candidates
+	}
+	return out
+}
+
+// matchesAnyModel reports whether model matches one of patterns, where '*' matches any
+// run of characters. Matching is case-insensitive.
+func matchesAnyModel(patterns []string, model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range patterns {
+		if matchModelWildcard(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchModelWildcard(pattern, model string) bool {
+	if pattern == "" {
+		return false
+	}
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == model
+	}
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(model, part)
+		if idx < 0 {
+			return false
+		}
+		model = model[idx+len(part):]
+	}
+	return strings.HasSuffix(model, parts[len(parts)-1])
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/expr"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestSizeRoutePreference(t *testing.T) {
+	rules := []internalconfig.SizeRoutingRule{{
+		Models:                []string{"claude-*"},
+		ThresholdTokens:       100,
+		FastProviders:         []string{"groq"},
+		LargeContextProviders: []string{"gemini"},
+	}}
+	if got := sizeRoutePreference(rules, expr.RequestEnv("claude-sonnet-4", 99, "", time.Now())); len(got) != 1 || got[0] != "groq" {
+		t.Fatalf("small prompt preference = %v", got)
+	}
+	if got := sizeRoutePreference(rules, expr.RequestEnv("Claude-Sonnet-4", 100, "", time.Now())); len(got) != 1 || got[0] != "gemini" {
+		t.Fatalf("large prompt preference = %v", got)
+	}
+	if got := sizeRoutePreference(rules, expr.RequestEnv("gpt-5", 10, "", time.Now())); got != nil {
+		t.Fatalf("unmatched models must have no preference, got %v", got)
+	}
+}
+
+func TestSizeRoutePreference_When(t *testing.T) {
+	rules := []internalconfig.SizeRoutingRule{
+		{
+			When:                  `tenant startsWith "sk-team" && hour >= 9 && hour < 18`,
+			FastProviders:         []string{"groq"},
+			LargeContextProviders: []string{"groq"},
+		},
+		{
+			Models:          []string{"claude-*"},
+			When:            `weekday in [0, 6] || model == ""`,
+			FastProviders:   []string{"ollama"},
+			ThresholdTokens: 1,
+		},
+		{Models: []string{"claude-*"}, When: `tokens >`, FastProviders: []string{"never"}},
+	}
+	day := time.Date(2025, 6, 4, 10, 0, 0, 0, time.Local) // a Wednesday
+	if got := sizeRoutePreference(rules, expr.RequestEnv("gpt-5", 10, "sk-team...1#ab", day)); len(got) != 1 || got[0] != "groq" {
+		t.Fatalf("tenant rule preference = %v", got)
+	}
+	if got := sizeRoutePreference(rules, expr.RequestEnv("gpt-5", 10, "sk-team...1#ab", day.Add(10*time.Hour))); got != nil {
+		t.Fatalf("out of hours preference = %v", got)
+	}
+	if got := sizeRoutePreference(rules, expr.RequestEnv("claude-sonnet-4", 0, "", day.AddDate(0, 0, 3))); len(got) != 1 || got[0] != "ollama" {
+		t.Fatalf("weekend preference = %v", got)
+	}
+	if got := sizeRoutePreference(rules, expr.RequestEnv("claude-sonnet-4", 0, "", day)); got != nil {
+		t.Fatalf("rules whose condition fails must not apply, got %v", got)
+	}
+}
+
+func TestPreferSizeRoute_FallsBackWithoutPreferredCredential(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetSizeRouting([]internalconfig.SizeRoutingRule{{Models: []string{"*"}, FastProviders: []string{"groq"}}})
+	fast := &Auth{ID: "fast", Provider: "groq"}
+	slow := &Auth{ID: "slow", Provider: "claude"}
+
+	ctx := m.withSizeRoute(context.Background(), cliproxyexecutor.Request{Model: "m", Payload: []byte(`{"prompt":"hi"}`)})
+	if got := preferSizeRoute(ctx, []*Auth{fast, slow}, "m"); len(got) != 1 || got[0] != fast {
+		t.Fatalf("small prompts must prefer the fast provider, got %v", got)
+	}
+	if got := preferSizeRoute(ctx, []*Auth{slow}, "m"); len(got) != 1 || got[0] != slow {
+		t.Fatalf("without a fast credential every candidate stays eligible, got %v", got)
+	}
+
+	large := bytes.Repeat([]byte("x"), defaultSizeThresholdTokens*bytesPerToken)
+	ctx = m.withSizeRoute(context.Background(), cliproxyexecutor.Request{Model: "m", Payload: large})
+	if got := preferSizeRoute(ctx, []*Auth{fast, slow}, "m"); len(got) != 2 {
+		t.Fatalf("large prompts without a large-context preference must keep every candidate, got %v", got)
+	}
+}
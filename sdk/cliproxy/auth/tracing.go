@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startAttemptSpan starts the span of one executor attempt on auth. The upstream HTTP
+// calls the executor makes on the returned context become its children.
+func startAttemptSpan(ctx context.Context, name string, auth *Auth, provider, model string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, trace.WithAttributes(
+		attribute.String("cliproxy.provider", provider),
+		attribute.String("cliproxy.auth_index", auth.EnsureIndex()),
+		attribute.String("cliproxy.model", model),
+	))
+}
@@ -273,6 +273,14 @@ func RegisterRefreshLeadProvider(provider string, factory func() *time.Duration)
 	refreshLeadMu.Unlock()
 }
 
+// UnregisterRefreshLeadProvider removes the refresh lead registered for provider.
+func UnregisterRefreshLeadProvider(provider string) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	refreshLeadMu.Lock()
+	delete(refreshLeadFactories, provider)
+	refreshLeadMu.Unlock()
+}
+
 var expireKeys = [...]string{"expired", "expire", "expires_at", "expiresAt", "expiry", "expires"}
 
 func expirationFromMap(meta map[string]any) (time.Time, bool) {
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// WarmupResult is the outcome of the latest warm-up request sent to one credential for
+// one model.
+type WarmupResult struct {
+	AuthID    string    `json:"auth_id"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	At        time.Time `json:"at"`
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Warm sends req to the credential authID through its provider executor, bypassing
+// selection, so a self-hosted backend loads the model before user traffic arrives. The
+// outcome is kept for WarmupResults but does not affect the credential's availability.
+func (m *Manager) Warm(ctx context.Context, authID string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) WarmupResult {
+	result := WarmupResult{AuthID: authID, Model: req.Model, At: time.Now()}
+	auth, ok := m.GetByID(authID)
+	if !ok {
+		result.Error = "auth not found"
+		return result
+	}
+	result.Provider = auth.Provider
+	execReq := req
+	execReq.Model, execReq.Metadata = rewriteModelForAuth(req.Model, req.Metadata, auth)
+	execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
+	exec := m.executorFor(executorKeyFromAuth(auth))
+	if exec == nil {
+		result.Error = "executor not registered for provider: " + auth.Provider
+	} else if _, err := exec.Execute(ctx, auth, execReq, opts); err != nil {
+		result.Error = err.Error()
+	}
+	result.LatencyMS = time.Since(result.At).Milliseconds()
+
+	m.warmupMu.Lock()
+	if m.warmups == nil {
+		m.warmups = make(map[string]WarmupResult)
+	}
+	m.warmups[authID+"\x00"+req.Model] = result
+	m.warmupMu.Unlock()
+	return result
+}
+
+// WarmupResults returns the latest warm-up outcome per credential and model, for
+// credentials that are still registered.
+func (m *Manager) WarmupResults() []WarmupResult {
+	m.warmupMu.Lock()
+	out := make([]WarmupResult, 0, len(m.warmups))
+	for _, result := range m.warmups {
+		out = append(out, result)
+	}
+	m.warmupMu.Unlock()
+
+	kept := out[:0]
+	for _, result := range out {
+		if _, ok := m.GetByID(result.AuthID); ok {
+			kept = append(kept, result)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		if kept[i].AuthID != kept[j].AuthID {
+			return kept[i].AuthID < kept[j].AuthID
+		}
+		return kept[i].Model < kept[j].Model
+	})
+	return kept
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type failingWarmupExecutor struct {
+	explainTestExecutor
+}
+
+func (failingWarmupExecutor) Identifier() string { return "warmup-fail" }
+
+func (failingWarmupExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, errors.New("model failed to load")
+}
+
+func TestWarm_RecordsLatestResultPerCredential(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(explainTestExecutor{id: "explain-test"})
+	m.RegisterExecutor(failingWarmupExecutor{})
+	for _, auth := range []*Auth{{ID: "ok", Provider: "explain-test"}, {ID: "bad", Provider: "warmup-fail"}} {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+	}
+
+	req := cliproxyexecutor.Request{Model: "llama3"}
+	if result := m.Warm(context.Background(), "ok", req, cliproxyexecutor.Options{}); result.Error != "" || result.Provider != "explain-test" {
+		t.Fatalf("warm ok = %+v", result)
+	}
+	if result := m.Warm(context.Background(), "bad", req, cliproxyexecutor.Options{}); result.Error != "model failed to load" {
+		t.Fatalf("warm bad = %+v", result)
+	}
+	if result := m.Warm(context.Background(), "gone", req, cliproxyexecutor.Options{}); result.Error == "" {
+		t.Fatalf("warm of unknown auth should fail: %+v", result)
+	}
+
+	results := m.WarmupResults()
+	if len(results) != 2 || results[0].AuthID != "bad" || results[1].AuthID != "ok" {
+		t.Fatalf("WarmupResults = %+v", results)
+	}
+	if results[0].Model != "llama3" || results[0].At.IsZero() {
+		t.Fatalf("unexpected result: %+v", results[0])
+	}
+}
@@ -0,0 +1,377 @@
+package cliproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultAutoscaleInterval is used when autoscale.interval-seconds is not set.
+	defaultAutoscaleInterval = 30 * time.Second
+	// autoscalePollInterval is how often a disabled controller checks for config changes.
+	autoscalePollInterval = time.Minute
+	// defaultAutoscaleTargetInFlight is used when autoscale.target-in-flight is not set.
+	defaultAutoscaleTargetInFlight = 4
+	// defaultAutoscaleScaleDownDelay is used when autoscale.scale-down-delay-seconds is not set.
+	defaultAutoscaleScaleDownDelay = 5 * time.Minute
+	// autoscaleWebhookTimeout bounds one webhook delivery.
+	autoscaleWebhookTimeout = 10 * time.Second
+)
+
+// Reasons reported with a change of a pool's desired replica count.
+const (
+	AutoscaleReasonInitial = "initial"
+	AutoscaleReasonDemand  = "demand"
+	AutoscaleReasonLatency = "latency"
+)
+
+// AutoscaleHint is a change of the desired replica count of a self-hosted backend pool,
+// as posted to autoscale.webhook and written to autoscale.desired-replicas-file.
+type AutoscaleHint struct {
+	Pool             string    `json:"pool"`
+	DesiredReplicas  int       `json:"desired_replicas"`
+	PreviousReplicas int       `json:"previous_replicas"`
+	Reason           string    `json:"reason"`
+	InFlight         int       `json:"in_flight"`
+	Queued           int       `json:"queued"`
+	AvgLatencyMS     int64     `json:"avg_latency_ms"`
+	At               time.Time `json:"at"`
+}
+
+// autoscaler derives the desired replica count of every self-hosted pool. It consumes
+// usage records for the upstream latency of each credential.
+type autoscaler struct {
+	mu      sync.Mutex
+	latency map[string]*latencySample
+	pools   map[string]*poolScale
+	// current is the last hint of every pool, as written to the desired-replicas file.
+	current map[string]AutoscaleHint
+	client  *http.Client
+}
+
+// latencySample sums the latencies reported for one credential since the last evaluation.
+type latencySample struct {
+	total time.Duration
+	count int64
+}
+
+// poolScale is the scaling state of one pool.
+type poolScale struct {
+	desired int
+	// lastHigh is the last time demand needed at least the desired count.
+	lastHigh time.Time
+}
+
+// poolDemand is what the proxy observed of one pool in an evaluation.
+type poolDemand struct {
+	inFlight   int
+	queued     int
+	latency    time.Duration
+	hasLatency bool
+}
+
+func newAutoscaler() *autoscaler {
+	return &autoscaler{
+		latency: make(map[string]*latencySample),
+		pools:   make(map[string]*poolScale),
+		current: make(map[string]AutoscaleHint),
+		client:  &http.Client{Timeout: autoscaleWebhookTimeout},
+	}
+}
+
+// HandleUsage implements usage.Plugin.
+func (a *autoscaler) HandleUsage(_ context.Context, record usage.Record) {
+	if record.AuthID == "" || record.Latency <= 0 {
+		return
+	}
+	a.mu.Lock()
+	sample := a.latency[record.AuthID]
+	if sample == nil {
+		sample = &latencySample{}
+		a.latency[record.AuthID] = sample
+	}
+	sample.total += record.Latency
+	sample.count++
+	a.mu.Unlock()
+}
+
+// runAutoscaler evaluates the self-hosted pools per the autoscale config, which is re-read
+// before every evaluation.
+func (s *Service) runAutoscaler(ctx context.Context) {
+	a := newAutoscaler()
+	usage.RegisterPlugin(a)
+	for {
+		s.cfgMu.RLock()
+		cfg := s.cfg
+		s.cfgMu.RUnlock()
+
+		wait := autoscalePollInterval
+		if cfg != nil && cfg.Autoscale.Enabled && s.coreManager != nil {
+			supports := GlobalModelRegistry().ClientSupportsModel
+			hints := a.evaluate(cfg.Autoscale, s.coreManager.List(), inflight.List(), supports, time.Now())
+			a.publish(cfg.Autoscale, hints)
+			wait = autoscaleInterval(cfg.Autoscale)
+		} else {
+			a.reset()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func autoscaleInterval(cfg config.AutoscaleConfig) time.Duration {
+	if cfg.IntervalSeconds > 0 {
+		return time.Duration(cfg.IntervalSeconds) * time.Second
+	}
+	return defaultAutoscaleInterval
+}
+
+// reset drops the state of a disabled controller, so enabling it starts afresh.
+func (a *autoscaler) reset() {
+	a.mu.Lock()
+	clear(a.latency)
+	clear(a.pools)
+	clear(a.current)
+	a.mu.Unlock()
+}
+
+// evaluate measures the demand of every pool among auths and returns the pools whose
+// desired replica count changed. supports reports whether a credential serves a model,
+// to attribute queued requests.
+func (a *autoscaler) evaluate(cfg config.AutoscaleConfig, auths []*coreauth.Auth, requests []inflight.Snapshot, supports func(authID, model string) bool, now time.Time) []AutoscaleHint {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	poolOf := make(map[string]string)
+	poolAuths := make(map[string][]string)
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || !warmupEligible(auth, cfg.Providers) {
+			continue
+		}
+		pool := autoscalePool(auth)
+		poolOf[auth.ID] = pool
+		poolAuths[pool] = append(poolAuths[pool], auth.ID)
+	}
+
+	demand := make(map[string]*poolDemand, len(poolAuths))
+	for pool := range poolAuths {
+		demand[pool] = &poolDemand{}
+	}
+	for _, request := range requests {
+		if pool, ok := poolOf[request.AuthID]; ok {
+			demand[pool].inFlight++
+			continue
+		}
+		if !request.Queued || request.Model == "" {
+			continue
+		}
+		for pool, ids := range poolAuths {
+			for _, id := range ids {
+				if supports(id, request.Model) {
+					demand[pool].queued++
+					break
+				}
+			}
+		}
+	}
+	for pool, ids := range poolAuths {
+		var total time.Duration
+		var count int64
+		for _, id := range ids {
+			if sample := a.latency[id]; sample != nil {
+				total += sample.total
+				count += sample.count
+			}
+		}
+		if count > 0 {
+			demand[pool].latency = total / time.Duration(count)
+			demand[pool].hasLatency = true
+		}
+	}
+	clear(a.latency)
+
+	var hints []AutoscaleHint
+	for pool := range a.pools {
+		if _, ok := demand[pool]; !ok {
+			delete(a.pools, pool)
+			delete(a.current, pool)
+		}
+	}
+	pools := make([]string, 0, len(demand))
+	for pool := range demand {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+	for _, pool := range pools {
+		if hint, changed := a.scale(cfg, pool, demand[pool], now); changed {
+			a.current[pool] = hint
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// scale updates the desired count of pool for its demand and reports whether it changed.
+func (a *autoscaler) scale(cfg config.AutoscaleConfig, pool string, d *poolDemand, now time.Time) (AutoscaleHint, bool) {
+	target := cfg.TargetInFlight
+	if target <= 0 {
+		target = defaultAutoscaleTargetInFlight
+	}
+	desired := (d.inFlight + d.queued + target - 1) / target
+	reason := AutoscaleReasonDemand
+
+	state := a.pools[pool]
+	previous := 0
+	if state != nil {
+		previous = state.desired
+	}
+	slow := cfg.MaxLatencyMS > 0 && d.hasLatency && d.latency > time.Duration(cfg.MaxLatencyMS)*time.Millisecond
+	if slow && desired <= previous {
+		desired = previous + 1
+		reason = AutoscaleReasonLatency
+	}
+	if desired < cfg.MinReplicas {
+		desired = cfg.MinReplicas
+	}
+	if cfg.MaxReplicas > 0 && desired > cfg.MaxReplicas {
+		desired = cfg.MaxReplicas
+	}
+
+	if state == nil {
+		state = &poolScale{desired: desired, lastHigh: now}
+		a.pools[pool] = state
+		return newAutoscaleHint(pool, desired, 0, AutoscaleReasonInitial, d, now), true
+	}
+	if desired >= previous {
+		state.lastHigh = now
+	} else {
+		delay := defaultAutoscaleScaleDownDelay
+		if cfg.ScaleDownDelaySeconds > 0 {
+			delay = time.Duration(cfg.ScaleDownDelaySeconds) * time.Second
+		}
+		if now.Sub(state.lastHigh) < delay {
+			return AutoscaleHint{}, false
+		}
+		state.lastHigh = now
+	}
+	if desired == previous {
+		return AutoscaleHint{}, false
+	}
+	state.desired = desired
+	return newAutoscaleHint(pool, desired, previous, reason, d, now), true
+}
+
+func newAutoscaleHint(pool string, desired, previous int, reason string, d *poolDemand, now time.Time) AutoscaleHint {
+	return AutoscaleHint{
+		Pool:             pool,
+		DesiredReplicas:  desired,
+		PreviousReplicas: previous,
+		Reason:           reason,
+		InFlight:         d.inFlight,
+		Queued:           d.queued,
+		AvgLatencyMS:     d.latency.Milliseconds(),
+		At:               now,
+	}
+}
+
+// autoscalePool returns the pool of a self-hosted credential: its openai-compatibility
+// name, or its provider for Ollama servers.
+func autoscalePool(auth *coreauth.Auth) string {
+	if auth.Attributes != nil {
+		if name := strings.ToLower(strings.TrimSpace(auth.Attributes["compat_name"])); name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(auth.Provider))
+}
+
+// publish logs hints, posts each to the webhook and rewrites the desired-replicas file.
+func (a *autoscaler) publish(cfg config.AutoscaleConfig, hints []AutoscaleHint) {
+	if len(hints) == 0 {
+		return
+	}
+	for _, hint := range hints {
+		log.Infof("autoscale: pool %s desired replicas %d -> %d (%s, in flight %d, queued %d, latency %dms)",
+			hint.Pool, hint.PreviousReplicas, hint.DesiredReplicas, hint.Reason, hint.InFlight, hint.Queued, hint.AvgLatencyMS)
+		if webhook := strings.TrimSpace(cfg.Webhook); webhook != "" {
+			go a.postHint(webhook, hint)
+		}
+	}
+	if path := strings.TrimSpace(cfg.DesiredReplicasFile); path != "" {
+		if err := a.writeDesiredReplicas(path, hints[len(hints)-1].At); err != nil {
+			log.Warnf("autoscale: write %s: %v", path, err)
+		}
+	}
+}
+
+func (a *autoscaler) postHint(webhook string, hint AutoscaleHint) {
+	payload, err := json.Marshal(hint)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		log.Warnf("autoscale webhook: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		log.Warnf("autoscale webhook: %v", err)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.Warnf("autoscale webhook returned status %d", resp.StatusCode)
+	}
+}
+
+// writeDesiredReplicas atomically replaces path with the current hint of every pool.
+func (a *autoscaler) writeDesiredReplicas(path string, updatedAt time.Time) error {
+	a.mu.Lock()
+	pools := make(map[string]AutoscaleHint, len(a.current))
+	for pool, hint := range a.current {
+		pools[pool] = hint
+	}
+	a.mu.Unlock()
+	data, err := json.MarshalIndent(map[string]any{"updated_at": updatedAt, "pools": pools}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".desired-replicas-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,98 @@
+package cliproxy
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/inflight"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestAutoscaler_FollowsDemandWithScaleDownDelay(t *testing.T) {
+	auths := []*coreauth.Auth{
+		{ID: "vllm-a", Provider: "openai-compatibility", Attributes: map[string]string{"compat_name": "vLLM"}},
+		{ID: "vllm-b", Provider: "openai-compatibility", Attributes: map[string]string{"compat_name": "vLLM"}},
+		{ID: "claude-1", Provider: "claude"},
+	}
+	supports := func(authID, model string) bool { return model == "llama" && authID != "claude-1" }
+	cfg := config.AutoscaleConfig{TargetInFlight: 2, MinReplicas: 1, MaxReplicas: 3, ScaleDownDelaySeconds: 60}
+	a := newAutoscaler()
+	now := time.Unix(1_700_000_000, 0)
+
+	hints := a.evaluate(cfg, auths, nil, supports, now)
+	if len(hints) != 1 || hints[0].Pool != "vllm" || hints[0].DesiredReplicas != 1 || hints[0].Reason != AutoscaleReasonInitial {
+		t.Fatalf("initial hints = %+v", hints)
+	}
+
+	busy := []inflight.Snapshot{
+		{ID: "1", AuthID: "vllm-a"}, {ID: "2", AuthID: "vllm-b"}, {ID: "3", AuthID: "vllm-a"},
+		{ID: "4", Model: "llama", Queued: true}, {ID: "5", Model: "claude-sonnet", Queued: true},
+		{ID: "6", AuthID: "claude-1"},
+	}
+	hints = a.evaluate(cfg, auths, busy, supports, now.Add(30*time.Second))
+	if len(hints) != 1 || hints[0].DesiredReplicas != 2 || hints[0].PreviousReplicas != 1 || hints[0].InFlight != 3 || hints[0].Queued != 1 {
+		t.Fatalf("busy hints = %+v", hints)
+	}
+
+	// Demand dropped, but not for long enough to scale down.
+	if hints = a.evaluate(cfg, auths, nil, supports, now.Add(60*time.Second)); len(hints) != 0 {
+		t.Fatalf("scaled down before the delay: %+v", hints)
+	}
+	hints = a.evaluate(cfg, auths, nil, supports, now.Add(91*time.Second))
+	if len(hints) != 1 || hints[0].DesiredReplicas != 1 || hints[0].Reason != AutoscaleReasonDemand {
+		t.Fatalf("idle hints = %+v", hints)
+	}
+}
+
+func TestAutoscaler_AddsReplicaWhileSlow(t *testing.T) {
+	auths := []*coreauth.Auth{{ID: "ollama-1", Provider: "ollama"}}
+	supports := func(string, string) bool { return false }
+	cfg := config.AutoscaleConfig{MaxLatencyMS: 1000, MaxReplicas: 2}
+	a := newAutoscaler()
+	now := time.Unix(1_700_000_000, 0)
+	a.evaluate(cfg, auths, nil, supports, now)
+
+	// One request needs one replica; the latency adds a second, up to max-replicas.
+	want := []struct {
+		desired int
+		reason  string
+	}{{1, AutoscaleReasonDemand}, {2, AutoscaleReasonLatency}, {0, ""}}
+	for i, w := range want {
+		a.HandleUsage(context.Background(), usage.Record{AuthID: "ollama-1", Latency: 3 * time.Second})
+		hints := a.evaluate(cfg, auths, []inflight.Snapshot{{ID: "1", AuthID: "ollama-1"}}, supports, now.Add(time.Duration(i+1)*time.Minute))
+		if w.desired == 0 {
+			if len(hints) != 0 {
+				t.Fatalf("max-replicas exceeded: %+v", hints)
+			}
+			continue
+		}
+		if len(hints) != 1 || hints[0].DesiredReplicas != w.desired || hints[0].Reason != w.reason || hints[0].AvgLatencyMS != 3000 {
+			t.Fatalf("evaluation %d hints = %+v", i, hints)
+		}
+	}
+}
+
+func TestAutoscaler_WritesDesiredReplicasFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scale", "desired.json")
+	cfg := config.AutoscaleConfig{MinReplicas: 2, DesiredReplicasFile: path}
+	a := newAutoscaler()
+	auths := []*coreauth.Auth{{ID: "ollama-1", Provider: "ollama"}}
+	a.publish(cfg, a.evaluate(cfg, auths, nil, func(string, string) bool { return false }, time.Now()))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var out struct {
+		Pools map[string]AutoscaleHint `json:"pools"`
+	}
+	if err = json.Unmarshal(data, &out); err != nil || out.Pools["ollama"].DesiredReplicas != 2 {
+		t.Fatalf("file = %s (%v)", data, err)
+	}
+}
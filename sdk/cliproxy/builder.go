@@ -11,6 +11,7 @@ import (
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
@@ -47,6 +48,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// usagePlugins receive a record for every upstream request.
+	usagePlugins []usage.Plugin
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -143,6 +147,20 @@ func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	return b
 }
 
+// WithUsagePlugins attaches usage sinks, e.g. StatsD, ClickHouse or Kafka writers, that
+// receive a usage.Record for every upstream request. Plugins run on the usage dispatcher
+// goroutine, one record at a time, so slow sinks should buffer internally.
+//
+// Parameters:
+//   - plugins: The usage plugins to register when the service is built
+//
+// Returns:
+//   - *Builder: The builder instance for method chaining
+func (b *Builder) WithUsagePlugins(plugins ...usage.Plugin) *Builder {
+	b.usagePlugins = append(b.usagePlugins, plugins...)
+	return b
+}
+
 // WithLocalManagementPassword configures a password that is only accepted from localhost management requests.
 func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	if password == "" {
@@ -229,5 +247,8 @@ func (b *Builder) Build() (*Service, error) {
 		coreManager:    coreManager,
 		serverOptions:  append([]api.ServerOption(nil), b.serverOptions...),
 	}
+	for _, plugin := range b.usagePlugins {
+		service.RegisterUsagePlugin(plugin)
+	}
 	return service, nil
 }
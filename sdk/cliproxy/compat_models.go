@@ -0,0 +1,33 @@
+package cliproxy
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// discoverCompatModels registers the models listed at the models-url of an
+// openai-compatibility credential. Like provider-key discovery it runs in the background
+// and keeps the previous registration until the fetch completes.
+func (s *Service) discoverCompatModels(a *coreauth.Auth, providerKey string, generation uint64) {
+	auth := a.Clone()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), providerKeyDiscoveryTimeout)
+		models := executor.FetchCompatModels(ctx, auth, s.cfg)
+		cancel()
+		if !s.isCurrentModelDiscovery(auth.ID, generation) {
+			return
+		}
+		if s.coreManager != nil {
+			if existing, ok := s.coreManager.GetByID(auth.ID); ok && existing != nil && existing.Disabled {
+				return
+			}
+		}
+		if len(models) == 0 {
+			GlobalModelRegistry().UnregisterClient(auth.ID)
+			return
+		}
+		GlobalModelRegistry().RegisterClient(auth.ID, providerKey, applyModelPrefixes(models, auth.Prefix, s.cfg != nil && s.cfg.ForceModelPrefix))
+	}()
+}
@@ -19,6 +19,10 @@ type Request struct {
 	Metadata map[string]any
 }
 
+// SessionIDMetadataKey is the Options.Metadata key carrying the client-supplied
+// conversation identifier (the X-Session-ID header), used for sticky credential selection.
+const SessionIDMetadataKey = "session_id"
+
 // Options controls execution behavior for both streaming and non-streaming calls.
 type Options struct {
 	// Stream toggles streaming mode.
@@ -35,6 +39,43 @@ type Options struct {
 	SourceFormat sdktranslator.Format
 	// Metadata carries extra execution hints shared across selection and executors.
 	Metadata map[string]any
+	// StreamTuning adjusts buffering and output of streaming calls. The zero value keeps
+	// the executors' defaults.
+	StreamTuning StreamOptions
+}
+
+// StreamOptions tunes ExecuteStream. Every built-in executor honors it.
+type StreamOptions struct {
+	// BufferSize is the capacity of the returned chunk channel. 0 keeps it unbuffered, so
+	// the upstream read waits for the consumer; a larger buffer lets a slow consumer lag
+	// behind by that many chunks.
+	BufferSize int
+	// MaxChunkSize caps the bytes of a single upstream SSE line. Longer lines end the
+	// stream with bufio.ErrTooLong. 0 keeps the executor's limit.
+	MaxChunkSize int
+	// IncludeUsage controls whether OpenAI-compatible upstreams are asked to append a
+	// usage chunk (stream_options.include_usage). nil keeps the executor default; with
+	// false the usage of the request is not recorded. Other providers always report usage.
+	IncludeUsage *bool
+	// RawPassthrough emits upstream events as received instead of translating them to
+	// the source format. Kiro, whose upstream stream is binary, emits Claude events.
+	RawPassthrough bool
+}
+
+// ChannelBuffer returns the capacity for a chunk channel.
+func (o StreamOptions) ChannelBuffer() int {
+	if o.BufferSize < 0 {
+		return 0
+	}
+	return o.BufferSize
+}
+
+// ChunkLimit returns the maximum line size for a stream scanner, falling back to def.
+func (o StreamOptions) ChunkLimit(def int) int {
+	if o.MaxChunkSize > 0 {
+		return o.MaxChunkSize
+	}
+	return def
 }
 
 // Response wraps either a full provider response or metadata for streaming flows.
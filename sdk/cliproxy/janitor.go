@@ -0,0 +1,41 @@
+package cliproxy
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/artifact"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/janitor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// defaultPartialArtifactMaxAge is how long a partial artifact may go unwritten before
+// the janitor deletes it, unless janitor.partial-artifact-max-age-hours is set.
+const defaultPartialArtifactMaxAge = 24 * time.Hour
+
+func init() {
+	janitor.Register("quota-counters", 5*time.Minute, func(_ *config.Config, _ time.Time) (int, error) {
+		return registry.GetGlobalRegistry().CleanupExpiredQuotas(), nil
+	})
+	janitor.Register("partial-artifacts", time.Hour, func(cfg *config.Config, now time.Time) (int, error) {
+		if cfg == nil || strings.TrimSpace(cfg.Streaming.ArtifactDir) == "" {
+			return 0, nil
+		}
+		maxAge := defaultPartialArtifactMaxAge
+		if cfg.Janitor.PartialArtifactMaxAgeHours > 0 {
+			maxAge = time.Duration(cfg.Janitor.PartialArtifactMaxAgeHours) * time.Hour
+		}
+		return artifact.RemoveStalePartials(strings.TrimSpace(cfg.Streaming.ArtifactDir), now.Add(-maxAge))
+	})
+}
+
+// runJanitor sweeps expired and orphaned state on the janitor schedule until ctx is done.
+func (s *Service) runJanitor(ctx context.Context) {
+	janitor.Run(ctx, func() *config.Config {
+		s.cfgMu.RLock()
+		defer s.cfgMu.RUnlock()
+		return s.cfg
+	})
+}
@@ -0,0 +1,207 @@
+package cliproxy
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// providerKeyDiscoveryTimeout bounds a single upstream model-list fetch.
+const providerKeyDiscoveryTimeout = 30 * time.Second
+
+// providerKeyProvider wires one provider-key section (see config.ProviderKeySpec) into
+// the service: the executor serving its credentials and where its models come from when
+// the entry configures none. Exactly one of models and discover is set.
+type providerKeyProvider struct {
+	newExecutor func(cfg *config.Config) coreauth.ProviderExecutor
+	// models returns the built-in model list.
+	models func() []*ModelInfo
+	// discover fetches the model list from the upstream. It runs off the registration
+	// path; an empty result unregisters the credential's models.
+	discover func(s *Service, ctx context.Context, a *coreauth.Auth) []*ModelInfo
+}
+
+var providerKeyProviders = map[string]providerKeyProvider{
+	"groq": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("groq", cfg)
+		},
+		discover: func(s *Service, ctx context.Context, a *coreauth.Auth) []*ModelInfo {
+			return executor.FetchGroqModels(ctx, a, s.cfg)
+		},
+	},
+	"xai": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("xai", cfg)
+		},
+		models: registry.GetXAIModels,
+	},
+	"openrouter": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("openrouter", cfg)
+		},
+		discover: (*Service).fetchOpenRouterCatalog,
+	},
+	"cohere": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor { return executor.NewCohereExecutor(cfg) },
+		models:      registry.GetCohereModels,
+	},
+	"fireworks": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("fireworks", cfg)
+		},
+		models: registry.GetFireworksModels,
+	},
+	"nvidia": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("nvidia", cfg)
+		},
+		models: registry.GetNVIDIAModels,
+	},
+	"moonshot": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("moonshot", cfg)
+		},
+		models: registry.GetMoonshotModels,
+	},
+	"zhipu": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("zhipu", cfg)
+		},
+		models: registry.GetZhipuModels,
+	},
+	"cerebras": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor("cerebras", cfg)
+		},
+		models: registry.GetCerebrasModels,
+	},
+	"ollama": {
+		newExecutor: func(cfg *config.Config) coreauth.ProviderExecutor { return executor.NewOllamaExecutor(cfg) },
+		discover: func(s *Service, ctx context.Context, a *coreauth.Auth) []*ModelInfo {
+			return executor.FetchOllamaModels(ctx, a, s.cfg)
+		},
+	},
+}
+
+// resolveConfigProviderKey finds the provider-key entry an auth was synthesized from.
+func (s *Service) resolveConfigProviderKey(a *coreauth.Auth) *config.ProviderKey {
+	if a == nil || a.Attributes == nil || s.cfg == nil {
+		return nil
+	}
+	return s.cfg.FindProviderKey(a.Provider, a.Attributes["api_key"], a.Attributes["base_url"])
+}
+
+// registerProviderKeyModels registers the models of a provider-key credential.
+// Configured and built-in lists are registered immediately. Discovered catalogs are
+// fetched in the background so a slow or unreachable upstream does not stall auth
+// registration or config reloads; the previous registration stays in place until the
+// fetch completes.
+func (s *Service) registerProviderKeyModels(a *coreauth.Auth, provider, authKind string, p providerKeyProvider) {
+	var excluded []string
+	entry := s.resolveConfigProviderKey(a)
+	if entry != nil {
+		excluded = entry.ExcludedModels
+	}
+	generation := s.nextModelDiscovery(a.ID)
+	if entry != nil && len(entry.Models) > 0 {
+		s.applyProviderKeyModels(a, provider, authKind, buildConfigModels(entry.Models, provider, provider), excluded)
+		return
+	}
+	if p.discover == nil {
+		s.applyProviderKeyModels(a, provider, authKind, p.models(), excluded)
+		return
+	}
+	auth := a.Clone()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), providerKeyDiscoveryTimeout)
+		models := p.discover(s, ctx, auth)
+		cancel()
+		if !s.isCurrentModelDiscovery(auth.ID, generation) {
+			return
+		}
+		if s.coreManager != nil {
+			if existing, ok := s.coreManager.GetByID(auth.ID); ok && existing != nil && existing.Disabled {
+				return
+			}
+		}
+		s.applyProviderKeyModels(auth, provider, authKind, models, excluded)
+	}()
+}
+
+// applyProviderKeyModels filters, maps and prefixes models and (re)binds them to the auth.
+func (s *Service) applyProviderKeyModels(a *coreauth.Auth, provider, authKind string, models []*ModelInfo, excluded []string) {
+	models = applyExcludedModels(models, excluded)
+	models = applyOAuthModelMappings(s.cfg, provider, authKind, models)
+	if len(models) == 0 {
+		GlobalModelRegistry().UnregisterClient(a.ID)
+		return
+	}
+	GlobalModelRegistry().RegisterClient(a.ID, provider, applyModelPrefixes(models, a.Prefix, s.cfg != nil && s.cfg.ForceModelPrefix))
+}
+
+// nextModelDiscovery starts a new model registration for authID and returns its
+// generation; results of older background fetches for the same auth are discarded.
+func (s *Service) nextModelDiscovery(authID string) uint64 {
+	s.modelDiscoveryMu.Lock()
+	defer s.modelDiscoveryMu.Unlock()
+	if s.modelDiscovery == nil {
+		s.modelDiscovery = make(map[string]uint64)
+	}
+	s.modelDiscovery[authID]++
+	return s.modelDiscovery[authID]
+}
+
+func (s *Service) isCurrentModelDiscovery(authID string, generation uint64) bool {
+	s.modelDiscoveryMu.Lock()
+	defer s.modelDiscoveryMu.Unlock()
+	return s.modelDiscovery[authID] == generation
+}
+
+// fetchOpenRouterCatalog fetches the OpenRouter catalog for auth, falling back to the
+// last successful result when the upstream call fails.
+func (s *Service) fetchOpenRouterCatalog(ctx context.Context, a *coreauth.Auth) []*ModelInfo {
+	models := executor.FetchOpenRouterModels(ctx, a, s.cfg)
+
+	s.openRouterCatalogMu.Lock()
+	defer s.openRouterCatalogMu.Unlock()
+	if len(models) == 0 {
+		return s.openRouterCatalog[a.ID]
+	}
+	if s.openRouterCatalog == nil {
+		s.openRouterCatalog = make(map[string][]*ModelInfo)
+	}
+	s.openRouterCatalog[a.ID] = models
+	return models
+}
+
+// syncOpenRouterCatalogs periodically re-registers OpenRouter credentials so newly
+// published models and price changes become visible without a restart.
+func (s *Service) syncOpenRouterCatalogs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.coreManager == nil {
+				continue
+			}
+			for _, a := range s.coreManager.List() {
+				if a == nil || a.Disabled || !strings.EqualFold(a.Provider, "openrouter") {
+					continue
+				}
+				if entry := s.resolveConfigProviderKey(a); entry != nil && len(entry.Models) > 0 {
+					continue
+				}
+				s.registerModelsForAuth(a)
+			}
+		}
+	}
+}
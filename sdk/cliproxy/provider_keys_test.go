@@ -0,0 +1,61 @@
+package cliproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestRegisterModelsForAuth_DiscoversOllamaModelsInBackground(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{"models":[{"name":"llama3.1:8b"}]}`))
+	}))
+	defer srv.Close()
+	defer func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	}()
+
+	cfg := &config.Config{OllamaKey: []config.OllamaKey{{BaseURL: srv.URL}}}
+	s := &Service{cfg: cfg}
+	a := &coreauth.Auth{
+		ID:         "ollama-background-test",
+		Provider:   "ollama",
+		Attributes: map[string]string{"base_url": srv.URL},
+	}
+	defer GlobalModelRegistry().UnregisterClient(a.ID)
+
+	done := make(chan struct{})
+	go func() {
+		s.registerModelsForAuth(a)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("registerModelsForAuth blocked on the model fetch")
+	}
+	if models := registry.GetGlobalRegistry().GetModelsForClient(a.ID); len(models) != 0 {
+		t.Fatalf("expected no models before the fetch completes, got %d", len(models))
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if models := registry.GetGlobalRegistry().GetModelsForClient(a.ID); len(models) == 1 && models[0].ID == "llama3.1:8b" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected discovered model to be registered")
+}
@@ -86,8 +86,21 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// openRouterCatalog keeps the last successfully fetched OpenRouter catalog per auth ID
+	// so a failed sync does not drop the registered models.
+	openRouterCatalogMu sync.Mutex
+	openRouterCatalog   map[string][]*ModelInfo
+
+	// modelDiscovery counts model registrations per auth ID so a background model fetch
+	// that finishes after a newer registration is discarded.
+	modelDiscoveryMu sync.Mutex
+	modelDiscovery   map[string]uint64
 }
 
+// openRouterCatalogSyncInterval is how often OpenRouter model catalogs are re-fetched.
+const openRouterCatalogSyncInterval = time.Hour
+
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
 // This allows external code to monitor API usage and token consumption.
 //
@@ -315,6 +328,17 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	}
 	maxInterval := time.Duration(cfg.MaxRetryInterval) * time.Second
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
+	s.coreManager.SetCanaryConfig(cfg.Routing.CanaryEvery, cfg.Routing.CanaryAlertWebhook)
+	s.coreManager.SetCanarySplits(cfg.Routing.CanarySplits)
+	s.coreManager.SetSizeRouting(cfg.Routing.SizeRouting)
+	s.coreManager.SetAffinity(cfg.Routing.Affinity)
+	s.coreManager.SetHedging(cfg.Routing.Hedging)
+	s.coreManager.SetConcurrency(cfg.Concurrency)
+	limits := make(map[string]coreauth.SessionLimit, len(cfg.SessionPolicy.Providers))
+	for provider, limit := range cfg.SessionPolicy.Providers {
+		limits[provider] = coreauth.SessionLimit{MaxAge: time.Duration(limit.MaxSessionHours) * time.Hour, Action: limit.Action}
+	}
+	s.coreManager.SetSessionLimits(limits, cfg.SessionPolicy.Webhook)
 }
 
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
@@ -357,6 +381,10 @@ func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 		s.coreManager.RegisterExecutor(executor.NewOpenAICompatExecutor(compatProviderKey, s.cfg))
 		return
 	}
+	if p, ok := providerKeyProviders[strings.ToLower(a.Provider)]; ok {
+		s.coreManager.RegisterExecutor(p.newExecutor(s.cfg))
+		return
+	}
 	switch strings.ToLower(a.Provider) {
 	case "gemini":
 		s.coreManager.RegisterExecutor(executor.NewGeminiExecutor(s.cfg))
@@ -586,6 +614,11 @@ func (s *Service) Run(ctx context.Context) error {
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
 	}
+	go s.syncOpenRouterCatalogs(ctx, openRouterCatalogSyncInterval)
+	go s.runWarmups(ctx)
+	go s.runAutoscaler(ctx)
+	go s.runJanitor(ctx)
+	go s.logStartupSummary(ctx)
 
 	select {
 	case <-ctx.Done():
@@ -708,6 +741,10 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	if compatDetected {
 		provider = "openai-compatibility"
 	}
+	if p, ok := providerKeyProviders[provider]; ok {
+		s.registerProviderKeyModels(a, provider, authKind, p)
+		return
+	}
 	excluded := s.oauthExcludedModels(provider, authKind)
 	var models []*ModelInfo
 	switch provider {
@@ -729,6 +766,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			if entry := s.resolveConfigVertexCompatKey(a); entry != nil && len(entry.Models) > 0 {
 				models = buildVertexCompatConfigModels(entry)
 			}
+		} else {
+			// Project credentials can also call Anthropic models through Vertex AI.
+			models = append(models, registry.GetVertexAnthropicModels()...)
 		}
 		models = applyExcludedModels(models, excluded)
 	case "gemini-cli":
@@ -819,6 +859,14 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 				compat := &s.cfg.OpenAICompatibility[i]
 				if strings.EqualFold(compat.Name, compatName) {
 					isCompatAuth = true
+					if providerKey == "" {
+						providerKey = "openai-compatibility"
+					}
+					generation := s.nextModelDiscovery(a.ID)
+					if len(compat.Models) == 0 && compat.ModelsURL != "" {
+						s.discoverCompatModels(a, providerKey, generation)
+						return
+					}
 					// Convert compatibility models to registry models
 					ms := make([]*ModelInfo, 0, len(compat.Models))
 					for j := range compat.Models {
@@ -839,9 +887,6 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 					}
 					// Register and return
 					if len(ms) > 0 {
-						if providerKey == "" {
-							providerKey = "openai-compatibility"
-						}
 						GlobalModelRegistry().RegisterClient(a.ID, providerKey, applyModelPrefixes(ms, a.Prefix, s.cfg.ForceModelPrefix))
 					} else {
 						// Ensure stale registrations are cleared when model list becomes empty.
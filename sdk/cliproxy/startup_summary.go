@@ -0,0 +1,63 @@
+package cliproxy
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// startupSummaryDelay gives the watcher time to dispatch the initial credentials before
+// the startup summary is logged.
+const startupSummaryDelay = 3 * time.Second
+
+// logStartupSummary logs the capability summary served by /v0/status once the initial
+// credentials have been loaded, with a warning for each sign of misconfiguration.
+func (s *Service) logStartupSummary(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(startupSummaryDelay):
+	}
+	if s.server == nil {
+		return
+	}
+	status := s.server.Status()
+
+	var enabled []string
+	for name, on := range status.Features {
+		if on {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	log.WithFields(log.Fields{
+		"version":     status.Version,
+		"listen":      status.Listen,
+		"tls":         status.TLS,
+		"providers":   len(status.Providers),
+		"credentials": status.Credentials,
+		"endpoints":   len(status.Endpoints),
+		"features":    strings.Join(enabled, ","),
+	}).Info("startup summary")
+
+	names := make([]string, 0, len(status.Providers))
+	for name := range status.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry := status.Providers[name]
+		log.WithFields(log.Fields{
+			"provider":    name,
+			"credentials": entry.Credentials,
+			"active":      entry.Active,
+			"disabled":    entry.Disabled,
+		}).Info("provider loaded")
+	}
+	for _, warning := range status.Warnings {
+		log.Warnf("startup summary: %s", warning)
+	}
+}
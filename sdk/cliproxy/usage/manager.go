@@ -19,8 +19,21 @@ type Record struct {
 	RequestedAt time.Time
 	Failed      bool
 	Detail      Detail
+	// Category separates non-chat traffic such as moderation from chat usage.
+	// Empty means chat.
+	Category string
+	// Seed is the sampling seed the client sent, nil when it sent none.
+	Seed *int64
+	// RequestID is the proxy request id, as used in request logs. Empty for requests
+	// that did not come through the HTTP API.
+	RequestID string
+	// Latency is the time from sending the request upstream until usage was known.
+	Latency time.Duration
 }
 
+// CategoryModeration marks records produced by moderation requests.
+const CategoryModeration = "moderation"
+
 // Detail holds the token usage breakdown.
 type Detail struct {
 	InputTokens     int64
@@ -30,14 +43,37 @@ type Detail struct {
 	TotalTokens     int64
 }
 
+// BandwidthRecord captures the bytes exchanged with an upstream provider for a single request.
+type BandwidthRecord struct {
+	Provider      string
+	AuthID        string
+	AuthIndex     string
+	APIKey        string
+	RequestedAt   time.Time
+	BytesSent     int64
+	BytesReceived int64
+}
+
 // Plugin consumes usage records emitted by the proxy runtime.
 type Plugin interface {
 	HandleUsage(ctx context.Context, record Record)
 }
 
+// PluginFunc adapts a function to the Plugin interface.
+type PluginFunc func(ctx context.Context, record Record)
+
+// HandleUsage implements Plugin.
+func (f PluginFunc) HandleUsage(ctx context.Context, record Record) { f(ctx, record) }
+
+// BandwidthPlugin is an optional interface for plugins that also consume bandwidth records.
+type BandwidthPlugin interface {
+	HandleBandwidth(ctx context.Context, record BandwidthRecord)
+}
+
 type queueItem struct {
-	ctx    context.Context
-	record Record
+	ctx       context.Context
+	record    Record
+	bandwidth *BandwidthRecord
 }
 
 // Manager maintains a queue of usage records and delivers them to registered plugins.
@@ -121,6 +157,22 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	m.cond.Signal()
 }
 
+// PublishBandwidth enqueues a bandwidth record for plugins implementing BandwidthPlugin.
+func (m *Manager) PublishBandwidth(ctx context.Context, record BandwidthRecord) {
+	if m == nil {
+		return
+	}
+	m.Start(context.Background())
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.queue = append(m.queue, queueItem{ctx: ctx, bandwidth: &record})
+	m.mu.Unlock()
+	m.cond.Signal()
+}
+
 func (m *Manager) run(ctx context.Context) {
 	for {
 		m.mu.Lock()
@@ -150,10 +202,25 @@ func (m *Manager) dispatch(item queueItem) {
 		if plugin == nil {
 			continue
 		}
+		if item.bandwidth != nil {
+			if bp, ok := plugin.(BandwidthPlugin); ok {
+				safeInvokeBandwidth(bp, item.ctx, *item.bandwidth)
+			}
+			continue
+		}
 		safeInvoke(plugin, item.ctx, item.record)
 	}
 }
 
+func safeInvokeBandwidth(plugin BandwidthPlugin, ctx context.Context, record BandwidthRecord) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("usage: bandwidth plugin panic recovered: %v", r)
+		}
+	}()
+	plugin.HandleBandwidth(ctx, record)
+}
+
 func safeInvoke(plugin Plugin, ctx context.Context, record Record) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -174,6 +241,11 @@ func RegisterPlugin(plugin Plugin) { DefaultManager().Register(plugin) }
 // PublishRecord publishes a record using the default manager.
 func PublishRecord(ctx context.Context, record Record) { DefaultManager().Publish(ctx, record) }
 
+// PublishBandwidth publishes a bandwidth record using the default manager.
+func PublishBandwidth(ctx context.Context, record BandwidthRecord) {
+	DefaultManager().PublishBandwidth(ctx, record)
+}
+
 // StartDefault starts the default manager's dispatcher.
 func StartDefault(ctx context.Context) { DefaultManager().Start(ctx) }
 
@@ -0,0 +1,24 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestManagerDeliversToPluginFunc(t *testing.T) {
+	m := NewManager(8)
+	defer m.Stop()
+	received := make(chan Record, 1)
+	m.Register(PluginFunc(func(_ context.Context, record Record) { received <- record }))
+
+	m.Publish(context.Background(), Record{Provider: "claude", RequestID: "a1b2c3d4", Latency: time.Second, Detail: Detail{TotalTokens: 7}})
+	select {
+	case record := <-received:
+		if record.RequestID != "a1b2c3d4" || record.Latency != time.Second || record.Detail.TotalTokens != 7 {
+			t.Fatalf("record = %+v", record)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("plugin did not receive the record")
+	}
+}
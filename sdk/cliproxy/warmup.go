@@ -0,0 +1,116 @@
+package cliproxy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// warmupStartupDelay lets discovered model catalogs (e.g. Ollama's /api/tags)
+	// register before the first warm-up pass.
+	warmupStartupDelay = 15 * time.Second
+	// warmupPollInterval is how often a startup-only schedule checks for config changes
+	// that enable a repeating one.
+	warmupPollInterval = time.Minute
+	// warmupTimeout bounds one warm-up request; loading a large model can take minutes.
+	warmupTimeout = 5 * time.Minute
+	// defaultWarmupPrompt is sent when warmup.prompt is empty.
+	defaultWarmupPrompt = "hi"
+)
+
+// runWarmups sends warm-up requests per the warmup config once after startup and then
+// on the configured interval. The config is re-read before every pass.
+func (s *Service) runWarmups(ctx context.Context) {
+	wait := warmupStartupDelay
+	warmedOnce := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		s.cfgMu.RLock()
+		cfg := s.cfg
+		s.cfgMu.RUnlock()
+		if cfg == nil {
+			wait = warmupPollInterval
+			continue
+		}
+		interval := time.Duration(cfg.Warmup.IntervalSeconds) * time.Second
+		if interval > 0 || !warmedOnce {
+			s.warmOnce(ctx, cfg.Warmup)
+			warmedOnce = true
+		}
+		wait = interval
+		if wait <= 0 {
+			wait = warmupPollInterval
+		}
+	}
+}
+
+// warmOnce warms every configured model on every self-hosted credential serving it.
+func (s *Service) warmOnce(ctx context.Context, cfg config.WarmupConfig) {
+	if s.coreManager == nil || len(cfg.Models) == 0 {
+		return
+	}
+	prompt := strings.TrimSpace(cfg.Prompt)
+	if prompt == "" {
+		prompt = defaultWarmupPrompt
+	}
+	for _, auth := range s.coreManager.List() {
+		if auth == nil || auth.Disabled || !warmupEligible(auth, cfg.Providers) {
+			continue
+		}
+		for _, model := range cfg.Models {
+			model = strings.TrimSpace(model)
+			if model == "" || !GlobalModelRegistry().ClientSupportsModel(auth.ID, model) {
+				continue
+			}
+			payload, _ := json.Marshal(map[string]any{
+				"model":      model,
+				"messages":   []map[string]string{{"role": "user", "content": prompt}},
+				"max_tokens": 1,
+			})
+			reqCtx, cancel := context.WithTimeout(ctx, warmupTimeout)
+			result := s.coreManager.Warm(reqCtx,
+				auth.ID,
+				cliproxyexecutor.Request{Model: model, Payload: payload},
+				cliproxyexecutor.Options{OriginalRequest: payload, SourceFormat: sdktranslator.FromString("openai")},
+			)
+			cancel()
+			if result.Error != "" {
+				log.Warnf("warm-up of %s on %s failed after %dms: %s", model, auth.ID, result.LatencyMS, result.Error)
+				continue
+			}
+			log.Debugf("warm-up of %s on %s took %dms", model, auth.ID, result.LatencyMS)
+		}
+	}
+}
+
+// warmupEligible reports whether auth is a self-hosted backend selected by providers:
+// an Ollama server or an openai-compatibility provider, optionally narrowed by name.
+func warmupEligible(auth *coreauth.Auth, providers []string) bool {
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	compat := auth.Attributes != nil && strings.TrimSpace(auth.Attributes["compat_name"]) != ""
+	if provider != "ollama" && !compat {
+		return false
+	}
+	if len(providers) == 0 {
+		return true
+	}
+	for _, name := range providers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == provider || (compat && strings.EqualFold(name, auth.Attributes["compat_name"])) {
+			return true
+		}
+	}
+	return false
+}
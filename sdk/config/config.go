@@ -13,6 +13,20 @@ type AccessProvider = internalconfig.AccessProvider
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type ResponsesStateConfig = internalconfig.ResponsesStateConfig
+type ModerationConfig = internalconfig.ModerationConfig
+type LocalePolicy = internalconfig.LocalePolicy
+type BestOfConfig = internalconfig.BestOfConfig
+type ModelRoute = internalconfig.ModelRoute
+type FallbackChain = internalconfig.FallbackChain
+type FallbackStep = internalconfig.FallbackStep
+type FairQueuingConfig = internalconfig.FairQueuingConfig
+type JobsConfig = internalconfig.JobsConfig
+type StructuredOutputConfig = internalconfig.StructuredOutputConfig
+type WarmupConfig = internalconfig.WarmupConfig
+type AutoscaleConfig = internalconfig.AutoscaleConfig
+type SessionPolicyConfig = internalconfig.SessionPolicyConfig
+type SessionLimit = internalconfig.SessionLimit
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
@@ -24,6 +38,28 @@ type PayloadModelRule = internalconfig.PayloadModelRule
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
+type ProviderKey = internalconfig.ProviderKey
+type ProviderModel = internalconfig.ProviderModel
+type GroqKey = internalconfig.GroqKey
+type GroqModel = internalconfig.GroqModel
+type XAIKey = internalconfig.XAIKey
+type XAIModel = internalconfig.XAIModel
+type OpenRouterKey = internalconfig.OpenRouterKey
+type OpenRouterModel = internalconfig.OpenRouterModel
+type CohereKey = internalconfig.CohereKey
+type CohereModel = internalconfig.CohereModel
+type FireworksKey = internalconfig.FireworksKey
+type FireworksModel = internalconfig.FireworksModel
+type NVIDIAKey = internalconfig.NVIDIAKey
+type NVIDIAModel = internalconfig.NVIDIAModel
+type MoonshotKey = internalconfig.MoonshotKey
+type MoonshotModel = internalconfig.MoonshotModel
+type ZhipuKey = internalconfig.ZhipuKey
+type ZhipuModel = internalconfig.ZhipuModel
+type CerebrasKey = internalconfig.CerebrasKey
+type CerebrasModel = internalconfig.CerebrasModel
+type OllamaKey = internalconfig.OllamaKey
+type OllamaModel = internalconfig.OllamaModel
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
@@ -36,6 +72,10 @@ const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
 	DefaultPanelGitHubRepository   = internalconfig.DefaultPanelGitHubRepository
+	DefaultModerationBaseURL       = internalconfig.DefaultModerationBaseURL
+	DefaultModerationModel         = internalconfig.DefaultModerationModel
+	DefaultBestOfMaxModels         = internalconfig.DefaultBestOfMaxModels
+	DefaultFairQueueMaxWaitSeconds = internalconfig.DefaultFairQueueMaxWaitSeconds
 )
 
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
@@ -3,6 +3,10 @@ package translator
 import (
 	"context"
 	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Registry manages translation functions across schemas.
@@ -72,7 +76,7 @@ func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model s
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.Stream != nil {
-			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			return translateStreamChunk(ctx, fn.Stream, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
 	return []string{string(rawJSON)}
@@ -85,7 +89,12 @@ func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, mode
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
-			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			spanCtx, span := tracing.Start(ctx, "translate.response", trace.WithAttributes(
+				attribute.String("cliproxy.translate.from", from.String()),
+				attribute.String("cliproxy.translate.to", to.String()),
+			))
+			defer span.End()
+			return fn.NonStream(spanCtx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
 	return string(rawJSON)
@@ -0,0 +1,78 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+// maxLoggedChunk caps how much of a malformed chunk is written to the log.
+const maxLoggedChunk = 512
+
+var (
+	streamFailuresMu sync.Mutex
+	streamFailures   = make(map[string]int64)
+)
+
+// StreamFailure counts the stream chunks a translator pair failed on.
+type StreamFailure struct {
+	// Pair is "<upstream format>-><client format>".
+	Pair  string `json:"pair"`
+	Count int64  `json:"count"`
+}
+
+// StreamFailures returns the number of chunks each translator pair failed on since
+// start, sorted by pair.
+func StreamFailures() []StreamFailure {
+	streamFailuresMu.Lock()
+	out := make([]StreamFailure, 0, len(streamFailures))
+	for pair, count := range streamFailures {
+		out = append(out, StreamFailure{Pair: pair, Count: count})
+	}
+	streamFailuresMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Pair < out[j].Pair })
+	return out
+}
+
+// translateStreamChunk runs a streaming response translator on one chunk. A translator
+// that panics on a malformed chunk costs that chunk only: the failure is counted and
+// logged, and a diagnostic chunk the client can ignore takes its place so the rest of
+// the stream keeps flowing.
+func translateStreamChunk(ctx context.Context, fn ResponseStreamTransform, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) (out []string) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		pair := string(from) + "->" + string(to)
+		streamFailuresMu.Lock()
+		streamFailures[pair]++
+		streamFailuresMu.Unlock()
+		chunk := rawJSON
+		if len(chunk) > maxLoggedChunk {
+			chunk = chunk[:maxLoggedChunk]
+		}
+		log.Warnf("translator %s: skipped malformed stream chunk: %v: %s", pair, recovered, chunk)
+		out = diagnosticChunk(to, fmt.Sprint(recovered))
+	}()
+	return fn(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+}
+
+// diagnosticChunk builds a chunk in the client format that reports a skipped upstream
+// chunk without ending the stream. Formats without a safely ignorable event get none.
+func diagnosticChunk(to Format, reason string) []string {
+	switch to {
+	case FormatOpenAI:
+		chunk, _ := sjson.Set(`{"object":"chat.completion.chunk","choices":[]}`, "diagnostic", "skipped malformed upstream chunk: "+reason)
+		return []string{chunk}
+	case FormatClaude:
+		data, _ := sjson.Set(`{"type":"ping"}`, "diagnostic", "skipped malformed upstream chunk: "+reason)
+		return []string{"event: ping\ndata: " + data + "\n\n"}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTranslateStream_RecoversFromPanickingTranslator(t *testing.T) {
+	r := NewRegistry()
+	r.Register(FormatOpenAI, "panicky", nil, ResponseTransform{
+		Stream: func(_ context.Context, _ string, _, _, rawJSON []byte, _ *any) []string {
+			if string(rawJSON) == "bad" {
+				var m map[string]int
+				m["boom"]++
+			}
+			return []string{string(rawJSON)}
+		},
+	})
+
+	var param any
+	if out := r.TranslateStream(context.Background(), "panicky", FormatOpenAI, "m", nil, nil, []byte("good"), &param); len(out) != 1 || out[0] != "good" {
+		t.Fatalf("good chunk = %v", out)
+	}
+	out := r.TranslateStream(context.Background(), "panicky", FormatOpenAI, "m", nil, nil, []byte("bad"), &param)
+	if len(out) != 1 || !strings.Contains(out[0], `"diagnostic"`) || !strings.Contains(out[0], `"choices":[]`) {
+		t.Fatalf("bad chunk must be replaced by a diagnostic chunk, got %v", out)
+	}
+	if out := r.TranslateStream(context.Background(), "panicky", FormatOpenAI, "m", nil, nil, []byte("after"), &param); len(out) != 1 || out[0] != "after" {
+		t.Fatalf("stream must continue after a bad chunk, got %v", out)
+	}
+
+	found := false
+	for _, failure := range StreamFailures() {
+		if failure.Pair == "panicky->openai" && failure.Count == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("failure not counted: %+v", StreamFailures())
+	}
+}
@@ -0,0 +1,48 @@
+package translator
+
+import "context"
+
+// StreamTranslator translates the chunks of one streaming response. It owns the state the
+// registered translator keeps between chunks, such as open content blocks, tool calls
+// being assembled and the finish reason, so callers feed it chunks in order instead of
+// threading a state parameter through every TranslateStream call. A StreamTranslator is
+// not safe for concurrent use.
+type StreamTranslator struct {
+	registry               *Registry
+	ctx                    context.Context
+	from, to               Format
+	model                  string
+	originalRequestRawJSON []byte
+	requestRawJSON         []byte
+	param                  any
+}
+
+// NewStreamTranslator returns a translator for a response streamed in format from to a
+// client expecting format to.
+func (r *Registry) NewStreamTranslator(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON []byte) *StreamTranslator {
+	return &StreamTranslator{
+		registry:               r,
+		ctx:                    ctx,
+		from:                   from,
+		to:                     to,
+		model:                  model,
+		originalRequestRawJSON: originalRequestRawJSON,
+		requestRawJSON:         requestRawJSON,
+	}
+}
+
+// Translate converts the next chunk of the stream.
+func (t *StreamTranslator) Translate(rawJSON []byte) []string {
+	return t.registry.TranslateStream(t.ctx, t.from, t.to, t.model, t.originalRequestRawJSON, t.requestRawJSON, rawJSON, &t.param)
+}
+
+// Flush signals the end of the stream with the "[DONE]" marker, so translators that
+// buffer output emit what they still hold.
+func (t *StreamTranslator) Flush() []string {
+	return t.Translate([]byte("[DONE]"))
+}
+
+// NewStreamTranslator returns a stream translator on the default registry.
+func NewStreamTranslator(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON []byte) *StreamTranslator {
+	return defaultRegistry.NewStreamTranslator(ctx, from, to, model, originalRequestRawJSON, requestRawJSON)
+}
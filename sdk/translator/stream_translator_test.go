@@ -0,0 +1,35 @@
+package translator
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestStreamTranslator_KeepsStateAcrossChunks(t *testing.T) {
+	r := NewRegistry()
+	r.Register(FormatOpenAI, "counting", nil, ResponseTransform{
+		Stream: func(_ context.Context, _ string, _, _, rawJSON []byte, param *any) []string {
+			if *param == nil {
+				*param = 0
+			}
+			*param = (*param).(int) + 1
+			return []string{strconv.Itoa((*param).(int)) + ":" + string(rawJSON)}
+		},
+	})
+
+	stream := r.NewStreamTranslator(context.Background(), "counting", FormatOpenAI, "m", nil, nil)
+	for i, want := range []string{"1:a", "2:b"} {
+		if out := stream.Translate([]byte{byte('a' + i)}); len(out) != 1 || out[0] != want {
+			t.Fatalf("chunk %d = %v, want %q", i, out, want)
+		}
+	}
+	if out := stream.Flush(); len(out) != 1 || out[0] != "3:[DONE]" {
+		t.Fatalf("flush = %v", out)
+	}
+
+	other := r.NewStreamTranslator(context.Background(), "counting", FormatOpenAI, "m", nil, nil)
+	if out := other.Translate([]byte("a")); out[0] != "1:a" {
+		t.Fatalf("streams must not share state, got %v", out)
+	}
+}
@@ -0,0 +1,94 @@
+package translator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ToolCallNames maps tool call ids to the names of the functions they call. Claude and
+// OpenAI tool results carry only the call id, while Gemini pairs results with calls by
+// function name, so translators record each call while walking the conversation and
+// look the name up when they reach its result.
+type ToolCallNames map[string]string
+
+// Add records that the tool call id calls the function name.
+func (n ToolCallNames) Add(id, name string) {
+	if id == "" || name == "" {
+		return
+	}
+	n[id] = name
+}
+
+// Name returns the function name recorded for the tool call id.
+func (n ToolCallNames) Name(id string) (string, bool) {
+	name, ok := n[id]
+	return name, ok
+}
+
+// ToolNameFromID recovers the function name from a tool call id generated by the Gemini
+// response translators, which have the form "<name>-<unix nanos>-<counter>". Ids of any
+// other form lose their last "-" segment, and ids without one are returned unchanged.
+func ToolNameFromID(id string) string {
+	segments := strings.Split(id, "-")
+	if len(segments) > 2 && isDigits(segments[len(segments)-1]) && isDigits(segments[len(segments)-2]) {
+		return strings.Join(segments[:len(segments)-2], "-")
+	}
+	if len(segments) > 1 {
+		return strings.Join(segments[:len(segments)-1], "-")
+	}
+	return id
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+// PendingToolCalls queues tool calls that have not been answered yet. Gemini function
+// responses may omit the call id, so results translated from Gemini are paired with the
+// call they answer through Match.
+type PendingToolCalls struct {
+	calls []pendingToolCall
+}
+
+type pendingToolCall struct {
+	id   string
+	name string
+}
+
+// Add queues the tool call id, which calls the function name.
+func (p *PendingToolCalls) Add(id, name string) {
+	p.calls = append(p.calls, pendingToolCall{id: id, name: name})
+}
+
+// Match removes and returns the id of the call answered by a result with id and name:
+// the call with that id, else the oldest call of the function name, else the oldest
+// call. It reports false when no call is pending.
+func (p *PendingToolCalls) Match(id, name string) (string, bool) {
+	match := -1
+	if id != "" {
+		for i, call := range p.calls {
+			if call.id == id {
+				match = i
+				break
+			}
+		}
+	}
+	if match < 0 && name != "" {
+		for i, call := range p.calls {
+			if call.name == name {
+				match = i
+				break
+			}
+		}
+	}
+	if match < 0 {
+		if len(p.calls) == 0 {
+			return "", false
+		}
+		match = 0
+	}
+	call := p.calls[match]
+	p.calls = append(p.calls[:match], p.calls[match+1:]...)
+	return call.id, true
+}
@@ -0,0 +1,38 @@
+package translator
+
+import "testing"
+
+func TestToolNameFromID(t *testing.T) {
+	for id, want := range map[string]string{
+		"get_weather-1760000000000000000-3": "get_weather",
+		"my-tool-1760000000000000000-12":    "my-tool",
+		"get_weather-abc":                   "get_weather",
+		"toolu_01":                          "toolu_01",
+	} {
+		if got := ToolNameFromID(id); got != want {
+			t.Errorf("ToolNameFromID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestPendingToolCallsMatch(t *testing.T) {
+	var pending PendingToolCalls
+	pending.Add("call_a", "get_weather")
+	pending.Add("call_b", "get_time")
+	pending.Add("call_c", "get_weather")
+
+	for _, tc := range []struct {
+		id, name, want string
+	}{
+		{"call_c", "get_weather", "call_c"},
+		{"", "get_time", "call_b"},
+		{"", "unknown", "call_a"},
+	} {
+		if got, ok := pending.Match(tc.id, tc.name); !ok || got != tc.want {
+			t.Fatalf("Match(%q, %q) = %q, %v, want %q", tc.id, tc.name, got, ok, tc.want)
+		}
+	}
+	if got, ok := pending.Match("", "get_weather"); ok {
+		t.Fatalf("Match on an empty queue = %q, want no match", got)
+	}
+}